@@ -1,20 +1,35 @@
 package main
 
 import (
+	"context"
+	"flag"
 	"log"
 	"os"
 	"os/signal"
+	"strings"
 	"syscall"
 
+	"connectrpc.com/connect"
+
 	droneConnect "github.com/flightpath-dev/flightpath-proto/gen/go/drone/v1/dronev1connect"
+	"github.com/flightpath-dev/flightpath-server/internal/audit"
 	"github.com/flightpath-dev/flightpath-server/internal/config"
+	"github.com/flightpath-dev/flightpath-server/internal/export"
+	"github.com/flightpath-dev/flightpath-server/internal/gateway"
+	"github.com/flightpath-dev/flightpath-server/internal/middleware"
+	"github.com/flightpath-dev/flightpath-server/internal/ntrip"
 	"github.com/flightpath-dev/flightpath-server/internal/server"
 	"github.com/flightpath-dev/flightpath-server/internal/services"
+	"github.com/flightpath-dev/flightpath-server/internal/terrain"
 )
 
 func main() {
+	selfTest := flag.Bool("self-test", false, "check registry, serial device and listen address before serving; exit on failure")
+	flag.Parse()
+
 	// Load configuration
 	cfg := config.Load()
+	cfg.Server.SelfTest = *selfTest
 
 	// Create server
 	srv := server.New(cfg)
@@ -22,11 +37,63 @@ func main() {
 	// Get shared dependencies
 	deps := srv.GetDependencies()
 
+	// Audit log (optional)
+	if cfg.Server.AuditLogPath != "" {
+		auditLog, err := audit.Open(cfg.Server.AuditLogPath)
+		if err != nil {
+			log.Fatalf("Audit log: %v", err)
+		}
+		deps.Audit = auditLog
+		log.Printf("Recording commands to audit log %s", cfg.Server.AuditLogPath)
+	}
+
+	// Terrain data for terrain following (optional)
+	if cfg.MAVLink.TerrainDir != "" {
+		source, err := terrain.Open(cfg.MAVLink.TerrainDir)
+		if err != nil {
+			log.Fatalf("Terrain: %v", err)
+		}
+		deps.Terrain = source
+		log.Printf("Serving terrain data from %s", cfg.MAVLink.TerrainDir)
+	}
+
 	// Register services
-	registerServices(srv, deps)
+	connServer := registerServices(srv, cfg, deps)
+
+	// Connect drones marked auto_connect in the background
+	autoConnectCtx, stopAutoConnect := context.WithCancel(context.Background())
+	connServer.AutoConnect(autoConnectCtx)
+
+	// Reconnect drones whose link stops accepting writes
+	connServer.ReconnectDownLinks(autoConnectCtx)
+
+	// Disconnect drones left unused (optional)
+	if cfg.Server.ClientIdleTimeout > 0 {
+		connServer.ReclaimIdleClients(autoConnectCtx, cfg.Server.ClientIdleTimeout)
+		log.Printf("Disconnecting drones idle for %s", cfg.Server.ClientIdleTimeout)
+	}
+
+	// Forward NTRIP corrections to drones with an ntrip section
+	ntripCtx, stopNTRIP := context.WithCancel(context.Background())
+	ntrip.StartForwarders(ntripCtx, deps)
+
+	// Telemetry exporter (optional)
+	var exporter *export.Exporter
+	if cfg.Export.Target != "" {
+		var err error
+		exporter, err = export.New(deps, cfg.Export)
+		if err != nil {
+			log.Fatalf("Telemetry exporter: %v", err)
+		}
+		exporter.Start()
+		log.Printf("Exporting telemetry to %s every %s", cfg.Export.Target, cfg.Export.Interval)
+	}
 
 	// Setup graceful shutdown
-	go handleShutdown(srv, deps)
+	go handleShutdown(srv, deps, exporter, stopAutoConnect, stopNTRIP)
+
+	// SIGHUP re-reads the log level
+	go handleReload(deps)
 
 	// Start server
 	if err := srv.Start(); err != nil {
@@ -34,31 +101,98 @@ func main() {
 	}
 }
 
-// registerServices registers all Connect services
-func registerServices(srv *server.Server, deps *server.Dependencies) {
+// registerServices registers the enabled Connect services and returns the
+// connection service, which auto-connect uses whether or not it is exposed
+// Disabled services get no Connect handler and no REST routes, so their paths 404.
+func registerServices(srv *server.Server, cfg *config.Config, deps *server.Dependencies) *services.ConnectionServer {
+	// Panics in RPC handlers become CodeInternal errors; the access log sees
+	// every RPC's code, including those
+	rpcOptions := connect.WithHandlerOptions(
+		middleware.ConnectAccessLog(),
+		middleware.ConnectRecovery(deps.GetLogger()),
+	)
+	enabled := cfg.Server.ServiceEnabled
+	var rest gateway.Services
+
 	// Connection service (fully implemented)
 	connServer := services.NewConnectionServer(deps)
-	connPath, connHandler := droneConnect.NewConnectionServiceHandler(connServer)
-	srv.RegisterService(connPath, connHandler)
+	if enabled("connection") {
+		connPath, connHandler := droneConnect.NewConnectionServiceHandler(connServer, rpcOptions)
+		srv.RegisterService(connPath, connHandler)
+		rest.Connection = connServer
+	}
 
 	// Control service (fully implemented)
-	ctrlServer := services.NewControlServer(deps)
-	ctrlPath, ctrlHandler := droneConnect.NewControlServiceHandler(ctrlServer)
-	srv.RegisterService(ctrlPath, ctrlHandler)
+	if enabled("control") {
+		ctrlServer := services.NewControlServer(deps)
+		ctrlPath, ctrlHandler := droneConnect.NewControlServiceHandler(ctrlServer, rpcOptions)
+		srv.RegisterService(ctrlPath, ctrlHandler)
+		calibrationPath, calibrationHandler := services.NewControlStreamCalibrationHandler(ctrlServer, rpcOptions)
+		srv.RegisterService(calibrationPath, calibrationHandler)
+		rest.Control = ctrlServer
+	}
 
 	// Telemetry service (skeleton implementation)
-	telemetryServer := services.NewTelemetryServer(deps)
-	telemetryPath, telemetryHandler := droneConnect.NewTelemetryServiceHandler(telemetryServer)
-	srv.RegisterService(telemetryPath, telemetryHandler)
+	if enabled("telemetry") {
+		telemetryServer := services.NewTelemetryServer(deps)
+		telemetryPath, telemetryHandler := droneConnect.NewTelemetryServiceHandler(telemetryServer, rpcOptions)
+		srv.RegisterService(telemetryPath, telemetryHandler)
+		snapshotAllPath, snapshotAllHandler := services.NewTelemetryGetSnapshotAllHandler(telemetryServer, rpcOptions)
+		srv.RegisterService(snapshotAllPath, snapshotAllHandler)
+		readinessPath, readinessHandler := services.NewTelemetryGetReadinessHandler(telemetryServer, rpcOptions)
+		srv.RegisterService(readinessPath, readinessHandler)
+		rest.Telemetry = telemetryServer
+	}
 
 	// Mission service (skeleton implementation)
-	missionServer := services.NewMissionServer(deps)
-	missionPath, missionHandler := droneConnect.NewMissionServiceHandler(missionServer)
-	srv.RegisterService(missionPath, missionHandler)
+	if enabled("mission") {
+		missionServer := services.NewMissionServer(deps)
+		missionPath, missionHandler := droneConnect.NewMissionServiceHandler(missionServer, rpcOptions)
+		srv.RegisterService(missionPath, missionHandler)
+		rest.Mission = missionServer
+	}
+
+	// Event stream (no proto service yet; JSON over Connect, also raw messages on REST)
+	if enabled("events") {
+		eventServer := services.NewEventServer(deps)
+		eventsPath, eventsHandler := services.NewEventStreamEventsHandler(eventServer, rpcOptions)
+		srv.RegisterService(eventsPath, eventsHandler)
+		rest.Events = eventServer
+	}
+
+	// REST gateway (optional, calls into the same services)
+	if cfg.Server.RESTEnabled {
+		if enabled("geofence") {
+			rest.Geofence = services.NewGeofenceServer(deps)
+		}
+		if enabled("parameters") {
+			rest.Parameters = services.NewParameterServer(deps)
+		}
+		srv.RegisterService(gateway.PathPrefix, gateway.NewREST(deps, rest))
+	}
+
+	log.Printf("Services: %s", strings.Join(cfg.Server.EnabledServices, ", "))
+	return connServer
+}
+
+// handleReload restores the configured log level (FLIGHTPATH_LOG_LEVEL) on SIGHUP
+// Undoes a level set through PUT /api/v1/log-level without a restart.
+func handleReload(deps *server.Dependencies) {
+	sigChan := make(chan os.Signal, 1)
+	signal.Notify(sigChan, syscall.SIGHUP)
+
+	for range sigChan {
+		level := deps.Config.Logging.Level
+		if err := deps.SetLogLevel(level); err != nil {
+			log.Printf("SIGHUP: keeping log level %s: %v", deps.GetLogLevel(), err)
+			continue
+		}
+		log.Printf("SIGHUP: log level set to %s", level)
+	}
 }
 
 // handleShutdown handles graceful shutdown on interrupt signals
-func handleShutdown(srv *server.Server, deps *server.Dependencies) {
+func handleShutdown(srv *server.Server, deps *server.Dependencies, exporter *export.Exporter, stopAutoConnect, stopNTRIP context.CancelFunc) {
 	sigChan := make(chan os.Signal, 1)
 	signal.Notify(sigChan, os.Interrupt, syscall.SIGTERM)
 
@@ -66,11 +200,31 @@ func handleShutdown(srv *server.Server, deps *server.Dependencies) {
 
 	log.Println("\n🛑 Shutting down server gracefully...")
 
-	// Close MAVLink connection if exists
-	if deps.HasMAVLinkClient() {
-		client := deps.GetMAVLinkClient()
+	// Stop auto-connect retries, the idle check and NTRIP sessions before closing the links
+	stopAutoConnect()
+	stopNTRIP()
+
+	// Flush buffered telemetry samples
+	if exporter != nil {
+		if err := exporter.Stop(); err != nil {
+			log.Printf("Error stopping telemetry exporter: %v", err)
+		}
+	}
+
+	// Close all MAVLink connections
+	for _, droneID := range deps.GetMAVLinkDroneIDs() {
+		client, ok := deps.GetMAVLinkClientFor(droneID)
+		if !ok {
+			continue
+		}
 		if err := client.Close(); err != nil {
-			log.Printf("Error closing MAVLink connection: %v", err)
+			log.Printf("Error closing MAVLink connection to %s: %v", droneID, err)
+		}
+	}
+
+	if deps.Audit != nil {
+		if err := deps.Audit.Close(); err != nil {
+			log.Printf("Error closing audit log: %v", err)
 		}
 	}
 
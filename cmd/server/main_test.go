@@ -0,0 +1,67 @@
+package main
+
+import (
+	"io"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/flightpath-dev/flightpath-server/internal/config"
+	"github.com/flightpath-dev/flightpath-server/internal/server"
+	"github.com/flightpath-dev/flightpath-server/internal/services"
+)
+
+func TestRegisterServicesDisabled(t *testing.T) {
+	out := log.Writer()
+	log.SetOutput(io.Discard)
+	t.Cleanup(func() { log.SetOutput(out) })
+
+	// A telemetry-only deployment
+	cfg := config.Default()
+	cfg.Server.DroneRegistryPath = filepath.Join(t.TempDir(), "drones.yaml")
+	cfg.Server.RESTEnabled = true
+	cfg.Server.EnabledServices = []string{"connection", "telemetry"}
+	srv := server.New(cfg)
+	registerServices(srv, cfg, srv.GetDependencies())
+	handler := srv.Handler()
+
+	// unrouted reports whether the request hit no route at all, as opposed to a
+	// handler answering 404 (e.g. for an unknown drone)
+	unrouted := func(method, path string) bool {
+		req := httptest.NewRequest(method, path, strings.NewReader("{}"))
+		req.Header.Set("Content-Type", "application/json")
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+		return rec.Code == http.StatusNotFound && rec.Body.String() == "404 page not found\n"
+	}
+
+	for _, route := range []struct{ method, path string }{
+		{"POST", "/api/v1/drones/alpha/arm"},
+		{"POST", "/api/v1/drones/alpha/takeoff"},
+		{"POST", "/api/v1/drones/alpha/mission"},
+		{"GET", "/api/v1/drones/alpha/mission/progress"},
+		{"POST", "/api/v1/drones/alpha/geofence"},
+		{"POST", services.ControlStreamCalibrationProcedure},
+		{"POST", services.EventStreamEventsProcedure},
+		{"POST", "/drone.v1.ControlService/Arm"},
+		{"POST", "/drone.v1.MissionService/UploadMission"},
+	} {
+		if !unrouted(route.method, route.path) {
+			t.Errorf("disabled %s %s is reachable", route.method, route.path)
+		}
+	}
+
+	for _, route := range []struct{ method, path string }{
+		{"GET", "/api/v1/drones"},
+		{"GET", "/api/v1/drones/alpha/snapshot"},
+		{"POST", "/api/v1/drones/alpha/connect"},
+		{"POST", services.TelemetryGetSnapshotAllProcedure},
+	} {
+		if unrouted(route.method, route.path) {
+			t.Errorf("enabled %s %s has no route", route.method, route.path)
+		}
+	}
+}
@@ -5,7 +5,9 @@ go 1.24.0
 require (
 	connectrpc.com/connect v1.19.1
 	github.com/bluenviron/gomavlib/v3 v3.3.0
+	go.bug.st/serial v1.6.4
 	golang.org/x/net v0.47.0
+	golang.org/x/sys v0.38.0
 	gopkg.in/yaml.v3 v3.0.1
 )
 
@@ -13,8 +15,6 @@ require (
 	github.com/creack/goselect v0.1.3 // indirect
 	github.com/pion/logging v0.2.4 // indirect
 	github.com/pion/transport/v2 v2.2.10 // indirect
-	go.bug.st/serial v1.6.4 // indirect
-	golang.org/x/sys v0.38.0 // indirect
 )
 
 require (
@@ -0,0 +1,104 @@
+package audit
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+)
+
+// Request headers identifying who sent a command
+// The server doesn't authenticate anyone itself: an authenticating proxy in
+// front of it is expected to set OperatorHeader (and strip any the client sent).
+const (
+	OperatorHeader  = "Flightpath-Operator"
+	RequestIDHeader = "X-Request-Id"
+)
+
+// Entry is one audit record: a state-changing request and its outcome
+type Entry struct {
+	Time      time.Time `json:"time"`
+	Operator  string    `json:"operator"` // "unauthenticated" without OperatorHeader
+	RequestID string    `json:"request_id,omitempty"`
+	DroneID   string    `json:"drone_id,omitempty"`
+	Action    string    `json:"action"` // e.g. "arm", "upload_mission"
+	Params    any       `json:"params,omitempty"`
+	Success   bool      `json:"success"`
+	Message   string    `json:"message,omitempty"`
+}
+
+// Log appends entries to a JSONL file
+// Every entry is synced to disk before Record returns, so a record survives a
+// crash right after the command it describes.
+type Log struct {
+	mu sync.Mutex
+	f  *os.File
+}
+
+// Open opens (or creates) the audit log at path for appending
+func Open(path string) (*Log, error) {
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0o600)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open audit log: %w", err)
+	}
+	return &Log{f: f}, nil
+}
+
+// Record appends an entry, filling in Time and Operator when unset
+func (l *Log) Record(e Entry) error {
+	if e.Time.IsZero() {
+		e.Time = time.Now().UTC()
+	}
+	if e.Operator == "" {
+		e.Operator = "unauthenticated"
+	}
+
+	line, err := json.Marshal(e)
+	if err != nil {
+		// Params that don't encode still leave a record of the action
+		e.Params = fmt.Sprintf("unencodable params: %v", err)
+		if line, err = json.Marshal(e); err != nil {
+			return err
+		}
+	}
+	line = append(line, '\n')
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if _, err := l.f.Write(line); err != nil {
+		return fmt.Errorf("failed to write audit record: %w", err)
+	}
+	if err := l.f.Sync(); err != nil {
+		return fmt.Errorf("failed to sync audit log: %w", err)
+	}
+	return nil
+}
+
+// Close closes the file
+func (l *Log) Close() error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.f.Close()
+}
+
+// Identity is who sent a request, taken from its headers
+type Identity struct {
+	Operator  string
+	RequestID string
+}
+
+type identityKey struct{}
+
+// WithIdentity returns a context carrying the request's identity
+func WithIdentity(ctx context.Context, id Identity) context.Context {
+	return context.WithValue(ctx, identityKey{}, id)
+}
+
+// IdentityFrom returns the identity stored by WithIdentity (zero if none)
+func IdentityFrom(ctx context.Context) Identity {
+	id, _ := ctx.Value(identityKey{}).(Identity)
+	return id
+}
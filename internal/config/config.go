@@ -1,13 +1,21 @@
 package config
 
 import (
+	"errors"
 	"fmt"
+	"net"
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
 )
 
 // Config holds all application configuration
 type Config struct {
 	Server  ServerConfig
 	MAVLink MAVLinkConfig
+	Export  ExportConfig
 	Logging LoggingConfig
 }
 
@@ -16,17 +24,258 @@ type ServerConfig struct {
 	Port              int
 	CORSOrigins       []string
 	DroneRegistryPath string // Path to drones.yaml
+
+	// Per-origin CORS policies, keyed by origin ("*" for any origin not
+	// otherwise listed); CORSOrigins get the global policy
+	CORSPolicies map[string]CORSPolicy
+
+	// Refuse to start unless the drone registry loads
+	RequireRegistry bool
+
+	// Accept state-changing requests from startup; false boots into
+	// monitoring only until commands are enabled at runtime (safe boot)
+	CommandsEnabled bool
+
+	// Run the startup self-test and exit if it fails (--self-test)
+	SelfTest bool
+
+	// Serve the REST+JSON gateway under /api/v1/ alongside Connect
+	RESTEnabled bool
+
+	// Bearer token required for the raw MAVLink feed ("" disables the feed)
+	RawStreamToken string
+
+	// Bearer token required for the admin routes that change or reveal server
+	// state: configuration inspection, the log level, enabling commands and
+	// force reset ("" disables them)
+	AdminToken string
+
+	// Services to expose over Connect and REST (see Services); the rest are
+	// not registered at all
+	EnabledServices []string
+
+	// Longest a telemetry frame stream stays silent during a telemetry gap
+	// before sending a stale keepalive frame
+	StreamKeepalive time.Duration
+
+	// Aggregate rate (Hz) of the fleet telemetry stream when the request
+	// doesn't set one; the drones share it in turn
+	FleetTelemetryRate int32
+
+	// JSONL file every state-changing request is appended to ("" disables)
+	AuditLogPath string
+
+	// Disconnect a drone no RPC or stream has used for this long, e.g. after a
+	// frontend crashed without disconnecting (0 disables)
+	ClientIdleTimeout time.Duration
+}
+
+// CORSPolicy is how cross-origin requests from an origin are answered
+type CORSPolicy struct {
+	Credentials bool     `json:"credentials"`
+	Methods     []string `json:"methods,omitempty"` // empty: the global policy's methods
+	Headers     []string `json:"headers,omitempty"` // empty: the global policy's headers
+}
+
+// Services are the names EnabledServices accepts. Connection, control,
+// telemetry and mission are Connect services with REST routes; the others
+// are REST only.
+var Services = []string{
+	"connection", "control", "telemetry", "mission", "geofence", "parameters", "events",
+}
+
+// DebounceableCommands are the names DebouncedCommands accepts: one-shot
+// commands only, never setpoints a client legitimately repeats (go-to,
+// reposition, yaw)
+var DebounceableCommands = []string{
+	"arm", "disarm", "set_mode", "takeoff", "land", "return_home",
+}
+
+// ServiceEnabled reports whether a service (one of Services) is exposed
+func (s ServerConfig) ServiceEnabled(name string) bool {
+	return slices.Contains(s.EnabledServices, name)
 }
 
 type MAVLinkConfig struct {
 	// Default connection settings (can be overridden per drone)
 	DefaultPort     string
 	DefaultBaudRate int
+
+	// What Connect does with an existing client whose link is down
+	StaleClientPolicy string // "replace", "reuse"
+
+	// What Disconnect does while the drone is armed (unless forced)
+	DisconnectWhileArmed string // "refuse", "rtl", "hold"
+
+	// Upper bound for ConnectRequest.timeout_ms; longer requests are clamped
+	MaxConnectTimeout time.Duration
+
+	// How old position data may get while armed before the watchdog alarms
+	TelemetryStaleTimeout time.Duration
+
+	// Stamp position and attitude with the vehicle's sample time from TIMESYNC
+	CorrectTimestamps bool
+
+	// Send SYSTEM_TIME with the GCS heartbeat; off for vehicles with their
+	// own time source (e.g. a companion computer's GPS)
+	SendSystemTime bool
+
+	// Request telemetry again after the vehicle arms, disarms or changes
+	// mode, as PX4 may reset stream subscriptions then
+	RerequestStreams bool
+
+	// MAV_STATE our HEARTBEAT reports ("" = ACTIVE), and an identity (e.g.
+	// a callsign) sent as STATUSTEXT after connecting and every
+	// GCSIdentityInterval ("" sends none)
+	GCSSystemStatus     string
+	GCSIdentity         string
+	GCSIdentityInterval time.Duration
+
+	// Largest mission upload accepted (0 = no limit)
+	MaxMissionItems int
+
+	// Defaults for waypoints that leave them unset: acceptance radius in
+	// meters (0 = the vehicle's) and whether to continue without the operator
+	MissionAcceptanceRadius float64
+	MissionAutocontinue     bool
+
+	// Re-sends of a command the vehicle TEMPORARILY_REJECTED
+	CommandRetries int
+
+	// Retries of an outbound write that failed transiently (buffer full)
+	WriteRetries int
+
+	// COMMAND_ACK wait, overridden per command by MAV_CMD name
+	// (see DefaultCommandAckTimeouts)
+	CommandAckTimeout  time.Duration
+	CommandAckTimeouts map[string]time.Duration
+
+	// How long a cached vehicle parameter is served before it is read again
+	ParamCacheMaxAge time.Duration
+
+	// Re-send GoToPosition targets at this rate (Hz) until within the
+	// acceptance radius (meters); 0 sends each target once
+	GoToSetpointRate     float64
+	GoToAcceptanceRadius float64
+
+	// Switch a vehicle still in OFFBOARD to hold when its streamed go-to
+	// target is reached or cancelled, instead of letting it fail safe
+	GoToHoldOnStop bool
+
+	// Rate (Hz) raw IMU data is requested at while a diagnostics stream runs,
+	// unless the stream asks for its own
+	IMURate float64
+
+	// Named per-message rate profile applied after connecting
+	// ("" requests all data streams at 10 Hz)
+	TelemetryProfile  string
+	TelemetryProfiles map[string]TelemetryProfile
+
+	// An identical command (one of DebouncedCommands, see
+	// DebounceableCommands) to the same drone within CommandDebounce of the
+	// last gets that command's result instead of being sent again (0 disables)
+	CommandDebounce   time.Duration
+	DebouncedCommands []string
+
+	// Commands that also require a healthy vehicle (see HealthGatableCommands);
+	// none by default, so gating is opt-in
+	HealthGatedCommands []string
+	MinSatellites       int
+	MinBatteryPercent   int
+
+	// Refuse takeoff without a 3D GPS fix, MinSatellites and at most
+	// TakeoffMaxHDOP, unless the request forces it
+	TakeoffGPSGate bool
+	TakeoffMaxHDOP float64
+
+	// Refuse takeoff, go-to and reposition while the vehicle flies a mission,
+	// unless the request overrides it
+	RejectDuringMission bool
+
+	// Assumptions behind the RTL battery estimate: speeds home and down
+	// (m/s) and the battery percent that must be left after landing
+	RTLCruiseSpeed    float64
+	RTLDescentSpeed   float64
+	RTLReservePercent int
+
+	// Speed between items assumed by the mission duration estimate (m/s)
+	MissionCruiseSpeed float64
+
+	// Directory of SRTM .hgt tiles answering the vehicle's TERRAIN_REQUESTs
+	// ("" leaves them unanswered)
+	TerrainDir string
+
+	// Readiness score: CAUTION below/above these, NOT_READY below the minimums
+	// above or above MaxPacketLossPercent
+	CautionSatellites        int
+	CautionBatteryPercent    int
+	CautionPacketLossPercent float64
+	MaxPacketLossPercent     float64
+}
+
+// HealthGatableCommands are the flight-initiating commands that can require
+// health checks. Recovery commands (land, rtl, disarm) are never gated.
+var HealthGatableCommands = []string{
+	"arm", "takeoff", "set_mode", "goto", "reposition", "upload_mission", "start_mission", "resume_mission",
+}
+
+// DefaultCommandAckTimeouts returns the built-in per-command COMMAND_ACK waits
+// Commands not listed use MAVLinkConfig.CommandAckTimeout. Calibration isn't
+// waited for at all: its progress arrives as STATUSTEXT.
+func DefaultCommandAckTimeouts() map[string]time.Duration {
+	return map[string]time.Duration{
+		// Answered immediately; a missing ack means a lost command
+		"DO_SET_MODE":          time.Second,
+		"COMPONENT_ARM_DISARM": 2 * time.Second,
+		"DO_FLIGHTTERMINATION": time.Second,
+	}
+}
+
+// Stale client policies
+const (
+	// StaleClientReplace closes the existing client and opens a new link
+	StaleClientReplace = "replace"
+	// StaleClientReuse waits for the existing client to reconnect first,
+	// replacing it only if no heartbeat arrives within the connect timeout
+	StaleClientReuse = "reuse"
+)
+
+// Disconnect-while-armed policies
+const (
+	// DisconnectRefuse keeps the link and tells the caller why
+	DisconnectRefuse = "refuse"
+	// DisconnectRTL commands return-to-launch, then disconnects
+	DisconnectRTL = "rtl"
+	// DisconnectHold commands hold (PX4 AUTO LOITER), then disconnects
+	DisconnectHold = "hold"
+)
+
+// MaxIMURate caps raw IMU request rates (Hz)
+const MaxIMURate = 200
+
+// ExportConfig configures the line-protocol telemetry exporter
+type ExportConfig struct {
+	// File path or http(s) URL to write to ("" disables the exporter)
+	Target string
+
+	// Sent as "Authorization: Token <token>" to http(s) targets (InfluxDB v2)
+	Token string
+
+	// How often each connected drone is sampled
+	Interval time.Duration
+
+	// Samples buffered before a write (also flushed every FlushInterval)
+	BatchSize     int
+	FlushInterval time.Duration
+
+	// Directory each drone's flight path is saved to as GPX on disconnect
+	// ("" keeps tracks in memory only)
+	TrackDir string
 }
 
 type LoggingConfig struct {
 	Level  string // "debug", "info", "warn", "error"
-	Format string // "json", "text"
+	Format string // access log lines: "json", "text" (key=value)
 }
 
 // Default returns a Config with sensible defaults
@@ -40,10 +289,51 @@ func Default() *Config {
 				"http://localhost:3000",
 			},
 			DroneRegistryPath: "./data/config/drones.yaml",
+			EnabledServices:   slices.Clone(Services),
+			StreamKeepalive:   15 * time.Second,
+			CommandsEnabled:   true,
+
+			FleetTelemetryRate: 10,
 		},
 		MAVLink: MAVLinkConfig{
-			DefaultPort:     "/dev/ttyUSB0",
-			DefaultBaudRate: 57600,
+			DefaultPort:           "/dev/ttyUSB0",
+			DefaultBaudRate:       57600,
+			StaleClientPolicy:     StaleClientReplace,
+			DisconnectWhileArmed:  DisconnectRefuse,
+			MaxConnectTimeout:     30 * time.Second,
+			TelemetryStaleTimeout: 3 * time.Second,
+			SendSystemTime:        true,
+			RerequestStreams:      true,
+			GCSIdentityInterval:   time.Minute,
+			CommandRetries:        2,
+			WriteRetries:          2,
+			MissionAutocontinue:   true,
+			CommandAckTimeout:     3 * time.Second,
+			CommandAckTimeouts:    DefaultCommandAckTimeouts(),
+			ParamCacheMaxAge:      5 * time.Minute,
+			GoToAcceptanceRadius:  2,
+			GoToHoldOnStop:        true,
+			IMURate:               10,
+			TelemetryProfiles:     DefaultTelemetryProfiles(),
+			DebouncedCommands:     slices.Clone(DebounceableCommands),
+			MinSatellites:         6,
+			MinBatteryPercent:     20,
+			TakeoffGPSGate:        true,
+			TakeoffMaxHDOP:        2.5,
+			RTLCruiseSpeed:        5,
+			RTLDescentSpeed:       1,
+			RTLReservePercent:     15,
+			MissionCruiseSpeed:    5,
+
+			CautionSatellites:        10,
+			CautionBatteryPercent:    40,
+			CautionPacketLossPercent: 5,
+			MaxPacketLossPercent:     20,
+		},
+		Export: ExportConfig{
+			Interval:      time.Second,
+			BatchSize:     100,
+			FlushInterval: 10 * time.Second,
 		},
 		Logging: LoggingConfig{
 			Level:  "info",
@@ -52,21 +342,274 @@ func Default() *Config {
 	}
 }
 
+// Redacted is the placeholder Config.Redacted puts in place of set secrets
+const Redacted = "[redacted]"
+
+// Redacted returns a copy of the configuration safe to show: tokens that are
+// set read Redacted and a password in the export target URL is masked
+// Maps and slices are shared with c, so the copy must not be modified.
+func (c *Config) Redacted() *Config {
+	r := *c
+	redact := func(secret *string) {
+		if *secret != "" {
+			*secret = Redacted
+		}
+	}
+	redact(&r.Server.RawStreamToken)
+	redact(&r.Server.AdminToken)
+	redact(&r.Export.Token)
+	if u, err := url.Parse(r.Export.Target); err == nil && u.User != nil {
+		r.Export.Target = u.Redacted()
+	}
+	return &r
+}
+
 // Validate checks if the configuration is valid
+// TLS is out of scope: the server has no certificate or key settings (terminate
+// TLS in a reverse proxy), so there are no paths to check.
 func (c *Config) Validate() error {
 	if c.Server.Port < 1 || c.Server.Port > 65535 {
 		return fmt.Errorf("invalid port: %d", c.Server.Port)
 	}
 
+	if !isValidHost(c.Server.Host) {
+		return fmt.Errorf("invalid host: %q (must be an IP address or hostname)", c.Server.Host)
+	}
+
+	for origin := range c.Server.CORSPolicies {
+		if !isValidOrigin(origin) {
+			return fmt.Errorf("invalid CORS policy origin: %q (must be * or scheme://host[:port])", origin)
+		}
+	}
+
+	for _, service := range c.Server.EnabledServices {
+		if !slices.Contains(Services, service) {
+			return fmt.Errorf("invalid service: %s (must be one of %s)", service, strings.Join(Services, ", "))
+		}
+	}
+
+	// Safe boot is only left through PUT /api/v1/commands
+	if !c.Server.CommandsEnabled && (!c.Server.RESTEnabled || c.Server.AdminToken == "") {
+		return errors.New("commands disabled at startup need the REST gateway and an admin token to enable them")
+	}
+
+	if c.MAVLink.CommandDebounce < 0 {
+		return fmt.Errorf("invalid command debounce window: %s (must be 0 or positive)", c.MAVLink.CommandDebounce)
+	}
+	for _, command := range c.MAVLink.DebouncedCommands {
+		if !slices.Contains(DebounceableCommands, command) {
+			return fmt.Errorf("invalid debounced command: %s (must be one of %s)",
+				command, strings.Join(DebounceableCommands, ", "))
+		}
+	}
+
+	if c.Server.StreamKeepalive <= 0 {
+		return fmt.Errorf("invalid stream keepalive interval: %s", c.Server.StreamKeepalive)
+	}
+
+	if c.Server.FleetTelemetryRate <= 0 || c.Server.FleetTelemetryRate > 50 {
+		return fmt.Errorf("invalid fleet telemetry rate: %d Hz (must be 1-50)", c.Server.FleetTelemetryRate)
+	}
+
+	if c.Server.ClientIdleTimeout < 0 {
+		return fmt.Errorf("invalid client idle timeout: %s (must be 0 or positive)", c.Server.ClientIdleTimeout)
+	}
+
+	if c.MAVLink.DefaultBaudRate < minBaudRate || c.MAVLink.DefaultBaudRate > maxBaudRate {
+		return fmt.Errorf("invalid MAVLink baud rate: %d (must be between %d and %d)",
+			c.MAVLink.DefaultBaudRate, minBaudRate, maxBaudRate)
+	}
+
+	if c.MAVLink.StaleClientPolicy != StaleClientReplace && c.MAVLink.StaleClientPolicy != StaleClientReuse {
+		return fmt.Errorf("invalid stale client policy: %s", c.MAVLink.StaleClientPolicy)
+	}
+
+	switch c.MAVLink.DisconnectWhileArmed {
+	case DisconnectRefuse, DisconnectRTL, DisconnectHold:
+	default:
+		return fmt.Errorf("invalid disconnect-while-armed policy: %s", c.MAVLink.DisconnectWhileArmed)
+	}
+
+	if c.MAVLink.MaxConnectTimeout <= 0 {
+		return fmt.Errorf("invalid max connect timeout: %s", c.MAVLink.MaxConnectTimeout)
+	}
+
+	if c.MAVLink.TelemetryStaleTimeout <= 0 {
+		return fmt.Errorf("invalid telemetry stale timeout: %s", c.MAVLink.TelemetryStaleTimeout)
+	}
+
+	if c.MAVLink.TelemetryProfile != "" {
+		if _, ok := c.MAVLink.TelemetryProfiles[c.MAVLink.TelemetryProfile]; !ok {
+			return fmt.Errorf("unknown telemetry profile: %s", c.MAVLink.TelemetryProfile)
+		}
+	}
+
+	for _, command := range c.MAVLink.HealthGatedCommands {
+		if !slices.Contains(HealthGatableCommands, command) {
+			return fmt.Errorf("invalid health-gated command: %s (must be one of %s)",
+				command, strings.Join(HealthGatableCommands, ", "))
+		}
+	}
+
+	if c.MAVLink.CautionSatellites < c.MAVLink.MinSatellites {
+		return fmt.Errorf("caution satellite count %d is below the minimum %d",
+			c.MAVLink.CautionSatellites, c.MAVLink.MinSatellites)
+	}
+	if c.MAVLink.CautionBatteryPercent < c.MAVLink.MinBatteryPercent || c.MAVLink.CautionBatteryPercent > 100 {
+		return fmt.Errorf("invalid caution battery percent: %d (must be %d-100)",
+			c.MAVLink.CautionBatteryPercent, c.MAVLink.MinBatteryPercent)
+	}
+	if c.MAVLink.CautionPacketLossPercent < 0 || c.MAVLink.CautionPacketLossPercent > c.MAVLink.MaxPacketLossPercent {
+		return fmt.Errorf("invalid caution packet loss: %.1f%% (must be 0-%.1f%%)",
+			c.MAVLink.CautionPacketLossPercent, c.MAVLink.MaxPacketLossPercent)
+	}
+
+	if c.MAVLink.CommandRetries < 0 || c.MAVLink.CommandRetries > 10 {
+		return fmt.Errorf("invalid command retries: %d (must be 0-10)", c.MAVLink.CommandRetries)
+	}
+	if c.MAVLink.WriteRetries < 0 || c.MAVLink.WriteRetries > 5 {
+		return fmt.Errorf("invalid write retries: %d (must be 0-5)", c.MAVLink.WriteRetries)
+	}
+
+	if c.MAVLink.CommandAckTimeout <= 0 {
+		return fmt.Errorf("invalid command ack timeout: %s", c.MAVLink.CommandAckTimeout)
+	}
+	for command, timeout := range c.MAVLink.CommandAckTimeouts {
+		if timeout <= 0 {
+			return fmt.Errorf("invalid command ack timeout for %s: %s", command, timeout)
+		}
+	}
+
+	if c.MAVLink.ParamCacheMaxAge <= 0 {
+		return fmt.Errorf("invalid parameter cache max age: %s", c.MAVLink.ParamCacheMaxAge)
+	}
+
+	if len(c.MAVLink.GCSIdentity) > 50 {
+		return fmt.Errorf("invalid GCS identity: %d bytes (at most 50, one STATUSTEXT)", len(c.MAVLink.GCSIdentity))
+	}
+	if c.MAVLink.GCSIdentityInterval <= 0 {
+		return fmt.Errorf("invalid GCS identity interval: %s", c.MAVLink.GCSIdentityInterval)
+	}
+	if c.MAVLink.GoToSetpointRate < 0 || c.MAVLink.GoToSetpointRate > 50 {
+		return fmt.Errorf("invalid go-to setpoint rate: %v Hz (must be 0-50)", c.MAVLink.GoToSetpointRate)
+	}
+	if c.MAVLink.GoToAcceptanceRadius <= 0 {
+		return fmt.Errorf("invalid go-to acceptance radius: %v m", c.MAVLink.GoToAcceptanceRadius)
+	}
+	if c.MAVLink.IMURate <= 0 || c.MAVLink.IMURate > MaxIMURate {
+		return fmt.Errorf("invalid IMU rate: %v Hz (must be above 0, at most %d)", c.MAVLink.IMURate, MaxIMURate)
+	}
+
+	if c.MAVLink.MissionAcceptanceRadius < 0 {
+		return fmt.Errorf("invalid mission acceptance radius: %v m", c.MAVLink.MissionAcceptanceRadius)
+	}
+
+	if c.MAVLink.MaxMissionItems < 0 {
+		return fmt.Errorf("invalid maximum mission items: %d", c.MAVLink.MaxMissionItems)
+	}
+
+	if c.MAVLink.MinSatellites < 0 {
+		return fmt.Errorf("invalid minimum satellite count: %d", c.MAVLink.MinSatellites)
+	}
+
+	if c.MAVLink.RTLCruiseSpeed <= 0 || c.MAVLink.RTLDescentSpeed <= 0 {
+		return fmt.Errorf("invalid RTL speeds: cruise %v m/s, descent %v m/s (must be above 0)",
+			c.MAVLink.RTLCruiseSpeed, c.MAVLink.RTLDescentSpeed)
+	}
+	if c.MAVLink.RTLReservePercent < 0 || c.MAVLink.RTLReservePercent > 100 {
+		return fmt.Errorf("invalid RTL reserve: %d%% (must be 0-100)", c.MAVLink.RTLReservePercent)
+	}
+
+	if c.MAVLink.MissionCruiseSpeed <= 0 {
+		return fmt.Errorf("invalid mission cruise speed: %v m/s (must be above 0)", c.MAVLink.MissionCruiseSpeed)
+	}
+
+	if c.MAVLink.MinBatteryPercent < 0 || c.MAVLink.MinBatteryPercent > 100 {
+		return fmt.Errorf("invalid minimum battery percent: %d", c.MAVLink.MinBatteryPercent)
+	}
+
+	if c.MAVLink.TakeoffMaxHDOP <= 0 {
+		return fmt.Errorf("invalid takeoff maximum HDOP: %v", c.MAVLink.TakeoffMaxHDOP)
+	}
+
+	if c.Export.Target != "" {
+		if c.Export.Interval <= 0 {
+			return fmt.Errorf("invalid export interval: %s", c.Export.Interval)
+		}
+		if c.Export.BatchSize < 1 {
+			return fmt.Errorf("invalid export batch size: %d", c.Export.BatchSize)
+		}
+		if c.Export.FlushInterval <= 0 {
+			return fmt.Errorf("invalid export flush interval: %s", c.Export.FlushInterval)
+		}
+	}
+
 	validLevels := map[string]bool{"debug": true, "info": true, "warn": true, "error": true}
 	if !validLevels[c.Logging.Level] {
 		return fmt.Errorf("invalid log level: %s", c.Logging.Level)
 	}
+	if c.Logging.Format != "text" && c.Logging.Format != "json" {
+		return fmt.Errorf("invalid log format: %s", c.Logging.Format)
+	}
 
 	return nil
 }
 
 // ServerAddr returns the server address as host:port
+// IPv6 literal hosts are bracketed (e.g. "[::1]:8080")
 func (c *Config) ServerAddr() string {
-	return fmt.Sprintf("%s:%d", c.Server.Host, c.Server.Port)
+	return net.JoinHostPort(c.Server.Host, strconv.Itoa(c.Server.Port))
+}
+
+// Serial baud rate bounds accepted by Validate
+const (
+	minBaudRate = 1200
+	maxBaudRate = 4000000
+)
+
+// isValidHost reports whether host is an IP address or an RFC 1123 hostname
+// An empty host is allowed and means "listen on all interfaces"
+func isValidHost(host string) bool {
+	if host == "" {
+		return true
+	}
+
+	if net.ParseIP(host) != nil {
+		return true
+	}
+
+	if len(host) > 253 {
+		return false
+	}
+
+	for _, label := range strings.Split(strings.TrimSuffix(host, "."), ".") {
+		if len(label) == 0 || len(label) > 63 {
+			return false
+		}
+		if label[0] == '-' || label[len(label)-1] == '-' {
+			return false
+		}
+		for _, r := range label {
+			isAlnum := (r >= 'a' && r <= 'z') || (r >= 'A' && r <= 'Z') || (r >= '0' && r <= '9')
+			if !isAlnum && r != '-' {
+				return false
+			}
+		}
+	}
+
+	return true
+}
+
+// isValidOrigin reports whether origin is "*" or a browser Origin value
+// (scheme://host[:port], no path)
+func isValidOrigin(origin string) bool {
+	if origin == "*" {
+		return true
+	}
+
+	u, err := url.Parse(origin)
+	if err != nil || u.Scheme == "" || u.Host == "" {
+		return false
+	}
+	return u.Path == "" && u.RawQuery == "" && u.Fragment == "" && u.User == nil
 }
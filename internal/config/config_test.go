@@ -0,0 +1,167 @@
+package config
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestValidateSafeBoot(t *testing.T) {
+	tests := []struct {
+		name       string
+		commands   bool
+		rest       bool
+		adminToken string
+		valid      bool
+	}{
+		{"commands enabled", true, false, "", true},
+		{"safe boot", false, true, "secret", true},
+		{"safe boot without an admin token", false, true, "", false},
+		{"safe boot without REST", false, false, "secret", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := Default()
+			cfg.Server.CommandsEnabled = tt.commands
+			cfg.Server.RESTEnabled = tt.rest
+			cfg.Server.AdminToken = tt.adminToken
+			if err := cfg.Validate(); (err == nil) != tt.valid {
+				t.Errorf("Validate() = %v, want valid=%v", err, tt.valid)
+			}
+		})
+	}
+}
+
+func TestServerAddr(t *testing.T) {
+	tests := []struct {
+		host string
+		want string
+	}{
+		{"", ":8080"},
+		{"localhost", "localhost:8080"},
+		{"127.0.0.1", "127.0.0.1:8080"},
+		{"::1", "[::1]:8080"},
+		{"fe80::1", "[fe80::1]:8080"},
+	}
+	for _, tt := range tests {
+		cfg := Default()
+		cfg.Server.Host = tt.host
+		cfg.Server.Port = 8080
+		if got := cfg.ServerAddr(); got != tt.want {
+			t.Errorf("ServerAddr() with host %q = %q, want %q", tt.host, got, tt.want)
+		}
+	}
+}
+
+func TestValidateHostAndBaudRate(t *testing.T) {
+	tests := []struct {
+		name      string
+		configure func(*Config)
+		wantErr   string // "" for valid
+	}{
+		{"defaults", func(*Config) {}, ""},
+		{"IPv6 host", func(c *Config) { c.Server.Host = "::1" }, ""},
+		{"hostname", func(c *Config) { c.Server.Host = "gcs-01.local" }, ""},
+		{"port 0", func(c *Config) { c.Server.Port = 0 }, "invalid port"},
+		{"port too large", func(c *Config) { c.Server.Port = 65536 }, "invalid port"},
+		{"bracketed IPv6 host", func(c *Config) { c.Server.Host = "[::1]" }, "invalid host"},
+		{"host with port", func(c *Config) { c.Server.Host = "localhost:8080" }, "invalid host"},
+		{"label starting with a hyphen", func(c *Config) { c.Server.Host = "-gcs.local" }, "invalid host"},
+		{"empty label", func(c *Config) { c.Server.Host = "gcs..local" }, "invalid host"},
+		{"label too long", func(c *Config) { c.Server.Host = strings.Repeat("a", 64) + ".local" }, "invalid host"},
+		{"lowest baud rate", func(c *Config) { c.MAVLink.DefaultBaudRate = minBaudRate }, ""},
+		{"highest baud rate", func(c *Config) { c.MAVLink.DefaultBaudRate = maxBaudRate }, ""},
+		{"zero baud rate", func(c *Config) { c.MAVLink.DefaultBaudRate = 0 }, "invalid MAVLink baud rate"},
+		{"baud rate too low", func(c *Config) { c.MAVLink.DefaultBaudRate = minBaudRate - 1 }, "invalid MAVLink baud rate"},
+		{"baud rate too high", func(c *Config) { c.MAVLink.DefaultBaudRate = maxBaudRate + 1 }, "invalid MAVLink baud rate"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := Default()
+			tt.configure(cfg)
+			err := cfg.Validate()
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Errorf("Validate() = %v, want valid", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("Validate() = %v, want %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestMissionWaypointDefaults(t *testing.T) {
+	cfg := Default()
+	if !cfg.MAVLink.MissionAutocontinue || cfg.MAVLink.MissionAcceptanceRadius != 0 {
+		t.Errorf("defaults: autocontinue %v, acceptance radius %v",
+			cfg.MAVLink.MissionAutocontinue, cfg.MAVLink.MissionAcceptanceRadius)
+	}
+
+	t.Setenv("FLIGHTPATH_MAVLINK_MISSION_ACCEPTANCE_RADIUS", "2.5")
+	t.Setenv("FLIGHTPATH_MAVLINK_MISSION_AUTOCONTINUE", "false")
+	cfg = Load()
+	if cfg.MAVLink.MissionAutocontinue || cfg.MAVLink.MissionAcceptanceRadius != 2.5 {
+		t.Errorf("from the environment: autocontinue %v, acceptance radius %v",
+			cfg.MAVLink.MissionAutocontinue, cfg.MAVLink.MissionAcceptanceRadius)
+	}
+
+	cfg.MAVLink.MissionAcceptanceRadius = -1
+	if err := cfg.Validate(); err == nil || !strings.Contains(err.Error(), "mission acceptance radius") {
+		t.Errorf("Validate() = %v, want a negative radius refused", err)
+	}
+}
+
+func TestSendSystemTime(t *testing.T) {
+	if !Default().MAVLink.SendSystemTime {
+		t.Error("SYSTEM_TIME disabled by default")
+	}
+
+	t.Setenv("FLIGHTPATH_MAVLINK_SEND_SYSTEM_TIME", "false")
+	if Load().MAVLink.SendSystemTime {
+		t.Error("SYSTEM_TIME still enabled with FLIGHTPATH_MAVLINK_SEND_SYSTEM_TIME=false")
+	}
+}
+
+func TestEnabledServices(t *testing.T) {
+	if cfg := Default(); !cfg.Server.ServiceEnabled("control") || !cfg.Server.ServiceEnabled("events") {
+		t.Errorf("defaults expose %v", cfg.Server.EnabledServices)
+	}
+
+	t.Setenv("FLIGHTPATH_SERVICES", " connection, telemetry ,")
+	cfg := Load()
+	if !cfg.Server.ServiceEnabled("telemetry") || cfg.Server.ServiceEnabled("control") || len(cfg.Server.EnabledServices) != 2 {
+		t.Errorf("from the environment: %q", cfg.Server.EnabledServices)
+	}
+	if err := cfg.Validate(); err != nil {
+		t.Errorf("Validate() = %v", err)
+	}
+
+	t.Setenv("FLIGHTPATH_SERVICES", "")
+	if cfg := Load(); len(cfg.Server.EnabledServices) != 0 {
+		t.Errorf("empty list exposes %v", cfg.Server.EnabledServices)
+	}
+
+	cfg.Server.EnabledServices = []string{"telemetry", "flight"}
+	if err := cfg.Validate(); err == nil || !strings.Contains(err.Error(), "invalid service: flight") {
+		t.Errorf("Validate() = %v, want the unknown service refused", err)
+	}
+}
+
+func TestValidateCORSPolicies(t *testing.T) {
+	for origin, valid := range map[string]bool{
+		"*":                          true,
+		"https://ops.example.com":    true,
+		"http://localhost:5173":      true,
+		"ops.example.com":            false,
+		"https://ops.example.com/ui": false,
+		"https://ops.example.com?x":  false,
+	} {
+		cfg := Default()
+		cfg.Server.CORSPolicies = map[string]CORSPolicy{origin: {}}
+		if err := cfg.Validate(); (err == nil) != valid {
+			t.Errorf("policy for %q: Validate() = %v, want valid=%v", origin, err, valid)
+		}
+	}
+}
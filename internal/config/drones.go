@@ -1,7 +1,9 @@
 package config
 
 import (
+	"errors"
 	"fmt"
+	"io/fs"
 	"os"
 
 	"gopkg.in/yaml.v3"
@@ -14,6 +16,68 @@ type DroneConfig struct {
 	Description string                 `yaml:"description"`
 	Protocol    string                 `yaml:"protocol"` // "mavlink", "dji", etc.
 	Connection  map[string]interface{} `yaml:"connection"`
+
+	// RTK corrections to forward to this drone while it is connected (optional)
+	NTRIP *NTRIPConfig `yaml:"ntrip,omitempty"`
+
+	// Box position targets and mission waypoints must stay inside (optional)
+	AllowedArea *AllowedAreaConfig `yaml:"allowed_area,omitempty"`
+
+	// Telemetry rates requested from this drone after connecting (optional)
+	Telemetry *TelemetryConfig `yaml:"telemetry,omitempty"`
+
+	// Altitude ceiling for takeoff, go-to, reposition and missions (optional)
+	MaxAltitude *MaxAltitudeConfig `yaml:"max_altitude,omitempty"`
+
+	// Flight modes SetFlightMode may switch to, as FlightMode names (e.g.
+	// FLIGHT_MODE_AUTO); empty allows all (optional)
+	AllowedFlightModes []string `yaml:"allowed_flight_modes,omitempty"`
+}
+
+// What happens to takeoff, go-to and reposition altitudes above max_altitude
+const (
+	MaxAltitudeReject = "reject" // refuse the command (default)
+	MaxAltitudeClamp  = "clamp"  // send it at the ceiling instead
+)
+
+// MaxAltitudeConfig is a ceiling in meters above home
+// Missions climbing above it are always rejected.
+type MaxAltitudeConfig struct {
+	Altitude float64 `yaml:"altitude"`
+	Action   string  `yaml:"action"` // MaxAltitudeReject or MaxAltitudeClamp
+}
+
+// TelemetryConfig selects the message rates requested with SET_MESSAGE_INTERVAL
+type TelemetryConfig struct {
+	// Profile to start from; overrides connection.telemetry_profile
+	Profile string `yaml:"profile"`
+
+	// Per-message rates in Hz (0 disables), applied over the profile
+	Rates TelemetryProfile `yaml:"rates"`
+}
+
+// AllowedAreaConfig is a latitude/longitude box in degrees
+type AllowedAreaConfig struct {
+	MinLatitude  float64 `yaml:"min_latitude"`
+	MaxLatitude  float64 `yaml:"max_latitude"`
+	MinLongitude float64 `yaml:"min_longitude"`
+	MaxLongitude float64 `yaml:"max_longitude"`
+
+	// Also send the box to the vehicle after connecting (SAFETY_SET_ALLOWED_AREA)
+	Push bool `yaml:"push"`
+}
+
+// NTRIPConfig is an NTRIP caster mountpoint to source RTCM corrections from
+type NTRIPConfig struct {
+	Host       string `yaml:"host"`
+	Port       int    `yaml:"port"` // 0 uses 2101
+	Mountpoint string `yaml:"mountpoint"`
+	Username   string `yaml:"username"`
+	Password   string `yaml:"password"`
+
+	// Seconds between GGA position reports (VRS mountpoints need them);
+	// 0 uses 10, negative sends none
+	GGAIntervalS int `yaml:"gga_interval_s"`
 }
 
 // DroneRegistry holds all configured drones
@@ -21,6 +85,33 @@ type DroneRegistry struct {
 	Drones []DroneConfig `yaml:"drones"`
 }
 
+// Registry load states
+const (
+	RegistryLoaded  = "loaded"
+	RegistryMissing = "missing" // file does not exist
+	RegistryInvalid = "invalid" // file exists but can't be read or parsed
+)
+
+// RegistryStatus describes how the drone registry loaded at startup
+type RegistryStatus struct {
+	Path   string `json:"path"`
+	State  string `json:"state"`
+	Drones int    `json:"drones"`
+	Error  string `json:"error,omitempty"`
+}
+
+// RegistryStatusFor classifies the result of LoadDroneRegistry
+func RegistryStatusFor(path string, registry *DroneRegistry, err error) RegistryStatus {
+	switch {
+	case err == nil:
+		return RegistryStatus{Path: path, State: RegistryLoaded, Drones: len(registry.Drones)}
+	case errors.Is(err, fs.ErrNotExist):
+		return RegistryStatus{Path: path, State: RegistryMissing, Error: err.Error()}
+	default:
+		return RegistryStatus{Path: path, State: RegistryInvalid, Error: err.Error()}
+	}
+}
+
 // LoadDroneRegistry loads drone configurations from a YAML file
 func LoadDroneRegistry(path string) (*DroneRegistry, error) {
 	data, err := os.ReadFile(path)
@@ -65,3 +156,40 @@ func (d *DroneConfig) GetConnectionInt(key string) int {
 	}
 	return 0
 }
+
+// GetConnectionStringList returns a connection parameter as a list of strings
+// Non-string entries are skipped.
+func (d *DroneConfig) GetConnectionStringList(key string) []string {
+	var list []string
+	if val, ok := d.Connection[key]; ok {
+		if items, ok := val.([]interface{}); ok {
+			for _, item := range items {
+				if str, ok := item.(string); ok {
+					list = append(list, str)
+				}
+			}
+		}
+	}
+	return list
+}
+
+// GetConnectionBool returns a connection parameter as bool
+func (d *DroneConfig) GetConnectionBool(key string) bool {
+	if val, ok := d.Connection[key]; ok {
+		if b, ok := val.(bool); ok {
+			return b
+		}
+	}
+	return false
+}
+
+// LookupConnectionBool returns a connection parameter as bool, and whether it
+// is set, for settings whose default comes from elsewhere
+func (d *DroneConfig) LookupConnectionBool(key string) (bool, bool) {
+	if val, ok := d.Connection[key]; ok {
+		if b, ok := val.(bool); ok {
+			return b, true
+		}
+	}
+	return false, false
+}
@@ -1,9 +1,12 @@
 package config
 
 import (
+	"encoding/json"
 	"log"
 	"os"
 	"strconv"
+	"strings"
+	"time"
 )
 
 // Load loads configuration from environment variables
@@ -26,6 +29,10 @@ func Load() *Config {
 		cfg.Logging.Level = logLevel
 	}
 
+	if logFormat := os.Getenv("FLIGHTPATH_LOG_FORMAT"); logFormat != "" {
+		cfg.Logging.Format = logFormat
+	}
+
 	if mavPort := os.Getenv("FLIGHTPATH_MAVLINK_PORT"); mavPort != "" {
 		cfg.MAVLink.DefaultPort = mavPort
 	}
@@ -36,10 +43,357 @@ func Load() *Config {
 		}
 	}
 
+	if policy := os.Getenv("FLIGHTPATH_MAVLINK_STALE_CLIENT"); policy != "" {
+		cfg.MAVLink.StaleClientPolicy = policy
+	}
+
+	if policy := os.Getenv("FLIGHTPATH_MAVLINK_DISCONNECT_WHILE_ARMED"); policy != "" {
+		cfg.MAVLink.DisconnectWhileArmed = policy
+	}
+
+	if maxMs := os.Getenv("FLIGHTPATH_MAVLINK_MAX_CONNECT_TIMEOUT_MS"); maxMs != "" {
+		if ms, err := strconv.Atoi(maxMs); err == nil {
+			cfg.MAVLink.MaxConnectTimeout = time.Duration(ms) * time.Millisecond
+		}
+	}
+
+	if staleMs := os.Getenv("FLIGHTPATH_MAVLINK_TELEMETRY_STALE_MS"); staleMs != "" {
+		if ms, err := strconv.Atoi(staleMs); err == nil {
+			cfg.MAVLink.TelemetryStaleTimeout = time.Duration(ms) * time.Millisecond
+		}
+	}
+
+	if correct := os.Getenv("FLIGHTPATH_MAVLINK_CORRECT_TIMESTAMPS"); correct != "" {
+		if enabled, err := strconv.ParseBool(correct); err == nil {
+			cfg.MAVLink.CorrectTimestamps = enabled
+		}
+	}
+
+	if send := os.Getenv("FLIGHTPATH_MAVLINK_SEND_SYSTEM_TIME"); send != "" {
+		if enabled, err := strconv.ParseBool(send); err == nil {
+			cfg.MAVLink.SendSystemTime = enabled
+		}
+	}
+
+	if rerequest := os.Getenv("FLIGHTPATH_MAVLINK_REREQUEST_STREAMS"); rerequest != "" {
+		if enabled, err := strconv.ParseBool(rerequest); err == nil {
+			cfg.MAVLink.RerequestStreams = enabled
+		}
+	}
+
+	if status := os.Getenv("FLIGHTPATH_MAVLINK_GCS_SYSTEM_STATUS"); status != "" {
+		cfg.MAVLink.GCSSystemStatus = status
+	}
+
+	if identity := os.Getenv("FLIGHTPATH_MAVLINK_GCS_IDENTITY"); identity != "" {
+		cfg.MAVLink.GCSIdentity = identity
+	}
+
+	if interval := os.Getenv("FLIGHTPATH_MAVLINK_GCS_IDENTITY_INTERVAL_S"); interval != "" {
+		if s, err := strconv.Atoi(interval); err == nil {
+			cfg.MAVLink.GCSIdentityInterval = time.Duration(s) * time.Second
+		}
+	}
+
+	if retries := os.Getenv("FLIGHTPATH_MAVLINK_COMMAND_RETRIES"); retries != "" {
+		if n, err := strconv.Atoi(retries); err == nil {
+			cfg.MAVLink.CommandRetries = n
+		}
+	}
+
+	if retries := os.Getenv("FLIGHTPATH_MAVLINK_WRITE_RETRIES"); retries != "" {
+		if n, err := strconv.Atoi(retries); err == nil {
+			cfg.MAVLink.WriteRetries = n
+		}
+	}
+
+	if ackMs := os.Getenv("FLIGHTPATH_MAVLINK_COMMAND_ACK_TIMEOUT_MS"); ackMs != "" {
+		if ms, err := strconv.Atoi(ackMs); err == nil {
+			cfg.MAVLink.CommandAckTimeout = time.Duration(ms) * time.Millisecond
+		}
+	}
+
+	if timeouts := os.Getenv("FLIGHTPATH_MAVLINK_COMMAND_ACK_TIMEOUTS"); timeouts != "" {
+		// COMMAND=ms pairs, merged over the defaults
+		for _, entry := range strings.Split(timeouts, ",") {
+			command, ms, ok := strings.Cut(strings.TrimSpace(entry), "=")
+			if !ok {
+				continue
+			}
+			if n, err := strconv.Atoi(strings.TrimSpace(ms)); err == nil {
+				cfg.MAVLink.CommandAckTimeouts[strings.ToUpper(strings.TrimSpace(command))] =
+					time.Duration(n) * time.Millisecond
+			}
+		}
+	}
+
+	if maxAge := os.Getenv("FLIGHTPATH_MAVLINK_PARAM_CACHE_MAX_AGE_S"); maxAge != "" {
+		if s, err := strconv.Atoi(maxAge); err == nil {
+			cfg.MAVLink.ParamCacheMaxAge = time.Duration(s) * time.Second
+		}
+	}
+
+	if rate := os.Getenv("FLIGHTPATH_MAVLINK_GOTO_SETPOINT_RATE"); rate != "" {
+		if f, err := strconv.ParseFloat(rate, 64); err == nil {
+			cfg.MAVLink.GoToSetpointRate = f
+		}
+	}
+
+	if radius := os.Getenv("FLIGHTPATH_MAVLINK_GOTO_ACCEPTANCE_RADIUS"); radius != "" {
+		if f, err := strconv.ParseFloat(radius, 64); err == nil {
+			cfg.MAVLink.GoToAcceptanceRadius = f
+		}
+	}
+
+	if hold := os.Getenv("FLIGHTPATH_MAVLINK_GOTO_HOLD_ON_STOP"); hold != "" {
+		if enabled, err := strconv.ParseBool(hold); err == nil {
+			cfg.MAVLink.GoToHoldOnStop = enabled
+		}
+	}
+
+	if rate := os.Getenv("FLIGHTPATH_MAVLINK_IMU_RATE"); rate != "" {
+		if f, err := strconv.ParseFloat(rate, 64); err == nil {
+			cfg.MAVLink.IMURate = f
+		}
+	}
+
+	if maxItems := os.Getenv("FLIGHTPATH_MAVLINK_MAX_MISSION_ITEMS"); maxItems != "" {
+		if n, err := strconv.Atoi(maxItems); err == nil {
+			cfg.MAVLink.MaxMissionItems = n
+		}
+	}
+
+	if radius := os.Getenv("FLIGHTPATH_MAVLINK_MISSION_ACCEPTANCE_RADIUS"); radius != "" {
+		if f, err := strconv.ParseFloat(radius, 64); err == nil {
+			cfg.MAVLink.MissionAcceptanceRadius = f
+		}
+	}
+
+	if autocontinue := os.Getenv("FLIGHTPATH_MAVLINK_MISSION_AUTOCONTINUE"); autocontinue != "" {
+		if enabled, err := strconv.ParseBool(autocontinue); err == nil {
+			cfg.MAVLink.MissionAutocontinue = enabled
+		}
+	}
+
+	if profile := os.Getenv("FLIGHTPATH_TELEMETRY_PROFILE"); profile != "" {
+		cfg.MAVLink.TelemetryProfile = profile
+	}
+
+	if gated, ok := os.LookupEnv("FLIGHTPATH_HEALTH_GATED_COMMANDS"); ok {
+		// Empty disables health gating
+		cfg.MAVLink.HealthGatedCommands = nil
+		for _, command := range strings.Split(gated, ",") {
+			if command = strings.TrimSpace(command); command != "" {
+				cfg.MAVLink.HealthGatedCommands = append(cfg.MAVLink.HealthGatedCommands, command)
+			}
+		}
+	}
+
+	if debounce := os.Getenv("FLIGHTPATH_COMMAND_DEBOUNCE_MS"); debounce != "" {
+		if ms, err := strconv.Atoi(debounce); err == nil {
+			cfg.MAVLink.CommandDebounce = time.Duration(ms) * time.Millisecond
+		}
+	}
+
+	if debounced, ok := os.LookupEnv("FLIGHTPATH_DEBOUNCED_COMMANDS"); ok {
+		cfg.MAVLink.DebouncedCommands = nil
+		for _, command := range strings.Split(debounced, ",") {
+			if command = strings.TrimSpace(command); command != "" {
+				cfg.MAVLink.DebouncedCommands = append(cfg.MAVLink.DebouncedCommands, command)
+			}
+		}
+	}
+
+	if sats := os.Getenv("FLIGHTPATH_MIN_SATELLITES"); sats != "" {
+		if n, err := strconv.Atoi(sats); err == nil {
+			cfg.MAVLink.MinSatellites = n
+		}
+	}
+
+	if battery := os.Getenv("FLIGHTPATH_MIN_BATTERY_PERCENT"); battery != "" {
+		if n, err := strconv.Atoi(battery); err == nil {
+			cfg.MAVLink.MinBatteryPercent = n
+		}
+	}
+
+	if gate := os.Getenv("FLIGHTPATH_TAKEOFF_GPS_GATE"); gate != "" {
+		if enabled, err := strconv.ParseBool(gate); err == nil {
+			cfg.MAVLink.TakeoffGPSGate = enabled
+		}
+	}
+
+	if hdop := os.Getenv("FLIGHTPATH_TAKEOFF_MAX_HDOP"); hdop != "" {
+		if f, err := strconv.ParseFloat(hdop, 64); err == nil {
+			cfg.MAVLink.TakeoffMaxHDOP = f
+		}
+	}
+
+	if reject := os.Getenv("FLIGHTPATH_REJECT_DURING_MISSION"); reject != "" {
+		if enabled, err := strconv.ParseBool(reject); err == nil {
+			cfg.MAVLink.RejectDuringMission = enabled
+		}
+	}
+
+	if speed := os.Getenv("FLIGHTPATH_RTL_CRUISE_SPEED"); speed != "" {
+		if f, err := strconv.ParseFloat(speed, 64); err == nil {
+			cfg.MAVLink.RTLCruiseSpeed = f
+		}
+	}
+
+	if speed := os.Getenv("FLIGHTPATH_RTL_DESCENT_SPEED"); speed != "" {
+		if f, err := strconv.ParseFloat(speed, 64); err == nil {
+			cfg.MAVLink.RTLDescentSpeed = f
+		}
+	}
+
+	if reserve := os.Getenv("FLIGHTPATH_RTL_RESERVE_PERCENT"); reserve != "" {
+		if n, err := strconv.Atoi(reserve); err == nil {
+			cfg.MAVLink.RTLReservePercent = n
+		}
+	}
+
+	if speed := os.Getenv("FLIGHTPATH_MISSION_CRUISE_SPEED"); speed != "" {
+		if f, err := strconv.ParseFloat(speed, 64); err == nil {
+			cfg.MAVLink.MissionCruiseSpeed = f
+		}
+	}
+
+	if dir := os.Getenv("FLIGHTPATH_TERRAIN_DIR"); dir != "" {
+		cfg.MAVLink.TerrainDir = dir
+	}
+
+	if sats := os.Getenv("FLIGHTPATH_READINESS_CAUTION_SATELLITES"); sats != "" {
+		if n, err := strconv.Atoi(sats); err == nil {
+			cfg.MAVLink.CautionSatellites = n
+		}
+	}
+
+	if battery := os.Getenv("FLIGHTPATH_READINESS_CAUTION_BATTERY_PERCENT"); battery != "" {
+		if n, err := strconv.Atoi(battery); err == nil {
+			cfg.MAVLink.CautionBatteryPercent = n
+		}
+	}
+
+	if loss := os.Getenv("FLIGHTPATH_READINESS_CAUTION_PACKET_LOSS_PERCENT"); loss != "" {
+		if f, err := strconv.ParseFloat(loss, 64); err == nil {
+			cfg.MAVLink.CautionPacketLossPercent = f
+		}
+	}
+
+	if loss := os.Getenv("FLIGHTPATH_READINESS_MAX_PACKET_LOSS_PERCENT"); loss != "" {
+		if f, err := strconv.ParseFloat(loss, 64); err == nil {
+			cfg.MAVLink.MaxPacketLossPercent = f
+		}
+	}
+
+	if rest := os.Getenv("FLIGHTPATH_REST_ENABLED"); rest != "" {
+		if enabled, err := strconv.ParseBool(rest); err == nil {
+			cfg.Server.RESTEnabled = enabled
+		}
+	}
+
+	if origins := os.Getenv("FLIGHTPATH_CORS_ORIGINS"); origins != "" {
+		cfg.Server.CORSOrigins = nil
+		for _, origin := range strings.Split(origins, ",") {
+			if origin = strings.TrimSpace(origin); origin != "" {
+				cfg.Server.CORSOrigins = append(cfg.Server.CORSOrigins, origin)
+			}
+		}
+	}
+
+	if policies := os.Getenv("FLIGHTPATH_CORS_POLICIES"); policies != "" {
+		// JSON object of origin -> policy; a typo must not silently loosen CORS
+		if err := json.Unmarshal([]byte(policies), &cfg.Server.CORSPolicies); err != nil {
+			log.Fatalf("Invalid FLIGHTPATH_CORS_POLICIES: %v", err)
+		}
+	}
+
+	if token := os.Getenv("FLIGHTPATH_RAW_STREAM_TOKEN"); token != "" {
+		cfg.Server.RawStreamToken = token
+	}
+
+	if token := os.Getenv("FLIGHTPATH_ADMIN_TOKEN"); token != "" {
+		cfg.Server.AdminToken = token
+	}
+
+	if path := os.Getenv("FLIGHTPATH_AUDIT_LOG"); path != "" {
+		cfg.Server.AuditLogPath = path
+	}
+
+	if enabled, ok := os.LookupEnv("FLIGHTPATH_SERVICES"); ok {
+		// Empty exposes no services
+		cfg.Server.EnabledServices = nil
+		for _, service := range strings.Split(enabled, ",") {
+			if service = strings.TrimSpace(service); service != "" {
+				cfg.Server.EnabledServices = append(cfg.Server.EnabledServices, service)
+			}
+		}
+	}
+
+	if keepalive := os.Getenv("FLIGHTPATH_STREAM_KEEPALIVE_MS"); keepalive != "" {
+		if ms, err := strconv.Atoi(keepalive); err == nil {
+			cfg.Server.StreamKeepalive = time.Duration(ms) * time.Millisecond
+		}
+	}
+
+	if rate := os.Getenv("FLIGHTPATH_FLEET_TELEMETRY_RATE_HZ"); rate != "" {
+		if n, err := strconv.ParseInt(rate, 10, 32); err == nil {
+			cfg.Server.FleetTelemetryRate = int32(n)
+		}
+	}
+
+	if idle := os.Getenv("FLIGHTPATH_CLIENT_IDLE_TIMEOUT_S"); idle != "" {
+		if s, err := strconv.Atoi(idle); err == nil {
+			cfg.Server.ClientIdleTimeout = time.Duration(s) * time.Second
+		}
+	}
+
+	if target := os.Getenv("FLIGHTPATH_EXPORT_TARGET"); target != "" {
+		cfg.Export.Target = target
+	}
+
+	if token := os.Getenv("FLIGHTPATH_EXPORT_TOKEN"); token != "" {
+		cfg.Export.Token = token
+	}
+
+	if intervalMs := os.Getenv("FLIGHTPATH_EXPORT_INTERVAL_MS"); intervalMs != "" {
+		if ms, err := strconv.Atoi(intervalMs); err == nil {
+			cfg.Export.Interval = time.Duration(ms) * time.Millisecond
+		}
+	}
+
+	if batch := os.Getenv("FLIGHTPATH_EXPORT_BATCH_SIZE"); batch != "" {
+		if n, err := strconv.Atoi(batch); err == nil {
+			cfg.Export.BatchSize = n
+		}
+	}
+
+	if flushMs := os.Getenv("FLIGHTPATH_EXPORT_FLUSH_MS"); flushMs != "" {
+		if ms, err := strconv.Atoi(flushMs); err == nil {
+			cfg.Export.FlushInterval = time.Duration(ms) * time.Millisecond
+		}
+	}
+
+	if dir := os.Getenv("FLIGHTPATH_EXPORT_TRACK_DIR"); dir != "" {
+		cfg.Export.TrackDir = dir
+	}
+
 	if registryPath := os.Getenv("FLIGHTPATH_DRONE_REGISTRY"); registryPath != "" {
 		cfg.Server.DroneRegistryPath = registryPath
 	}
 
+	if require := os.Getenv("FLIGHTPATH_REQUIRE_REGISTRY"); require != "" {
+		if required, err := strconv.ParseBool(require); err == nil {
+			cfg.Server.RequireRegistry = required
+		}
+	}
+
+	if commands := os.Getenv("FLIGHTPATH_COMMANDS_ENABLED"); commands != "" {
+		if enabled, err := strconv.ParseBool(commands); err == nil {
+			cfg.Server.CommandsEnabled = enabled
+		}
+	}
+
 	// Validate configuration
 	if err := cfg.Validate(); err != nil {
 		log.Fatalf("Invalid configuration: %v", err)
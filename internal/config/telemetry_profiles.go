@@ -0,0 +1,42 @@
+package config
+
+// TelemetryProfile maps MAVLink message names to rates in Hz
+// A rate of 0 disables the message.
+type TelemetryProfile map[string]float64
+
+// Built-in telemetry profile names
+const (
+	TelemetryProfileMinimal  = "minimal"
+	TelemetryProfileStandard = "standard"
+	TelemetryProfileHighRate = "high-rate"
+)
+
+// DefaultTelemetryProfiles returns the built-in telemetry profiles
+func DefaultTelemetryProfiles() map[string]TelemetryProfile {
+	return map[string]TelemetryProfile{
+		// Constrained links: enough to fly safely, nothing more
+		TelemetryProfileMinimal: {
+			"ATTITUDE":            2,
+			"SYS_STATUS":          1,
+			"GLOBAL_POSITION_INT": 1,
+			"VFR_HUD":             0,
+			"GPS_RAW_INT":         0,
+		},
+		TelemetryProfileStandard: {
+			"ATTITUDE":            10,
+			"SYS_STATUS":          2,
+			"GLOBAL_POSITION_INT": 5,
+			"VFR_HUD":             4,
+			"GPS_RAW_INT":         2,
+			"MISSION_CURRENT":     1,
+		},
+		TelemetryProfileHighRate: {
+			"ATTITUDE":            20,
+			"SYS_STATUS":          20,
+			"GLOBAL_POSITION_INT": 20,
+			"VFR_HUD":             20,
+			"GPS_RAW_INT":         20,
+			"MISSION_CURRENT":     20,
+		},
+	}
+}
@@ -0,0 +1,174 @@
+package export
+
+import (
+	"bytes"
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/flightpath-dev/flightpath-server/internal/config"
+	"github.com/flightpath-dev/flightpath-server/internal/server"
+)
+
+// httpTimeout bounds a single batch POST
+const httpTimeout = 10 * time.Second
+
+// writer delivers a batch of newline-terminated lines
+type writer interface {
+	write(batch []byte) error
+}
+
+// Exporter samples every connected drone's telemetry and writes it as line protocol
+type Exporter struct {
+	deps *server.Dependencies
+	cfg  config.ExportConfig
+	out  writer
+
+	// Lines waiting for the next write (only touched by run)
+	batch   bytes.Buffer
+	pending int
+
+	stop chan struct{}
+	done chan struct{}
+}
+
+// New creates an exporter for cfg.Target
+// Targets starting with http:// or https:// are POSTed to; anything else is a file path.
+func New(deps *server.Dependencies, cfg config.ExportConfig) (*Exporter, error) {
+	var out writer
+	if strings.HasPrefix(cfg.Target, "http://") || strings.HasPrefix(cfg.Target, "https://") {
+		out = &httpWriter{url: cfg.Target, token: cfg.Token, client: &http.Client{Timeout: httpTimeout}}
+	} else {
+		f, err := os.OpenFile(cfg.Target, os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0o644)
+		if err != nil {
+			return nil, fmt.Errorf("failed to open export file: %w", err)
+		}
+		out = &fileWriter{f: f}
+	}
+
+	return &Exporter{
+		deps: deps,
+		cfg:  cfg,
+		out:  out,
+		stop: make(chan struct{}),
+		done: make(chan struct{}),
+	}, nil
+}
+
+// Start begins sampling in the background
+func (e *Exporter) Start() {
+	go e.run()
+}
+
+// Stop flushes buffered samples and stops the exporter
+func (e *Exporter) Stop() error {
+	close(e.stop)
+	<-e.done
+
+	if f, ok := e.out.(*fileWriter); ok {
+		return f.f.Close()
+	}
+	return nil
+}
+
+func (e *Exporter) run() {
+	defer close(e.done)
+
+	sampleTicker := time.NewTicker(e.cfg.Interval)
+	defer sampleTicker.Stop()
+	flushTicker := time.NewTicker(e.cfg.FlushInterval)
+	defer flushTicker.Stop()
+
+	for {
+		select {
+		case <-e.stop:
+			e.flush()
+			return
+
+		case <-sampleTicker.C:
+			e.sample()
+			if e.pending >= e.cfg.BatchSize {
+				e.flush()
+			}
+
+		case <-flushTicker.C:
+			e.flush()
+		}
+	}
+}
+
+// sample appends one line per connected drone that has received telemetry
+func (e *Exporter) sample() {
+	now := time.Now()
+
+	for _, droneID := range e.deps.GetMAVLinkDroneIDs() {
+		client, ok := e.deps.GetMAVLinkClientFor(droneID)
+		if !ok || !client.IsConnected() {
+			continue
+		}
+
+		telemetry := client.GetTelemetry()
+		if telemetry.LastUpdate.IsZero() {
+			continue
+		}
+
+		e.batch.WriteString(FormatLine(droneID, telemetry, client.IsArmed(), now))
+		e.batch.WriteByte('\n')
+		e.pending++
+	}
+}
+
+// flush writes the batch; a failed batch is dropped so a dead target can't grow memory
+func (e *Exporter) flush() {
+	if e.pending == 0 {
+		return
+	}
+
+	if err := e.out.write(e.batch.Bytes()); err != nil {
+		e.deps.GetLogger().Printf("Export: Warning - dropped %d samples: %v", e.pending, err)
+	}
+
+	e.batch.Reset()
+	e.pending = 0
+}
+
+// fileWriter appends batches to a local file
+type fileWriter struct {
+	f *os.File
+}
+
+func (w *fileWriter) write(batch []byte) error {
+	_, err := w.f.Write(batch)
+	return err
+}
+
+// httpWriter POSTs batches to an InfluxDB-compatible write endpoint
+type httpWriter struct {
+	url    string
+	token  string
+	client *http.Client
+}
+
+func (w *httpWriter) write(batch []byte) error {
+	req, err := http.NewRequest(http.MethodPost, w.url, bytes.NewReader(batch))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "text/plain; charset=utf-8")
+	if w.token != "" {
+		req.Header.Set("Authorization", "Token "+w.token)
+	}
+
+	resp, err := w.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("write endpoint returned %s", resp.Status)
+	}
+	return nil
+}
@@ -0,0 +1,72 @@
+package export
+
+import (
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/flightpath-dev/flightpath-server/internal/mavlink"
+)
+
+// Measurement is the line-protocol measurement name for telemetry samples
+const Measurement = "telemetry"
+
+// tagEscaper escapes tag keys and values (commas, equals signs and spaces)
+var tagEscaper = strings.NewReplacer(",", `\,`, "=", `\=`, " ", `\ `)
+
+// FormatLine formats a telemetry sample as one InfluxDB line-protocol line
+// (without the trailing newline). Timestamps are in nanoseconds.
+func FormatLine(droneID string, t mavlink.TelemetryData, armed bool, ts time.Time) string {
+	var b strings.Builder
+
+	b.WriteString(Measurement)
+	b.WriteString(",drone_id=")
+	b.WriteString(tagEscaper.Replace(droneID))
+	b.WriteByte(' ')
+
+	writeFloat(&b, "lat", t.Latitude, true)
+	writeFloat(&b, "lon", t.Longitude, false)
+	writeFloat(&b, "alt", t.Altitude, false)
+	writeFloat(&b, "vx", t.VelocityX, false)
+	writeFloat(&b, "vy", t.VelocityY, false)
+	writeFloat(&b, "vz", t.VelocityZ, false)
+	writeFloat(&b, "roll", t.Roll, false)
+	writeFloat(&b, "pitch", t.Pitch, false)
+	writeFloat(&b, "yaw", t.Yaw, false)
+	writeFloat(&b, "heading", t.Heading, false)
+	writeFloat(&b, "ground_speed", t.GroundSpeed, false)
+	writeFloat(&b, "vertical_speed", t.VerticalSpeed, false)
+	writeFloat(&b, "battery_voltage", t.BatteryVoltage, false)
+	writeFloat(&b, "battery_current", t.BatteryCurrent, false)
+	writeInt(&b, "battery_remaining", int64(t.BatteryRemaining))
+	writeFloat(&b, "gps_accuracy", t.GPSAccuracy, false)
+	writeInt(&b, "satellites", int64(t.SatelliteCount))
+	writeInt(&b, "custom_mode", int64(t.CustomMode))
+	b.WriteString(",armed=")
+	b.WriteString(strconv.FormatBool(armed))
+	b.WriteString(",sensors_healthy=")
+	b.WriteString(strconv.FormatBool(t.SensorsHealthy))
+
+	b.WriteByte(' ')
+	b.WriteString(strconv.FormatInt(ts.UnixNano(), 10))
+
+	return b.String()
+}
+
+func writeFloat(b *strings.Builder, key string, v float64, first bool) {
+	if !first {
+		b.WriteByte(',')
+	}
+	b.WriteString(key)
+	b.WriteByte('=')
+	b.WriteString(strconv.FormatFloat(v, 'f', -1, 64))
+}
+
+// writeInt writes an integer field (line protocol marks these with an "i" suffix)
+func writeInt(b *strings.Builder, key string, v int64) {
+	b.WriteByte(',')
+	b.WriteString(key)
+	b.WriteByte('=')
+	b.WriteString(strconv.FormatInt(v, 10))
+	b.WriteByte('i')
+}
@@ -0,0 +1,49 @@
+package export
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/flightpath-dev/flightpath-server/internal/mavlink"
+)
+
+func TestFormatLine(t *testing.T) {
+	telemetry := mavlink.TelemetryData{
+		Latitude:         47.3977419,
+		Longitude:        8.5455938,
+		Altitude:         488.5,
+		VelocityX:        1.5,
+		VelocityZ:        -0.25,
+		Yaw:              1.2,
+		Heading:          69,
+		GroundSpeed:      1.5,
+		BatteryVoltage:   16.2,
+		BatteryCurrent:   12.75,
+		BatteryRemaining: 87,
+		GPSAccuracy:      0.8,
+		SatelliteCount:   14,
+		CustomMode:       393216,
+		SensorsHealthy:   true,
+	}
+	ts := time.Unix(1700000000, 123456789)
+
+	want := "telemetry,drone_id=alpha " +
+		"lat=47.3977419,lon=8.5455938,alt=488.5,vx=1.5,vy=0,vz=-0.25,roll=0,pitch=0,yaw=1.2," +
+		"heading=69,ground_speed=1.5,vertical_speed=0,battery_voltage=16.2,battery_current=12.75," +
+		"battery_remaining=87i,gps_accuracy=0.8,satellites=14i,custom_mode=393216i," +
+		"armed=true,sensors_healthy=true 1700000000123456789"
+	if got := FormatLine("alpha", telemetry, true, ts); got != want {
+		t.Errorf("FormatLine() =\n%s\nwant\n%s", got, want)
+	}
+}
+
+func TestFormatLineEscapesDroneID(t *testing.T) {
+	line := FormatLine("field drone,1=a", mavlink.TelemetryData{}, false, time.Unix(0, 0))
+	if want := `telemetry,drone_id=field\ drone\,1\=a lat=0,`; !strings.HasPrefix(line, want) {
+		t.Errorf("line = %s, want prefix %s", line, want)
+	}
+	if !strings.HasSuffix(line, ",armed=false,sensors_healthy=false 0") {
+		t.Errorf("line = %s", line)
+	}
+}
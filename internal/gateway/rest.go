@@ -0,0 +1,1315 @@
+package gateway
+
+import (
+	"context"
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"connectrpc.com/connect"
+
+	drone "github.com/flightpath-dev/flightpath-proto/gen/go/drone/v1"
+	"github.com/flightpath-dev/flightpath-server/internal/audit"
+	"github.com/flightpath-dev/flightpath-server/internal/mavlink"
+	"github.com/flightpath-dev/flightpath-server/internal/missionfile"
+	"github.com/flightpath-dev/flightpath-server/internal/server"
+	"github.com/flightpath-dev/flightpath-server/internal/services"
+)
+
+// PathPrefix is the URL prefix all REST routes live under
+const PathPrefix = "/api/v1/"
+
+// maxBodyBytes caps REST request bodies (missions are the largest)
+const maxBodyBytes = 1 << 20
+
+// Services groups the Connect service implementations the gateway calls into
+// A nil service is disabled and gets no routes.
+type Services struct {
+	Connection *services.ConnectionServer
+	Control    *services.ControlServer
+	Telemetry  *services.TelemetryServer
+	Mission    *services.MissionServer
+	Events     *services.EventServer
+	Geofence   *services.GeofenceServer
+	Parameters *services.ParameterServer
+}
+
+// REST maps resource-style JSON routes onto the Connect service methods
+// Handlers only translate HTTP to service calls; all logic stays in the services.
+// Drone-scoped routes make the drone in the path the active drone before calling
+// the service, the same as a Connect call to an already connected drone.
+type REST struct {
+	deps     *server.Dependencies
+	services Services
+	mux      *http.ServeMux
+}
+
+// NewREST creates the REST gateway and registers its routes
+func NewREST(deps *server.Dependencies, svc Services) *REST {
+	g := &REST{
+		deps:     deps,
+		services: svc,
+		mux:      http.NewServeMux(),
+	}
+
+	// Admin
+	g.mux.HandleFunc("GET /api/v1/log-level", g.getLogLevel)
+	g.mux.HandleFunc("PUT /api/v1/log-level", g.setLogLevel)
+	g.mux.HandleFunc("GET /api/v1/commands", g.getCommands)
+	g.mux.HandleFunc("PUT /api/v1/commands", g.setCommands)
+	g.mux.HandleFunc("GET /api/v1/config", g.getConfig)
+
+	// Routes of disabled (nil) services aren't registered, so they 404
+	if svc.Connection != nil {
+		g.mux.HandleFunc("GET /api/v1/drones", g.listDrones)
+		g.mux.HandleFunc("GET /api/v1/diagnostics", g.diagnostics)
+		g.mux.HandleFunc("GET /api/v1/inventory", g.inventory)
+		g.mux.HandleFunc("POST /api/v1/drones/{id}/connect", g.connect)
+		g.mux.HandleFunc("POST /api/v1/drones/{id}/disconnect", g.disconnect)
+		g.mux.HandleFunc("POST /api/v1/drones/{id}/force-reset", g.forceReset)
+		g.mux.HandleFunc("GET /api/v1/drones/{id}/status", g.status)
+		g.mux.HandleFunc("GET /api/v1/drones/{id}/connection", g.connectionInfo)
+		g.mux.HandleFunc("GET /api/v1/drones/{id}/capabilities", g.capabilities)
+	}
+
+	if svc.Control != nil {
+		g.mux.HandleFunc("POST /api/v1/drones/{id}/arm", g.arm)
+		g.mux.HandleFunc("POST /api/v1/drones/{id}/disarm", g.disarm)
+		g.mux.HandleFunc("POST /api/v1/drones/{id}/mode", g.setFlightMode)
+		g.mux.HandleFunc("POST /api/v1/drones/{id}/takeoff", g.takeoff)
+		g.mux.HandleFunc("POST /api/v1/drones/{id}/land", g.land)
+		g.mux.HandleFunc("POST /api/v1/drones/{id}/rtl", g.returnHome)
+		g.mux.HandleFunc("POST /api/v1/drones/{id}/goto", g.goToPosition)
+		g.mux.HandleFunc("POST /api/v1/drones/{id}/goto/cancel", g.cancelGoTo)
+		g.mux.HandleFunc("POST /api/v1/drones/{id}/reposition", g.reposition)
+		g.mux.HandleFunc("POST /api/v1/drones/{id}/home", g.setHome)
+		g.mux.HandleFunc("POST /api/v1/drones/{id}/yaw", g.setYaw)
+		g.mux.HandleFunc("POST /api/v1/drones/{id}/status-text", g.sendStatusText)
+		g.mux.HandleFunc("POST /api/v1/drones/{id}/rtcm", g.injectRTCM)
+		g.mux.HandleFunc("POST /api/v1/drones/{id}/flight-termination", g.flightTerminate)
+		g.mux.HandleFunc("POST /api/v1/drones/{id}/calibration", g.streamCalibration)
+		g.mux.HandleFunc("GET /api/v1/drones/{id}/gimbal", g.gimbal)
+		g.mux.HandleFunc("POST /api/v1/drones/{id}/gimbal/mode", g.setGimbalMode)
+		g.mux.HandleFunc("POST /api/v1/drones/{id}/gimbal/flags", g.setGimbalFlags)
+		g.mux.HandleFunc("POST /api/v1/drones/{id}/servo", g.setServo)
+		g.mux.HandleFunc("POST /api/v1/drones/{id}/servo/repeat", g.repeatServo)
+	}
+
+	if svc.Telemetry != nil {
+		g.mux.HandleFunc("GET /api/v1/snapshots", g.snapshotAll)
+		g.mux.HandleFunc("GET /api/v1/telemetry/stream", g.streamFleetTelemetry)
+		g.mux.HandleFunc("GET /api/v1/drones/{id}/snapshot", g.snapshot)
+		g.mux.HandleFunc("GET /api/v1/drones/{id}/telemetry/stream", g.streamTelemetry)
+		g.mux.HandleFunc("GET /api/v1/drones/{id}/readiness", g.readiness)
+		g.mux.HandleFunc("POST /api/v1/drones/{id}/telemetry/profile", g.setTelemetryProfile)
+		g.mux.HandleFunc("GET /api/v1/drones/{id}/named-values", g.namedValues)
+		g.mux.HandleFunc("GET /api/v1/drones/{id}/rtl-estimate", g.rtlEstimate)
+		g.mux.HandleFunc("GET /api/v1/drones/{id}/terrain", g.terrainStatus)
+		g.mux.HandleFunc("GET /api/v1/drones/{id}/batteries", g.batteries)
+		g.mux.HandleFunc("GET /api/v1/drones/{id}/track.gpx", g.trackGPX)
+		g.mux.HandleFunc("GET /api/v1/drones/{id}/named-values/stream", g.streamNamedValues)
+		g.mux.HandleFunc("GET /api/v1/drones/{id}/imu/stream", g.streamIMU)
+	}
+
+	if svc.Mission != nil {
+		g.mux.HandleFunc("POST /api/v1/drones/{id}/mission", g.uploadMission)
+		g.mux.HandleFunc("POST /api/v1/drones/{id}/mission/import", g.importMission)
+		g.mux.HandleFunc("GET /api/v1/drones/{id}/mission/export", g.exportMission)
+		g.mux.HandleFunc("GET /api/v1/drones/{id}/mission", g.downloadMission)
+		g.mux.HandleFunc("DELETE /api/v1/drones/{id}/mission", g.clearMission)
+		g.mux.HandleFunc("GET /api/v1/drones/{id}/mission/uploaded", g.uploadedMission)
+		g.mux.HandleFunc("GET /api/v1/drones/{id}/mission/stats", g.missionStats)
+		g.mux.HandleFunc("POST /api/v1/drones/{id}/mission/waypoints", g.appendWaypoint)
+		g.mux.HandleFunc("POST /api/v1/drones/{id}/mission/waypoints/{index}", g.insertWaypoint)
+		g.mux.HandleFunc("POST /api/v1/drones/{id}/mission/start", g.startMission)
+		g.mux.HandleFunc("POST /api/v1/drones/{id}/mission/pause", g.pauseMission)
+		g.mux.HandleFunc("POST /api/v1/drones/{id}/mission/resume", g.resumeMission)
+		g.mux.HandleFunc("POST /api/v1/drones/{id}/mission/abort", g.abortMission)
+		g.mux.HandleFunc("POST /api/v1/drones/{id}/mission/upload/cancel", g.cancelUpload)
+		g.mux.HandleFunc("GET /api/v1/drones/{id}/mission/progress", g.missionProgress)
+		g.mux.HandleFunc("GET /api/v1/drones/{id}/mission/timeline", g.missionTimeline)
+	}
+
+	if svc.Geofence != nil {
+		g.mux.HandleFunc("POST /api/v1/drones/{id}/geofence", g.setGeofence)
+	}
+
+	if svc.Parameters != nil {
+		g.mux.HandleFunc("GET /api/v1/drones/{id}/parameters", g.parametersByPrefix)
+		g.mux.HandleFunc("POST /api/v1/drones/{id}/parameters/read", g.readParameters)
+	}
+
+	if svc.Events != nil {
+		g.mux.HandleFunc("GET /api/v1/drones/{id}/raw", g.streamRawMessages)
+		g.mux.HandleFunc("GET /api/v1/drones/{id}/events", g.streamEvents)
+	}
+
+	return g
+}
+
+// ServeHTTP implements http.Handler
+func (g *REST) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	g.mux.ServeHTTP(w, r)
+}
+
+// Request bodies
+
+type connectBody struct {
+	TimeoutMs int32 `json:"timeout_ms"`
+}
+
+type flightModeBody struct {
+	Mode string `json:"mode"` // e.g. "FLIGHT_MODE_GUIDED"
+}
+
+type takeoffBody struct {
+	Altitude float64 `json:"altitude"`
+
+	// Take off without a good GPS fix
+	Force bool `json:"force,omitempty"`
+
+	// Take off even while a mission is flying
+	OverrideMission bool `json:"override_mission,omitempty"`
+}
+
+type goToBody struct {
+	positionBody
+
+	// Go to the position even while a mission is flying
+	OverrideMission bool `json:"override_mission,omitempty"`
+}
+
+type cancelGoToBody struct {
+	// Switch to hold (PX4 AUTO.LOITER) at the current position
+	Hold bool `json:"hold,omitempty"`
+}
+
+type telemetryProfileBody struct {
+	Profile string `json:"profile"` // e.g. "minimal"
+}
+
+type positionBody struct {
+	Latitude  float64 `json:"latitude"`
+	Longitude float64 `json:"longitude"`
+	Altitude  float64 `json:"altitude"`
+}
+
+// waypointBody and missionBody follow the mission.json format
+type waypointBody struct {
+	Sequence         int32        `json:"sequence"`
+	Position         positionBody `json:"position"`
+	Action           string       `json:"action"` // e.g. "ACTION_WAYPOINT"
+	HoldTimeSec      float64      `json:"hold_time_sec"`
+	AcceptanceRadius float64      `json:"acceptance_radius"`
+	Heading          float64      `json:"heading"`
+	AltitudeFrame    string       `json:"altitude_frame"` // "relative" (default), "msl" or "terrain"
+	LoiterRadius     float64      `json:"loiter_radius"`  // LOITER/HOLD only
+	LoiterTurns      float64      `json:"loiter_turns"`   // LOITER/HOLD only
+	Autocontinue     *bool        `json:"autocontinue"`   // omitted = server default
+
+	// Camera commands run once the waypoint is reached
+	Camera []mavlink.CameraAction `json:"camera"`
+}
+
+// toProto converts a waypoint body, resolving the action and altitude frame
+// names; settings the proto has no fields for are returned as options
+func (wp waypointBody) toProto() (*drone.Waypoint, mavlink.WaypointOptions, error) {
+	var opts mavlink.WaypointOptions
+	action, ok := drone.Waypoint_Action_value[wp.Action]
+	if !ok {
+		return nil, opts, fmt.Errorf("unknown action: %q", wp.Action)
+	}
+	frame, err := mavlink.ParseAltitudeFrame(wp.AltitudeFrame)
+	if err != nil {
+		return nil, opts, err
+	}
+	opts = mavlink.WaypointOptions{
+		AltitudeFrame: frame,
+		LoiterRadius:  wp.LoiterRadius,
+		LoiterTurns:   wp.LoiterTurns,
+		Autocontinue:  wp.Autocontinue,
+		Camera:        wp.Camera,
+	}
+	return &drone.Waypoint{
+		Sequence: wp.Sequence,
+		Position: &drone.Position{
+			Latitude:  wp.Position.Latitude,
+			Longitude: wp.Position.Longitude,
+			Altitude:  wp.Position.Altitude,
+		},
+		Action:           drone.Waypoint_Action(action),
+		HoldTimeSec:      wp.HoldTimeSec,
+		AcceptanceRadius: wp.AcceptanceRadius,
+		Heading:          wp.Heading,
+	}, opts, nil
+}
+
+type missionBody struct {
+	ID        string         `json:"id"`
+	Waypoints []waypointBody `json:"waypoints"`
+
+	// Read the item count back from the vehicle after the upload
+	VerifyCount bool `json:"verify_count,omitempty"`
+
+	// Set as home (altitude MSL) with DO_SET_HOME before uploading
+	PlannedHome *positionBody `json:"planned_home,omitempty"`
+}
+
+type disconnectBody struct {
+	// Close the link even while the drone is armed
+	Force bool `json:"force,omitempty"`
+}
+
+// Fleet
+
+func (g *REST) listDrones(w http.ResponseWriter, r *http.Request) {
+	resp, err := g.services.Connection.ListDrones(r.Context(), connect.NewRequest(&drone.ListDronesRequest{}))
+	writeResponse(w, resp, err)
+}
+
+func (g *REST) snapshotAll(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, g.services.Telemetry.GetSnapshotAll(r.Context()))
+}
+
+func (g *REST) diagnostics(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, g.services.Connection.GetDiagnostics(r.Context()))
+}
+
+func (g *REST) inventory(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, g.services.Connection.GetFleetInventory(r.Context()))
+}
+
+// Admin
+
+type logLevelBody struct {
+	Level string `json:"level"`
+}
+
+func (g *REST) getLogLevel(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, logLevelBody{Level: g.deps.GetLogLevel()})
+}
+
+// setLogLevel changes the live log level; SIGHUP restores the configured one
+func (g *REST) setLogLevel(w http.ResponseWriter, r *http.Request) {
+	if !authorizeBearer(w, r, g.deps.Config.Server.AdminToken, "changing the log level") {
+		return
+	}
+
+	var body logLevelBody
+	if !decodeBody(w, r, &body) {
+		return
+	}
+
+	previous := g.deps.GetLogLevel()
+	if err := g.deps.SetLogLevel(body.Level); err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	g.deps.GetLogger().Printf("Log level changed from %s to %s", previous, body.Level)
+	writeJSON(w, http.StatusOK, logLevelBody{Level: body.Level})
+}
+
+type commandsBody struct {
+	Enabled *bool `json:"enabled"`
+}
+
+func (g *REST) getCommands(w http.ResponseWriter, r *http.Request) {
+	enabled := g.deps.CommandsEnabled()
+	writeJSON(w, http.StatusOK, commandsBody{Enabled: &enabled})
+}
+
+// setCommands enables or disables state-changing requests (safe boot) until
+// restart; the change is audited
+func (g *REST) setCommands(w http.ResponseWriter, r *http.Request) {
+	if !authorizeBearer(w, r, g.deps.Config.Server.AdminToken, "enabling commands") {
+		return
+	}
+
+	var body commandsBody
+	if !decodeBody(w, r, &body) {
+		return
+	}
+	if body.Enabled == nil {
+		writeError(w, http.StatusBadRequest, "enabled is required")
+		return
+	}
+
+	enabled := *body.Enabled
+	g.deps.SetCommandsEnabled(enabled)
+
+	action := "disable_commands"
+	if enabled {
+		action = "enable_commands"
+	}
+	id := audit.IdentityFrom(r.Context())
+	g.deps.GetLogger().Printf("Commands enabled=%v (operator=%s, request_id=%s)", enabled, id.Operator, id.RequestID)
+	if g.deps.Audit != nil {
+		if err := g.deps.Audit.Record(audit.Entry{
+			Operator:  id.Operator,
+			RequestID: id.RequestID,
+			Action:    action,
+			Success:   true,
+		}); err != nil {
+			g.deps.GetLogger().Printf("ERROR - %v (action=%s)", err, action)
+		}
+	}
+	writeJSON(w, http.StatusOK, body)
+}
+
+// getConfig returns the effective configuration (defaults merged with the
+// environment), secrets redacted, to check overrides took effect
+func (g *REST) getConfig(w http.ResponseWriter, r *http.Request) {
+	if !authorizeBearer(w, r, g.deps.Config.Server.AdminToken, "configuration inspection") {
+		return
+	}
+	writeJSON(w, http.StatusOK, g.deps.Config.Redacted())
+}
+
+// Connection
+
+func (g *REST) connect(w http.ResponseWriter, r *http.Request) {
+	var body connectBody
+	if !decodeBody(w, r, &body) {
+		return
+	}
+
+	resp, err := g.services.Connection.Connect(r.Context(), connect.NewRequest(&drone.ConnectRequest{
+		DroneId:   r.PathValue("id"),
+		TimeoutMs: body.TimeoutMs,
+	}))
+	writeResponse(w, resp, err)
+}
+
+func (g *REST) disconnect(w http.ResponseWriter, r *http.Request) {
+	var body disconnectBody
+	if !decodeBody(w, r, &body) {
+		return
+	}
+	ctx, ok := g.droneContext(w, r)
+	if !ok {
+		return
+	}
+
+	req := connect.NewRequest(&drone.DisconnectRequest{})
+	if body.Force {
+		req.Header().Set(services.ForceDisconnectHeader, "true")
+	}
+	resp, err := g.services.Connection.Disconnect(ctx, req)
+	writeResponse(w, resp, err)
+}
+
+// forceReset abandons the drone's client without making it the active drone first
+func (g *REST) forceReset(w http.ResponseWriter, r *http.Request) {
+	if !authorizeBearer(w, r, g.deps.Config.Server.AdminToken, "force reset") {
+		return
+	}
+	resp, err := g.services.Connection.ForceReset(r.Context(), r.PathValue("id"))
+	if err != nil {
+		writeError(w, httpStatusFromCode(connect.CodeOf(err)), err.Error())
+		return
+	}
+	writeJSON(w, http.StatusOK, resp)
+}
+
+func (g *REST) status(w http.ResponseWriter, r *http.Request) {
+	ctx, ok := g.droneContext(w, r)
+	if !ok {
+		return
+	}
+	resp, err := g.services.Connection.GetStatus(ctx, connect.NewRequest(&drone.GetStatusRequest{}))
+	if resp != nil {
+		w.Header().Set(services.CommandsEnabledHeader, resp.Header().Get(services.CommandsEnabledHeader))
+	}
+	writeResponse(w, resp, err)
+}
+
+func (g *REST) connectionInfo(w http.ResponseWriter, r *http.Request) {
+	info, err := g.services.Connection.GetConnectionInfo(r.Context(), r.PathValue("id"))
+	if err != nil {
+		writeError(w, httpStatusFromCode(connect.CodeOf(err)), err.Error())
+		return
+	}
+	writeJSON(w, http.StatusOK, info)
+}
+
+func (g *REST) capabilities(w http.ResponseWriter, r *http.Request) {
+	caps, err := g.services.Connection.GetCapabilities(r.Context(), r.PathValue("id"))
+	if err != nil {
+		writeError(w, httpStatusFromCode(connect.CodeOf(err)), err.Error())
+		return
+	}
+	writeJSON(w, http.StatusOK, caps)
+}
+
+// Control
+
+func (g *REST) arm(w http.ResponseWriter, r *http.Request) {
+	callScoped(g, w, r, g.services.Control.Arm, &drone.ArmRequest{})
+}
+
+func (g *REST) disarm(w http.ResponseWriter, r *http.Request) {
+	callScoped(g, w, r, g.services.Control.Disarm, &drone.DisarmRequest{})
+}
+
+func (g *REST) setFlightMode(w http.ResponseWriter, r *http.Request) {
+	var body flightModeBody
+	if !decodeBody(w, r, &body) {
+		return
+	}
+
+	mode, ok := drone.FlightMode_value[body.Mode]
+	if !ok {
+		writeError(w, http.StatusBadRequest, fmt.Sprintf("unknown flight mode: %q", body.Mode))
+		return
+	}
+
+	callScoped(g, w, r, g.services.Control.SetFlightMode, &drone.SetFlightModeRequest{
+		Mode: drone.FlightMode(mode),
+	})
+}
+
+func (g *REST) takeoff(w http.ResponseWriter, r *http.Request) {
+	var body takeoffBody
+	if !decodeBody(w, r, &body) {
+		return
+	}
+	ctx, ok := g.droneContext(w, r)
+	if !ok {
+		return
+	}
+
+	req := connect.NewRequest(&drone.TakeoffRequest{Altitude: body.Altitude})
+	if body.Force {
+		req.Header().Set(services.ForceTakeoffHeader, "true")
+	}
+	if body.OverrideMission {
+		req.Header().Set(services.OverrideMissionHeader, "true")
+	}
+	resp, err := g.services.Control.Takeoff(ctx, req)
+	writeResponse(w, resp, err)
+}
+
+func (g *REST) land(w http.ResponseWriter, r *http.Request) {
+	callScoped(g, w, r, g.services.Control.Land, &drone.LandRequest{})
+}
+
+func (g *REST) returnHome(w http.ResponseWriter, r *http.Request) {
+	callScoped(g, w, r, g.services.Control.ReturnHome, &drone.ReturnHomeRequest{})
+}
+
+func (g *REST) goToPosition(w http.ResponseWriter, r *http.Request) {
+	var body goToBody
+	if !decodeBody(w, r, &body) {
+		return
+	}
+	ctx, ok := g.droneContext(w, r)
+	if !ok {
+		return
+	}
+
+	req := connect.NewRequest(&drone.GoToPositionRequest{
+		Target: &drone.Position{
+			Latitude:  body.Latitude,
+			Longitude: body.Longitude,
+			Altitude:  body.Altitude,
+		},
+	})
+	if body.OverrideMission {
+		req.Header().Set(services.OverrideMissionHeader, "true")
+	}
+	resp, err := g.services.Control.GoToPosition(ctx, req)
+	writeResponse(w, resp, err)
+}
+
+func (g *REST) cancelGoTo(w http.ResponseWriter, r *http.Request) {
+	var body cancelGoToBody
+	if !decodeBody(w, r, &body) {
+		return
+	}
+	ctx, ok := g.droneContext(w, r)
+	if !ok {
+		return
+	}
+
+	resp, err := g.services.Control.CancelGoTo(ctx, body.Hold)
+	if err != nil {
+		writeError(w, httpStatusFromCode(connect.CodeOf(err)), err.Error())
+		return
+	}
+	writeJSON(w, http.StatusOK, resp)
+}
+
+func (g *REST) reposition(w http.ResponseWriter, r *http.Request) {
+	var body services.RepositionRequest
+	if !decodeBody(w, r, &body) {
+		return
+	}
+	ctx, ok := g.droneContext(w, r)
+	if !ok {
+		return
+	}
+
+	resp, err := g.services.Control.Reposition(ctx, &body)
+	if err != nil {
+		writeError(w, httpStatusFromCode(connect.CodeOf(err)), err.Error())
+		return
+	}
+	writeJSON(w, http.StatusOK, resp)
+}
+
+func (g *REST) setHome(w http.ResponseWriter, r *http.Request) {
+	var body services.SetHomeRequest
+	if !decodeBody(w, r, &body) {
+		return
+	}
+	ctx, ok := g.droneContext(w, r)
+	if !ok {
+		return
+	}
+
+	resp, err := g.services.Control.SetHome(ctx, &body)
+	if err != nil {
+		writeError(w, httpStatusFromCode(connect.CodeOf(err)), err.Error())
+		return
+	}
+	writeJSON(w, http.StatusOK, resp)
+}
+
+func (g *REST) setYaw(w http.ResponseWriter, r *http.Request) {
+	var body services.SetYawRequest
+	if !decodeBody(w, r, &body) {
+		return
+	}
+	ctx, ok := g.droneContext(w, r)
+	if !ok {
+		return
+	}
+
+	resp, err := g.services.Control.SetYaw(ctx, &body)
+	if err != nil {
+		writeError(w, httpStatusFromCode(connect.CodeOf(err)), err.Error())
+		return
+	}
+	writeJSON(w, http.StatusOK, resp)
+}
+
+func (g *REST) gimbal(w http.ResponseWriter, r *http.Request) {
+	gimbal, err := g.services.Control.GetGimbal(r.Context(), r.PathValue("id"))
+	if err != nil {
+		writeError(w, httpStatusFromCode(connect.CodeOf(err)), err.Error())
+		return
+	}
+	writeJSON(w, http.StatusOK, gimbal)
+}
+
+func (g *REST) setGimbalMode(w http.ResponseWriter, r *http.Request) {
+	var body services.SetGimbalModeRequest
+	if !decodeBody(w, r, &body) {
+		return
+	}
+	ctx, ok := g.droneContext(w, r)
+	if !ok {
+		return
+	}
+
+	resp, err := g.services.Control.SetGimbalMode(ctx, &body)
+	if err != nil {
+		writeError(w, httpStatusFromCode(connect.CodeOf(err)), err.Error())
+		return
+	}
+	writeJSON(w, http.StatusOK, resp)
+}
+
+func (g *REST) setGimbalFlags(w http.ResponseWriter, r *http.Request) {
+	var body services.SetGimbalFlagsRequest
+	if !decodeBody(w, r, &body) {
+		return
+	}
+	ctx, ok := g.droneContext(w, r)
+	if !ok {
+		return
+	}
+
+	resp, err := g.services.Control.SetGimbalFlags(ctx, &body)
+	if err != nil {
+		writeError(w, httpStatusFromCode(connect.CodeOf(err)), err.Error())
+		return
+	}
+	writeJSON(w, http.StatusOK, resp)
+}
+
+func (g *REST) setServo(w http.ResponseWriter, r *http.Request) {
+	var body services.SetServoRequest
+	if !decodeBody(w, r, &body) {
+		return
+	}
+	ctx, ok := g.droneContext(w, r)
+	if !ok {
+		return
+	}
+
+	resp, err := g.services.Control.SetServo(ctx, &body)
+	if err != nil {
+		writeError(w, httpStatusFromCode(connect.CodeOf(err)), err.Error())
+		return
+	}
+	writeJSON(w, http.StatusOK, resp)
+}
+
+func (g *REST) repeatServo(w http.ResponseWriter, r *http.Request) {
+	var body services.RepeatServoRequest
+	if !decodeBody(w, r, &body) {
+		return
+	}
+	ctx, ok := g.droneContext(w, r)
+	if !ok {
+		return
+	}
+
+	resp, err := g.services.Control.RepeatServo(ctx, &body)
+	if err != nil {
+		writeError(w, httpStatusFromCode(connect.CodeOf(err)), err.Error())
+		return
+	}
+	writeJSON(w, http.StatusOK, resp)
+}
+
+// injectRTCM forwards a raw RTCM3 request body, streamed for as long as the
+// caller keeps it open, to the drone's GPS
+func (g *REST) injectRTCM(w http.ResponseWriter, r *http.Request) {
+	result, err := g.services.Control.InjectRTCM(r.Context(), r.PathValue("id"), r.Body)
+	if err != nil {
+		writeError(w, httpStatusFromCode(connect.CodeOf(err)), err.Error())
+		return
+	}
+	writeJSON(w, http.StatusOK, result)
+}
+
+func (g *REST) sendStatusText(w http.ResponseWriter, r *http.Request) {
+	var body services.SendStatusTextRequest
+	if !decodeBody(w, r, &body) {
+		return
+	}
+	ctx, ok := g.droneContext(w, r)
+	if !ok {
+		return
+	}
+
+	resp, err := g.services.Control.SendStatusText(ctx, &body)
+	if err != nil {
+		writeError(w, httpStatusFromCode(connect.CodeOf(err)), err.Error())
+		return
+	}
+	writeJSON(w, http.StatusOK, resp)
+}
+
+// streamCalibration runs a sensor calibration, streaming its progress as NDJSON
+// Closing the request before it ends cancels the calibration on the vehicle.
+func (g *REST) streamCalibration(w http.ResponseWriter, r *http.Request) {
+	var body services.StreamCalibrationRequest
+	if !decodeBody(w, r, &body) {
+		return
+	}
+	calType, err := mavlink.ParseCalibrationType(body.Type)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	stream := newNDJSONStream[mavlink.CalibrationStatus](w)
+	err = g.services.Control.StreamCalibration(r.Context(), r.PathValue("id"), calType, stream)
+	stream.finish(err)
+}
+
+// flightTerminate names the drone from the path instead of selecting it, so the
+// active drone isn't changed and can't change under the request
+// The operator header is only a label, so the admin token is what authorizes it.
+func (g *REST) flightTerminate(w http.ResponseWriter, r *http.Request) {
+	if !authorizeBearer(w, r, g.deps.Config.Server.AdminToken, "flight termination") {
+		return
+	}
+	var body services.FlightTerminateRequest
+	if !decodeBody(w, r, &body) {
+		return
+	}
+	body.DroneID = r.PathValue("id")
+	body.RequestID = r.Header.Get(audit.RequestIDHeader)
+	body.Operator = r.Header.Get(audit.OperatorHeader)
+
+	resp, err := g.services.Control.FlightTerminate(r.Context(), &body)
+	if err != nil {
+		writeError(w, httpStatusFromCode(connect.CodeOf(err)), err.Error())
+		return
+	}
+	writeJSON(w, http.StatusOK, resp)
+}
+
+// Parameters
+
+// parametersByPrefix lists cached parameters; ?prefix=GF_ filters them by name
+func (g *REST) parametersByPrefix(w http.ResponseWriter, r *http.Request) {
+	params, err := g.services.Parameters.GetParametersByPrefix(
+		r.Context(), r.PathValue("id"), r.URL.Query().Get("prefix"))
+	if err != nil {
+		writeError(w, httpStatusFromCode(connect.CodeOf(err)), err.Error())
+		return
+	}
+	writeJSON(w, http.StatusOK, params)
+}
+
+func (g *REST) readParameters(w http.ResponseWriter, r *http.Request) {
+	var body services.GetParametersRequest
+	if !decodeBody(w, r, &body) {
+		return
+	}
+
+	resp, err := g.services.Parameters.GetParameters(r.Context(), r.PathValue("id"), &body)
+	if err != nil {
+		writeError(w, httpStatusFromCode(connect.CodeOf(err)), err.Error())
+		return
+	}
+	writeJSON(w, http.StatusOK, resp)
+}
+
+// Geofence
+
+func (g *REST) setGeofence(w http.ResponseWriter, r *http.Request) {
+	var body services.SetGeofenceRequest
+	if !decodeBody(w, r, &body) {
+		return
+	}
+	ctx, ok := g.droneContext(w, r)
+	if !ok {
+		return
+	}
+
+	resp, err := g.services.Geofence.SetGeofence(ctx, &body)
+	if err != nil {
+		writeError(w, httpStatusFromCode(connect.CodeOf(err)), err.Error())
+		return
+	}
+	writeJSON(w, http.StatusOK, resp)
+}
+
+// Telemetry
+
+func (g *REST) snapshot(w http.ResponseWriter, r *http.Request) {
+	callScoped(g, w, r, g.services.Telemetry.GetSnapshot, &drone.GetSnapshotRequest{})
+}
+
+func (g *REST) setTelemetryProfile(w http.ResponseWriter, r *http.Request) {
+	var body telemetryProfileBody
+	if !decodeBody(w, r, &body) {
+		return
+	}
+
+	err := g.services.Telemetry.SetTelemetryProfile(r.Context(), r.PathValue("id"), body.Profile)
+	if err != nil {
+		writeError(w, httpStatusFromCode(connect.CodeOf(err)), err.Error())
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]any{
+		"success": true,
+		"message": fmt.Sprintf("Telemetry profile %s applied", body.Profile),
+	})
+}
+
+func (g *REST) readiness(w http.ResponseWriter, r *http.Request) {
+	readiness, err := g.services.Telemetry.GetReadiness(r.Context(), r.PathValue("id"))
+	if err != nil {
+		writeError(w, httpStatusFromCode(connect.CodeOf(err)), err.Error())
+		return
+	}
+	writeJSON(w, http.StatusOK, readiness)
+}
+
+func (g *REST) namedValues(w http.ResponseWriter, r *http.Request) {
+	values, err := g.services.Telemetry.GetNamedValues(r.Context(), r.PathValue("id"))
+	if err != nil {
+		writeError(w, httpStatusFromCode(connect.CodeOf(err)), err.Error())
+		return
+	}
+	writeJSON(w, http.StatusOK, values)
+}
+
+func (g *REST) rtlEstimate(w http.ResponseWriter, r *http.Request) {
+	estimate, err := g.services.Telemetry.GetRTLEstimate(r.Context(), r.PathValue("id"))
+	if err != nil {
+		writeError(w, httpStatusFromCode(connect.CodeOf(err)), err.Error())
+		return
+	}
+	writeJSON(w, http.StatusOK, estimate)
+}
+
+func (g *REST) terrainStatus(w http.ResponseWriter, r *http.Request) {
+	status, err := g.services.Telemetry.GetTerrainStatus(r.Context(), r.PathValue("id"))
+	if err != nil {
+		writeError(w, httpStatusFromCode(connect.CodeOf(err)), err.Error())
+		return
+	}
+	writeJSON(w, http.StatusOK, status)
+}
+
+// trackGPX serves the current flight's track as a GPX file download
+func (g *REST) trackGPX(w http.ResponseWriter, r *http.Request) {
+	droneID := r.PathValue("id")
+	content, err := g.services.Telemetry.GetTrackGPX(r.Context(), droneID)
+	if err != nil {
+		writeError(w, httpStatusFromCode(connect.CodeOf(err)), err.Error())
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/gpx+xml")
+	w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", droneID+".gpx"))
+	w.WriteHeader(http.StatusOK)
+	_, _ = w.Write(content)
+}
+
+func (g *REST) batteries(w http.ResponseWriter, r *http.Request) {
+	batteries, err := g.services.Telemetry.GetBatteries(r.Context(), r.PathValue("id"))
+	if err != nil {
+		writeError(w, httpStatusFromCode(connect.CodeOf(err)), err.Error())
+		return
+	}
+	writeJSON(w, http.StatusOK, batteries)
+}
+
+// streamTelemetry serves telemetry frames as NDJSON; ?rate_hz=5 sets the rate
+// The StreamTelemetry output headers (Flightpath-Units, Flightpath-Velocity-Frame) apply.
+func (g *REST) streamTelemetry(w http.ResponseWriter, r *http.Request) {
+	var rateHz int64
+	if rate := r.URL.Query().Get("rate_hz"); rate != "" {
+		var err error
+		if rateHz, err = strconv.ParseInt(rate, 10, 32); err != nil {
+			writeError(w, http.StatusBadRequest, fmt.Sprintf("invalid rate_hz: %q", rate))
+			return
+		}
+	}
+
+	stream := newNDJSONStream[services.TelemetryFrame](w)
+	err := g.services.Telemetry.StreamTelemetryFrames(r.Context(), r.PathValue("id"), int32(rateHz), r.Header, stream)
+	stream.finish(err)
+}
+
+// streamFleetTelemetry serves every drone's telemetry as one NDJSON stream;
+// ?rate_hz=20 sets the aggregate rate
+// The StreamTelemetry output headers (Flightpath-Units, Flightpath-Velocity-Frame) apply.
+func (g *REST) streamFleetTelemetry(w http.ResponseWriter, r *http.Request) {
+	var rateHz int64
+	if rate := r.URL.Query().Get("rate_hz"); rate != "" {
+		var err error
+		if rateHz, err = strconv.ParseInt(rate, 10, 32); err != nil {
+			writeError(w, http.StatusBadRequest, fmt.Sprintf("invalid rate_hz: %q", rate))
+			return
+		}
+	}
+
+	stream := newNDJSONStream[services.FleetTelemetryFrame](w)
+	err := g.services.Telemetry.StreamFleetTelemetry(r.Context(), int32(rateHz), r.Header, stream)
+	stream.finish(err)
+}
+
+// streamNamedValues serves named value updates as NDJSON; ?names=flow,tank filters them
+func (g *REST) streamNamedValues(w http.ResponseWriter, r *http.Request) {
+	var names []string
+	if n := r.URL.Query().Get("names"); n != "" {
+		names = strings.Split(n, ",")
+	}
+
+	stream := newNDJSONStream[mavlink.NamedValue](w)
+	err := g.services.Telemetry.StreamNamedValues(r.Context(), r.PathValue("id"), names, stream)
+	stream.finish(err)
+}
+
+// streamIMU serves raw IMU samples as NDJSON; ?rate_hz= sets the requested rate
+func (g *REST) streamIMU(w http.ResponseWriter, r *http.Request) {
+	var rateHz float64
+	if rate := r.URL.Query().Get("rate_hz"); rate != "" {
+		var err error
+		if rateHz, err = strconv.ParseFloat(rate, 64); err != nil {
+			writeError(w, http.StatusBadRequest, fmt.Sprintf("invalid rate_hz: %q", rate))
+			return
+		}
+	}
+
+	stream := newNDJSONStream[mavlink.IMUSample](w)
+	err := g.services.Telemetry.StreamIMU(r.Context(), r.PathValue("id"), rateHz, stream)
+	stream.finish(err)
+}
+
+// Mission
+
+func (g *REST) uploadMission(w http.ResponseWriter, r *http.Request) {
+	var body missionBody
+	if !decodeBody(w, r, &body) {
+		return
+	}
+
+	waypoints := make([]*drone.Waypoint, len(body.Waypoints))
+	options := make([]mavlink.WaypointOptions, len(body.Waypoints))
+	for i, wp := range body.Waypoints {
+		waypoint, opts, err := wp.toProto()
+		if err != nil {
+			writeError(w, http.StatusBadRequest, fmt.Sprintf("waypoint %d: %v", i, err))
+			return
+		}
+		waypoints[i] = waypoint
+		options[i] = opts
+	}
+
+	ctx, ok := g.droneContext(w, r)
+	if !ok {
+		return
+	}
+	req := connect.NewRequest(&drone.UploadMissionRequest{
+		Mission: &drone.Mission{
+			Id:        body.ID,
+			Waypoints: waypoints,
+		},
+	})
+	if body.VerifyCount {
+		req.Header().Set(services.VerifyUploadHeader, "count")
+	}
+
+	var plannedHome *drone.Position
+	if home := body.PlannedHome; home != nil {
+		plannedHome = &drone.Position{Latitude: home.Latitude, Longitude: home.Longitude, Altitude: home.Altitude}
+	}
+	resp, err := g.services.Mission.UploadMissionOptions(ctx, req, options, plannedHome)
+	writeResponse(w, resp, err)
+}
+
+func (g *REST) importMission(w http.ResponseWriter, r *http.Request) {
+	var body services.ImportMissionRequest
+	if !decodeBody(w, r, &body) {
+		return
+	}
+	ctx, ok := g.droneContext(w, r)
+	if !ok {
+		return
+	}
+
+	resp, err := g.services.Mission.ImportMission(ctx, &body)
+	writeResponse(w, resp, err)
+}
+
+// exportMission serves the uploaded mission as a file download (?format=plan|waypoints)
+func (g *REST) exportMission(w http.ResponseWriter, r *http.Request) {
+	droneID := r.PathValue("id")
+	file, err := g.services.Mission.ExportMission(r.Context(), droneID, r.URL.Query().Get("format"))
+	if err != nil {
+		writeError(w, httpStatusFromCode(connect.CodeOf(err)), err.Error())
+		return
+	}
+
+	contentType := "text/plain"
+	if file.Format == missionfile.FormatPlan {
+		contentType = "application/json"
+	}
+	w.Header().Set("Content-Type", contentType)
+	w.Header().Set("Content-Disposition",
+		fmt.Sprintf("attachment; filename=%q", droneID+file.Format.Extension()))
+	w.WriteHeader(http.StatusOK)
+	_, _ = io.WriteString(w, file.Content)
+}
+
+func (g *REST) downloadMission(w http.ResponseWriter, r *http.Request) {
+	callScoped(g, w, r, g.services.Mission.DownloadMission, &drone.DownloadMissionRequest{})
+}
+
+func (g *REST) clearMission(w http.ResponseWriter, r *http.Request) {
+	callScoped(g, w, r, g.services.Mission.ClearMission, &drone.ClearMissionRequest{})
+}
+
+func (g *REST) uploadedMission(w http.ResponseWriter, r *http.Request) {
+	mission, err := g.services.Mission.GetUploadedMission(r.Context(), r.PathValue("id"))
+	if err != nil {
+		writeError(w, httpStatusFromCode(connect.CodeOf(err)), err.Error())
+		return
+	}
+	writeJSON(w, http.StatusOK, mission)
+}
+
+func (g *REST) missionStats(w http.ResponseWriter, r *http.Request) {
+	stats, err := g.services.Mission.GetMissionStats(r.Context(), r.PathValue("id"))
+	if err != nil {
+		writeError(w, httpStatusFromCode(connect.CodeOf(err)), err.Error())
+		return
+	}
+	writeJSON(w, http.StatusOK, stats)
+}
+
+func (g *REST) appendWaypoint(w http.ResponseWriter, r *http.Request) {
+	var body waypointBody
+	if !decodeBody(w, r, &body) {
+		return
+	}
+	wp, opts, err := body.toProto()
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	mission, err := g.services.Mission.AppendWaypoint(r.Context(), r.PathValue("id"), wp, opts)
+	if err != nil {
+		writeError(w, httpStatusFromCode(connect.CodeOf(err)), err.Error())
+		return
+	}
+	writeJSON(w, http.StatusOK, mission)
+}
+
+func (g *REST) insertWaypoint(w http.ResponseWriter, r *http.Request) {
+	index, err := strconv.Atoi(r.PathValue("index"))
+	if err != nil {
+		writeError(w, http.StatusBadRequest, fmt.Sprintf("invalid waypoint index: %q", r.PathValue("index")))
+		return
+	}
+
+	var body waypointBody
+	if !decodeBody(w, r, &body) {
+		return
+	}
+	wp, opts, err := body.toProto()
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	mission, err := g.services.Mission.InsertWaypoint(r.Context(), r.PathValue("id"), index, wp, opts)
+	if err != nil {
+		writeError(w, httpStatusFromCode(connect.CodeOf(err)), err.Error())
+		return
+	}
+	writeJSON(w, http.StatusOK, mission)
+}
+
+func (g *REST) startMission(w http.ResponseWriter, r *http.Request) {
+	callScoped(g, w, r, g.services.Mission.StartMission, &drone.StartMissionRequest{})
+}
+
+func (g *REST) pauseMission(w http.ResponseWriter, r *http.Request) {
+	callScoped(g, w, r, g.services.Mission.PauseMission, &drone.PauseMissionRequest{})
+}
+
+func (g *REST) resumeMission(w http.ResponseWriter, r *http.Request) {
+	callScoped(g, w, r, g.services.Mission.ResumeMission, &drone.ResumeMissionRequest{})
+}
+
+func (g *REST) abortMission(w http.ResponseWriter, r *http.Request) {
+	ctx, ok := g.droneContext(w, r)
+	if !ok {
+		return
+	}
+
+	resp, err := g.services.Mission.AbortMission(ctx)
+	if err != nil {
+		writeError(w, httpStatusFromCode(connect.CodeOf(err)), err.Error())
+		return
+	}
+	writeJSON(w, http.StatusOK, resp)
+}
+
+func (g *REST) cancelUpload(w http.ResponseWriter, r *http.Request) {
+	ctx, ok := g.droneContext(w, r)
+	if !ok {
+		return
+	}
+
+	resp, err := g.services.Mission.CancelUpload(ctx)
+	if err != nil {
+		writeError(w, httpStatusFromCode(connect.CodeOf(err)), err.Error())
+		return
+	}
+	writeJSON(w, http.StatusOK, resp)
+}
+
+func (g *REST) missionProgress(w http.ResponseWriter, r *http.Request) {
+	callScoped(g, w, r, g.services.Mission.GetProgress, &drone.GetProgressRequest{})
+}
+
+func (g *REST) missionTimeline(w http.ResponseWriter, r *http.Request) {
+	timeline, err := g.services.Mission.GetTimeline(r.Context(), r.PathValue("id"))
+	if err != nil {
+		writeError(w, httpStatusFromCode(connect.CodeOf(err)), err.Error())
+		return
+	}
+	writeJSON(w, http.StatusOK, timeline)
+}
+
+// Events
+
+// streamRawMessages serves the raw MAVLink feed as NDJSON
+// The feed exposes everything on the link, so it needs the configured bearer
+// token and is off when none is set. ?types=ATTITUDE,HEARTBEAT filters it.
+func (g *REST) streamRawMessages(w http.ResponseWriter, r *http.Request) {
+	if !authorizeBearer(w, r, g.deps.Config.Server.RawStreamToken, "raw message stream") {
+		return
+	}
+
+	var types []string
+	if t := r.URL.Query().Get("types"); t != "" {
+		types = strings.Split(t, ",")
+	}
+
+	stream := newNDJSONStream[mavlink.RawMessage](w)
+	err := g.services.Events.StreamRawMessages(r.Context(), r.PathValue("id"), types, stream)
+	stream.finish(err)
+}
+
+// streamEvents serves the drone's vehicle and link events as NDJSON
+func (g *REST) streamEvents(w http.ResponseWriter, r *http.Request) {
+	stream := newNDJSONStream[mavlink.Event](w)
+	err := g.services.Events.StreamEvents(r.Context(), r.PathValue("id"), stream)
+	stream.finish(err)
+}
+
+// Helpers
+
+// authorizeBearer checks the request's bearer token against token, answering
+// 403 when the feature is disabled (no token) and 401 on a wrong or missing one
+func authorizeBearer(w http.ResponseWriter, r *http.Request, token, feature string) bool {
+	if token == "" {
+		writeError(w, http.StatusForbidden, feature+" is disabled")
+		return false
+	}
+	given, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+	if !ok || subtle.ConstantTimeCompare([]byte(given), []byte(token)) != 1 {
+		writeError(w, http.StatusUnauthorized, "invalid or missing bearer token")
+		return false
+	}
+	return true
+}
+
+// callScoped calls a unary service method on the drone in the path
+func callScoped[Req, Res any](
+	g *REST,
+	w http.ResponseWriter,
+	r *http.Request,
+	method func(context.Context, *connect.Request[Req]) (*connect.Response[Res], error),
+	msg *Req,
+) {
+	ctx, ok := g.droneContext(w, r)
+	if !ok {
+		return
+	}
+	resp, err := method(ctx, connect.NewRequest(msg))
+	writeResponse(w, resp, err)
+}
+
+// droneContext returns the request's context scoped to the drone in the path
+// (see services.WithDrone); the active drone is left alone, so concurrent
+// requests for different drones can't address each other's vehicle
+// Writes a 404 and returns false if the drone has no MAVLink client.
+func (g *REST) droneContext(w http.ResponseWriter, r *http.Request) (context.Context, bool) {
+	droneID := r.PathValue("id")
+	if _, ok := g.deps.GetMAVLinkClientFor(droneID); !ok {
+		writeError(w, http.StatusNotFound,
+			fmt.Sprintf("drone %q is not connected. Connect it first.", droneID))
+		return nil, false
+	}
+	return services.WithDrone(r.Context(), droneID), true
+}
+
+// decodeBody decodes an optional JSON request body into v
+// Writes a 400 and returns false if the body is not valid JSON.
+func decodeBody(w http.ResponseWriter, r *http.Request, v any) bool {
+	dec := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxBodyBytes))
+	if err := dec.Decode(v); err != nil && !errors.Is(err, io.EOF) {
+		writeError(w, http.StatusBadRequest, fmt.Sprintf("invalid request body: %v", err))
+		return false
+	}
+	return true
+}
+
+// ndjsonStream sends stream items as newline-delimited JSON, flushing each one
+// The 200 header goes out with the first item, so errors before that still get
+// a proper status.
+type ndjsonStream[T any] struct {
+	w       http.ResponseWriter
+	rc      *http.ResponseController
+	enc     *json.Encoder
+	started bool
+}
+
+func newNDJSONStream[T any](w http.ResponseWriter) *ndjsonStream[T] {
+	return &ndjsonStream[T]{w: w, rc: http.NewResponseController(w), enc: json.NewEncoder(w)}
+}
+
+func (s *ndjsonStream[T]) Send(v *T) error {
+	if !s.started {
+		s.w.Header().Set("Content-Type", "application/x-ndjson")
+		s.w.WriteHeader(http.StatusOK)
+		s.started = true
+	}
+	if err := s.enc.Encode(v); err != nil {
+		return err
+	}
+	return s.rc.Flush()
+}
+
+// finish reports a stream error: as a status if nothing was sent, else as a last line
+func (s *ndjsonStream[T]) finish(err error) {
+	if err == nil {
+		return
+	}
+	if !s.started {
+		writeError(s.w, httpStatusFromCode(connect.CodeOf(err)), err.Error())
+		return
+	}
+	_ = s.enc.Encode(map[string]string{"error": err.Error()})
+}
+
+// writeResponse writes a service result as JSON
+func writeResponse[Res any](w http.ResponseWriter, resp *connect.Response[Res], err error) {
+	if err != nil {
+		writeError(w, httpStatusFromCode(connect.CodeOf(err)), err.Error())
+		return
+	}
+	writeJSON(w, http.StatusOK, resp.Msg)
+}
+
+func writeError(w http.ResponseWriter, status int, message string) {
+	writeJSON(w, status, map[string]string{"error": message})
+}
+
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(v)
+}
+
+// httpStatusFromCode maps Connect error codes to HTTP status codes
+// (same mapping the Connect protocol uses on the wire)
+func httpStatusFromCode(code connect.Code) int {
+	switch code {
+	case connect.CodeCanceled:
+		return 499
+	case connect.CodeInvalidArgument, connect.CodeOutOfRange:
+		return http.StatusBadRequest
+	case connect.CodeDeadlineExceeded:
+		return http.StatusGatewayTimeout
+	case connect.CodeNotFound:
+		return http.StatusNotFound
+	case connect.CodeAlreadyExists, connect.CodeAborted:
+		return http.StatusConflict
+	case connect.CodePermissionDenied:
+		return http.StatusForbidden
+	case connect.CodeResourceExhausted:
+		return http.StatusTooManyRequests
+	case connect.CodeFailedPrecondition:
+		return http.StatusPreconditionFailed
+	case connect.CodeUnimplemented:
+		return http.StatusNotImplemented
+	case connect.CodeUnavailable:
+		return http.StatusServiceUnavailable
+	case connect.CodeUnauthenticated:
+		return http.StatusUnauthorized
+	default:
+		return http.StatusInternalServerError
+	}
+}
@@ -0,0 +1,101 @@
+package mavlink
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/bluenviron/gomavlib/v3/pkg/dialects/common"
+)
+
+// ErrOutsideAllowedArea is returned for position targets and mission items
+// outside the client's allowed area
+var ErrOutsideAllowedArea = errors.New("outside the allowed area")
+
+// AllowedArea is an axis-aligned latitude/longitude box the vehicle may be sent to
+// Altitude isn't limited. Boxes crossing the antimeridian aren't supported.
+type AllowedArea struct {
+	MinLatitude  float64 `json:"min_latitude"`
+	MaxLatitude  float64 `json:"max_latitude"`
+	MinLongitude float64 `json:"min_longitude"`
+	MaxLongitude float64 `json:"max_longitude"`
+}
+
+// Validate checks the box has valid, ordered corners
+func (a AllowedArea) Validate() error {
+	if a.MinLatitude < -90 || a.MaxLatitude > 90 || a.MinLatitude >= a.MaxLatitude {
+		return fmt.Errorf("latitudes must satisfy -90 <= min < max <= 90: %v, %v", a.MinLatitude, a.MaxLatitude)
+	}
+	if a.MinLongitude < -180 || a.MaxLongitude > 180 || a.MinLongitude >= a.MaxLongitude {
+		return fmt.Errorf("longitudes must satisfy -180 <= min < max <= 180: %v, %v", a.MinLongitude, a.MaxLongitude)
+	}
+	return nil
+}
+
+// Contains reports whether a position is inside the box (edges included)
+func (a AllowedArea) Contains(latitude, longitude float64) bool {
+	return latitude >= a.MinLatitude && latitude <= a.MaxLatitude &&
+		longitude >= a.MinLongitude && longitude <= a.MaxLongitude
+}
+
+// AllowedArea returns the client's allowed area, or false when targets aren't limited
+func (c *Client) AllowedArea() (AllowedArea, bool) {
+	if c.allowedArea == nil {
+		return AllowedArea{}, false
+	}
+	return *c.allowedArea, true
+}
+
+// checkAllowedArea rejects a position target outside the allowed area
+func (c *Client) checkAllowedArea(latitude, longitude float64) error {
+	if c.allowedArea == nil || c.allowedArea.Contains(latitude, longitude) {
+		return nil
+	}
+	return fmt.Errorf("target %.7f, %.7f is %w", latitude, longitude, ErrOutsideAllowedArea)
+}
+
+// checkMissionArea rejects a mission whose navigation items leave the allowed area
+// Items without a position (0, 0: e.g. LAND or TAKEOFF at the current location)
+// and non-navigation items such as ROI are not checked.
+func (c *Client) checkMissionArea(items []MissionItem) error {
+	if c.allowedArea == nil {
+		return nil
+	}
+	for i, item := range items {
+		if item.Command >= common.MAV_CMD_NAV_LAST || (item.X == 0 && item.Y == 0) {
+			continue
+		}
+		switch item.Frame {
+		case common.MAV_FRAME_GLOBAL, common.MAV_FRAME_GLOBAL_INT,
+			common.MAV_FRAME_GLOBAL_RELATIVE_ALT, common.MAV_FRAME_GLOBAL_RELATIVE_ALT_INT,
+			common.MAV_FRAME_GLOBAL_TERRAIN_ALT, common.MAV_FRAME_GLOBAL_TERRAIN_ALT_INT:
+		default:
+			continue
+		}
+		if err := c.checkAllowedArea(float64(item.X)/1e7, float64(item.Y)/1e7); err != nil {
+			return fmt.Errorf("mission item %d: %w", i, err)
+		}
+	}
+	return nil
+}
+
+// sendAllowedArea tells the vehicle about the allowed area with SAFETY_SET_ALLOWED_AREA
+// Few autopilots act on it; the server-side checks apply either way.
+func (c *Client) sendAllowedArea() error {
+	c.mu.RLock()
+	systemID := c.systemID
+	c.mu.RUnlock()
+
+	a := c.allowedArea
+	c.logger.Printf("MAVLink: Sending allowed area: lat %.7f..%.7f, lon %.7f..%.7f",
+		a.MinLatitude, a.MaxLatitude, a.MinLongitude, a.MaxLongitude)
+
+	return c.writeMessage(&common.MessageSafetySetAllowedArea{
+		TargetSystem:    systemID,
+		TargetComponent: 1,
+		Frame:           common.MAV_FRAME_GLOBAL,
+		P1x:             float32(a.MinLatitude),
+		P1y:             float32(a.MinLongitude),
+		P2x:             float32(a.MaxLatitude),
+		P2y:             float32(a.MaxLongitude),
+	})
+}
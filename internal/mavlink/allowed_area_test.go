@@ -0,0 +1,119 @@
+package mavlink
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/bluenviron/gomavlib/v3/pkg/dialects/common"
+)
+
+// zurichBox is a field east of Zurich
+var zurichBox = AllowedArea{MinLatitude: 47.39, MaxLatitude: 47.40, MinLongitude: 8.54, MaxLongitude: 8.56}
+
+func TestAllowedAreaContains(t *testing.T) {
+	tests := []struct {
+		name     string
+		lat, lon float64
+		want     bool
+	}{
+		{"inside", 47.395, 8.55, true},
+		{"on the edge", 47.39, 8.56, true},
+		{"south", 47.3899, 8.55, false},
+		{"north", 47.4001, 8.55, false},
+		{"west", 47.395, 8.5399, false},
+		{"east", 47.395, 8.5601, false},
+	}
+	for _, tt := range tests {
+		if got := zurichBox.Contains(tt.lat, tt.lon); got != tt.want {
+			t.Errorf("%s: Contains(%v, %v) = %v", tt.name, tt.lat, tt.lon, got)
+		}
+	}
+
+	if err := zurichBox.Validate(); err != nil {
+		t.Error(err)
+	}
+	for _, invalid := range []AllowedArea{
+		{MinLatitude: 47.40, MaxLatitude: 47.39, MinLongitude: 8.54, MaxLongitude: 8.56},
+		{MinLatitude: 47.39, MaxLatitude: 47.40, MinLongitude: 8.56, MaxLongitude: 8.56},
+		{MinLatitude: -91, MaxLatitude: 47.40, MinLongitude: 8.54, MaxLongitude: 8.56},
+		{MinLatitude: 47.39, MaxLatitude: 47.40, MinLongitude: 8.54, MaxLongitude: 181},
+	} {
+		if err := invalid.Validate(); err == nil {
+			t.Errorf("%+v accepted", invalid)
+		}
+	}
+}
+
+func TestAllowedAreaTargets(t *testing.T) {
+	c, vehicle := newLinkedTestClient(t)
+	area := zurichBox
+	c.allowedArea = &area
+
+	if err := c.GoToPosition(47.41, 8.55, 30); !errors.Is(err, ErrOutsideAllowedArea) {
+		t.Errorf("go-to outside: %v, want ErrOutsideAllowedArea", err)
+	}
+	if err := c.Reposition(47.395, 8.57, 30, 5, 0); !errors.Is(err, ErrOutsideAllowedArea) {
+		t.Errorf("reposition outside: %v, want ErrOutsideAllowedArea", err)
+	}
+
+	// Nothing was sent for those: the first command the vehicle sees is this one
+	result := make(chan error, 1)
+	go func() { result <- c.Reposition(47.395, 8.55, 30, 5, 0) }()
+	msg := receive[*common.MessageCommandInt](t, vehicle)
+	if msg.X != 473950000 || msg.Y != 85500000 {
+		t.Errorf("reposition sent to %d, %d", msg.X, msg.Y)
+	}
+	ack(c, msg.Command, common.MAV_RESULT_ACCEPTED)
+	if err := <-result; err != nil {
+		t.Errorf("reposition inside: %v", err)
+	}
+}
+
+func TestUploadItemsOutsideAllowedArea(t *testing.T) {
+	c, vehicle := newLinkedTestClient(t)
+	v := serveMissions(t, c, vehicle)
+	area := zurichBox
+	c.allowedArea = &area
+
+	items := []MissionItem{
+		// Takeoff where the vehicle is, and a command item: not checked
+		{Command: common.MAV_CMD_NAV_TAKEOFF, Frame: common.MAV_FRAME_GLOBAL_RELATIVE_ALT, Z: 20},
+		{Command: common.MAV_CMD_DO_SET_ROI_LOCATION, Frame: common.MAV_FRAME_GLOBAL_RELATIVE_ALT, X: 480000000, Y: 90000000},
+		{Command: common.MAV_CMD_NAV_WAYPOINT, Frame: common.MAV_FRAME_GLOBAL_RELATIVE_ALT, X: 473950000, Y: 85500000, Z: 30},
+		{Command: common.MAV_CMD_NAV_WAYPOINT, Frame: common.MAV_FRAME_GLOBAL_RELATIVE_ALT, X: 474100000, Y: 85500000, Z: 30},
+	}
+	err := c.UploadItems(common.MAV_MISSION_TYPE_MISSION, items)
+	if !errors.Is(err, ErrOutsideAllowedArea) || !strings.Contains(err.Error(), "mission item 3") {
+		t.Fatalf("mission leaving the area: %v", err)
+	}
+	v.mu.Lock()
+	_, started := v.items[common.MAV_MISSION_TYPE_MISSION]
+	v.mu.Unlock()
+	if started {
+		t.Error("rejected mission sent to the vehicle")
+	}
+
+	if err := c.UploadItems(common.MAV_MISSION_TYPE_MISSION, items[:3]); err != nil {
+		t.Errorf("mission inside the area: %v", err)
+	}
+	// Rally points aren't flight targets
+	if err := c.UploadRallyPoints([]RallyPoint{{48, 9, 30}}); err != nil {
+		t.Errorf("rally point outside the area: %v", err)
+	}
+}
+
+func TestSendAllowedArea(t *testing.T) {
+	c, vehicle := newLinkedTestClient(t)
+	area := zurichBox
+	c.allowedArea = &area
+
+	if err := c.sendAllowedArea(); err != nil {
+		t.Fatal(err)
+	}
+	msg := receive[*common.MessageSafetySetAllowedArea](t, vehicle)
+	if msg.Frame != common.MAV_FRAME_GLOBAL ||
+		msg.P1x != float32(47.39) || msg.P1y != float32(8.54) || msg.P2x != float32(47.40) || msg.P2y != float32(8.56) {
+		t.Errorf("SAFETY_SET_ALLOWED_AREA = %+v", msg)
+	}
+}
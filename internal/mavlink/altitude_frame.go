@@ -0,0 +1,55 @@
+package mavlink
+
+import (
+	"fmt"
+
+	"github.com/bluenviron/gomavlib/v3/pkg/dialects/common"
+)
+
+// AltitudeFrame is what a waypoint altitude is measured from
+type AltitudeFrame string
+
+const (
+	AltitudeRelative AltitudeFrame = "relative" // above home (default)
+	AltitudeMSL      AltitudeFrame = "msl"      // above mean sea level
+	AltitudeTerrain  AltitudeFrame = "terrain"  // above ground, needs terrain data on the vehicle
+)
+
+// ParseAltitudeFrame parses a frame name; "" means AltitudeRelative
+func ParseAltitudeFrame(name string) (AltitudeFrame, error) {
+	switch frame := AltitudeFrame(name); frame {
+	case "":
+		return AltitudeRelative, nil
+	case AltitudeRelative, AltitudeMSL, AltitudeTerrain:
+		return frame, nil
+	default:
+		return "", fmt.Errorf("unknown altitude frame: %q (must be relative, msl or terrain)", name)
+	}
+}
+
+// MAVFrame returns the MAV_FRAME for mission items in this altitude frame
+func (f AltitudeFrame) MAVFrame() common.MAV_FRAME {
+	switch f {
+	case AltitudeMSL:
+		return common.MAV_FRAME_GLOBAL
+	case AltitudeTerrain:
+		return common.MAV_FRAME_GLOBAL_TERRAIN_ALT
+	default:
+		return common.MAV_FRAME_GLOBAL_RELATIVE_ALT
+	}
+}
+
+// AltitudeFrameFromMAV maps a mission item frame back to an altitude frame
+// Returns false for frames that aren't global positions (e.g. MAV_FRAME_MISSION).
+func AltitudeFrameFromMAV(frame common.MAV_FRAME) (AltitudeFrame, bool) {
+	switch frame {
+	case common.MAV_FRAME_GLOBAL_RELATIVE_ALT, common.MAV_FRAME_GLOBAL_RELATIVE_ALT_INT:
+		return AltitudeRelative, true
+	case common.MAV_FRAME_GLOBAL, common.MAV_FRAME_GLOBAL_INT:
+		return AltitudeMSL, true
+	case common.MAV_FRAME_GLOBAL_TERRAIN_ALT, common.MAV_FRAME_GLOBAL_TERRAIN_ALT_INT:
+		return AltitudeTerrain, true
+	default:
+		return "", false
+	}
+}
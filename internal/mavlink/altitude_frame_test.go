@@ -0,0 +1,93 @@
+package mavlink
+
+import (
+	"testing"
+
+	"github.com/bluenviron/gomavlib/v3/pkg/dialects/common"
+
+	drone "github.com/flightpath-dev/flightpath-proto/gen/go/drone/v1"
+)
+
+func TestAltitudeFrameMapping(t *testing.T) {
+	tests := []struct {
+		name  string
+		frame AltitudeFrame
+		mav   common.MAV_FRAME
+	}{
+		{"", AltitudeRelative, common.MAV_FRAME_GLOBAL_RELATIVE_ALT},
+		{"relative", AltitudeRelative, common.MAV_FRAME_GLOBAL_RELATIVE_ALT},
+		{"msl", AltitudeMSL, common.MAV_FRAME_GLOBAL},
+		{"terrain", AltitudeTerrain, common.MAV_FRAME_GLOBAL_TERRAIN_ALT},
+	}
+	for _, tt := range tests {
+		frame, err := ParseAltitudeFrame(tt.name)
+		if err != nil || frame != tt.frame {
+			t.Errorf("ParseAltitudeFrame(%q) = %q, %v", tt.name, frame, err)
+		}
+		if got := frame.MAVFrame(); got != tt.mav {
+			t.Errorf("%s.MAVFrame() = %s, want %s", frame, got, tt.mav)
+		}
+		if back, ok := AltitudeFrameFromMAV(tt.mav); !ok || back != tt.frame {
+			t.Errorf("AltitudeFrameFromMAV(%s) = %q, %v", tt.mav, back, ok)
+		}
+	}
+
+	// Downloads may use the _INT variants
+	if frame, _ := AltitudeFrameFromMAV(common.MAV_FRAME_GLOBAL_TERRAIN_ALT_INT); frame != AltitudeTerrain {
+		t.Errorf("terrain INT frame = %q", frame)
+	}
+	if _, ok := AltitudeFrameFromMAV(common.MAV_FRAME_MISSION); ok {
+		t.Error("MAV_FRAME_MISSION mapped to an altitude frame")
+	}
+	if _, err := ParseAltitudeFrame("agl"); err == nil {
+		t.Error("unknown frame accepted")
+	}
+}
+
+func TestUploadWaypointFrames(t *testing.T) {
+	c, vehicle := newLinkedTestClient(t)
+	v := serveMissions(t, c, vehicle)
+
+	waypoints := make([]*drone.Waypoint, 4)
+	for i := range waypoints {
+		waypoints[i] = &drone.Waypoint{
+			Sequence: int32(i),
+			Action:   drone.Waypoint_ACTION_WAYPOINT,
+			Position: &drone.Position{Latitude: 47, Longitude: 8, Altitude: 30},
+		}
+	}
+	// The zero value is relative to home, as before frames could be chosen
+	options := []WaypointOptions{{}, {AltitudeFrame: AltitudeMSL}, {AltitudeFrame: AltitudeTerrain}, {AltitudeFrame: AltitudeRelative}}
+	if err := c.UploadMissionOptions(waypoints, options); err != nil {
+		t.Fatal(err)
+	}
+
+	want := []common.MAV_FRAME{
+		common.MAV_FRAME_GLOBAL_RELATIVE_ALT,
+		common.MAV_FRAME_GLOBAL,
+		common.MAV_FRAME_GLOBAL_TERRAIN_ALT,
+		common.MAV_FRAME_GLOBAL_RELATIVE_ALT,
+	}
+	v.mu.Lock()
+	uploaded := v.items[common.MAV_MISSION_TYPE_MISSION]
+	v.mu.Unlock()
+	if len(uploaded) != len(want) {
+		t.Fatalf("%d items uploaded, want %d", len(uploaded), len(want))
+	}
+	for i, item := range uploaded {
+		if item.Frame != want[i] {
+			t.Errorf("waypoint %d uploaded in %s, want %s", i, item.Frame, want[i])
+		}
+	}
+
+	// Downloaded items keep the frame they were uploaded in
+	items, err := c.DownloadMission()
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i, item := range items {
+		if item.Frame != want[i] {
+			t.Errorf("waypoint %d downloaded in %s, want %s", i, item.Frame, want[i])
+		}
+	}
+}
@@ -0,0 +1,128 @@
+package mavlink
+
+import (
+	"encoding/hex"
+	"fmt"
+	"slices"
+
+	"github.com/bluenviron/gomavlib/v3/pkg/dialects/common"
+)
+
+// AutopilotVersion is the vehicle's firmware and board identity from
+// AUTOPILOT_VERSION, for fleet inventory
+// Versions are semver strings ("4.5.1", "1.15.0-beta2"), empty when the
+// vehicle leaves them 0; git hashes are the 8 bytes AUTOPILOT_VERSION carries.
+type AutopilotVersion struct {
+	Reported bool `json:"reported"`
+
+	FlightSoftware     string `json:"flight_sw_version,omitempty"`
+	FlightGitHash      string `json:"flight_git_hash,omitempty"`
+	MiddlewareSoftware string `json:"middleware_sw_version,omitempty"`
+	MiddlewareGitHash  string `json:"middleware_git_hash,omitempty"`
+	OSSoftware         string `json:"os_sw_version,omitempty"`
+	OSGitHash          string `json:"os_git_hash,omitempty"`
+
+	// Raw flight_sw_version (major << 24 | minor << 16 | patch << 8 | type)
+	FlightSoftwareRaw uint32 `json:"flight_sw_version_raw"`
+
+	// board_version; its top 16 bits are the bootloader board type
+	BoardVersion uint32 `json:"board_version"`
+	BoardType    uint16 `json:"board_type"`
+	VendorID     uint16 `json:"vendor_id"`
+	ProductID    uint16 `json:"product_id"`
+
+	// Hardware UID in hex (uid2 when set, else uid; empty without either)
+	UID string `json:"uid,omitempty"`
+}
+
+// AutopilotVersionFrom decodes AUTOPILOT_VERSION; Reported is left for the
+// caller to set
+func AutopilotVersionFrom(msg *common.MessageAutopilotVersion) AutopilotVersion {
+	v := AutopilotVersion{
+		FlightSoftware:     FormatSoftwareVersion(msg.FlightSwVersion),
+		FlightGitHash:      formatGitHash(msg.FlightCustomVersion),
+		MiddlewareSoftware: FormatSoftwareVersion(msg.MiddlewareSwVersion),
+		MiddlewareGitHash:  formatGitHash(msg.MiddlewareCustomVersion),
+		OSSoftware:         FormatSoftwareVersion(msg.OsSwVersion),
+		OSGitHash:          formatGitHash(msg.OsCustomVersion),
+		FlightSoftwareRaw:  msg.FlightSwVersion,
+		BoardVersion:       msg.BoardVersion,
+		BoardType:          uint16(msg.BoardVersion >> 16),
+		VendorID:           msg.VendorId,
+		ProductID:          msg.ProductId,
+	}
+
+	switch {
+	case slices.ContainsFunc(msg.Uid2[:], func(b uint8) bool { return b != 0 }):
+		v.UID = hex.EncodeToString(msg.Uid2[:])
+	case msg.Uid != 0:
+		v.UID = fmt.Sprintf("%016x", msg.Uid)
+	}
+	return v
+}
+
+// FormatSoftwareVersion formats an AUTOPILOT_VERSION software version
+// (major << 24 | minor << 16 | patch << 8 | FIRMWARE_VERSION_TYPE) as semver
+// Pre-release types get a suffix, numbered when the type byte is past the
+// type's base (e.g. 0x82 is beta2): 0-63 dev, 64-127 alpha, 128-191 beta,
+// 192-254 rc, 255 official. 0 (not reported) formats as "".
+func FormatSoftwareVersion(v uint32) string {
+	if v == 0 {
+		return ""
+	}
+
+	version := fmt.Sprintf("%d.%d.%d", v>>24, (v>>16)&0xFF, (v>>8)&0xFF)
+
+	release := uint8(v)
+	var suffix string
+	var base uint8
+	switch {
+	case release == uint8(common.FIRMWARE_VERSION_TYPE_OFFICIAL):
+		return version
+	case release >= uint8(common.FIRMWARE_VERSION_TYPE_RC):
+		suffix, base = "rc", uint8(common.FIRMWARE_VERSION_TYPE_RC)
+	case release >= uint8(common.FIRMWARE_VERSION_TYPE_BETA):
+		suffix, base = "beta", uint8(common.FIRMWARE_VERSION_TYPE_BETA)
+	case release >= uint8(common.FIRMWARE_VERSION_TYPE_ALPHA):
+		suffix, base = "alpha", uint8(common.FIRMWARE_VERSION_TYPE_ALPHA)
+	default:
+		suffix, base = "dev", uint8(common.FIRMWARE_VERSION_TYPE_DEV)
+	}
+
+	if n := release - base; n > 0 {
+		return fmt.Sprintf("%s-%s%d", version, suffix, n)
+	}
+	return version + "-" + suffix
+}
+
+// formatGitHash formats a custom version field
+// ArduPilot sends the hash as ASCII hex digits; PX4 sends it as a
+// little-endian integer, so its bytes are printed in reverse. All zeros is "".
+func formatGitHash(b [8]uint8) string {
+	if b == [8]uint8{} {
+		return ""
+	}
+
+	ascii := true
+	for _, c := range b {
+		if !(c >= '0' && c <= '9' || c >= 'a' && c <= 'f' || c >= 'A' && c <= 'F') {
+			ascii = false
+			break
+		}
+	}
+	if ascii {
+		return string(b[:])
+	}
+
+	reversed := b
+	slices.Reverse(reversed[:])
+	return hex.EncodeToString(reversed[:])
+}
+
+// GetAutopilotVersion returns the vehicle's firmware and board identity
+// Reported is false until AUTOPILOT_VERSION arrives (requested after connecting).
+func (c *Client) GetAutopilotVersion() AutopilotVersion {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.stats.autopilotVersion
+}
@@ -0,0 +1,100 @@
+package mavlink
+
+import (
+	"testing"
+
+	"github.com/bluenviron/gomavlib/v3/pkg/dialects/common"
+)
+
+func TestFormatSoftwareVersion(t *testing.T) {
+	for _, tt := range []struct {
+		version uint32
+		want    string
+	}{
+		{0, ""},
+		{0x040501FF, "4.5.1"},
+		{0x010F0080, "1.15.0-beta"},
+		{0x010F0082, "1.15.0-beta2"},
+		{0x020300C1, "2.3.0-rc1"},
+		{0x01020340, "1.2.3-alpha"},
+		{0x01020300, "1.2.3-dev"},
+		{0x01020305, "1.2.3-dev5"},
+	} {
+		if got := FormatSoftwareVersion(tt.version); got != tt.want {
+			t.Errorf("FormatSoftwareVersion(%#08x) = %q, want %q", tt.version, got, tt.want)
+		}
+	}
+}
+
+func TestAutopilotVersionFrom(t *testing.T) {
+	// As sent by ArduPilot: ASCII git hashes, no OS version, 64-bit UID
+	ardupilot := AutopilotVersionFrom(&common.MessageAutopilotVersion{
+		FlightSwVersion:     0x040501FF,
+		MiddlewareSwVersion: 0x040501FF,
+		FlightCustomVersion: [8]uint8{'a', '1', 'b', '2', 'c', '3', 'd', '4'},
+		BoardVersion:        0x008C0000,
+		VendorId:            0x1209,
+		ProductId:           0x5741,
+		Uid:                 0x1234,
+	})
+	want := AutopilotVersion{
+		FlightSoftware:     "4.5.1",
+		FlightGitHash:      "a1b2c3d4",
+		MiddlewareSoftware: "4.5.1",
+		FlightSoftwareRaw:  0x040501FF,
+		BoardVersion:       0x008C0000,
+		BoardType:          140,
+		VendorID:           0x1209,
+		ProductID:          0x5741,
+		UID:                "0000000000001234",
+	}
+	if ardupilot != want {
+		t.Errorf("ArduPilot:\n got %+v\nwant %+v", ardupilot, want)
+	}
+
+	// As sent by PX4: little-endian git hashes, uid2 taking precedence
+	var uid2 [18]uint8
+	uid2[0], uid2[17] = 0xAB, 0xCD
+	px4 := AutopilotVersionFrom(&common.MessageAutopilotVersion{
+		FlightSwVersion:     0x010F0082,
+		OsSwVersion:         0x0C0300FF,
+		FlightCustomVersion: [8]uint8{0xEF, 0xCD, 0xAB, 0x89, 0x67, 0x45, 0x23, 0x01},
+		OsCustomVersion:     [8]uint8{0x10, 0x32, 0x54, 0x76, 0x98, 0xBA, 0xDC, 0xFE},
+		BoardVersion:        0x00320000,
+		Uid:                 0x1234,
+		Uid2:                uid2,
+	})
+	want = AutopilotVersion{
+		FlightSoftware:    "1.15.0-beta2",
+		FlightGitHash:     "0123456789abcdef",
+		OSSoftware:        "12.3.0",
+		OSGitHash:         "fedcba9876543210",
+		FlightSoftwareRaw: 0x010F0082,
+		BoardVersion:      0x00320000,
+		BoardType:         50,
+		UID:               "ab00000000000000000000000000000000cd",
+	}
+	if px4 != want {
+		t.Errorf("PX4:\n got %+v\nwant %+v", px4, want)
+	}
+}
+
+func TestGetAutopilotVersion(t *testing.T) {
+	c := newConnectedTestClient()
+	if v := c.GetAutopilotVersion(); v.Reported || v.FlightSoftware != "" {
+		t.Fatalf("before AUTOPILOT_VERSION: %+v", v)
+	}
+
+	c.handleMessage(&common.MessageAutopilotVersion{
+		FlightSwVersion: 0x040501FF,
+		BoardVersion:    0x008C0000,
+	}, 1, 1)
+
+	v := c.GetAutopilotVersion()
+	if !v.Reported || v.FlightSoftware != "4.5.1" || v.BoardType != 140 {
+		t.Errorf("autopilot version = %+v", v)
+	}
+	if info := c.GetConnectionInfo(); info.Version != v || info.FirmwareVersion != "4.5.1" {
+		t.Errorf("connection info: firmware %q, version %+v", info.FirmwareVersion, info.Version)
+	}
+}
@@ -0,0 +1,141 @@
+package mavlink
+
+import (
+	"math"
+	"time"
+)
+
+// Discharge rate measurement window
+// BatteryRemaining is a whole percent, so the rate is fitted over a window
+// rather than taken between two reports, and needs a minimum span to mean
+// anything.
+const (
+	dischargeWindow  = 60 * time.Second
+	minDischargeSpan = 15 * time.Second
+)
+
+type batterySample struct {
+	time    time.Time
+	percent float64
+}
+
+// DischargeTracker estimates how fast the battery drains from battery_remaining
+// reports (SYS_STATUS, or the primary BATTERY_STATUS battery). Not safe for concurrent use; the client guards
+// it with c.mu.
+type DischargeTracker struct {
+	samples []batterySample
+}
+
+// Add records a battery_remaining report (-1, unknown, is ignored)
+// A rise (battery swapped or the estimate recalibrated) starts over.
+func (t *DischargeTracker) Add(percent int32, now time.Time) {
+	if percent < 0 {
+		return
+	}
+	if n := len(t.samples); n > 0 && float64(percent) > t.samples[n-1].percent {
+		t.samples = t.samples[:0]
+	}
+	t.samples = append(t.samples, batterySample{time: now, percent: float64(percent)})
+
+	drop := 0
+	for drop < len(t.samples)-1 && now.Sub(t.samples[drop].time) > dischargeWindow {
+		drop++
+	}
+	t.samples = t.samples[drop:]
+}
+
+// Rate returns the discharge rate in percent per second, by least squares
+// over the window; false until the window spans minDischargeSpan or while the
+// battery isn't draining (e.g. disarmed)
+func (t *DischargeTracker) Rate() (float64, bool) {
+	n := len(t.samples)
+	if n < 2 || t.samples[n-1].time.Sub(t.samples[0].time) < minDischargeSpan {
+		return 0, false
+	}
+
+	var sumX, sumY, sumXX, sumXY float64
+	for _, s := range t.samples {
+		x := s.time.Sub(t.samples[0].time).Seconds()
+		sumX += x
+		sumY += s.percent
+		sumXX += x * x
+		sumXY += x * s.percent
+	}
+	denominator := float64(n)*sumXX - sumX*sumX
+	if denominator == 0 {
+		return 0, false
+	}
+	rate := -(float64(n)*sumXY - sumX*sumY) / denominator
+	if rate <= 0 || math.IsNaN(rate) {
+		return 0, false
+	}
+	return rate, true
+}
+
+// RTLAssumptions are the flight figures an RTL estimate is based on
+type RTLAssumptions struct {
+	CruiseSpeed    float64 // m/s flying home
+	DescentSpeed   float64 // m/s descending to land at home
+	ReservePercent float64 // battery that must be left after landing
+}
+
+// RTLEstimate is whether the battery lasts for a return to launch from here
+// Available is false (with Reason) until there is a position, a home and a
+// measured discharge rate; the other fields are only set when it is true.
+type RTLEstimate struct {
+	Available bool   `json:"available"`
+	Reason    string `json:"reason,omitempty"`
+
+	// The battery left after the return stays at or above the reserve
+	Feasible bool `json:"rtl_feasible"`
+
+	DistanceToHome float64 `json:"distance_to_home"` // meters
+	TimeToHome     float64 `json:"time_to_home_s"`   // flight and descent
+	DischargeRate  float64 `json:"discharge_rate"`   // percent per minute
+	BatteryNeeded  float64 `json:"battery_needed"`   // percent used by the return
+
+	// Battery above reserve after the return (negative when infeasible),
+	// and the flight time that leaves before the return must start
+	MarginPercent float64 `json:"margin_percent"`
+	MarginTime    float64 `json:"margin_time_s"`
+}
+
+// EstimateRTL estimates whether the vehicle can return home on its battery
+// The return is the straight-line distance at CruiseSpeed, then a descent
+// from the current height above home at DescentSpeed, draining at the rate
+// measured over the last minute.
+func (c *Client) EstimateRTL(a RTLAssumptions) RTLEstimate {
+	c.mu.RLock()
+	telemetry := c.telemetry
+	rate, rateOK := c.battery.Rate()
+	c.mu.RUnlock()
+
+	home, homeOK := c.GetHomePosition()
+
+	switch {
+	case !telemetry.PositionValid():
+		return RTLEstimate{Reason: "no position"}
+	case !homeOK:
+		return RTLEstimate{Reason: "no home position"}
+	case telemetry.BatteryRemaining < 0 || telemetry.SysStatusUpdated.IsZero():
+		return RTLEstimate{Reason: "battery level unknown"}
+	case !rateOK:
+		return RTLEstimate{Reason: "battery discharge rate not measured yet"}
+	}
+
+	distance := horizontalDistance(telemetry.Latitude, telemetry.Longitude, home.Latitude, home.Longitude)
+	timeToHome := distance/a.CruiseSpeed + math.Max(telemetry.Altitude-home.Altitude, 0)/a.DescentSpeed
+	needed := rate * timeToHome
+	margin := float64(telemetry.BatteryRemaining) - needed - a.ReservePercent
+
+	return RTLEstimate{
+		Available:      true,
+		Feasible:       margin >= 0,
+		DistanceToHome: distance,
+		TimeToHome:     timeToHome,
+		DischargeRate:  rate * 60,
+		BatteryNeeded:  needed,
+		MarginPercent:  margin,
+		MarginTime:     margin / rate,
+	}
+}
@@ -0,0 +1,139 @@
+package mavlink
+
+import (
+	"math"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/bluenviron/gomavlib/v3/pkg/dialects/common"
+)
+
+// batteryStatusMaxAge is how long a BATTERY_STATUS keeps its values ahead of
+// SYS_STATUS's aggregate ones; after that SYS_STATUS takes over again
+const batteryStatusMaxAge = 5 * time.Second
+
+// BatteryInfo is one battery instance as reported by BATTERY_STATUS
+// Unknown values are left out (nil, empty) or -1 where noted.
+type BatteryInfo struct {
+	ID       uint8  `json:"id"`
+	Function string `json:"function"` // ALL, PROPULSION, AVIONICS, PAYLOAD or UNKNOWN
+	Type     string `json:"type"`     // chemistry: LIPO, LIFE, LION, NIMH or UNKNOWN
+
+	Voltage      float64   `json:"voltage"`                 // volts, 0 when unknown
+	CellVoltages []float64 `json:"cell_voltages,omitempty"` // volts, cell 1 first; empty without per-cell readings
+	Current      float64   `json:"current"`                 // amps, -1 when not measured
+	Consumed     int32     `json:"consumed_mah"`            // mAh drawn, -1 when not estimated
+	Remaining    int32     `json:"remaining"`               // percent, -1 when not estimated
+
+	Temperature   *float64 `json:"temperature,omitempty"`    // °C
+	TimeRemaining int32    `json:"time_remaining,omitempty"` // seconds
+	ChargeState   string   `json:"charge_state,omitempty"`   // OK, LOW, CRITICAL, EMERGENCY, FAILED, ...
+
+	Updated time.Time `json:"updated"`
+}
+
+// BatteryInfoFrom decodes BATTERY_STATUS
+// Cells 1-10 come from voltages (UINT16_MAX: no cell) and 11-14 from
+// voltages_ext (0: no cell). A battery without per-cell readings sends its
+// total in cell 1 alone, split over cells 1 and 2 above 65.534 V.
+func BatteryInfoFrom(msg *common.MessageBatteryStatus) BatteryInfo {
+	info := BatteryInfo{
+		ID:        msg.Id,
+		Function:  strings.TrimPrefix(msg.BatteryFunction.String(), "MAV_BATTERY_FUNCTION_"),
+		Type:      strings.TrimPrefix(msg.Type.String(), "MAV_BATTERY_TYPE_"),
+		Current:   -1,
+		Consumed:  msg.CurrentConsumed,
+		Remaining: int32(msg.BatteryRemaining),
+
+		TimeRemaining: msg.TimeRemaining,
+	}
+	if msg.CurrentBattery != -1 {
+		info.Current = float64(msg.CurrentBattery) / 100.0
+	}
+	if msg.Temperature != math.MaxInt16 {
+		temperature := float64(msg.Temperature) / 100.0
+		info.Temperature = &temperature
+	}
+	if msg.ChargeState != common.MAV_BATTERY_CHARGE_STATE_UNDEFINED {
+		info.ChargeState = strings.TrimPrefix(msg.ChargeState.String(), "MAV_BATTERY_CHARGE_STATE_")
+	}
+
+	var cells []float64
+	var totalMV int
+	for _, mv := range msg.Voltages {
+		if mv == math.MaxUint16 {
+			break
+		}
+		cells = append(cells, float64(mv)/1000.0)
+		totalMV += int(mv)
+	}
+	if len(cells) == len(msg.Voltages) {
+		for _, mv := range msg.VoltagesExt {
+			if mv == 0 {
+				break
+			}
+			cells = append(cells, float64(mv)/1000.0)
+			totalMV += int(mv)
+		}
+	}
+
+	info.Voltage = float64(totalMV) / 1000.0
+	totalOnly := len(cells) == 1 ||
+		(len(cells) == 2 && msg.Voltages[0] == math.MaxUint16-1)
+	if !totalOnly {
+		info.CellVoltages = cells
+	}
+	return info
+}
+
+// handleBatteryStatus records a BATTERY_STATUS instance
+// The lowest-numbered battery is the primary one: its known voltage,
+// current and remaining charge replace SYS_STATUS's aggregate values while it
+// keeps reporting (see batteryStatusMaxAge).
+func (c *Client) handleBatteryStatus(msg *common.MessageBatteryStatus) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	now := time.Now()
+	info := BatteryInfoFrom(msg)
+	info.Updated = now
+
+	// Snapshots share the old slice, so build a new one
+	batteries := make([]BatteryInfo, 0, len(c.telemetry.Batteries)+1)
+	for _, b := range c.telemetry.Batteries {
+		if b.ID != info.ID {
+			batteries = append(batteries, b)
+		}
+	}
+	batteries = append(batteries, info)
+	slices.SortFunc(batteries, func(a, b BatteryInfo) int { return int(a.ID) - int(b.ID) })
+	c.telemetry.Batteries = batteries
+
+	if batteries[0].ID == info.ID {
+		if info.Voltage > 0 {
+			c.telemetry.BatteryVoltage = info.Voltage
+		}
+		if info.Current >= 0 {
+			c.telemetry.BatteryCurrent = info.Current
+		}
+		if info.Remaining >= 0 {
+			c.telemetry.BatteryRemaining = info.Remaining
+			c.battery.Add(info.Remaining, now)
+		}
+	}
+
+	c.telemetry.LastUpdate = now
+	c.telemetry.BatteryStatusUpdated = now
+	c.publishTelemetry()
+}
+
+// primaryBatteryLocked returns the primary BATTERY_STATUS battery, if it
+// reported within batteryStatusMaxAge; c.mu must be held
+func (c *Client) primaryBatteryLocked() (BatteryInfo, bool) {
+	if len(c.telemetry.Batteries) == 0 {
+		return BatteryInfo{}, false
+	}
+	primary := c.telemetry.Batteries[0]
+	return primary, time.Since(primary.Updated) <= batteryStatusMaxAge
+}
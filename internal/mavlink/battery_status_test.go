@@ -0,0 +1,118 @@
+package mavlink
+
+import (
+	"math"
+	"reflect"
+	"testing"
+
+	"github.com/bluenviron/gomavlib/v3/pkg/dialects/common"
+)
+
+// noCells is BATTERY_STATUS voltages with no cell readings
+func noCells() [10]uint16 {
+	var v [10]uint16
+	for i := range v {
+		v[i] = math.MaxUint16
+	}
+	return v
+}
+
+// sixCellPack is a synthetic 6S LiPo at 3.9-4.0 V per cell
+func sixCellPack(id uint8, remaining int8) *common.MessageBatteryStatus {
+	voltages := noCells()
+	copy(voltages[:], []uint16{4000, 3980, 3960, 3940, 3920, 3900})
+	return &common.MessageBatteryStatus{
+		Id:               id,
+		BatteryFunction:  common.MAV_BATTERY_FUNCTION_ALL,
+		Type:             common.MAV_BATTERY_TYPE_LIPO,
+		Temperature:      3150,
+		Voltages:         voltages,
+		CurrentBattery:   1250,
+		CurrentConsumed:  1800,
+		BatteryRemaining: remaining,
+		TimeRemaining:    600,
+		ChargeState:      common.MAV_BATTERY_CHARGE_STATE_OK,
+	}
+}
+
+func TestBatteryInfoFrom(t *testing.T) {
+	info := BatteryInfoFrom(sixCellPack(0, 64))
+	want := []float64{4.0, 3.98, 3.96, 3.94, 3.92, 3.9}
+	if !reflect.DeepEqual(info.CellVoltages, want) {
+		t.Errorf("cells = %v, want %v", info.CellVoltages, want)
+	}
+	if !near(info.Voltage, 23.7) || !near(info.Current, 12.5) || info.Consumed != 1800 ||
+		info.Remaining != 64 || info.TimeRemaining != 600 {
+		t.Errorf("six cells: %+v", info)
+	}
+	if info.Temperature == nil || !near(*info.Temperature, 31.5) {
+		t.Errorf("temperature = %v, want 31.5", info.Temperature)
+	}
+	if info.Function != "ALL" || info.Type != "LIPO" || info.ChargeState != "OK" {
+		t.Errorf("function %q, type %q, charge state %q", info.Function, info.Type, info.ChargeState)
+	}
+
+	// Total only: no cell list, unknown current and temperature
+	voltages := noCells()
+	voltages[0] = 16800
+	info = BatteryInfoFrom(&common.MessageBatteryStatus{
+		Voltages:         voltages,
+		CurrentBattery:   -1,
+		CurrentConsumed:  -1,
+		Temperature:      math.MaxInt16,
+		BatteryRemaining: -1,
+	})
+	if info.CellVoltages != nil || !near(info.Voltage, 16.8) || info.Current != -1 ||
+		info.Temperature != nil || info.Remaining != -1 || info.ChargeState != "" {
+		t.Errorf("total only: %+v", info)
+	}
+
+	// Over 65.534 V, the total is split over cells 1 and 2
+	voltages = noCells()
+	voltages[0], voltages[1] = math.MaxUint16-1, 4466
+	if info = BatteryInfoFrom(&common.MessageBatteryStatus{Voltages: voltages}); info.CellVoltages != nil || !near(info.Voltage, 70) {
+		t.Errorf("split total: %+v", info)
+	}
+
+	// 14 cells: 11-14 from voltages_ext
+	var full [10]uint16
+	for i := range full {
+		full[i] = 3700
+	}
+	info = BatteryInfoFrom(&common.MessageBatteryStatus{Voltages: full, VoltagesExt: [4]uint16{3700, 3700, 3600, 0}})
+	if len(info.CellVoltages) != 13 || info.CellVoltages[12] != 3.6 || !near(info.Voltage, 48) {
+		t.Errorf("extended cells: %v (%v V)", info.CellVoltages, info.Voltage)
+	}
+}
+
+func TestHandleBatteryStatus(t *testing.T) {
+	c := newConnectedTestClient()
+	c.handleMessage(&common.MessageSysStatus{VoltageBattery: 22000, CurrentBattery: 900, BatteryRemaining: 50}, 1, 1)
+
+	// The lowest ID is the primary battery, whichever reports first
+	c.handleMessage(sixCellPack(1, 70), 1, 1)
+	c.handleMessage(sixCellPack(0, 64), 1, 1)
+
+	telemetry := c.GetTelemetry()
+	if len(telemetry.Batteries) != 2 || telemetry.Batteries[0].ID != 0 || telemetry.Batteries[1].ID != 1 {
+		t.Fatalf("batteries = %+v", telemetry.Batteries)
+	}
+	if len(telemetry.Batteries[0].CellVoltages) != 6 {
+		t.Errorf("primary cells = %v", telemetry.Batteries[0].CellVoltages)
+	}
+	if telemetry.BatteryRemaining != 64 || !near(telemetry.BatteryVoltage, 23.7) || !near(telemetry.BatteryCurrent, 12.5) {
+		t.Errorf("aggregates: %v%%, %v V, %v A; want battery 0's", telemetry.BatteryRemaining, telemetry.BatteryVoltage, telemetry.BatteryCurrent)
+	}
+
+	// SYS_STATUS doesn't override a reporting battery
+	c.handleMessage(&common.MessageSysStatus{VoltageBattery: 22000, CurrentBattery: 900, BatteryRemaining: 50}, 1, 1)
+	if telemetry = c.GetTelemetry(); telemetry.BatteryRemaining != 64 || !near(telemetry.BatteryVoltage, 23.7) {
+		t.Errorf("after SYS_STATUS: %v%%, %v V", telemetry.BatteryRemaining, telemetry.BatteryVoltage)
+	}
+
+	// An update replaces its instance only
+	c.handleMessage(sixCellPack(1, 69), 1, 1)
+	if telemetry = c.GetTelemetry(); len(telemetry.Batteries) != 2 || telemetry.Batteries[1].Remaining != 69 || telemetry.BatteryRemaining != 64 {
+		t.Errorf("after battery 1 update: %+v, remaining %v", telemetry.Batteries, telemetry.BatteryRemaining)
+	}
+}
@@ -0,0 +1,131 @@
+package mavlink
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	"github.com/bluenviron/gomavlib/v3/pkg/dialects/common"
+)
+
+// discharge feeds a tracker one battery_remaining report a second for the
+// given number of seconds, the level following curve(seconds since start)
+// and truncated to a whole percent as the autopilot reports it
+func discharge(tracker *DischargeTracker, start time.Time, seconds int, curve func(float64) float64) time.Time {
+	now := start
+	for i := range seconds {
+		now = start.Add(time.Duration(i) * time.Second)
+		tracker.Add(int32(curve(float64(i))), now)
+	}
+	return now
+}
+
+func TestDischargeTrackerRate(t *testing.T) {
+	start := time.Now()
+	tests := []struct {
+		name     string
+		feed     func(*DischargeTracker)
+		wantRate float64 // percent per second
+		wantOK   bool
+	}{
+		{"steady drain", func(tr *DischargeTracker) {
+			discharge(tr, start, 90, func(s float64) float64 { return 95 - 0.05*s })
+		}, 0.05, true},
+		{"window follows a faster drain", func(tr *DischargeTracker) {
+			end := discharge(tr, start, 120, func(s float64) float64 { return 95 - 0.02*s })
+			discharge(tr, end.Add(time.Second), 90, func(s float64) float64 { return 92 - 0.2*s })
+		}, 0.2, true},
+		{"too short to measure", func(tr *DischargeTracker) {
+			discharge(tr, start, 10, func(s float64) float64 { return 95 - 0.5*s })
+		}, 0, false},
+		{"not draining", func(tr *DischargeTracker) {
+			discharge(tr, start, 60, func(float64) float64 { return 80 })
+		}, 0, false},
+		{"battery swap starts over", func(tr *DischargeTracker) {
+			end := discharge(tr, start, 60, func(s float64) float64 { return 40 - 0.1*s })
+			discharge(tr, end.Add(time.Second), 10, func(s float64) float64 { return 100 - 0.1*s })
+		}, 0, false},
+		{"unknown levels ignored", func(tr *DischargeTracker) {
+			discharge(tr, start, 60, func(s float64) float64 {
+				if int(s)%2 == 1 {
+					return -1
+				}
+				return 95 - 0.1*s
+			})
+		}, 0.1, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var tracker DischargeTracker
+			tt.feed(&tracker)
+
+			rate, ok := tracker.Rate()
+			if ok != tt.wantOK {
+				t.Fatalf("Rate() ok = %v, want %v", ok, tt.wantOK)
+			}
+			// Whole-percent reports leave up to a percent of error over the window
+			if ok && math.Abs(rate-tt.wantRate) > tt.wantRate*0.1 {
+				t.Errorf("Rate() = %v%%/s, want %v%%/s", rate, tt.wantRate)
+			}
+		})
+	}
+}
+
+func TestEstimateRTL(t *testing.T) {
+	assumptions := RTLAssumptions{CruiseSpeed: 10, DescentSpeed: 2, ReservePercent: 10}
+	c := newConnectedTestClient()
+
+	if e := c.EstimateRTL(assumptions); e.Available || e.Reason != "no position" {
+		t.Fatalf("without a position: %+v", e)
+	}
+
+	// 1 km north of home and 100 m above it
+	north := int32(math.Round((47 + 1000/earthRadius*180/math.Pi) * 1e7))
+	c.handleMessage(&common.MessageGlobalPositionInt{Lat: north, Lon: 80000000, Alt: 500000}, 1, 1)
+	if e := c.EstimateRTL(assumptions); e.Available || e.Reason != "no home position" {
+		t.Fatalf("without a home: %+v", e)
+	}
+
+	c.handleMessage(&common.MessageHomePosition{Latitude: 470000000, Longitude: 80000000, Altitude: 400000}, 1, 1)
+	if e := c.EstimateRTL(assumptions); e.Available || e.Reason != "battery level unknown" {
+		t.Fatalf("without a battery level: %+v", e)
+	}
+
+	c.handleMessage(&common.MessageSysStatus{BatteryRemaining: 30}, 1, 1)
+	if e := c.EstimateRTL(assumptions); e.Available || e.Reason != "battery discharge rate not measured yet" {
+		t.Fatalf("without a discharge rate: %+v", e)
+	}
+
+	// Draining at 0.1%/s, 30% left now
+	c.mu.Lock()
+	c.battery = DischargeTracker{}
+	now := time.Now()
+	for i, percent := range []int32{32, 31, 30} {
+		c.battery.Add(percent, now.Add(time.Duration(i-2)*10*time.Second))
+	}
+	c.mu.Unlock()
+
+	e := c.EstimateRTL(assumptions)
+	if !e.Available {
+		t.Fatalf("estimate unavailable: %s", e.Reason)
+	}
+	// 100 s home at 10 m/s, then 50 s descending 100 m at 2 m/s
+	if math.Abs(e.DistanceToHome-1000) > 0.1 || math.Abs(e.TimeToHome-150) > 0.01 {
+		t.Errorf("distance, time to home = %v m, %v s, want 1000 m, 150 s", e.DistanceToHome, e.TimeToHome)
+	}
+	if math.Abs(e.DischargeRate-6) > 0.1 {
+		t.Errorf("discharge rate = %v%%/min, want 6", e.DischargeRate)
+	}
+	// 15% for the return leaves 5% above the 10% reserve: 50 s at 0.1%/s
+	if !e.Feasible || math.Abs(e.BatteryNeeded-15) > 0.2 || math.Abs(e.MarginPercent-5) > 0.2 || math.Abs(e.MarginTime-50) > 2 {
+		t.Errorf("feasible=%v needed=%v margin=%v%% (%v s), want feasible with 15%% needed and 5%% (50 s) left",
+			e.Feasible, e.BatteryNeeded, e.MarginPercent, e.MarginTime)
+	}
+
+	// A 20% reserve can't be kept
+	assumptions.ReservePercent = 20
+	if e := c.EstimateRTL(assumptions); e.Feasible || e.MarginPercent >= 0 || e.MarginTime >= 0 {
+		t.Errorf("with a 20%% reserve: feasible=%v margin=%v%% (%v s), want infeasible", e.Feasible, e.MarginPercent, e.MarginTime)
+	}
+}
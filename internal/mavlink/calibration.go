@@ -0,0 +1,196 @@
+package mavlink
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/bluenviron/gomavlib/v3/pkg/dialects/common"
+)
+
+// CalibrationType selects the sensor calibrated by MAV_CMD_PREFLIGHT_CALIBRATION
+type CalibrationType int
+
+const (
+	CalibrationGyro CalibrationType = iota + 1
+	CalibrationMagnetometer
+	CalibrationAccelerometer
+	CalibrationLevel
+	CalibrationBarometer
+	CalibrationRadio
+)
+
+// String returns the calibration type name
+func (t CalibrationType) String() string {
+	switch t {
+	case CalibrationGyro:
+		return "gyro"
+	case CalibrationMagnetometer:
+		return "mag"
+	case CalibrationAccelerometer:
+		return "accel"
+	case CalibrationLevel:
+		return "level"
+	case CalibrationBarometer:
+		return "baro"
+	case CalibrationRadio:
+		return "radio"
+	default:
+		return fmt.Sprintf("unknown(%d)", int(t))
+	}
+}
+
+// CalibrationStatus is a calibration progress update parsed from STATUSTEXT
+type CalibrationStatus struct {
+	// Text is the STATUSTEXT verbatim, including orientation prompts
+	// ("rotate vehicle", "hold still") during multi-step mag/accel calibration
+	Text string `json:"text"`
+
+	// Progress in percent, or -1 if this message carries no progress value
+	Progress int `json:"progress"`
+
+	Done   bool `json:"done"`
+	Failed bool `json:"failed"`
+}
+
+// ParseCalibrationType returns the calibration type with the given name (see String)
+func ParseCalibrationType(name string) (CalibrationType, error) {
+	for t := CalibrationGyro; t <= CalibrationRadio; t++ {
+		if strings.EqualFold(name, t.String()) {
+			return t, nil
+		}
+	}
+	return 0, fmt.Errorf("unknown calibration type %q (gyro, mag, accel, level, baro or radio)", name)
+}
+
+// calProgressPattern matches PX4's "[cal] progress <N>" messages
+var calProgressPattern = regexp.MustCompile(`progress\s*<(\d+)>`)
+
+// calibrationParams returns the MAV_CMD_PREFLIGHT_CALIBRATION params 1-7 for a type
+// All-zero params cancel a running calibration on PX4
+func calibrationParams(calType CalibrationType) ([7]float32, error) {
+	var params [7]float32
+
+	switch calType {
+	case CalibrationGyro:
+		params[0] = 1 // param1: gyro
+	case CalibrationMagnetometer:
+		params[1] = 1 // param2: magnetometer
+	case CalibrationBarometer:
+		params[2] = 1 // param3: ground pressure
+	case CalibrationRadio:
+		params[3] = 1 // param4: radio (RC) trim
+	case CalibrationAccelerometer:
+		params[4] = 1 // param5: 1 = accelerometer
+	case CalibrationLevel:
+		params[4] = 2 // param5: 2 = board level
+	default:
+		return params, fmt.Errorf("unsupported calibration type: %d", int(calType))
+	}
+
+	return params, nil
+}
+
+// StartCalibration starts a sensor calibration on the vehicle
+// Progress is reported by the autopilot via STATUSTEXT; see ParseCalibrationStatus.
+func (c *Client) StartCalibration(calType CalibrationType) error {
+	params, err := calibrationParams(calType)
+	if err != nil {
+		return err
+	}
+
+	c.mu.RLock()
+	systemID := c.systemID
+	c.mu.RUnlock()
+
+	if !c.IsConnected() {
+		return fmt.Errorf("not connected to drone")
+	}
+
+	if c.IsArmed() {
+		return fmt.Errorf("cannot calibrate while armed")
+	}
+
+	c.logger.Printf("MAVLink: Starting %s calibration", calType)
+
+	// Progress comes as STATUSTEXT; the ACK may only follow at the end. Marked
+	// before sending so a quick final ACK isn't overtaken.
+	c.markCommandInProgress(common.MAV_CMD_PREFLIGHT_CALIBRATION)
+
+	err = c.writeMessage(&common.MessageCommandLong{
+		TargetSystem:    systemID,
+		TargetComponent: 1,
+		Command:         common.MAV_CMD_PREFLIGHT_CALIBRATION,
+		Param1:          params[0],
+		Param2:          params[1],
+		Param3:          params[2],
+		Param4:          params[3],
+		Param5:          params[4],
+		Param6:          params[5],
+		Param7:          params[6],
+	})
+	if err != nil {
+		c.mu.Lock()
+		delete(c.commandsInProgress, common.MAV_CMD_PREFLIGHT_CALIBRATION)
+		c.mu.Unlock()
+	}
+	return err
+}
+
+// CancelCalibration aborts a running calibration
+// COMMAND_CANCEL is sent first for autopilots implementing it, then the
+// all-zero PREFLIGHT_CALIBRATION that PX4 and ArduPilot treat as cancel.
+func (c *Client) CancelCalibration() error {
+	c.mu.RLock()
+	systemID := c.systemID
+	c.mu.RUnlock()
+
+	if !c.IsConnected() {
+		return fmt.Errorf("not connected to drone")
+	}
+
+	c.logger.Println("MAVLink: Cancelling calibration")
+
+	if err := c.CancelCommand(common.MAV_CMD_PREFLIGHT_CALIBRATION); err != nil {
+		c.logger.Printf("MAVLink: Warning - COMMAND_CANCEL not sent: %v", err)
+	}
+
+	return c.writeMessage(&common.MessageCommandLong{
+		TargetSystem:    systemID,
+		TargetComponent: 1,
+		Command:         common.MAV_CMD_PREFLIGHT_CALIBRATION,
+	})
+}
+
+// ParseCalibrationStatus interprets a STATUSTEXT emitted during calibration
+// Returns false for messages unrelated to calibration. PX4 prefixes its
+// calibration messages with "[cal]"; ArduPilot messages are matched by keyword.
+func ParseCalibrationStatus(text string) (CalibrationStatus, bool) {
+	lower := strings.ToLower(text)
+	isPX4 := strings.HasPrefix(lower, "[cal]")
+	if !isPX4 && !strings.Contains(lower, "calibration") && !strings.Contains(lower, "place vehicle") {
+		return CalibrationStatus{}, false
+	}
+
+	status := CalibrationStatus{
+		Text:     text,
+		Progress: -1,
+	}
+
+	if m := calProgressPattern.FindStringSubmatch(lower); m != nil {
+		if p, err := strconv.Atoi(m[1]); err == nil {
+			status.Progress = p
+		}
+	}
+
+	switch {
+	case strings.Contains(lower, "failed"), strings.Contains(lower, "cancelled"):
+		status.Failed = true
+	case strings.Contains(lower, "calibration done"), strings.Contains(lower, "calibration successful"):
+		status.Done = true
+		status.Progress = 100
+	}
+
+	return status, true
+}
@@ -0,0 +1,135 @@
+package mavlink
+
+import (
+	"testing"
+
+	"github.com/bluenviron/gomavlib/v3/pkg/dialects/common"
+)
+
+func TestCalibrationParams(t *testing.T) {
+	tests := []struct {
+		calType CalibrationType
+		param   int // 1-based MAV_CMD_PREFLIGHT_CALIBRATION param set
+		value   float32
+	}{
+		{CalibrationGyro, 1, 1},
+		{CalibrationMagnetometer, 2, 1},
+		{CalibrationBarometer, 3, 1},
+		{CalibrationRadio, 4, 1},
+		{CalibrationAccelerometer, 5, 1},
+		{CalibrationLevel, 5, 2},
+	}
+	for _, tt := range tests {
+		t.Run(tt.calType.String(), func(t *testing.T) {
+			params, err := calibrationParams(tt.calType)
+			if err != nil {
+				t.Fatal(err)
+			}
+			var want [7]float32
+			want[tt.param-1] = tt.value
+			if params != want {
+				t.Errorf("params = %v, want %v", params, want)
+			}
+		})
+	}
+
+	if _, err := calibrationParams(CalibrationType(99)); err == nil {
+		t.Error("unknown calibration type accepted")
+	}
+}
+
+func TestParseCalibrationType(t *testing.T) {
+	for calType := CalibrationGyro; calType <= CalibrationRadio; calType++ {
+		if got, err := ParseCalibrationType(calType.String()); err != nil || got != calType {
+			t.Errorf("ParseCalibrationType(%q) = %v, %v", calType.String(), got, err)
+		}
+	}
+	if got, err := ParseCalibrationType("MAG"); err != nil || got != CalibrationMagnetometer {
+		t.Errorf("ParseCalibrationType(MAG) = %v, %v", got, err)
+	}
+	if _, err := ParseCalibrationType("compass"); err == nil {
+		t.Error("unknown calibration type name accepted")
+	}
+}
+
+func TestStartCalibrationSendsParams(t *testing.T) {
+	c, vehicle := newLinkedTestClient(t)
+
+	if err := c.StartCalibration(CalibrationLevel); err != nil {
+		t.Fatal(err)
+	}
+	msg := receive[*common.MessageCommandLong](t, vehicle)
+	if msg.Command != common.MAV_CMD_PREFLIGHT_CALIBRATION {
+		t.Fatalf("command = %s", msg.Command)
+	}
+	got := [7]float32{msg.Param1, msg.Param2, msg.Param3, msg.Param4, msg.Param5, msg.Param6, msg.Param7}
+	if want := [7]float32{0, 0, 0, 0, 2, 0, 0}; got != want {
+		t.Errorf("params = %v, want %v", got, want)
+	}
+
+	// Armed vehicles aren't calibrated
+	c.handleMessage(&common.MessageHeartbeat{
+		Type:      common.MAV_TYPE_QUADROTOR,
+		Autopilot: common.MAV_AUTOPILOT_PX4,
+		BaseMode:  common.MAV_MODE_FLAG_SAFETY_ARMED,
+	}, 1, 1)
+	if err := c.StartCalibration(CalibrationGyro); err == nil {
+		t.Error("calibration started while armed")
+	}
+}
+
+func TestParseCalibrationStatus(t *testing.T) {
+	tests := []struct {
+		text     string
+		ok       bool
+		progress int
+		done     bool
+		failed   bool
+	}{
+		{"[cal] calibration started: 2 mag", true, -1, false, false},
+		{"[cal] progress <42>", true, 42, false, false},
+		{"[cal] calibration done: mag", true, 100, true, false},
+		{"[cal] calibration failed: timeout", true, -1, false, true},
+		{"Place vehicle level and press any key", true, -1, false, false},
+		{"Takeoff detected", false, 0, false, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.text, func(t *testing.T) {
+			status, ok := ParseCalibrationStatus(tt.text)
+			if ok != tt.ok {
+				t.Fatalf("recognized = %v, want %v", ok, tt.ok)
+			}
+			if !ok {
+				return
+			}
+			if status.Progress != tt.progress || status.Done != tt.done || status.Failed != tt.failed {
+				t.Errorf("status = %+v", status)
+			}
+		})
+	}
+}
+
+func TestCancelCalibration(t *testing.T) {
+	c, vehicle := newLinkedTestClient(t)
+
+	if err := c.StartCalibration(CalibrationMagnetometer); err != nil {
+		t.Fatal(err)
+	}
+	receive[*common.MessageCommandLong](t, vehicle)
+
+	if err := c.CancelCalibration(); err != nil {
+		t.Fatal(err)
+	}
+	if msg := receive[*common.MessageCommandCancel](t, vehicle); msg.Command != common.MAV_CMD_PREFLIGHT_CALIBRATION {
+		t.Errorf("COMMAND_CANCEL for %s", msg.Command)
+	}
+	// Then the all-zero calibration PX4 and ArduPilot cancel on
+	msg := receive[*common.MessageCommandLong](t, vehicle)
+	got := [7]float32{msg.Param1, msg.Param2, msg.Param3, msg.Param4, msg.Param5, msg.Param6, msg.Param7}
+	if msg.Command != common.MAV_CMD_PREFLIGHT_CALIBRATION || got != [7]float32{} {
+		t.Errorf("cancel sent %s with params %v", msg.Command, got)
+	}
+	if inProgress(c, common.MAV_CMD_PREFLIGHT_CALIBRATION) {
+		t.Error("calibration still in progress after cancelling")
+	}
+}
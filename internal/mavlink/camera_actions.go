@@ -0,0 +1,114 @@
+package mavlink
+
+import (
+	"fmt"
+
+	"github.com/bluenviron/gomavlib/v3/pkg/dialects/common"
+)
+
+// CameraActionType is what a waypoint's camera action does
+type CameraActionType string
+
+const (
+	CameraTrigger         CameraActionType = "trigger"          // Take one photo
+	CameraStartInterval   CameraActionType = "start_interval"   // Photos every Interval seconds
+	CameraStopInterval    CameraActionType = "stop_interval"    // End interval capture
+	CameraTriggerDistance CameraActionType = "trigger_distance" // Photos every Distance meters (0 stops)
+)
+
+// CameraAction is a camera command run once its waypoint is reached, for
+// survey missions
+// Each is uploaded as a command item right after the waypoint's nav item:
+//
+//	trigger:          MAV_CMD_DO_DIGICAM_CONTROL, X (param5) = 1 (shoot)
+//	start_interval:   MAV_CMD_IMAGE_START_CAPTURE, Param2 = interval (s), Param3 = count (0 until stopped)
+//	stop_interval:    MAV_CMD_IMAGE_STOP_CAPTURE
+//	trigger_distance: MAV_CMD_DO_SET_CAM_TRIGG_DIST, Param1 = distance (m)
+type CameraAction struct {
+	Action   CameraActionType `json:"action"`
+	Interval float64          `json:"interval,omitempty"` // start_interval
+	Count    int              `json:"count,omitempty"`    // start_interval
+	Distance float64          `json:"distance,omitempty"` // trigger_distance
+}
+
+// Validate checks the action is known and its settings apply to it
+func (a CameraAction) Validate() error {
+	switch a.Action {
+	case CameraTrigger, CameraStopInterval:
+		if a.Interval != 0 || a.Count != 0 || a.Distance != 0 {
+			return fmt.Errorf("camera action %s takes no interval, count or distance", a.Action)
+		}
+	case CameraStartInterval:
+		if a.Interval <= 0 {
+			return fmt.Errorf("camera action start_interval needs an interval above 0 s: %v", a.Interval)
+		}
+		if a.Count < 0 || a.Distance != 0 {
+			return fmt.Errorf("camera action start_interval takes a count of 0 or more and no distance")
+		}
+	case CameraTriggerDistance:
+		if a.Distance < 0 || a.Interval != 0 || a.Count != 0 {
+			return fmt.Errorf("camera action trigger_distance takes only a distance of 0 m or more")
+		}
+	default:
+		return fmt.Errorf("unknown camera action: %q (want trigger, start_interval, stop_interval or trigger_distance)", a.Action)
+	}
+	return nil
+}
+
+// MissionItem builds the command item for the action
+func (a CameraAction) MissionItem() MissionItem {
+	item := MissionItem{
+		Frame:        common.MAV_FRAME_MISSION,
+		Autocontinue: true,
+	}
+	switch a.Action {
+	case CameraTrigger:
+		item.Command = common.MAV_CMD_DO_DIGICAM_CONTROL
+		item.X = 1
+	case CameraStartInterval:
+		item.Command = common.MAV_CMD_IMAGE_START_CAPTURE
+		item.Param2 = float32(a.Interval)
+		item.Param3 = float32(a.Count)
+	case CameraStopInterval:
+		item.Command = common.MAV_CMD_IMAGE_STOP_CAPTURE
+	case CameraTriggerDistance:
+		item.Command = common.MAV_CMD_DO_SET_CAM_TRIGG_DIST
+		item.Param1 = float32(a.Distance)
+	}
+	return item
+}
+
+// CameraActionFromItem recognizes a camera command item, the inverse of
+// CameraAction.MissionItem
+// DO_DIGICAM_CONTROL items that don't shoot aren't camera actions.
+func CameraActionFromItem(item MissionItem) (CameraAction, bool) {
+	switch item.Command {
+	case common.MAV_CMD_DO_DIGICAM_CONTROL:
+		if item.X != 1 {
+			return CameraAction{}, false
+		}
+		return CameraAction{Action: CameraTrigger}, true
+	case common.MAV_CMD_IMAGE_START_CAPTURE:
+		return CameraAction{
+			Action:   CameraStartInterval,
+			Interval: float64(item.Param2),
+			Count:    int(item.Param3),
+		}, true
+	case common.MAV_CMD_IMAGE_STOP_CAPTURE:
+		return CameraAction{Action: CameraStopInterval}, true
+	case common.MAV_CMD_DO_SET_CAM_TRIGG_DIST:
+		return CameraAction{Action: CameraTriggerDistance, Distance: float64(item.Param1)}, true
+	default:
+		return CameraAction{}, false
+	}
+}
+
+// MissionItemCount returns how many items waypoints with these options upload
+// as: one per waypoint plus one per camera action
+func MissionItemCount(waypoints int, options []WaypointOptions) int {
+	count := waypoints
+	for _, opts := range options {
+		count += len(opts.Camera)
+	}
+	return count
+}
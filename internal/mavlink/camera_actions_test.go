@@ -0,0 +1,135 @@
+package mavlink
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/bluenviron/gomavlib/v3/pkg/dialects/common"
+
+	drone "github.com/flightpath-dev/flightpath-proto/gen/go/drone/v1"
+)
+
+func TestCameraActionItems(t *testing.T) {
+	tests := []struct {
+		action  CameraAction
+		command common.MAV_CMD
+	}{
+		{CameraAction{Action: CameraTrigger}, common.MAV_CMD_DO_DIGICAM_CONTROL},
+		{CameraAction{Action: CameraStartInterval, Interval: 2.5, Count: 40}, common.MAV_CMD_IMAGE_START_CAPTURE},
+		{CameraAction{Action: CameraStopInterval}, common.MAV_CMD_IMAGE_STOP_CAPTURE},
+		{CameraAction{Action: CameraTriggerDistance, Distance: 12.5}, common.MAV_CMD_DO_SET_CAM_TRIGG_DIST},
+		{CameraAction{Action: CameraTriggerDistance}, common.MAV_CMD_DO_SET_CAM_TRIGG_DIST}, // stops distance triggering
+	}
+	for _, tt := range tests {
+		if err := tt.action.Validate(); err != nil {
+			t.Errorf("%+v: %v", tt.action, err)
+		}
+		item := tt.action.MissionItem()
+		if item.Command != tt.command || item.Frame != common.MAV_FRAME_MISSION || !item.Autocontinue {
+			t.Errorf("%+v: item %v in %v", tt.action, item.Command, item.Frame)
+		}
+		if back, ok := CameraActionFromItem(item); !ok || back != tt.action {
+			t.Errorf("%+v read back as %+v, %v", tt.action, back, ok)
+		}
+	}
+
+	// A DO_DIGICAM_CONTROL that doesn't shoot isn't a camera action
+	if _, ok := CameraActionFromItem(MissionItem{Command: common.MAV_CMD_DO_DIGICAM_CONTROL}); ok {
+		t.Error("non-shooting DO_DIGICAM_CONTROL read as a trigger")
+	}
+	if _, ok := CameraActionFromItem(MissionItem{Command: common.MAV_CMD_NAV_WAYPOINT}); ok {
+		t.Error("waypoint read as a camera action")
+	}
+}
+
+func TestCameraActionValidate(t *testing.T) {
+	for _, action := range []CameraAction{
+		{Action: "zoom"},
+		{Action: CameraTrigger, Interval: 2},
+		{Action: CameraStartInterval},
+		{Action: CameraStartInterval, Interval: 2, Count: -1},
+		{Action: CameraTriggerDistance, Distance: -5},
+		{Action: CameraStopInterval, Distance: 5},
+	} {
+		if err := action.Validate(); err == nil {
+			t.Errorf("%+v accepted", action)
+		}
+	}
+}
+
+func TestUploadMissionCameraActions(t *testing.T) {
+	c, vehicle := newLinkedTestClient(t)
+	v := serveMissions(t, c, vehicle)
+
+	waypoints := []*drone.Waypoint{
+		{Position: &drone.Position{Latitude: 47, Longitude: 8, Altitude: 20}, Action: drone.Waypoint_ACTION_TAKEOFF},
+		{Position: &drone.Position{Latitude: 47.001, Longitude: 8, Altitude: 40}, Action: drone.Waypoint_ACTION_WAYPOINT},
+		{Position: &drone.Position{Latitude: 47.002, Longitude: 8, Altitude: 40}, Action: drone.Waypoint_ACTION_WAYPOINT},
+		{Position: &drone.Position{Latitude: 47.002, Longitude: 8.001, Altitude: 40}, Action: drone.Waypoint_ACTION_WAYPOINT},
+	}
+	options := DefaultWaypointOptions(len(waypoints))
+	options[1].Camera = []CameraAction{
+		{Action: CameraTriggerDistance, Distance: 10},
+		{Action: CameraStartInterval, Interval: 2},
+	}
+	options[3].Camera = []CameraAction{
+		{Action: CameraStopInterval},
+		{Action: CameraTriggerDistance},
+		{Action: CameraTrigger},
+	}
+	if n := MissionItemCount(len(waypoints), options); n != 9 {
+		t.Fatalf("MissionItemCount = %d, want 9", n)
+	}
+	if err := c.UploadMissionOptions(waypoints, options); err != nil {
+		t.Fatal(err)
+	}
+
+	// Each waypoint's camera items follow its nav item, numbered in sequence
+	wantCommands := []common.MAV_CMD{
+		common.MAV_CMD_NAV_TAKEOFF,
+		common.MAV_CMD_NAV_WAYPOINT,
+		common.MAV_CMD_DO_SET_CAM_TRIGG_DIST,
+		common.MAV_CMD_IMAGE_START_CAPTURE,
+		common.MAV_CMD_NAV_WAYPOINT,
+		common.MAV_CMD_NAV_WAYPOINT,
+		common.MAV_CMD_IMAGE_STOP_CAPTURE,
+		common.MAV_CMD_DO_SET_CAM_TRIGG_DIST,
+		common.MAV_CMD_DO_DIGICAM_CONTROL,
+	}
+	v.mu.Lock()
+	uploaded := v.items[common.MAV_MISSION_TYPE_MISSION]
+	v.mu.Unlock()
+	if len(uploaded) != len(wantCommands) {
+		t.Fatalf("uploaded %d items, want %d", len(uploaded), len(wantCommands))
+	}
+	for i, item := range uploaded {
+		if int(item.Seq) != i || item.Command != wantCommands[i] {
+			t.Errorf("item %d: seq %d, %v, want %v", i, item.Seq, item.Command, wantCommands[i])
+		}
+	}
+	if _, total, _ := c.GetMissionProgress(); total != 9 {
+		t.Errorf("total = %d items, want 9", total)
+	}
+
+	// Downloaded back, the camera items attach to the waypoint before them
+	items, err := c.DownloadMission()
+	if err != nil {
+		t.Fatal(err)
+	}
+	var camera [][]CameraAction
+	for _, item := range items {
+		if action, ok := CameraActionFromItem(item); ok {
+			camera[len(camera)-1] = append(camera[len(camera)-1], action)
+		} else {
+			camera = append(camera, nil)
+		}
+	}
+	if len(camera) != len(waypoints) {
+		t.Fatalf("downloaded %d waypoints, want %d", len(camera), len(waypoints))
+	}
+	for i, opts := range options {
+		if !reflect.DeepEqual(camera[i], opts.Camera) {
+			t.Errorf("waypoint %d camera = %+v, want %+v", i, camera[i], opts.Camera)
+		}
+	}
+}
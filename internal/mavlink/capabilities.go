@@ -0,0 +1,137 @@
+package mavlink
+
+import (
+	"github.com/bluenviron/gomavlib/v3/pkg/dialects/common"
+)
+
+// Capabilities is what the vehicle reports it supports, for enabling UI controls
+// Protocol flags come from AUTOPILOT_VERSION (all false until it arrives, see
+// Reported); VTOL from the vehicle type; gimbal and camera also from component
+// heartbeats.
+type Capabilities struct {
+	Reported bool   `json:"reported"`
+	Bitmask  uint64 `json:"bitmask"` // raw MAV_PROTOCOL_CAPABILITY bits
+
+	// Summary for UI controls
+	Missions          bool `json:"missions"`            // MISSION_INT or MISSION_FLOAT
+	Fences            bool `json:"fences"`              // MISSION_FENCE
+	RallyPoints       bool `json:"rally_points"`        // MISSION_RALLY
+	SetPositionTarget bool `json:"set_position_target"` // SET_POSITION_TARGET_GLOBAL_INT (GoToPosition)
+	Reposition        bool `json:"reposition"`          // COMMAND_INT, which DO_REPOSITION is sent as
+	Parameters        bool `json:"parameters"`          // PARAM_FLOAT or a parameter encoding
+	VTOL              bool `json:"vtol"`
+	Gimbal            bool `json:"gimbal"`
+	Camera            bool `json:"camera"`
+
+	// Flight modes the server lets SetFlightMode switch to (FlightMode names);
+	// set by the server from the drone's allowed_flight_modes, not the vehicle
+	FlightModes []string `json:"flight_modes,omitempty"`
+
+	// One field per MAV_PROTOCOL_CAPABILITY flag
+	MissionFloat               bool `json:"mission_float"`
+	ParamFloat                 bool `json:"param_float"`
+	MissionInt                 bool `json:"mission_int"`
+	CommandInt                 bool `json:"command_int"`
+	ParamEncodeBytewise        bool `json:"param_encode_bytewise"`
+	FTP                        bool `json:"ftp"`
+	SetAttitudeTarget          bool `json:"set_attitude_target"`
+	SetPositionTargetLocalNED  bool `json:"set_position_target_local_ned"`
+	SetPositionTargetGlobalInt bool `json:"set_position_target_global_int"`
+	Terrain                    bool `json:"terrain"`
+	FlightTermination          bool `json:"flight_termination"`
+	CompassCalibration         bool `json:"compass_calibration"`
+	MAVLink2                   bool `json:"mavlink2"`
+	MissionFence               bool `json:"mission_fence"`
+	MissionRally               bool `json:"mission_rally"`
+	ParamEncodeCCast           bool `json:"param_encode_c_cast"`
+	GimbalManager              bool `json:"component_implements_gimbal_manager"`
+	AcceptsGCSControl          bool `json:"component_accepts_gcs_control"`
+	Gripper                    bool `json:"gripper"`
+}
+
+// CapabilitiesFrom decodes an AUTOPILOT_VERSION capability bitmask
+// vehicleType is the vehicle's HEARTBEAT type and components the types of the
+// other components seen on the vehicle's system (gimbals, cameras). Reported
+// is left for the caller to set.
+func CapabilitiesFrom(bitmask uint64, vehicleType common.MAV_TYPE, components []common.MAV_TYPE) Capabilities {
+	has := func(flag common.MAV_PROTOCOL_CAPABILITY) bool {
+		return bitmask&uint64(flag) != 0
+	}
+
+	c := Capabilities{
+		Bitmask: bitmask,
+
+		MissionFloat:               has(common.MAV_PROTOCOL_CAPABILITY_MISSION_FLOAT),
+		ParamFloat:                 has(common.MAV_PROTOCOL_CAPABILITY_PARAM_FLOAT),
+		MissionInt:                 has(common.MAV_PROTOCOL_CAPABILITY_MISSION_INT),
+		CommandInt:                 has(common.MAV_PROTOCOL_CAPABILITY_COMMAND_INT),
+		ParamEncodeBytewise:        has(common.MAV_PROTOCOL_CAPABILITY_PARAM_ENCODE_BYTEWISE),
+		FTP:                        has(common.MAV_PROTOCOL_CAPABILITY_FTP),
+		SetAttitudeTarget:          has(common.MAV_PROTOCOL_CAPABILITY_SET_ATTITUDE_TARGET),
+		SetPositionTargetLocalNED:  has(common.MAV_PROTOCOL_CAPABILITY_SET_POSITION_TARGET_LOCAL_NED),
+		SetPositionTargetGlobalInt: has(common.MAV_PROTOCOL_CAPABILITY_SET_POSITION_TARGET_GLOBAL_INT),
+		Terrain:                    has(common.MAV_PROTOCOL_CAPABILITY_TERRAIN),
+		FlightTermination:          has(common.MAV_PROTOCOL_CAPABILITY_FLIGHT_TERMINATION),
+		CompassCalibration:         has(common.MAV_PROTOCOL_CAPABILITY_COMPASS_CALIBRATION),
+		MAVLink2:                   has(common.MAV_PROTOCOL_CAPABILITY_MAVLINK2),
+		MissionFence:               has(common.MAV_PROTOCOL_CAPABILITY_MISSION_FENCE),
+		MissionRally:               has(common.MAV_PROTOCOL_CAPABILITY_MISSION_RALLY),
+		ParamEncodeCCast:           has(common.MAV_PROTOCOL_CAPABILITY_PARAM_ENCODE_C_CAST),
+		GimbalManager:              has(common.MAV_PROTOCOL_CAPABILITY_COMPONENT_IMPLEMENTS_GIMBAL_MANAGER),
+		AcceptsGCSControl:          has(common.MAV_PROTOCOL_CAPABILITY_COMPONENT_ACCEPTS_GCS_CONTROL),
+		Gripper:                    has(common.MAV_PROTOCOL_CAPABILITY_GRIPPER),
+	}
+
+	c.Missions = c.MissionInt || c.MissionFloat
+	c.Fences = c.MissionFence
+	c.RallyPoints = c.MissionRally
+	c.SetPositionTarget = c.SetPositionTargetGlobalInt
+	c.Reposition = c.CommandInt
+	c.Parameters = c.ParamFloat || c.ParamEncodeBytewise || c.ParamEncodeCCast
+	c.VTOL = isVTOL(vehicleType)
+	c.Gimbal = c.GimbalManager
+
+	for _, t := range components {
+		switch t {
+		case common.MAV_TYPE_GIMBAL:
+			c.Gimbal = true
+		case common.MAV_TYPE_CAMERA:
+			c.Camera = true
+		}
+	}
+	return c
+}
+
+// isVTOL reports whether a vehicle type takes off vertically and cruises on wings
+func isVTOL(t common.MAV_TYPE) bool {
+	switch t {
+	case common.MAV_TYPE_VTOL_TAILSITTER_DUOROTOR, common.MAV_TYPE_VTOL_TAILSITTER_QUADROTOR,
+		common.MAV_TYPE_VTOL_TILTROTOR, common.MAV_TYPE_VTOL_FIXEDROTOR,
+		common.MAV_TYPE_VTOL_TAILSITTER, common.MAV_TYPE_VTOL_TILTWING,
+		common.MAV_TYPE_VTOL_RESERVED5, common.MAV_TYPE_VTOL_GYRODYNE:
+		return true
+	}
+	return false
+}
+
+// GetCapabilities returns what the vehicle reports it supports
+// Reported is false until AUTOPILOT_VERSION arrives (requested after connecting).
+func (c *Client) GetCapabilities() Capabilities {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.capabilitiesLocked()
+}
+
+// capabilitiesLocked builds Capabilities; caller must hold c.mu
+func (c *Client) capabilitiesLocked() Capabilities {
+	var components []common.MAV_TYPE
+	if sys, ok := c.visibleSystems[c.systemID]; ok {
+		for _, t := range sys.components {
+			components = append(components, t)
+		}
+	}
+
+	caps := CapabilitiesFrom(c.stats.capabilities, c.stats.vehicleType, components)
+	caps.Reported = c.stats.capabilitiesReported
+	return caps
+}
@@ -0,0 +1,82 @@
+package mavlink
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/bluenviron/gomavlib/v3/pkg/dialects/common"
+)
+
+func TestCapabilitiesFrom(t *testing.T) {
+	// As reported by PX4 SITL: no fence or rally upload, C-cast parameters
+	px4 := uint64(common.MAV_PROTOCOL_CAPABILITY_MISSION_FLOAT |
+		common.MAV_PROTOCOL_CAPABILITY_PARAM_FLOAT |
+		common.MAV_PROTOCOL_CAPABILITY_MISSION_INT |
+		common.MAV_PROTOCOL_CAPABILITY_COMMAND_INT |
+		common.MAV_PROTOCOL_CAPABILITY_PARAM_ENCODE_C_CAST |
+		common.MAV_PROTOCOL_CAPABILITY_FTP |
+		common.MAV_PROTOCOL_CAPABILITY_SET_ATTITUDE_TARGET |
+		common.MAV_PROTOCOL_CAPABILITY_SET_POSITION_TARGET_LOCAL_NED |
+		common.MAV_PROTOCOL_CAPABILITY_SET_POSITION_TARGET_GLOBAL_INT |
+		common.MAV_PROTOCOL_CAPABILITY_MAVLINK2)
+
+	got := CapabilitiesFrom(px4, common.MAV_TYPE_QUADROTOR, []common.MAV_TYPE{common.MAV_TYPE_QUADROTOR})
+	want := Capabilities{
+		Bitmask:           px4,
+		Missions:          true,
+		SetPositionTarget: true,
+		Reposition:        true,
+		Parameters:        true,
+
+		MissionFloat:               true,
+		ParamFloat:                 true,
+		MissionInt:                 true,
+		CommandInt:                 true,
+		FTP:                        true,
+		SetAttitudeTarget:          true,
+		SetPositionTargetLocalNED:  true,
+		SetPositionTargetGlobalInt: true,
+		MAVLink2:                   true,
+		ParamEncodeCCast:           true,
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("PX4 capabilities:\n got %+v\nwant %+v", got, want)
+	}
+
+	// Fences, rally points and a gimbal manager, on a VTOL with a camera
+	mask := uint64(common.MAV_PROTOCOL_CAPABILITY_MISSION_FENCE |
+		common.MAV_PROTOCOL_CAPABILITY_MISSION_RALLY |
+		common.MAV_PROTOCOL_CAPABILITY_COMPONENT_IMPLEMENTS_GIMBAL_MANAGER)
+	got = CapabilitiesFrom(mask, common.MAV_TYPE_VTOL_TILTROTOR, []common.MAV_TYPE{common.MAV_TYPE_CAMERA})
+	if !got.Fences || !got.RallyPoints || !got.Gimbal || !got.GimbalManager || !got.VTOL || !got.Camera {
+		t.Errorf("capabilities = %+v", got)
+	}
+	if got.Missions || got.Parameters || got.Reposition || got.SetPositionTarget {
+		t.Errorf("unreported capabilities set: %+v", got)
+	}
+
+	// A gimbal component without the manager flag still counts
+	if got := CapabilitiesFrom(0, common.MAV_TYPE_FIXED_WING, []common.MAV_TYPE{common.MAV_TYPE_GIMBAL}); !got.Gimbal || got.VTOL {
+		t.Errorf("gimbal component: %+v", got)
+	}
+}
+
+func TestGetCapabilities(t *testing.T) {
+	c := newConnectedTestClient()
+	if caps := c.GetCapabilities(); caps.Reported || caps.Missions {
+		t.Fatalf("before AUTOPILOT_VERSION: %+v", caps)
+	}
+
+	c.handleMessage(&common.MessageHeartbeat{Type: common.MAV_TYPE_CAMERA, Autopilot: common.MAV_AUTOPILOT_INVALID}, 1, 100)
+	c.handleMessage(&common.MessageAutopilotVersion{
+		Capabilities: common.MAV_PROTOCOL_CAPABILITY_MISSION_INT | common.MAV_PROTOCOL_CAPABILITY_MISSION_FENCE,
+	}, 1, 1)
+
+	caps := c.GetCapabilities()
+	if !caps.Reported || !caps.Missions || !caps.Fences || caps.RallyPoints || !caps.Camera {
+		t.Errorf("capabilities = %+v", caps)
+	}
+	if info := c.GetConnectionInfo(); !reflect.DeepEqual(info.Capabilities, caps) {
+		t.Errorf("connection info capabilities = %+v", info.Capabilities)
+	}
+}
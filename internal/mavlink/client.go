@@ -1,9 +1,14 @@
 package mavlink
 
 import (
+	"errors"
 	"fmt"
 	"log"
+	"math"
+	"slices"
+	"sort"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/bluenviron/gomavlib/v3"
@@ -60,6 +65,9 @@ const (
 	POSITION_TARGET_TYPEMASK_YAW_RATE_IGNORE = 0b0000100000000000
 )
 
+// ErrPassiveMode is returned by commands on a client configured as a passive observer
+var ErrPassiveMode = errors.New("client is in passive (listen-only) mode")
+
 // TelemetryData holds current telemetry state
 type TelemetryData struct {
 	// Position (from GLOBAL_POSITION_INT)
@@ -82,40 +90,110 @@ type TelemetryData struct {
 	GroundSpeed   float64 // m/s
 	VerticalSpeed float64 // m/s
 
-	// Battery (from SYS_STATUS)
+	// Battery (from SYS_STATUS, or the primary BATTERY_STATUS battery where
+	// it reports them)
 	BatteryVoltage   float64 // volts
 	BatteryRemaining int32   // percent
 	BatteryCurrent   float64 // amps
 
+	// Every battery instance from BATTERY_STATUS, by ID (empty until one
+	// arrives); replaced, never modified, on update
+	Batteries []BatteryInfo
+
 	// GPS (from GPS_RAW_INT)
 	GPSAccuracy    float64 // meters
 	SatelliteCount int32
+	GPSFixType     common.GPS_FIX_TYPE
+	GPSHDOP        float64 // 0 when the receiver doesn't report it
 
 	// System health (from SYS_STATUS)
 	SensorsHealthy bool
 
+	// Attitude/position estimator (EKF) health: the SYS_STATUS AHRS bit.
+	// True until the vehicle reports it enabled and unhealthy.
+	EstimatorHealthy bool
+
 	// Flight mode (from HEARTBEAT)
-	CustomMode uint32
-	BaseMode   uint8
+	CustomMode   uint32
+	BaseMode     uint8
+	SystemStatus uint8 // MAV_STATE
 
 	// Timestamps
 	LastUpdate time.Time
+
+	// Per-source freshness (zero until the message is first received)
+	PositionUpdated  time.Time // GLOBAL_POSITION_INT
+	AttitudeUpdated  time.Time // ATTITUDE
+	VfrHudUpdated    time.Time // VFR_HUD
+	SysStatusUpdated time.Time // SYS_STATUS
+	GPSUpdated       time.Time // GPS_RAW_INT
+
+	BatteryStatusUpdated time.Time // BATTERY_STATUS (any instance)
+}
+
+// Armed reports the armed flag of the last HEARTBEAT, like Client.IsArmed
+// but without taking the client lock
+func (t TelemetryData) Armed() bool {
+	return t.BaseMode&uint8(common.MAV_MODE_FLAG_SAFETY_ARMED) != 0
+}
+
+// PositionValid reports whether the position fields hold a real position
+// It needs a GLOBAL_POSITION_INT, at least a 2D fix when the vehicle reports
+// GPS_RAW_INT (vehicles without GPS may still estimate a global position), and
+// anything but 0, 0, which autopilots send before they have a position.
+func (t TelemetryData) PositionValid() bool {
+	if t.PositionUpdated.IsZero() || (t.Latitude == 0 && t.Longitude == 0) {
+		return false
+	}
+	return t.GPSUpdated.IsZero() || t.GPSFixType >= common.GPS_FIX_TYPE_2D_FIX
 }
 
 // MissionState holds mission upload/download state
 type MissionState struct {
 	Uploading        bool
 	Downloading      bool
-	Waypoints        []*drone.Waypoint
+	Waypoints        []*drone.Waypoint // last mission uploaded via UploadMission
 	CurrentIndex     int
 	TotalCount       int
 	UploadComplete   chan error
 	DownloadComplete chan error
 
+	// Waypoints were read back from the vehicle and matched what was uploaded
+	WaypointsConfirmed bool
+
+	// Altitude frame and loiter settings of each entry in Waypoints
+	WaypointOptions []WaypointOptions
+
+	// Items of the last mission uploaded, waypoints or mixed (for GetMissionStats)
+	MissionItems []MissionItem
+
+	// Active transfer (any MAV_MISSION_TYPE)
+	TransferType common.MAV_MISSION_TYPE
+	Items        []MissionItem
+
+	// Receives the MISSION_COUNT for ReadItemCount (nil when not reading)
+	CountReply chan uint16
+
 	// Mission progress
 	CurrentWaypoint int32
 	TotalWaypoints  int32
 	MissionActive   bool
+
+	// AbortMission stopped the mission; MISSION_CURRENT doesn't mark it active
+	// again until it is started or the vehicle re-enters AUTO.MISSION
+	Aborted bool
+}
+
+// VisibleSystem describes a MAVLink system seen sending heartbeats on the link
+type VisibleSystem struct {
+	SystemID      uint8                `json:"system_id"`
+	ComponentID   uint8                `json:"component_id"`
+	Type          common.MAV_TYPE      `json:"type"`
+	Autopilot     common.MAV_AUTOPILOT `json:"autopilot"`
+	LastHeartbeat time.Time            `json:"last_heartbeat"`
+
+	// Heartbeat types of the system's components, by component ID
+	components map[uint8]common.MAV_TYPE
 }
 
 // Client represents a MAVLink connection to a drone
@@ -132,19 +210,177 @@ type Client struct {
 	// Last heartbeat time
 	lastHeartbeat time.Time
 
+	// System this client is bound to (0 = latch onto the first vehicle seen)
+	targetSystemID uint8
+
+	// Autopilot component whose heartbeats describe the vehicle (0 until the
+	// first autopilot heartbeat)
+	componentID uint8
+
+	// Every system seen on the link, keyed by system ID
+	visibleSystems map[uint8]*VisibleSystem
+
 	// Connection parameters
 	port     string
 	baudRate int
 
-	// Telemetry data
+	// Listen-only: never write to the link
+	passive bool
+
+	// Leave SYSTEM_TIME out of the ground station messages
+	disableSystemTime bool
+
+	// Our HEARTBEAT's system status, and the identity STATUSTEXT ("" sends none)
+	gcsSystemStatus     common.MAV_STATE
+	gcsIdentity         string
+	gcsIdentityInterval time.Duration
+
+	// Request telemetry again after arm and mode changes (see
+	// scheduleStreamRerequest); the timer coalesces quick successions
+	rerequestStreams bool
+	streamRerequest  *time.Timer
+
+	// Telemetry data, updated by the message handlers under mu
 	telemetry TelemetryData
 
+	// Immutable copy of telemetry swapped in after each update, so
+	// GetTelemetry is a lock-free load instead of contending with the handlers
+	telemetrySnapshot atomic.Pointer[TelemetryData]
+
+	// Reached waypoints and leg timing, reset on upload and start
+	missionProgress MissionProgressTracker
+
 	// Mission state
 	missionState MissionState
 
+	// Serializes mission transfers of all types
+	transferMu sync.Mutex
+
 	// Ground station heartbeat
 	stopHeartbeat chan struct{}
 	heartbeatDone chan struct{}
+
+	// Subscribers to STATUSTEXT, vehicle events, the raw message feed and home changes
+	statusTexts *broadcaster[StatusText]
+	events      *broadcaster[Event]
+	rawMessages *broadcaster[RawMessage]
+	homeUpdates *broadcaster[HomePosition]
+
+	// Id of the last multi-chunk STATUSTEXT we sent
+	statusTextID uint16
+
+	// 5-bit sequence ID of the next GPS_RTCM_DATA message
+	rtcmSequence uint8
+
+	// Re-sends of a TEMPORARILY_REJECTED command (see sendAcknowledged)
+	commandRetries int
+
+	// Extra attempts at a write that failed transiently (see writeWithRetry)
+	writeRetries int
+
+	// Applied to uploaded waypoints that don't set their own
+	waypointAcceptanceRadius float64
+	waitAtWaypoints          bool
+
+	// COMMAND_ACK wait per command, and for commands not listed
+	commandAckTimeouts map[common.MAV_CMD]time.Duration
+	commandAckTimeout  time.Duration
+
+	// Go-to target being re-sent (nil when none or gotoSetpointRate is 0)
+	gotoStream           *gotoStream
+	gotoSetpointRate     float64
+	gotoAcceptanceRadius float64
+	gotoHoldOnStop       bool
+
+	// Latest NAMED_VALUE_FLOAT/INT by name, and their subscribers
+	namedValues       map[string]NamedValue
+	namedValueUpdates *broadcaster[NamedValue]
+
+	// Battery drain from SYS_STATUS or BATTERY_STATUS, for RTL estimates
+	battery DischargeTracker
+
+	// Gimbal manager discovery and status (zero without a gimbal manager)
+	gimbal gimbalState
+
+	// Latest raw IMU sample (zero Updated until received) and its subscribers
+	imu        IMUSample
+	imuUpdates *broadcaster[IMUSample]
+
+	// Last HOME_POSITION (zero Updated until received)
+	home HomePosition
+
+	// Active failsafes keyed by type
+	failsafes map[FailsafeType]*FailsafeEvent
+
+	// Stale-telemetry watchdog
+	telemetryStaleTimeout time.Duration
+	telemetryStale        bool
+	stopWatchdog          chan struct{}
+	watchdogDone          chan struct{}
+
+	// Link statistics for GetConnectionInfo
+	stats linkStats
+
+	// TIMESYNC round trip and clock offset
+	timesync timesyncState
+
+	// Answers the vehicle's TERRAIN_REQUESTs (nil = requests are only counted)
+	terrainSource TerrainSource
+	terrain       terrainState
+
+	// Flight path since arming, saved to trackSink (if any) on Close
+	track     trackRecorder
+	trackSink TrackSink
+
+	// Stamp position and attitude with the vehicle's sample time (see vehicleTime)
+	correctTimestamps bool
+
+	// Largest mission upload accepted (0 = no limit)
+	maxMissionItems int
+
+	// Box position targets and mission items must stay inside (nil = anywhere)
+	allowedArea     *AllowedArea
+	pushAllowedArea bool
+
+	// Ceiling for commanded and mission altitudes (nil = none)
+	maxAltitude *AltitudeLimit
+
+	// Message IDs handled by the listener (nil = all)
+	inboundAllowed map[uint32]bool
+
+	// Senders waiting for a COMMAND_ACK, keyed by command
+	pendingAcks map[common.MAV_CMD]chan *common.MessageCommandAck
+
+	// Long-running commands the vehicle is still executing (acknowledged
+	// IN_PROGRESS, or started without waiting), for CancelCommand
+	commandsInProgress map[common.MAV_CMD]bool
+
+	// Setters waiting for a PARAM_VALUE echo, keyed by parameter name
+	pendingParams map[string]chan *common.MessageParamValue
+
+	// Parameters received from the vehicle (see GetParameters)
+	params           paramCache
+	paramCacheMaxAge time.Duration
+	paramListMu      sync.Mutex // one PARAM_REQUEST_LIST download at a time
+
+	// How the mission was last paused, and whether the vehicle refused
+	// DO_PAUSE_CONTINUE (see PauseMission)
+	pausedWith               PauseMethod
+	pauseContinueUnsupported bool
+
+	// Last breach action set, restored when PX4's geofence is re-enabled
+	geofenceAction GeofenceAction
+
+	// Per-message rates in Hz applied after connecting (nil = request all streams)
+	messageRates map[string]float64
+
+	// Shutdown coordination
+	listenDone chan struct{}
+	closeOnce  sync.Once
+
+	// Background close started by CloseTimeout, closed when it finishes
+	closeStart sync.Once
+	closeDone  chan struct{}
 }
 
 // Config holds MAVLink client configuration
@@ -152,6 +388,128 @@ type Config struct {
 	Port     string
 	BaudRate int
 	Logger   *log.Logger
+
+	// Serial line settings (zero value: 8N1, no flow control)
+	Serial SerialConfig
+
+	// TargetSystemID binds the client to one vehicle on a shared link.
+	// Messages from other system IDs are ignored. 0 binds to the first
+	// vehicle that sends a heartbeat.
+	TargetSystemID uint8
+
+	// PassiveMode makes the client listen-only: no GCS HEARTBEAT/SYSTEM_TIME,
+	// no stream requests and no commands. Use it for a monitoring instance
+	// that must not influence the vehicle (e.g. its GCS-loss timer).
+	PassiveMode bool
+
+	// DisableSystemTime stops the once-a-second SYSTEM_TIME sent with the GCS
+	// heartbeat, for vehicles that take their time from elsewhere (e.g. a
+	// companion computer's GPS) and would otherwise get two time sources
+	DisableSystemTime bool
+
+	// GCSSystemStatus is the MAV_STATE our HEARTBEAT reports (e.g. "STANDBY");
+	// "" reports ACTIVE
+	GCSSystemStatus string
+
+	// GCSIdentity, when set, is sent as an INFO STATUSTEXT (at most 50 bytes)
+	// after connecting and every GCSIdentityInterval (0 uses
+	// DefaultGCSIdentityInterval), so the vehicle's log records which ground
+	// station commanded it
+	GCSIdentity         string
+	GCSIdentityInterval time.Duration
+
+	// RerequestStreams re-sends the telemetry requests (SET_MESSAGE_INTERVAL
+	// or REQUEST_DATA_STREAM) shortly after the vehicle arms, disarms or
+	// changes flight mode, as PX4 may reset stream subscriptions then and
+	// telemetry rates drop. Ignored in passive mode.
+	RerequestStreams bool
+
+	// MessageRates sets per-message rates in Hz with SET_MESSAGE_INTERVAL
+	// after connecting. Empty requests all data streams at 10 Hz instead.
+	MessageRates map[string]float64
+
+	// InboundMessages limits which message types are decoded and handled
+	// (MAVLink names, e.g. "ATTITUDE"). Protocol essentials such as HEARTBEAT,
+	// COMMAND_ACK and the mission protocol are always handled. Empty handles
+	// every message.
+	InboundMessages []string
+
+	// TelemetryStaleTimeout is how old position data may get while armed
+	// before an EventTelemetryStale alarm. 0 uses DefaultTelemetryStaleTimeout.
+	TelemetryStaleTimeout time.Duration
+
+	// MaxMissionItems rejects mission uploads with more items before the
+	// transfer starts, for autopilots with limited mission storage. 0 = no limit.
+	MaxMissionItems int
+
+	// CorrectTimestamps stamps GLOBAL_POSITION_INT and ATTITUDE with the time
+	// the vehicle sampled them, using the TIMESYNC clock offset, instead of the
+	// time they arrived.
+	CorrectTimestamps bool
+
+	// CommandRetries re-sends an acknowledged command this many times while the
+	// vehicle answers TEMPORARILY_REJECTED, incrementing COMMAND_LONG's
+	// confirmation field each time. 0 reports the first rejection.
+	CommandRetries int
+
+	// WriteRetries retries a write that failed transiently (e.g. a full
+	// buffer) this many times with a short backoff before failing it
+	WriteRetries int
+
+	// CommandAckTimeout is how long to wait for a COMMAND_ACK. 0 uses
+	// DefaultCommandAckTimeout.
+	CommandAckTimeout time.Duration
+
+	// CommandAckTimeouts overrides CommandAckTimeout for individual commands,
+	// keyed by MAV_CMD name (e.g. "PREFLIGHT_CALIBRATION"), so slow commands
+	// aren't declared failed early and fast ones fail quickly
+	CommandAckTimeouts map[string]time.Duration
+
+	// WaypointAcceptanceRadius is sent for WAYPOINT waypoints whose acceptance
+	// radius is 0. 0 leaves it to the vehicle's default.
+	WaypointAcceptanceRadius float64
+
+	// WaitAtWaypoints uploads waypoints without autocontinue, so the vehicle
+	// waits at each for the operator, unless the waypoint's options say otherwise
+	WaitAtWaypoints bool
+
+	// ParamCacheMaxAge is how long GetParameters serves a cached parameter
+	// before reading it again. 0 uses DefaultParamCacheMaxAge.
+	ParamCacheMaxAge time.Duration
+
+	// GoToSetpointRate re-sends each GoToPosition target at this rate (Hz) until
+	// the vehicle is within GoToAcceptanceRadius meters, a new target or
+	// CancelGoTo replaces it, or the vehicle leaves OFFBOARD. 0 sends it once.
+	GoToSetpointRate float64
+
+	// GoToAcceptanceRadius is how close counts as arrived for streamed go-to
+	// targets. 0 uses DefaultGoToAcceptanceRadius.
+	GoToAcceptanceRadius float64
+
+	// GoToHoldOnStop switches a vehicle still in OFFBOARD to hold when its
+	// streamed go-to target is reached or cancelled, rather than leaving it to
+	// fail safe once setpoints stop arriving
+	GoToHoldOnStop bool
+
+	// AllowedArea rejects GoToPosition and Reposition targets and mission
+	// navigation items outside it with ErrOutsideAllowedArea. nil allows any.
+	AllowedArea *AllowedArea
+
+	// PushAllowedArea also sends the area to the vehicle after connecting
+	// (SAFETY_SET_ALLOWED_AREA)
+	PushAllowedArea bool
+
+	// MaxAltitude clamps or rejects takeoff, go-to and reposition altitudes
+	// above it and rejects missions climbing above it. nil allows any.
+	MaxAltitude *AltitudeLimit
+
+	// Terrain answers the vehicle's TERRAIN_REQUESTs with TERRAIN_DATA, for
+	// terrain following. nil leaves them unanswered.
+	Terrain TerrainSource
+
+	// TrackSink receives the recorded flight path when the client closes.
+	// nil keeps it in memory only (see Client.Track).
+	TrackSink TrackSink
 }
 
 // NewClient creates a new MAVLink client
@@ -159,17 +517,75 @@ func NewClient(cfg Config) (*Client, error) {
 	if cfg.Logger == nil {
 		cfg.Logger = log.Default()
 	}
+	if err := cfg.Serial.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid serial settings: %w", err)
+	}
+	if err := ValidateMessageRates(cfg.MessageRates); err != nil {
+		return nil, fmt.Errorf("invalid message rates: %w", err)
+	}
+	if cfg.TelemetryStaleTimeout <= 0 {
+		cfg.TelemetryStaleTimeout = DefaultTelemetryStaleTimeout
+	}
+	if cfg.CommandRetries < 0 || cfg.CommandRetries > 255 {
+		return nil, fmt.Errorf("invalid command retries: %d", cfg.CommandRetries)
+	}
+	if cfg.WriteRetries < 0 {
+		return nil, fmt.Errorf("invalid write retries: %d", cfg.WriteRetries)
+	}
+	if cfg.WaypointAcceptanceRadius < 0 {
+		return nil, fmt.Errorf("invalid waypoint acceptance radius: %v", cfg.WaypointAcceptanceRadius)
+	}
+	if cfg.ParamCacheMaxAge <= 0 {
+		cfg.ParamCacheMaxAge = DefaultParamCacheMaxAge
+	}
+	if cfg.CommandAckTimeout <= 0 {
+		cfg.CommandAckTimeout = DefaultCommandAckTimeout
+	}
+	commandAckTimeouts, err := parseCommandAckTimeouts(cfg.CommandAckTimeouts)
+	if err != nil {
+		return nil, fmt.Errorf("invalid command ack timeouts: %w", err)
+	}
+	gcsSystemStatus, err := parseGCSSystemStatus(cfg.GCSSystemStatus)
+	if err != nil {
+		return nil, fmt.Errorf("invalid GCS system status: %w", err)
+	}
+	if len(cfg.GCSIdentity) > statusTextChunkLen {
+		return nil, fmt.Errorf("GCS identity must be at most %d bytes: %d", statusTextChunkLen, len(cfg.GCSIdentity))
+	}
+	if cfg.GCSIdentityInterval <= 0 {
+		cfg.GCSIdentityInterval = DefaultGCSIdentityInterval
+	}
+	if cfg.GoToSetpointRate < 0 {
+		return nil, fmt.Errorf("invalid go-to setpoint rate: %v", cfg.GoToSetpointRate)
+	}
+	if cfg.GoToAcceptanceRadius <= 0 {
+		cfg.GoToAcceptanceRadius = DefaultGoToAcceptanceRadius
+	}
+	if cfg.AllowedArea != nil {
+		if err := cfg.AllowedArea.Validate(); err != nil {
+			return nil, fmt.Errorf("invalid allowed area: %w", err)
+		}
+	}
+	if cfg.MaxAltitude != nil {
+		if err := cfg.MaxAltitude.Validate(); err != nil {
+			return nil, fmt.Errorf("invalid maximum altitude: %w", err)
+		}
+	}
+
+	decodeDialect, inboundAllowed, err := inboundFilter(cfg.InboundMessages)
+	if err != nil {
+		return nil, fmt.Errorf("invalid inbound message filter: %w", err)
+	}
 
 	node, err := gomavlib.NewNode(gomavlib.NodeConf{
 		Endpoints: []gomavlib.EndpointConf{
-			gomavlib.EndpointSerial{
-				Device: cfg.Port,
-				Baud:   cfg.BaudRate,
-			},
+			serialEndpoint(cfg.Port, cfg.BaudRate, cfg.Serial),
 		},
-		Dialect:     common.Dialect,
+		Dialect:     decodeDialect,
 		OutVersion:  gomavlib.V2,
 		OutSystemID: 255, // GCS system ID
+		// gomavlib's own heartbeat would still reach the vehicle in passive mode
+		HeartbeatDisable: cfg.PassiveMode,
 	})
 	if err != nil {
 		return nil, fmt.Errorf("failed to create MAVLink node: %w", err)
@@ -181,32 +597,124 @@ func NewClient(cfg Config) (*Client, error) {
 		connected: false,
 		port:      cfg.Port,
 		baudRate:  cfg.BaudRate,
+		passive:   cfg.PassiveMode,
+
+		disableSystemTime: cfg.DisableSystemTime,
+		rerequestStreams:  cfg.RerequestStreams && !cfg.PassiveMode,
+
+		gcsSystemStatus:     gcsSystemStatus,
+		gcsIdentity:         cfg.GCSIdentity,
+		gcsIdentityInterval: cfg.GCSIdentityInterval,
+
+		targetSystemID:    cfg.TargetSystemID,
+		visibleSystems:    make(map[uint8]*VisibleSystem),
+		statusTexts:       newBroadcaster[StatusText](),
+		events:            newBroadcaster[Event](),
+		rawMessages:       newBroadcaster[RawMessage](),
+		homeUpdates:       newBroadcaster[HomePosition](),
+		namedValues:       make(map[string]NamedValue),
+		namedValueUpdates: newBroadcaster[NamedValue](),
+		failsafes:         make(map[FailsafeType]*FailsafeEvent),
+		imuUpdates:        newBroadcaster[IMUSample](),
+
+		stats:                 linkStats{lastSequence: make(map[uint8]uint8)},
+		pendingAcks:           make(map[common.MAV_CMD]chan *common.MessageCommandAck),
+		commandsInProgress:    make(map[common.MAV_CMD]bool),
+		pendingParams:         make(map[string]chan *common.MessageParamValue),
+		params:                paramCache{entries: make(map[string]Parameter)},
+		paramCacheMaxAge:      cfg.ParamCacheMaxAge,
+		inboundAllowed:        inboundAllowed,
+		messageRates:          cfg.MessageRates,
+		telemetryStaleTimeout: cfg.TelemetryStaleTimeout,
+		correctTimestamps:     cfg.CorrectTimestamps,
+		maxMissionItems:       cfg.MaxMissionItems,
+		commandRetries:        cfg.CommandRetries,
+		writeRetries:          cfg.WriteRetries,
+		commandAckTimeouts:    commandAckTimeouts,
+		commandAckTimeout:     cfg.CommandAckTimeout,
+		gotoSetpointRate:      cfg.GoToSetpointRate,
+		gotoAcceptanceRadius:  cfg.GoToAcceptanceRadius,
+		gotoHoldOnStop:        cfg.GoToHoldOnStop,
+		stopWatchdog:          make(chan struct{}),
+		watchdogDone:          make(chan struct{}),
+
+		waypointAcceptanceRadius: cfg.WaypointAcceptanceRadius,
+		waitAtWaypoints:          cfg.WaitAtWaypoints,
+
+		allowedArea:     cfg.AllowedArea,
+		pushAllowedArea: cfg.PushAllowedArea && cfg.AllowedArea != nil,
+
+		maxAltitude: cfg.MaxAltitude,
+
+		terrainSource: cfg.Terrain,
+
+		track:     trackRecorder{started: time.Now()},
+		trackSink: cfg.TrackSink,
+
 		telemetry: TelemetryData{
-			LastUpdate: time.Now(),
+			LastUpdate:       time.Now(),
+			EstimatorHealthy: true,
 		},
-		missionState:  MissionState{},
-		stopHeartbeat: make(chan struct{}),
-		heartbeatDone: make(chan struct{}),
+		missionState:    MissionState{},
+		missionProgress: MissionProgressTracker{current: -1},
+		stopHeartbeat:   make(chan struct{}),
+		heartbeatDone:   make(chan struct{}),
+		listenDone:      make(chan struct{}),
+		closeDone:       make(chan struct{}),
 	}
 
+	client.publishTelemetry()
+
 	// Start listening for messages
 	go client.listen()
 
+	// Alarm if telemetry stops while armed
+	go client.watchTelemetry()
+
 	// Start sending ground station heartbeat and system time
-	go client.sendGroundStationMessages()
+	if cfg.PassiveMode {
+		cfg.Logger.Println("MAVLink: Passive mode - not sending ground station messages")
+		close(client.heartbeatDone)
+	} else {
+		go client.sendGroundStationMessages()
+	}
 
 	return client, nil
 }
 
+// writeMessage sends a message to the vehicle
+// All outbound traffic goes through here so passive clients stay read-only.
+func (c *Client) writeMessage(msg message.Message) error {
+	if c.passive {
+		return ErrPassiveMode
+	}
+	if err := c.writeWithRetry(func() error { return c.node.WriteMessageAll(msg) }); err != nil {
+		c.recordWriteFailure(err)
+		return err
+	}
+	c.stats.messagesSent.Add(1)
+	c.recordWriteSuccess()
+	return nil
+}
+
+// IsPassive returns true if the client is listen-only
+func (c *Client) IsPassive() bool {
+	return c.passive
+}
+
 // sendGroundStationMessages sends periodic HEARTBEAT and SYSTEM_TIME messages
 // This identifies Flightpath as a ground station and provides GPS assistance
 func (c *Client) sendGroundStationMessages() {
 	defer close(c.heartbeatDone)
 	c.logger.Println("MAVLink: Starting ground station message sender")
+	if c.disableSystemTime {
+		c.logger.Println("MAVLink: SYSTEM_TIME disabled - sending HEARTBEAT and TIMESYNC only")
+	}
 
 	ticker := time.NewTicker(1 * time.Second)
 	defer ticker.Stop()
 
+	var lastIdentity time.Time
 	for {
 		select {
 		case <-c.stopHeartbeat:
@@ -216,27 +724,30 @@ func (c *Client) sendGroundStationMessages() {
 		case <-ticker.C:
 			// Send HEARTBEAT - identifies us as a ground control station
 			// This satisfies PX4's COM_DL_LOSS_T requirement
-			err := c.node.WriteMessageAll(&common.MessageHeartbeat{
-				Type:           common.MAV_TYPE_GCS, // Ground Control Station
-				Autopilot:      common.MAV_AUTOPILOT_INVALID,
-				BaseMode:       0,
-				CustomMode:     0,
-				SystemStatus:   common.MAV_STATE_ACTIVE,
-				MavlinkVersion: 3,
-			})
+			err := c.writeMessage(c.gcsHeartbeat())
 			if err != nil {
 				c.logger.Printf("MAVLink: Error sending HEARTBEAT: %v", err)
 			}
 
+			// Send the GCS identity STATUSTEXT when due
+			lastIdentity = c.sendGCSIdentity(time.Now(), lastIdentity)
+
 			// Send SYSTEM_TIME - provides accurate time for GPS assistance
 			// This helps GPS achieve lock faster (warm start vs cold start)
-			currentTime := time.Now()
-			err = c.node.WriteMessageAll(&common.MessageSystemTime{
-				TimeUnixUsec: uint64(currentTime.UnixMicro()),
-				TimeBootMs:   uint32(currentTime.UnixMilli() % (1 << 32)),
-			})
-			if err != nil {
-				c.logger.Printf("MAVLink: Error sending SYSTEM_TIME: %v", err)
+			if !c.disableSystemTime {
+				currentTime := time.Now()
+				err = c.writeMessage(&common.MessageSystemTime{
+					TimeUnixUsec: uint64(currentTime.UnixMicro()),
+					TimeBootMs:   uint32(currentTime.UnixMilli() % (1 << 32)),
+				})
+				if err != nil {
+					c.logger.Printf("MAVLink: Error sending SYSTEM_TIME: %v", err)
+				}
+			}
+
+			// Send TIMESYNC - measures link latency and the vehicle's clock offset
+			if err := c.sendTimesync(time.Now()); err != nil {
+				c.logger.Printf("MAVLink: Error sending TIMESYNC: %v", err)
 			}
 		}
 	}
@@ -252,7 +763,7 @@ func (c *Client) requestDataStreams() error {
 	c.logger.Println("MAVLink: Requesting data streams from drone")
 
 	// Request all data streams at 10 Hz
-	return c.node.WriteMessageAll(&common.MessageRequestDataStream{
+	return c.writeMessage(&common.MessageRequestDataStream{
 		TargetSystem:    systemID,
 		TargetComponent: 1,
 		ReqStreamId:     uint8(common.MAV_DATA_STREAM_ALL),
@@ -263,11 +774,28 @@ func (c *Client) requestDataStreams() error {
 
 // listen processes incoming MAVLink messages
 func (c *Client) listen() {
+	defer close(c.listenDone)
 	c.logger.Println("MAVLink: Starting message listener")
 
 	for evt := range c.node.Events() {
-		if frm, ok := evt.(*gomavlib.EventFrame); ok {
-			c.handleMessage(frm.Message(), frm.SystemID(), frm.ComponentID())
+		switch e := evt.(type) {
+		case *gomavlib.EventFrame:
+			c.recordFrame(e)
+			c.publishRawMessage(e)
+			if c.inboundAllowed != nil && !c.inboundAllowed[e.Message().GetID()] {
+				continue
+			}
+			c.handleMessage(e.Message(), e.SystemID(), e.ComponentID())
+
+		case *gomavlib.EventChannelOpen:
+			c.mu.Lock()
+			c.stats.channelOpened = true
+			c.mu.Unlock()
+
+		case *gomavlib.EventParseError:
+			c.mu.Lock()
+			c.stats.parseErrors++
+			c.mu.Unlock()
 		}
 	}
 
@@ -276,15 +804,29 @@ func (c *Client) listen() {
 
 // handleMessage processes individual MAVLink messages
 func (c *Client) handleMessage(msg message.Message, sysID, compID uint8) {
+	if hb, ok := msg.(*common.MessageHeartbeat); ok {
+		c.trackSystem(hb, sysID, compID)
+	}
+
+	if !c.acceptsMessage(msg, sysID) {
+		return
+	}
+
 	switch m := msg.(type) {
 	case *common.MessageHeartbeat:
-		c.handleHeartbeat(m, sysID)
+		c.handleHeartbeat(m, sysID, compID)
 
 	case *common.MessageCommandAck:
 		c.handleCommandAck(m)
 
 	case *common.MessageStatustext:
-		c.logger.Printf("MAVLink STATUS: [%d] %s", m.Severity, m.Text)
+		c.handleStatusText(m)
+
+	case *common.MessageAutopilotVersion:
+		c.handleAutopilotVersion(m)
+
+	case *common.MessageProtocolVersion:
+		c.handleProtocolVersion(m)
 
 	case *common.MessageGlobalPositionInt:
 		c.handleGlobalPosition(m)
@@ -298,6 +840,9 @@ func (c *Client) handleMessage(msg message.Message, sysID, compID uint8) {
 	case *common.MessageSysStatus:
 		c.handleSysStatus(m)
 
+	case *common.MessageBatteryStatus:
+		c.handleBatteryStatus(m)
+
 	case *common.MessageGpsRawInt:
 		c.handleGpsRaw(m)
 
@@ -310,26 +855,140 @@ func (c *Client) handleMessage(msg message.Message, sysID, compID uint8) {
 	case *common.MessageMissionAck:
 		c.handleMissionAck(m)
 
+	case *common.MessageMissionCount:
+		c.handleMissionCount(m)
+
+	case *common.MessageMissionItemInt:
+		c.handleMissionItemInt(m)
+
 	case *common.MessageMissionCurrent:
 		c.handleMissionCurrent(m)
 
 	case *common.MessageMissionItemReached:
 		c.handleMissionItemReached(m)
+
+	case *common.MessageNamedValueFloat:
+		c.handleNamedValueFloat(m)
+
+	case *common.MessageNamedValueInt:
+		c.handleNamedValueInt(m)
+
+	case *common.MessageHighresImu:
+		c.handleHighresIMU(m)
+
+	case *common.MessageScaledImu:
+		c.handleScaledIMU(m)
+
+	case *common.MessageParamValue:
+		c.handleParamValue(m)
+
+	case *common.MessageTimesync:
+		c.handleTimesync(m, sysID, compID)
+
+	case *common.MessageHomePosition:
+		c.handleHomePosition(m)
+
+	case *common.MessageGimbalManagerInformation:
+		c.handleGimbalManagerInformation(m, compID)
+
+	case *common.MessageGimbalManagerStatus:
+		c.handleGimbalManagerStatus(m)
+
+	case *common.MessageTerrainRequest:
+		c.handleTerrainRequest(m)
+
+	case *common.MessageTerrainReport:
+		c.handleTerrainReport(m)
 	}
 }
 
-// handleHeartbeat processes HEARTBEAT messages
-func (c *Client) handleHeartbeat(msg *common.MessageHeartbeat, sysID uint8) {
+// trackSystem records a heartbeat from any system on the link
+func (c *Client) trackSystem(msg *common.MessageHeartbeat, sysID, compID uint8) {
 	c.mu.Lock()
 	defer c.mu.Unlock()
 
+	sys, ok := c.visibleSystems[sysID]
+	if !ok {
+		sys = &VisibleSystem{SystemID: sysID, components: make(map[uint8]common.MAV_TYPE)}
+		c.visibleSystems[sysID] = sys
+		if len(c.visibleSystems) > 1 {
+			c.logger.Printf("MAVLink: Multiple systems on link, new system %d (type %s)", sysID, msg.Type)
+		}
+	}
+
+	sys.components[compID] = msg.Type
+	sys.ComponentID = compID
+	sys.Type = msg.Type
+	sys.Autopilot = msg.Autopilot
+	sys.LastHeartbeat = time.Now()
+}
+
+// acceptsMessage reports whether a message comes from the vehicle this client is bound to
+// Until a vehicle is bound, only vehicle heartbeats (not other ground stations) are accepted.
+func (c *Client) acceptsMessage(msg message.Message, sysID uint8) bool {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	if c.targetSystemID != 0 {
+		return sysID == c.targetSystemID
+	}
+
+	if c.systemID != 0 {
+		return sysID == c.systemID
+	}
+
+	hb, ok := msg.(*common.MessageHeartbeat)
+	return ok && hb.Type != common.MAV_TYPE_GCS && hb.Autopilot != common.MAV_AUTOPILOT_INVALID
+}
+
+// GetVisibleSystems returns every system seen on the link, sorted by system ID
+func (c *Client) GetVisibleSystems() []VisibleSystem {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.visibleSystemsLocked()
+}
+
+// visibleSystemsLocked is GetVisibleSystems; caller must hold c.mu
+func (c *Client) visibleSystemsLocked() []VisibleSystem {
+	systems := make([]VisibleSystem, 0, len(c.visibleSystems))
+	for _, sys := range c.visibleSystems {
+		systems = append(systems, *sys)
+	}
+	sort.Slice(systems, func(i, j int) bool {
+		return systems[i].SystemID < systems[j].SystemID
+	})
+	return systems
+}
+
+// handleHeartbeat processes HEARTBEAT messages from the bound vehicle
+// Gimbals, cameras and other components of the vehicle send heartbeats too
+// (MAV_AUTOPILOT_INVALID); only the autopilot's carry its mode, arming and
+// status, so the rest are ignored, as are other autopilot components once one
+// is bound.
+func (c *Client) handleHeartbeat(msg *common.MessageHeartbeat, sysID, compID uint8) {
+	if msg.Autopilot == common.MAV_AUTOPILOT_INVALID || msg.Type == common.MAV_TYPE_GCS {
+		return
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.componentID != 0 && compID != c.componentID {
+		return
+	}
+	c.componentID = compID
+
+	wasConnected := c.connected
 	if !c.connected {
 		c.logger.Printf("MAVLink: Connected to system %d", sysID)
 	}
 
+	now := time.Now()
+	c.recordHeartbeat(msg, now)
+
 	c.connected = true
 	c.systemID = sysID
-	c.lastHeartbeat = time.Now()
+	c.lastHeartbeat = now
 
 	// Check armed status (bit 7 of base_mode)
 	wasArmed := c.armed
@@ -337,11 +996,31 @@ func (c *Client) handleHeartbeat(msg *common.MessageHeartbeat, sysID uint8) {
 
 	if wasArmed != c.armed {
 		c.logger.Printf("MAVLink: Armed status changed: %v", c.armed)
+		if c.armed {
+			c.startTrackLocked(now)
+		}
 	}
 
 	// Store flight mode
+	modeChanged := c.telemetry.CustomMode != msg.CustomMode ||
+		c.telemetry.SystemStatus != uint8(msg.SystemStatus)
 	c.telemetry.CustomMode = msg.CustomMode
 	c.telemetry.BaseMode = uint8(msg.BaseMode)
+	c.telemetry.SystemStatus = uint8(msg.SystemStatus)
+	c.publishTelemetry()
+
+	if modeChanged {
+		c.handleFailsafeModeChange(msg.CustomMode, msg.SystemStatus)
+		if c.missionState.Aborted && IsMissionMode(msg.CustomMode) {
+			c.missionState.Aborted = false
+		}
+	}
+
+	// PX4 may reset stream subscriptions on these transitions; the first
+	// heartbeat isn't one (WaitForConnection requests telemetry then)
+	if c.rerequestStreams && wasConnected && (wasArmed != c.armed || modeChanged) {
+		c.scheduleStreamRerequest()
+	}
 }
 
 // handleGlobalPosition processes GLOBAL_POSITION_INT messages
@@ -361,7 +1040,11 @@ func (c *Client) handleGlobalPosition(msg *common.MessageGlobalPositionInt) {
 	c.telemetry.VelocityY = float64(msg.Vy) / 100.0
 	c.telemetry.VelocityZ = float64(msg.Vz) / 100.0
 
-	c.telemetry.LastUpdate = time.Now()
+	now := c.vehicleTime(msg.TimeBootMs, time.Now())
+	c.telemetry.LastUpdate = now
+	c.telemetry.PositionUpdated = now
+	c.recordTrackPointLocked(now)
+	c.publishTelemetry()
 }
 
 // handleAttitude processes ATTITUDE messages
@@ -373,7 +1056,10 @@ func (c *Client) handleAttitude(msg *common.MessageAttitude) {
 	c.telemetry.Pitch = float64(msg.Pitch)
 	c.telemetry.Yaw = float64(msg.Yaw)
 
-	c.telemetry.LastUpdate = time.Now()
+	now := c.vehicleTime(msg.TimeBootMs, time.Now())
+	c.telemetry.LastUpdate = now
+	c.telemetry.AttitudeUpdated = now
+	c.publishTelemetry()
 }
 
 // handleVfrHud processes VFR_HUD messages
@@ -385,7 +1071,10 @@ func (c *Client) handleVfrHud(msg *common.MessageVfrHud) {
 	c.telemetry.GroundSpeed = float64(msg.Groundspeed)
 	c.telemetry.VerticalSpeed = float64(msg.Climb)
 
-	c.telemetry.LastUpdate = time.Now()
+	now := time.Now()
+	c.telemetry.LastUpdate = now
+	c.telemetry.VfrHudUpdated = now
+	c.publishTelemetry()
 }
 
 // handleSysStatus processes SYS_STATUS messages
@@ -393,18 +1082,37 @@ func (c *Client) handleSysStatus(msg *common.MessageSysStatus) {
 	c.mu.Lock()
 	defer c.mu.Unlock()
 
+	// A reporting BATTERY_STATUS battery has the better figures
+	primary, fresh := c.primaryBatteryLocked()
+
 	// Convert from millivolts to volts
-	c.telemetry.BatteryVoltage = float64(msg.VoltageBattery) / 1000.0
-	c.telemetry.BatteryRemaining = int32(msg.BatteryRemaining)
+	if !fresh || primary.Voltage <= 0 {
+		c.telemetry.BatteryVoltage = float64(msg.VoltageBattery) / 1000.0
+	}
+	if !fresh || primary.Remaining < 0 {
+		c.telemetry.BatteryRemaining = int32(msg.BatteryRemaining)
+		c.battery.Add(int32(msg.BatteryRemaining), time.Now())
+	}
 
 	// Convert from centiamps to amps
-	c.telemetry.BatteryCurrent = float64(msg.CurrentBattery) / 100.0
+	if !fresh || primary.Current < 0 {
+		c.telemetry.BatteryCurrent = float64(msg.CurrentBattery) / 100.0
+	}
 
 	// Check if critical sensors are healthy
 	c.telemetry.SensorsHealthy = (msg.OnboardControlSensorsHealth &
 		msg.OnboardControlSensorsEnabled) == msg.OnboardControlSensorsEnabled
 
-	c.telemetry.LastUpdate = time.Now()
+	ahrs := common.MAV_SYS_STATUS_AHRS
+	c.telemetry.EstimatorHealthy = msg.OnboardControlSensorsEnabled&ahrs == 0 ||
+		msg.OnboardControlSensorsHealth&ahrs != 0
+
+	c.handleRCReceiverHealth(msg)
+
+	now := time.Now()
+	c.telemetry.LastUpdate = now
+	c.telemetry.SysStatusUpdated = now
+	c.publishTelemetry()
 }
 
 // handleGpsRaw processes GPS_RAW_INT messages
@@ -415,67 +1123,16 @@ func (c *Client) handleGpsRaw(msg *common.MessageGpsRawInt) {
 	// EPH (HDOP * 100) - convert to meters (approximate)
 	c.telemetry.GPSAccuracy = float64(msg.Eph) / 100.0
 	c.telemetry.SatelliteCount = int32(msg.SatellitesVisible)
-
-	c.telemetry.LastUpdate = time.Now()
-}
-
-// handleMissionRequest processes MISSION_REQUEST messages
-func (c *Client) handleMissionRequest(msg *common.MessageMissionRequest) {
-	c.handleMissionRequestInt(&common.MessageMissionRequestInt{
-		Seq: msg.Seq,
-	})
-}
-
-// handleMissionRequestInt processes MISSION_REQUEST_INT messages
-func (c *Client) handleMissionRequestInt(msg *common.MessageMissionRequestInt) {
-	c.mu.Lock()
-	defer c.mu.Unlock()
-
-	if !c.missionState.Uploading {
-		c.logger.Printf("MAVLink: Received unexpected MISSION_REQUEST_INT for seq %d", msg.Seq)
-		return
-	}
-
-	seq := int(msg.Seq)
-	if seq >= len(c.missionState.Waypoints) {
-		c.logger.Printf("MAVLink: Invalid waypoint sequence %d (max %d)", seq, len(c.missionState.Waypoints))
-		return
+	c.telemetry.GPSFixType = msg.FixType
+	c.telemetry.GPSHDOP = 0
+	if msg.Eph != math.MaxUint16 {
+		c.telemetry.GPSHDOP = float64(msg.Eph) / 100.0
 	}
 
-	c.logger.Printf("MAVLink: Sending waypoint %d/%d", seq+1, len(c.missionState.Waypoints))
-
-	// Send the requested waypoint
-	wp := c.missionState.Waypoints[seq]
-	if err := c.sendMissionItem(wp); err != nil {
-		c.logger.Printf("MAVLink: Error sending waypoint %d: %v", seq, err)
-		if c.missionState.UploadComplete != nil {
-			c.missionState.UploadComplete <- err
-			c.missionState.UploadComplete = nil
-		}
-		c.missionState.Uploading = false
-	}
-}
-
-// handleMissionAck processes MISSION_ACK messages
-func (c *Client) handleMissionAck(msg *common.MessageMissionAck) {
-	c.mu.Lock()
-	defer c.mu.Unlock()
-
-	c.logger.Printf("MAVLink: Mission ACK received: type=%d", msg.Type)
-
-	if c.missionState.Uploading {
-		c.missionState.Uploading = false
-		if c.missionState.UploadComplete != nil {
-			if msg.Type == common.MAV_MISSION_ACCEPTED {
-				c.logger.Println("MAVLink: Mission upload successful")
-				c.missionState.UploadComplete <- nil
-			} else {
-				c.logger.Printf("MAVLink: Mission upload failed: %d", msg.Type)
-				c.missionState.UploadComplete <- fmt.Errorf("mission upload failed: %d", msg.Type)
-			}
-			c.missionState.UploadComplete = nil
-		}
-	}
+	now := time.Now()
+	c.telemetry.LastUpdate = now
+	c.telemetry.GPSUpdated = now
+	c.publishTelemetry()
 }
 
 // handleMissionCurrent processes MISSION_CURRENT messages
@@ -484,7 +1141,8 @@ func (c *Client) handleMissionCurrent(msg *common.MessageMissionCurrent) {
 	defer c.mu.Unlock()
 
 	c.missionState.CurrentWaypoint = int32(msg.Seq)
-	c.missionState.MissionActive = msg.Seq >= 0
+	c.missionState.MissionActive = !c.missionState.Aborted
+	c.missionProgress.Current(int(msg.Seq))
 
 	c.logger.Printf("MAVLink: Current mission waypoint: %d", msg.Seq)
 }
@@ -494,44 +1152,53 @@ func (c *Client) handleMissionItemReached(msg *common.MessageMissionItemReached)
 	c.mu.Lock()
 	defer c.mu.Unlock()
 
+	c.missionProgress.Reached(int(msg.Seq), time.Now())
+
 	c.logger.Printf("MAVLink: Mission waypoint %d reached", msg.Seq)
 }
 
 // handleCommandAck processes command acknowledgments
 func (c *Client) handleCommandAck(msg *common.MessageCommandAck) {
-	result := "UNKNOWN"
-	switch msg.Result {
-	case common.MAV_RESULT_ACCEPTED:
-		result = "ACCEPTED"
-	case common.MAV_RESULT_TEMPORARILY_REJECTED:
-		result = "TEMPORARILY_REJECTED"
-	case common.MAV_RESULT_DENIED:
-		result = "DENIED"
-	case common.MAV_RESULT_UNSUPPORTED:
-		result = "UNSUPPORTED"
-	case common.MAV_RESULT_FAILED:
-		result = "FAILED"
-	case common.MAV_RESULT_IN_PROGRESS:
-		result = "IN_PROGRESS"
-	}
-
-	c.logger.Printf("MAVLink: Command %d result: %s", msg.Command, result)
+	c.logger.Printf("MAVLink: Command %d result: %s", msg.Command, commandResultName(msg.Result))
+	c.deliverCommandAck(msg)
 }
 
 // GoToPosition sends a position setpoint to the drone
-// The drone must be in GUIDED (OFFBOARD) mode to accept position commands
+// The drone must be in GUIDED (OFFBOARD) mode to accept position commands.
+// With a GoToSetpointRate the target is re-sent until reached (see startGoToStream);
+// either way it replaces any target still being re-sent.
 func (c *Client) GoToPosition(latitude, longitude, altitude float64) error {
-	c.mu.RLock()
-	systemID := c.systemID
-	c.mu.RUnlock()
-
 	if !c.IsConnected() {
 		return fmt.Errorf("not connected to drone")
 	}
+	if err := c.checkAllowedArea(latitude, longitude); err != nil {
+		return err
+	}
+	altitude, err := c.limitAltitude(altitude)
+	if err != nil {
+		return err
+	}
+
+	c.stopGoTo(false)
 
 	c.logger.Printf("MAVLink: Sending position setpoint: lat=%.6f, lon=%.6f, alt=%.2f",
 		latitude, longitude, altitude)
 
+	if err := c.sendPositionTarget(latitude, longitude, altitude); err != nil {
+		return err
+	}
+	if c.gotoSetpointRate > 0 {
+		c.startGoToStream(latitude, longitude, altitude)
+	}
+	return nil
+}
+
+// sendPositionTarget sends one SET_POSITION_TARGET_GLOBAL_INT (altitude relative to home)
+func (c *Client) sendPositionTarget(latitude, longitude, altitude float64) error {
+	c.mu.RLock()
+	systemID := c.systemID
+	c.mu.RUnlock()
+
 	// Convert to MAVLink format
 	lat := int32(latitude * 1e7)  // degrees * 1E7
 	lon := int32(longitude * 1e7) // degrees * 1E7
@@ -550,7 +1217,7 @@ func (c *Client) GoToPosition(latitude, longitude, altitude float64) error {
 	)
 
 	// Send SET_POSITION_TARGET_GLOBAL_INT message
-	return c.node.WriteMessageAll(&common.MessageSetPositionTargetGlobalInt{
+	return c.writeMessage(&common.MessageSetPositionTargetGlobalInt{
 		TargetSystem:    systemID,
 		TargetComponent: 1,
 		TimeBootMs:      uint32(time.Now().UnixMilli()),
@@ -570,81 +1237,85 @@ func (c *Client) GoToPosition(latitude, longitude, altitude float64) error {
 	})
 }
 
-// UploadMission uploads a mission to the drone
+// UploadMission uploads a mission to the drone (altitudes relative to home)
 func (c *Client) UploadMission(waypoints []*drone.Waypoint) error {
-	c.mu.Lock()
+	return c.UploadMissionOptions(waypoints, nil)
+}
 
-	if c.missionState.Uploading {
-		c.mu.Unlock()
-		return fmt.Errorf("mission upload already in progress")
+// UploadMissionOptions uploads a mission with per-waypoint options (altitude
+// frame, loiter radius and turns)
+// options is nil (defaults, relative to home) or has one entry per waypoint.
+func (c *Client) UploadMissionOptions(waypoints []*drone.Waypoint, options []WaypointOptions) error {
+	if options == nil {
+		options = DefaultWaypointOptions(len(waypoints))
+	}
+	if len(options) != len(waypoints) {
+		return fmt.Errorf("got %d waypoint options for %d waypoints", len(options), len(waypoints))
 	}
 
-	systemID := c.systemID
-	c.missionState.Uploading = true
-	c.missionState.Waypoints = waypoints
-	c.missionState.TotalCount = len(waypoints)
-	c.missionState.CurrentIndex = 0
-	c.missionState.UploadComplete = make(chan error, 1)
-
-	uploadComplete := c.missionState.UploadComplete
-	c.mu.Unlock()
-
-	c.logger.Printf("MAVLink: Starting mission upload (%d waypoints)", len(waypoints))
-
-	// Send MISSION_COUNT
-	err := c.node.WriteMessageAll(&common.MessageMissionCount{
-		TargetSystem:    systemID,
-		TargetComponent: 1,
-		Count:           uint16(len(waypoints)),
-	})
-
-	if err != nil {
-		c.mu.Lock()
-		c.missionState.Uploading = false
-		c.mu.Unlock()
-		return fmt.Errorf("failed to send MISSION_COUNT: %w", err)
+	// Camera actions follow their waypoint, so item sequence numbers (progress,
+	// MISSION_CURRENT) differ from waypoint indexes once there are any
+	items := make([]MissionItem, 0, MissionItemCount(len(waypoints), options))
+	for i, wp := range waypoints {
+		items = append(items, c.waypointToMissionItem(wp, options[i]))
+		for _, action := range options[i].Camera {
+			items = append(items, action.MissionItem())
+		}
 	}
 
-	// Wait for upload to complete (with timeout)
-	select {
-	case err := <-uploadComplete:
+	if err := c.UploadItems(common.MAV_MISSION_TYPE_MISSION, items); err != nil {
 		return err
-	case <-time.After(30 * time.Second):
-		c.mu.Lock()
-		c.missionState.Uploading = false
-		c.mu.Unlock()
-		return fmt.Errorf("mission upload timeout")
 	}
-}
 
-// sendMissionItem sends a single mission item to the drone
-func (c *Client) sendMissionItem(wp *drone.Waypoint) error {
-	systemID := c.systemID
+	c.mu.Lock()
+	c.missionState.Waypoints = waypoints
+	c.missionState.WaypointOptions = options
+	c.missionState.MissionItems = items
+	c.missionState.WaypointsConfirmed = false
+	c.missionState.TotalWaypoints = int32(len(items))
+	c.missionProgress.ResetItems(items, time.Now())
+	c.mu.Unlock()
+
+	return nil
+}
 
-	// Map action to MAVLink command
+// waypointToMissionItem converts a proto waypoint to a MAVLink mission item
+// Params: 1 hold time (turn count for LOITER_TURNS), 2 acceptance radius,
+// 3 loiter radius (LOITER and HOLD only), 4 heading. Unset acceptance radius
+// (WAYPOINT only, where param 2 means that) and autocontinue take the client defaults.
+func (c *Client) waypointToMissionItem(wp *drone.Waypoint, opts WaypointOptions) MissionItem {
 	command := c.mapWaypointActionToMAVLink(wp.Action)
+	param1 := float32(wp.HoldTimeSec)
+	var loiterRadius float32
+	if isLoiterAction(wp.Action) {
+		loiterRadius = float32(opts.LoiterRadius)
+		if opts.LoiterTurns > 0 {
+			command = common.MAV_CMD_NAV_LOITER_TURNS
+			param1 = float32(opts.LoiterTurns)
+		}
+	}
 
-	// Convert position
-	lat := int32(wp.Position.Latitude * 1e7)
-	lon := int32(wp.Position.Longitude * 1e7)
-	alt := float32(wp.Position.Altitude)
+	acceptanceRadius := wp.AcceptanceRadius
+	if acceptanceRadius == 0 && wp.Action == drone.Waypoint_ACTION_WAYPOINT {
+		acceptanceRadius = c.waypointAcceptanceRadius
+	}
+	autocontinue := !c.waitAtWaypoints
+	if opts.Autocontinue != nil {
+		autocontinue = *opts.Autocontinue
+	}
 
-	return c.node.WriteMessageAll(&common.MessageMissionItemInt{
-		TargetSystem:    systemID,
-		TargetComponent: 1,
-		Seq:             uint16(wp.Sequence),
-		Frame:           common.MAV_FRAME_GLOBAL_RELATIVE_ALT,
-		Command:         command,
-		Current:         0,
-		Autocontinue:    1,
-		Param1:          float32(wp.HoldTimeSec),
-		Param2:          float32(wp.AcceptanceRadius),
-		Param3:          0,
-		Param4:          float32(wp.Heading),
-		X:               lat,
-		Y:               lon,
-		Z:               alt,
-	})
+	return MissionItem{
+		Command:      command,
+		Frame:        opts.AltitudeFrame.MAVFrame(),
+		Autocontinue: autocontinue,
+		Param1:       param1,
+		Param2:       float32(acceptanceRadius),
+		Param3:       loiterRadius,
+		Param4:       float32(wp.Heading),
+		X:            int32(wp.Position.Latitude * 1e7),
+		Y:            int32(wp.Position.Longitude * 1e7),
+		Z:            float32(wp.Position.Altitude),
+	}
 }
 
 // mapWaypointActionToMAVLink maps proto waypoint action to MAVLink command
@@ -665,22 +1336,58 @@ func (c *Client) mapWaypointActionToMAVLink(action drone.Waypoint_Action) common
 	}
 }
 
+// ReadMissionCount returns how many mission items the vehicle holds
+func (c *Client) ReadMissionCount() (int, error) {
+	return c.ReadItemCount(common.MAV_MISSION_TYPE_MISSION)
+}
+
+// DownloadMission reads the mission items the vehicle holds
+// When they match the last upload, the uploaded waypoints are confirmed
+// (see GetUploadedWaypoints).
+func (c *Client) DownloadMission() ([]MissionItem, error) {
+	items, err := c.DownloadItems(common.MAV_MISSION_TYPE_MISSION)
+	if err != nil {
+		return nil, err
+	}
+
+	c.mu.Lock()
+	if len(c.missionState.Waypoints) > 0 && slices.Equal(items, c.missionState.MissionItems) {
+		c.missionState.WaypointsConfirmed = true
+	}
+	c.mu.Unlock()
+
+	return items, nil
+}
+
 // ClearMission clears the mission from the drone
 func (c *Client) ClearMission() error {
-	c.mu.RLock()
-	systemID := c.systemID
-	c.mu.RUnlock()
-
-	if !c.IsConnected() {
-		return fmt.Errorf("not connected to drone")
+	if err := c.ClearItems(common.MAV_MISSION_TYPE_MISSION); err != nil {
+		return err
 	}
 
-	c.logger.Println("MAVLink: Clearing mission")
+	c.mu.Lock()
+	c.missionState.Waypoints = nil
+	c.missionState.WaypointOptions = nil
+	c.missionState.MissionItems = nil
+	c.missionState.WaypointsConfirmed = false
+	c.missionProgress.Reset(0, time.Now())
+	c.mu.Unlock()
 
-	return c.node.WriteMessageAll(&common.MessageMissionClearAll{
-		TargetSystem:    systemID,
-		TargetComponent: 1,
-	})
+	return nil
+}
+
+// GetUploadedWaypoints returns the waypoints last uploaded via UploadMission and
+// their options. No MAVLink traffic; confirmed is true once they were
+// verified against the vehicle.
+func (c *Client) GetUploadedWaypoints() (waypoints []*drone.Waypoint, options []WaypointOptions, confirmed bool) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	waypoints = make([]*drone.Waypoint, len(c.missionState.Waypoints))
+	copy(waypoints, c.missionState.Waypoints)
+	options = make([]WaypointOptions, len(c.missionState.WaypointOptions))
+	copy(options, c.missionState.WaypointOptions)
+	return waypoints, options, c.missionState.WaypointsConfirmed
 }
 
 // StartMission starts mission execution at specified waypoint
@@ -696,11 +1403,20 @@ func (c *Client) StartMission(waypointIndex int32) error {
 	c.logger.Printf("MAVLink: Starting mission at waypoint %d", waypointIndex)
 
 	// Send MISSION_SET_CURRENT
-	return c.node.WriteMessageAll(&common.MessageMissionSetCurrent{
+	err := c.writeMessage(&common.MessageMissionSetCurrent{
 		TargetSystem:    systemID,
 		TargetComponent: 1,
 		Seq:             uint16(waypointIndex),
 	})
+	if err != nil {
+		return err
+	}
+
+	c.mu.Lock()
+	c.missionState.Aborted = false
+	c.missionProgress.Reset(int(c.missionState.TotalWaypoints), time.Now())
+	c.mu.Unlock()
+	return nil
 }
 
 // GetMissionProgress returns current mission progress
@@ -711,25 +1427,45 @@ func (c *Client) GetMissionProgress() (currentWaypoint int32, totalWaypoints int
 	return c.missionState.CurrentWaypoint, c.missionState.TotalWaypoints, c.missionState.MissionActive
 }
 
-// GetTelemetry returns current telemetry data (thread-safe)
+// GetTelemetry returns current telemetry data (thread-safe, lock-free)
 func (c *Client) GetTelemetry() TelemetryData {
-	c.mu.RLock()
-	defer c.mu.RUnlock()
-	return c.telemetry
+	return *c.telemetrySnapshot.Load()
+}
+
+// TelemetrySnapshot returns the shared current telemetry without copying it
+// The snapshot is immutable (updates swap in a new one), so callers must not
+// modify it. Meant for per-tick stream loops, where it is one atomic load.
+func (c *Client) TelemetrySnapshot() *TelemetryData {
+	return c.telemetrySnapshot.Load()
+}
+
+// publishTelemetry swaps in a copy of the updated telemetry for readers
+// Caller must hold c.mu.
+func (c *Client) publishTelemetry() {
+	snapshot := c.telemetry
+	c.telemetrySnapshot.Store(&snapshot)
 }
 
 // IsConnected returns true if connected to drone
 func (c *Client) IsConnected() bool {
 	c.mu.RLock()
-	defer c.mu.RUnlock()
+	connected := c.connected
+	stale := connected && time.Since(c.lastHeartbeat) > heartbeatTimeout
+	c.mu.RUnlock()
 
 	// Consider disconnected if no heartbeat in 3 seconds
-	if c.connected && time.Since(c.lastHeartbeat) > 3*time.Second {
-		c.connected = false
-		c.logger.Println("MAVLink: Connection timeout (no heartbeat)")
+	if stale {
+		c.mu.Lock()
+		// A heartbeat may have arrived since the check
+		if c.connected && time.Since(c.lastHeartbeat) > heartbeatTimeout {
+			c.connected = false
+			c.logger.Println("MAVLink: Connection timeout (no heartbeat)")
+		}
+		connected = c.connected
+		c.mu.Unlock()
 	}
 
-	return c.connected
+	return connected && !c.stats.writeDown.Load()
 }
 
 // IsArmed returns true if drone is armed
@@ -758,10 +1494,29 @@ func (c *Client) WaitForConnection(timeout time.Duration) error {
 		if c.IsConnected() {
 			c.logger.Printf("MAVLink: Heartbeat received from system %d", c.GetSystemID())
 
-			// Request data streams now that we're connected
-			if err := c.requestDataStreams(); err != nil {
-				c.logger.Printf("MAVLink: Warning - failed to request data streams: %v", err)
-				// Non-fatal - continue anyway
+			// Request telemetry now that we're connected, as the
+			// negotiated protocol version allows
+			c.requestTelemetry(c.negotiateProtocolVersion())
+
+			// Firmware version for GetConnectionInfo
+			if err := c.requestAutopilotVersion(); err != nil {
+				c.logger.Printf("MAVLink: Warning - failed to request autopilot version: %v", err)
+			}
+
+			// Gimbal manager discovery for GetGimbal and SetGimbalMode
+			if err := c.requestGimbalManagerInformation(); err != nil {
+				c.logger.Printf("MAVLink: Warning - failed to request gimbal manager information: %v", err)
+			}
+
+			if c.pushAllowedArea {
+				if err := c.sendAllowedArea(); err != nil {
+					c.logger.Printf("MAVLink: Warning - failed to send allowed area: %v", err)
+				}
+			}
+
+			// A mission may already be loaded (e.g. after a server restart mid-flight)
+			if !c.passive {
+				go c.seedMissionProgress()
 			}
 
 			return nil
@@ -775,68 +1530,45 @@ func (c *Client) WaitForConnection(timeout time.Duration) error {
 	}
 }
 
-// Arm sends arm command to the drone
+// Arm arms the drone and waits for the vehicle to accept
+// A TEMPORARILY_REJECTED arm (e.g. while preflight checks finish) is retried;
+// see sendAcknowledged.
 func (c *Client) Arm() error {
-	c.mu.RLock()
-	systemID := c.systemID
-	c.mu.RUnlock()
-
 	if !c.IsConnected() {
 		return fmt.Errorf("not connected to drone")
 	}
 
 	c.logger.Println("MAVLink: Sending ARM command")
 
-	return c.node.WriteMessageAll(&common.MessageCommandLong{
-		TargetSystem:    systemID,
-		TargetComponent: 1,
-		Command:         common.MAV_CMD_COMPONENT_ARM_DISARM,
-		Param1:          1, // 1 = arm, 0 = disarm
-	})
+	// Param1: 1 = arm, 0 = disarm
+	return c.sendCommandLong(common.MAV_CMD_COMPONENT_ARM_DISARM, [7]float32{1})
 }
 
-// Disarm sends disarm command to the drone
+// Disarm disarms the drone and waits for the vehicle to accept
 func (c *Client) Disarm() error {
-	c.mu.RLock()
-	systemID := c.systemID
-	c.mu.RUnlock()
-
 	if !c.IsConnected() {
 		return fmt.Errorf("not connected to drone")
 	}
 
 	c.logger.Println("MAVLink: Sending DISARM command")
 
-	return c.node.WriteMessageAll(&common.MessageCommandLong{
-		TargetSystem:    systemID,
-		TargetComponent: 1,
-		Command:         common.MAV_CMD_COMPONENT_ARM_DISARM,
-		Param1:          0, // 1 = arm, 0 = disarm
-	})
+	return c.sendCommandLong(common.MAV_CMD_COMPONENT_ARM_DISARM, [7]float32{0})
 }
 
-// SetMode sets the flight mode using PX4's mode encoding
+// SetMode sets the flight mode using PX4's mode encoding and waits for the vehicle to accept
 // The mode value is encoded in MAVLink's custom_mode field
 func (c *Client) SetMode(px4Mode uint32) error {
-	c.mu.RLock()
-	systemID := c.systemID
-	c.mu.RUnlock()
-
 	if !c.IsConnected() {
 		return fmt.Errorf("not connected to drone")
 	}
 
 	c.logger.Printf("MAVLink: Setting PX4 mode to %d", px4Mode)
 
-	// Send MAV_CMD_DO_SET_MODE command
 	// Param1: MAV_MODE_FLAG_CUSTOM_MODE_ENABLED tells MAVLink to use custom_mode field
 	// Param2: The PX4-specific mode value
-	return c.node.WriteMessageAll(&common.MessageCommandLong{
-		TargetSystem:    systemID,
-		TargetComponent: 1,
-		Command:         common.MAV_CMD_DO_SET_MODE,
-		Param1:          float32(common.MAV_MODE_FLAG_CUSTOM_MODE_ENABLED),
-		Param2:          float32(px4Mode),
+	return c.sendCommandLong(common.MAV_CMD_DO_SET_MODE, [7]float32{
+		float32(common.MAV_MODE_FLAG_CUSTOM_MODE_ENABLED),
+		float32(px4Mode),
 	})
 }
 
@@ -849,10 +1581,15 @@ func (c *Client) Takeoff(altitude float32) error {
 	if !c.IsConnected() {
 		return fmt.Errorf("not connected to drone")
 	}
+	limited, err := c.limitAltitude(float64(altitude))
+	if err != nil {
+		return err
+	}
+	altitude = float32(limited)
 
 	c.logger.Printf("MAVLink: Sending TAKEOFF command (altitude: %.2fm)", altitude)
 
-	return c.node.WriteMessageAll(&common.MessageCommandLong{
+	return c.writeMessage(&common.MessageCommandLong{
 		TargetSystem:    systemID,
 		TargetComponent: 1,
 		Command:         common.MAV_CMD_NAV_TAKEOFF,
@@ -872,7 +1609,7 @@ func (c *Client) Land() error {
 
 	c.logger.Println("MAVLink: Sending LAND command")
 
-	return c.node.WriteMessageAll(&common.MessageCommandLong{
+	return c.writeMessage(&common.MessageCommandLong{
 		TargetSystem:    systemID,
 		TargetComponent: 1,
 		Command:         common.MAV_CMD_NAV_LAND,
@@ -891,7 +1628,7 @@ func (c *Client) ReturnToLaunch() error {
 
 	c.logger.Println("MAVLink: Sending RETURN_TO_LAUNCH command")
 
-	return c.node.WriteMessageAll(&common.MessageCommandLong{
+	return c.writeMessage(&common.MessageCommandLong{
 		TargetSystem:    systemID,
 		TargetComponent: 1,
 		Command:         common.MAV_CMD_NAV_RETURN_TO_LAUNCH,
@@ -899,39 +1636,80 @@ func (c *Client) ReturnToLaunch() error {
 }
 
 // Close closes the MAVLink connection
+// It stops the ground station sender, closes the node (which cancels gomavlib's
+// endpoint reconnect loop) and waits for the listener to drain. Safe to call
+// more than once.
 func (c *Client) Close() error {
-	c.logger.Println("MAVLink: Closing connection")
+	c.closeOnce.Do(func() {
+		c.logger.Println("MAVLink: Closing connection")
 
-	// Stop ground station message sender
-	close(c.stopHeartbeat)
+		// Stop re-sending any go-to target
+		c.stopGoTo(false)
 
-	// Wait for goroutine to finish (with timeout)
-	select {
-	case <-c.heartbeatDone:
-		c.logger.Println("MAVLink: Ground station message sender stopped")
-	case <-time.After(2 * time.Second):
-		c.logger.Println("MAVLink: Warning - ground station message sender stop timeout")
-	}
+		// Stop ground station message sender
+		close(c.stopHeartbeat)
 
-	c.mu.Lock()
-	c.connected = false
-	c.mu.Unlock()
+		// Wait for goroutine to finish (with timeout)
+		select {
+		case <-c.heartbeatDone:
+			c.logger.Println("MAVLink: Ground station message sender stopped")
+		case <-time.After(2 * time.Second):
+			c.logger.Println("MAVLink: Warning - ground station message sender stop timeout")
+		}
+
+		// Stop stale-telemetry watchdog
+		close(c.stopWatchdog)
+		select {
+		case <-c.watchdogDone:
+		case <-time.After(2 * time.Second):
+			c.logger.Println("MAVLink: Warning - telemetry watchdog stop timeout")
+		}
+
+		c.mu.Lock()
+		c.connected = false
+		if c.streamRerequest != nil {
+			c.streamRerequest.Stop()
+		}
+		c.mu.Unlock()
+
+		c.node.Close()
+
+		// The listener exits once the node closes its event channel
+		select {
+		case <-c.listenDone:
+		case <-time.After(2 * time.Second):
+			c.logger.Println("MAVLink: Warning - message listener stop timeout")
+		}
+
+		c.saveTrack()
 
-	c.node.Close()
+		// Let subscribers know no more messages will arrive
+		c.statusTexts.close()
+		c.events.close()
+		c.rawMessages.close()
+		c.homeUpdates.close()
+		c.namedValueUpdates.close()
+		c.imuUpdates.close()
+	})
 	return nil
 }
 
-// GetConnectionInfo returns connection information
-func (c *Client) GetConnectionInfo() map[string]interface{} {
-	c.mu.RLock()
-	defer c.mu.RUnlock()
+// CloseTimeout closes the client like Close but stops waiting after timeout
+// Returns false if the close didn't finish in time; it keeps running in the
+// background and releases the port once it does (a wedged node may never).
+// Repeated calls wait on the same close, so they don't pile up goroutines.
+func (c *Client) CloseTimeout(timeout time.Duration) bool {
+	c.closeStart.Do(func() {
+		go func() {
+			c.Close()
+			close(c.closeDone)
+		}()
+	})
 
-	return map[string]interface{}{
-		"port":           c.port,
-		"baud_rate":      c.baudRate,
-		"system_id":      c.systemID,
-		"connected":      c.connected,
-		"armed":          c.armed,
-		"last_heartbeat": c.lastHeartbeat,
+	select {
+	case <-c.closeDone:
+		return true
+	case <-time.After(timeout):
+		return false
 	}
 }
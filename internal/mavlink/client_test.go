@@ -0,0 +1,590 @@
+package mavlink
+
+import (
+	"errors"
+	"io"
+	"log"
+	"net"
+	"path/filepath"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/bluenviron/gomavlib/v3"
+	"github.com/bluenviron/gomavlib/v3/pkg/dialects/common"
+	"github.com/bluenviron/gomavlib/v3/pkg/message"
+
+	drone "github.com/flightpath-dev/flightpath-proto/gen/go/drone/v1"
+)
+
+// newTestClient returns a client with no link, enough to feed messages to its
+// handlers
+func newTestClient() *Client {
+	c := &Client{
+		logger:            log.New(io.Discard, "", 0),
+		visibleSystems:    make(map[uint8]*VisibleSystem),
+		statusTexts:       newBroadcaster[StatusText](),
+		events:            newBroadcaster[Event](),
+		rawMessages:       newBroadcaster[RawMessage](),
+		homeUpdates:       newBroadcaster[HomePosition](),
+		namedValues:       make(map[string]NamedValue),
+		namedValueUpdates: newBroadcaster[NamedValue](),
+		failsafes:         make(map[FailsafeType]*FailsafeEvent),
+		imuUpdates:        newBroadcaster[IMUSample](),
+		telemetry:         TelemetryData{EstimatorHealthy: true},
+
+		pendingAcks:        make(map[common.MAV_CMD]chan *common.MessageCommandAck),
+		commandsInProgress: make(map[common.MAV_CMD]bool),
+		commandAckTimeout:  DefaultCommandAckTimeout,
+		pendingParams:      make(map[string]chan *common.MessageParamValue),
+		params:             paramCache{entries: make(map[string]Parameter)},
+	}
+	c.publishTelemetry()
+	return c
+}
+
+// newConnectedTestClient returns a test client that has heard system 1's
+// autopilot, so it handles that system's messages
+func newConnectedTestClient() *Client {
+	c := newTestClient()
+	c.handleMessage(&common.MessageHeartbeat{
+		Type:      common.MAV_TYPE_QUADROTOR,
+		Autopilot: common.MAV_AUTOPILOT_PX4,
+	}, 1, 1)
+	return c
+}
+
+// newLinkedTestClient returns a test client connected to a vehicle node, which
+// receives everything the client writes
+func newLinkedTestClient(t *testing.T) (*Client, *gomavlib.Node) {
+	t.Helper()
+	vehicleSide, gcsSide := net.Pipe()
+
+	newNode := func(rw io.ReadWriteCloser, systemID uint8) *gomavlib.Node {
+		node, err := gomavlib.NewNode(gomavlib.NodeConf{
+			Endpoints:        []gomavlib.EndpointConf{gomavlib.EndpointCustom{ReadWriteCloser: rw}},
+			Dialect:          common.Dialect,
+			OutVersion:       gomavlib.V2,
+			OutSystemID:      systemID,
+			HeartbeatDisable: true,
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		t.Cleanup(node.Close)
+		return node
+	}
+	vehicle := newNode(vehicleSide, 1)
+
+	c := newConnectedTestClient()
+	c.node = newNode(gcsSide, 255)
+
+	// Writes are dropped until the channel is open
+	timeout := time.After(5 * time.Second)
+	for open := false; !open; {
+		select {
+		case evt := <-c.node.Events():
+			_, open = evt.(*gomavlib.EventChannelOpen)
+		case <-timeout:
+			t.Fatal("channel never opened")
+		}
+	}
+	return c, vehicle
+}
+
+// receive returns the next T the vehicle node receives, skipping other messages
+func receive[T message.Message](t *testing.T, vehicle *gomavlib.Node) T {
+	t.Helper()
+	timeout := time.After(5 * time.Second)
+	for {
+		select {
+		case evt := <-vehicle.Events():
+			if frame, ok := evt.(*gomavlib.EventFrame); ok {
+				if msg, ok := frame.Message().(T); ok {
+					return msg
+				}
+			}
+		case <-timeout:
+			var zero T
+			t.Fatalf("no %T received", zero)
+		}
+	}
+}
+
+func TestHandleHeartbeatIgnoresOtherComponents(t *testing.T) {
+	c := newTestClient()
+
+	autopilot := &common.MessageHeartbeat{
+		Type:         common.MAV_TYPE_QUADROTOR,
+		Autopilot:    common.MAV_AUTOPILOT_PX4,
+		BaseMode:     common.MAV_MODE_FLAG_SAFETY_ARMED | common.MAV_MODE_FLAG_CUSTOM_MODE_ENABLED,
+		CustomMode:   0x04040000, // AUTO.MISSION
+		SystemStatus: common.MAV_STATE_ACTIVE,
+	}
+	c.handleMessage(autopilot, 1, 1)
+
+	tests := []struct {
+		name   string
+		compID uint8
+		msg    *common.MessageHeartbeat
+	}{
+		{"gimbal", 154, &common.MessageHeartbeat{
+			Type:         common.MAV_TYPE_GIMBAL,
+			Autopilot:    common.MAV_AUTOPILOT_INVALID,
+			SystemStatus: common.MAV_STATE_CRITICAL,
+		}},
+		{"camera", 100, &common.MessageHeartbeat{
+			Type:         common.MAV_TYPE_CAMERA,
+			Autopilot:    common.MAV_AUTOPILOT_INVALID,
+			SystemStatus: common.MAV_STATE_STANDBY,
+		}},
+		{"second autopilot component", 2, &common.MessageHeartbeat{
+			Type:         common.MAV_TYPE_QUADROTOR,
+			Autopilot:    common.MAV_AUTOPILOT_PX4,
+			SystemStatus: common.MAV_STATE_STANDBY,
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c.handleMessage(tt.msg, 1, tt.compID)
+
+			telemetry := c.TelemetrySnapshot()
+			if !c.IsArmed() {
+				t.Error("vehicle reported disarmed")
+			}
+			if telemetry.CustomMode != autopilot.CustomMode {
+				t.Errorf("CustomMode = %#x, want %#x", telemetry.CustomMode, autopilot.CustomMode)
+			}
+			if telemetry.SystemStatus != uint8(common.MAV_STATE_ACTIVE) {
+				t.Errorf("SystemStatus = %d, want ACTIVE", telemetry.SystemStatus)
+			}
+			if failsafes := c.GetActiveFailsafes(); len(failsafes) != 0 {
+				t.Errorf("failsafes raised: %v", failsafes)
+			}
+		})
+	}
+}
+
+// Run with -race: several readers hitting the stale-heartbeat transition in
+// IsConnected at once must not race with each other or the message handlers
+func TestHeartbeatsFromTwoSystems(t *testing.T) {
+	heartbeat := &common.MessageHeartbeat{
+		Type:      common.MAV_TYPE_QUADROTOR,
+		Autopilot: common.MAV_AUTOPILOT_PX4,
+	}
+	armed := &common.MessageHeartbeat{
+		Type:      common.MAV_TYPE_QUADROTOR,
+		Autopilot: common.MAV_AUTOPILOT_PX4,
+		BaseMode:  common.MAV_MODE_FLAG_SAFETY_ARMED,
+	}
+
+	// Unbound: the first vehicle heard sticks, the other is only listed
+	c := newTestClient()
+	c.handleMessage(heartbeat, 1, 1)
+	c.handleMessage(armed, 2, 1)
+	info := c.GetConnectionInfo()
+	if info.SystemID != 1 || info.Armed {
+		t.Errorf("bound to system %d (armed %v), want 1 (disarmed)", info.SystemID, info.Armed)
+	}
+	if len(info.VisibleSystems) != 2 || info.VisibleSystems[0].SystemID != 1 || info.VisibleSystems[1].SystemID != 2 {
+		t.Fatalf("visible systems = %+v", info.VisibleSystems)
+	}
+
+	// Bound to system 2: system 1's earlier heartbeat is listed but not followed
+	c = newTestClient()
+	c.targetSystemID = 2
+	c.handleMessage(heartbeat, 1, 1)
+	if c.IsConnected() {
+		t.Fatal("connected to system 1 when bound to 2")
+	}
+	c.handleMessage(armed, 2, 1)
+	c.handleMessage(heartbeat, 1, 1)
+	if info := c.GetConnectionInfo(); info.SystemID != 2 || !info.Armed {
+		t.Errorf("bound to system %d (armed %v), want 2 (armed)", info.SystemID, info.Armed)
+	}
+	systems := c.GetVisibleSystems()
+	if len(systems) != 2 || systems[0].Autopilot != common.MAV_AUTOPILOT_PX4 || systems[0].LastHeartbeat.IsZero() {
+		t.Errorf("visible systems = %+v", systems)
+	}
+}
+
+func TestUploadedWaypoints(t *testing.T) {
+	c, vehicle := newLinkedTestClient(t)
+	v := serveMissions(t, c, vehicle)
+
+	waypoints := []*drone.Waypoint{
+		{Position: &drone.Position{Latitude: 47.3977, Longitude: 8.5456, Altitude: 20}, Action: drone.Waypoint_ACTION_TAKEOFF},
+		{Position: &drone.Position{Latitude: 47.3980, Longitude: 8.5460, Altitude: 30}, Action: drone.Waypoint_ACTION_WAYPOINT, HoldTimeSec: 5},
+		{Position: &drone.Position{Latitude: 47.3977, Longitude: 8.5456}, Action: drone.Waypoint_ACTION_LAND},
+	}
+	if err := c.UploadMission(waypoints); err != nil {
+		t.Fatal(err)
+	}
+
+	got, options, confirmed := c.GetUploadedWaypoints()
+	if len(got) != len(waypoints) || len(options) != len(waypoints) || confirmed {
+		t.Fatalf("got %d waypoints, %d options, confirmed %v", len(got), len(options), confirmed)
+	}
+	for i := range waypoints {
+		if got[i] != waypoints[i] {
+			t.Errorf("waypoint %d = %+v, want %+v", i, got[i], waypoints[i])
+		}
+	}
+
+	// Reading the same mission back confirms it
+	if _, err := c.DownloadMission(); err != nil {
+		t.Fatal(err)
+	}
+	if _, _, confirmed := c.GetUploadedWaypoints(); !confirmed {
+		t.Error("not confirmed by a matching download")
+	}
+
+	// Uploaded again, then changed on the vehicle: not confirmed
+	if err := c.UploadMission(waypoints); err != nil {
+		t.Fatal(err)
+	}
+	v.mu.Lock()
+	v.items[common.MAV_MISSION_TYPE_MISSION][1].Z = 50
+	v.mu.Unlock()
+	if _, err := c.DownloadMission(); err != nil {
+		t.Fatal(err)
+	}
+	if _, _, confirmed := c.GetUploadedWaypoints(); confirmed {
+		t.Error("confirmed by a download that differs")
+	}
+
+	if err := c.ClearMission(); err != nil {
+		t.Fatal(err)
+	}
+	if got, _, _ := c.GetUploadedWaypoints(); len(got) != 0 {
+		t.Errorf("%d waypoints left after ClearMission", len(got))
+	}
+}
+
+func TestIsConnectedConcurrent(t *testing.T) {
+	c := newTestClient()
+	heartbeat := &common.MessageHeartbeat{
+		Type:      common.MAV_TYPE_QUADROTOR,
+		Autopilot: common.MAV_AUTOPILOT_PX4,
+	}
+	c.handleMessage(heartbeat, 1, 1)
+
+	for range 50 {
+		// Connected, but the last heartbeat is too old
+		c.mu.Lock()
+		c.connected = true
+		c.lastHeartbeat = time.Now().Add(-2 * heartbeatTimeout)
+		c.mu.Unlock()
+
+		start := make(chan struct{})
+		var wg sync.WaitGroup
+		for range 4 {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				<-start
+				if c.IsConnected() {
+					t.Error("connected with a stale heartbeat")
+				}
+			}()
+		}
+		close(start)
+		wg.Wait()
+	}
+
+	var wg sync.WaitGroup
+	wg.Add(2)
+	go func() {
+		defer wg.Done()
+		for range 200 {
+			c.IsConnected()
+		}
+	}()
+	go func() {
+		defer wg.Done()
+		for range 200 {
+			c.handleMessage(heartbeat, 1, 1)
+		}
+	}()
+	wg.Wait()
+
+	if !c.IsConnected() {
+		t.Error("not connected after a fresh heartbeat")
+	}
+}
+
+func TestWriteFailuresMarkLinkDown(t *testing.T) {
+	c := newTestClient()
+	c.handleMessage(&common.MessageHeartbeat{
+		Type:      common.MAV_TYPE_QUADROTOR,
+		Autopilot: common.MAV_AUTOPILOT_PX4,
+	}, 1, 1)
+	events, unsubscribe := c.SubscribeEvents()
+	defer unsubscribe()
+
+	writeErr := errors.New("write: broken pipe")
+	for i := 1; i < writeFailureThreshold; i++ {
+		c.recordWriteFailure(writeErr)
+		if !c.IsConnected() {
+			t.Fatalf("disconnected after %d failed writes, want %d", i, writeFailureThreshold)
+		}
+	}
+
+	c.recordWriteFailure(writeErr)
+	if c.IsConnected() || !c.IsWriteDown() {
+		t.Fatalf("connected=%v write_down=%v after %d failed writes", c.IsConnected(), c.IsWriteDown(), writeFailureThreshold)
+	}
+	if evt := <-events; evt.Kind != EventLinkDown {
+		t.Errorf("event = %s, want %s", evt.Kind, EventLinkDown)
+	}
+
+	c.recordWriteSuccess()
+	if !c.IsConnected() || c.IsWriteDown() {
+		t.Errorf("connected=%v write_down=%v after a successful write", c.IsConnected(), c.IsWriteDown())
+	}
+	if evt := <-events; evt.Kind != EventLinkRestored {
+		t.Errorf("event = %s, want %s", evt.Kind, EventLinkRestored)
+	}
+}
+
+// Run with -race: readers load whole snapshots while ATTITUDE updates swap
+// them, so a reader never sees roll, pitch and yaw from different messages
+func TestTelemetrySnapshotConsistent(t *testing.T) {
+	c := newConnectedTestClient()
+	const updates = 2000
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for i := 1; i <= updates; i++ {
+			v := float32(i)
+			c.handleMessage(&common.MessageAttitude{Roll: v, Pitch: v, Yaw: v}, 1, 1)
+		}
+	}()
+
+	var wg sync.WaitGroup
+	for r := 0; r < 4; r++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for {
+				copied := c.GetTelemetry()
+				shared := c.TelemetrySnapshot()
+				for _, tel := range []*TelemetryData{&copied, shared} {
+					if tel.Roll != tel.Pitch || tel.Pitch != tel.Yaw {
+						t.Errorf("torn snapshot: roll=%v pitch=%v yaw=%v", tel.Roll, tel.Pitch, tel.Yaw)
+						return
+					}
+				}
+				select {
+				case <-done:
+					return
+				default:
+				}
+			}
+		}()
+	}
+	wg.Wait()
+
+	if got := c.GetTelemetry().Roll; got != updates {
+		t.Errorf("final roll = %v, want %v", got, updates)
+	}
+}
+
+// telemetrySink keeps benchmark reads from being optimized away
+var telemetrySink atomic.Value
+
+// attitudeWriter handles ATTITUDE messages (1 kHz, well above real rates)
+// until the returned function is called
+func attitudeWriter(c *Client) (stop func()) {
+	done := make(chan struct{})
+	stopped := make(chan struct{})
+	go func() {
+		defer close(stopped)
+		ticker := time.NewTicker(time.Millisecond)
+		defer ticker.Stop()
+		for i := 0; ; i++ {
+			select {
+			case <-done:
+				return
+			case <-ticker.C:
+				c.handleMessage(&common.MessageAttitude{Roll: float32(i)}, 1, 1)
+			}
+		}
+	}()
+	return func() {
+		close(done)
+		<-stopped
+	}
+}
+
+// BenchmarkGetTelemetry compares concurrent reads under a lock (how telemetry
+// was read before the snapshot) with GetTelemetry's atomic snapshot load,
+// while ATTITUDE updates keep arriving. Also runs under -race.
+func BenchmarkGetTelemetry(b *testing.B) {
+	c := newConnectedTestClient()
+	defer attitudeWriter(c)()
+
+	b.Run("locked", func(b *testing.B) {
+		b.RunParallel(func(pb *testing.PB) {
+			var roll float64
+			for pb.Next() {
+				c.mu.RLock()
+				tel := c.telemetry
+				c.mu.RUnlock()
+				roll += tel.Roll
+			}
+			telemetrySink.Store(roll)
+		})
+	})
+	b.Run("snapshot", func(b *testing.B) {
+		b.RunParallel(func(pb *testing.PB) {
+			var roll float64
+			for pb.Next() {
+				tel := c.GetTelemetry()
+				roll += tel.Roll
+			}
+			telemetrySink.Store(roll)
+		})
+	})
+}
+
+// BenchmarkStreamTick compares what each telemetry stream tick costs when it
+// copies the telemetry (GetTelemetry) and when it loads the shared snapshot,
+// with one reader per stream and ATTITUDE updates arriving. Also runs under -race.
+func BenchmarkStreamTick(b *testing.B) {
+	c := newConnectedTestClient()
+	defer attitudeWriter(c)()
+
+	b.Run("copy", func(b *testing.B) {
+		b.ReportAllocs()
+		b.RunParallel(func(pb *testing.PB) {
+			var lastUpdate time.Time
+			updates := 0
+			for pb.Next() {
+				tel := c.GetTelemetry()
+				if tel.LastUpdate.After(lastUpdate) {
+					lastUpdate = tel.LastUpdate
+					updates++
+				}
+			}
+			telemetrySink.Store(float64(updates))
+		})
+	})
+	b.Run("shared", func(b *testing.B) {
+		b.ReportAllocs()
+		b.RunParallel(func(pb *testing.PB) {
+			var lastUpdate time.Time
+			updates := 0
+			for pb.Next() {
+				tel := c.TelemetrySnapshot()
+				if tel.LastUpdate.After(lastUpdate) {
+					lastUpdate = tel.LastUpdate
+					updates++
+				}
+			}
+			telemetrySink.Store(float64(updates))
+		})
+	})
+}
+
+func TestCloseTimeoutWedged(t *testing.T) {
+	// Non-default serial settings open the device lazily, so it needn't exist
+	c, err := NewClient(Config{
+		Port:        filepath.Join(t.TempDir(), "ttyUSB0"),
+		BaudRate:    57600,
+		Serial:      SerialConfig{StopBits: 2},
+		PassiveMode: true,
+		Logger:      log.New(io.Discard, "", 0),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// A goroutine stuck holding the lock wedges Close
+	c.mu.Lock()
+	if c.CloseTimeout(100 * time.Millisecond) {
+		c.mu.Unlock()
+		t.Fatal("wedged close reported finished")
+	}
+	// Later calls wait on the same close rather than starting another
+	if c.CloseTimeout(100 * time.Millisecond) {
+		c.mu.Unlock()
+		t.Fatal("second call reported the wedged close finished")
+	}
+
+	c.mu.Unlock()
+	if !c.CloseTimeout(10 * time.Second) {
+		t.Fatal("close never finished once unblocked")
+	}
+	if !c.CloseTimeout(time.Second) {
+		t.Error("finished close not reported to a later call")
+	}
+}
+
+func TestPositionValid(t *testing.T) {
+	c := newConnectedTestClient()
+	valid := func() bool { return c.GetTelemetry().PositionValid() }
+	position := &common.MessageGlobalPositionInt{Lat: 473977419, Lon: 85455938, Alt: 488000}
+
+	if valid() {
+		t.Fatal("position valid before GLOBAL_POSITION_INT")
+	}
+
+	// Before a fix: estimator output at 0, 0, then a position without a fix
+	c.handleMessage(&common.MessageGpsRawInt{FixType: common.GPS_FIX_TYPE_NO_FIX}, 1, 1)
+	c.handleMessage(&common.MessageGlobalPositionInt{}, 1, 1)
+	if valid() {
+		t.Error("0, 0 reported valid")
+	}
+	c.handleMessage(position, 1, 1)
+	if valid() {
+		t.Error("position valid without a GPS fix")
+	}
+
+	c.handleMessage(&common.MessageGpsRawInt{FixType: common.GPS_FIX_TYPE_3D_FIX, SatellitesVisible: 12}, 1, 1)
+	if !valid() {
+		t.Error("position invalid with a 3D fix")
+	}
+
+	// Without GPS_RAW_INT (no GPS) a global position estimate counts
+	c = newConnectedTestClient()
+	c.handleMessage(position, 1, 1)
+	if !valid() {
+		t.Error("position from a vehicle without GPS invalid")
+	}
+}
+
+func TestGroundStationMessagesSystemTime(t *testing.T) {
+	for _, disabled := range []bool{false, true} {
+		c, vehicle := newLinkedTestClient(t)
+		c.disableSystemTime = disabled
+		c.stopHeartbeat = make(chan struct{})
+		c.heartbeatDone = make(chan struct{})
+		go c.sendGroundStationMessages()
+
+		// One tick's messages: HEARTBEAT first and TIMESYNC last
+		sent := make(map[uint32]bool)
+		timeout := time.After(5 * time.Second)
+		for !sent[(&common.MessageTimesync{}).GetID()] {
+			select {
+			case evt := <-vehicle.Events():
+				if frame, ok := evt.(*gomavlib.EventFrame); ok {
+					sent[frame.Message().GetID()] = true
+				}
+			case <-timeout:
+				t.Fatal("no TIMESYNC sent")
+			}
+		}
+		close(c.stopHeartbeat)
+		<-c.heartbeatDone
+
+		if !sent[(&common.MessageHeartbeat{}).GetID()] {
+			t.Errorf("disabled=%v: HEARTBEAT not sent", disabled)
+		}
+		if got := sent[(&common.MessageSystemTime{}).GetID()]; got == disabled {
+			t.Errorf("disabled=%v: SYSTEM_TIME sent %v", disabled, got)
+		}
+	}
+}
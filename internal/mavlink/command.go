@@ -0,0 +1,355 @@
+package mavlink
+
+import (
+	"fmt"
+	"math"
+	"strings"
+	"time"
+
+	"github.com/bluenviron/gomavlib/v3/pkg/dialects/common"
+	"github.com/bluenviron/gomavlib/v3/pkg/message"
+)
+
+// DefaultCommandAckTimeout bounds the wait for a COMMAND_ACK when the command
+// has no timeout of its own (see Config.CommandAckTimeouts)
+const DefaultCommandAckTimeout = 3 * time.Second
+
+// commandRetryDelay is the pause before re-sending a TEMPORARILY_REJECTED command
+const commandRetryDelay = 500 * time.Millisecond
+
+// CommandRejectedError is returned when the vehicle acknowledges a command
+// with anything other than ACCEPTED or IN_PROGRESS
+type CommandRejectedError struct {
+	Command common.MAV_CMD
+	Result  common.MAV_RESULT
+}
+
+func (e *CommandRejectedError) Error() string {
+	return fmt.Sprintf("%s rejected: %s", e.Command, commandResultName(e.Result))
+}
+
+// ResultName returns the short MAV_RESULT name (e.g. "DENIED")
+func (e *CommandRejectedError) ResultName() string {
+	return commandResultName(e.Result)
+}
+
+// sendCommandInt sends a COMMAND_INT and waits for its COMMAND_ACK
+// Use it for commands that carry a position: latitude/longitude go in x/y as
+// degrees * 1E7 and the frame is explicit, so no precision is lost to float32
+// params as with COMMAND_LONG.
+func (c *Client) sendCommandInt(
+	command common.MAV_CMD,
+	frame common.MAV_FRAME,
+	params [4]float32,
+	x, y int32,
+	z float32,
+) error {
+	// COMMAND_INT has no confirmation field; retries are plain re-sends
+	return c.sendAcknowledged(command, func(systemID, _ uint8) message.Message {
+		return &common.MessageCommandInt{
+			TargetSystem:    systemID,
+			TargetComponent: 1,
+			Frame:           frame,
+			Command:         command,
+			Param1:          params[0],
+			Param2:          params[1],
+			Param3:          params[2],
+			Param4:          params[3],
+			X:               x,
+			Y:               y,
+			Z:               z,
+		}
+	})
+}
+
+// sendCommandLong sends a COMMAND_LONG and waits for its COMMAND_ACK
+// Use it for commands without a position, which some autopilots only accept
+// as COMMAND_LONG.
+func (c *Client) sendCommandLong(command common.MAV_CMD, params [7]float32) error {
+	return c.sendAcknowledged(command, func(systemID, confirmation uint8) message.Message {
+		return &common.MessageCommandLong{
+			TargetSystem:    systemID,
+			TargetComponent: 1,
+			Command:         command,
+			Confirmation:    confirmation,
+			Param1:          params[0],
+			Param2:          params[1],
+			Param3:          params[2],
+			Param4:          params[3],
+			Param5:          params[4],
+			Param6:          params[5],
+			Param7:          params[6],
+		}
+	})
+}
+
+// sendAcknowledged writes the command built for the bound vehicle and waits for its COMMAND_ACK
+// Each transmission waits ackTimeout(command) for its acknowledgement.
+// A TEMPORARILY_REJECTED command is re-sent up to c.commandRetries times. build
+// gets the transmission's confirmation number (0 first, +1 per retry) so the
+// vehicle can tell a retry from a new command.
+func (c *Client) sendAcknowledged(command common.MAV_CMD, build func(systemID, confirmation uint8) message.Message) error {
+	c.mu.Lock()
+	systemID := c.systemID
+	if _, busy := c.pendingAcks[command]; busy {
+		c.mu.Unlock()
+		return fmt.Errorf("%s already awaiting acknowledgement", command)
+	}
+	ack := make(chan *common.MessageCommandAck, 1)
+	c.pendingAcks[command] = ack
+	c.mu.Unlock()
+
+	defer func() {
+		c.mu.Lock()
+		delete(c.pendingAcks, command)
+		c.mu.Unlock()
+	}()
+
+	timeout := c.ackTimeout(command)
+	for confirmation := 0; ; confirmation++ {
+		if err := c.writeMessage(build(systemID, uint8(confirmation))); err != nil {
+			return err
+		}
+
+		select {
+		case msg := <-ack:
+			switch msg.Result {
+			case common.MAV_RESULT_ACCEPTED:
+				return nil
+			case common.MAV_RESULT_IN_PROGRESS:
+				c.markCommandInProgress(command)
+				return nil
+			case common.MAV_RESULT_TEMPORARILY_REJECTED:
+				if confirmation < c.commandRetries {
+					c.logger.Printf("MAVLink: %s temporarily rejected, retrying (confirmation %d)",
+						command, confirmation+1)
+					time.Sleep(commandRetryDelay)
+					continue
+				}
+			}
+			return &CommandRejectedError{Command: command, Result: msg.Result}
+		case <-time.After(timeout):
+			return fmt.Errorf("%s not acknowledged within %s", command, timeout)
+		}
+	}
+}
+
+// ackTimeout returns how long to wait for a command's COMMAND_ACK
+func (c *Client) ackTimeout(command common.MAV_CMD) time.Duration {
+	if timeout, ok := c.commandAckTimeouts[command]; ok {
+		return timeout
+	}
+	return c.commandAckTimeout
+}
+
+// parseCommandAckTimeouts resolves MAV_CMD names (with or without the MAV_CMD_
+// prefix, e.g. "PREFLIGHT_CALIBRATION") to commands
+func parseCommandAckTimeouts(timeouts map[string]time.Duration) (map[common.MAV_CMD]time.Duration, error) {
+	byCommand := make(map[common.MAV_CMD]time.Duration, len(timeouts))
+	for name, timeout := range timeouts {
+		label := strings.ToUpper(name)
+		if !strings.HasPrefix(label, "MAV_CMD_") {
+			label = "MAV_CMD_" + label
+		}
+
+		var command common.MAV_CMD
+		if err := command.UnmarshalText([]byte(label)); err != nil {
+			return nil, fmt.Errorf("unknown command: %s", name)
+		}
+		if timeout <= 0 {
+			return nil, fmt.Errorf("invalid timeout for %s: %s", name, timeout)
+		}
+		byCommand[command] = timeout
+	}
+	return byCommand, nil
+}
+
+// deliverCommandAck hands a COMMAND_ACK to the sender waiting for it
+// A final result also ends the command's in-progress tracking.
+func (c *Client) deliverCommandAck(msg *common.MessageCommandAck) {
+	c.mu.Lock()
+	ack, ok := c.pendingAcks[msg.Command]
+	if msg.Result != common.MAV_RESULT_IN_PROGRESS {
+		delete(c.commandsInProgress, msg.Command)
+	}
+	c.mu.Unlock()
+
+	if !ok {
+		return
+	}
+
+	select {
+	case ack <- msg:
+	default:
+		// Already acknowledged (e.g. IN_PROGRESS followed by ACCEPTED)
+	}
+}
+
+// markCommandInProgress records a long-running command the vehicle is executing
+func (c *Client) markCommandInProgress(command common.MAV_CMD) {
+	c.mu.Lock()
+	c.commandsInProgress[command] = true
+	c.mu.Unlock()
+}
+
+// CancelCommand asks the vehicle to abort a long-running command with COMMAND_CANCEL
+// Only commands still in progress can be cancelled. The vehicle answers with a
+// COMMAND_ACK for the original command (CANCELLED if it stopped); autopilots
+// without COMMAND_CANCEL ignore it, so callers may need a command-specific
+// abort as well.
+func (c *Client) CancelCommand(command common.MAV_CMD) error {
+	if !c.IsConnected() {
+		return fmt.Errorf("not connected to drone")
+	}
+
+	c.mu.Lock()
+	systemID := c.systemID
+	inProgress := c.commandsInProgress[command]
+	delete(c.commandsInProgress, command)
+	c.mu.Unlock()
+
+	if !inProgress {
+		return fmt.Errorf("%s is not in progress", command)
+	}
+
+	c.logger.Printf("MAVLink: Cancelling %s", command)
+
+	return c.writeMessage(&common.MessageCommandCancel{
+		TargetSystem:    systemID,
+		TargetComponent: 1,
+		Command:         command,
+	})
+}
+
+// commandResultName returns a short name for a MAV_RESULT
+func commandResultName(result common.MAV_RESULT) string {
+	switch result {
+	case common.MAV_RESULT_ACCEPTED:
+		return "ACCEPTED"
+	case common.MAV_RESULT_TEMPORARILY_REJECTED:
+		return "TEMPORARILY_REJECTED"
+	case common.MAV_RESULT_DENIED:
+		return "DENIED"
+	case common.MAV_RESULT_UNSUPPORTED:
+		return "UNSUPPORTED"
+	case common.MAV_RESULT_FAILED:
+		return "FAILED"
+	case common.MAV_RESULT_IN_PROGRESS:
+		return "IN_PROGRESS"
+	case common.MAV_RESULT_CANCELLED:
+		return "CANCELLED"
+	default:
+		return "UNKNOWN"
+	}
+}
+
+// Reposition flies to a position with MAV_CMD_DO_REPOSITION
+// Altitude is relative to home. groundSpeed <= 0 uses the vehicle default and
+// a NaN yaw (degrees) keeps the vehicle's heading behavior. Unlike
+// GoToPosition the vehicle switches into its reposition/hold mode itself,
+// so it works from AUTO, HOLD or GUIDED without an OFFBOARD setpoint stream.
+func (c *Client) Reposition(latitude, longitude, altitude, groundSpeed, yaw float64) error {
+	if !c.IsConnected() {
+		return fmt.Errorf("not connected to drone")
+	}
+	if err := c.checkAllowedArea(latitude, longitude); err != nil {
+		return err
+	}
+	altitude, err := c.limitAltitude(altitude)
+	if err != nil {
+		return err
+	}
+
+	c.logger.Printf("MAVLink: Sending REPOSITION command: lat=%.6f, lon=%.6f, alt=%.2f, speed=%.1f, yaw=%.1f",
+		latitude, longitude, altitude, groundSpeed, yaw)
+
+	speed := float32(-1) // Vehicle default
+	if groundSpeed > 0 {
+		speed = float32(groundSpeed)
+	}
+
+	return c.sendCommandInt(
+		common.MAV_CMD_DO_REPOSITION,
+		common.MAV_FRAME_GLOBAL_RELATIVE_ALT_INT,
+		[4]float32{
+			speed,
+			float32(common.MAV_DO_REPOSITION_FLAGS_CHANGE_MODE),
+			0, // Reserved
+			float32(yaw),
+		},
+		int32(latitude*1e7),
+		int32(longitude*1e7),
+		float32(altitude),
+	)
+}
+
+// SetROILocation points the camera/gimbal at a location (altitude relative to home)
+func (c *Client) SetROILocation(latitude, longitude, altitude float64) error {
+	if !c.IsConnected() {
+		return fmt.Errorf("not connected to drone")
+	}
+
+	c.logger.Printf("MAVLink: Setting ROI: lat=%.6f, lon=%.6f, alt=%.2f",
+		latitude, longitude, altitude)
+
+	return c.sendCommandInt(
+		common.MAV_CMD_DO_SET_ROI_LOCATION,
+		common.MAV_FRAME_GLOBAL_RELATIVE_ALT_INT,
+		[4]float32{},
+		int32(latitude*1e7),
+		int32(longitude*1e7),
+		float32(altitude),
+	)
+}
+
+// maxYawRate bounds SetYaw's turn rate (deg/s)
+const maxYawRate = 360
+
+// SetYaw turns the vehicle to a heading with MAV_CMD_CONDITION_YAW
+// heading is degrees (0-360): absolute from north, or an offset from the current
+// heading when relative. rate is deg/s (0 = vehicle default); clockwise picks
+// the turn direction. Works while the vehicle holds position in GUIDED/AUTO.
+func (c *Client) SetYaw(heading float64, relative bool, rate float64, clockwise bool) error {
+	if math.IsNaN(heading) || heading < 0 || heading > 360 {
+		return fmt.Errorf("heading must be 0-360 degrees: %v", heading)
+	}
+	if math.IsNaN(rate) || rate < 0 || rate > maxYawRate {
+		return fmt.Errorf("yaw rate must be 0-%d deg/s: %v", maxYawRate, rate)
+	}
+	if !c.IsConnected() {
+		return fmt.Errorf("not connected to drone")
+	}
+
+	c.logger.Printf("MAVLink: Sending CONDITION_YAW: heading=%.1f, relative=%v, rate=%.1f, clockwise=%v",
+		heading, relative, rate, clockwise)
+
+	direction := float32(-1) // Counter-clockwise
+	if clockwise {
+		direction = 1
+	}
+	frame := float32(0) // Absolute
+	if relative {
+		frame = 1
+	}
+
+	return c.sendCommandLong(common.MAV_CMD_CONDITION_YAW, [7]float32{
+		float32(heading),
+		float32(rate),
+		direction,
+		frame,
+	})
+}
+
+// FlightTerminate cuts the motors with MAV_CMD_DO_FLIGHTTERMINATION
+// Unlike Disarm this is accepted in flight and the vehicle falls: it is the last
+// resort when a vehicle must come down now. PX4 ignores it unless the
+// CBRK_FLIGHTTERM circuit breaker is disabled.
+func (c *Client) FlightTerminate() error {
+	if !c.IsConnected() {
+		return fmt.Errorf("not connected to drone")
+	}
+
+	c.logger.Println("MAVLink: ERROR - Sending FLIGHT TERMINATION command")
+
+	return c.sendCommandLong(common.MAV_CMD_DO_FLIGHTTERMINATION, [7]float32{1}) // 1 = terminate
+}
@@ -0,0 +1,270 @@
+package mavlink
+
+import (
+	"errors"
+	"math"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/bluenviron/gomavlib/v3/pkg/dialects/common"
+)
+
+// inProgress reports whether the client tracks command as in progress
+func inProgress(c *Client, command common.MAV_CMD) bool {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.commandsInProgress[command]
+}
+
+func TestCancelCommand(t *testing.T) {
+	c, vehicle := newLinkedTestClient(t)
+	const command = common.MAV_CMD_PREFLIGHT_CALIBRATION
+
+	if err := c.CancelCommand(command); err == nil {
+		t.Error("cancelled a command that isn't in progress")
+	}
+
+	// The vehicle acknowledges IN_PROGRESS: the command is running
+	result := make(chan error, 1)
+	go func() { result <- c.sendCommandLong(command, [7]float32{1}) }()
+	receive[*common.MessageCommandLong](t, vehicle)
+	c.handleMessage(&common.MessageCommandAck{Command: command, Result: common.MAV_RESULT_IN_PROGRESS}, 1, 1)
+	if err := <-result; err != nil {
+		t.Fatal(err)
+	}
+	if !inProgress(c, command) {
+		t.Fatal("IN_PROGRESS command not tracked")
+	}
+
+	if err := c.CancelCommand(command); err != nil {
+		t.Fatal(err)
+	}
+	msg := receive[*common.MessageCommandCancel](t, vehicle)
+	if msg.Command != command || msg.TargetSystem != 1 {
+		t.Errorf("COMMAND_CANCEL for %s on system %d", msg.Command, msg.TargetSystem)
+	}
+	if inProgress(c, command) {
+		t.Error("cancelled command still tracked")
+	}
+	if err := c.CancelCommand(command); err == nil {
+		t.Error("cancelled the same command twice")
+	}
+}
+
+func TestFinalAckEndsCommandInProgress(t *testing.T) {
+	c := newConnectedTestClient()
+	const command = common.MAV_CMD_PREFLIGHT_CALIBRATION
+
+	c.markCommandInProgress(command)
+	c.handleMessage(&common.MessageCommandAck{Command: command, Result: common.MAV_RESULT_IN_PROGRESS}, 1, 1)
+	if !inProgress(c, command) {
+		t.Fatal("IN_PROGRESS ack ended tracking")
+	}
+
+	c.handleMessage(&common.MessageCommandAck{Command: command, Result: common.MAV_RESULT_ACCEPTED}, 1, 1)
+	if inProgress(c, command) {
+		t.Error("command still tracked after its final ack")
+	}
+	if err := c.CancelCommand(command); err == nil {
+		t.Error("cancelled a finished command")
+	}
+}
+
+// ack answers the command the vehicle received on behalf of the vehicle
+func ack(c *Client, command common.MAV_CMD, result common.MAV_RESULT) {
+	c.handleMessage(&common.MessageCommandAck{Command: command, Result: result}, 1, 1)
+}
+
+func TestArmRetriesWithConfirmation(t *testing.T) {
+	c, vehicle := newLinkedTestClient(t)
+	c.commandRetries = 2
+
+	result := make(chan error, 1)
+	go func() { result <- c.Arm() }()
+
+	for confirmation := uint8(0); confirmation < 2; confirmation++ {
+		msg := receive[*common.MessageCommandLong](t, vehicle)
+		if msg.Command != common.MAV_CMD_COMPONENT_ARM_DISARM || msg.Param1 != 1 {
+			t.Fatalf("sent %s param1=%v", msg.Command, msg.Param1)
+		}
+		if msg.Confirmation != confirmation {
+			t.Errorf("transmission %d has confirmation %d", confirmation, msg.Confirmation)
+		}
+		if confirmation == 0 {
+			ack(c, msg.Command, common.MAV_RESULT_TEMPORARILY_REJECTED)
+		} else {
+			ack(c, msg.Command, common.MAV_RESULT_ACCEPTED)
+		}
+	}
+	if err := <-result; err != nil {
+		t.Errorf("Arm: %v", err)
+	}
+}
+
+func TestDisarmAndSetModeWaitForAck(t *testing.T) {
+	c, vehicle := newLinkedTestClient(t)
+
+	result := make(chan error, 1)
+	go func() { result <- c.Disarm() }()
+	msg := receive[*common.MessageCommandLong](t, vehicle)
+	if msg.Command != common.MAV_CMD_COMPONENT_ARM_DISARM || msg.Param1 != 0 {
+		t.Fatalf("disarm sent %s param1=%v", msg.Command, msg.Param1)
+	}
+	ack(c, msg.Command, common.MAV_RESULT_DENIED)
+	var rejected *CommandRejectedError
+	if err := <-result; !errors.As(err, &rejected) || rejected.ResultName() != "DENIED" {
+		t.Errorf("denied disarm: %v", err)
+	}
+
+	hold := uint32(PX4_MAIN_MODE_AUTO | PX4_AUTO_MODE_LOITER<<16)
+	go func() { result <- c.SetMode(hold) }()
+	msg = receive[*common.MessageCommandLong](t, vehicle)
+	if msg.Command != common.MAV_CMD_DO_SET_MODE ||
+		msg.Param1 != float32(common.MAV_MODE_FLAG_CUSTOM_MODE_ENABLED) || msg.Param2 != float32(hold) {
+		t.Fatalf("set mode sent %s params %v, %v", msg.Command, msg.Param1, msg.Param2)
+	}
+	ack(c, msg.Command, common.MAV_RESULT_ACCEPTED)
+	if err := <-result; err != nil {
+		t.Errorf("SetMode: %v", err)
+	}
+}
+
+func TestAckTimeoutOverride(t *testing.T) {
+	timeouts, err := parseCommandAckTimeouts(map[string]time.Duration{
+		"DO_SET_MODE":                  50 * time.Millisecond,
+		"mav_cmd_component_arm_disarm": time.Minute,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if timeouts[common.MAV_CMD_COMPONENT_ARM_DISARM] != time.Minute {
+		t.Errorf("lowercase prefixed name not resolved: %v", timeouts)
+	}
+	if _, err := parseCommandAckTimeouts(map[string]time.Duration{"NOT_A_COMMAND": time.Second}); err == nil {
+		t.Error("unknown command accepted")
+	}
+
+	c, vehicle := newLinkedTestClient(t)
+	c.commandAckTimeouts = timeouts
+	c.commandAckTimeout = time.Minute
+
+	// Unanswered: SetMode gives up after its own timeout, not the general one
+	start := time.Now()
+	err = c.SetMode(uint32(PX4_MAIN_MODE_AUTO | PX4_AUTO_MODE_LOITER<<16))
+	receive[*common.MessageCommandLong](t, vehicle)
+	if err == nil || !strings.Contains(err.Error(), "within 50ms") {
+		t.Errorf("SetMode = %v, want a 50ms timeout", err)
+	}
+	if elapsed := time.Since(start); elapsed > 5*time.Second {
+		t.Errorf("SetMode waited %s", elapsed)
+	}
+
+	if got := c.ackTimeout(common.MAV_CMD_NAV_LAND); got != time.Minute {
+		t.Errorf("command without an override waits %s, want the general timeout", got)
+	}
+}
+
+func TestCommandIntCarriesScaledPosition(t *testing.T) {
+	c, vehicle := newLinkedTestClient(t)
+
+	result := make(chan error, 1)
+	go func() { result <- c.SetROILocation(47.3977419, 8.5455938, 12.5) }()
+	msg := receive[*common.MessageCommandInt](t, vehicle)
+	if msg.Command != common.MAV_CMD_DO_SET_ROI_LOCATION || msg.Frame != common.MAV_FRAME_GLOBAL_RELATIVE_ALT_INT {
+		t.Fatalf("sent %s in %s", msg.Command, msg.Frame)
+	}
+	// Full 1E7 precision, which float32 COMMAND_LONG params can't hold
+	if msg.X != 473977419 || msg.Y != 85455938 || msg.Z != 12.5 {
+		t.Errorf("position = %d, %d, %v", msg.X, msg.Y, msg.Z)
+	}
+	if msg.TargetSystem != 1 || msg.TargetComponent != 1 {
+		t.Errorf("sent to %d/%d", msg.TargetSystem, msg.TargetComponent)
+	}
+
+	// An acknowledgement for another command doesn't complete it
+	ack(c, common.MAV_CMD_DO_REPOSITION, common.MAV_RESULT_DENIED)
+	select {
+	case err := <-result:
+		t.Fatalf("completed by another command's ack: %v", err)
+	case <-time.After(50 * time.Millisecond):
+	}
+	ack(c, common.MAV_CMD_DO_SET_ROI_LOCATION, common.MAV_RESULT_ACCEPTED)
+	if err := <-result; err != nil {
+		t.Errorf("SetROILocation: %v", err)
+	}
+}
+
+func TestReposition(t *testing.T) {
+	c, vehicle := newLinkedTestClient(t)
+
+	result := make(chan error, 1)
+	go func() { result <- c.Reposition(47.3977419, 8.5455938, 40, 5, 90) }()
+	msg := receive[*common.MessageCommandInt](t, vehicle)
+	if msg.Command != common.MAV_CMD_DO_REPOSITION || msg.Frame != common.MAV_FRAME_GLOBAL_RELATIVE_ALT_INT {
+		t.Fatalf("sent %s in %s", msg.Command, msg.Frame)
+	}
+	if msg.Param1 != 5 || msg.Param2 != float32(common.MAV_DO_REPOSITION_FLAGS_CHANGE_MODE) || msg.Param4 != 90 {
+		t.Errorf("params = %v, %v, %v, %v", msg.Param1, msg.Param2, msg.Param3, msg.Param4)
+	}
+	if msg.X != 473977419 || msg.Y != 85455938 || msg.Z != 40 {
+		t.Errorf("position = %d, %d, %v", msg.X, msg.Y, msg.Z)
+	}
+	ack(c, msg.Command, common.MAV_RESULT_ACCEPTED)
+	if err := <-result; err != nil {
+		t.Errorf("Reposition: %v", err)
+	}
+
+	// No speed: the vehicle's default; a refusal comes back with its result
+	go func() { result <- c.Reposition(47.3977419, 8.5455938, 40, 0, 0) }()
+	if msg := receive[*common.MessageCommandInt](t, vehicle); msg.Param1 != -1 {
+		t.Errorf("default speed sent as %v, want -1", msg.Param1)
+	}
+	ack(c, common.MAV_CMD_DO_REPOSITION, common.MAV_RESULT_UNSUPPORTED)
+	var rejected *CommandRejectedError
+	if err := <-result; !errors.As(err, &rejected) || rejected.ResultName() != "UNSUPPORTED" {
+		t.Errorf("unsupported reposition: %v", err)
+	}
+}
+
+func TestSetYaw(t *testing.T) {
+	c, vehicle := newLinkedTestClient(t)
+
+	tests := []struct {
+		heading, rate       float64
+		relative, clockwise bool
+		want                [4]float32 // heading, rate, direction, relative
+	}{
+		{270, 30, false, true, [4]float32{270, 30, 1, 0}},
+		{45, 0, true, false, [4]float32{45, 0, -1, 1}},
+	}
+	for _, tt := range tests {
+		done := make(chan error, 1)
+		go func() { done <- c.SetYaw(tt.heading, tt.relative, tt.rate, tt.clockwise) }()
+		msg := receive[*common.MessageCommandLong](t, vehicle)
+		got := [4]float32{msg.Param1, msg.Param2, msg.Param3, msg.Param4}
+		if msg.Command != common.MAV_CMD_CONDITION_YAW || got != tt.want {
+			t.Errorf("sent %s with params %v, want %v", msg.Command, got, tt.want)
+		}
+		ack(c, common.MAV_CMD_CONDITION_YAW, common.MAV_RESULT_ACCEPTED)
+		if err := <-done; err != nil {
+			t.Errorf("SetYaw: %v", err)
+		}
+	}
+
+	// Not in a mode that yaws on command
+	done := make(chan error, 1)
+	go func() { done <- c.SetYaw(90, false, 10, true) }()
+	receive[*common.MessageCommandLong](t, vehicle)
+	ack(c, common.MAV_CMD_CONDITION_YAW, common.MAV_RESULT_TEMPORARILY_REJECTED)
+	var rejected *CommandRejectedError
+	if err := <-done; !errors.As(err, &rejected) || rejected.Result != common.MAV_RESULT_TEMPORARILY_REJECTED {
+		t.Errorf("rejected yaw: %v", err)
+	}
+
+	for _, args := range [][2]float64{{-1, 10}, {361, 10}, {math.NaN(), 10}, {90, -1}, {90, maxYawRate + 1}} {
+		if err := c.SetYaw(args[0], false, args[1], true); err == nil {
+			t.Errorf("SetYaw(heading %v, rate %v) accepted", args[0], args[1])
+		}
+	}
+}
@@ -0,0 +1,279 @@
+package mavlink
+
+import (
+	"fmt"
+	"sync/atomic"
+	"time"
+
+	"github.com/bluenviron/gomavlib/v3"
+	"github.com/bluenviron/gomavlib/v3/pkg/dialects/common"
+)
+
+// heartbeatTimeout is how long without a heartbeat before the link is considered down
+const heartbeatTimeout = 3 * time.Second
+
+// writeFailureThreshold is how many consecutive failed writes mark the link down
+// Heartbeats can keep arriving over a link that no longer accepts writes, so
+// IsConnected also reports false until a write succeeds again.
+const writeFailureThreshold = 5
+
+// ConnectionInfo describes the MAVLink link to a drone
+type ConnectionInfo struct {
+	Port          string    `json:"port"`
+	BaudRate      int       `json:"baud_rate"`
+	SystemID      uint8     `json:"system_id"`
+	Connected     bool      `json:"connected"`
+	Armed         bool      `json:"armed"`
+	Passive       bool      `json:"passive"`
+	LastHeartbeat time.Time `json:"last_heartbeat"`
+
+	// Time since the current connection's first heartbeat (0 while disconnected)
+	ConnectedSince time.Time     `json:"connected_since"`
+	Uptime         time.Duration `json:"uptime_ns"`
+	ReconnectCount int           `json:"reconnect_count"`
+
+	// Message counters (all systems on the link)
+	MessagesReceived uint64 `json:"messages_received"`
+	MessagesSent     uint64 `json:"messages_sent"`
+
+	// Consecutive failed writes; the link is down for writing at writeFailureThreshold
+	WriteFailures uint32 `json:"write_failures"`
+	WriteDown     bool   `json:"write_down"`
+
+	// Raw message feed frames missed by slow subscribers
+	RawMessagesDropped uint64 `json:"raw_messages_dropped"`
+
+	// Armed with no position update for the stale-telemetry timeout (see watchTelemetry)
+	TelemetryStale bool `json:"telemetry_stale"`
+
+	// Packet loss from sequence number gaps of the bound vehicle
+	PacketsLost       uint64  `json:"packets_lost"`
+	PacketLossPercent float64 `json:"packet_loss_percent"`
+
+	// From TIMESYNC exchanges (zero until the vehicle answers one)
+	RoundTripTime time.Duration `json:"round_trip_ns"`
+	ClockOffset   time.Duration `json:"clock_offset_ns"` // vehicle clock minus server clock
+
+	// Telemetry radio link quality (from RADIO_STATUS, zero if no radio reports it)
+	RSSI         uint8  `json:"rssi"`
+	RemoteRSSI   uint8  `json:"remote_rssi"`
+	RadioRxError uint16 `json:"radio_rx_errors"`
+
+	// Vehicle identity (from HEARTBEAT and AUTOPILOT_VERSION)
+	Autopilot       common.MAV_AUTOPILOT `json:"autopilot"`
+	VehicleType     common.MAV_TYPE      `json:"vehicle_type"`
+	FirmwareVersion string               `json:"firmware_version,omitempty"`
+
+	// Firmware versions, git hashes and board IDs (see GetAutopilotVersion)
+	Version AutopilotVersion `json:"autopilot_version"`
+
+	// Negotiated MAVLink version * 100 (200 assumed unless reported)
+	ProtocolVersion         uint16 `json:"protocol_version"`
+	ProtocolVersionReported bool   `json:"protocol_version_reported"`
+
+	// What the vehicle supports (see GetCapabilities)
+	Capabilities Capabilities `json:"capabilities"`
+
+	// Every system heard on the link, the bound one included (see GetVisibleSystems)
+	VisibleSystems []VisibleSystem `json:"visible_systems"`
+}
+
+// linkStats holds counters behind ConnectionInfo
+// Message and write-failure counters are atomic because writeMessage is called with and without c.mu
+// held; everything else is guarded by c.mu.
+type linkStats struct {
+	messagesReceived atomic.Uint64
+	messagesSent     atomic.Uint64
+
+	writeFailures atomic.Uint32
+	writeDown     atomic.Bool
+
+	framesFromVehicle uint64
+	packetsLost       uint64
+	lastSequence      map[uint8]uint8 // by component ID
+
+	connectedSince time.Time
+	reconnectCount int
+
+	// For DiagnoseConnectFailure
+	channelOpened bool
+	parseErrors   uint64
+
+	rssi       uint8
+	remoteRSSI uint8
+	rxErrors   uint16
+
+	autopilot   common.MAV_AUTOPILOT
+	vehicleType common.MAV_TYPE
+
+	// Firmware and board identity (see GetAutopilotVersion)
+	autopilotVersion AutopilotVersion
+
+	// AUTOPILOT_VERSION capability bitmask (see GetCapabilities)
+	capabilities         uint64
+	capabilitiesReported bool
+
+	// Negotiated from PROTOCOL_VERSION (see ProtocolVersion)
+	protocolVersion         uint16
+	protocolVersionReported bool
+}
+
+// recordFrame updates link statistics for every received frame
+// RADIO_STATUS comes from the radio's own system ID, so it is read here
+// before messages are filtered by vehicle.
+func (c *Client) recordFrame(frm *gomavlib.EventFrame) {
+	c.stats.messagesReceived.Add(1)
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if radio, ok := frm.Message().(*common.MessageRadioStatus); ok {
+		c.stats.rssi = radio.Rssi
+		c.stats.remoteRSSI = radio.Remrssi
+		c.stats.rxErrors = radio.Rxerrors
+	}
+
+	if c.systemID == 0 || frm.SystemID() != c.systemID {
+		return
+	}
+
+	seq := frm.Frame.GetSequenceNumber()
+	compID := frm.ComponentID()
+	if last, ok := c.stats.lastSequence[compID]; ok {
+		// Sequence numbers wrap at 256
+		c.stats.packetsLost += uint64(seq - last - 1)
+	}
+	c.stats.lastSequence[compID] = seq
+	c.stats.framesFromVehicle++
+}
+
+// recordHeartbeat tracks uptime and reconnects
+// Caller must hold c.mu, before updating lastHeartbeat
+func (c *Client) recordHeartbeat(msg *common.MessageHeartbeat, now time.Time) {
+	wasConnected := c.connected && now.Sub(c.lastHeartbeat) <= heartbeatTimeout
+	if !wasConnected {
+		if !c.stats.connectedSince.IsZero() {
+			c.stats.reconnectCount++
+		}
+		c.stats.connectedSince = now
+	}
+
+	c.stats.autopilot = msg.Autopilot
+	c.stats.vehicleType = msg.Type
+}
+
+// recordWriteFailure counts a failed write and marks the link down at the threshold
+// Does not take c.mu: writeMessage is called with and without it held.
+func (c *Client) recordWriteFailure(err error) {
+	failures := c.stats.writeFailures.Add(1)
+	if failures < writeFailureThreshold || !c.stats.writeDown.CompareAndSwap(false, true) {
+		return
+	}
+
+	message := fmt.Sprintf("Link down: %d consecutive writes failed (last: %v)", failures, err)
+	c.logger.Printf("MAVLink: ERROR - %s", message)
+	c.publishEvent(Event{
+		Kind:     EventLinkDown,
+		Severity: common.MAV_SEVERITY_CRITICAL,
+		Message:  message,
+	})
+}
+
+// IsWriteDown reports whether the link stopped accepting writes
+// (writeFailureThreshold consecutive failures, none succeeding since)
+func (c *Client) IsWriteDown() bool {
+	return c.stats.writeDown.Load()
+}
+
+// recordWriteSuccess resets the failure count and clears a write-down link
+func (c *Client) recordWriteSuccess() {
+	c.stats.writeFailures.Store(0)
+	if !c.stats.writeDown.CompareAndSwap(true, false) {
+		return
+	}
+
+	message := "Link restored: writes succeeding again"
+	c.logger.Printf("MAVLink: %s", message)
+	c.publishEvent(Event{
+		Kind:     EventLinkRestored,
+		Severity: common.MAV_SEVERITY_INFO,
+		Message:  message,
+	})
+}
+
+// handleAutopilotVersion processes AUTOPILOT_VERSION messages
+func (c *Client) handleAutopilotVersion(msg *common.MessageAutopilotVersion) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.stats.autopilotVersion = AutopilotVersionFrom(msg)
+	c.stats.autopilotVersion.Reported = true
+	c.stats.capabilities = uint64(msg.Capabilities)
+	c.stats.capabilitiesReported = true
+}
+
+// requestAutopilotVersion asks the vehicle for its AUTOPILOT_VERSION message
+func (c *Client) requestAutopilotVersion() error {
+	return c.requestMessage(&common.MessageAutopilotVersion{})
+}
+
+// GetConnectionInfo returns connection information and link statistics
+func (c *Client) GetConnectionInfo() ConnectionInfo {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	now := time.Now()
+	writeDown := c.stats.writeDown.Load()
+	connected := c.connected && now.Sub(c.lastHeartbeat) <= heartbeatTimeout && !writeDown
+
+	info := ConnectionInfo{
+		Port:          c.port,
+		BaudRate:      c.baudRate,
+		SystemID:      c.systemID,
+		Connected:     connected,
+		Armed:         c.armed,
+		Passive:       c.passive,
+		LastHeartbeat: c.lastHeartbeat,
+
+		ReconnectCount: c.stats.reconnectCount,
+
+		MessagesReceived: c.stats.messagesReceived.Load(),
+		MessagesSent:     c.stats.messagesSent.Load(),
+		WriteFailures:    c.stats.writeFailures.Load(),
+		WriteDown:        writeDown,
+
+		RawMessagesDropped: c.rawMessages.dropped.Load(),
+
+		TelemetryStale: c.telemetryStale,
+
+		PacketsLost: c.stats.packetsLost,
+
+		RoundTripTime: c.timesync.rtt,
+		ClockOffset:   c.timesync.offset,
+
+		RSSI:         c.stats.rssi,
+		RemoteRSSI:   c.stats.remoteRSSI,
+		RadioRxError: c.stats.rxErrors,
+
+		Autopilot:       c.stats.autopilot,
+		VehicleType:     c.stats.vehicleType,
+		FirmwareVersion: c.stats.autopilotVersion.FlightSoftware,
+		Version:         c.stats.autopilotVersion,
+		Capabilities:    c.capabilitiesLocked(),
+
+		VisibleSystems: c.visibleSystemsLocked(),
+	}
+
+	info.ProtocolVersion, info.ProtocolVersionReported = c.protocolVersionLocked()
+
+	if connected {
+		info.ConnectedSince = c.stats.connectedSince
+		info.Uptime = now.Sub(c.stats.connectedSince)
+	}
+
+	if total := c.stats.framesFromVehicle + c.stats.packetsLost; total > 0 {
+		info.PacketLossPercent = float64(c.stats.packetsLost) / float64(total) * 100
+	}
+
+	return info
+}
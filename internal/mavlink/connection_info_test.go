@@ -0,0 +1,76 @@
+package mavlink
+
+import (
+	"testing"
+	"time"
+
+	"github.com/bluenviron/gomavlib/v3"
+	"github.com/bluenviron/gomavlib/v3/pkg/dialects/common"
+	"github.com/bluenviron/gomavlib/v3/pkg/frame"
+	"github.com/bluenviron/gomavlib/v3/pkg/message"
+)
+
+// receiveFrame passes msg to c as its listener would a frame from sysID
+func receiveFrame(c *Client, msg message.Message, sysID uint8, seq uint8) {
+	evt := &gomavlib.EventFrame{Frame: &frame.V2Frame{
+		SequenceNumber: seq,
+		SystemID:       sysID,
+		ComponentID:    1,
+		Message:        msg,
+	}}
+	c.recordFrame(evt)
+	c.handleMessage(evt.Message(), sysID, 1)
+}
+
+func TestConnectionInfoCounters(t *testing.T) {
+	c, _ := newLinkedTestClient(t)
+	c.stats.lastSequence = make(map[uint8]uint8)
+	heartbeat := &common.MessageHeartbeat{
+		Type:      common.MAV_TYPE_QUADROTOR,
+		Autopilot: common.MAV_AUTOPILOT_PX4,
+	}
+
+	before := c.GetConnectionInfo()
+	receiveFrame(c, heartbeat, 1, 10)
+	receiveFrame(c, &common.MessageAttitude{}, 1, 11)
+	// Frames 12 and 13 lost
+	receiveFrame(c, &common.MessageAttitude{}, 1, 14)
+	// From the radio, not the vehicle: counted, but not a sequence gap
+	receiveFrame(c, &common.MessageRadioStatus{Rssi: 180, Remrssi: 170, Rxerrors: 3}, 51, 200)
+
+	info := c.GetConnectionInfo()
+	if got := info.MessagesReceived - before.MessagesReceived; got != 4 {
+		t.Errorf("messages received grew by %d, want 4", got)
+	}
+	if info.PacketsLost != 2 || info.PacketLossPercent != 40 {
+		t.Errorf("lost %d packets (%.0f%%), want 2 (40%%)", info.PacketsLost, info.PacketLossPercent)
+	}
+	if info.RSSI != 180 || info.RemoteRSSI != 170 || info.RadioRxError != 3 {
+		t.Errorf("radio = %d/%d/%d", info.RSSI, info.RemoteRSSI, info.RadioRxError)
+	}
+	if info.Autopilot != common.MAV_AUTOPILOT_PX4 || info.VehicleType != common.MAV_TYPE_QUADROTOR {
+		t.Errorf("identity = %s %s", info.Autopilot, info.VehicleType)
+	}
+	if !info.Connected || info.ConnectedSince.IsZero() {
+		t.Errorf("connected %v since %v", info.Connected, info.ConnectedSince)
+	}
+
+	if err := c.writeMessage(&common.MessageHeartbeat{Type: common.MAV_TYPE_GCS}); err != nil {
+		t.Fatal(err)
+	}
+	if got := c.GetConnectionInfo().MessagesSent - before.MessagesSent; got != 1 {
+		t.Errorf("messages sent grew by %d, want 1", got)
+	}
+
+	// Heartbeats lost for longer than the timeout, then back: a reconnect
+	c.mu.Lock()
+	c.lastHeartbeat = time.Now().Add(-2 * heartbeatTimeout)
+	c.mu.Unlock()
+	if c.GetConnectionInfo().Connected {
+		t.Fatal("still connected without heartbeats")
+	}
+	receiveFrame(c, heartbeat, 1, 15)
+	if info := c.GetConnectionInfo(); info.ReconnectCount != before.ReconnectCount+1 || !info.Connected {
+		t.Errorf("reconnects = %d (connected %v), want %d", info.ReconnectCount, info.Connected, before.ReconnectCount+1)
+	}
+}
@@ -0,0 +1,74 @@
+package mavlink
+
+import (
+	"errors"
+	"fmt"
+	"os"
+
+	"go.bug.st/serial"
+)
+
+// Connect failure causes, distinguishable with errors.Is
+var (
+	ErrDeviceNotFound = errors.New("serial device not found")
+	ErrPortOpenFailed = errors.New("could not open port")
+	ErrNotMAVLink     = errors.New("port opened, receiving data that is not MAVLink")
+	ErrNoHeartbeat    = errors.New("port opened, no heartbeat")
+)
+
+// DiagnoseConnectFailure explains why WaitForConnection timed out
+// Call it after Close, so probing the port doesn't race gomavlib's reconnect loop.
+// The returned error wraps one of ErrDeviceNotFound, ErrPortOpenFailed,
+// ErrNotMAVLink or ErrNoHeartbeat and carries a hint for the operator.
+func (c *Client) DiagnoseConnectFailure() error {
+	c.mu.RLock()
+	opened := c.stats.channelOpened
+	parseErrors := c.stats.parseErrors
+	c.mu.RUnlock()
+
+	frames := c.stats.messagesReceived.Load()
+
+	switch {
+	case !opened:
+		return probeSerialDevice(c.port, c.baudRate)
+	case frames == 0 && parseErrors > 0:
+		return fmt.Errorf("%w on %s (%d parse errors): check the baud rate (%d)",
+			ErrNotMAVLink, c.port, parseErrors, c.baudRate)
+	case frames > 0:
+		return fmt.Errorf("%w from a vehicle on %s: %d MAVLink messages received but none from a vehicle this client accepts (check system_id)",
+			ErrNoHeartbeat, c.port, frames)
+	default:
+		return fmt.Errorf("%w on %s: nothing received; check wiring and that the vehicle is powered",
+			ErrNoHeartbeat, c.port)
+	}
+}
+
+// CheckSerialDevice verifies a serial device exists and can be opened
+// The error wraps ErrDeviceNotFound or ErrPortOpenFailed.
+func CheckSerialDevice(device string, baudRate int) error {
+	if _, err := os.Stat(device); err != nil {
+		if os.IsNotExist(err) {
+			return fmt.Errorf("%w: %s (is the radio/USB cable plugged in?)", ErrDeviceNotFound, device)
+		}
+		return fmt.Errorf("%w %s: %v", ErrPortOpenFailed, device, err)
+	}
+
+	port, err := serial.Open(device, &serial.Mode{BaudRate: baudRate})
+	if err != nil {
+		return fmt.Errorf("%w %s: %v (check permissions and that no other program is using it)",
+			ErrPortOpenFailed, device, err)
+	}
+	port.Close()
+	return nil
+}
+
+// probeSerialDevice reports why a serial device can't be opened
+func probeSerialDevice(device string, baudRate int) error {
+	if err := CheckSerialDevice(device, baudRate); err != nil {
+		return err
+	}
+
+	// Opens now; the link was never established while the client was running
+	return fmt.Errorf("%w %s while connecting, but it opens now; retry the connection",
+		ErrPortOpenFailed, device)
+}
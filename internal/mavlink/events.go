@@ -0,0 +1,123 @@
+package mavlink
+
+import (
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/bluenviron/gomavlib/v3/pkg/dialects/common"
+)
+
+// subscriberBuffer is the per-subscriber queue length; slow subscribers drop messages
+const subscriberBuffer = 32
+
+// broadcaster fans values out to subscribers without blocking the publisher
+type broadcaster[T any] struct {
+	mu     sync.Mutex
+	subs   map[chan T]struct{}
+	closed bool
+
+	// Values not delivered because a subscriber's queue was full
+	dropped atomic.Uint64
+}
+
+func newBroadcaster[T any]() *broadcaster[T] {
+	return &broadcaster[T]{subs: make(map[chan T]struct{})}
+}
+
+// subscribe returns a channel receiving published values and an unsubscribe func
+// The channel is closed on unsubscribe or when the broadcaster closes.
+func (b *broadcaster[T]) subscribe() (<-chan T, func()) {
+	ch := make(chan T, subscriberBuffer)
+
+	b.mu.Lock()
+	if b.closed {
+		close(ch)
+	} else {
+		b.subs[ch] = struct{}{}
+	}
+	b.mu.Unlock()
+
+	unsubscribe := func() {
+		b.mu.Lock()
+		defer b.mu.Unlock()
+		if _, ok := b.subs[ch]; ok {
+			delete(b.subs, ch)
+			close(ch)
+		}
+	}
+
+	return ch, unsubscribe
+}
+
+// publish delivers v to every subscriber that has room for it
+func (b *broadcaster[T]) publish(v T) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	for ch := range b.subs {
+		select {
+		case ch <- v:
+		default:
+			// Subscriber is not keeping up
+			b.dropped.Add(1)
+		}
+	}
+}
+
+// hasSubscribers reports whether anyone is subscribed
+// Lets publishers skip building values nobody will receive.
+func (b *broadcaster[T]) hasSubscribers() bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return len(b.subs) > 0
+}
+
+// close closes every subscriber channel; later subscribers get a closed channel
+func (b *broadcaster[T]) close() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	for ch := range b.subs {
+		close(ch)
+		delete(b.subs, ch)
+	}
+	b.closed = true
+}
+
+// EventKind identifies what an Event reports
+type EventKind string
+
+const (
+	EventFailsafe         EventKind = "failsafe"
+	EventTelemetryStale   EventKind = "telemetry_stale"
+	EventTelemetryResumed EventKind = "telemetry_resumed"
+	EventLinkDown         EventKind = "link_down"
+	EventLinkRestored     EventKind = "link_restored"
+	EventTargetReached    EventKind = "target_reached"
+	EventSetpointLagging  EventKind = "setpoint_lagging"
+	EventSetpointHandoff  EventKind = "setpoint_handoff"
+)
+
+// Event is a notable vehicle or link occurrence
+type Event struct {
+	Kind     EventKind           `json:"kind"`
+	Severity common.MAV_SEVERITY `json:"severity"`
+	Message  string              `json:"message"`
+	Time     time.Time           `json:"time"`
+
+	// Set for EventFailsafe
+	Failsafe *FailsafeEvent `json:"failsafe,omitempty"`
+}
+
+// SubscribeEvents returns a channel receiving vehicle events
+// Call the returned function to unsubscribe; the channel is closed afterwards.
+func (c *Client) SubscribeEvents() (<-chan Event, func()) {
+	return c.events.subscribe()
+}
+
+// publishEvent timestamps and publishes an event to all subscribers
+func (c *Client) publishEvent(evt Event) {
+	evt.Time = time.Now()
+	c.events.publish(evt)
+}
@@ -0,0 +1,248 @@
+package mavlink
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/bluenviron/gomavlib/v3/pkg/dialects/common"
+)
+
+// FailsafeType is the condition that triggered a failsafe
+type FailsafeType string
+
+const (
+	FailsafeRCLoss  FailsafeType = "rc_loss"
+	FailsafeGCSLoss FailsafeType = "gcs_loss"
+	FailsafeBattery FailsafeType = "battery"
+	// FailsafeUnknown is reported when the vehicle enters a critical state
+	// without a recognizable failsafe message
+	FailsafeUnknown FailsafeType = "unknown"
+)
+
+// FailsafeAction is what the vehicle does in response to a failsafe
+type FailsafeAction string
+
+const (
+	FailsafeActionUnknown   FailsafeAction = "unknown"
+	FailsafeActionWarn      FailsafeAction = "warn"
+	FailsafeActionHold      FailsafeAction = "hold"
+	FailsafeActionRTL       FailsafeAction = "rtl"
+	FailsafeActionLand      FailsafeAction = "land"
+	FailsafeActionTerminate FailsafeAction = "terminate"
+)
+
+// FailsafeEvent describes a failsafe becoming active, changing action, or clearing
+type FailsafeEvent struct {
+	Type   FailsafeType   `json:"type"`
+	Action FailsafeAction `json:"action"`
+	Active bool           `json:"active"`
+
+	// STATUSTEXT that reported it, if any
+	Text string `json:"text,omitempty"`
+}
+
+// classifyFailsafeText recognizes PX4 and ArduPilot failsafe STATUSTEXT messages
+func classifyFailsafeText(text string) (FailsafeEvent, bool) {
+	lower := strings.ToLower(text)
+
+	var fsType FailsafeType
+	switch {
+	case strings.Contains(lower, "radio failsafe"),
+		strings.Contains(lower, "rc failsafe"),
+		strings.Contains(lower, "manual control lost"),
+		strings.Contains(lower, "manual control regained"),
+		strings.Contains(lower, "rc signal lost"),
+		strings.Contains(lower, "rc lost"),
+		strings.Contains(lower, "rc regained"):
+		fsType = FailsafeRCLoss
+
+	case strings.Contains(lower, "gcs failsafe"),
+		strings.Contains(lower, "data link lost"),
+		strings.Contains(lower, "data link regained"),
+		strings.Contains(lower, "connection to ground station lost"),
+		strings.Contains(lower, "connection to ground station regained"),
+		strings.Contains(lower, "gcs connection lost"):
+		fsType = FailsafeGCSLoss
+
+	case strings.Contains(lower, "battery failsafe"),
+		strings.Contains(lower, "low battery"),
+		strings.Contains(lower, "critical battery"),
+		strings.Contains(lower, "emergency battery"),
+		strings.Contains(lower, "battery level"):
+		fsType = FailsafeBattery
+
+	default:
+		return FailsafeEvent{}, false
+	}
+
+	active := !(strings.Contains(lower, "cleared") ||
+		strings.Contains(lower, "regained") ||
+		strings.Contains(lower, "restored"))
+
+	return FailsafeEvent{
+		Type:   fsType,
+		Action: failsafeActionFromText(lower),
+		Active: active,
+		Text:   text,
+	}, true
+}
+
+// failsafeActionFromText extracts the announced failsafe action, if any
+func failsafeActionFromText(lower string) FailsafeAction {
+	switch {
+	case strings.Contains(lower, "terminat"):
+		return FailsafeActionTerminate
+	case strings.Contains(lower, "return"), strings.Contains(lower, "rtl"):
+		return FailsafeActionRTL
+	case strings.Contains(lower, "land"):
+		return FailsafeActionLand
+	case strings.Contains(lower, "hold"), strings.Contains(lower, "loiter"):
+		return FailsafeActionHold
+	case strings.Contains(lower, "warning"):
+		return FailsafeActionWarn
+	default:
+		return FailsafeActionUnknown
+	}
+}
+
+// failsafeActionFromMode infers the failsafe action from the flight mode the vehicle switched to
+func failsafeActionFromMode(customMode uint32) FailsafeAction {
+	mainMode := customMode & 0xFF
+	subMode := (customMode >> 16) & 0xFF
+
+	if mainMode != PX4_MAIN_MODE_AUTO {
+		return FailsafeActionUnknown
+	}
+
+	switch subMode {
+	case PX4_AUTO_MODE_RTL:
+		return FailsafeActionRTL
+	case PX4_AUTO_MODE_LAND, PX4_AUTO_MODE_PRECLAND:
+		return FailsafeActionLand
+	case PX4_AUTO_MODE_LOITER:
+		return FailsafeActionHold
+	default:
+		return FailsafeActionUnknown
+	}
+}
+
+// GetActiveFailsafes returns the failsafes currently active on the vehicle
+func (c *Client) GetActiveFailsafes() []FailsafeEvent {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	active := make([]FailsafeEvent, 0, len(c.failsafes))
+	for _, fs := range c.failsafes {
+		active = append(active, *fs)
+	}
+	return active
+}
+
+// handleFailsafeText updates failsafe state from a STATUSTEXT message
+func (c *Client) handleFailsafeText(text string) {
+	evt, ok := classifyFailsafeText(text)
+	if !ok {
+		return
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if evt.Active && evt.Action == FailsafeActionUnknown {
+		// The mode switch may already have told us what the vehicle is doing
+		evt.Action = failsafeActionFromMode(c.telemetry.CustomMode)
+	}
+	c.setFailsafeLocked(evt)
+}
+
+// handleRCReceiverHealth raises or clears an RC-loss failsafe from SYS_STATUS
+// Caller must hold c.mu
+func (c *Client) handleRCReceiverHealth(msg *common.MessageSysStatus) {
+	rc := common.MAV_SYS_STATUS_SENSOR_RC_RECEIVER
+	if msg.OnboardControlSensorsPresent&rc == 0 || msg.OnboardControlSensorsEnabled&rc == 0 {
+		return
+	}
+
+	healthy := msg.OnboardControlSensorsHealth&rc != 0
+	_, active := c.failsafes[FailsafeRCLoss]
+
+	switch {
+	case !healthy && !active:
+		c.setFailsafeLocked(FailsafeEvent{
+			Type:   FailsafeRCLoss,
+			Action: failsafeActionFromMode(c.telemetry.CustomMode),
+			Active: true,
+		})
+	case healthy && active:
+		c.setFailsafeLocked(FailsafeEvent{Type: FailsafeRCLoss})
+	}
+}
+
+// handleFailsafeModeChange fills in unknown failsafe actions after a mode switch
+// and raises an unknown failsafe when the vehicle goes critical unexplained.
+// Caller must hold c.mu
+func (c *Client) handleFailsafeModeChange(customMode uint32, systemStatus common.MAV_STATE) {
+	if action := failsafeActionFromMode(customMode); action != FailsafeActionUnknown {
+		for _, fs := range c.failsafes {
+			if fs.Action == FailsafeActionUnknown {
+				updated := *fs
+				updated.Action = action
+				c.setFailsafeLocked(updated)
+			}
+		}
+	}
+
+	critical := systemStatus == common.MAV_STATE_CRITICAL || systemStatus == common.MAV_STATE_EMERGENCY
+	_, unknownActive := c.failsafes[FailsafeUnknown]
+
+	switch {
+	case critical && len(c.failsafes) == 0:
+		c.setFailsafeLocked(FailsafeEvent{
+			Type:   FailsafeUnknown,
+			Action: failsafeActionFromMode(customMode),
+			Active: true,
+		})
+	case !critical && unknownActive:
+		c.setFailsafeLocked(FailsafeEvent{Type: FailsafeUnknown})
+	}
+}
+
+// setFailsafeLocked records a failsafe transition and publishes it if anything changed
+// Caller must hold c.mu
+func (c *Client) setFailsafeLocked(evt FailsafeEvent) {
+	current, active := c.failsafes[evt.Type]
+
+	if !evt.Active {
+		if !active {
+			return
+		}
+		evt.Action = current.Action
+		delete(c.failsafes, evt.Type)
+	} else {
+		if active && current.Action == evt.Action {
+			return
+		}
+		if active && evt.Action == FailsafeActionUnknown {
+			// Never downgrade a known action
+			return
+		}
+		stored := evt
+		c.failsafes[evt.Type] = &stored
+	}
+
+	severity := common.MAV_SEVERITY_CRITICAL
+	message := fmt.Sprintf("Failsafe %s active (action: %s)", evt.Type, evt.Action)
+	if !evt.Active {
+		severity = common.MAV_SEVERITY_INFO
+		message = fmt.Sprintf("Failsafe %s cleared", evt.Type)
+	}
+
+	c.logger.Printf("MAVLink: %s", message)
+
+	c.publishEvent(Event{
+		Kind:     EventFailsafe,
+		Severity: severity,
+		Message:  message,
+		Failsafe: &evt,
+	})
+}
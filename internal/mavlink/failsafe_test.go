@@ -0,0 +1,199 @@
+package mavlink
+
+import (
+	"testing"
+
+	"github.com/bluenviron/gomavlib/v3/pkg/dialects/common"
+)
+
+func TestClassifyFailsafeText(t *testing.T) {
+	tests := []struct {
+		text   string
+		want   FailsafeType
+		action FailsafeAction
+		active bool
+	}{
+		{"Failsafe enabled: No manual control stick input", "", "", false},
+		{"Manual control lost", FailsafeRCLoss, FailsafeActionUnknown, true},
+		{"Radio Failsafe - Returning to launch", FailsafeRCLoss, FailsafeActionRTL, true},
+		{"RC regained", FailsafeRCLoss, FailsafeActionUnknown, false},
+		{"Data link lost", FailsafeGCSLoss, FailsafeActionUnknown, true},
+		{"GCS Failsafe: Landing", FailsafeGCSLoss, FailsafeActionLand, true},
+		{"Connection to ground station regained", FailsafeGCSLoss, FailsafeActionUnknown, false},
+		{"Low battery level! Return advised", FailsafeBattery, FailsafeActionRTL, true},
+		{"Critical battery level! Landing", FailsafeBattery, FailsafeActionLand, true},
+		{"Emergency battery level: flight termination", FailsafeBattery, FailsafeActionTerminate, true},
+		{"Battery failsafe cleared", FailsafeBattery, FailsafeActionUnknown, false},
+		{"Takeoff detected", "", "", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.text, func(t *testing.T) {
+			evt, ok := classifyFailsafeText(tt.text)
+			if ok != (tt.want != "") {
+				t.Fatalf("recognized = %v, want %v", ok, tt.want != "")
+			}
+			if !ok {
+				return
+			}
+			if evt.Type != tt.want || evt.Action != tt.action || evt.Active != tt.active {
+				t.Errorf("got %s/%s active=%v, want %s/%s active=%v",
+					evt.Type, evt.Action, evt.Active, tt.want, tt.action, tt.active)
+			}
+			if evt.Text != tt.text {
+				t.Errorf("text = %q", evt.Text)
+			}
+		})
+	}
+}
+
+// nextFailsafe returns the next failsafe event published, failing if there is none
+func nextFailsafe(t *testing.T, events <-chan Event) FailsafeEvent {
+	t.Helper()
+	select {
+	case evt := <-events:
+		if evt.Kind != EventFailsafe || evt.Failsafe == nil {
+			t.Fatalf("event = %s, want a failsafe", evt.Kind)
+		}
+		return *evt.Failsafe
+	default:
+		t.Fatal("no failsafe event published")
+		return FailsafeEvent{}
+	}
+}
+
+// noEvent fails if an event was published
+func noEvent(t *testing.T, events <-chan Event) {
+	t.Helper()
+	select {
+	case evt := <-events:
+		t.Fatalf("unexpected %s event: %s", evt.Kind, evt.Message)
+	default:
+	}
+}
+
+func TestFailsafeTextEvents(t *testing.T) {
+	tests := []struct {
+		name   string
+		raise  string
+		clear  string
+		fsType FailsafeType
+		action FailsafeAction
+	}{
+		{"rc", "RC signal lost - return to launch", "RC regained", FailsafeRCLoss, FailsafeActionRTL},
+		{"gcs", "Data link lost: holding", "Data link regained", FailsafeGCSLoss, FailsafeActionHold},
+		{"battery", "Low battery - landing", "Battery failsafe cleared", FailsafeBattery, FailsafeActionLand},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := newConnectedTestClient()
+			events, unsubscribe := c.SubscribeEvents()
+			defer unsubscribe()
+
+			c.handleMessage(&common.MessageStatustext{Severity: common.MAV_SEVERITY_CRITICAL, Text: tt.raise}, 1, 1)
+			evt := nextFailsafe(t, events)
+			if evt.Type != tt.fsType || !evt.Active || evt.Action != tt.action {
+				t.Errorf("raised: %+v", evt)
+			}
+			if active := c.GetActiveFailsafes(); len(active) != 1 || active[0].Type != tt.fsType {
+				t.Errorf("active failsafes = %+v", active)
+			}
+
+			// Repeats don't publish again
+			c.handleMessage(&common.MessageStatustext{Severity: common.MAV_SEVERITY_CRITICAL, Text: tt.raise}, 1, 1)
+			noEvent(t, events)
+
+			c.handleMessage(&common.MessageStatustext{Severity: common.MAV_SEVERITY_INFO, Text: tt.clear}, 1, 1)
+			evt = nextFailsafe(t, events)
+			if evt.Type != tt.fsType || evt.Active || evt.Action != tt.action {
+				t.Errorf("cleared: %+v", evt)
+			}
+			if active := c.GetActiveFailsafes(); len(active) != 0 {
+				t.Errorf("active failsafes after clearing = %+v", active)
+			}
+		})
+	}
+}
+
+func TestHandleRCReceiverHealth(t *testing.T) {
+	rc := common.MAV_SYS_STATUS_SENSOR_RC_RECEIVER
+	sysStatus := func(present, healthy bool) *common.MessageSysStatus {
+		msg := &common.MessageSysStatus{BatteryRemaining: -1}
+		if present {
+			msg.OnboardControlSensorsPresent = rc
+			msg.OnboardControlSensorsEnabled = rc
+		}
+		if healthy {
+			msg.OnboardControlSensorsHealth = rc
+		}
+		return msg
+	}
+
+	c := newConnectedTestClient()
+	events, unsubscribe := c.SubscribeEvents()
+	defer unsubscribe()
+
+	// No RC receiver: its health bit means nothing
+	c.handleMessage(sysStatus(false, false), 1, 1)
+	noEvent(t, events)
+
+	c.handleMessage(sysStatus(true, false), 1, 1)
+	if evt := nextFailsafe(t, events); evt.Type != FailsafeRCLoss || !evt.Active {
+		t.Errorf("unhealthy receiver: %+v", evt)
+	}
+	c.handleMessage(sysStatus(true, false), 1, 1)
+	noEvent(t, events)
+
+	c.handleMessage(sysStatus(true, true), 1, 1)
+	if evt := nextFailsafe(t, events); evt.Type != FailsafeRCLoss || evt.Active {
+		t.Errorf("healthy receiver: %+v", evt)
+	}
+}
+
+func TestHandleFailsafeModeChange(t *testing.T) {
+	heartbeat := func(customMode uint32, status common.MAV_STATE) *common.MessageHeartbeat {
+		return &common.MessageHeartbeat{
+			Type:         common.MAV_TYPE_QUADROTOR,
+			Autopilot:    common.MAV_AUTOPILOT_PX4,
+			CustomMode:   customMode,
+			SystemStatus: status,
+		}
+	}
+	rtl := uint32(PX4_MAIN_MODE_AUTO | PX4_AUTO_MODE_RTL<<16)
+	hold := uint32(PX4_MAIN_MODE_AUTO | PX4_AUTO_MODE_LOITER<<16)
+
+	t.Run("unknown", func(t *testing.T) {
+		c := newConnectedTestClient()
+		events, unsubscribe := c.SubscribeEvents()
+		defer unsubscribe()
+
+		// Critical without a recognizable message
+		c.handleMessage(heartbeat(hold, common.MAV_STATE_CRITICAL), 1, 1)
+		if evt := nextFailsafe(t, events); evt.Type != FailsafeUnknown || !evt.Active || evt.Action != FailsafeActionHold {
+			t.Errorf("critical: %+v", evt)
+		}
+
+		c.handleMessage(heartbeat(hold, common.MAV_STATE_ACTIVE), 1, 1)
+		if evt := nextFailsafe(t, events); evt.Type != FailsafeUnknown || evt.Active {
+			t.Errorf("recovered: %+v", evt)
+		}
+	})
+
+	t.Run("action from mode switch", func(t *testing.T) {
+		c := newConnectedTestClient()
+		events, unsubscribe := c.SubscribeEvents()
+		defer unsubscribe()
+
+		c.handleMessage(&common.MessageStatustext{Text: "Manual control lost"}, 1, 1)
+		if evt := nextFailsafe(t, events); evt.Action != FailsafeActionUnknown {
+			t.Errorf("raised: %+v", evt)
+		}
+
+		// The RTL that follows tells what the vehicle is doing; a critical
+		// state with a known failsafe raises no unknown one
+		c.handleMessage(heartbeat(rtl, common.MAV_STATE_CRITICAL), 1, 1)
+		if evt := nextFailsafe(t, events); evt.Type != FailsafeRCLoss || evt.Action != FailsafeActionRTL {
+			t.Errorf("after RTL: %+v", evt)
+		}
+		noEvent(t, events)
+	})
+}
@@ -0,0 +1,68 @@
+package mavlink
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/bluenviron/gomavlib/v3/pkg/dialects/common"
+)
+
+// DefaultGCSIdentityInterval is how often the GCS identity STATUSTEXT is
+// repeated when Config.GCSIdentityInterval is 0
+const DefaultGCSIdentityInterval = time.Minute
+
+// parseGCSSystemStatus parses a MAV_STATE name ("STANDBY" or
+// "MAV_STATE_STANDBY"); "" is ACTIVE
+func parseGCSSystemStatus(name string) (common.MAV_STATE, error) {
+	if name == "" {
+		return common.MAV_STATE_ACTIVE, nil
+	}
+
+	label := strings.ToUpper(name)
+	if !strings.HasPrefix(label, "MAV_STATE_") {
+		label = "MAV_STATE_" + label
+	}
+	var state common.MAV_STATE
+	if err := state.UnmarshalText([]byte(label)); err != nil {
+		return 0, fmt.Errorf("unknown MAV_STATE: %s", name)
+	}
+	return state, nil
+}
+
+// gcsHeartbeat is the HEARTBEAT identifying us as a ground control station,
+// with the configured system status
+func (c *Client) gcsHeartbeat() *common.MessageHeartbeat {
+	return &common.MessageHeartbeat{
+		Type:           common.MAV_TYPE_GCS, // Ground Control Station
+		Autopilot:      common.MAV_AUTOPILOT_INVALID,
+		BaseMode:       0,
+		CustomMode:     0,
+		SystemStatus:   c.gcsSystemStatus,
+		MavlinkVersion: 3,
+	}
+}
+
+// sendGCSIdentity sends the GCS identity as an INFO STATUSTEXT, so the
+// vehicle's log records which ground station was commanding it
+// It goes out once the vehicle is connected and every gcsIdentityInterval
+// after; last is when it was last sent (zero for never). Returns when it was
+// last sent after this call.
+func (c *Client) sendGCSIdentity(now, last time.Time) time.Time {
+	if c.gcsIdentity == "" || !c.IsConnected() {
+		return last
+	}
+	if !last.IsZero() && now.Sub(last) < c.gcsIdentityInterval {
+		return last
+	}
+
+	err := c.writeMessage(&common.MessageStatustext{
+		Severity: common.MAV_SEVERITY_INFO,
+		Text:     c.gcsIdentity,
+	})
+	if err != nil {
+		c.logger.Printf("MAVLink: Error sending GCS identity: %v", err)
+		return last
+	}
+	return now
+}
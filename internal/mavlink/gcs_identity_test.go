@@ -0,0 +1,99 @@
+package mavlink
+
+import (
+	"io"
+	"log"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/bluenviron/gomavlib/v3/pkg/dialects/common"
+)
+
+func TestParseGCSSystemStatus(t *testing.T) {
+	tests := []struct {
+		name string
+		want common.MAV_STATE
+		ok   bool
+	}{
+		{"", common.MAV_STATE_ACTIVE, true},
+		{"STANDBY", common.MAV_STATE_STANDBY, true},
+		{"standby", common.MAV_STATE_STANDBY, true},
+		{"MAV_STATE_CRITICAL", common.MAV_STATE_CRITICAL, true},
+		{"BUSY", 0, false},
+	}
+	for _, tt := range tests {
+		got, err := parseGCSSystemStatus(tt.name)
+		if (err == nil) != tt.ok || got != tt.want {
+			t.Errorf("parseGCSSystemStatus(%q) = %s, %v; want %s, ok %v", tt.name, got, err, tt.want, tt.ok)
+		}
+	}
+}
+
+func TestNewClientRejectsGCSIdentity(t *testing.T) {
+	base := Config{
+		Port:     filepath.Join(t.TempDir(), "ttyMissing"),
+		BaudRate: 57600,
+		Logger:   log.New(io.Discard, "", 0),
+	}
+
+	cfg := base
+	cfg.GCSSystemStatus = "BUSY"
+	if _, err := NewClient(cfg); err == nil || !strings.Contains(err.Error(), "GCS system status") {
+		t.Errorf("unknown status: %v", err)
+	}
+	cfg = base
+	cfg.GCSIdentity = strings.Repeat("x", statusTextChunkLen+1)
+	if _, err := NewClient(cfg); err == nil || !strings.Contains(err.Error(), "GCS identity") {
+		t.Errorf("identity over one STATUSTEXT: %v", err)
+	}
+}
+
+func TestGCSHeartbeatStatusAndIdentity(t *testing.T) {
+	c, vehicle := newLinkedTestClient(t)
+	c.gcsSystemStatus = common.MAV_STATE_STANDBY
+	c.gcsIdentity = "GCS-2 north field"
+	c.gcsIdentityInterval = time.Minute
+	c.stopHeartbeat = make(chan struct{})
+	c.heartbeatDone = make(chan struct{})
+	go c.sendGroundStationMessages()
+	defer func() {
+		close(c.stopHeartbeat)
+		<-c.heartbeatDone
+	}()
+
+	heartbeat := receive[*common.MessageHeartbeat](t, vehicle)
+	if heartbeat.Type != common.MAV_TYPE_GCS || heartbeat.SystemStatus != common.MAV_STATE_STANDBY {
+		t.Errorf("heartbeat: %s, status %s", heartbeat.Type, heartbeat.SystemStatus)
+	}
+	text := receive[*common.MessageStatustext](t, vehicle)
+	if text.Text != "GCS-2 north field" || text.Severity != common.MAV_SEVERITY_INFO {
+		t.Errorf("identity: %s %q", text.Severity, text.Text)
+	}
+}
+
+func TestSendGCSIdentityInterval(t *testing.T) {
+	c, _ := newLinkedTestClient(t)
+	c.gcsIdentity = "GCS-2"
+	c.gcsIdentityInterval = time.Minute
+
+	start := time.Now()
+	last := c.sendGCSIdentity(start, time.Time{})
+	if !last.Equal(start) {
+		t.Fatalf("not sent once connected: last = %v", last)
+	}
+	if got := c.sendGCSIdentity(start.Add(59*time.Second), last); !got.Equal(start) {
+		t.Errorf("sent again within the interval")
+	}
+	if got := c.sendGCSIdentity(start.Add(time.Minute), last); !got.Equal(start.Add(time.Minute)) {
+		t.Errorf("not repeated after the interval")
+	}
+
+	// Nothing until a vehicle is connected
+	idle := newTestClient()
+	idle.gcsIdentity = "GCS-2"
+	if got := idle.sendGCSIdentity(start, time.Time{}); !got.IsZero() {
+		t.Errorf("sent without a vehicle")
+	}
+}
@@ -0,0 +1,233 @@
+package mavlink
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/bluenviron/gomavlib/v3/pkg/dialects/common"
+)
+
+// GeofenceAction is what the vehicle does when it breaches the geofence
+type GeofenceAction string
+
+const (
+	GeofenceActionNone GeofenceAction = "none"
+	GeofenceActionWarn GeofenceAction = "warn"
+	GeofenceActionRTL  GeofenceAction = "rtl"
+	GeofenceActionLand GeofenceAction = "land"
+)
+
+// ParseGeofenceAction parses a breach action name (case-insensitive)
+func ParseGeofenceAction(name string) (GeofenceAction, error) {
+	action := GeofenceAction(strings.ToLower(name))
+	switch action {
+	case GeofenceActionNone, GeofenceActionWarn, GeofenceActionRTL, GeofenceActionLand:
+		return action, nil
+	default:
+		return "", fmt.Errorf("unknown geofence action %q (want none, warn, rtl or land)", name)
+	}
+}
+
+// px4GeofenceActions are GF_ACTION values
+var px4GeofenceActions = map[GeofenceAction]int32{
+	GeofenceActionNone: 0,
+	GeofenceActionWarn: 1,
+	GeofenceActionRTL:  3,
+	GeofenceActionLand: 5,
+}
+
+// ardupilotGeofenceActions are FENCE_ACTION values
+// ArduPilot has no silent action; "none" is report-only like "warn".
+var ardupilotGeofenceActions = map[GeofenceAction]int32{
+	GeofenceActionNone: 0,
+	GeofenceActionWarn: 0,
+	GeofenceActionRTL:  1,
+	GeofenceActionLand: 2,
+}
+
+// SetGeofenceAction sets the breach action (PX4 GF_ACTION, ArduPilot FENCE_ACTION)
+// Succeeds once the vehicle echoes the new parameter value.
+func (c *Client) SetGeofenceAction(action GeofenceAction) error {
+	if !c.IsConnected() {
+		return fmt.Errorf("not connected to drone")
+	}
+
+	c.mu.RLock()
+	autopilot := c.stats.autopilot
+	c.mu.RUnlock()
+
+	var err error
+	switch autopilot {
+	case common.MAV_AUTOPILOT_PX4:
+		err = c.setIntParameter("GF_ACTION", px4GeofenceActions[action], common.MAV_PARAM_TYPE_INT32)
+	case common.MAV_AUTOPILOT_ARDUPILOTMEGA:
+		err = c.setIntParameter("FENCE_ACTION", ardupilotGeofenceActions[action], common.MAV_PARAM_TYPE_INT8)
+	default:
+		return fmt.Errorf("geofence action not supported for autopilot %s", autopilot)
+	}
+	if err != nil {
+		return err
+	}
+
+	c.mu.Lock()
+	c.geofenceAction = action
+	c.mu.Unlock()
+	return nil
+}
+
+// SetGeofenceEnabled enables or disables the geofence with MAV_CMD_DO_FENCE_ENABLE
+// ArduPilot acknowledges the command. PX4 has no fence switch and rejects it as
+// unsupported; there the fence is disabled by setting GF_ACTION to none and
+// enabled by restoring the last action set through SetGeofenceAction.
+func (c *Client) SetGeofenceEnabled(enabled bool) error {
+	if !c.IsConnected() {
+		return fmt.Errorf("not connected to drone")
+	}
+
+	c.logger.Printf("MAVLink: Setting geofence enabled=%v", enabled)
+
+	param := float32(0)
+	if enabled {
+		param = 1
+	}
+
+	err := c.sendCommandLong(common.MAV_CMD_DO_FENCE_ENABLE, [7]float32{param})
+
+	c.mu.RLock()
+	autopilot := c.stats.autopilot
+	action := c.geofenceAction
+	c.mu.RUnlock()
+
+	var rejected *CommandRejectedError
+	if autopilot != common.MAV_AUTOPILOT_PX4 || !errors.As(err, &rejected) ||
+		rejected.Result != common.MAV_RESULT_UNSUPPORTED {
+		return err
+	}
+
+	if !enabled {
+		return c.setIntParameter("GF_ACTION", px4GeofenceActions[GeofenceActionNone], common.MAV_PARAM_TYPE_INT32)
+	}
+	if action == "" || action == GeofenceActionNone {
+		return fmt.Errorf("PX4 enables the geofence through its breach action; set one other than none first")
+	}
+	return c.setIntParameter("GF_ACTION", px4GeofenceActions[action], common.MAV_PARAM_TYPE_INT32)
+}
+
+// FencePoint is a geofence vertex or circle center
+type FencePoint struct {
+	Latitude  float64 `json:"latitude"`
+	Longitude float64 `json:"longitude"`
+}
+
+// FenceZone is one geofence zone stored on the vehicle: a polygon of at least
+// three vertices, or a circle when Vertices is empty
+// An inclusion zone must be stayed in, an exclusion zone stayed out of.
+type FenceZone struct {
+	Inclusion bool         `json:"inclusion"`
+	Vertices  []FencePoint `json:"vertices,omitempty"`
+	Center    FencePoint   `json:"center"`
+	Radius    float64      `json:"radius,omitempty"` // meters
+}
+
+// fenceItems encodes zones as MAV_MISSION_TYPE_FENCE items
+// Every polygon vertex carries the polygon's vertex count in param1.
+func fenceItems(zones []FenceZone) ([]MissionItem, error) {
+	var items []MissionItem
+	for i, zone := range zones {
+		if len(zone.Vertices) == 0 {
+			if zone.Radius <= 0 {
+				return nil, fmt.Errorf("fence zone %d: circle needs a positive radius", i)
+			}
+			command := common.MAV_CMD_NAV_FENCE_CIRCLE_EXCLUSION
+			if zone.Inclusion {
+				command = common.MAV_CMD_NAV_FENCE_CIRCLE_INCLUSION
+			}
+			items = append(items, fenceItem(command, float32(zone.Radius), zone.Center))
+			continue
+		}
+
+		if len(zone.Vertices) < 3 {
+			return nil, fmt.Errorf("fence zone %d: polygon needs at least 3 vertices, got %d", i, len(zone.Vertices))
+		}
+		command := common.MAV_CMD_NAV_FENCE_POLYGON_VERTEX_EXCLUSION
+		if zone.Inclusion {
+			command = common.MAV_CMD_NAV_FENCE_POLYGON_VERTEX_INCLUSION
+		}
+		for _, vertex := range zone.Vertices {
+			items = append(items, fenceItem(command, float32(len(zone.Vertices)), vertex))
+		}
+	}
+	return items, nil
+}
+
+func fenceItem(command common.MAV_CMD, param1 float32, p FencePoint) MissionItem {
+	return MissionItem{
+		Command: command,
+		Frame:   common.MAV_FRAME_GLOBAL,
+		Param1:  param1,
+		X:       int32(p.Latitude * 1e7),
+		Y:       int32(p.Longitude * 1e7),
+	}
+}
+
+// fenceZones decodes MAV_MISSION_TYPE_FENCE items into zones
+// Items other than polygon vertices and circles (e.g. a return point) are skipped.
+func fenceZones(items []MissionItem) ([]FenceZone, error) {
+	var zones []FenceZone
+	for i := 0; i < len(items); {
+		item := items[i]
+		point := FencePoint{Latitude: float64(item.X) / 1e7, Longitude: float64(item.Y) / 1e7}
+
+		switch item.Command {
+		case common.MAV_CMD_NAV_FENCE_CIRCLE_INCLUSION, common.MAV_CMD_NAV_FENCE_CIRCLE_EXCLUSION:
+			zones = append(zones, FenceZone{
+				Inclusion: item.Command == common.MAV_CMD_NAV_FENCE_CIRCLE_INCLUSION,
+				Center:    point,
+				Radius:    float64(item.Param1),
+			})
+			i++
+
+		case common.MAV_CMD_NAV_FENCE_POLYGON_VERTEX_INCLUSION, common.MAV_CMD_NAV_FENCE_POLYGON_VERTEX_EXCLUSION:
+			count := int(item.Param1)
+			if count < 3 || i+count > len(items) {
+				return nil, fmt.Errorf("fence item %d: polygon of %d vertices with %d items left", i, count, len(items)-i)
+			}
+			zone := FenceZone{Inclusion: item.Command == common.MAV_CMD_NAV_FENCE_POLYGON_VERTEX_INCLUSION}
+			for _, vertex := range items[i : i+count] {
+				if vertex.Command != item.Command {
+					return nil, fmt.Errorf("fence item %d: polygon interrupted by %s", i, vertex.Command)
+				}
+				zone.Vertices = append(zone.Vertices, FencePoint{
+					Latitude:  float64(vertex.X) / 1e7,
+					Longitude: float64(vertex.Y) / 1e7,
+				})
+			}
+			zones = append(zones, zone)
+			i += count
+
+		default:
+			i++
+		}
+	}
+	return zones, nil
+}
+
+// UploadGeofence replaces the geofence zones stored on the vehicle
+// Whether the fence is enforced is set separately (see SetGeofenceEnabled).
+func (c *Client) UploadGeofence(zones []FenceZone) error {
+	items, err := fenceItems(zones)
+	if err != nil {
+		return err
+	}
+	return c.UploadItems(common.MAV_MISSION_TYPE_FENCE, items)
+}
+
+// DownloadGeofence reads the geofence zones stored on the vehicle
+func (c *Client) DownloadGeofence() ([]FenceZone, error) {
+	items, err := c.DownloadItems(common.MAV_MISSION_TYPE_FENCE)
+	if err != nil {
+		return nil, err
+	}
+	return fenceZones(items)
+}
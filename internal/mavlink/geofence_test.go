@@ -0,0 +1,117 @@
+package mavlink
+
+import (
+	"math"
+	"testing"
+
+	"github.com/bluenviron/gomavlib/v3"
+	"github.com/bluenviron/gomavlib/v3/pkg/dialects/common"
+)
+
+// newAutopilotTestClient returns a linked test client whose vehicle runs autopilot
+func newAutopilotTestClient(t *testing.T, autopilot common.MAV_AUTOPILOT) (*Client, *gomavlib.Node) {
+	t.Helper()
+	c, vehicle := newLinkedTestClient(t)
+	c.handleMessage(&common.MessageHeartbeat{Type: common.MAV_TYPE_QUADROTOR, Autopilot: autopilot}, 1, 1)
+	return c, vehicle
+}
+
+// echoParam receives a PARAM_SET for name and echoes its value back
+// Returns the value as an integer, decoded the way the autopilot encodes it.
+func echoParam(t *testing.T, c *Client, vehicle *gomavlib.Node, name string, paramType common.MAV_PARAM_TYPE) int32 {
+	t.Helper()
+	msg := receive[*common.MessageParamSet](t, vehicle)
+	if msg.ParamId != name || msg.ParamType != paramType {
+		t.Fatalf("set %s (%s), want %s (%s)", msg.ParamId, msg.ParamType, name, paramType)
+	}
+	c.handleMessage(&common.MessageParamValue{ParamId: msg.ParamId, ParamValue: msg.ParamValue, ParamType: msg.ParamType}, 1, 1)
+
+	c.mu.RLock()
+	px4 := c.stats.autopilot == common.MAV_AUTOPILOT_PX4
+	c.mu.RUnlock()
+	if px4 {
+		return int32(math.Float32bits(msg.ParamValue))
+	}
+	return int32(msg.ParamValue)
+}
+
+func TestGeofenceArduPilot(t *testing.T) {
+	c, vehicle := newAutopilotTestClient(t, common.MAV_AUTOPILOT_ARDUPILOTMEGA)
+
+	done := make(chan error, 1)
+	go func() { done <- c.SetGeofenceAction(GeofenceActionLand) }()
+	if v := echoParam(t, c, vehicle, "FENCE_ACTION", common.MAV_PARAM_TYPE_INT8); v != 2 {
+		t.Errorf("FENCE_ACTION = %d, want 2", v)
+	}
+	if err := <-done; err != nil {
+		t.Fatal(err)
+	}
+
+	// ArduPilot switches the fence with DO_FENCE_ENABLE
+	for _, enabled := range []bool{true, false} {
+		go func() { done <- c.SetGeofenceEnabled(enabled) }()
+		msg := receive[*common.MessageCommandLong](t, vehicle)
+		if want := map[bool]float32{true: 1, false: 0}[enabled]; msg.Command != common.MAV_CMD_DO_FENCE_ENABLE || msg.Param1 != want {
+			t.Errorf("enabled=%v: sent %s with param1 %v", enabled, msg.Command, msg.Param1)
+		}
+		ack(c, common.MAV_CMD_DO_FENCE_ENABLE, common.MAV_RESULT_ACCEPTED)
+		if err := <-done; err != nil {
+			t.Errorf("enabled=%v: %v", enabled, err)
+		}
+	}
+}
+
+func TestGeofencePX4(t *testing.T) {
+	c, vehicle := newAutopilotTestClient(t, common.MAV_AUTOPILOT_PX4)
+
+	done := make(chan error, 1)
+	go func() { done <- c.SetGeofenceAction(GeofenceActionRTL) }()
+	if v := echoParam(t, c, vehicle, "GF_ACTION", common.MAV_PARAM_TYPE_INT32); v != 3 {
+		t.Errorf("GF_ACTION = %d, want 3", v)
+	}
+	if err := <-done; err != nil {
+		t.Fatal(err)
+	}
+
+	// PX4 refuses DO_FENCE_ENABLE; disabling sets the action to none and
+	// enabling restores the one set above
+	for _, tt := range []struct {
+		enabled bool
+		action  int32
+	}{{false, 0}, {true, 3}} {
+		go func() { done <- c.SetGeofenceEnabled(tt.enabled) }()
+		receive[*common.MessageCommandLong](t, vehicle)
+		ack(c, common.MAV_CMD_DO_FENCE_ENABLE, common.MAV_RESULT_UNSUPPORTED)
+		if v := echoParam(t, c, vehicle, "GF_ACTION", common.MAV_PARAM_TYPE_INT32); v != tt.action {
+			t.Errorf("enabled=%v: GF_ACTION = %d, want %d", tt.enabled, v, tt.action)
+		}
+		if err := <-done; err != nil {
+			t.Errorf("enabled=%v: %v", tt.enabled, err)
+		}
+	}
+}
+
+func TestGeofencePX4EnableNeedsAction(t *testing.T) {
+	c, vehicle := newAutopilotTestClient(t, common.MAV_AUTOPILOT_PX4)
+
+	done := make(chan error, 1)
+	go func() { done <- c.SetGeofenceEnabled(true) }()
+	receive[*common.MessageCommandLong](t, vehicle)
+	ack(c, common.MAV_CMD_DO_FENCE_ENABLE, common.MAV_RESULT_UNSUPPORTED)
+	if err := <-done; err == nil {
+		t.Error("PX4 fence enabled without a breach action")
+	}
+}
+
+func TestGeofenceActionRefused(t *testing.T) {
+	c, vehicle := newAutopilotTestClient(t, common.MAV_AUTOPILOT_ARDUPILOTMEGA)
+
+	done := make(chan error, 1)
+	go func() { done <- c.SetGeofenceAction(GeofenceActionRTL) }()
+	msg := receive[*common.MessageParamSet](t, vehicle)
+	// The vehicle echoes the value it kept
+	c.handleMessage(&common.MessageParamValue{ParamId: msg.ParamId, ParamValue: 0, ParamType: msg.ParamType}, 1, 1)
+	if err := <-done; err == nil {
+		t.Error("refused FENCE_ACTION reported as set")
+	}
+}
@@ -0,0 +1,397 @@
+package mavlink
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/bluenviron/gomavlib/v3/pkg/dialects/common"
+)
+
+// Gimbal modes (see SetGimbalMode)
+const (
+	GimbalModeRetract = "retract" // stowed
+	GimbalModeNeutral = "neutral" // default forward-facing position
+	GimbalModeFollow  = "follow"  // yaw turns with the vehicle
+	GimbalModeLock    = "lock"    // yaw holds its earth-frame heading
+)
+
+// Gimbal control protocols
+const (
+	GimbalProtocolManager = "gimbal_manager" // GIMBAL_MANAGER_* messages and commands
+	GimbalProtocolMount   = "mount"          // legacy DO_MOUNT_CONTROL
+)
+
+// gimbalFlags are the GIMBAL_MANAGER_FLAGS SetGimbalFlags accepts, by name
+var gimbalFlags = map[string]common.GIMBAL_MANAGER_FLAGS{
+	"retract":              common.GIMBAL_MANAGER_FLAGS_RETRACT,
+	"neutral":              common.GIMBAL_MANAGER_FLAGS_NEUTRAL,
+	"roll_lock":            common.GIMBAL_MANAGER_FLAGS_ROLL_LOCK,
+	"pitch_lock":           common.GIMBAL_MANAGER_FLAGS_PITCH_LOCK,
+	"yaw_lock":             common.GIMBAL_MANAGER_FLAGS_YAW_LOCK,
+	"yaw_in_vehicle_frame": common.GIMBAL_MANAGER_FLAGS_YAW_IN_VEHICLE_FRAME,
+	"yaw_in_earth_frame":   common.GIMBAL_MANAGER_FLAGS_YAW_IN_EARTH_FRAME,
+	"rc_exclusive":         common.GIMBAL_MANAGER_FLAGS_RC_EXCLUSIVE,
+	"rc_mixed":             common.GIMBAL_MANAGER_FLAGS_RC_MIXED,
+}
+
+// GimbalCapabilities is what a gimbal manager reports its gimbal can do
+type GimbalCapabilities struct {
+	Retract             bool `json:"retract"`
+	Neutral             bool `json:"neutral"`
+	RollAxis            bool `json:"roll_axis"`
+	RollFollow          bool `json:"roll_follow"`
+	RollLock            bool `json:"roll_lock"`
+	PitchAxis           bool `json:"pitch_axis"`
+	PitchFollow         bool `json:"pitch_follow"`
+	PitchLock           bool `json:"pitch_lock"`
+	YawAxis             bool `json:"yaw_axis"`
+	YawFollow           bool `json:"yaw_follow"`
+	YawLock             bool `json:"yaw_lock"`
+	InfiniteYaw         bool `json:"infinite_yaw"`
+	YawInEarthFrame     bool `json:"yaw_in_earth_frame"`
+	RCInputs            bool `json:"rc_inputs"`
+	PointLocationLocal  bool `json:"point_location_local"`
+	PointLocationGlobal bool `json:"point_location_global"`
+}
+
+// GimbalCapabilitiesFrom decodes GIMBAL_MANAGER_INFORMATION cap_flags
+func GimbalCapabilitiesFrom(flags common.GIMBAL_MANAGER_CAP_FLAGS) GimbalCapabilities {
+	has := func(flag common.GIMBAL_MANAGER_CAP_FLAGS) bool {
+		return flags&flag != 0
+	}
+
+	return GimbalCapabilities{
+		Retract:             has(common.GIMBAL_MANAGER_CAP_FLAGS_HAS_RETRACT),
+		Neutral:             has(common.GIMBAL_MANAGER_CAP_FLAGS_HAS_NEUTRAL),
+		RollAxis:            has(common.GIMBAL_MANAGER_CAP_FLAGS_HAS_ROLL_AXIS),
+		RollFollow:          has(common.GIMBAL_MANAGER_CAP_FLAGS_HAS_ROLL_FOLLOW),
+		RollLock:            has(common.GIMBAL_MANAGER_CAP_FLAGS_HAS_ROLL_LOCK),
+		PitchAxis:           has(common.GIMBAL_MANAGER_CAP_FLAGS_HAS_PITCH_AXIS),
+		PitchFollow:         has(common.GIMBAL_MANAGER_CAP_FLAGS_HAS_PITCH_FOLLOW),
+		PitchLock:           has(common.GIMBAL_MANAGER_CAP_FLAGS_HAS_PITCH_LOCK),
+		YawAxis:             has(common.GIMBAL_MANAGER_CAP_FLAGS_HAS_YAW_AXIS),
+		YawFollow:           has(common.GIMBAL_MANAGER_CAP_FLAGS_HAS_YAW_FOLLOW),
+		YawLock:             has(common.GIMBAL_MANAGER_CAP_FLAGS_HAS_YAW_LOCK),
+		InfiniteYaw:         has(common.GIMBAL_MANAGER_CAP_FLAGS_SUPPORTS_INFINITE_YAW),
+		YawInEarthFrame:     has(common.GIMBAL_MANAGER_CAP_FLAGS_SUPPORTS_YAW_IN_EARTH_FRAME),
+		RCInputs:            has(common.GIMBAL_MANAGER_CAP_FLAGS_HAS_RC_INPUTS),
+		PointLocationLocal:  has(common.GIMBAL_MANAGER_CAP_FLAGS_CAN_POINT_LOCATION_LOCAL),
+		PointLocationGlobal: has(common.GIMBAL_MANAGER_CAP_FLAGS_CAN_POINT_LOCATION_GLOBAL),
+	}
+}
+
+// SupportedGimbalModes returns the modes a gimbal accepts over a protocol
+// Over the legacy mount protocol follow is MAVLINK_TARGETING, and there is
+// no lock.
+func SupportedGimbalModes(protocol string, caps GimbalCapabilities) []string {
+	if protocol == GimbalProtocolMount {
+		return []string{GimbalModeRetract, GimbalModeNeutral, GimbalModeFollow}
+	}
+
+	var modes []string
+	if caps.Retract {
+		modes = append(modes, GimbalModeRetract)
+	}
+	if caps.Neutral {
+		modes = append(modes, GimbalModeNeutral)
+	}
+	// Without a yaw axis the gimbal points wherever the vehicle does
+	if caps.YawFollow || !caps.YawAxis {
+		modes = append(modes, GimbalModeFollow)
+	}
+	if caps.YawLock {
+		modes = append(modes, GimbalModeLock)
+	}
+	return modes
+}
+
+// GimbalModeFlags returns the GIMBAL_MANAGER_FLAGS selecting a mode, or an
+// error if the gimbal doesn't support it
+func GimbalModeFlags(mode string, caps GimbalCapabilities) (common.GIMBAL_MANAGER_FLAGS, error) {
+	var flags common.GIMBAL_MANAGER_FLAGS
+	switch mode {
+	case GimbalModeRetract:
+		flags = common.GIMBAL_MANAGER_FLAGS_RETRACT
+	case GimbalModeNeutral:
+		flags = common.GIMBAL_MANAGER_FLAGS_NEUTRAL
+	case GimbalModeFollow:
+		flags = common.GIMBAL_MANAGER_FLAGS_YAW_IN_VEHICLE_FRAME
+	case GimbalModeLock:
+		flags = common.GIMBAL_MANAGER_FLAGS_YAW_LOCK | common.GIMBAL_MANAGER_FLAGS_YAW_IN_EARTH_FRAME
+	default:
+		return 0, fmt.Errorf("unknown gimbal mode: %q (must be retract, neutral, follow or lock)", mode)
+	}
+
+	for _, supported := range SupportedGimbalModes(GimbalProtocolManager, caps) {
+		if supported == mode {
+			return flags, nil
+		}
+	}
+	return 0, fmt.Errorf("gimbal does not support %s mode", mode)
+}
+
+// gimbalModeFromFlags names the mode a GIMBAL_MANAGER_STATUS flags value applies
+func gimbalModeFromFlags(flags common.GIMBAL_MANAGER_FLAGS) string {
+	switch {
+	case flags&common.GIMBAL_MANAGER_FLAGS_RETRACT != 0:
+		return GimbalModeRetract
+	case flags&common.GIMBAL_MANAGER_FLAGS_NEUTRAL != 0:
+		return GimbalModeNeutral
+	case flags&common.GIMBAL_MANAGER_FLAGS_YAW_LOCK != 0:
+		return GimbalModeLock
+	}
+	return GimbalModeFollow
+}
+
+// mountMode returns the legacy MAV_MOUNT_MODE for a gimbal mode
+func mountMode(mode string) (common.MAV_MOUNT_MODE, error) {
+	switch mode {
+	case GimbalModeRetract:
+		return common.MAV_MOUNT_MODE_RETRACT, nil
+	case GimbalModeNeutral:
+		return common.MAV_MOUNT_MODE_NEUTRAL, nil
+	case GimbalModeFollow:
+		return common.MAV_MOUNT_MODE_MAVLINK_TARGETING, nil
+	case GimbalModeLock:
+		return 0, fmt.Errorf("gimbal lock mode needs a gimbal manager; the vehicle only supports DO_MOUNT_CONTROL")
+	}
+	return 0, fmt.Errorf("unknown gimbal mode: %q (must be retract, neutral, follow or lock)", mode)
+}
+
+// ParseGimbalFlags converts flag names (e.g. "pitch_lock") to GIMBAL_MANAGER_FLAGS
+func ParseGimbalFlags(names []string) (common.GIMBAL_MANAGER_FLAGS, error) {
+	var flags common.GIMBAL_MANAGER_FLAGS
+	for _, name := range names {
+		flag, ok := gimbalFlags[strings.ToLower(name)]
+		if !ok {
+			return 0, fmt.Errorf("unknown gimbal flag: %q", name)
+		}
+		flags |= flag
+	}
+	return flags, nil
+}
+
+// gimbalFlagNames lists the names of the flags set, sorted
+func gimbalFlagNames(flags common.GIMBAL_MANAGER_FLAGS) []string {
+	var names []string
+	for name, flag := range gimbalFlags {
+		if flags&flag != 0 {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	return names
+}
+
+// GimbalInfo is the vehicle's gimbal as discovered over MAVLink
+// Protocol is GimbalProtocolManager once GIMBAL_MANAGER_INFORMATION arrives,
+// GimbalProtocolMount when only a gimbal component's heartbeat was seen, and
+// empty when no gimbal is known. Angle limits are degrees; status fields are
+// set once GIMBAL_MANAGER_STATUS arrives.
+type GimbalInfo struct {
+	Protocol       string   `json:"protocol"`
+	SupportedModes []string `json:"supported_modes"`
+
+	ManagerComponentID uint8               `json:"manager_component_id,omitempty"`
+	DeviceID           uint8               `json:"device_id,omitempty"`
+	Capabilities       *GimbalCapabilities `json:"capabilities,omitempty"`
+
+	RollMin  float64 `json:"roll_min,omitempty"`
+	RollMax  float64 `json:"roll_max,omitempty"`
+	PitchMin float64 `json:"pitch_min,omitempty"`
+	PitchMax float64 `json:"pitch_max,omitempty"`
+	YawMin   float64 `json:"yaw_min,omitempty"`
+	YawMax   float64 `json:"yaw_max,omitempty"`
+
+	Mode  string   `json:"mode,omitempty"`
+	Flags []string `json:"flags,omitempty"`
+
+	// Who has primary control ("sysid/compid", empty for no one), and whether it's this server
+	PrimaryControl string `json:"primary_control,omitempty"`
+	InControl      bool   `json:"in_control"`
+
+	Updated time.Time `json:"updated"`
+}
+
+// gimbalState is the latest gimbal manager messages; guarded by c.mu
+type gimbalState struct {
+	componentID uint8
+	info        *common.MessageGimbalManagerInformation
+	status      *common.MessageGimbalManagerStatus
+	updated     time.Time
+}
+
+// GCS identity the gimbal manager sees as primary controller
+const (
+	gcsSystemID    = 255
+	gcsComponentID = 1
+)
+
+// GetGimbal returns the vehicle's gimbal and the modes it can be set to
+func (c *Client) GetGimbal() GimbalInfo {
+	c.mu.RLock()
+	state := c.gimbal
+	hasGimbal := c.capabilitiesLocked().Gimbal
+	c.mu.RUnlock()
+
+	if state.info == nil {
+		if !hasGimbal {
+			return GimbalInfo{}
+		}
+		return GimbalInfo{
+			Protocol:       GimbalProtocolMount,
+			SupportedModes: SupportedGimbalModes(GimbalProtocolMount, GimbalCapabilities{}),
+		}
+	}
+
+	caps := GimbalCapabilitiesFrom(state.info.CapFlags)
+	info := GimbalInfo{
+		Protocol:       GimbalProtocolManager,
+		SupportedModes: SupportedGimbalModes(GimbalProtocolManager, caps),
+
+		ManagerComponentID: state.componentID,
+		DeviceID:           state.info.GimbalDeviceId,
+		Capabilities:       &caps,
+
+		RollMin:  radToDeg(state.info.RollMin),
+		RollMax:  radToDeg(state.info.RollMax),
+		PitchMin: radToDeg(state.info.PitchMin),
+		PitchMax: radToDeg(state.info.PitchMax),
+		YawMin:   radToDeg(state.info.YawMin),
+		YawMax:   radToDeg(state.info.YawMax),
+
+		Updated: state.updated,
+	}
+
+	if s := state.status; s != nil {
+		info.Mode = gimbalModeFromFlags(s.Flags)
+		info.Flags = gimbalFlagNames(s.Flags)
+		if s.PrimaryControlSysid != 0 {
+			info.PrimaryControl = fmt.Sprintf("%d/%d", s.PrimaryControlSysid, s.PrimaryControlCompid)
+		}
+		info.InControl = s.PrimaryControlSysid == gcsSystemID && s.PrimaryControlCompid == gcsComponentID
+	}
+	return info
+}
+
+// SetGimbalMode switches the gimbal to retract, neutral, follow or lock
+// With a gimbal manager the mode is sent as DO_GIMBAL_MANAGER_PITCHYAW flags,
+// taking primary control first if needed; otherwise it falls back to the legacy
+// DO_MOUNT_CONTROL, which has no lock mode.
+func (c *Client) SetGimbalMode(mode string) error {
+	if !c.IsConnected() {
+		return fmt.Errorf("not connected to drone")
+	}
+
+	c.mu.RLock()
+	info := c.gimbal.info
+	c.mu.RUnlock()
+
+	if info == nil {
+		mount, err := mountMode(mode)
+		if err != nil {
+			return err
+		}
+		c.logger.Printf("MAVLink: Sending DO_MOUNT_CONTROL: mode=%s", mode)
+		return c.sendCommandLong(common.MAV_CMD_DO_MOUNT_CONTROL, [7]float32{6: float32(mount)})
+	}
+
+	flags, err := GimbalModeFlags(mode, GimbalCapabilitiesFrom(info.CapFlags))
+	if err != nil {
+		return err
+	}
+	c.logger.Printf("MAVLink: Setting gimbal mode: %s", mode)
+	return c.sendGimbalManagerFlags(flags)
+}
+
+// SetGimbalFlags applies GIMBAL_MANAGER_FLAGS by name (e.g. "pitch_lock",
+// "yaw_in_earth_frame"), replacing the current ones
+// Needs a gimbal manager; the legacy mount protocol has no flags.
+func (c *Client) SetGimbalFlags(names []string) error {
+	flags, err := ParseGimbalFlags(names)
+	if err != nil {
+		return err
+	}
+	if !c.IsConnected() {
+		return fmt.Errorf("not connected to drone")
+	}
+
+	c.mu.RLock()
+	discovered := c.gimbal.info != nil
+	c.mu.RUnlock()
+	if !discovered {
+		return fmt.Errorf("no gimbal manager discovered; gimbal flags need GIMBAL_MANAGER support")
+	}
+
+	c.logger.Printf("MAVLink: Setting gimbal flags: %v", gimbalFlagNames(flags))
+	return c.sendGimbalManagerFlags(flags)
+}
+
+// sendGimbalManagerFlags sends DO_GIMBAL_MANAGER_PITCHYAW with flags and no
+// angle or rate change (NaN), taking primary control first unless it's ours
+func (c *Client) sendGimbalManagerFlags(flags common.GIMBAL_MANAGER_FLAGS) error {
+	c.mu.RLock()
+	deviceID := float32(c.gimbal.info.GimbalDeviceId)
+	status := c.gimbal.status
+	c.mu.RUnlock()
+
+	if status == nil || status.PrimaryControlSysid != gcsSystemID || status.PrimaryControlCompid != gcsComponentID {
+		c.logger.Printf("MAVLink: Taking gimbal primary control")
+		if err := c.sendCommandLong(common.MAV_CMD_DO_GIMBAL_MANAGER_CONFIGURE, [7]float32{
+			gcsSystemID,
+			gcsComponentID,
+			-1, // Secondary control unchanged
+			-1,
+			6: deviceID,
+		}); err != nil {
+			return fmt.Errorf("failed to take gimbal control: %w", err)
+		}
+	}
+
+	nan := float32(math.NaN())
+	return c.sendCommandLong(common.MAV_CMD_DO_GIMBAL_MANAGER_PITCHYAW, [7]float32{
+		nan, // Pitch unchanged
+		nan, // Yaw unchanged
+		nan, // Pitch rate unchanged
+		nan, // Yaw rate unchanged
+		float32(flags),
+		0,
+		deviceID,
+	})
+}
+
+// handleGimbalManagerInformation processes GIMBAL_MANAGER_INFORMATION messages
+func (c *Client) handleGimbalManagerInformation(msg *common.MessageGimbalManagerInformation, compID uint8) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.gimbal.info == nil {
+		c.logger.Printf("MAVLink: Gimbal manager discovered on component %d (device %d)", compID, msg.GimbalDeviceId)
+	}
+	c.gimbal.componentID = compID
+	c.gimbal.info = msg
+	c.gimbal.updated = time.Now()
+}
+
+// handleGimbalManagerStatus processes GIMBAL_MANAGER_STATUS messages
+func (c *Client) handleGimbalManagerStatus(msg *common.MessageGimbalManagerStatus) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.gimbal.status = msg
+	c.gimbal.updated = time.Now()
+}
+
+// requestGimbalManagerInformation asks for GIMBAL_MANAGER_INFORMATION
+// Vehicles without a gimbal manager don't answer.
+func (c *Client) requestGimbalManagerInformation() error {
+	return c.requestMessage(&common.MessageGimbalManagerInformation{})
+}
+
+// radToDeg converts a MAVLink float32 angle in radians to degrees
+func radToDeg(rad float32) float64 {
+	return float64(rad) * 180 / math.Pi
+}
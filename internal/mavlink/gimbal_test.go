@@ -0,0 +1,170 @@
+package mavlink
+
+import (
+	"math"
+	"reflect"
+	"testing"
+
+	"github.com/bluenviron/gomavlib/v3/pkg/dialects/common"
+)
+
+// Capabilities of a three-axis gimbal that can retract and lock yaw
+const threeAxisGimbal = common.GIMBAL_MANAGER_CAP_FLAGS_HAS_RETRACT |
+	common.GIMBAL_MANAGER_CAP_FLAGS_HAS_NEUTRAL |
+	common.GIMBAL_MANAGER_CAP_FLAGS_HAS_ROLL_AXIS |
+	common.GIMBAL_MANAGER_CAP_FLAGS_HAS_PITCH_AXIS |
+	common.GIMBAL_MANAGER_CAP_FLAGS_HAS_YAW_AXIS |
+	common.GIMBAL_MANAGER_CAP_FLAGS_HAS_YAW_FOLLOW |
+	common.GIMBAL_MANAGER_CAP_FLAGS_HAS_YAW_LOCK
+
+func TestGimbalDiscovery(t *testing.T) {
+	c := newConnectedTestClient()
+	if info := c.GetGimbal(); info.Protocol != "" {
+		t.Fatalf("without a gimbal: %+v", info)
+	}
+
+	// A gimbal component's heartbeat alone means the legacy mount protocol
+	c.handleMessage(&common.MessageHeartbeat{Type: common.MAV_TYPE_GIMBAL, Autopilot: common.MAV_AUTOPILOT_INVALID}, 1, 154)
+	info := c.GetGimbal()
+	if info.Protocol != GimbalProtocolMount || !reflect.DeepEqual(info.SupportedModes, []string{"retract", "neutral", "follow"}) {
+		t.Fatalf("mount gimbal: %+v", info)
+	}
+
+	c.handleMessage(&common.MessageGimbalManagerInformation{
+		CapFlags:       threeAxisGimbal,
+		GimbalDeviceId: 154,
+		PitchMin:       -math.Pi / 2,
+		PitchMax:       math.Pi / 6,
+		YawMin:         -math.Pi,
+		YawMax:         math.Pi,
+	}, 1, 1)
+	info = c.GetGimbal()
+	if info.Protocol != GimbalProtocolManager || info.ManagerComponentID != 1 || info.DeviceID != 154 {
+		t.Fatalf("gimbal manager: %+v", info)
+	}
+	if !reflect.DeepEqual(info.SupportedModes, []string{"retract", "neutral", "follow", "lock"}) {
+		t.Errorf("supported modes = %v", info.SupportedModes)
+	}
+	if caps := info.Capabilities; caps == nil || !caps.Retract || !caps.YawLock || caps.RollLock || caps.InfiniteYaw {
+		t.Errorf("capabilities = %+v", caps)
+	}
+	// Limits arrive as float32 radians
+	degreesNear := func(got, want float64) bool { return math.Abs(got-want) < 1e-4 }
+	if !degreesNear(info.PitchMin, -90) || !degreesNear(info.PitchMax, 30) || !degreesNear(info.YawMax, 180) {
+		t.Errorf("pitch %v..%v, yaw max %v", info.PitchMin, info.PitchMax, info.YawMax)
+	}
+	if info.Mode != "" || info.InControl {
+		t.Errorf("status before GIMBAL_MANAGER_STATUS: mode %q, in control %v", info.Mode, info.InControl)
+	}
+
+	c.handleMessage(&common.MessageGimbalManagerStatus{
+		Flags:                common.GIMBAL_MANAGER_FLAGS_YAW_LOCK | common.GIMBAL_MANAGER_FLAGS_YAW_IN_EARTH_FRAME,
+		GimbalDeviceId:       154,
+		PrimaryControlSysid:  gcsSystemID,
+		PrimaryControlCompid: gcsComponentID,
+	}, 1, 1)
+	info = c.GetGimbal()
+	if info.Mode != GimbalModeLock || !reflect.DeepEqual(info.Flags, []string{"yaw_in_earth_frame", "yaw_lock"}) {
+		t.Errorf("mode %q, flags %v", info.Mode, info.Flags)
+	}
+	if info.PrimaryControl != "255/1" || !info.InControl {
+		t.Errorf("primary control %q, in control %v", info.PrimaryControl, info.InControl)
+	}
+}
+
+func TestGimbalModeFlags(t *testing.T) {
+	full := GimbalCapabilitiesFrom(threeAxisGimbal)
+	pitchOnly := GimbalCapabilitiesFrom(common.GIMBAL_MANAGER_CAP_FLAGS_HAS_PITCH_AXIS)
+	tests := []struct {
+		mode    string
+		caps    GimbalCapabilities
+		want    common.GIMBAL_MANAGER_FLAGS
+		wantErr bool
+	}{
+		{"retract", full, common.GIMBAL_MANAGER_FLAGS_RETRACT, false},
+		{"neutral", full, common.GIMBAL_MANAGER_FLAGS_NEUTRAL, false},
+		{"follow", full, common.GIMBAL_MANAGER_FLAGS_YAW_IN_VEHICLE_FRAME, false},
+		{"lock", full, common.GIMBAL_MANAGER_FLAGS_YAW_LOCK | common.GIMBAL_MANAGER_FLAGS_YAW_IN_EARTH_FRAME, false},
+		// Without a yaw axis the gimbal can only follow the vehicle
+		{"follow", pitchOnly, common.GIMBAL_MANAGER_FLAGS_YAW_IN_VEHICLE_FRAME, false},
+		{"lock", pitchOnly, 0, true},
+		{"retract", pitchOnly, 0, true},
+		{"stow", full, 0, true},
+	}
+	for _, tt := range tests {
+		got, err := GimbalModeFlags(tt.mode, tt.caps)
+		if (err != nil) != tt.wantErr || got != tt.want {
+			t.Errorf("GimbalModeFlags(%q, %+v) = %v, %v", tt.mode, tt.caps, got, err)
+		}
+	}
+}
+
+func TestSetGimbalModeManager(t *testing.T) {
+	c, vehicle := newLinkedTestClient(t)
+	c.handleMessage(&common.MessageGimbalManagerInformation{CapFlags: threeAxisGimbal, GimbalDeviceId: 154}, 1, 1)
+
+	if err := c.SetGimbalMode("stow"); err == nil {
+		t.Fatal("unknown mode accepted")
+	}
+
+	// Primary control is taken first, then the mode's flags are sent
+	done := make(chan error, 1)
+	go func() { done <- c.SetGimbalMode(GimbalModeLock) }()
+	configure := receive[*common.MessageCommandLong](t, vehicle)
+	if configure.Command != common.MAV_CMD_DO_GIMBAL_MANAGER_CONFIGURE ||
+		configure.Param1 != gcsSystemID || configure.Param2 != gcsComponentID || configure.Param7 != 154 {
+		t.Fatalf("sent %v (%v, %v, device %v)", configure.Command, configure.Param1, configure.Param2, configure.Param7)
+	}
+	ack(c, common.MAV_CMD_DO_GIMBAL_MANAGER_CONFIGURE, common.MAV_RESULT_ACCEPTED)
+	pitchYaw := receive[*common.MessageCommandLong](t, vehicle)
+	wantFlags := common.GIMBAL_MANAGER_FLAGS_YAW_LOCK | common.GIMBAL_MANAGER_FLAGS_YAW_IN_EARTH_FRAME
+	if pitchYaw.Command != common.MAV_CMD_DO_GIMBAL_MANAGER_PITCHYAW || pitchYaw.Param5 != float32(wantFlags) || pitchYaw.Param7 != 154 {
+		t.Fatalf("sent %v with flags %v, device %v", pitchYaw.Command, pitchYaw.Param5, pitchYaw.Param7)
+	}
+	if !math.IsNaN(float64(pitchYaw.Param1)) || !math.IsNaN(float64(pitchYaw.Param2)) {
+		t.Errorf("pitch, yaw = %v, %v, want unchanged (NaN)", pitchYaw.Param1, pitchYaw.Param2)
+	}
+	ack(c, common.MAV_CMD_DO_GIMBAL_MANAGER_PITCHYAW, common.MAV_RESULT_ACCEPTED)
+	if err := <-done; err != nil {
+		t.Fatal(err)
+	}
+
+	// Already in control, flags go straight out
+	c.handleMessage(&common.MessageGimbalManagerStatus{
+		GimbalDeviceId:       154,
+		PrimaryControlSysid:  gcsSystemID,
+		PrimaryControlCompid: gcsComponentID,
+	}, 1, 1)
+	go func() { done <- c.SetGimbalFlags([]string{"pitch_lock", "yaw_in_vehicle_frame"}) }()
+	msg := receive[*common.MessageCommandLong](t, vehicle)
+	wantFlags = common.GIMBAL_MANAGER_FLAGS_PITCH_LOCK | common.GIMBAL_MANAGER_FLAGS_YAW_IN_VEHICLE_FRAME
+	if msg.Command != common.MAV_CMD_DO_GIMBAL_MANAGER_PITCHYAW || msg.Param5 != float32(wantFlags) {
+		t.Fatalf("sent %v with flags %v, want DO_GIMBAL_MANAGER_PITCHYAW with %v", msg.Command, msg.Param5, wantFlags)
+	}
+	ack(c, common.MAV_CMD_DO_GIMBAL_MANAGER_PITCHYAW, common.MAV_RESULT_ACCEPTED)
+	if err := <-done; err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestSetGimbalModeMountFallback(t *testing.T) {
+	c, vehicle := newLinkedTestClient(t)
+
+	if err := c.SetGimbalFlags([]string{"pitch_lock"}); err == nil {
+		t.Error("gimbal flags accepted without a gimbal manager")
+	}
+	if err := c.SetGimbalMode(GimbalModeLock); err == nil {
+		t.Error("lock mode accepted over DO_MOUNT_CONTROL")
+	}
+
+	done := make(chan error, 1)
+	go func() { done <- c.SetGimbalMode(GimbalModeRetract) }()
+	msg := receive[*common.MessageCommandLong](t, vehicle)
+	if msg.Command != common.MAV_CMD_DO_MOUNT_CONTROL || msg.Param7 != float32(common.MAV_MOUNT_MODE_RETRACT) {
+		t.Fatalf("sent %v with mode %v, want DO_MOUNT_CONTROL retract", msg.Command, msg.Param7)
+	}
+	ack(c, common.MAV_CMD_DO_MOUNT_CONTROL, common.MAV_RESULT_ACCEPTED)
+	if err := <-done; err != nil {
+		t.Fatal(err)
+	}
+}
@@ -0,0 +1,184 @@
+package mavlink
+
+import (
+	"fmt"
+	"math"
+	"time"
+
+	"github.com/bluenviron/gomavlib/v3/pkg/dialects/common"
+)
+
+// DefaultGoToAcceptanceRadius is how close (meters, horizontally) a streamed
+// go-to target must get before it counts as reached
+const DefaultGoToAcceptanceRadius = 2.0
+
+// earthRadius is the mean Earth radius in meters
+const earthRadius = 6371000.0
+
+// gotoLagFactor is how many setpoint intervals may pass without a setpoint
+// going out before the stream is reported as falling behind
+const gotoLagFactor = 2
+
+// gotoStream re-sends one go-to target until it is reached or replaced
+type gotoStream struct {
+	stop chan struct{}
+	done chan struct{}
+
+	// Switch to hold when stopped (see stopGoTo); set before stop is closed
+	handoff bool
+}
+
+// startGoToStream re-sends the target at gotoSetpointRate on a background goroutine
+// PX4 leaves OFFBOARD when setpoints stop arriving, so a single setpoint may be ignored.
+func (c *Client) startGoToStream(latitude, longitude, altitude float64) {
+	s := &gotoStream{
+		stop: make(chan struct{}),
+		done: make(chan struct{}),
+	}
+
+	c.mu.Lock()
+	c.gotoStream = s
+	c.mu.Unlock()
+
+	go c.streamGoTo(s, latitude, longitude, altitude)
+}
+
+// streamGoTo sends setpoints until arrival, cancellation, or the vehicle leaving OFFBOARD
+// Without setpoints the vehicle fails safe once it notices, so when the stream
+// ends on arrival or CancelGoTo with the vehicle still in OFFBOARD, control is
+// handed back with a switch to hold (with gotoHoldOnStop). A stream falling
+// behind its rate, e.g. on a slow link, raises EventSetpointLagging.
+func (c *Client) streamGoTo(s *gotoStream, latitude, longitude, altitude float64) {
+	defer close(s.done)
+	defer c.clearGoToStream(s)
+
+	interval := time.Duration(float64(time.Second) / c.gotoSetpointRate)
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	lastSent := time.Now()
+	lagging := false
+
+	for {
+		select {
+		case <-s.stop:
+			if s.handoff {
+				c.handOffGoTo("Go-to cancelled")
+			}
+			return
+		case <-ticker.C:
+		}
+
+		t := c.GetTelemetry()
+
+		// A pilot or another command (land, RTL, a mode change) took over
+		if t.CustomMode&0xFF != PX4_MAIN_MODE_OFFBOARD {
+			c.logger.Printf("MAVLink: Vehicle left GUIDED mode, no longer streaming go-to target")
+			return
+		}
+
+		if gap := time.Since(lastSent); gap > gotoLagFactor*interval && !lagging {
+			lagging = true
+			message := fmt.Sprintf("Go-to setpoints falling behind: none sent for %s (want one every %s)",
+				gap.Round(time.Millisecond), interval)
+			c.logger.Printf("MAVLink: Warning - %s", message)
+			c.publishEvent(Event{
+				Kind:     EventSetpointLagging,
+				Severity: common.MAV_SEVERITY_WARNING,
+				Message:  message,
+			})
+		}
+
+		if distance := horizontalDistance(t.Latitude, t.Longitude, latitude, longitude); distance <= c.gotoAcceptanceRadius {
+			message := fmt.Sprintf("Reached go-to target lat=%.6f, lon=%.6f (%.1f m away)", latitude, longitude, distance)
+			c.logger.Printf("MAVLink: %s", message)
+			c.publishEvent(Event{
+				Kind:     EventTargetReached,
+				Severity: common.MAV_SEVERITY_INFO,
+				Message:  message,
+			})
+			c.handOffGoTo("Go-to target reached")
+			return
+		}
+
+		if err := c.sendPositionTarget(latitude, longitude, altitude); err != nil {
+			c.logger.Printf("MAVLink: Warning - failed to re-send go-to target: %v", err)
+			continue
+		}
+		lastSent = time.Now()
+		if lagging {
+			lagging = false
+			c.logger.Printf("MAVLink: Go-to setpoints back at rate")
+		}
+	}
+}
+
+// handOffGoTo switches a vehicle still in OFFBOARD to hold (AUTO.LOITER) once
+// its go-to stream ends, so it doesn't fail safe for lack of setpoints
+func (c *Client) handOffGoTo(reason string) {
+	if !c.gotoHoldOnStop || c.GetTelemetry().CustomMode&0xFF != PX4_MAIN_MODE_OFFBOARD {
+		return
+	}
+
+	if err := c.SetMode(uint32(PX4_MAIN_MODE_AUTO | (PX4_AUTO_MODE_LOITER << 16))); err != nil {
+		message := fmt.Sprintf("%s, but switching to hold failed: %v; the vehicle will fail safe without setpoints", reason, err)
+		c.logger.Printf("MAVLink: Warning - %s", message)
+		c.publishEvent(Event{
+			Kind:     EventSetpointHandoff,
+			Severity: common.MAV_SEVERITY_WARNING,
+			Message:  message,
+		})
+		return
+	}
+
+	message := reason + ", switched to hold"
+	c.logger.Printf("MAVLink: %s", message)
+	c.publishEvent(Event{
+		Kind:     EventSetpointHandoff,
+		Severity: common.MAV_SEVERITY_INFO,
+		Message:  message,
+	})
+}
+
+// clearGoToStream forgets s unless it was already replaced
+func (c *Client) clearGoToStream(s *gotoStream) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.gotoStream == s {
+		c.gotoStream = nil
+	}
+}
+
+// CancelGoTo stops re-sending the current go-to target
+// A vehicle still in OFFBOARD is switched to hold (with gotoHoldOnStop;
+// otherwise it keeps its last setpoint until it fails safe). Returns false if
+// no target was being streamed.
+func (c *Client) CancelGoTo() bool {
+	return c.stopGoTo(true)
+}
+
+// stopGoTo stops the go-to stream, handing off to hold if asked to
+// A target replacing another, or the client closing, doesn't hand off.
+func (c *Client) stopGoTo(handoff bool) bool {
+	c.mu.Lock()
+	s := c.gotoStream
+	c.gotoStream = nil
+	c.mu.Unlock()
+
+	if s == nil {
+		return false
+	}
+	s.handoff = handoff
+	close(s.stop)
+	<-s.done
+	return true
+}
+
+// horizontalDistance approximates the ground distance in meters between two points
+// Equirectangular projection: accurate to well under 1% over go-to distances.
+func horizontalDistance(lat1, lon1, lat2, lon2 float64) float64 {
+	const rad = math.Pi / 180
+	x := (lon2 - lon1) * rad * math.Cos((lat1+lat2)/2*rad)
+	y := (lat2 - lat1) * rad
+	return math.Hypot(x, y) * earthRadius
+}
@@ -0,0 +1,142 @@
+package mavlink
+
+import (
+	"testing"
+	"time"
+
+	"github.com/bluenviron/gomavlib/v3"
+	"github.com/bluenviron/gomavlib/v3/pkg/dialects/common"
+)
+
+// newGoToTestClient returns a linked test client, armed in OFFBOARD at (47, 8),
+// that re-sends go-to targets at 50 Hz
+func newGoToTestClient(t *testing.T) (*Client, *gomavlib.Node) {
+	t.Helper()
+	c, vehicle := newLinkedTestClient(t)
+	c.gotoSetpointRate = 50
+	c.gotoAcceptanceRadius = DefaultGoToAcceptanceRadius
+	t.Cleanup(func() { c.stopGoTo(false) })
+
+	c.handleMessage(&common.MessageHeartbeat{
+		Type:       common.MAV_TYPE_QUADROTOR,
+		Autopilot:  common.MAV_AUTOPILOT_PX4,
+		BaseMode:   common.MAV_MODE_FLAG_SAFETY_ARMED | common.MAV_MODE_FLAG_CUSTOM_MODE_ENABLED,
+		CustomMode: PX4_MAIN_MODE_OFFBOARD,
+	}, 1, 1)
+	c.handleMessage(&common.MessageGlobalPositionInt{Lat: 470000000, Lon: 80000000}, 1, 1)
+	return c, vehicle
+}
+
+// noMoreSetpoints fails if the vehicle keeps receiving setpoints once those
+// already on the link have arrived
+func noMoreSetpoints(t *testing.T, vehicle *gomavlib.Node) {
+	t.Helper()
+	drained := time.After(100 * time.Millisecond)
+	for draining := true; draining; {
+		select {
+		case <-vehicle.Events():
+		case <-drained:
+			draining = false
+		}
+	}
+
+	quiet := time.After(200 * time.Millisecond)
+	for {
+		select {
+		case evt := <-vehicle.Events():
+			if frame, ok := evt.(*gomavlib.EventFrame); ok {
+				if _, ok := frame.Message().(*common.MessageSetPositionTargetGlobalInt); ok {
+					t.Fatal("setpoints still being sent")
+				}
+			}
+		case <-quiet:
+			return
+		}
+	}
+}
+
+func TestGoToStreamUntilReached(t *testing.T) {
+	c, vehicle := newGoToTestClient(t)
+	events, unsubscribe := c.SubscribeEvents()
+	defer unsubscribe()
+
+	// About 110 m north
+	if err := c.GoToPosition(47.001, 8, 30); err != nil {
+		t.Fatal(err)
+	}
+	// The first setpoint and at least four re-sent ones
+	for i := 0; i < 5; i++ {
+		msg := receive[*common.MessageSetPositionTargetGlobalInt](t, vehicle)
+		if msg.LatInt != 470010000 || msg.LonInt != 80000000 || msg.Alt != 30 {
+			t.Fatalf("setpoint %d = %d, %d, %v", i, msg.LatInt, msg.LonInt, msg.Alt)
+		}
+	}
+
+	// About 1 m short: within the acceptance radius
+	c.handleMessage(&common.MessageGlobalPositionInt{Lat: 470009900, Lon: 80000000}, 1, 1)
+	select {
+	case evt := <-events:
+		if evt.Kind != EventTargetReached {
+			t.Fatalf("event = %s (%s), want target_reached", evt.Kind, evt.Message)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("target never reached")
+	}
+	noMoreSetpoints(t, vehicle)
+	if c.CancelGoTo() {
+		t.Error("stream still running after the target was reached")
+	}
+}
+
+func TestGoToStreamCancel(t *testing.T) {
+	c, vehicle := newGoToTestClient(t)
+	events, unsubscribe := c.SubscribeEvents()
+	defer unsubscribe()
+
+	if err := c.GoToPosition(47.001, 8, 30); err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < 3; i++ {
+		receive[*common.MessageSetPositionTargetGlobalInt](t, vehicle)
+	}
+
+	if !c.CancelGoTo() {
+		t.Fatal("CancelGoTo found no stream")
+	}
+	noMoreSetpoints(t, vehicle)
+	noEvent(t, events)
+}
+
+func TestGoToStreamHandoffOnCancel(t *testing.T) {
+	c, vehicle := newGoToTestClient(t)
+	c.gotoHoldOnStop = true
+	events, unsubscribe := c.SubscribeEvents()
+	defer unsubscribe()
+
+	if err := c.GoToPosition(47.001, 8, 30); err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < 3; i++ {
+		receive[*common.MessageSetPositionTargetGlobalInt](t, vehicle)
+	}
+
+	// Still in OFFBOARD when the stream closes: switched to hold
+	cancelled := make(chan bool, 1)
+	go func() { cancelled <- c.CancelGoTo() }()
+	hold := float32(PX4_MAIN_MODE_AUTO | PX4_AUTO_MODE_LOITER<<16)
+	expectCommand(t, c, vehicle, common.MAV_CMD_DO_SET_MODE,
+		float32(common.MAV_MODE_FLAG_CUSTOM_MODE_ENABLED), hold, common.MAV_RESULT_ACCEPTED)
+	if !<-cancelled {
+		t.Fatal("CancelGoTo found no stream")
+	}
+
+	select {
+	case evt := <-events:
+		if evt.Kind != EventSetpointHandoff || evt.Severity != common.MAV_SEVERITY_INFO {
+			t.Errorf("event = %s %s (%s), want an informational setpoint_handoff", evt.Kind, evt.Severity, evt.Message)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("no handoff event")
+	}
+	noMoreSetpoints(t, vehicle)
+}
@@ -0,0 +1,129 @@
+package mavlink
+
+import (
+	"fmt"
+	"math"
+	"time"
+
+	"github.com/bluenviron/gomavlib/v3/pkg/dialects/common"
+	"github.com/bluenviron/gomavlib/v3/pkg/message"
+)
+
+// homeConfirmTimeout bounds the wait for HOME_POSITION after DO_SET_HOME is accepted
+const homeConfirmTimeout = 3 * time.Second
+
+// HomePosition is the vehicle's home as reported by HOME_POSITION
+type HomePosition struct {
+	Latitude  float64   `json:"latitude"`  // degrees
+	Longitude float64   `json:"longitude"` // degrees
+	Altitude  float64   `json:"altitude"`  // meters (MSL)
+	Updated   time.Time `json:"updated"`
+}
+
+// GetHomePosition returns the last reported home and whether it is usable
+// A home is unusable until HOME_POSITION is received, and while it is 0, 0
+// (sent by some autopilots before a GPS fix).
+func (c *Client) GetHomePosition() (HomePosition, bool) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.home, !c.home.Updated.IsZero() && !(c.home.Latitude == 0 && c.home.Longitude == 0)
+}
+
+// handleHomePosition processes HOME_POSITION messages
+func (c *Client) handleHomePosition(msg *common.MessageHomePosition) {
+	home := HomePosition{
+		Latitude:  float64(msg.Latitude) / 1e7,
+		Longitude: float64(msg.Longitude) / 1e7,
+		Altitude:  float64(msg.Altitude) / 1000.0,
+		Updated:   time.Now(),
+	}
+
+	c.mu.Lock()
+	c.home = home
+	c.mu.Unlock()
+
+	c.homeUpdates.publish(home)
+}
+
+// SetHome sets the home position to a location (altitude MSL) with MAV_CMD_DO_SET_HOME
+// Returns the home the vehicle reports back once the command is accepted.
+func (c *Client) SetHome(latitude, longitude, altitude float64) (HomePosition, error) {
+	if err := validateHomeLocation(latitude, longitude, altitude); err != nil {
+		return HomePosition{}, err
+	}
+
+	c.logger.Printf("MAVLink: Setting home: lat=%.6f, lon=%.6f, alt=%.2f", latitude, longitude, altitude)
+
+	return c.setHome(
+		[4]float32{0}, // Use specified location
+		int32(latitude*1e7),
+		int32(longitude*1e7),
+		float32(altitude),
+	)
+}
+
+// SetHomeToCurrent sets the home position to the vehicle's current location
+// Returns the home the vehicle reports back once the command is accepted.
+func (c *Client) SetHomeToCurrent() (HomePosition, error) {
+	c.logger.Println("MAVLink: Setting home to current position")
+
+	return c.setHome([4]float32{1}, 0, 0, 0) // Use current location
+}
+
+// setHome sends DO_SET_HOME and waits for the HOME_POSITION that confirms it
+func (c *Client) setHome(params [4]float32, x, y int32, z float32) (HomePosition, error) {
+	if !c.IsConnected() {
+		return HomePosition{}, fmt.Errorf("not connected to drone")
+	}
+
+	// Subscribe first so a HOME_POSITION sent right after the ACK isn't missed
+	updates, unsubscribe := c.homeUpdates.subscribe()
+	defer unsubscribe()
+
+	if err := c.sendCommandInt(common.MAV_CMD_DO_SET_HOME, common.MAV_FRAME_GLOBAL_INT, params, x, y, z); err != nil {
+		return HomePosition{}, err
+	}
+
+	// Not every autopilot pushes HOME_POSITION on change, so ask for it
+	if err := c.requestMessage(&common.MessageHomePosition{}); err != nil {
+		c.logger.Printf("MAVLink: Warning - failed to request HOME_POSITION: %v", err)
+	}
+
+	select {
+	case home, ok := <-updates:
+		if !ok {
+			return HomePosition{}, fmt.Errorf("connection closed while waiting for home position")
+		}
+		c.logger.Printf("MAVLink: Home set: lat=%.6f, lon=%.6f, alt=%.2f", home.Latitude, home.Longitude, home.Altitude)
+		return home, nil
+	case <-time.After(homeConfirmTimeout):
+		return HomePosition{}, fmt.Errorf("home accepted but no HOME_POSITION received within %s", homeConfirmTimeout)
+	}
+}
+
+// requestMessage asks the vehicle to send one instance of a message
+func (c *Client) requestMessage(msg message.Message) error {
+	c.mu.RLock()
+	systemID := c.systemID
+	c.mu.RUnlock()
+
+	return c.writeMessage(&common.MessageCommandLong{
+		TargetSystem:    systemID,
+		TargetComponent: 1,
+		Command:         common.MAV_CMD_REQUEST_MESSAGE,
+		Param1:          float32(msg.GetID()),
+	})
+}
+
+// validateHomeLocation rejects coordinates the vehicle can't use as home
+func validateHomeLocation(latitude, longitude, altitude float64) error {
+	switch {
+	case math.IsNaN(latitude) || latitude < -90 || latitude > 90:
+		return fmt.Errorf("latitude out of range: %f", latitude)
+	case math.IsNaN(longitude) || longitude < -180 || longitude > 180:
+		return fmt.Errorf("longitude out of range: %f", longitude)
+	case math.IsNaN(altitude) || math.IsInf(altitude, 0):
+		return fmt.Errorf("invalid altitude: %f", altitude)
+	}
+	return nil
+}
@@ -0,0 +1,115 @@
+package mavlink
+
+import (
+	"testing"
+
+	"github.com/bluenviron/gomavlib/v3"
+	"github.com/bluenviron/gomavlib/v3/pkg/dialects/common"
+)
+
+// confirmHome acknowledges DO_SET_HOME and answers the HOME_POSITION request
+// that follows with home at lat, lon (1E7 degrees) and alt (mm)
+func confirmHome(t *testing.T, c *Client, vehicle *gomavlib.Node, lat, lon, alt int32) {
+	t.Helper()
+	ack(c, common.MAV_CMD_DO_SET_HOME, common.MAV_RESULT_ACCEPTED)
+	req := receive[*common.MessageCommandLong](t, vehicle)
+	if req.Command != common.MAV_CMD_REQUEST_MESSAGE || req.Param1 != float32((&common.MessageHomePosition{}).GetID()) {
+		t.Fatalf("sent %s for message %v, want a HOME_POSITION request", req.Command, req.Param1)
+	}
+	c.handleMessage(&common.MessageHomePosition{Latitude: lat, Longitude: lon, Altitude: alt}, 1, 1)
+}
+
+func TestSetHome(t *testing.T) {
+	c, vehicle := newLinkedTestClient(t)
+
+	type result struct {
+		home HomePosition
+		err  error
+	}
+	done := make(chan result, 1)
+	go func() {
+		home, err := c.SetHome(47.3977419, 8.5455938, 488.5)
+		done <- result{home, err}
+	}()
+
+	msg := receive[*common.MessageCommandInt](t, vehicle)
+	if msg.Command != common.MAV_CMD_DO_SET_HOME || msg.Frame != common.MAV_FRAME_GLOBAL_INT || msg.Param1 != 0 {
+		t.Fatalf("sent %s in %s with param1 %v", msg.Command, msg.Frame, msg.Param1)
+	}
+	if msg.X != 473977419 || msg.Y != 85455938 || msg.Z != 488.5 {
+		t.Errorf("location = %d, %d, %v", msg.X, msg.Y, msg.Z)
+	}
+	confirmHome(t, c, vehicle, 473977419, 85455938, 488500)
+
+	r := <-done
+	if r.err != nil {
+		t.Fatal(r.err)
+	}
+	if !near(r.home.Latitude, 47.3977419) || !near(r.home.Longitude, 8.5455938) || r.home.Altitude != 488.5 {
+		t.Errorf("confirmed home = %+v", r.home)
+	}
+	if home, ok := c.GetHomePosition(); !ok || home != r.home {
+		t.Errorf("GetHomePosition() = %+v, %v", home, ok)
+	}
+}
+
+func TestSetHomeToCurrent(t *testing.T) {
+	c, vehicle := newLinkedTestClient(t)
+
+	done := make(chan error, 1)
+	go func() {
+		_, err := c.SetHomeToCurrent()
+		done <- err
+	}()
+
+	msg := receive[*common.MessageCommandInt](t, vehicle)
+	if msg.Command != common.MAV_CMD_DO_SET_HOME || msg.Param1 != 1 || msg.X != 0 || msg.Y != 0 || msg.Z != 0 {
+		t.Fatalf("sent %s with param1 %v at %d, %d, %v", msg.Command, msg.Param1, msg.X, msg.Y, msg.Z)
+	}
+	confirmHome(t, c, vehicle, 470000000, 80000000, 400000)
+	if err := <-done; err != nil {
+		t.Fatal(err)
+	}
+	if home, _ := c.GetHomePosition(); home.Latitude != 47 || home.Altitude != 400 {
+		t.Errorf("home = %+v", home)
+	}
+}
+
+func TestSetHomeRejected(t *testing.T) {
+	c, vehicle := newLinkedTestClient(t)
+
+	done := make(chan error, 1)
+	go func() {
+		_, err := c.SetHomeToCurrent()
+		done <- err
+	}()
+	receive[*common.MessageCommandInt](t, vehicle)
+	ack(c, common.MAV_CMD_DO_SET_HOME, common.MAV_RESULT_DENIED)
+	if err := <-done; err == nil {
+		t.Error("denied DO_SET_HOME reported as set")
+	}
+
+	for _, loc := range [][3]float64{{91, 8, 0}, {47, -181, 0}} {
+		if _, err := c.SetHome(loc[0], loc[1], loc[2]); err == nil {
+			t.Errorf("home %v accepted", loc)
+		}
+	}
+}
+
+func TestHomeInvalidBeforeFix(t *testing.T) {
+	c := newConnectedTestClient()
+	if _, ok := c.GetHomePosition(); ok {
+		t.Error("home usable before HOME_POSITION")
+	}
+
+	// Sent by some autopilots before a GPS fix
+	c.handleMessage(&common.MessageHomePosition{Altitude: 488000}, 1, 1)
+	if _, ok := c.GetHomePosition(); ok {
+		t.Error("home at 0, 0 reported usable")
+	}
+
+	c.handleMessage(&common.MessageHomePosition{Latitude: 473977419, Longitude: 85455938, Altitude: 488000}, 1, 1)
+	if home, ok := c.GetHomePosition(); !ok || !near(home.Latitude, 47.3977419) {
+		t.Errorf("GetHomePosition() = %+v, %v", home, ok)
+	}
+}
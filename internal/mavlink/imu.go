@@ -0,0 +1,139 @@
+package mavlink
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/bluenviron/gomavlib/v3/pkg/dialects/common"
+)
+
+// standardGravity converts SCALED_IMU milli-g to m/s²
+const standardGravity = 9.80665
+
+// IMUSample is one raw IMU reading, for diagnosing sensor issues
+// Units are SI whichever message it came from: SCALED_IMU values are converted.
+type IMUSample struct {
+	Source string `json:"source"` // HIGHRES_IMU or SCALED_IMU
+	IMU    uint8  `json:"imu"`    // HIGHRES_IMU id (0 is IMU1); 0 for SCALED_IMU
+
+	// Sender's timestamp: µs (HIGHRES_IMU) converted from ms for SCALED_IMU
+	TimeUsec uint64 `json:"time_usec"`
+
+	XAcc  float64 `json:"x_acc"` // m/s²
+	YAcc  float64 `json:"y_acc"`
+	ZAcc  float64 `json:"z_acc"`
+	XGyro float64 `json:"x_gyro"` // rad/s
+	YGyro float64 `json:"y_gyro"`
+	ZGyro float64 `json:"z_gyro"`
+	XMag  float64 `json:"x_mag"` // gauss
+	YMag  float64 `json:"y_mag"`
+	ZMag  float64 `json:"z_mag"`
+
+	// HIGHRES_IMU only
+	AbsPressure  float64 `json:"abs_pressure,omitempty"`  // hPa
+	DiffPressure float64 `json:"diff_pressure,omitempty"` // hPa
+	PressureAlt  float64 `json:"pressure_alt,omitempty"`  // meters
+
+	Temperature float64   `json:"temperature"` // °C (0 when the IMU doesn't report it)
+	Updated     time.Time `json:"updated"`
+}
+
+// imuMessages are requested while IMU samples are subscribed: PX4 sends
+// HIGHRES_IMU, ArduPilot SCALED_IMU
+var imuMessages = []uint32{
+	(&common.MessageHighresImu{}).GetID(),
+	(&common.MessageScaledImu{}).GetID(),
+}
+
+// GetIMU returns the latest IMU sample and whether one was received
+// Autopilots don't send raw IMU data unless asked, so there is usually none
+// without a SubscribeIMU running.
+func (c *Client) GetIMU() (IMUSample, bool) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.imu, !c.imu.Updated.IsZero()
+}
+
+// SubscribeIMU requests raw IMU messages at rateHz and returns a channel
+// receiving every sample
+// The messages are requested with SET_MESSAGE_INTERVAL while at least one
+// subscriber remains; the last unsubscribe turns them off again, since raw IMU
+// data is high-bandwidth. Passive clients only listen for IMU data already on
+// the link. Call the returned function to unsubscribe; the channel is closed
+// afterwards.
+func (c *Client) SubscribeIMU(rateHz float64) (<-chan IMUSample, func(), error) {
+	if rateHz <= 0 {
+		return nil, nil, fmt.Errorf("invalid IMU rate: %g Hz", rateHz)
+	}
+
+	if !c.passive {
+		for _, id := range imuMessages {
+			if err := c.setMessageInterval(id, rateHz); err != nil {
+				return nil, nil, fmt.Errorf("failed to request IMU data: %w", err)
+			}
+		}
+	}
+
+	updates, unsubscribe := c.imuUpdates.subscribe()
+	return updates, func() {
+		unsubscribe()
+		if c.passive || c.imuUpdates.hasSubscribers() {
+			return
+		}
+		for _, id := range imuMessages {
+			if err := c.setMessageInterval(id, 0); err != nil {
+				c.logger.Printf("MAVLink: Warning - failed to stop IMU data: %v", err)
+			}
+		}
+	}, nil
+}
+
+// handleHighresIMU processes HIGHRES_IMU messages
+func (c *Client) handleHighresIMU(msg *common.MessageHighresImu) {
+	c.storeIMU(IMUSample{
+		Source:       "HIGHRES_IMU",
+		IMU:          msg.Id,
+		TimeUsec:     msg.TimeUsec,
+		XAcc:         float64(msg.Xacc),
+		YAcc:         float64(msg.Yacc),
+		ZAcc:         float64(msg.Zacc),
+		XGyro:        float64(msg.Xgyro),
+		YGyro:        float64(msg.Ygyro),
+		ZGyro:        float64(msg.Zgyro),
+		XMag:         float64(msg.Xmag),
+		YMag:         float64(msg.Ymag),
+		ZMag:         float64(msg.Zmag),
+		AbsPressure:  float64(msg.AbsPressure),
+		DiffPressure: float64(msg.DiffPressure),
+		PressureAlt:  float64(msg.PressureAlt),
+		Temperature:  float64(msg.Temperature),
+	})
+}
+
+// handleScaledIMU processes SCALED_IMU messages (mG, mrad/s, mgauss, c°C)
+func (c *Client) handleScaledIMU(msg *common.MessageScaledImu) {
+	c.storeIMU(IMUSample{
+		Source:      "SCALED_IMU",
+		TimeUsec:    uint64(msg.TimeBootMs) * 1000,
+		XAcc:        float64(msg.Xacc) / 1000 * standardGravity,
+		YAcc:        float64(msg.Yacc) / 1000 * standardGravity,
+		ZAcc:        float64(msg.Zacc) / 1000 * standardGravity,
+		XGyro:       float64(msg.Xgyro) / 1000,
+		YGyro:       float64(msg.Ygyro) / 1000,
+		ZGyro:       float64(msg.Zgyro) / 1000,
+		XMag:        float64(msg.Xmag) / 1000,
+		YMag:        float64(msg.Ymag) / 1000,
+		ZMag:        float64(msg.Zmag) / 1000,
+		Temperature: float64(msg.Temperature) / 100,
+	})
+}
+
+func (c *Client) storeIMU(s IMUSample) {
+	s.Updated = time.Now()
+
+	c.mu.Lock()
+	c.imu = s
+	c.mu.Unlock()
+
+	c.imuUpdates.publish(s)
+}
@@ -0,0 +1,102 @@
+package mavlink
+
+import (
+	"testing"
+	"time"
+
+	"github.com/bluenviron/gomavlib/v3"
+	"github.com/bluenviron/gomavlib/v3/pkg/dialects/common"
+)
+
+func TestHandleIMU(t *testing.T) {
+	c := newConnectedTestClient()
+	if _, ok := c.GetIMU(); ok {
+		t.Fatal("IMU sample before any was received")
+	}
+
+	c.handleMessage(&common.MessageHighresImu{
+		TimeUsec: 12345678, Id: 1,
+		Xacc: 0.1, Yacc: -0.2, Zacc: -9.81,
+		Xgyro: 0.01, Ygyro: 0.02, Zgyro: -0.03,
+		Xmag: 0.21, Ymag: 0.02, Zmag: 0.43,
+		AbsPressure: 1013.25, PressureAlt: 488, Temperature: 41.5,
+	}, 1, 1)
+	s, ok := c.GetIMU()
+	if !ok || s.Source != "HIGHRES_IMU" || s.IMU != 1 || s.TimeUsec != 12345678 {
+		t.Fatalf("sample = %+v", s)
+	}
+	if !near(s.ZAcc, -9.81) || !near(s.ZGyro, -0.03) || !near(s.ZMag, 0.43) || !near(s.AbsPressure, 1013.25) || s.Temperature != 41.5 {
+		t.Errorf("HIGHRES_IMU values = %+v", s)
+	}
+
+	// SCALED_IMU in mG, mrad/s, mgauss and c°C
+	c.handleMessage(&common.MessageScaledImu{
+		TimeBootMs: 5000, Zacc: -1000, Xgyro: 20, Ymag: -150, Temperature: 3550,
+	}, 1, 1)
+	s, _ = c.GetIMU()
+	if s.Source != "SCALED_IMU" || s.TimeUsec != 5000000 || s.AbsPressure != 0 {
+		t.Fatalf("sample = %+v", s)
+	}
+	if !near(s.ZAcc, -standardGravity) || !near(s.XGyro, 0.02) || !near(s.YMag, -0.15) || !near(s.Temperature, 35.5) {
+		t.Errorf("SCALED_IMU values = %+v", s)
+	}
+}
+
+// imuIntervals receives the SET_MESSAGE_INTERVAL commands for both IMU messages
+func imuIntervals(t *testing.T, vehicle *gomavlib.Node) map[uint32]float32 {
+	t.Helper()
+	intervals := make(map[uint32]float32)
+	for range imuMessages {
+		msg := receive[*common.MessageCommandLong](t, vehicle)
+		if msg.Command != common.MAV_CMD_SET_MESSAGE_INTERVAL {
+			t.Fatalf("sent %v", msg.Command)
+		}
+		intervals[uint32(msg.Param1)] = msg.Param2
+	}
+	return intervals
+}
+
+func TestSubscribeIMU(t *testing.T) {
+	c, vehicle := newLinkedTestClient(t)
+	highres, scaled := imuMessages[0], imuMessages[1]
+
+	if _, _, err := c.SubscribeIMU(0); err == nil {
+		t.Error("0 Hz accepted")
+	}
+
+	// Requested on demand
+	samples, unsubscribe, err := c.SubscribeIMU(50)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := imuIntervals(t, vehicle); got[highres] != 20000 || got[scaled] != 20000 {
+		t.Errorf("intervals = %v, want 20000 us", got)
+	}
+	second, unsubscribeSecond, err := c.SubscribeIMU(50)
+	if err != nil {
+		t.Fatal(err)
+	}
+	imuIntervals(t, vehicle)
+
+	c.handleMessage(&common.MessageHighresImu{Zacc: -9.81}, 1, 1)
+	for _, ch := range []<-chan IMUSample{samples, second} {
+		select {
+		case s := <-ch:
+			if !near(s.ZAcc, -9.81) {
+				t.Errorf("sample = %+v", s)
+			}
+		case <-time.After(time.Second):
+			t.Fatal("sample not delivered")
+		}
+	}
+
+	// Turned off after the last subscriber leaves
+	unsubscribe()
+	if _, open := <-samples; open {
+		t.Error("channel open after unsubscribing")
+	}
+	unsubscribeSecond()
+	if got := imuIntervals(t, vehicle); got[highres] != -1 || got[scaled] != -1 {
+		t.Errorf("intervals after the last unsubscribe = %v, want -1", got)
+	}
+}
@@ -0,0 +1,87 @@
+package mavlink
+
+import (
+	"errors"
+	"fmt"
+	"math"
+
+	"github.com/bluenviron/gomavlib/v3/pkg/dialects/common"
+)
+
+// ErrAboveMaxAltitude is returned for altitudes above the client's ceiling
+var ErrAboveMaxAltitude = errors.New("above the maximum altitude")
+
+// AltitudeLimit is a ceiling in meters above home for takeoff, go-to,
+// reposition and mission altitudes
+// Clamp lowers takeoff, go-to and reposition altitudes above it to the ceiling
+// instead of rejecting them. Missions are always rejected: clamping would
+// silently reshape the plan.
+type AltitudeLimit struct {
+	Max   float64 `json:"max"`
+	Clamp bool    `json:"clamp"`
+}
+
+// Validate checks the ceiling is a positive altitude
+func (l AltitudeLimit) Validate() error {
+	if math.IsNaN(l.Max) || l.Max <= 0 {
+		return fmt.Errorf("maximum altitude must be above 0 m: %v", l.Max)
+	}
+	return nil
+}
+
+// MaxAltitude returns the client's altitude ceiling, or false when altitudes aren't limited
+func (c *Client) MaxAltitude() (AltitudeLimit, bool) {
+	if c.maxAltitude == nil {
+		return AltitudeLimit{}, false
+	}
+	return *c.maxAltitude, true
+}
+
+// limitAltitude applies the ceiling to a command altitude (meters above home)
+// Returns the altitude to send: unchanged at or below the ceiling, the ceiling
+// when clamping, else ErrAboveMaxAltitude.
+func (c *Client) limitAltitude(altitude float64) (float64, error) {
+	if c.maxAltitude == nil || altitude <= c.maxAltitude.Max {
+		return altitude, nil
+	}
+	if !c.maxAltitude.Clamp {
+		return 0, fmt.Errorf("altitude %.1f m is %w (%.1f m above home)", altitude, ErrAboveMaxAltitude, c.maxAltitude.Max)
+	}
+	c.logger.Printf("MAVLink: Warning - altitude %.1f m clamped to the %.1f m ceiling", altitude, c.maxAltitude.Max)
+	return c.maxAltitude.Max, nil
+}
+
+// checkMissionAltitude rejects a mission whose navigation items climb above the ceiling
+// Relative items are compared directly, terrain-relative ones too (height above
+// ground is what altitude rules limit) and AMSL ones against home, which must
+// then be known. Items in other frames are not checked.
+func (c *Client) checkMissionAltitude(items []MissionItem) error {
+	if c.maxAltitude == nil {
+		return nil
+	}
+	for i, item := range items {
+		if item.Command >= common.MAV_CMD_NAV_LAST {
+			continue
+		}
+
+		altitude := float64(item.Z)
+		switch item.Frame {
+		case common.MAV_FRAME_GLOBAL_RELATIVE_ALT, common.MAV_FRAME_GLOBAL_RELATIVE_ALT_INT,
+			common.MAV_FRAME_GLOBAL_TERRAIN_ALT, common.MAV_FRAME_GLOBAL_TERRAIN_ALT_INT:
+		case common.MAV_FRAME_GLOBAL, common.MAV_FRAME_GLOBAL_INT:
+			home, ok := c.GetHomePosition()
+			if !ok {
+				return fmt.Errorf("mission item %d: no home position to check its AMSL altitude against the maximum altitude", i)
+			}
+			altitude -= home.Altitude
+		default:
+			continue
+		}
+
+		if altitude > c.maxAltitude.Max {
+			return fmt.Errorf("mission item %d: altitude %.1f m is %w (%.1f m above home)",
+				i, altitude, ErrAboveMaxAltitude, c.maxAltitude.Max)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,111 @@
+package mavlink
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/bluenviron/gomavlib/v3/pkg/dialects/common"
+)
+
+func TestAltitudeLimitValidate(t *testing.T) {
+	if err := (AltitudeLimit{Max: 120}).Validate(); err != nil {
+		t.Errorf("120 m: %v", err)
+	}
+	if err := (AltitudeLimit{Max: 0}).Validate(); err == nil {
+		t.Error("0 m accepted")
+	}
+}
+
+func TestTakeoffMaxAltitude(t *testing.T) {
+	tests := []struct {
+		name     string
+		clamp    bool
+		altitude float32
+		want     float32 // sent altitude; 0 when rejected
+	}{
+		{"reject at ceiling", false, 120, 120},
+		{"reject above ceiling", false, 150, 0},
+		{"clamp at ceiling", true, 120, 120},
+		{"clamp above ceiling", true, 150, 120},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c, vehicle := newLinkedTestClient(t)
+			c.maxAltitude = &AltitudeLimit{Max: 120, Clamp: tt.clamp}
+
+			err := c.Takeoff(tt.altitude)
+			if tt.want == 0 {
+				if !errors.Is(err, ErrAboveMaxAltitude) {
+					t.Fatalf("Takeoff(%v) = %v, want ErrAboveMaxAltitude", tt.altitude, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			msg := receive[*common.MessageCommandLong](t, vehicle)
+			if msg.Command != common.MAV_CMD_NAV_TAKEOFF || msg.Param7 != tt.want {
+				t.Errorf("sent %v to %v m, want NAV_TAKEOFF to %v m", msg.Command, msg.Param7, tt.want)
+			}
+		})
+	}
+}
+
+func TestGoToMaxAltitude(t *testing.T) {
+	c, vehicle := newLinkedTestClient(t)
+	c.maxAltitude = &AltitudeLimit{Max: 120}
+
+	if err := c.GoToPosition(47.001, 8, 120.5); !errors.Is(err, ErrAboveMaxAltitude) {
+		t.Fatalf("GoToPosition above the ceiling = %v", err)
+	}
+	if err := c.Reposition(47.001, 8, 200, 0, 0); !errors.Is(err, ErrAboveMaxAltitude) {
+		t.Fatalf("Reposition above the ceiling = %v", err)
+	}
+
+	c.maxAltitude.Clamp = true
+	if err := c.GoToPosition(47.001, 8, 200); err != nil {
+		t.Fatal(err)
+	}
+	if msg := receive[*common.MessageSetPositionTargetGlobalInt](t, vehicle); msg.Alt != 120 {
+		t.Errorf("setpoint altitude = %v, want the 120 m ceiling", msg.Alt)
+	}
+}
+
+func TestMissionMaxAltitude(t *testing.T) {
+	c := newConnectedTestClient()
+	c.maxAltitude = &AltitudeLimit{Max: 120, Clamp: true}
+	waypoint := func(frame common.MAV_FRAME, z float32) MissionItem {
+		return MissionItem{Command: common.MAV_CMD_NAV_WAYPOINT, Frame: frame, Z: z}
+	}
+
+	// Missions are rejected even when other commands clamp
+	if err := c.checkMissionAltitude([]MissionItem{
+		waypoint(common.MAV_FRAME_GLOBAL_RELATIVE_ALT_INT, 120),
+		// Not a navigation item: its Z isn't an altitude
+		{Command: common.MAV_CMD_DO_CHANGE_SPEED, Frame: common.MAV_FRAME_GLOBAL_RELATIVE_ALT_INT, Z: 500},
+	}); err != nil {
+		t.Errorf("mission at the ceiling: %v", err)
+	}
+	err := c.UploadItems(common.MAV_MISSION_TYPE_MISSION, []MissionItem{
+		waypoint(common.MAV_FRAME_GLOBAL_RELATIVE_ALT_INT, 100),
+		waypoint(common.MAV_FRAME_GLOBAL_TERRAIN_ALT_INT, 121),
+	})
+	if !errors.Is(err, ErrAboveMaxAltitude) || c.missionState.Uploading {
+		t.Fatalf("mission above the ceiling: %v (uploading %v)", err, c.missionState.Uploading)
+	}
+
+	// AMSL altitudes are measured from home
+	amsl := []MissionItem{waypoint(common.MAV_FRAME_GLOBAL_INT, 600)}
+	if err := c.checkMissionAltitude(amsl); err == nil || errors.Is(err, ErrAboveMaxAltitude) {
+		t.Errorf("AMSL item without a home = %v, want a missing home error", err)
+	}
+	c.handleMessage(&common.MessageHomePosition{Latitude: 470000000, Longitude: 80000000, Altitude: 488000}, 1, 1)
+	if err := c.checkMissionAltitude(amsl); err != nil {
+		t.Errorf("AMSL item 112 m above home: %v", err)
+	}
+	c.handleMessage(&common.MessageHomePosition{Latitude: 470000000, Longitude: 80000000, Altitude: 470000}, 1, 1)
+	if err := c.checkMissionAltitude(amsl); !errors.Is(err, ErrAboveMaxAltitude) {
+		t.Errorf("AMSL item 130 m above home = %v, want ErrAboveMaxAltitude", err)
+	}
+}
@@ -0,0 +1,121 @@
+package mavlink
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+	"unicode"
+
+	"github.com/bluenviron/gomavlib/v3/pkg/dialect"
+	"github.com/bluenviron/gomavlib/v3/pkg/dialects/common"
+	"github.com/bluenviron/gomavlib/v3/pkg/message"
+)
+
+// coreMessages are always decoded: the client can't work without them,
+// and everything it sends must be in the dialect to be encoded.
+var coreMessages = []message.Message{
+	// Inbound
+	&common.MessageHeartbeat{},
+	&common.MessageCommandAck{},
+	&common.MessageMissionRequest{},
+	&common.MessageMissionRequestInt{}, // also sent
+	&common.MessageMissionAck{},        // also sent
+	&common.MessageHomePosition{},
+	&common.MessageTimesync{}, // also sent
+	&common.MessageParamValue{},
+	&common.MessageProtocolVersion{},
+	&common.MessageTerrainRequest{},
+	&common.MessageTerrainReport{},
+
+	// Outbound
+	&common.MessageCommandCancel{},
+	&common.MessageCommandInt{},
+	&common.MessageCommandLong{},
+	&common.MessageGpsRtcmData{},
+	&common.MessageMissionClearAll{},
+	&common.MessageMissionCount{},   // also received
+	&common.MessageMissionItemInt{}, // also received
+	&common.MessageMissionRequestList{},
+	&common.MessageMissionSetCurrent{},
+	&common.MessageParamRequestList{},
+	&common.MessageParamRequestRead{},
+	&common.MessageParamSet{},
+	&common.MessageRequestDataStream{},
+	&common.MessageSafetySetAllowedArea{},
+	&common.MessageSetPositionTargetGlobalInt{},
+	&common.MessageStatustext{}, // also received
+	&common.MessageSystemTime{},
+	&common.MessageTerrainData{},
+}
+
+// messagesByName indexes the common dialect by MAVLink message name (e.g. "GPS_RAW_INT")
+var messagesByName = func() map[string]message.Message {
+	byName := make(map[string]message.Message, len(common.Dialect.Messages))
+	for _, msg := range common.Dialect.Messages {
+		byName[messageName(msg)] = msg
+	}
+	return byName
+}()
+
+// messageName derives the MAVLink name from a gomavlib message type
+// (MessageGpsRawInt -> GPS_RAW_INT)
+func messageName(msg message.Message) string {
+	typeName := strings.TrimPrefix(reflect.TypeOf(msg).Elem().Name(), "Message")
+
+	var b strings.Builder
+	for i, r := range typeName {
+		if i > 0 && unicode.IsUpper(r) {
+			prev := rune(typeName[i-1])
+			if unicode.IsLower(prev) || unicode.IsDigit(prev) {
+				b.WriteByte('_')
+			}
+		}
+		b.WriteRune(unicode.ToUpper(r))
+	}
+	return b.String()
+}
+
+// ValidateMessageNames checks that every name is a MAVLink message in the common dialect
+func ValidateMessageNames(names []string) error {
+	for _, name := range names {
+		if _, ok := messagesByName[strings.ToUpper(name)]; !ok {
+			return fmt.Errorf("unknown message: %s", name)
+		}
+	}
+	return nil
+}
+
+// inboundFilter builds the decoding dialect and handled-ID set for an allowlist
+// An empty allowlist returns the full common dialect and a nil set (handle everything).
+// Messages outside the dialect reach the listener undecoded as MessageRaw.
+func inboundFilter(allowlist []string) (*dialect.Dialect, map[uint32]bool, error) {
+	if len(allowlist) == 0 {
+		return common.Dialect, nil, nil
+	}
+
+	allowed := make(map[uint32]bool)
+	messages := make([]message.Message, 0, len(coreMessages)+len(allowlist))
+
+	add := func(msg message.Message) {
+		if !allowed[msg.GetID()] {
+			allowed[msg.GetID()] = true
+			messages = append(messages, msg)
+		}
+	}
+
+	for _, msg := range coreMessages {
+		add(msg)
+	}
+	for _, name := range allowlist {
+		msg, ok := messagesByName[strings.ToUpper(name)]
+		if !ok {
+			return nil, nil, fmt.Errorf("unknown message: %s", name)
+		}
+		add(msg)
+	}
+
+	return &dialect.Dialect{
+		Version:  common.Dialect.Version,
+		Messages: messages,
+	}, allowed, nil
+}
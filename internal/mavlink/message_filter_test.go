@@ -0,0 +1,156 @@
+package mavlink
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"io"
+	"testing"
+
+	"github.com/bluenviron/gomavlib/v3/pkg/dialect"
+	"github.com/bluenviron/gomavlib/v3/pkg/dialects/common"
+	"github.com/bluenviron/gomavlib/v3/pkg/frame"
+	"github.com/bluenviron/gomavlib/v3/pkg/message"
+	"github.com/bluenviron/gomavlib/v3/pkg/streamwriter"
+)
+
+// newDialectRW returns a ReadWriter for d
+func newDialectRW(tb testing.TB, d *dialect.Dialect) *dialect.ReadWriter {
+	tb.Helper()
+	rw := &dialect.ReadWriter{Dialect: d}
+	if err := rw.Initialize(); err != nil {
+		tb.Fatal(err)
+	}
+	return rw
+}
+
+// encodeStream encodes messages from system 1 as a MAVLink 2 byte stream
+func encodeStream(tb testing.TB, msgs []message.Message) []byte {
+	tb.Helper()
+	var buf bytes.Buffer
+	fw := &frame.Writer{ByteWriter: &buf, DialectRW: newDialectRW(tb, common.Dialect)}
+	if err := fw.Initialize(); err != nil {
+		tb.Fatal(err)
+	}
+	w := &streamwriter.Writer{FrameWriter: fw, Version: streamwriter.V2, SystemID: 1}
+	if err := w.Initialize(); err != nil {
+		tb.Fatal(err)
+	}
+	for _, msg := range msgs {
+		if err := w.Write(msg); err != nil {
+			tb.Fatal(err)
+		}
+	}
+	return buf.Bytes()
+}
+
+// receiveStream decodes a byte stream and handles its messages the way listen
+// does with the given inbound filter
+func receiveStream(tb testing.TB, c *Client, stream []byte, rw *dialect.ReadWriter, allowed map[uint32]bool) {
+	r := &frame.Reader{BufByteReader: bufio.NewReader(bytes.NewReader(stream)), DialectRW: rw}
+	if err := r.Initialize(); err != nil {
+		tb.Fatal(err)
+	}
+
+	for {
+		fr, err := r.Read()
+		if errors.Is(err, io.EOF) {
+			return
+		}
+		if err != nil {
+			tb.Fatal(err)
+		}
+		msg := fr.GetMessage()
+		if allowed != nil && !allowed[msg.GetID()] {
+			continue
+		}
+		c.handleMessage(msg, fr.GetSystemID(), fr.GetComponentID())
+	}
+}
+
+// noisyLink is one second of a busy link: the telemetry the client uses and
+// high-rate messages it doesn't
+func noisyLink() []message.Message {
+	msgs := []message.Message{&common.MessageHeartbeat{
+		Type:      common.MAV_TYPE_QUADROTOR,
+		Autopilot: common.MAV_AUTOPILOT_PX4,
+	}}
+	for i := 0; i < 50; i++ {
+		msgs = append(msgs,
+			&common.MessageAttitude{Roll: 0.1, Pitch: 0.2, Yaw: 0.3},
+			&common.MessageServoOutputRaw{Servo1Raw: 1500, Servo2Raw: 1500},
+			&common.MessageScaledImu{Xacc: 1, Yacc: 2, Zacc: 1000},
+			&common.MessageHighresImu{Xacc: 0.01, Yacc: 0.02, Zacc: 9.81},
+		)
+	}
+	for i := 0; i < 5; i++ {
+		msgs = append(msgs, &common.MessageGpsRawInt{FixType: common.GPS_FIX_TYPE_3D_FIX, SatellitesVisible: 12})
+	}
+	return msgs
+}
+
+func TestInboundFilterIgnoresOtherMessages(t *testing.T) {
+	d, allowed, err := inboundFilter([]string{"attitude"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !allowed[(&common.MessageAttitude{}).GetID()] || !allowed[(&common.MessageHeartbeat{}).GetID()] {
+		t.Error("allowlisted or core message not allowed")
+	}
+	if allowed[(&common.MessageGpsRawInt{}).GetID()] {
+		t.Error("GPS_RAW_INT allowed without being allowlisted")
+	}
+
+	stream := encodeStream(t, noisyLink())
+
+	// Without a filter GPS_RAW_INT sets the satellite count
+	unfiltered := newTestClient()
+	receiveStream(t, unfiltered, stream, newDialectRW(t, common.Dialect), nil)
+	if got := unfiltered.GetTelemetry().SatelliteCount; got != 12 {
+		t.Fatalf("unfiltered: %d satellites, want 12", got)
+	}
+
+	c := newTestClient()
+	receiveStream(t, c, stream, newDialectRW(t, d), allowed)
+
+	telemetry := c.GetTelemetry()
+	if telemetry.Roll == 0 {
+		t.Error("allowlisted ATTITUDE was not handled")
+	}
+	if telemetry.SatelliteCount != 0 {
+		t.Errorf("filtered GPS_RAW_INT was handled: %d satellites", telemetry.SatelliteCount)
+	}
+
+	if _, _, err := inboundFilter([]string{"NOT_A_MESSAGE"}); err == nil {
+		t.Error("unknown message name accepted")
+	}
+}
+
+// BenchmarkInboundFilter decodes and handles one second of a noisy link with
+// every message handled and with only ATTITUDE and GPS_RAW_INT allowlisted
+func BenchmarkInboundFilter(b *testing.B) {
+	stream := encodeStream(b, noisyLink())
+	filtered, allowed, err := inboundFilter([]string{"ATTITUDE", "GPS_RAW_INT"})
+	if err != nil {
+		b.Fatal(err)
+	}
+
+	for _, bm := range []struct {
+		name    string
+		dialect *dialect.Dialect
+		allowed map[uint32]bool
+	}{
+		{"all", common.Dialect, nil},
+		{"allowlist", filtered, allowed},
+	} {
+		b.Run(bm.name, func(b *testing.B) {
+			c := newTestClient()
+			rw := newDialectRW(b, bm.dialect)
+			b.ReportAllocs()
+			b.SetBytes(int64(len(stream)))
+			for i := 0; i < b.N; i++ {
+				receiveStream(b, c, stream, rw, bm.allowed)
+			}
+		})
+	}
+}
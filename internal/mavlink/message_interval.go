@@ -0,0 +1,138 @@
+package mavlink
+
+import (
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/bluenviron/gomavlib/v3/pkg/dialects/common"
+	"github.com/bluenviron/gomavlib/v3/pkg/message"
+)
+
+// intervalMessages are the messages whose rate can be set by name
+var intervalMessages = map[string]message.Message{
+	"ATTITUDE":            &common.MessageAttitude{},
+	"SYS_STATUS":          &common.MessageSysStatus{},
+	"GLOBAL_POSITION_INT": &common.MessageGlobalPositionInt{},
+	"VFR_HUD":             &common.MessageVfrHud{},
+	"GPS_RAW_INT":         &common.MessageGpsRawInt{},
+	"MISSION_CURRENT":     &common.MessageMissionCurrent{},
+	"BATTERY_STATUS":      &common.MessageBatteryStatus{},
+	"RADIO_STATUS":        &common.MessageRadioStatus{},
+	"EXTENDED_SYS_STATE":  &common.MessageExtendedSysState{},
+	"HOME_POSITION":       &common.MessageHomePosition{},
+}
+
+// streamRerequestDelay lets the vehicle settle after an arm or mode change
+// before telemetry is requested again; further changes restart the wait
+const streamRerequestDelay = time.Second
+
+// ValidateMessageRates checks that every message name is known and every rate is >= 0
+func ValidateMessageRates(rates map[string]float64) error {
+	for name, hz := range rates {
+		if _, ok := intervalMessages[name]; !ok {
+			return fmt.Errorf("unknown message: %s", name)
+		}
+		if hz < 0 {
+			return fmt.Errorf("invalid rate for %s: %g Hz", name, hz)
+		}
+	}
+	return nil
+}
+
+// SetMessageRates sets per-message intervals with MAV_CMD_SET_MESSAGE_INTERVAL
+// Rates are in Hz; 0 disables the message.
+func (c *Client) SetMessageRates(rates map[string]float64) error {
+	if err := ValidateMessageRates(rates); err != nil {
+		return err
+	}
+
+	if !c.IsConnected() {
+		return fmt.Errorf("not connected to drone")
+	}
+
+	// Sorted for a deterministic command order
+	names := make([]string, 0, len(rates))
+	for name := range rates {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		if err := c.setMessageInterval(intervalMessages[name].GetID(), rates[name]); err != nil {
+			return fmt.Errorf("failed to set %s interval: %w", name, err)
+		}
+	}
+
+	c.mu.Lock()
+	c.messageRates = rates
+	c.mu.Unlock()
+
+	return nil
+}
+
+// requestTelemetry requests telemetry at the current message rates, or all
+// data streams, as the protocol version allows (see StreamRequestMethod)
+// Failures are logged; telemetry may still arrive at the vehicle's defaults.
+func (c *Client) requestTelemetry(protocolVersion uint16) {
+	c.mu.RLock()
+	rates := c.messageRates
+	c.mu.RUnlock()
+
+	if StreamRequestMethod(protocolVersion, len(rates) > 0) == StreamRequestMessageInterval {
+		if err := c.SetMessageRates(rates); err != nil {
+			c.logger.Printf("MAVLink: Warning - failed to set message rates: %v", err)
+		}
+		return
+	}
+
+	if len(rates) > 0 {
+		c.logger.Printf("MAVLink: Warning - MAVLink 1 vehicle, requesting data streams instead of message rates")
+	}
+	if err := c.requestDataStreams(); err != nil {
+		c.logger.Printf("MAVLink: Warning - failed to request data streams: %v", err)
+	}
+}
+
+// scheduleStreamRerequest requests telemetry again streamRerequestDelay from
+// now, replacing a re-request still waiting; caller must hold c.mu
+func (c *Client) scheduleStreamRerequest() {
+	if c.streamRerequest == nil {
+		c.streamRerequest = time.AfterFunc(streamRerequestDelay, c.rerequestTelemetry)
+		return
+	}
+	c.streamRerequest.Reset(streamRerequestDelay)
+}
+
+// rerequestTelemetry re-issues the telemetry requests after an arm or mode change
+func (c *Client) rerequestTelemetry() {
+	if !c.IsConnected() {
+		return
+	}
+	c.logger.Println("MAVLink: Arm or mode change - requesting telemetry again")
+	version, _ := c.ProtocolVersion()
+	c.requestTelemetry(version)
+}
+
+// setMessageInterval sends one MAV_CMD_SET_MESSAGE_INTERVAL command
+func (c *Client) setMessageInterval(messageID uint32, rateHz float64) error {
+	c.mu.RLock()
+	systemID := c.systemID
+	c.mu.RUnlock()
+
+	// Interval in microseconds; -1 disables the message
+	interval := float32(-1)
+	if rateHz > 0 {
+		interval = float32(1e6 / rateHz)
+	}
+
+	c.logger.Printf("MAVLink: Setting message %d interval to %.0f us", messageID, interval)
+
+	return c.writeMessage(&common.MessageCommandLong{
+		TargetSystem:    systemID,
+		TargetComponent: 1,
+		Command:         common.MAV_CMD_SET_MESSAGE_INTERVAL,
+		Param1:          float32(messageID),
+		Param2:          interval,
+	})
+}
@@ -0,0 +1,101 @@
+package mavlink
+
+import (
+	"testing"
+
+	"github.com/bluenviron/gomavlib/v3/pkg/dialects/common"
+
+	"github.com/flightpath-dev/flightpath-server/internal/config"
+)
+
+func TestTelemetryProfileSetsIntervals(t *testing.T) {
+	for name, profile := range config.DefaultTelemetryProfiles() {
+		t.Run(name, func(t *testing.T) {
+			c, vehicle := newLinkedTestClient(t)
+			if err := c.SetMessageRates(profile); err != nil {
+				t.Fatal(err)
+			}
+
+			want := make(map[uint32]float32, len(profile))
+			for msgName, hz := range profile {
+				interval := float32(-1) // disabled
+				if hz > 0 {
+					interval = float32(1e6 / hz)
+				}
+				want[intervalMessages[msgName].GetID()] = interval
+			}
+			for range profile {
+				msg := receive[*common.MessageCommandLong](t, vehicle)
+				if msg.Command != common.MAV_CMD_SET_MESSAGE_INTERVAL {
+					t.Fatalf("command = %s", msg.Command)
+				}
+				id := uint32(msg.Param1)
+				interval, ok := want[id]
+				if !ok {
+					t.Fatalf("unexpected or repeated interval for message %d", id)
+				}
+				if msg.Param2 != interval {
+					t.Errorf("message %d interval = %v us, want %v", id, msg.Param2, interval)
+				}
+				delete(want, id)
+			}
+		})
+	}
+}
+
+func TestSetMessageRatesRejectsUnknownMessages(t *testing.T) {
+	c, vehicle := newLinkedTestClient(t)
+	if err := c.SetMessageRates(map[string]float64{"ATTITUDE": 10, "HIGHRES_IMU": 50}); err == nil {
+		t.Fatal("unknown message accepted")
+	}
+	if err := c.SetMessageRates(map[string]float64{"ATTITUDE": -1}); err == nil {
+		t.Fatal("negative rate accepted")
+	}
+
+	// Nothing was sent for either: the next command is this one
+	if err := c.SetMessageRates(map[string]float64{"VFR_HUD": 4}); err != nil {
+		t.Fatal(err)
+	}
+	if msg := receive[*common.MessageCommandLong](t, vehicle); uint32(msg.Param1) != (&common.MessageVfrHud{}).GetID() {
+		t.Errorf("first interval sent for message %v", msg.Param1)
+	}
+}
+
+func TestStreamRerequestOnArm(t *testing.T) {
+	heartbeat := func(c *Client, baseMode common.MAV_MODE_FLAG) {
+		c.handleMessage(&common.MessageHeartbeat{
+			Type:      common.MAV_TYPE_QUADROTOR,
+			Autopilot: common.MAV_AUTOPILOT_PX4,
+			BaseMode:  baseMode,
+		}, 1, 1)
+	}
+	scheduled := func(c *Client) bool {
+		c.mu.RLock()
+		defer c.mu.RUnlock()
+		return c.streamRerequest != nil
+	}
+
+	// Switched off: arming schedules nothing
+	off, _ := newLinkedTestClient(t)
+	heartbeat(off, common.MAV_MODE_FLAG_SAFETY_ARMED)
+	if scheduled(off) {
+		t.Error("re-request scheduled with rerequestStreams off")
+	}
+
+	c, vehicle := newLinkedTestClient(t)
+	c.rerequestStreams = true
+	c.messageRates = map[string]float64{"ATTITUDE": 20}
+
+	// An unchanged heartbeat isn't a transition
+	heartbeat(c, 0)
+	if scheduled(c) {
+		t.Fatal("re-request scheduled without a transition")
+	}
+
+	heartbeat(c, common.MAV_MODE_FLAG_SAFETY_ARMED)
+	msg := receive[*common.MessageCommandLong](t, vehicle)
+	if msg.Command != common.MAV_CMD_SET_MESSAGE_INTERVAL ||
+		uint32(msg.Param1) != (&common.MessageAttitude{}).GetID() || msg.Param2 != 50000 {
+		t.Errorf("after arming: %s message %v interval %v us", msg.Command, msg.Param1, msg.Param2)
+	}
+}
@@ -0,0 +1,121 @@
+package mavlink
+
+import (
+	"time"
+
+	"github.com/bluenviron/gomavlib/v3/pkg/dialects/common"
+
+	drone "github.com/flightpath-dev/flightpath-proto/gen/go/drone/v1"
+)
+
+// Non-navigation mission items
+//
+// DO_/CONDITION_ commands don't fly anywhere, so their params don't follow the
+// waypoint layout used by waypointToMissionItem. Each builder below places the
+// params where the command definition expects them:
+//
+//	MAV_CMD_DO_SET_ROI_LOCATION: X/Y/Z = ROI latitude/longitude/altitude
+//	MAV_CMD_DO_SET_ROI_NONE:     no params
+//	MAV_CMD_DO_MOUNT_CONTROL:    Param1-3 = pitch/roll/yaw (deg), Z (param7) = MAV_MOUNT_MODE
+
+// NewROILocationItem points the camera/gimbal at a location (altitude relative to home)
+func NewROILocationItem(latitude, longitude, altitude float64) MissionItem {
+	return MissionItem{
+		Command:      common.MAV_CMD_DO_SET_ROI_LOCATION,
+		Frame:        common.MAV_FRAME_GLOBAL_RELATIVE_ALT_INT,
+		Autocontinue: true,
+		X:            int32(latitude * 1e7),
+		Y:            int32(longitude * 1e7),
+		Z:            float32(altitude),
+	}
+}
+
+// NewROINoneItem cancels a previous ROI so the camera follows the vehicle heading again
+func NewROINoneItem() MissionItem {
+	return MissionItem{
+		Command:      common.MAV_CMD_DO_SET_ROI_NONE,
+		Frame:        common.MAV_FRAME_MISSION,
+		Autocontinue: true,
+	}
+}
+
+// NewMountControlItem sets the gimbal angles in degrees
+func NewMountControlItem(pitch, roll, yaw float32, mode common.MAV_MOUNT_MODE) MissionItem {
+	return MissionItem{
+		Command:      common.MAV_CMD_DO_MOUNT_CONTROL,
+		Frame:        common.MAV_FRAME_MISSION,
+		Autocontinue: true,
+		Param1:       pitch,
+		Param2:       roll,
+		Param3:       yaw,
+		Z:            float32(mode),
+	}
+}
+
+// isNavCommand returns true for commands that move the vehicle (MAV_CMD_NAV_*)
+func isNavCommand(cmd common.MAV_CMD) bool {
+	return cmd < common.MAV_CMD_NAV_LAST
+}
+
+// commandUsesLocation returns true for non-nav commands whose X/Y/Z are a global position
+func commandUsesLocation(cmd common.MAV_CMD) bool {
+	switch cmd {
+	case common.MAV_CMD_DO_SET_ROI_LOCATION,
+		common.MAV_CMD_DO_SET_HOME,
+		common.MAV_CMD_DO_LAND_START:
+		return true
+	default:
+		return false
+	}
+}
+
+// prepareMissionItem fixes up frame and autocontinue for non-nav items
+// Non-nav items always continue to the next item, and only carry a global
+// frame when X/Y/Z are a position; otherwise they use MAV_FRAME_MISSION so
+// the vehicle doesn't treat their params as coordinates.
+func prepareMissionItem(item MissionItem) MissionItem {
+	if isNavCommand(item.Command) {
+		return item
+	}
+
+	item.Autocontinue = true
+
+	if !commandUsesLocation(item.Command) {
+		item.Frame = common.MAV_FRAME_MISSION
+	} else if item.Frame == common.MAV_FRAME_MISSION {
+		item.Frame = common.MAV_FRAME_GLOBAL_RELATIVE_ALT_INT
+	}
+
+	return item
+}
+
+// UploadMissionItems uploads a mission that mixes waypoints and command items
+// Use waypoint items for navigation and the New*Item builders for camera/ROI
+// commands between them.
+func (c *Client) UploadMissionItems(items []MissionItem) error {
+	prepared := make([]MissionItem, len(items))
+	for i, item := range items {
+		prepared[i] = prepareMissionItem(item)
+	}
+
+	if err := c.UploadItems(common.MAV_MISSION_TYPE_MISSION, prepared); err != nil {
+		return err
+	}
+
+	c.mu.Lock()
+	c.missionState.Waypoints = nil // not expressible as proto waypoints
+	c.missionState.WaypointOptions = nil
+	c.missionState.MissionItems = prepared
+	c.missionState.WaypointsConfirmed = false
+	c.missionState.TotalWaypoints = int32(len(prepared))
+	c.missionProgress.ResetItems(prepared, time.Now())
+	c.mu.Unlock()
+
+	return nil
+}
+
+// WaypointItem converts a proto waypoint to a mission item for UploadMissionItems
+// The altitude is relative to home.
+func (c *Client) WaypointItem(wp *drone.Waypoint) MissionItem {
+	return c.waypointToMissionItem(wp, WaypointOptions{AltitudeFrame: AltitudeRelative})
+}
@@ -0,0 +1,89 @@
+package mavlink
+
+import (
+	"testing"
+
+	"github.com/bluenviron/gomavlib/v3/pkg/dialects/common"
+)
+
+func TestPrepareMissionItem(t *testing.T) {
+	tests := []struct {
+		name         string
+		item         MissionItem
+		frame        common.MAV_FRAME
+		autocontinue bool
+	}{
+		{"nav item untouched",
+			MissionItem{Command: common.MAV_CMD_NAV_WAYPOINT, Frame: common.MAV_FRAME_GLOBAL, Autocontinue: false},
+			common.MAV_FRAME_GLOBAL, false},
+		{"ROI keeps its global frame",
+			MissionItem{Command: common.MAV_CMD_DO_SET_ROI_LOCATION, Frame: common.MAV_FRAME_GLOBAL_INT},
+			common.MAV_FRAME_GLOBAL_INT, true},
+		{"ROI given the mission frame",
+			MissionItem{Command: common.MAV_CMD_DO_SET_ROI_LOCATION, Frame: common.MAV_FRAME_MISSION},
+			common.MAV_FRAME_GLOBAL_RELATIVE_ALT_INT, true},
+		{"mount control never global",
+			MissionItem{Command: common.MAV_CMD_DO_MOUNT_CONTROL, Frame: common.MAV_FRAME_GLOBAL_RELATIVE_ALT},
+			common.MAV_FRAME_MISSION, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := prepareMissionItem(tt.item)
+			if got.Frame != tt.frame || got.Autocontinue != tt.autocontinue {
+				t.Errorf("frame %s, autocontinue %v; want %s, %v", got.Frame, got.Autocontinue, tt.frame, tt.autocontinue)
+			}
+		})
+	}
+}
+
+func TestROIItemRoundTrip(t *testing.T) {
+	c, vehicle := newLinkedTestClient(t)
+	v := serveMissions(t, c, vehicle)
+
+	roi := NewROILocationItem(47.3977, 8.5456, 15)
+	mount := NewMountControlItem(-45, 0, 90, common.MAV_MOUNT_MODE_MAVLINK_TARGETING)
+	mount.Frame = common.MAV_FRAME_GLOBAL // fixed up on upload
+	items := []MissionItem{
+		{Command: common.MAV_CMD_NAV_TAKEOFF, Frame: common.MAV_FRAME_GLOBAL_RELATIVE_ALT, Autocontinue: true, Z: 20},
+		roi,
+		mount,
+		{Command: common.MAV_CMD_NAV_WAYPOINT, Frame: common.MAV_FRAME_GLOBAL_RELATIVE_ALT, Autocontinue: true,
+			X: 473980000, Y: 85460000, Z: 20},
+		NewROINoneItem(),
+	}
+	if err := c.UploadMissionItems(items); err != nil {
+		t.Fatal(err)
+	}
+
+	// The command params go where each command expects them
+	v.mu.Lock()
+	sent := v.items[common.MAV_MISSION_TYPE_MISSION]
+	v.mu.Unlock()
+	if len(sent) != len(items) {
+		t.Fatalf("vehicle got %d items, want %d", len(sent), len(items))
+	}
+	if msg := sent[1]; msg.Command != common.MAV_CMD_DO_SET_ROI_LOCATION || msg.Frame != common.MAV_FRAME_GLOBAL_RELATIVE_ALT_INT ||
+		msg.X != 473977000 || msg.Y != 85456000 || msg.Z != 15 || msg.Autocontinue != 1 {
+		t.Errorf("ROI item = %+v", msg)
+	}
+	if msg := sent[2]; msg.Command != common.MAV_CMD_DO_MOUNT_CONTROL || msg.Frame != common.MAV_FRAME_MISSION ||
+		msg.Param1 != -45 || msg.Param3 != 90 || msg.Z != float32(common.MAV_MOUNT_MODE_MAVLINK_TARGETING) {
+		t.Errorf("mount control item = %+v", msg)
+	}
+
+	got, err := c.DownloadMission()
+	if err != nil {
+		t.Fatal(err)
+	}
+	c.mu.RLock()
+	want := c.missionState.MissionItems
+	c.mu.RUnlock()
+	if len(got) != len(want) {
+		t.Fatalf("downloaded %d items, uploaded %d", len(got), len(want))
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("item %d = %+v, uploaded %+v", i, got[i], want[i])
+		}
+	}
+}
@@ -0,0 +1,109 @@
+package mavlink
+
+import (
+	"errors"
+
+	"github.com/bluenviron/gomavlib/v3/pkg/dialects/common"
+)
+
+// PauseMethod is how a mission was paused
+type PauseMethod string
+
+const (
+	// PauseCommand paused with MAV_CMD_DO_PAUSE_CONTINUE, staying in mission mode
+	PauseCommand PauseMethod = "DO_PAUSE_CONTINUE"
+	// PauseModeSwitch switched to AUTO.LOITER
+	PauseModeSwitch PauseMethod = "AUTO_LOITER"
+)
+
+// PauseMission holds the vehicle where it is mid-mission
+// MAV_CMD_DO_PAUSE_CONTINUE is preferred: it pauses without a mode change, so
+// ArduPilot doesn't apply its loiter radius. A vehicle answering UNSUPPORTED is
+// switched to AUTO.LOITER instead and remembered, so later pauses go straight
+// to the mode switch.
+func (c *Client) PauseMission() (PauseMethod, error) {
+	c.mu.RLock()
+	unsupported := c.pauseContinueUnsupported
+	c.mu.RUnlock()
+
+	if !unsupported {
+		err := c.sendCommandLong(common.MAV_CMD_DO_PAUSE_CONTINUE, [7]float32{0})
+		if err == nil {
+			c.setPausedWith(PauseCommand)
+			return PauseCommand, nil
+		}
+		if !isUnsupported(err) {
+			return "", err
+		}
+		c.logger.Printf("MAVLink: DO_PAUSE_CONTINUE unsupported, pausing with AUTO.LOITER")
+		c.mu.Lock()
+		c.pauseContinueUnsupported = true
+		c.mu.Unlock()
+	}
+
+	if err := c.SetMode(uint32(PX4_MAIN_MODE_AUTO | (PX4_AUTO_MODE_LOITER << 16))); err != nil {
+		return "", err
+	}
+	c.setPausedWith(PauseModeSwitch)
+	return PauseModeSwitch, nil
+}
+
+// ResumeMission continues a paused mission the way it was paused
+// A mission paused with DO_PAUSE_CONTINUE is continued with it; otherwise (or
+// if the vehicle then answers UNSUPPORTED) the vehicle is switched to AUTO.MISSION.
+func (c *Client) ResumeMission() (PauseMethod, error) {
+	c.mu.RLock()
+	pausedWith := c.pausedWith
+	c.mu.RUnlock()
+
+	if pausedWith == PauseCommand {
+		err := c.sendCommandLong(common.MAV_CMD_DO_PAUSE_CONTINUE, [7]float32{1})
+		if err == nil {
+			c.setPausedWith("")
+			return PauseCommand, nil
+		}
+		if !isUnsupported(err) {
+			return "", err
+		}
+	}
+
+	if err := c.SetMode(uint32(PX4_MAIN_MODE_AUTO | (PX4_AUTO_MODE_MISSION << 16))); err != nil {
+		return "", err
+	}
+	c.setPausedWith("")
+	return PauseModeSwitch, nil
+}
+
+// AbortMission stops the mission and holds position (AUTO.LOITER)
+// Unlike ClearMission the mission stays loaded on the vehicle and in server
+// memory, so it can be resumed or restarted. Progress stops reporting the
+// mission as active until then.
+func (c *Client) AbortMission() error {
+	if err := c.SetMode(uint32(PX4_MAIN_MODE_AUTO | (PX4_AUTO_MODE_LOITER << 16))); err != nil {
+		return err
+	}
+
+	c.mu.Lock()
+	c.pausedWith = ""
+	c.missionState.Aborted = true
+	c.missionState.MissionActive = false
+	c.mu.Unlock()
+	return nil
+}
+
+// IsMissionMode reports whether a PX4 custom mode is AUTO.MISSION
+func IsMissionMode(customMode uint32) bool {
+	return customMode&0xFF == PX4_MAIN_MODE_AUTO && (customMode>>16)&0xFF == PX4_AUTO_MODE_MISSION
+}
+
+func (c *Client) setPausedWith(method PauseMethod) {
+	c.mu.Lock()
+	c.pausedWith = method
+	c.mu.Unlock()
+}
+
+// isUnsupported reports whether the vehicle acknowledged a command with UNSUPPORTED
+func isUnsupported(err error) bool {
+	var rejected *CommandRejectedError
+	return errors.As(err, &rejected) && rejected.Result == common.MAV_RESULT_UNSUPPORTED
+}
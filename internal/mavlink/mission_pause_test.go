@@ -0,0 +1,136 @@
+package mavlink
+
+import (
+	"testing"
+
+	"github.com/bluenviron/gomavlib/v3"
+	"github.com/bluenviron/gomavlib/v3/pkg/dialects/common"
+
+	drone "github.com/flightpath-dev/flightpath-proto/gen/go/drone/v1"
+)
+
+// expectCommand receives the next COMMAND_LONG, fails unless it is command
+// with param1 and param2, and acknowledges it with result
+func expectCommand(t *testing.T, c *Client, vehicle *gomavlib.Node, command common.MAV_CMD, param1, param2 float32, result common.MAV_RESULT) {
+	t.Helper()
+	msg := receive[*common.MessageCommandLong](t, vehicle)
+	if msg.Command != command || msg.Param1 != param1 || msg.Param2 != param2 {
+		t.Fatalf("sent %v (%v, %v), want %v (%v, %v)", msg.Command, msg.Param1, msg.Param2, command, param1, param2)
+	}
+	ack(c, command, result)
+}
+
+func TestPauseMissionCommand(t *testing.T) {
+	c, vehicle := newLinkedTestClient(t)
+
+	type outcome struct {
+		method PauseMethod
+		err    error
+	}
+	done := make(chan outcome, 1)
+	go func() { m, err := c.PauseMission(); done <- outcome{m, err} }()
+	expectCommand(t, c, vehicle, common.MAV_CMD_DO_PAUSE_CONTINUE, 0, 0, common.MAV_RESULT_ACCEPTED)
+	if got := <-done; got.err != nil || got.method != PauseCommand {
+		t.Fatalf("PauseMission = %q, %v", got.method, got.err)
+	}
+
+	// Continued the same way, without a mode change
+	go func() { m, err := c.ResumeMission(); done <- outcome{m, err} }()
+	expectCommand(t, c, vehicle, common.MAV_CMD_DO_PAUSE_CONTINUE, 1, 0, common.MAV_RESULT_ACCEPTED)
+	if got := <-done; got.err != nil || got.method != PauseCommand {
+		t.Fatalf("ResumeMission = %q, %v", got.method, got.err)
+	}
+}
+
+func TestPauseMissionFallback(t *testing.T) {
+	c, vehicle := newLinkedTestClient(t)
+	loiter := float32(PX4_MAIN_MODE_AUTO | PX4_AUTO_MODE_LOITER<<16)
+	mission := float32(PX4_MAIN_MODE_AUTO | PX4_AUTO_MODE_MISSION<<16)
+	customMode := float32(common.MAV_MODE_FLAG_CUSTOM_MODE_ENABLED)
+
+	type outcome struct {
+		method PauseMethod
+		err    error
+	}
+	done := make(chan outcome, 1)
+	go func() { m, err := c.PauseMission(); done <- outcome{m, err} }()
+	expectCommand(t, c, vehicle, common.MAV_CMD_DO_PAUSE_CONTINUE, 0, 0, common.MAV_RESULT_UNSUPPORTED)
+	expectCommand(t, c, vehicle, common.MAV_CMD_DO_SET_MODE, customMode, loiter, common.MAV_RESULT_ACCEPTED)
+	if got := <-done; got.err != nil || got.method != PauseModeSwitch {
+		t.Fatalf("PauseMission = %q, %v", got.method, got.err)
+	}
+
+	go func() { m, err := c.ResumeMission(); done <- outcome{m, err} }()
+	expectCommand(t, c, vehicle, common.MAV_CMD_DO_SET_MODE, customMode, mission, common.MAV_RESULT_ACCEPTED)
+	if got := <-done; got.err != nil || got.method != PauseModeSwitch {
+		t.Fatalf("ResumeMission = %q, %v", got.method, got.err)
+	}
+
+	// The vehicle is remembered not to support the command
+	go func() { m, err := c.PauseMission(); done <- outcome{m, err} }()
+	expectCommand(t, c, vehicle, common.MAV_CMD_DO_SET_MODE, customMode, loiter, common.MAV_RESULT_ACCEPTED)
+	if got := <-done; got.err != nil || got.method != PauseModeSwitch {
+		t.Fatalf("second PauseMission = %q, %v", got.method, got.err)
+	}
+}
+
+func TestPauseMissionDenied(t *testing.T) {
+	c, vehicle := newLinkedTestClient(t)
+
+	// A refusal other than UNSUPPORTED is reported, not worked around
+	done := make(chan error, 1)
+	go func() { _, err := c.PauseMission(); done <- err }()
+	expectCommand(t, c, vehicle, common.MAV_CMD_DO_PAUSE_CONTINUE, 0, 0, common.MAV_RESULT_DENIED)
+	if err := <-done; err == nil || isUnsupported(err) {
+		t.Fatalf("PauseMission: %v, want the denial", err)
+	}
+}
+
+func TestAbortMission(t *testing.T) {
+	c, vehicle := newLinkedTestClient(t)
+	waypoints := []*drone.Waypoint{
+		{Sequence: 0, Position: &drone.Position{Latitude: 47, Longitude: 8, Altitude: 30}},
+		{Sequence: 1, Position: &drone.Position{Latitude: 47.001, Longitude: 8, Altitude: 30}},
+		{Sequence: 2, Position: &drone.Position{Latitude: 47.002, Longitude: 8, Altitude: 30}},
+	}
+	c.mu.Lock()
+	c.missionState.Waypoints = waypoints
+	c.missionState.TotalWaypoints = 3
+	c.mu.Unlock()
+	c.handleMessage(&common.MessageMissionCurrent{Seq: 1}, 1, 1)
+
+	done := make(chan error, 1)
+	go func() { done <- c.AbortMission() }()
+	expectCommand(t, c, vehicle, common.MAV_CMD_DO_SET_MODE,
+		float32(common.MAV_MODE_FLAG_CUSTOM_MODE_ENABLED), float32(PX4_MAIN_MODE_AUTO|PX4_AUTO_MODE_LOITER<<16),
+		common.MAV_RESULT_ACCEPTED)
+	if err := <-done; err != nil {
+		t.Fatal(err)
+	}
+
+	// Stopped but still loaded, unlike ClearMission
+	if current, total, active := c.GetMissionProgress(); current != 1 || total != 3 || active {
+		t.Errorf("progress = %d of %d (active %v), want 1 of 3 inactive", current, total, active)
+	}
+	if kept, _, _ := c.GetUploadedWaypoints(); len(kept) != 3 {
+		t.Errorf("%d waypoints kept, want 3", len(kept))
+	}
+
+	// The vehicle keeps reporting its current item while holding
+	c.handleMessage(&common.MessageMissionCurrent{Seq: 1}, 1, 1)
+	if _, _, active := c.GetMissionProgress(); active {
+		t.Error("MISSION_CURRENT reactivated the aborted mission")
+	}
+
+	// Back in AUTO.MISSION (e.g. switched from the RC) it is flying again
+	c.handleMessage(&common.MessageHeartbeat{
+		Type:       common.MAV_TYPE_QUADROTOR,
+		Autopilot:  common.MAV_AUTOPILOT_PX4,
+		BaseMode:   common.MAV_MODE_FLAG_SAFETY_ARMED | common.MAV_MODE_FLAG_CUSTOM_MODE_ENABLED,
+		CustomMode: PX4_MAIN_MODE_AUTO | PX4_AUTO_MODE_MISSION<<16,
+	}, 1, 1)
+	c.handleMessage(&common.MessageMissionCurrent{Seq: 2}, 1, 1)
+	if current, _, active := c.GetMissionProgress(); current != 2 || !active {
+		t.Errorf("after resuming: item %d, active %v", current, active)
+	}
+}
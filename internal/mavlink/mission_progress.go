@@ -0,0 +1,158 @@
+package mavlink
+
+import (
+	"time"
+
+	"github.com/bluenviron/gomavlib/v3/pkg/dialects/common"
+)
+
+// WaypointReached records when the vehicle reported reaching a mission item
+type WaypointReached struct {
+	Seq  int       `json:"seq"`
+	Time time.Time `json:"time"`
+}
+
+// MissionProgress is the tracked completion model of the current mission
+type MissionProgress struct {
+	TotalWaypoints  int               `json:"total_waypoints"`
+	CurrentWaypoint int               `json:"current_waypoint"` // from MISSION_CURRENT (-1 until reported)
+	Reached         []WaypointReached `json:"reached"`
+	Started         time.Time         `json:"started"` // upload or start that reset the model
+
+	// Mean time between consecutive reached waypoints (0 until two are reached)
+	AverageLeg time.Duration `json:"average_leg_ns"`
+
+	// AverageLeg times the legs left (0 when unknown or complete)
+	EstimatedRemaining time.Duration `json:"estimated_remaining_ns"`
+
+	// The last waypoint was reached. Latched until the next upload, start or
+	// clear, so completion stays visible after the vehicle lands and disarms.
+	Complete  bool      `json:"complete"`
+	Completed time.Time `json:"completed,omitempty"` // when the last waypoint was reached
+}
+
+// MissionProgressTracker builds a per-waypoint completion model from
+// MISSION_CURRENT and MISSION_ITEM_REACHED. Not safe for concurrent use;
+// the client guards it with c.mu.
+type MissionProgressTracker struct {
+	total   int
+	final   int // sequence number whose MISSION_ITEM_REACHED completes the mission
+	current int
+	started time.Time
+	reached []WaypointReached
+
+	completed time.Time // zero until the last waypoint is reached
+}
+
+// Reset starts a new model for a mission of total waypoints
+func (t *MissionProgressTracker) Reset(total int, now time.Time) {
+	*t = MissionProgressTracker{
+		total:   total,
+		final:   total - 1,
+		current: -1,
+		started: now,
+	}
+}
+
+// ResetItems starts a new model for an uploaded mission
+// It completes at the last navigation item: command items after it (e.g.
+// camera actions) get no MISSION_ITEM_REACHED.
+func (t *MissionProgressTracker) ResetItems(items []MissionItem, now time.Time) {
+	t.Reset(len(items), now)
+	for i := len(items) - 1; i >= 0; i-- {
+		if isNavCommand(items[i].Command) {
+			t.final = i
+			break
+		}
+	}
+}
+
+// Current records the MISSION_CURRENT sequence number
+func (t *MissionProgressTracker) Current(seq int) {
+	t.current = seq
+}
+
+// Reached records a MISSION_ITEM_REACHED
+// Repeats of the last reached waypoint (autopilots resend it) are ignored. A
+// new waypoint after completion means the vehicle flew the mission again (e.g.
+// restarted from the RC), so the timeline starts over.
+func (t *MissionProgressTracker) Reached(seq int, now time.Time) {
+	if n := len(t.reached); n > 0 && t.reached[n-1].Seq == seq {
+		return
+	}
+	if !t.completed.IsZero() {
+		t.reached = nil
+		t.completed = time.Time{}
+	}
+
+	t.reached = append(t.reached, WaypointReached{Seq: seq, Time: now})
+	if t.total > 0 && seq >= t.final {
+		t.completed = now
+	}
+}
+
+// Progress returns a copy of the model with leg timing derived from it
+func (t *MissionProgressTracker) Progress() MissionProgress {
+	p := MissionProgress{
+		TotalWaypoints:  t.total,
+		CurrentWaypoint: t.current,
+		Reached:         append([]WaypointReached{}, t.reached...),
+		Started:         t.started,
+		Complete:        !t.completed.IsZero(),
+		Completed:       t.completed,
+	}
+
+	n := len(t.reached)
+	if n == 0 {
+		return p
+	}
+	last := t.reached[n-1]
+
+	if n >= 2 {
+		p.AverageLeg = last.Time.Sub(t.reached[0].Time) / time.Duration(n-1)
+	}
+	if remaining := t.final - last.Seq; !p.Complete && remaining > 0 {
+		p.EstimatedRemaining = p.AverageLeg * time.Duration(remaining)
+	}
+	return p
+}
+
+// GetMissionTimeline returns the tracked completion model of the current mission
+func (c *Client) GetMissionTimeline() MissionProgress {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.missionProgress.Progress()
+}
+
+// seedMissionProgress reads the size of a mission already on the vehicle, so
+// progress is reported after connecting without a fresh upload
+// MISSION_CURRENT is requested as well to seed the current index; autopilots
+// also stream it. An upload finishing first takes precedence.
+func (c *Client) seedMissionProgress() {
+	count, err := c.ReadItemCount(common.MAV_MISSION_TYPE_MISSION)
+	if err != nil {
+		c.logger.Printf("MAVLink: Warning - failed to read the loaded mission: %v", err)
+		return
+	}
+	if count == 0 {
+		return
+	}
+
+	c.mu.Lock()
+	if c.missionState.TotalWaypoints != 0 {
+		c.mu.Unlock()
+		return
+	}
+	c.missionState.TotalWaypoints = int32(count)
+	c.missionProgress.Reset(count, time.Now())
+	if c.missionState.MissionActive {
+		c.missionProgress.Current(int(c.missionState.CurrentWaypoint))
+	}
+	c.mu.Unlock()
+
+	c.logger.Printf("MAVLink: Vehicle has a %d-item mission loaded", count)
+
+	if err := c.requestMessage(&common.MessageMissionCurrent{}); err != nil {
+		c.logger.Printf("MAVLink: Warning - failed to request MISSION_CURRENT: %v", err)
+	}
+}
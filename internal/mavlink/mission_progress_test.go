@@ -0,0 +1,191 @@
+package mavlink
+
+import (
+	"testing"
+	"time"
+
+	"github.com/bluenviron/gomavlib/v3/pkg/dialects/common"
+)
+
+func TestMissionProgressTracker(t *testing.T) {
+	start := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	at := func(sec int) time.Time { return start.Add(time.Duration(sec) * time.Second) }
+
+	var tr MissionProgressTracker
+	tr.Reset(5, start)
+	if p := tr.Progress(); p.TotalWaypoints != 5 || p.CurrentWaypoint != -1 || len(p.Reached) != 0 || !p.Started.Equal(start) {
+		t.Fatalf("new model = %+v", p)
+	}
+
+	tr.Current(1)
+	tr.Reached(0, at(10))
+	tr.Reached(0, at(11)) // resent by the autopilot
+	tr.Current(2)
+	tr.Reached(1, at(40))
+	tr.Reached(2, at(70))
+
+	p := tr.Progress()
+	if p.CurrentWaypoint != 2 || len(p.Reached) != 3 {
+		t.Fatalf("model = %+v", p)
+	}
+	for i, r := range p.Reached {
+		if r.Seq != i || !r.Time.Equal(at(10+30*i)) {
+			t.Errorf("reached[%d] = %+v", i, r)
+		}
+	}
+	// Two 30 s legs so far, two left
+	if p.AverageLeg != 30*time.Second || p.EstimatedRemaining != time.Minute || p.Complete {
+		t.Errorf("average leg %s, remaining %s, complete %v", p.AverageLeg, p.EstimatedRemaining, p.Complete)
+	}
+
+	tr.Reached(3, at(100))
+	tr.Reached(4, at(130))
+	p = tr.Progress()
+	if !p.Complete || !p.Completed.Equal(at(130)) || p.EstimatedRemaining != 0 {
+		t.Errorf("finished model = %+v", p)
+	}
+
+	// Flown again without a new upload: a fresh timeline
+	tr.Reached(0, at(500))
+	if p := tr.Progress(); p.Complete || len(p.Reached) != 1 || p.Reached[0].Seq != 0 {
+		t.Errorf("re-flown model = %+v", p)
+	}
+
+	// Progress is a copy
+	p = tr.Progress()
+	p.Reached[0].Seq = 9
+	if tr.Progress().Reached[0].Seq != 0 {
+		t.Error("Progress shares the reached list")
+	}
+}
+
+func TestMissionProgressCompletesAtLastNavItem(t *testing.T) {
+	var tr MissionProgressTracker
+	// A camera trigger after the last waypoint is never reached
+	tr.ResetItems([]MissionItem{
+		{Command: common.MAV_CMD_NAV_TAKEOFF},
+		{Command: common.MAV_CMD_NAV_WAYPOINT},
+		{Command: common.MAV_CMD_IMAGE_START_CAPTURE},
+	}, time.Now())
+
+	tr.Reached(0, time.Now())
+	tr.Reached(1, time.Now())
+	if p := tr.Progress(); !p.Complete || p.TotalWaypoints != 3 {
+		t.Errorf("model = %+v, want complete", p)
+	}
+}
+
+func TestMissionProgressFromMessages(t *testing.T) {
+	c, vehicle := newLinkedTestClient(t)
+	serveMissions(t, c, vehicle)
+
+	items := []MissionItem{
+		{Command: common.MAV_CMD_NAV_TAKEOFF, Frame: common.MAV_FRAME_GLOBAL_RELATIVE_ALT, Z: 20},
+		{Command: common.MAV_CMD_NAV_WAYPOINT, Frame: common.MAV_FRAME_GLOBAL_RELATIVE_ALT, X: 470000000, Y: 80000000, Z: 20},
+	}
+	if err := c.UploadMissionItems(items); err != nil {
+		t.Fatal(err)
+	}
+	c.handleMessage(&common.MessageMissionItemReached{Seq: 0}, 1, 1)
+	c.handleMessage(&common.MessageMissionCurrent{Seq: 1}, 1, 1)
+	if p := c.GetMissionTimeline(); len(p.Reached) != 1 || p.CurrentWaypoint != 1 {
+		t.Fatalf("timeline = %+v", p)
+	}
+
+	// A new upload starts over
+	if err := c.UploadMissionItems(items); err != nil {
+		t.Fatal(err)
+	}
+	if p := c.GetMissionTimeline(); len(p.Reached) != 0 || p.CurrentWaypoint != -1 || p.TotalWaypoints != 2 {
+		t.Errorf("timeline after upload = %+v", p)
+	}
+}
+
+func TestMissionCompletionLatched(t *testing.T) {
+	c, vehicle := newLinkedTestClient(t)
+	serveMissions(t, c, vehicle)
+
+	items := []MissionItem{
+		{Command: common.MAV_CMD_NAV_TAKEOFF, Frame: common.MAV_FRAME_GLOBAL_RELATIVE_ALT, Z: 20},
+		{Command: common.MAV_CMD_NAV_LAND, Frame: common.MAV_FRAME_GLOBAL_RELATIVE_ALT, X: 470000000, Y: 80000000},
+	}
+	if err := c.UploadMissionItems(items); err != nil {
+		t.Fatal(err)
+	}
+	c.handleMessage(&common.MessageMissionItemReached{Seq: 0}, 1, 1)
+	c.handleMessage(&common.MessageMissionItemReached{Seq: 1}, 1, 1)
+	completed := c.GetMissionTimeline().Completed
+	if completed.IsZero() {
+		t.Fatal("mission not complete")
+	}
+
+	// Landed and disarmed: MISSION_CURRENT keeps coming, completion stays
+	c.handleMessage(&common.MessageMissionCurrent{Seq: 1}, 1, 1)
+	c.handleMessage(&common.MessageMissionItemReached{Seq: 1}, 1, 1)
+	for i := 0; i < 2; i++ {
+		if p := c.GetMissionTimeline(); !p.Complete || !p.Completed.Equal(completed) {
+			t.Fatalf("poll %d: timeline = %+v", i, p)
+		}
+	}
+
+	if err := c.ClearMission(); err != nil {
+		t.Fatal(err)
+	}
+	if p := c.GetMissionTimeline(); p.Complete || !p.Completed.IsZero() {
+		t.Errorf("timeline after clearing = %+v", p)
+	}
+}
+
+func TestSeedMissionProgress(t *testing.T) {
+	c, vehicle := newLinkedTestClient(t)
+
+	// The vehicle is flying item 2 of a mission loaded before the server started
+	c.handleMessage(&common.MessageMissionCurrent{Seq: 2}, 1, 1)
+
+	done := make(chan struct{})
+	go func() {
+		c.seedMissionProgress()
+		close(done)
+	}()
+	list := receive[*common.MessageMissionRequestList](t, vehicle)
+	if list.MissionType != common.MAV_MISSION_TYPE_MISSION {
+		t.Fatalf("requested the %v list", list.MissionType)
+	}
+	c.handleMessage(&common.MessageMissionCount{Count: 5, MissionType: common.MAV_MISSION_TYPE_MISSION}, 1, 1)
+
+	request := receive[*common.MessageCommandLong](t, vehicle)
+	if request.Command != common.MAV_CMD_REQUEST_MESSAGE || request.Param1 != float32((&common.MessageMissionCurrent{}).GetID()) {
+		t.Errorf("sent %v (%v), want MISSION_CURRENT requested", request.Command, request.Param1)
+	}
+	ack(c, request.Command, common.MAV_RESULT_ACCEPTED)
+	<-done
+
+	if current, total, active := c.GetMissionProgress(); current != 2 || total != 5 || !active {
+		t.Errorf("progress = %d of %d (active %v), want 2 of 5", current, total, active)
+	}
+	if p := c.GetMissionTimeline(); p.TotalWaypoints != 5 || p.CurrentWaypoint != 2 {
+		t.Errorf("timeline = %+v", p)
+	}
+}
+
+func TestSeedMissionProgressAfterUpload(t *testing.T) {
+	c, vehicle := newLinkedTestClient(t)
+
+	done := make(chan struct{})
+	go func() {
+		c.seedMissionProgress()
+		close(done)
+	}()
+	receive[*common.MessageMissionRequestList](t, vehicle)
+
+	// An upload finished while the count was being read
+	c.mu.Lock()
+	c.missionState.TotalWaypoints = 3
+	c.mu.Unlock()
+	c.handleMessage(&common.MessageMissionCount{Count: 5, MissionType: common.MAV_MISSION_TYPE_MISSION}, 1, 1)
+	<-done
+
+	if _, total, _ := c.GetMissionProgress(); total != 3 {
+		t.Errorf("total = %d, want the uploaded 3", total)
+	}
+}
@@ -0,0 +1,171 @@
+package mavlink
+
+import (
+	"math"
+
+	"github.com/bluenviron/gomavlib/v3/pkg/dialects/common"
+)
+
+// MissionStats summarizes a mission for review: item counts by kind, path
+// length, estimated duration and altitude span
+type MissionStats struct {
+	Items int `json:"items"`
+
+	Waypoints      int `json:"waypoints"`
+	Takeoffs       int `json:"takeoffs"`
+	Lands          int `json:"lands"`
+	Loiters        int `json:"loiters"`
+	ReturnToLaunch int `json:"return_to_launch"`
+	ROIChanges     int `json:"roi_changes"`
+	Other          int `json:"other"`
+
+	// Count of every command, by MAV_CMD name
+	ByCommand map[string]int `json:"by_command"`
+
+	// Horizontal path through the positioned items, from home when it is known (meters)
+	Distance float64 `json:"distance"`
+
+	// Distance at CruiseSpeed plus hold and loiter times (seconds). Unlimited
+	// loiters and loiter turns can't be timed; DurationIncomplete marks them.
+	EstimatedDuration  float64 `json:"estimated_duration_s"`
+	DurationIncomplete bool    `json:"duration_incomplete"`
+	CruiseSpeed        float64 `json:"cruise_speed"`
+
+	// Navigation altitudes above home (terrain-relative items as given, AMSL
+	// ones only when home is known); false without any
+	AltitudeKnown bool    `json:"altitude_known"`
+	MinAltitude   float64 `json:"min_altitude"`
+	MaxAltitude   float64 `json:"max_altitude"`
+	AltitudeRange float64 `json:"altitude_range"`
+}
+
+// ComputeMissionStats summarizes mission items
+// home is nil when unknown; cruiseSpeed is the assumed speed between items in m/s.
+func ComputeMissionStats(items []MissionItem, home *HomePosition, cruiseSpeed float64) MissionStats {
+	stats := MissionStats{
+		Items:       len(items),
+		ByCommand:   make(map[string]int),
+		CruiseSpeed: cruiseSpeed,
+	}
+
+	var lastLat, lastLon float64
+	havePosition := false
+	if home != nil {
+		lastLat, lastLon = home.Latitude, home.Longitude
+		havePosition = true
+	}
+
+	for _, item := range items {
+		stats.ByCommand[item.Command.String()]++
+
+		switch item.Command {
+		case common.MAV_CMD_NAV_WAYPOINT, common.MAV_CMD_NAV_SPLINE_WAYPOINT:
+			stats.Waypoints++
+			stats.EstimatedDuration += float64(item.Param1) // Hold time
+		case common.MAV_CMD_NAV_TAKEOFF, common.MAV_CMD_NAV_VTOL_TAKEOFF:
+			stats.Takeoffs++
+		case common.MAV_CMD_NAV_LAND, common.MAV_CMD_NAV_VTOL_LAND:
+			stats.Lands++
+		case common.MAV_CMD_NAV_LOITER_TIME:
+			stats.Loiters++
+			stats.EstimatedDuration += float64(item.Param1)
+		case common.MAV_CMD_NAV_LOITER_UNLIM, common.MAV_CMD_NAV_LOITER_TURNS, common.MAV_CMD_NAV_LOITER_TO_ALT:
+			stats.Loiters++
+			if item.Command != common.MAV_CMD_NAV_LOITER_TO_ALT {
+				stats.DurationIncomplete = true
+			}
+		case common.MAV_CMD_NAV_RETURN_TO_LAUNCH:
+			stats.ReturnToLaunch++
+		case common.MAV_CMD_DO_SET_ROI, common.MAV_CMD_DO_SET_ROI_LOCATION,
+			common.MAV_CMD_DO_SET_ROI_WPNEXT_OFFSET, common.MAV_CMD_DO_SET_ROI_NONE:
+			stats.ROIChanges++
+		default:
+			stats.Other++
+		}
+
+		if !isNavCommand(item.Command) {
+			continue
+		}
+
+		// The return leg to home
+		if item.Command == common.MAV_CMD_NAV_RETURN_TO_LAUNCH {
+			if home != nil && havePosition {
+				stats.Distance += horizontalDistance(lastLat, lastLon, home.Latitude, home.Longitude)
+				lastLat, lastLon = home.Latitude, home.Longitude
+			}
+			continue
+		}
+
+		if altitude, ok := itemAltitude(item, home); ok && item.Command != common.MAV_CMD_NAV_LAND &&
+			item.Command != common.MAV_CMD_NAV_VTOL_LAND {
+			if !stats.AltitudeKnown {
+				stats.MinAltitude, stats.MaxAltitude = altitude, altitude
+				stats.AltitudeKnown = true
+			}
+			stats.MinAltitude = math.Min(stats.MinAltitude, altitude)
+			stats.MaxAltitude = math.Max(stats.MaxAltitude, altitude)
+		}
+
+		// Items at 0, 0 (e.g. takeoff or land here) don't move the vehicle sideways
+		if !isGlobalFrame(item.Frame) || (item.X == 0 && item.Y == 0) {
+			continue
+		}
+		lat, lon := float64(item.X)/1e7, float64(item.Y)/1e7
+		if havePosition {
+			stats.Distance += horizontalDistance(lastLat, lastLon, lat, lon)
+		}
+		lastLat, lastLon = lat, lon
+		havePosition = true
+	}
+
+	stats.AltitudeRange = stats.MaxAltitude - stats.MinAltitude
+	if cruiseSpeed > 0 {
+		stats.EstimatedDuration += stats.Distance / cruiseSpeed
+	}
+	return stats
+}
+
+// isGlobalFrame reports whether an item's X/Y are latitude/longitude
+func isGlobalFrame(frame common.MAV_FRAME) bool {
+	switch frame {
+	case common.MAV_FRAME_GLOBAL, common.MAV_FRAME_GLOBAL_INT,
+		common.MAV_FRAME_GLOBAL_RELATIVE_ALT, common.MAV_FRAME_GLOBAL_RELATIVE_ALT_INT,
+		common.MAV_FRAME_GLOBAL_TERRAIN_ALT, common.MAV_FRAME_GLOBAL_TERRAIN_ALT_INT:
+		return true
+	}
+	return false
+}
+
+// itemAltitude returns an item's altitude above home, false when its frame
+// has none or it is AMSL and home is unknown
+func itemAltitude(item MissionItem, home *HomePosition) (float64, bool) {
+	switch item.Frame {
+	case common.MAV_FRAME_GLOBAL_RELATIVE_ALT, common.MAV_FRAME_GLOBAL_RELATIVE_ALT_INT,
+		common.MAV_FRAME_GLOBAL_TERRAIN_ALT, common.MAV_FRAME_GLOBAL_TERRAIN_ALT_INT:
+		return float64(item.Z), true
+	case common.MAV_FRAME_GLOBAL, common.MAV_FRAME_GLOBAL_INT:
+		if home == nil {
+			return 0, false
+		}
+		return float64(item.Z) - home.Altitude, true
+	}
+	return 0, false
+}
+
+// GetMissionStats summarizes the last mission uploaded through this client
+// Returns false when none was uploaded (or it was cleared).
+func (c *Client) GetMissionStats(cruiseSpeed float64) (MissionStats, bool) {
+	c.mu.RLock()
+	items := c.missionState.MissionItems
+	c.mu.RUnlock()
+
+	if len(items) == 0 {
+		return MissionStats{}, false
+	}
+
+	var home *HomePosition
+	if h, ok := c.GetHomePosition(); ok {
+		home = &h
+	}
+	return ComputeMissionStats(items, home, cruiseSpeed), true
+}
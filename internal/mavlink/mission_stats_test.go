@@ -0,0 +1,99 @@
+package mavlink
+
+import (
+	"math"
+	"testing"
+
+	"github.com/bluenviron/gomavlib/v3/pkg/dialects/common"
+)
+
+// surveyMission takes off at home, flies 1 km north then 1 km east, loiters,
+// returns and lands, with an ROI and a speed change along the way
+func surveyMission() []MissionItem {
+	north := int32(math.Round((47 + 1000/earthRadius*180/math.Pi) * 1e7))
+	east := int32(math.Round((8 + 1000/earthRadius*180/math.Pi/math.Cos(47*math.Pi/180)) * 1e7))
+	relative := common.MAV_FRAME_GLOBAL_RELATIVE_ALT_INT
+	return []MissionItem{
+		{Command: common.MAV_CMD_NAV_TAKEOFF, Frame: relative, Z: 20},
+		{Command: common.MAV_CMD_NAV_WAYPOINT, Frame: relative, X: north, Y: 80000000, Z: 50, Param1: 10},
+		{Command: common.MAV_CMD_DO_SET_ROI_LOCATION, Frame: relative, X: north, Y: east, Z: 0},
+		{Command: common.MAV_CMD_NAV_WAYPOINT, Frame: common.MAV_FRAME_GLOBAL_INT, X: north, Y: east, Z: 568},
+		{Command: common.MAV_CMD_NAV_LOITER_TIME, Frame: relative, X: north, Y: east, Z: 60, Param1: 30},
+		{Command: common.MAV_CMD_DO_CHANGE_SPEED, Param2: 8},
+		{Command: common.MAV_CMD_NAV_RETURN_TO_LAUNCH},
+		{Command: common.MAV_CMD_NAV_LAND, Frame: relative},
+	}
+}
+
+func TestComputeMissionStats(t *testing.T) {
+	items := surveyMission()
+	home := &HomePosition{Latitude: 47, Longitude: 8, Altitude: 488}
+	stats := ComputeMissionStats(items, home, 10)
+
+	counts := []struct {
+		name      string
+		got, want int
+	}{
+		{"items", stats.Items, 8},
+		{"waypoints", stats.Waypoints, 2},
+		{"takeoffs", stats.Takeoffs, 1},
+		{"lands", stats.Lands, 1},
+		{"loiters", stats.Loiters, 1},
+		{"return to launch", stats.ReturnToLaunch, 1},
+		{"ROI changes", stats.ROIChanges, 1},
+		{"other", stats.Other, 1},
+		{"NAV_WAYPOINT", stats.ByCommand[common.MAV_CMD_NAV_WAYPOINT.String()], 2},
+		{"DO_CHANGE_SPEED", stats.ByCommand[common.MAV_CMD_DO_CHANGE_SPEED.String()], 1},
+	}
+	for _, c := range counts {
+		if c.got != c.want {
+			t.Errorf("%s = %d, want %d", c.name, c.got, c.want)
+		}
+	}
+
+	// 1 km north, 1 km east, then the diagonal home
+	wantDistance := 2000 + 1000*math.Sqrt2
+	if math.Abs(stats.Distance-wantDistance) > 1 {
+		t.Errorf("distance = %v m, want %v m", stats.Distance, wantDistance)
+	}
+	// The flight at 10 m/s plus a 10 s hold and a 30 s loiter
+	if math.Abs(stats.EstimatedDuration-(wantDistance/10+40)) > 0.1 || stats.DurationIncomplete {
+		t.Errorf("duration = %v s (incomplete %v), want %v s", stats.EstimatedDuration, stats.DurationIncomplete, wantDistance/10+40)
+	}
+
+	// The AMSL waypoint at 568 m is 80 m above home; the landing isn't counted
+	if !stats.AltitudeKnown || stats.MinAltitude != 20 || stats.MaxAltitude != 80 || stats.AltitudeRange != 60 {
+		t.Errorf("altitude %v..%v (range %v, known %v), want 20..80", stats.MinAltitude, stats.MaxAltitude, stats.AltitudeRange, stats.AltitudeKnown)
+	}
+}
+
+func TestComputeMissionStatsWithoutHome(t *testing.T) {
+	items := append(surveyMission(), MissionItem{Command: common.MAV_CMD_NAV_LOITER_UNLIM, Frame: common.MAV_FRAME_GLOBAL_RELATIVE_ALT_INT, Z: 30})
+	stats := ComputeMissionStats(items, nil, 10)
+
+	// The path starts at the first waypoint and has no way home
+	if math.Abs(stats.Distance-1000) > 1 {
+		t.Errorf("distance = %v m, want 1000 m", stats.Distance)
+	}
+	// The AMSL waypoint can't be placed above home
+	if stats.MinAltitude != 20 || stats.MaxAltitude != 60 {
+		t.Errorf("altitude %v..%v, want 20..60", stats.MinAltitude, stats.MaxAltitude)
+	}
+	if !stats.DurationIncomplete || stats.Loiters != 2 {
+		t.Errorf("unlimited loiter: %d loiters, duration incomplete %v", stats.Loiters, stats.DurationIncomplete)
+	}
+}
+
+func TestGetMissionStats(t *testing.T) {
+	c := newConnectedTestClient()
+	if _, ok := c.GetMissionStats(10); ok {
+		t.Fatal("stats without an uploaded mission")
+	}
+
+	c.missionState.MissionItems = surveyMission()
+	c.handleMessage(&common.MessageHomePosition{Latitude: 470000000, Longitude: 80000000, Altitude: 488000}, 1, 1)
+	stats, ok := c.GetMissionStats(5)
+	if !ok || stats.Items != 8 || stats.CruiseSpeed != 5 || stats.MaxAltitude != 80 {
+		t.Errorf("GetMissionStats = %+v, %v", stats, ok)
+	}
+}
@@ -0,0 +1,497 @@
+package mavlink
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/bluenviron/gomavlib/v3/pkg/dialects/common"
+)
+
+// missionTransferTimeout bounds a whole upload or download transaction
+const missionTransferTimeout = 30 * time.Second
+
+// missionCountTimeout bounds the wait for MISSION_COUNT after MISSION_REQUEST_LIST
+const missionCountTimeout = 3 * time.Second
+
+// ErrMissionTooLarge is returned for mission uploads over the configured item limit
+var ErrMissionTooLarge = errors.New("mission exceeds the vehicle's item limit")
+
+// ErrUploadCancelled is returned by an upload CancelMissionUpload stopped
+var ErrUploadCancelled = errors.New("upload cancelled")
+
+// MaxMissionItems returns the largest mission upload accepted (0 = no limit)
+func (c *Client) MaxMissionItems() int {
+	return c.maxMissionItems
+}
+
+// MissionItem is a single MAVLink mission item, independent of mission type
+// X/Y are latitude/longitude in degrees * 1E7 for global frames
+type MissionItem struct {
+	Command      common.MAV_CMD
+	Frame        common.MAV_FRAME
+	Autocontinue bool
+	Param1       float32
+	Param2       float32
+	Param3       float32
+	Param4       float32
+	X            int32
+	Y            int32
+	Z            float32
+}
+
+// UploadItems uploads a list of items for any MAV_MISSION_TYPE
+// The mission type is passed through to the vehicle as-is, so types the
+// server doesn't know about (beyond mission/fence/rally) work unchanged.
+// The vehicle handles one mission transaction at a time, so transfers of
+// any type are serialized.
+func (c *Client) UploadItems(missionType common.MAV_MISSION_TYPE, items []MissionItem) error {
+	if missionType == common.MAV_MISSION_TYPE_MISSION && c.maxMissionItems > 0 && len(items) > c.maxMissionItems {
+		return fmt.Errorf("%w: %d items, limit %d", ErrMissionTooLarge, len(items), c.maxMissionItems)
+	}
+	if missionType == common.MAV_MISSION_TYPE_MISSION {
+		if err := c.checkMissionArea(items); err != nil {
+			return err
+		}
+		if err := c.checkMissionAltitude(items); err != nil {
+			return err
+		}
+	}
+
+	c.transferMu.Lock()
+	defer c.transferMu.Unlock()
+
+	c.mu.Lock()
+	systemID := c.systemID
+	c.missionState.Uploading = true
+	c.missionState.TransferType = missionType
+	c.missionState.Items = items
+	c.missionState.TotalCount = len(items)
+	c.missionState.CurrentIndex = 0
+	c.missionState.UploadComplete = make(chan error, 1)
+
+	uploadComplete := c.missionState.UploadComplete
+	c.mu.Unlock()
+
+	c.logger.Printf("MAVLink: Starting %s upload (%d items)", missionType, len(items))
+
+	// Send MISSION_COUNT
+	err := c.writeMessage(&common.MessageMissionCount{
+		TargetSystem:    systemID,
+		TargetComponent: 1,
+		Count:           uint16(len(items)),
+		MissionType:     missionType,
+	})
+
+	if err != nil {
+		c.endUpload()
+		return fmt.Errorf("failed to send MISSION_COUNT: %w", err)
+	}
+
+	// Wait for upload to complete (with timeout)
+	select {
+	case err := <-uploadComplete:
+		return err
+	case <-time.After(missionTransferTimeout):
+		c.endUpload()
+		return fmt.Errorf("%s upload timeout", missionType)
+	}
+}
+
+// ClearItems clears all items of a MAV_MISSION_TYPE from the vehicle
+func (c *Client) ClearItems(missionType common.MAV_MISSION_TYPE) error {
+	c.mu.RLock()
+	systemID := c.systemID
+	c.mu.RUnlock()
+
+	if !c.IsConnected() {
+		return fmt.Errorf("not connected to drone")
+	}
+
+	c.logger.Printf("MAVLink: Clearing %s", missionType)
+
+	return c.writeMessage(&common.MessageMissionClearAll{
+		TargetSystem:    systemID,
+		TargetComponent: 1,
+		MissionType:     missionType,
+	})
+}
+
+// DownloadItems downloads the items of any MAV_MISSION_TYPE from the vehicle
+// MISSION_REQUEST_LIST starts the transfer, each item is requested with
+// MISSION_REQUEST_INT in turn, and a MISSION_ACK ends it. Like uploads,
+// downloads are serialized with every other transfer.
+func (c *Client) DownloadItems(missionType common.MAV_MISSION_TYPE) ([]MissionItem, error) {
+	if !c.IsConnected() {
+		return nil, fmt.Errorf("not connected to drone")
+	}
+
+	c.transferMu.Lock()
+	defer c.transferMu.Unlock()
+
+	c.mu.Lock()
+	systemID := c.systemID
+	c.missionState.Downloading = true
+	c.missionState.TransferType = missionType
+	c.missionState.Items = nil
+	c.missionState.TotalCount = 0
+	c.missionState.DownloadComplete = make(chan error, 1)
+
+	downloadComplete := c.missionState.DownloadComplete
+	c.mu.Unlock()
+
+	c.logger.Printf("MAVLink: Starting %s download", missionType)
+
+	err := c.writeMessage(&common.MessageMissionRequestList{
+		TargetSystem:    systemID,
+		TargetComponent: 1,
+		MissionType:     missionType,
+	})
+	if err != nil {
+		c.endDownload()
+		return nil, fmt.Errorf("failed to send MISSION_REQUEST_LIST: %w", err)
+	}
+
+	select {
+	case err := <-downloadComplete:
+		if err != nil {
+			return nil, err
+		}
+		c.mu.RLock()
+		items := c.missionState.Items
+		c.mu.RUnlock()
+		return items, nil
+	case <-time.After(missionTransferTimeout):
+		c.endDownload()
+		// Stop the vehicle waiting for further requests
+		err := c.writeMessage(&common.MessageMissionAck{
+			TargetSystem:    systemID,
+			TargetComponent: 1,
+			Type:            common.MAV_MISSION_OPERATION_CANCELLED,
+			MissionType:     missionType,
+		})
+		if err != nil {
+			c.logger.Printf("MAVLink: Warning - failed to cancel %s download: %v", missionType, err)
+		}
+		return nil, fmt.Errorf("%s download timeout", missionType)
+	}
+}
+
+// ReadItemCount asks the vehicle how many items of a MAV_MISSION_TYPE it holds
+// Starts a download with MISSION_REQUEST_LIST and cancels it once MISSION_COUNT
+// arrives, so no items are transferred.
+func (c *Client) ReadItemCount(missionType common.MAV_MISSION_TYPE) (int, error) {
+	if !c.IsConnected() {
+		return 0, fmt.Errorf("not connected to drone")
+	}
+
+	c.transferMu.Lock()
+	defer c.transferMu.Unlock()
+
+	c.mu.Lock()
+	systemID := c.systemID
+	c.missionState.TransferType = missionType
+	c.missionState.CountReply = make(chan uint16, 1)
+	countReply := c.missionState.CountReply
+	c.mu.Unlock()
+
+	defer func() {
+		c.mu.Lock()
+		c.missionState.CountReply = nil
+		c.mu.Unlock()
+	}()
+
+	err := c.writeMessage(&common.MessageMissionRequestList{
+		TargetSystem:    systemID,
+		TargetComponent: 1,
+		MissionType:     missionType,
+	})
+	if err != nil {
+		return 0, fmt.Errorf("failed to send MISSION_REQUEST_LIST: %w", err)
+	}
+
+	select {
+	case count := <-countReply:
+		// End the download the vehicle started; it would otherwise wait for item requests
+		err := c.writeMessage(&common.MessageMissionAck{
+			TargetSystem:    systemID,
+			TargetComponent: 1,
+			Type:            common.MAV_MISSION_OPERATION_CANCELLED,
+			MissionType:     missionType,
+		})
+		if err != nil {
+			c.logger.Printf("MAVLink: Warning - failed to cancel %s download: %v", missionType, err)
+		}
+		return int(count), nil
+	case <-time.After(missionCountTimeout):
+		return 0, fmt.Errorf("no %s count received within %s", missionType, missionCountTimeout)
+	}
+}
+
+// CancelMissionUpload aborts the upload in progress, of any mission type
+// The waiting upload returns ErrUploadCancelled at once and the vehicle is
+// sent MISSION_ACK with MAV_MISSION_OPERATION_CANCELLED so it stops
+// requesting items. Returns false when no upload is in progress.
+func (c *Client) CancelMissionUpload() (bool, error) {
+	c.mu.Lock()
+	if !c.missionState.Uploading {
+		c.mu.Unlock()
+		return false, nil
+	}
+	systemID := c.systemID
+	missionType := c.missionState.TransferType
+	c.missionState.Uploading = false
+	if c.missionState.UploadComplete != nil {
+		c.missionState.UploadComplete <- ErrUploadCancelled
+		c.missionState.UploadComplete = nil
+	}
+	c.mu.Unlock()
+
+	c.logger.Printf("MAVLink: Cancelling %s upload", missionType)
+
+	err := c.writeMessage(&common.MessageMissionAck{
+		TargetSystem:    systemID,
+		TargetComponent: 1,
+		Type:            common.MAV_MISSION_OPERATION_CANCELLED,
+		MissionType:     missionType,
+	})
+	if err != nil {
+		return true, fmt.Errorf("upload stopped, but the vehicle wasn't told: %w", err)
+	}
+	return true, nil
+}
+
+// handleMissionCount processes MISSION_COUNT messages, which start a
+// download or answer ReadItemCount
+func (c *Client) handleMissionCount(msg *common.MessageMissionCount) {
+	c.mu.Lock()
+	if c.missionState.Downloading && msg.MissionType == c.missionState.TransferType {
+		c.missionState.TotalCount = int(msg.Count)
+		c.missionState.Items = make([]MissionItem, 0, msg.Count)
+		c.mu.Unlock()
+		c.requestNextItem()
+		return
+	}
+	defer c.mu.Unlock()
+
+	if c.missionState.CountReply == nil || msg.MissionType != c.missionState.TransferType {
+		return
+	}
+
+	select {
+	case c.missionState.CountReply <- msg.Count:
+	default:
+	}
+}
+
+// endUpload resets the upload state after a failure or timeout
+func (c *Client) endUpload() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.missionState.Uploading = false
+	c.missionState.UploadComplete = nil
+}
+
+// endDownload resets the download state after a failure or timeout
+func (c *Client) endDownload() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.missionState.Downloading = false
+	c.missionState.DownloadComplete = nil
+}
+
+// finishDownload ends the download in progress with err; caller must hold c.mu
+func (c *Client) finishDownload(err error) {
+	c.missionState.Downloading = false
+	if c.missionState.DownloadComplete != nil {
+		c.missionState.DownloadComplete <- err
+		c.missionState.DownloadComplete = nil
+	}
+}
+
+// requestNextItem asks for the next item of the download in progress, or
+// acknowledges the download once every item arrived
+// Like handleMissionRequestInt it writes without holding c.mu.
+func (c *Client) requestNextItem() {
+	c.mu.Lock()
+	if !c.missionState.Downloading {
+		c.mu.Unlock()
+		return
+	}
+	systemID := c.systemID
+	missionType := c.missionState.TransferType
+	seq := len(c.missionState.Items)
+	done := seq >= c.missionState.TotalCount
+	if done {
+		c.logger.Printf("MAVLink: %s download complete (%d items)", missionType, seq)
+		c.finishDownload(nil)
+	}
+	c.mu.Unlock()
+
+	if done {
+		err := c.writeMessage(&common.MessageMissionAck{
+			TargetSystem:    systemID,
+			TargetComponent: 1,
+			Type:            common.MAV_MISSION_ACCEPTED,
+			MissionType:     missionType,
+		})
+		if err != nil {
+			c.logger.Printf("MAVLink: Warning - failed to acknowledge %s download: %v", missionType, err)
+		}
+		return
+	}
+
+	err := c.writeMessage(&common.MessageMissionRequestInt{
+		TargetSystem:    systemID,
+		TargetComponent: 1,
+		Seq:             uint16(seq),
+		MissionType:     missionType,
+	})
+	if err == nil {
+		return
+	}
+	c.logger.Printf("MAVLink: Error requesting %s item %d: %v", missionType, seq, err)
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.missionState.Downloading && c.missionState.TransferType == missionType {
+		c.finishDownload(err)
+	}
+}
+
+// handleMissionItemInt processes the MISSION_ITEM_INT answering a download's request
+// Items out of sequence (e.g. a duplicate after a slow reply) are ignored.
+func (c *Client) handleMissionItemInt(msg *common.MessageMissionItemInt) {
+	c.mu.Lock()
+	if !c.missionState.Downloading || msg.MissionType != c.missionState.TransferType ||
+		int(msg.Seq) != len(c.missionState.Items) {
+		c.mu.Unlock()
+		return
+	}
+	c.missionState.Items = append(c.missionState.Items, MissionItem{
+		Command:      msg.Command,
+		Frame:        msg.Frame,
+		Autocontinue: msg.Autocontinue != 0,
+		Param1:       msg.Param1,
+		Param2:       msg.Param2,
+		Param3:       msg.Param3,
+		Param4:       msg.Param4,
+		X:            msg.X,
+		Y:            msg.Y,
+		Z:            msg.Z,
+	})
+	c.mu.Unlock()
+
+	c.requestNextItem()
+}
+
+// handleMissionRequest processes MISSION_REQUEST messages
+func (c *Client) handleMissionRequest(msg *common.MessageMissionRequest) {
+	c.handleMissionRequestInt(&common.MessageMissionRequestInt{
+		Seq:         msg.Seq,
+		MissionType: msg.MissionType,
+	})
+}
+
+// handleMissionRequestInt processes MISSION_REQUEST_INT messages
+// The item is sent without holding c.mu: a write may back off and retry
+// (see writeWithRetry), which must not stall readers of client state.
+func (c *Client) handleMissionRequestInt(msg *common.MessageMissionRequestInt) {
+	c.mu.Lock()
+	if !c.missionState.Uploading || msg.MissionType != c.missionState.TransferType {
+		c.mu.Unlock()
+		c.logger.Printf("MAVLink: Received unexpected MISSION_REQUEST_INT for %s seq %d", msg.MissionType, msg.Seq)
+		return
+	}
+
+	seq := int(msg.Seq)
+	if seq >= len(c.missionState.Items) {
+		c.mu.Unlock()
+		c.logger.Printf("MAVLink: Invalid item sequence %d (max %d)", seq, len(c.missionState.Items))
+		return
+	}
+
+	c.logger.Printf("MAVLink: Sending %s item %d/%d", msg.MissionType, seq+1, len(c.missionState.Items))
+
+	c.missionState.CurrentIndex = seq
+	item := c.missionItemMessage(uint16(seq), c.missionState.Items[seq])
+	complete := c.missionState.UploadComplete
+	c.mu.Unlock()
+
+	// Send the requested item
+	err := c.writeMessage(item)
+	if err == nil {
+		return
+	}
+	c.logger.Printf("MAVLink: Error sending item %d: %v", seq, err)
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	// Unless the transfer ended (or another began) while we were sending
+	if !c.missionState.Uploading || c.missionState.UploadComplete != complete {
+		return
+	}
+	if complete != nil {
+		complete <- err
+		c.missionState.UploadComplete = nil
+	}
+	c.missionState.Uploading = false
+}
+
+// handleMissionAck processes MISSION_ACK messages
+func (c *Client) handleMissionAck(msg *common.MessageMissionAck) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.logger.Printf("MAVLink: Mission ACK received: type=%d mission_type=%s", msg.Type, msg.MissionType)
+
+	// During a download the vehicle only acknowledges to give up
+	if c.missionState.Downloading && msg.MissionType == c.missionState.TransferType {
+		c.logger.Printf("MAVLink: %s download failed: %s", msg.MissionType, msg.Type)
+		c.finishDownload(fmt.Errorf("%s download failed: %s", msg.MissionType, msg.Type))
+		return
+	}
+
+	if !c.missionState.Uploading || msg.MissionType != c.missionState.TransferType {
+		return
+	}
+
+	c.missionState.Uploading = false
+	if c.missionState.UploadComplete != nil {
+		if msg.Type == common.MAV_MISSION_ACCEPTED {
+			c.logger.Printf("MAVLink: %s upload successful", msg.MissionType)
+			c.missionState.UploadComplete <- nil
+		} else {
+			c.logger.Printf("MAVLink: %s upload failed: %d", msg.MissionType, msg.Type)
+			c.missionState.UploadComplete <- fmt.Errorf("mission upload failed: %d", msg.Type)
+		}
+		c.missionState.UploadComplete = nil
+	}
+}
+
+// missionItemMessage builds the MISSION_ITEM_INT for one item of the active
+// transfer; caller must hold c.mu
+func (c *Client) missionItemMessage(seq uint16, item MissionItem) *common.MessageMissionItemInt {
+	autocontinue := uint8(0)
+	if item.Autocontinue {
+		autocontinue = 1
+	}
+
+	return &common.MessageMissionItemInt{
+		TargetSystem:    c.systemID,
+		TargetComponent: 1,
+		Seq:             seq,
+		Frame:           item.Frame,
+		Command:         item.Command,
+		Current:         0,
+		Autocontinue:    autocontinue,
+		Param1:          item.Param1,
+		Param2:          item.Param2,
+		Param3:          item.Param3,
+		Param4:          item.Param4,
+		X:               item.X,
+		Y:               item.Y,
+		Z:               item.Z,
+		MissionType:     c.missionState.TransferType,
+	}
+}
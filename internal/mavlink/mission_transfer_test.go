@@ -0,0 +1,329 @@
+package mavlink
+
+import (
+	"errors"
+	"math"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/bluenviron/gomavlib/v3"
+	"github.com/bluenviron/gomavlib/v3/pkg/dialects/common"
+	"github.com/bluenviron/gomavlib/v3/pkg/message"
+)
+
+// missionVehicle is the vehicle side of the mission protocol: it stores the
+// items uploaded for each mission type and serves them back to downloads
+type missionVehicle struct {
+	mu    sync.Mutex
+	items map[common.MAV_MISSION_TYPE][]*common.MessageMissionItemInt
+	acks  chan *common.MessageMissionAck   // acknowledgements received, in order
+	deny  map[common.MAV_MISSION_TYPE]bool // downloads of these types are refused
+}
+
+// serveMissions answers c's transfers until the test ends
+// Replies are handed straight to c.handleMessage, as its read loop would.
+func serveMissions(t *testing.T, c *Client, vehicle *gomavlib.Node) *missionVehicle {
+	t.Helper()
+	v := &missionVehicle{
+		items: make(map[common.MAV_MISSION_TYPE][]*common.MessageMissionItemInt),
+		acks:  make(chan *common.MessageMissionAck, 16),
+		deny:  make(map[common.MAV_MISSION_TYPE]bool),
+	}
+	done := make(chan struct{})
+	t.Cleanup(func() { close(done) })
+
+	go func() {
+		uploading := make(map[common.MAV_MISSION_TYPE]int) // expected item count
+		for {
+			var evt gomavlib.Event
+			select {
+			case evt = <-vehicle.Events():
+			case <-done:
+				return
+			}
+			frame, ok := evt.(*gomavlib.EventFrame)
+			if !ok {
+				continue
+			}
+
+			v.mu.Lock()
+			var reply []message.Message
+			switch msg := frame.Message().(type) {
+			case *common.MessageMissionCount:
+				uploading[msg.MissionType] = int(msg.Count)
+				v.items[msg.MissionType] = nil
+				reply = append(reply, &common.MessageMissionRequestInt{MissionType: msg.MissionType})
+
+			case *common.MessageMissionItemInt:
+				v.items[msg.MissionType] = append(v.items[msg.MissionType], msg)
+				if n := len(v.items[msg.MissionType]); n < uploading[msg.MissionType] {
+					reply = append(reply, &common.MessageMissionRequestInt{Seq: uint16(n), MissionType: msg.MissionType})
+				} else {
+					reply = append(reply, &common.MessageMissionAck{Type: common.MAV_MISSION_ACCEPTED, MissionType: msg.MissionType})
+				}
+
+			case *common.MessageMissionRequestList:
+				if v.deny[msg.MissionType] {
+					reply = append(reply, &common.MessageMissionAck{Type: common.MAV_MISSION_DENIED, MissionType: msg.MissionType})
+				} else {
+					reply = append(reply, &common.MessageMissionCount{
+						Count:       uint16(len(v.items[msg.MissionType])),
+						MissionType: msg.MissionType,
+					})
+				}
+
+			case *common.MessageMissionRequestInt:
+				reply = append(reply, v.items[msg.MissionType][msg.Seq])
+
+			case *common.MessageMissionClearAll:
+				delete(v.items, msg.MissionType)
+
+			case *common.MessageMissionAck:
+				v.acks <- msg
+			}
+			v.mu.Unlock()
+
+			for _, msg := range reply {
+				c.handleMessage(msg, 1, 1)
+			}
+		}
+	}()
+	return v
+}
+
+// nextAck fails unless the next MISSION_ACK the vehicle receives accepts a
+// transfer of missionType
+func (v *missionVehicle) nextAck(t *testing.T, missionType common.MAV_MISSION_TYPE) {
+	t.Helper()
+	select {
+	case ack := <-v.acks:
+		if ack.MissionType != missionType || ack.Type != common.MAV_MISSION_ACCEPTED {
+			t.Errorf("MISSION_ACK %s for %s, want accepted for %s", ack.Type, ack.MissionType, missionType)
+		}
+	case <-time.After(5 * time.Second):
+		t.Errorf("%s download never acknowledged", missionType)
+	}
+}
+
+func near(a, b float64) bool { return math.Abs(a-b) < 1e-6 }
+
+func TestDownloadItemsPerMissionType(t *testing.T) {
+	c, vehicle := newLinkedTestClient(t)
+	v := serveMissions(t, c, vehicle)
+
+	mission := []MissionItem{
+		{Command: common.MAV_CMD_NAV_TAKEOFF, Frame: common.MAV_FRAME_GLOBAL_RELATIVE_ALT, Autocontinue: true, Z: 20},
+		{Command: common.MAV_CMD_NAV_WAYPOINT, Frame: common.MAV_FRAME_GLOBAL_RELATIVE_ALT, Autocontinue: true,
+			Param2: 2, X: 473977420, Y: 85455940, Z: 30},
+		{Command: common.MAV_CMD_NAV_RETURN_TO_LAUNCH, Frame: common.MAV_FRAME_MISSION, Autocontinue: true},
+	}
+	if err := c.UploadItems(common.MAV_MISSION_TYPE_MISSION, mission); err != nil {
+		t.Fatal(err)
+	}
+
+	zones := []FenceZone{
+		{Inclusion: true, Vertices: []FencePoint{{47.39, 8.54}, {47.40, 8.54}, {47.40, 8.55}, {47.39, 8.55}}},
+		{Center: FencePoint{47.395, 8.545}, Radius: 15},
+	}
+	if err := c.UploadGeofence(zones); err != nil {
+		t.Fatal(err)
+	}
+
+	// Downloads of one type leave the others alone
+	got, err := c.DownloadMission()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(got) != len(mission) {
+		t.Fatalf("downloaded %d mission items, want %d", len(got), len(mission))
+	}
+	for i := range mission {
+		if got[i] != mission[i] {
+			t.Errorf("mission item %d = %+v, want %+v", i, got[i], mission[i])
+		}
+	}
+	v.nextAck(t, common.MAV_MISSION_TYPE_MISSION)
+
+	fence, err := c.DownloadGeofence()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(fence) != 2 || !fence[0].Inclusion || len(fence[0].Vertices) != 4 ||
+		fence[1].Inclusion || fence[1].Vertices != nil || fence[1].Radius != 15 {
+		t.Fatalf("downloaded fence = %+v", fence)
+	}
+	for i, p := range zones[0].Vertices {
+		if q := fence[0].Vertices[i]; !near(p.Latitude, q.Latitude) || !near(p.Longitude, q.Longitude) {
+			t.Errorf("vertex %d = %+v, want %+v", i, q, p)
+		}
+	}
+	v.nextAck(t, common.MAV_MISSION_TYPE_FENCE)
+
+	// Nothing stored yet: an empty download, still acknowledged
+	rally, err := c.DownloadRallyPoints()
+	if err != nil || len(rally) != 0 {
+		t.Fatalf("rally points = %v, %v", rally, err)
+	}
+	v.nextAck(t, common.MAV_MISSION_TYPE_RALLY)
+}
+
+func TestRallyPointsRoundTrip(t *testing.T) {
+	c, vehicle := newLinkedTestClient(t)
+	v := serveMissions(t, c, vehicle)
+
+	points := []RallyPoint{{47.3977, 8.5456, 40}, {47.4012, 8.5501, 25}}
+	if err := c.UploadRallyPoints(points); err != nil {
+		t.Fatal(err)
+	}
+	v.mu.Lock()
+	stored := v.items[common.MAV_MISSION_TYPE_RALLY]
+	v.mu.Unlock()
+	for _, item := range stored {
+		if item.Command != common.MAV_CMD_NAV_RALLY_POINT || item.Frame != common.MAV_FRAME_GLOBAL_RELATIVE_ALT {
+			t.Errorf("rally item %d sent as %s in %s", item.Seq, item.Command, item.Frame)
+		}
+	}
+
+	got, err := c.DownloadRallyPoints()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(got) != len(points) {
+		t.Fatalf("downloaded %d rally points, want %d", len(got), len(points))
+	}
+	for i, p := range points {
+		if q := got[i]; !near(p.Latitude, q.Latitude) || !near(p.Longitude, q.Longitude) || p.Altitude != q.Altitude {
+			t.Errorf("rally point %d = %+v, want %+v", i, q, p)
+		}
+	}
+}
+
+func TestDownloadItemsRefused(t *testing.T) {
+	c, vehicle := newLinkedTestClient(t)
+	v := serveMissions(t, c, vehicle)
+	v.mu.Lock()
+	v.deny[common.MAV_MISSION_TYPE_FENCE] = true
+	v.mu.Unlock()
+
+	_, err := c.DownloadItems(common.MAV_MISSION_TYPE_FENCE)
+	if err == nil || !strings.Contains(err.Error(), "download failed") {
+		t.Fatalf("refused download: %v", err)
+	}
+	// The engine is free again
+	if _, err := c.DownloadMission(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestFenceItems(t *testing.T) {
+	triangle := []FencePoint{{1, 1}, {1, 2}, {2, 2}}
+	items, err := fenceItems([]FenceZone{{Vertices: triangle}, {Inclusion: true, Center: FencePoint{1, 1}, Radius: 50}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(items) != 4 {
+		t.Fatalf("%d items, want 4", len(items))
+	}
+	for _, item := range items[:3] {
+		if item.Command != common.MAV_CMD_NAV_FENCE_POLYGON_VERTEX_EXCLUSION || item.Param1 != 3 {
+			t.Errorf("vertex item = %s with param1 %v", item.Command, item.Param1)
+		}
+	}
+	if items[3].Command != common.MAV_CMD_NAV_FENCE_CIRCLE_INCLUSION || items[3].Param1 != 50 {
+		t.Errorf("circle item = %s with param1 %v", items[3].Command, items[3].Param1)
+	}
+
+	if _, err := fenceItems([]FenceZone{{Vertices: triangle[:2]}}); err == nil {
+		t.Error("two-vertex polygon accepted")
+	}
+	if _, err := fenceItems([]FenceZone{{Center: FencePoint{1, 1}}}); err == nil {
+		t.Error("circle without a radius accepted")
+	}
+
+	// A polygon cut short on the vehicle
+	if _, err := fenceZones(items[:2]); err == nil {
+		t.Error("truncated polygon decoded")
+	}
+}
+
+func TestUploadItemsMissionLimit(t *testing.T) {
+	c, vehicle := newLinkedTestClient(t)
+	v := serveMissions(t, c, vehicle)
+	c.maxMissionItems = 2
+
+	items := []MissionItem{
+		{Command: common.MAV_CMD_NAV_WAYPOINT, Frame: common.MAV_FRAME_GLOBAL_RELATIVE_ALT, X: 470000000, Y: 80000000, Z: 20},
+		{Command: common.MAV_CMD_NAV_WAYPOINT, Frame: common.MAV_FRAME_GLOBAL_RELATIVE_ALT, X: 470010000, Y: 80000000, Z: 20},
+		{Command: common.MAV_CMD_NAV_RETURN_TO_LAUNCH, Frame: common.MAV_FRAME_MISSION},
+	}
+	if err := c.UploadItems(common.MAV_MISSION_TYPE_MISSION, items); !errors.Is(err, ErrMissionTooLarge) {
+		t.Fatalf("oversized mission: %v, want ErrMissionTooLarge", err)
+	}
+	// Rejected before the transfer started
+	v.mu.Lock()
+	_, started := v.items[common.MAV_MISSION_TYPE_MISSION]
+	v.mu.Unlock()
+	if started {
+		t.Error("oversized mission sent to the vehicle")
+	}
+
+	if err := c.UploadItems(common.MAV_MISSION_TYPE_MISSION, items[:2]); err != nil {
+		t.Errorf("mission at the limit: %v", err)
+	}
+	// The limit is on mission storage; fence and rally items are stored separately
+	rally := []RallyPoint{{47, 8, 30}, {47.001, 8, 30}, {47.002, 8, 30}}
+	if err := c.UploadRallyPoints(rally); err != nil {
+		t.Errorf("rally points over the mission limit: %v", err)
+	}
+}
+
+func TestCancelMissionUpload(t *testing.T) {
+	c, vehicle := newLinkedTestClient(t)
+	if cancelled, err := c.CancelMissionUpload(); cancelled || err != nil {
+		t.Fatalf("cancel with no upload: %v, %v", cancelled, err)
+	}
+
+	items := []MissionItem{
+		{Command: common.MAV_CMD_NAV_WAYPOINT, Frame: common.MAV_FRAME_GLOBAL_RELATIVE_ALT, X: 470000000, Y: 80000000, Z: 20},
+		{Command: common.MAV_CMD_NAV_WAYPOINT, Frame: common.MAV_FRAME_GLOBAL_RELATIVE_ALT, X: 470010000, Y: 80000000, Z: 20},
+	}
+	uploaded := make(chan error, 1)
+	go func() { uploaded <- c.UploadItems(common.MAV_MISSION_TYPE_MISSION, items) }()
+
+	// The vehicle takes the first item, then the user changes their mind
+	receive[*common.MessageMissionCount](t, vehicle)
+	c.handleMessage(&common.MessageMissionRequestInt{MissionType: common.MAV_MISSION_TYPE_MISSION}, 1, 1)
+	receive[*common.MessageMissionItemInt](t, vehicle)
+
+	if cancelled, err := c.CancelMissionUpload(); !cancelled || err != nil {
+		t.Fatalf("cancel mid-upload: %v, %v", cancelled, err)
+	}
+	select {
+	case err := <-uploaded:
+		if !errors.Is(err, ErrUploadCancelled) {
+			t.Errorf("cancelled upload: %v, want ErrUploadCancelled", err)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("upload still waiting after the cancel")
+	}
+	if ack := receive[*common.MessageMissionAck](t, vehicle); ack.Type != common.MAV_MISSION_OPERATION_CANCELLED ||
+		ack.MissionType != common.MAV_MISSION_TYPE_MISSION {
+		t.Errorf("vehicle told %s for %s", ack.Type, ack.MissionType)
+	}
+
+	// Nothing left to cancel, and the next upload starts afresh
+	if cancelled, _ := c.CancelMissionUpload(); cancelled {
+		t.Error("second cancel found an upload")
+	}
+	v := serveMissions(t, c, vehicle)
+	if err := c.UploadItems(common.MAV_MISSION_TYPE_MISSION, items); err != nil {
+		t.Fatalf("upload after the cancel: %v", err)
+	}
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	if n := len(v.items[common.MAV_MISSION_TYPE_MISSION]); n != len(items) {
+		t.Errorf("vehicle holds %d items after the second upload, want %d", n, len(items))
+	}
+}
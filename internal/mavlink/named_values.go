@@ -0,0 +1,72 @@
+package mavlink
+
+import (
+	"time"
+
+	"github.com/bluenviron/gomavlib/v3/pkg/dialects/common"
+)
+
+// NamedValue is the latest NAMED_VALUE_FLOAT or NAMED_VALUE_INT for a name
+// Autopilots and payloads use these for custom metrics (e.g. a sprayer flow rate).
+type NamedValue struct {
+	Name       string    `json:"name"`
+	Value      float64   `json:"value"`
+	Integer    bool      `json:"integer"`      // from NAMED_VALUE_INT
+	TimeBootMs uint32    `json:"time_boot_ms"` // sender's timestamp
+	Updated    time.Time `json:"updated"`
+}
+
+// GetNamedValues returns the latest value of every name received, keyed by name
+func (c *Client) GetNamedValues() map[string]NamedValue {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	values := make(map[string]NamedValue, len(c.namedValues))
+	for name, v := range c.namedValues {
+		values[name] = v
+	}
+	return values
+}
+
+// GetNamedValue returns the latest value for a name and whether one was received
+func (c *Client) GetNamedValue(name string) (NamedValue, bool) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	v, ok := c.namedValues[name]
+	return v, ok
+}
+
+// SubscribeNamedValues returns a channel receiving every named value update
+// Call the returned function to unsubscribe; the channel is closed afterwards.
+func (c *Client) SubscribeNamedValues() (<-chan NamedValue, func()) {
+	return c.namedValueUpdates.subscribe()
+}
+
+// handleNamedValueFloat processes NAMED_VALUE_FLOAT messages
+func (c *Client) handleNamedValueFloat(msg *common.MessageNamedValueFloat) {
+	c.storeNamedValue(NamedValue{
+		Name:       msg.Name,
+		Value:      float64(msg.Value),
+		TimeBootMs: msg.TimeBootMs,
+	})
+}
+
+// handleNamedValueInt processes NAMED_VALUE_INT messages
+func (c *Client) handleNamedValueInt(msg *common.MessageNamedValueInt) {
+	c.storeNamedValue(NamedValue{
+		Name:       msg.Name,
+		Value:      float64(msg.Value),
+		Integer:    true,
+		TimeBootMs: msg.TimeBootMs,
+	})
+}
+
+func (c *Client) storeNamedValue(v NamedValue) {
+	v.Updated = time.Now()
+
+	c.mu.Lock()
+	c.namedValues[v.Name] = v
+	c.mu.Unlock()
+
+	c.namedValueUpdates.publish(v)
+}
@@ -0,0 +1,45 @@
+package mavlink
+
+import (
+	"testing"
+
+	"github.com/bluenviron/gomavlib/v3/pkg/dialects/common"
+)
+
+func TestNamedValues(t *testing.T) {
+	c := newConnectedTestClient()
+	updates, unsubscribe := c.SubscribeNamedValues()
+	defer unsubscribe()
+
+	c.handleMessage(&common.MessageNamedValueFloat{Name: "flow_rate", Value: 1.25, TimeBootMs: 1000}, 1, 1)
+	c.handleMessage(&common.MessageNamedValueInt{Name: "tank_pct", Value: 80, TimeBootMs: 1100}, 1, 1)
+	c.handleMessage(&common.MessageNamedValueFloat{Name: "flow_rate", Value: 1.5, TimeBootMs: 1200}, 1, 1)
+
+	v, ok := c.GetNamedValue("flow_rate")
+	if !ok || v.Value != 1.5 || v.Integer || v.TimeBootMs != 1200 || v.Updated.IsZero() {
+		t.Errorf("flow_rate = %+v, %v", v, ok)
+	}
+	if v, ok := c.GetNamedValue("tank_pct"); !ok || v.Value != 80 || !v.Integer {
+		t.Errorf("tank_pct = %+v, %v", v, ok)
+	}
+	if _, ok := c.GetNamedValue("pressure"); ok {
+		t.Error("value reported for a name never received")
+	}
+
+	values := c.GetNamedValues()
+	if len(values) != 2 || values["flow_rate"].Value != 1.5 {
+		t.Errorf("GetNamedValues() = %+v", values)
+	}
+	// A copy: callers can't change the client's map
+	delete(values, "flow_rate")
+	if _, ok := c.GetNamedValue("flow_rate"); !ok {
+		t.Error("deleting from the returned map removed the value")
+	}
+
+	// Every update is published, in order
+	for _, want := range []string{"flow_rate", "tank_pct", "flow_rate"} {
+		if got := <-updates; got.Name != want {
+			t.Errorf("update for %s, want %s", got.Name, want)
+		}
+	}
+}
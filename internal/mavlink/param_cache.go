@@ -0,0 +1,328 @@
+package mavlink
+
+import (
+	"fmt"
+	"math"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/bluenviron/gomavlib/v3/pkg/dialects/common"
+)
+
+const (
+	// DefaultParamCacheMaxAge is how long a cached parameter is served before it is read again
+	DefaultParamCacheMaxAge = 5 * time.Minute
+
+	// paramReadTimeout bounds the wait for PARAM_VALUE replies to a batch of reads
+	paramReadTimeout = 2 * time.Second
+
+	// paramReadAttempts is how often an unanswered read is sent (lossy links drop some)
+	paramReadAttempts = 2
+
+	// paramListIdleTimeout ends a PARAM_REQUEST_LIST download that has stopped delivering
+	paramListIdleTimeout = 3 * time.Second
+
+	// paramPollInterval is how often waiters check the cache for replies
+	paramPollInterval = 50 * time.Millisecond
+)
+
+// Parameter is a vehicle parameter as last reported by PARAM_VALUE
+type Parameter struct {
+	Name    string    `json:"name"`
+	Value   float64   `json:"value"`
+	Type    string    `json:"type"`  // MAV_PARAM_TYPE without prefix, e.g. "INT32", "REAL32"
+	Index   int       `json:"index"` // -1 when the vehicle didn't send one
+	Updated time.Time `json:"updated"`
+}
+
+// paramCache holds parameters received from the vehicle, guarded by c.mu
+// Every PARAM_VALUE updates it, whether we asked for it or not.
+type paramCache struct {
+	entries map[string]Parameter
+
+	count        int       // total the vehicle reports (0 until the first PARAM_VALUE)
+	lastReceived time.Time // last PARAM_VALUE
+	listed       time.Time // last complete PARAM_REQUEST_LIST download (zero = never)
+}
+
+// GetParameters returns the named parameters, reading any missing or stale
+// ones from the vehicle with PARAM_REQUEST_READ
+// Parameters the vehicle doesn't answer for are returned in missing, in the
+// order asked. Names are case-sensitive, as in MAVLink.
+func (c *Client) GetParameters(names []string) (params []Parameter, missing []string, err error) {
+	if !c.IsConnected() {
+		return nil, nil, fmt.Errorf("not connected to drone")
+	}
+
+	start := time.Now()
+	stale := c.staleParams(names, start)
+
+	for attempt := 0; attempt < paramReadAttempts && len(stale) > 0; attempt++ {
+		if err := c.requestParams(stale); err != nil {
+			return nil, nil, err
+		}
+		stale = c.waitForParams(stale, start, paramReadTimeout)
+	}
+
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	for _, name := range names {
+		if p, ok := c.params.entries[name]; ok && !slices.Contains(stale, name) {
+			params = append(params, p)
+		} else {
+			missing = append(missing, name)
+		}
+	}
+	return params, missing, nil
+}
+
+// GetParametersByPrefix returns every parameter whose name starts with prefix,
+// sorted by name ("" returns all)
+// The full list is downloaded first if it never was or is older than the cache age.
+func (c *Client) GetParametersByPrefix(prefix string) ([]Parameter, error) {
+	c.mu.RLock()
+	listed := c.params.listed
+	c.mu.RUnlock()
+
+	if listed.IsZero() || time.Since(listed) > c.paramCacheMaxAge {
+		if err := c.DownloadParameters(); err != nil {
+			return nil, err
+		}
+	}
+
+	c.mu.RLock()
+	params := make([]Parameter, 0)
+	for name, p := range c.params.entries {
+		if strings.HasPrefix(name, prefix) {
+			params = append(params, p)
+		}
+	}
+	c.mu.RUnlock()
+
+	sort.Slice(params, func(i, j int) bool { return params[i].Name < params[j].Name })
+	return params, nil
+}
+
+// DownloadParameters refreshes the whole cache with PARAM_REQUEST_LIST
+// Indices lost on the link are read individually afterwards. Concurrent calls
+// share one download.
+func (c *Client) DownloadParameters() error {
+	if !c.IsConnected() {
+		return fmt.Errorf("not connected to drone")
+	}
+
+	c.paramListMu.Lock()
+	defer c.paramListMu.Unlock()
+
+	// A download that finished while we waited for the lock is fresh enough
+	start := time.Now()
+	c.mu.RLock()
+	systemID := c.systemID
+	justListed := !c.params.listed.IsZero() && time.Since(c.params.listed) < paramListIdleTimeout
+	c.mu.RUnlock()
+	if justListed {
+		return nil
+	}
+
+	c.logger.Println("MAVLink: Requesting parameter list")
+	err := c.writeMessage(&common.MessageParamRequestList{
+		TargetSystem:    systemID,
+		TargetComponent: 1,
+	})
+	if err != nil {
+		return err
+	}
+
+	missing := c.waitForParamList(start)
+	for attempt := 0; attempt < paramReadAttempts && len(missing) > 0; attempt++ {
+		c.logger.Printf("MAVLink: Parameter list missing %d entries, requesting them", len(missing))
+		if err := c.requestParamIndices(missing); err != nil {
+			return err
+		}
+		missing = c.waitForParamList(start)
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.params.count == 0 {
+		return fmt.Errorf("no parameters received within %s", paramListIdleTimeout)
+	}
+	if len(missing) > 0 {
+		return fmt.Errorf("received %d of %d parameters", c.params.count-len(missing), c.params.count)
+	}
+	c.params.listed = time.Now()
+	c.logger.Printf("MAVLink: Downloaded %d parameters", c.params.count)
+	return nil
+}
+
+// staleParams returns the names not cached or cached longer than the cache age
+func (c *Client) staleParams(names []string, now time.Time) []string {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	var stale []string
+	for _, name := range names {
+		p, ok := c.params.entries[name]
+		if !ok || now.Sub(p.Updated) > c.paramCacheMaxAge {
+			stale = append(stale, name)
+		}
+	}
+	return stale
+}
+
+// requestParams sends a PARAM_REQUEST_READ by name for each parameter
+func (c *Client) requestParams(names []string) error {
+	c.mu.RLock()
+	systemID := c.systemID
+	c.mu.RUnlock()
+
+	for _, name := range names {
+		err := c.writeMessage(&common.MessageParamRequestRead{
+			TargetSystem:    systemID,
+			TargetComponent: 1,
+			ParamId:         name,
+			ParamIndex:      -1, // Use the name
+		})
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// requestParamIndices sends a PARAM_REQUEST_READ by index for each index
+func (c *Client) requestParamIndices(indices []int) error {
+	c.mu.RLock()
+	systemID := c.systemID
+	c.mu.RUnlock()
+
+	for _, index := range indices {
+		err := c.writeMessage(&common.MessageParamRequestRead{
+			TargetSystem:    systemID,
+			TargetComponent: 1,
+			ParamIndex:      int16(index),
+		})
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// waitForParams waits until every name has a value received since start,
+// returning those still outstanding when timeout expires
+func (c *Client) waitForParams(names []string, start time.Time, timeout time.Duration) []string {
+	deadline := time.Now().Add(timeout)
+	for {
+		c.mu.RLock()
+		var outstanding []string
+		for _, name := range names {
+			if p, ok := c.params.entries[name]; !ok || p.Updated.Before(start) {
+				outstanding = append(outstanding, name)
+			}
+		}
+		c.mu.RUnlock()
+
+		if len(outstanding) == 0 || time.Now().After(deadline) {
+			return outstanding
+		}
+		time.Sleep(paramPollInterval)
+	}
+}
+
+// waitForParamList waits until every index has been received since start, or
+// the list stops arriving, and returns the indices still missing
+func (c *Client) waitForParamList(start time.Time) []int {
+	idleSince := time.Now()
+	for {
+		c.mu.RLock()
+		count := c.params.count
+		received := make(map[int]bool, count)
+		for _, p := range c.params.entries {
+			if p.Index >= 0 && !p.Updated.Before(start) {
+				received[p.Index] = true
+			}
+		}
+		if c.params.lastReceived.After(idleSince) {
+			idleSince = c.params.lastReceived
+		}
+		c.mu.RUnlock()
+
+		if count > 0 && len(received) >= count {
+			return nil
+		}
+		if time.Since(idleSince) > paramListIdleTimeout {
+			missing := make([]int, 0, count-len(received))
+			for i := 0; i < count; i++ {
+				if !received[i] {
+					missing = append(missing, i)
+				}
+			}
+			return missing
+		}
+		time.Sleep(paramPollInterval)
+	}
+}
+
+// invalidateParam drops a cached parameter so it is read again
+func (c *Client) invalidateParam(name string) {
+	c.mu.Lock()
+	delete(c.params.entries, name)
+	c.mu.Unlock()
+}
+
+// cacheParamValue stores a PARAM_VALUE, replacing any cached value
+func (c *Client) cacheParamValue(msg *common.MessageParamValue) {
+	now := time.Now()
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	index := int(msg.ParamIndex)
+	if msg.ParamIndex == math.MaxUint16 {
+		// Unsolicited (e.g. a PARAM_SET echo): keep the index from the list
+		index = -1
+		if prev, ok := c.params.entries[msg.ParamId]; ok {
+			index = prev.Index
+		}
+	}
+
+	c.params.entries[msg.ParamId] = Parameter{
+		Name:    msg.ParamId,
+		Value:   decodeParamValue(c.stats.autopilot, msg.ParamValue, msg.ParamType),
+		Type:    strings.TrimPrefix(msg.ParamType.String(), "MAV_PARAM_TYPE_"),
+		Index:   index,
+		Updated: now,
+	}
+	c.params.count = int(msg.ParamCount)
+	c.params.lastReceived = now
+}
+
+// decodeParamValue converts PARAM_VALUE's float field to the parameter's value
+// PX4 packs integer bits into the float (bytewise encoding); ArduPilot casts.
+func decodeParamValue(autopilot common.MAV_AUTOPILOT, value float32, paramType common.MAV_PARAM_TYPE) float64 {
+	if autopilot != common.MAV_AUTOPILOT_PX4 {
+		return float64(value)
+	}
+
+	bits := math.Float32bits(value)
+	switch paramType {
+	case common.MAV_PARAM_TYPE_UINT8:
+		return float64(uint8(bits))
+	case common.MAV_PARAM_TYPE_INT8:
+		return float64(int8(bits))
+	case common.MAV_PARAM_TYPE_UINT16:
+		return float64(uint16(bits))
+	case common.MAV_PARAM_TYPE_INT16:
+		return float64(int16(bits))
+	case common.MAV_PARAM_TYPE_UINT32:
+		return float64(bits)
+	case common.MAV_PARAM_TYPE_INT32:
+		return float64(int32(bits))
+	default:
+		return float64(value)
+	}
+}
@@ -0,0 +1,245 @@
+package mavlink
+
+import (
+	"math"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/bluenviron/gomavlib/v3"
+	"github.com/bluenviron/gomavlib/v3/pkg/dialects/common"
+)
+
+// paramVehicle is the vehicle side of the parameter protocol, serving REAL32
+// parameters by index (their order in names)
+type paramVehicle struct {
+	mu     sync.Mutex
+	names  []string
+	values map[string]float32
+	lists  int             // PARAM_REQUEST_LIST received
+	reads  int             // PARAM_REQUEST_READ received
+	drop   map[string]bool // left out of list downloads, as on a lossy link
+}
+
+// serveParams answers c's parameter requests until the test ends, sending
+// heartbeats meanwhile so the client stays connected through idle waits
+func serveParams(t *testing.T, c *Client, vehicle *gomavlib.Node, names []string, values []float32) *paramVehicle {
+	t.Helper()
+	v := &paramVehicle{names: names, values: make(map[string]float32), drop: make(map[string]bool)}
+	for i, name := range names {
+		v.values[name] = values[i]
+	}
+	done := make(chan struct{})
+	t.Cleanup(func() { close(done) })
+
+	go func() {
+		heartbeats := time.NewTicker(500 * time.Millisecond)
+		defer heartbeats.Stop()
+		for {
+			var evt gomavlib.Event
+			select {
+			case evt = <-vehicle.Events():
+			case <-heartbeats.C:
+				c.handleMessage(&common.MessageHeartbeat{
+					Type:      common.MAV_TYPE_QUADROTOR,
+					Autopilot: common.MAV_AUTOPILOT_PX4,
+				}, 1, 1)
+				continue
+			case <-done:
+				return
+			}
+			frame, ok := evt.(*gomavlib.EventFrame)
+			if !ok {
+				continue
+			}
+
+			v.mu.Lock()
+			var reply []*common.MessageParamValue
+			switch msg := frame.Message().(type) {
+			case *common.MessageParamRequestList:
+				v.lists++
+				for i, name := range v.names {
+					if !v.drop[name] {
+						reply = append(reply, v.value(i))
+					}
+				}
+			case *common.MessageParamRequestRead:
+				v.reads++
+				for i, name := range v.names {
+					if name == msg.ParamId || msg.ParamIndex == int16(i) {
+						reply = append(reply, v.value(i))
+					}
+				}
+			}
+			v.mu.Unlock()
+
+			for _, msg := range reply {
+				c.handleMessage(msg, 1, 1)
+			}
+		}
+	}()
+	return v
+}
+
+// value is the PARAM_VALUE for parameter index i, with v.mu held
+func (v *paramVehicle) value(i int) *common.MessageParamValue {
+	return &common.MessageParamValue{
+		ParamId:    v.names[i],
+		ParamValue: v.values[v.names[i]],
+		ParamType:  common.MAV_PARAM_TYPE_REAL32,
+		ParamCount: uint16(len(v.names)),
+		ParamIndex: uint16(i),
+	}
+}
+
+// requests returns the list and read requests received so far
+func (v *paramVehicle) requests() (lists, reads int) {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	return v.lists, v.reads
+}
+
+func (v *paramVehicle) set(name string, value float32) {
+	v.mu.Lock()
+	v.values[name] = value
+	v.mu.Unlock()
+}
+
+func TestGetParametersCacheHit(t *testing.T) {
+	c, vehicle := newLinkedTestClient(t)
+	c.paramCacheMaxAge = DefaultParamCacheMaxAge
+	v := serveParams(t, c, vehicle, []string{"MPC_XY_VEL_MAX", "MPC_Z_VEL_MAX_UP"}, []float32{12, 3})
+
+	names := []string{"MPC_Z_VEL_MAX_UP", "MPC_XY_VEL_MAX"}
+	params, missing, err := c.GetParameters(names)
+	if err != nil || len(missing) != 0 {
+		t.Fatalf("GetParameters: missing %v, %v", missing, err)
+	}
+	if len(params) != 2 || params[0].Name != names[0] || params[0].Value != 3 || params[1].Value != 12 {
+		t.Fatalf("params = %+v", params)
+	}
+	if params[0].Type != "REAL32" || params[1].Index != 0 {
+		t.Errorf("param %+v", params[0])
+	}
+	if _, reads := v.requests(); reads != 2 {
+		t.Errorf("%d reads, want 2", reads)
+	}
+
+	// Served from the cache
+	v.set("MPC_XY_VEL_MAX", 8)
+	params, _, err = c.GetParameters(names)
+	if err != nil || params[1].Value != 12 {
+		t.Fatalf("cached params = %+v, %v", params, err)
+	}
+	if _, reads := v.requests(); reads != 2 {
+		t.Errorf("%d reads after a cache hit, want 2", reads)
+	}
+}
+
+func TestGetParametersStale(t *testing.T) {
+	c, vehicle := newLinkedTestClient(t)
+	c.paramCacheMaxAge = 50 * time.Millisecond
+	v := serveParams(t, c, vehicle, []string{"MPC_XY_VEL_MAX"}, []float32{12})
+
+	if _, _, err := c.GetParameters([]string{"MPC_XY_VEL_MAX"}); err != nil {
+		t.Fatal(err)
+	}
+	v.set("MPC_XY_VEL_MAX", 8)
+	time.Sleep(100 * time.Millisecond)
+
+	params, _, err := c.GetParameters([]string{"MPC_XY_VEL_MAX"})
+	if err != nil || len(params) != 1 || params[0].Value != 8 {
+		t.Fatalf("stale parameter not read again: %+v, %v", params, err)
+	}
+	if _, reads := v.requests(); reads != 2 {
+		t.Errorf("%d reads, want 2", reads)
+	}
+}
+
+func TestParamCacheInvalidation(t *testing.T) {
+	c, vehicle := newLinkedTestClient(t)
+	c.paramCacheMaxAge = DefaultParamCacheMaxAge
+
+	cached := func(name string) (Parameter, bool) {
+		c.mu.RLock()
+		defer c.mu.RUnlock()
+		p, ok := c.params.entries[name]
+		return p, ok
+	}
+
+	// Unsolicited values replace the cached one, keeping the listed index
+	c.handleMessage(&common.MessageParamValue{
+		ParamId: "COM_RC_LOSS_T", ParamValue: 0.5, ParamType: common.MAV_PARAM_TYPE_REAL32,
+		ParamCount: 40, ParamIndex: 7,
+	}, 1, 1)
+	c.handleMessage(&common.MessageParamValue{
+		ParamId: "COM_RC_LOSS_T", ParamValue: 1.5, ParamType: common.MAV_PARAM_TYPE_REAL32,
+		ParamCount: 40, ParamIndex: math.MaxUint16,
+	}, 1, 1)
+	if p, ok := cached("COM_RC_LOSS_T"); !ok || p.Value != 1.5 || p.Index != 7 {
+		t.Errorf("after an unsolicited PARAM_VALUE: %+v", p)
+	}
+
+	// PARAM_SET drops the entry until the vehicle echoes the new value
+	c.handleMessage(&common.MessageParamValue{
+		ParamId: "NAV_RCL_ACT", ParamValue: math.Float32frombits(2), ParamType: common.MAV_PARAM_TYPE_INT32,
+		ParamCount: 40, ParamIndex: 8,
+	}, 1, 1)
+	done := make(chan error, 1)
+	go func() { done <- c.setIntParameter("NAV_RCL_ACT", 3, common.MAV_PARAM_TYPE_INT32) }()
+
+	set := receive[*common.MessageParamSet](t, vehicle)
+	if p, ok := cached("NAV_RCL_ACT"); ok {
+		t.Errorf("%+v still cached while being set", p)
+	}
+	c.handleMessage(&common.MessageParamValue{
+		ParamId: set.ParamId, ParamValue: set.ParamValue, ParamType: set.ParamType,
+		ParamCount: 40, ParamIndex: math.MaxUint16,
+	}, 1, 1)
+	if err := <-done; err != nil {
+		t.Fatal(err)
+	}
+	if p, ok := cached("NAV_RCL_ACT"); !ok || p.Value != 3 {
+		t.Errorf("after the echo: %+v", p)
+	}
+}
+
+func TestGetParametersByPrefix(t *testing.T) {
+	c, vehicle := newLinkedTestClient(t)
+	c.paramCacheMaxAge = DefaultParamCacheMaxAge
+	v := serveParams(t, c, vehicle,
+		[]string{"MPC_Z_VEL_MAX_UP", "COM_RC_LOSS_T", "MPC_XY_VEL_MAX", "MPC_TKO_SPEED"},
+		[]float32{3, 0.5, 12, 1.5})
+	v.mu.Lock()
+	v.drop["MPC_TKO_SPEED"] = true
+	v.mu.Unlock()
+
+	// The lost entry is read by index once the list goes quiet
+	params, err := c.GetParametersByPrefix("MPC_")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []string{"MPC_TKO_SPEED", "MPC_XY_VEL_MAX", "MPC_Z_VEL_MAX_UP"}
+	if len(params) != len(want) {
+		t.Fatalf("params = %+v", params)
+	}
+	for i, p := range params {
+		if p.Name != want[i] {
+			t.Errorf("param %d = %s, want %s", i, p.Name, want[i])
+		}
+	}
+	if lists, reads := v.requests(); lists != 1 || reads != 1 {
+		t.Errorf("%d lists and %d reads, want 1 and 1", lists, reads)
+	}
+
+	// The downloaded list serves later prefixes and batch reads
+	if all, err := c.GetParametersByPrefix(""); err != nil || len(all) != 4 {
+		t.Fatalf("all params = %+v, %v", all, err)
+	}
+	if _, _, err := c.GetParameters([]string{"COM_RC_LOSS_T"}); err != nil {
+		t.Fatal(err)
+	}
+	if lists, reads := v.requests(); lists != 1 || reads != 1 {
+		t.Errorf("%d lists and %d reads after cache hits, want 1 and 1", lists, reads)
+	}
+}
@@ -0,0 +1,84 @@
+package mavlink
+
+import (
+	"fmt"
+	"math"
+	"time"
+
+	"github.com/bluenviron/gomavlib/v3/pkg/dialects/common"
+)
+
+// paramSetTimeout bounds the wait for the PARAM_VALUE that confirms a PARAM_SET
+const paramSetTimeout = 3 * time.Second
+
+// setIntParameter sets an integer parameter and waits for the vehicle to echo it
+// PX4 packs integer bits into the float field (bytewise encoding) while
+// ArduPilot sends the numeric value, so the encoding follows the autopilot.
+func (c *Client) setIntParameter(name string, value int32, paramType common.MAV_PARAM_TYPE) error {
+	c.mu.Lock()
+	systemID := c.systemID
+	autopilot := c.stats.autopilot
+	if _, busy := c.pendingParams[name]; busy {
+		c.mu.Unlock()
+		return fmt.Errorf("parameter %s already being set", name)
+	}
+	echo := make(chan *common.MessageParamValue, 1)
+	c.pendingParams[name] = echo
+	c.mu.Unlock()
+
+	defer func() {
+		c.mu.Lock()
+		delete(c.pendingParams, name)
+		c.mu.Unlock()
+	}()
+
+	encoded := float32(value)
+	if autopilot == common.MAV_AUTOPILOT_PX4 {
+		encoded = math.Float32frombits(uint32(value))
+	}
+
+	c.logger.Printf("MAVLink: Setting parameter %s=%d", name, value)
+
+	// Until the echo arrives the cached value may no longer be the vehicle's
+	c.invalidateParam(name)
+
+	err := c.writeMessage(&common.MessageParamSet{
+		TargetSystem:    systemID,
+		TargetComponent: 1,
+		ParamId:         name,
+		ParamValue:      encoded,
+		ParamType:       paramType,
+	})
+	if err != nil {
+		return err
+	}
+
+	select {
+	case msg := <-echo:
+		// The vehicle echoes the current value, which differs if it refused the new one
+		if math.Float32bits(msg.ParamValue) != math.Float32bits(encoded) {
+			return fmt.Errorf("vehicle kept %s at its previous value", name)
+		}
+		return nil
+	case <-time.After(paramSetTimeout):
+		return fmt.Errorf("parameter %s not confirmed within %s", name, paramSetTimeout)
+	}
+}
+
+// handleParamValue caches a PARAM_VALUE and hands it to the setter waiting for it
+func (c *Client) handleParamValue(msg *common.MessageParamValue) {
+	c.cacheParamValue(msg)
+
+	c.mu.RLock()
+	echo, ok := c.pendingParams[msg.ParamId]
+	c.mu.RUnlock()
+
+	if !ok {
+		return
+	}
+
+	select {
+	case echo <- msg:
+	default:
+	}
+}
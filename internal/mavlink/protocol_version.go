@@ -0,0 +1,111 @@
+package mavlink
+
+import (
+	"errors"
+	"time"
+
+	"github.com/bluenviron/gomavlib/v3/pkg/dialects/common"
+)
+
+// MAVLink protocol versions as PROTOCOL_VERSION reports them (version * 100)
+const (
+	ProtocolVersion1 = 100
+	ProtocolVersion2 = 200
+)
+
+// protocolVersionTimeout bounds the wait for PROTOCOL_VERSION while connecting;
+// vehicles that don't answer are assumed to speak MAVLink 2
+const protocolVersionTimeout = time.Second
+
+// How telemetry is requested after connecting (see StreamRequestMethod)
+const (
+	StreamRequestMessageInterval = "message_interval" // SET_MESSAGE_INTERVAL per message
+	StreamRequestDataStream      = "data_stream"      // REQUEST_DATA_STREAM for all streams
+)
+
+// NegotiateProtocolVersion returns the version both sides speak: the highest
+// the vehicle supports, capped at MAVLink 2 (what the client sends)
+// Vehicles leaving max_version unset report only their active version.
+func NegotiateProtocolVersion(msg *common.MessageProtocolVersion) uint16 {
+	version := msg.MaxVersion
+	if version == 0 {
+		version = msg.Version
+	}
+	return min(version, ProtocolVersion2)
+}
+
+// StreamRequestMethod picks how telemetry is requested after connecting
+// Per-message rates need SET_MESSAGE_INTERVAL, which firmware old enough to
+// speak only MAVLink 1 doesn't implement, so such vehicles (and clients
+// without rates) get REQUEST_DATA_STREAM.
+func StreamRequestMethod(protocolVersion uint16, hasRates bool) string {
+	if hasRates && protocolVersion >= ProtocolVersion2 {
+		return StreamRequestMessageInterval
+	}
+	return StreamRequestDataStream
+}
+
+// ProtocolVersion returns the negotiated MAVLink version and whether the
+// vehicle reported it (ProtocolVersion2 is assumed when it didn't)
+func (c *Client) ProtocolVersion() (uint16, bool) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.protocolVersionLocked()
+}
+
+// protocolVersionLocked is ProtocolVersion; caller must hold c.mu
+func (c *Client) protocolVersionLocked() (uint16, bool) {
+	if !c.stats.protocolVersionReported {
+		return ProtocolVersion2, false
+	}
+	return c.stats.protocolVersion, true
+}
+
+// handleProtocolVersion processes PROTOCOL_VERSION messages
+func (c *Client) handleProtocolVersion(msg *common.MessageProtocolVersion) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.stats.protocolVersion = NegotiateProtocolVersion(msg)
+	c.stats.protocolVersionReported = true
+}
+
+// negotiateProtocolVersion requests PROTOCOL_VERSION and waits up to
+// protocolVersionTimeout for the answer, falling back to MAVLink 2
+func (c *Client) negotiateProtocolVersion() uint16 {
+	c.mu.RLock()
+	systemID := c.systemID
+	c.mu.RUnlock()
+
+	// Not sendAcknowledged: the answer that matters is PROTOCOL_VERSION, and
+	// vehicles that don't know the command may not ACK it at all
+	err := c.writeMessage(&common.MessageCommandLong{
+		TargetSystem:    systemID,
+		TargetComponent: 1,
+		Command:         common.MAV_CMD_REQUEST_PROTOCOL_VERSION,
+		Param1:          1, // Request
+	})
+	if err != nil {
+		if !errors.Is(err, ErrPassiveMode) {
+			c.logger.Printf("MAVLink: Warning - failed to request protocol version: %v", err)
+		}
+		return ProtocolVersion2
+	}
+
+	deadline := time.Now().Add(protocolVersionTimeout)
+	for {
+		version, reported := c.ProtocolVersion()
+		if reported {
+			c.logger.Printf("MAVLink: Protocol version %d.%d negotiated", version/100, version%100)
+			if version < ProtocolVersion2 {
+				c.logger.Printf("MAVLink: Warning - vehicle supports MAVLink 1 only; the client sends MAVLink 2 frames")
+			}
+			return version
+		}
+		if time.Now().After(deadline) {
+			c.logger.Printf("MAVLink: No PROTOCOL_VERSION within %s, assuming MAVLink 2", protocolVersionTimeout)
+			return ProtocolVersion2
+		}
+		time.Sleep(20 * time.Millisecond)
+	}
+}
@@ -0,0 +1,86 @@
+package mavlink
+
+import (
+	"testing"
+
+	"github.com/bluenviron/gomavlib/v3/pkg/dialects/common"
+)
+
+func TestNegotiateProtocolVersion(t *testing.T) {
+	tests := []struct {
+		name string
+		msg  common.MessageProtocolVersion
+		want uint16
+	}{
+		{"MAVLink 2 vehicle", common.MessageProtocolVersion{Version: 200, MinVersion: 100, MaxVersion: 200}, ProtocolVersion2},
+		{"MAVLink 1 only", common.MessageProtocolVersion{Version: 100, MinVersion: 100, MaxVersion: 100}, ProtocolVersion1},
+		{"newer than the client", common.MessageProtocolVersion{Version: 200, MaxVersion: 300}, ProtocolVersion2},
+		{"max version unset", common.MessageProtocolVersion{Version: 100}, ProtocolVersion1},
+	}
+	for _, tt := range tests {
+		if got := NegotiateProtocolVersion(&tt.msg); got != tt.want {
+			t.Errorf("%s: NegotiateProtocolVersion() = %d, want %d", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestNegotiateProtocolVersionRequest(t *testing.T) {
+	c, vehicle := newLinkedTestClient(t)
+	if version, reported := c.ProtocolVersion(); version != ProtocolVersion2 || reported {
+		t.Fatalf("before negotiating: %d, reported %v; want MAVLink 2 assumed", version, reported)
+	}
+
+	done := make(chan uint16, 1)
+	go func() { done <- c.negotiateProtocolVersion() }()
+	msg := receive[*common.MessageCommandLong](t, vehicle)
+	if msg.Command != common.MAV_CMD_REQUEST_PROTOCOL_VERSION || msg.Param1 != 1 {
+		t.Fatalf("sent %v (%v), want a protocol version request", msg.Command, msg.Param1)
+	}
+	c.handleMessage(&common.MessageProtocolVersion{Version: 100, MinVersion: 100, MaxVersion: 100}, 1, 1)
+
+	if version := <-done; version != ProtocolVersion1 {
+		t.Errorf("negotiated %d, want %d", version, ProtocolVersion1)
+	}
+	if version, reported := c.ProtocolVersion(); version != ProtocolVersion1 || !reported {
+		t.Errorf("stored %d, reported %v", version, reported)
+	}
+	if info := c.GetConnectionInfo(); info.ProtocolVersion != ProtocolVersion1 || !info.ProtocolVersionReported {
+		t.Errorf("connection info version %d, reported %v", info.ProtocolVersion, info.ProtocolVersionReported)
+	}
+}
+
+func TestProtocolVersionSelectsStreamRequest(t *testing.T) {
+	if got := StreamRequestMethod(ProtocolVersion2, false); got != StreamRequestDataStream {
+		t.Errorf("without message rates: %s", got)
+	}
+
+	c, vehicle := newLinkedTestClient(t)
+	c.messageRates = map[string]float64{"ATTITUDE": 10}
+	requestsDataStream := func() bool {
+		t.Helper()
+		version, _ := c.ProtocolVersion()
+		c.requestTelemetry(version)
+		msg := receive[*common.MessageRequestDataStream](t, vehicle)
+		return msg.StartStop == 1 && msg.ReqStreamId == uint8(common.MAV_DATA_STREAM_ALL)
+	}
+	setsIntervals := func() bool {
+		t.Helper()
+		version, _ := c.ProtocolVersion()
+		c.requestTelemetry(version)
+		msg := receive[*common.MessageCommandLong](t, vehicle)
+		return msg.Command == common.MAV_CMD_SET_MESSAGE_INTERVAL && uint32(msg.Param1) == (&common.MessageAttitude{}).GetID()
+	}
+
+	// Unreported, MAVLink 2 is assumed
+	if !setsIntervals() {
+		t.Error("assumed MAVLink 2 vehicle not sent SET_MESSAGE_INTERVAL")
+	}
+	c.handleMessage(&common.MessageProtocolVersion{Version: 100, MaxVersion: 100}, 1, 1)
+	if !requestsDataStream() {
+		t.Error("MAVLink 1 vehicle not sent REQUEST_DATA_STREAM")
+	}
+	c.handleMessage(&common.MessageProtocolVersion{Version: 200, MaxVersion: 200}, 1, 1)
+	if !setsIntervals() {
+		t.Error("MAVLink 2 vehicle not sent SET_MESSAGE_INTERVAL")
+	}
+}
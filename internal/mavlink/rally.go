@@ -0,0 +1,49 @@
+package mavlink
+
+import (
+	"github.com/bluenviron/gomavlib/v3/pkg/dialects/common"
+)
+
+// RallyPoint is an alternative return location stored on the vehicle
+// Altitude is relative to home, in meters.
+type RallyPoint struct {
+	Latitude  float64 `json:"latitude"`
+	Longitude float64 `json:"longitude"`
+	Altitude  float64 `json:"altitude"`
+}
+
+// UploadRallyPoints replaces the rally points stored on the vehicle
+func (c *Client) UploadRallyPoints(points []RallyPoint) error {
+	items := make([]MissionItem, len(points))
+	for i, p := range points {
+		items[i] = MissionItem{
+			Command: common.MAV_CMD_NAV_RALLY_POINT,
+			Frame:   common.MAV_FRAME_GLOBAL_RELATIVE_ALT,
+			X:       int32(p.Latitude * 1e7),
+			Y:       int32(p.Longitude * 1e7),
+			Z:       float32(p.Altitude),
+		}
+	}
+	return c.UploadItems(common.MAV_MISSION_TYPE_RALLY, items)
+}
+
+// DownloadRallyPoints reads the rally points stored on the vehicle
+func (c *Client) DownloadRallyPoints() ([]RallyPoint, error) {
+	items, err := c.DownloadItems(common.MAV_MISSION_TYPE_RALLY)
+	if err != nil {
+		return nil, err
+	}
+
+	points := make([]RallyPoint, 0, len(items))
+	for _, item := range items {
+		if item.Command != common.MAV_CMD_NAV_RALLY_POINT {
+			continue
+		}
+		points = append(points, RallyPoint{
+			Latitude:  float64(item.X) / 1e7,
+			Longitude: float64(item.Y) / 1e7,
+			Altitude:  float64(item.Z),
+		})
+	}
+	return points, nil
+}
@@ -0,0 +1,64 @@
+package mavlink
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/bluenviron/gomavlib/v3"
+	"github.com/bluenviron/gomavlib/v3/pkg/message"
+)
+
+// RawMessage is an incoming MAVLink frame for clients that decode it themselves
+// Messages outside the decoding dialect (see Config.InboundMessages) have no
+// Name or Fields; their undecoded payload is in Payload instead.
+type RawMessage struct {
+	ID          uint32          `json:"id"`
+	Name        string          `json:"name,omitempty"`
+	SystemID    uint8           `json:"system_id"`
+	ComponentID uint8           `json:"component_id"`
+	Fields      json.RawMessage `json:"fields,omitempty"`
+	Payload     []byte          `json:"payload,omitempty"`
+	Time        time.Time       `json:"time"`
+}
+
+// SubscribeRawMessages returns a channel receiving every frame on the link,
+// from all systems. Frames are dropped when the subscriber falls behind (see
+// RawMessagesDropped). Call the returned function to unsubscribe; the channel
+// is closed afterwards.
+func (c *Client) SubscribeRawMessages() (<-chan RawMessage, func()) {
+	return c.rawMessages.subscribe()
+}
+
+// RawMessagesDropped returns how many raw messages slow subscribers missed
+func (c *Client) RawMessagesDropped() uint64 {
+	return c.rawMessages.dropped.Load()
+}
+
+// publishRawMessage encodes a frame once and fans it out to raw subscribers
+func (c *Client) publishRawMessage(frm *gomavlib.EventFrame) {
+	if !c.rawMessages.hasSubscribers() {
+		return
+	}
+
+	msg := frm.Message()
+	raw := RawMessage{
+		ID:          msg.GetID(),
+		SystemID:    frm.SystemID(),
+		ComponentID: frm.ComponentID(),
+		Time:        time.Now(),
+	}
+
+	if undecoded, ok := msg.(*message.MessageRaw); ok {
+		raw.Payload = undecoded.Payload
+	} else {
+		raw.Name = messageName(msg)
+		fields, err := json.Marshal(msg)
+		if err != nil {
+			c.logger.Printf("MAVLink: Warning - failed to encode %s: %v", raw.Name, err)
+			return
+		}
+		raw.Fields = fields
+	}
+
+	c.rawMessages.publish(raw)
+}
@@ -0,0 +1,59 @@
+package mavlink
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/bluenviron/gomavlib/v3"
+	"github.com/bluenviron/gomavlib/v3/pkg/dialects/common"
+	"github.com/bluenviron/gomavlib/v3/pkg/frame"
+	"github.com/bluenviron/gomavlib/v3/pkg/message"
+)
+
+func rawFrame(msg message.Message, sysID, compID uint8) *gomavlib.EventFrame {
+	return &gomavlib.EventFrame{Frame: &frame.V2Frame{SystemID: sysID, ComponentID: compID, Message: msg}}
+}
+
+func TestRawMessagesReachSubscriber(t *testing.T) {
+	c := newTestClient()
+	messages, unsubscribe := c.SubscribeRawMessages()
+	defer unsubscribe()
+
+	c.publishRawMessage(rawFrame(&common.MessageAttitude{Roll: 0.5}, 1, 1))
+	// Frames from other systems too, and ones the dialect can't decode
+	c.publishRawMessage(rawFrame(&message.MessageRaw{ID: 60000, Payload: []byte{1, 2, 3}}, 51, 68))
+
+	msg := <-messages
+	if msg.Name != "ATTITUDE" || msg.ID != 30 || msg.SystemID != 1 || msg.ComponentID != 1 || msg.Time.IsZero() {
+		t.Errorf("decoded message = %+v", msg)
+	}
+	var fields struct{ Roll float32 }
+	if err := json.Unmarshal(msg.Fields, &fields); err != nil || fields.Roll != 0.5 {
+		t.Errorf("fields = %s: %v", msg.Fields, err)
+	}
+
+	msg = <-messages
+	if msg.Name != "" || msg.ID != 60000 || msg.SystemID != 51 || msg.ComponentID != 68 ||
+		string(msg.Payload) != "\x01\x02\x03" || msg.Fields != nil {
+		t.Errorf("undecoded message = %+v", msg)
+	}
+}
+
+func TestRawMessagesDroppedForSlowSubscriber(t *testing.T) {
+	c := newTestClient()
+	messages, unsubscribe := c.SubscribeRawMessages()
+	defer unsubscribe()
+
+	for range subscriberBuffer + 5 {
+		c.publishRawMessage(rawFrame(&common.MessageHeartbeat{}, 1, 1))
+	}
+	if got := c.RawMessagesDropped(); got != 5 {
+		t.Errorf("dropped = %d, want 5", got)
+	}
+	if len(messages) != subscriberBuffer {
+		t.Errorf("%d queued, want %d", len(messages), subscriberBuffer)
+	}
+	if got := c.GetConnectionInfo().RawMessagesDropped; got != 5 {
+		t.Errorf("connection info reports %d dropped", got)
+	}
+}
@@ -0,0 +1,141 @@
+package mavlink
+
+import (
+	"bytes"
+	"fmt"
+
+	"github.com/bluenviron/gomavlib/v3/pkg/dialects/common"
+)
+
+const (
+	// rtcmFragmentLen is the GPS_RTCM_DATA payload size
+	rtcmFragmentLen = 180
+
+	// rtcmMaxFragments is how many fragments the 2-bit fragment ID can number
+	rtcmMaxFragments = 4
+
+	// MaxRTCMMessageLen is the largest RTCM message InjectRTCM can send
+	MaxRTCMMessageLen = rtcmFragmentLen * rtcmMaxFragments
+)
+
+// InjectRTCM sends one RTCM message (e.g. an RTCM3 frame from an NTRIP caster)
+// to the vehicle's GPS as GPS_RTCM_DATA
+// Messages up to 180 bytes go in one unfragmented GPS_RTCM_DATA. Longer ones are
+// split into up to 4 fragments sharing a 5-bit sequence ID, which wraps after 31.
+func (c *Client) InjectRTCM(data []byte) error {
+	if len(data) == 0 {
+		return fmt.Errorf("RTCM message is empty")
+	}
+	if len(data) > MaxRTCMMessageLen {
+		return fmt.Errorf("RTCM message must be at most %d bytes: %d", MaxRTCMMessageLen, len(data))
+	}
+	if !c.IsConnected() {
+		return fmt.Errorf("not connected to drone")
+	}
+
+	c.mu.Lock()
+	sequence := c.rtcmSequence
+	c.rtcmSequence = (c.rtcmSequence + 1) & 0x1F
+	c.mu.Unlock()
+
+	fragments := rtcmFragments(data, sequence)
+	for i, fragment := range fragments {
+		if err := c.writeMessage(fragment); err != nil {
+			return fmt.Errorf("failed to send GPS_RTCM_DATA fragment %d/%d: %w", i+1, len(fragments), err)
+		}
+	}
+	return nil
+}
+
+// rtcmFragments splits an RTCM message into GPS_RTCM_DATA messages
+// Flags: bit 0 fragmented, bits 1-2 fragment ID, bits 3-7 sequence ID. The
+// autopilot treats a short fragment as the last one, so a message filling its
+// last fragment exactly (and fewer than 4) is followed by an empty fragment.
+func rtcmFragments(data []byte, sequence uint8) []*common.MessageGpsRtcmData {
+	sequenceBits := (sequence & 0x1F) << 3
+
+	if len(data) <= rtcmFragmentLen {
+		msg := &common.MessageGpsRtcmData{Flags: sequenceBits, Len: uint8(len(data))}
+		copy(msg.Data[:], data)
+		return []*common.MessageGpsRtcmData{msg}
+	}
+
+	var fragments []*common.MessageGpsRtcmData
+	for id := 0; len(data) > 0; id++ {
+		n := min(len(data), rtcmFragmentLen)
+		msg := &common.MessageGpsRtcmData{
+			Flags: 1 | uint8(id)<<1 | sequenceBits,
+			Len:   uint8(n),
+		}
+		copy(msg.Data[:], data[:n])
+		fragments = append(fragments, msg)
+		data = data[n:]
+	}
+
+	if last := fragments[len(fragments)-1]; last.Len == rtcmFragmentLen && len(fragments) < rtcmMaxFragments {
+		fragments = append(fragments, &common.MessageGpsRtcmData{
+			Flags: 1 | uint8(len(fragments))<<1 | sequenceBits,
+		})
+	}
+	return fragments
+}
+
+// RTCM3 framing: preamble, 6 reserved bits and a 10-bit payload length, payload, CRC-24Q
+const (
+	rtcm3Preamble  = 0xD3
+	rtcm3HeaderLen = 3
+	rtcm3CRCLen    = 3
+)
+
+// ScanRTCM3Frames is a bufio.SplitFunc that yields complete RTCM3 frames from a
+// raw correction stream (e.g. an NTRIP response body)
+// Bytes before a preamble and frames failing their CRC are skipped, so the
+// scanner resynchronizes after corruption.
+func ScanRTCM3Frames(data []byte, atEOF bool) (advance int, token []byte, err error) {
+	for {
+		start := bytes.IndexByte(data[advance:], rtcm3Preamble)
+		if start < 0 {
+			return len(data), nil, nil
+		}
+		advance += start
+		frame := data[advance:]
+
+		if len(frame) < rtcm3HeaderLen && !atEOF {
+			return advance, nil, nil
+		}
+
+		// Reserved bits must be zero; otherwise the preamble byte was payload
+		if len(frame) >= rtcm3HeaderLen && frame[1]&0xFC == 0 {
+			payloadLen := int(frame[1])<<8 | int(frame[2])
+			frameLen := rtcm3HeaderLen + payloadLen + rtcm3CRCLen
+			if len(frame) < frameLen && !atEOF {
+				return advance, nil, nil
+			}
+			if len(frame) >= frameLen {
+				crc := uint32(frame[frameLen-3])<<16 | uint32(frame[frameLen-2])<<8 | uint32(frame[frameLen-1])
+				if crc24q(frame[:frameLen-rtcm3CRCLen]) == crc {
+					return advance + frameLen, frame[:frameLen], nil
+				}
+			}
+		}
+
+		// Not a frame (or truncated at the end of the stream): resume the
+		// search after this preamble byte
+		advance++
+	}
+}
+
+// crc24q computes the Qualcomm CRC-24 used by RTCM3
+func crc24q(data []byte) uint32 {
+	var crc uint32
+	for _, b := range data {
+		crc ^= uint32(b) << 16
+		for i := 0; i < 8; i++ {
+			crc <<= 1
+			if crc&0x1000000 != 0 {
+				crc ^= 0x1864CFB
+			}
+		}
+	}
+	return crc & 0xFFFFFF
+}
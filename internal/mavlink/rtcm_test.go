@@ -0,0 +1,80 @@
+package mavlink
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/bluenviron/gomavlib/v3/pkg/dialects/common"
+)
+
+func TestRTCMFragments(t *testing.T) {
+	tests := []struct {
+		name string
+		len  int
+		lens []uint8 // Len of each GPS_RTCM_DATA
+	}{
+		{"short", 1, []uint8{1}},
+		{"exactly one fragment", 180, []uint8{180}},
+		{"two fragments", 181, []uint8{180, 1}},
+		{"full last fragment gets an empty one", 360, []uint8{180, 180, 0}},
+		{"three fragments", 500, []uint8{180, 180, 140}},
+		{"four full fragments", MaxRTCMMessageLen, []uint8{180, 180, 180, 180}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			data := make([]byte, tt.len)
+			for i := range data {
+				data[i] = byte(i)
+			}
+
+			fragments := rtcmFragments(data, 21)
+			if len(fragments) != len(tt.lens) {
+				t.Fatalf("got %d fragments, want %d", len(fragments), len(tt.lens))
+			}
+
+			var reassembled []byte
+			fragmented := len(fragments) > 1
+			for i, fragment := range fragments {
+				if fragment.Len != tt.lens[i] {
+					t.Errorf("fragment %d: len = %d, want %d", i, fragment.Len, tt.lens[i])
+				}
+				if got := fragment.Flags&1 != 0; got != fragmented {
+					t.Errorf("fragment %d: fragmented flag = %v, want %v", i, got, fragmented)
+				}
+				if fragmented {
+					if id := int(fragment.Flags>>1) & 0x03; id != i {
+						t.Errorf("fragment %d: fragment ID = %d", i, id)
+					}
+				}
+				if seq := fragment.Flags >> 3; seq != 21 {
+					t.Errorf("fragment %d: sequence ID = %d, want 21", i, seq)
+				}
+				reassembled = append(reassembled, fragment.Data[:fragment.Len]...)
+			}
+			if !bytes.Equal(reassembled, data) {
+				t.Error("fragments don't reassemble to the message")
+			}
+		})
+	}
+}
+
+func TestInjectRTCMSequence(t *testing.T) {
+	c, vehicle := newLinkedTestClient(t)
+
+	// The 5-bit sequence ID counts messages, not fragments, and wraps after 31
+	for i := 0; i < 34; i++ {
+		if err := c.InjectRTCM(make([]byte, 200)); err != nil {
+			t.Fatalf("InjectRTCM %d: %v", i, err)
+		}
+
+		for fragment := 0; fragment < 2; fragment++ {
+			msg := receive[*common.MessageGpsRtcmData](t, vehicle)
+			if seq, want := int(msg.Flags>>3), i%32; seq != want {
+				t.Fatalf("message %d: sequence ID = %d, want %d", i, seq, want)
+			}
+			if id := int(msg.Flags>>1) & 0x03; id != fragment {
+				t.Fatalf("message %d: fragment ID = %d, want %d", i, id, fragment)
+			}
+		}
+	}
+}
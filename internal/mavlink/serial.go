@@ -0,0 +1,158 @@
+package mavlink
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net"
+	"time"
+
+	"github.com/bluenviron/gomavlib/v3"
+	"go.bug.st/serial"
+)
+
+// Serial parity settings
+const (
+	ParityNone  = "none"
+	ParityOdd   = "odd"
+	ParityEven  = "even"
+	ParityMark  = "mark"
+	ParitySpace = "space"
+)
+
+// Serial flow control settings
+const (
+	FlowControlNone   = "none"
+	FlowControlRTSCTS = "rtscts"
+)
+
+// SerialConfig holds serial line settings beyond device and baud rate
+// The zero value is 8N1 without flow control, matching gomavlib's serial endpoint.
+type SerialConfig struct {
+	DataBits    int    // 5-8 (0 = 8)
+	Parity      string // ParityNone (default), ParityOdd, ParityEven, ParityMark, ParitySpace
+	StopBits    int    // 1 (default) or 2
+	FlowControl string // FlowControlNone (default) or FlowControlRTSCTS
+}
+
+// withDefaults fills in unset fields
+func (s SerialConfig) withDefaults() SerialConfig {
+	if s.DataBits == 0 {
+		s.DataBits = 8
+	}
+	if s.Parity == "" {
+		s.Parity = ParityNone
+	}
+	if s.StopBits == 0 {
+		s.StopBits = 1
+	}
+	if s.FlowControl == "" {
+		s.FlowControl = FlowControlNone
+	}
+	return s
+}
+
+// isDefault returns true for 8N1 without flow control
+func (s SerialConfig) isDefault() bool {
+	return s.withDefaults() == SerialConfig{}.withDefaults()
+}
+
+// Validate checks the serial settings
+func (s SerialConfig) Validate() error {
+	s = s.withDefaults()
+
+	if s.DataBits < 5 || s.DataBits > 8 {
+		return fmt.Errorf("invalid data bits: %d (must be 5-8)", s.DataBits)
+	}
+
+	switch s.Parity {
+	case ParityNone, ParityOdd, ParityEven, ParityMark, ParitySpace:
+	default:
+		return fmt.Errorf("invalid parity: %q (must be none, odd, even, mark or space)", s.Parity)
+	}
+
+	if s.StopBits != 1 && s.StopBits != 2 {
+		return fmt.Errorf("invalid stop bits: %d (must be 1 or 2)", s.StopBits)
+	}
+
+	switch s.FlowControl {
+	case FlowControlNone, FlowControlRTSCTS:
+	default:
+		return fmt.Errorf("invalid flow control: %q (must be none or rtscts)", s.FlowControl)
+	}
+
+	return nil
+}
+
+// serialMode converts the settings to go.bug.st/serial's mode
+func (s SerialConfig) serialMode(baudRate int) *serial.Mode {
+	s = s.withDefaults()
+
+	parity := map[string]serial.Parity{
+		ParityNone:  serial.NoParity,
+		ParityOdd:   serial.OddParity,
+		ParityEven:  serial.EvenParity,
+		ParityMark:  serial.MarkParity,
+		ParitySpace: serial.SpaceParity,
+	}[s.Parity]
+
+	stopBits := serial.OneStopBit
+	if s.StopBits == 2 {
+		stopBits = serial.TwoStopBits
+	}
+
+	return &serial.Mode{
+		BaudRate: baudRate,
+		DataBits: s.DataBits,
+		Parity:   parity,
+		StopBits: stopBits,
+	}
+}
+
+// serialEndpoint returns the gomavlib endpoint for a serial device
+// Default settings use gomavlib's own serial endpoint; anything else opens
+// the port here, since gomavlib only configures device and baud rate.
+func serialEndpoint(device string, baudRate int, settings SerialConfig) gomavlib.EndpointConf {
+	if settings.isDefault() {
+		return gomavlib.EndpointSerial{
+			Device: device,
+			Baud:   baudRate,
+		}
+	}
+
+	settings = settings.withDefaults()
+
+	return gomavlib.EndpointCustomClient{
+		Label: "serial",
+		Connect: func(_ context.Context) (net.Conn, error) {
+			port, err := serial.Open(device, settings.serialMode(baudRate))
+			if err != nil {
+				return nil, err
+			}
+
+			if settings.FlowControl == FlowControlRTSCTS {
+				if err := enableRTSCTS(device); err != nil {
+					port.Close()
+					return nil, err
+				}
+			}
+
+			// Same modem lines gomavlib's serial endpoint raises
+			port.SetDTR(true) //nolint:errcheck
+			port.SetRTS(true) //nolint:errcheck
+
+			return &serialConn{ReadWriteCloser: port}, nil
+		},
+	}
+}
+
+// serialConn adapts a serial port to net.Conn for gomavlib
+type serialConn struct {
+	io.ReadWriteCloser
+}
+
+func (*serialConn) LocalAddr() net.Addr                { return nil }
+func (*serialConn) RemoteAddr() net.Addr               { return nil }
+func (*serialConn) SetDeadline(_ time.Time) error      { return nil }
+func (*serialConn) SetReadDeadline(_ time.Time) error  { return nil }
+func (*serialConn) SetWriteDeadline(_ time.Time) error { return nil }
@@ -0,0 +1,31 @@
+package mavlink
+
+import (
+	"fmt"
+
+	"golang.org/x/sys/unix"
+)
+
+// enableRTSCTS turns on hardware flow control for a serial device
+// go.bug.st/serial always opens ports with flow control off. Termios settings
+// belong to the device rather than the file descriptor, so setting CRTSCTS
+// through a second descriptor applies to the open port.
+func enableRTSCTS(device string) error {
+	fd, err := unix.Open(device, unix.O_RDWR|unix.O_NOCTTY|unix.O_NONBLOCK|unix.O_CLOEXEC, 0)
+	if err != nil {
+		return fmt.Errorf("failed to open %s for flow control: %w", device, err)
+	}
+	defer unix.Close(fd)
+
+	termios, err := unix.IoctlGetTermios(fd, unix.TCGETS)
+	if err != nil {
+		return fmt.Errorf("failed to read serial settings: %w", err)
+	}
+
+	termios.Cflag |= unix.CRTSCTS
+
+	if err := unix.IoctlSetTermios(fd, unix.TCSETS, termios); err != nil {
+		return fmt.Errorf("failed to enable RTS/CTS flow control: %w", err)
+	}
+	return nil
+}
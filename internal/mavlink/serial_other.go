@@ -0,0 +1,10 @@
+//go:build !linux
+
+package mavlink
+
+import "fmt"
+
+// enableRTSCTS is only implemented on Linux
+func enableRTSCTS(device string) error {
+	return fmt.Errorf("RTS/CTS flow control is not supported on this platform")
+}
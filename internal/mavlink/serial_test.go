@@ -0,0 +1,67 @@
+package mavlink
+
+import (
+	"testing"
+
+	"github.com/bluenviron/gomavlib/v3"
+	"go.bug.st/serial"
+)
+
+func TestSerialSettingsReachEndpoint(t *testing.T) {
+	// 8N1 without flow control stays on gomavlib's own endpoint
+	for _, settings := range []SerialConfig{{}, {DataBits: 8, Parity: ParityNone, StopBits: 1, FlowControl: FlowControlNone}} {
+		conf, ok := serialEndpoint("/dev/ttyUSB0", 57600, settings).(gomavlib.EndpointSerial)
+		if !ok || conf.Device != "/dev/ttyUSB0" || conf.Baud != 57600 {
+			t.Errorf("%+v: endpoint = %#v", settings, conf)
+		}
+	}
+
+	tests := []struct {
+		settings SerialConfig
+		want     serial.Mode
+	}{
+		{SerialConfig{Parity: ParityEven}, serial.Mode{BaudRate: 57600, DataBits: 8, Parity: serial.EvenParity, StopBits: serial.OneStopBit}},
+		{SerialConfig{DataBits: 7, Parity: ParityOdd, StopBits: 2}, serial.Mode{BaudRate: 57600, DataBits: 7, Parity: serial.OddParity, StopBits: serial.TwoStopBits}},
+		{SerialConfig{Parity: ParityMark}, serial.Mode{BaudRate: 57600, DataBits: 8, Parity: serial.MarkParity, StopBits: serial.OneStopBit}},
+		{SerialConfig{Parity: ParitySpace}, serial.Mode{BaudRate: 57600, DataBits: 8, Parity: serial.SpaceParity, StopBits: serial.OneStopBit}},
+		{SerialConfig{FlowControl: FlowControlRTSCTS}, serial.Mode{BaudRate: 57600, DataBits: 8, Parity: serial.NoParity, StopBits: serial.OneStopBit}},
+	}
+	for _, tt := range tests {
+		if _, ok := serialEndpoint("/dev/ttyUSB0", 57600, tt.settings).(gomavlib.EndpointCustomClient); !ok {
+			t.Errorf("%+v: not opened by the client", tt.settings)
+		}
+		if got := *tt.settings.serialMode(57600); got != tt.want {
+			t.Errorf("%+v: mode = %+v, want %+v", tt.settings, got, tt.want)
+		}
+	}
+}
+
+func TestSerialConfigValidate(t *testing.T) {
+	valid := []SerialConfig{
+		{},
+		{DataBits: 5, Parity: ParitySpace, StopBits: 2, FlowControl: FlowControlRTSCTS},
+	}
+	for _, settings := range valid {
+		if err := settings.Validate(); err != nil {
+			t.Errorf("%+v: %v", settings, err)
+		}
+	}
+
+	invalid := []SerialConfig{
+		{DataBits: 4},
+		{DataBits: 9},
+		{Parity: "E"},
+		{StopBits: 3},
+		{FlowControl: "xonxoff"},
+	}
+	for _, settings := range invalid {
+		if err := settings.Validate(); err == nil {
+			t.Errorf("%+v accepted", settings)
+		}
+	}
+
+	// Checked before the port is touched
+	if _, err := NewClient(Config{Port: "/dev/null", BaudRate: 57600, Serial: SerialConfig{Parity: "E"}}); err == nil {
+		t.Error("NewClient accepted invalid serial settings")
+	}
+}
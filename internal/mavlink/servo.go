@@ -0,0 +1,94 @@
+package mavlink
+
+import (
+	"fmt"
+	"math"
+
+	"github.com/bluenviron/gomavlib/v3/pkg/dialects/common"
+)
+
+// Servo outputs and pulse widths SetServo accepts
+const (
+	MaxServoChannel = 16 // SERVO1..SERVO16, as SERVO_OUTPUT_RAW reports them
+	MinServoPWM     = 1000
+	MaxServoPWM     = 2000
+
+	// Bounds on RepeatServo's cycle count and cycle time (s)
+	MaxServoRepeats   = 100
+	MinServoCycleTime = 0.1
+	MaxServoCycleTime = 60
+)
+
+// ValidateServo checks a servo output channel (1-MaxServoChannel) and pulse
+// width (MinServoPWM-MaxServoPWM us)
+func ValidateServo(channel, pwm int) error {
+	if channel < 1 || channel > MaxServoChannel {
+		return fmt.Errorf("servo channel must be 1-%d: %d", MaxServoChannel, channel)
+	}
+	if pwm < MinServoPWM || pwm > MaxServoPWM {
+		return fmt.Errorf("servo PWM must be %d-%d us: %d", MinServoPWM, MaxServoPWM, pwm)
+	}
+	return nil
+}
+
+// ValidateServoRepeat checks a repeated actuation: the channel and PWM as for
+// ValidateServo, 1-MaxServoRepeats cycles, and a cycle time of
+// MinServoCycleTime-MaxServoCycleTime seconds
+func ValidateServoRepeat(channel, pwm, count int, cycleTime float64) error {
+	if err := ValidateServo(channel, pwm); err != nil {
+		return err
+	}
+	if count < 1 || count > MaxServoRepeats {
+		return fmt.Errorf("servo repeat count must be 1-%d: %d", MaxServoRepeats, count)
+	}
+	if math.IsNaN(cycleTime) || cycleTime < MinServoCycleTime || cycleTime > MaxServoCycleTime {
+		return fmt.Errorf("servo cycle time must be %v-%v s: %v", MinServoCycleTime, MaxServoCycleTime, cycleTime)
+	}
+	return nil
+}
+
+// servoParams encodes MAV_CMD_DO_SET_SERVO: Param1 = output channel,
+// Param2 = PWM (us)
+func servoParams(channel, pwm int) [7]float32 {
+	return [7]float32{float32(channel), float32(pwm)}
+}
+
+// SetServo sets a servo output to a pulse width with MAV_CMD_DO_SET_SERVO,
+// for payloads on an aux output (release mechanisms, sprayers)
+// The output must be configured as a passthrough/servo function on the
+// vehicle (e.g. ArduPilot SERVOn_FUNCTION 0, PX4 an actuator set to a
+// peripheral), or the autopilot rejects or overrides it.
+func (c *Client) SetServo(channel, pwm int) error {
+	if err := ValidateServo(channel, pwm); err != nil {
+		return err
+	}
+	if !c.IsConnected() {
+		return fmt.Errorf("not connected to drone")
+	}
+
+	c.logger.Printf("MAVLink: Sending DO_SET_SERVO: channel=%d, pwm=%d", channel, pwm)
+	return c.sendCommandLong(common.MAV_CMD_DO_SET_SERVO, servoParams(channel, pwm))
+}
+
+// servoRepeatParams encodes MAV_CMD_DO_REPEAT_SERVO: Param1 = output channel,
+// Param2 = PWM (us), Param3 = cycle count, Param4 = cycle time (s)
+func servoRepeatParams(channel, pwm, count int, cycleTime float64) [7]float32 {
+	return [7]float32{float32(channel), float32(pwm), float32(count), float32(cycleTime)}
+}
+
+// RepeatServo cycles a servo output between a pulse width and its trim count
+// times with MAV_CMD_DO_REPEAT_SERVO, e.g. to drop a sequence of payloads
+// Each cycle lasts cycleTime seconds, half at pwm; the vehicle runs the cycles
+// itself once it has acknowledged the command.
+func (c *Client) RepeatServo(channel, pwm, count int, cycleTime float64) error {
+	if err := ValidateServoRepeat(channel, pwm, count, cycleTime); err != nil {
+		return err
+	}
+	if !c.IsConnected() {
+		return fmt.Errorf("not connected to drone")
+	}
+
+	c.logger.Printf("MAVLink: Sending DO_REPEAT_SERVO: channel=%d, pwm=%d, count=%d, cycle=%.2fs",
+		channel, pwm, count, cycleTime)
+	return c.sendCommandLong(common.MAV_CMD_DO_REPEAT_SERVO, servoRepeatParams(channel, pwm, count, cycleTime))
+}
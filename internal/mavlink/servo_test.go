@@ -0,0 +1,98 @@
+package mavlink
+
+import (
+	"math"
+	"testing"
+
+	"github.com/bluenviron/gomavlib/v3/pkg/dialects/common"
+)
+
+func TestValidateServo(t *testing.T) {
+	tests := []struct {
+		channel, pwm int
+		ok           bool
+	}{
+		{1, 1000, true},
+		{16, 2000, true},
+		{9, 1500, true},
+		{0, 1500, false},
+		{17, 1500, false},
+		{9, 999, false},
+		{9, 2001, false},
+	}
+	for _, tt := range tests {
+		if err := ValidateServo(tt.channel, tt.pwm); (err == nil) != tt.ok {
+			t.Errorf("ValidateServo(%d, %d) = %v, want ok %v", tt.channel, tt.pwm, err, tt.ok)
+		}
+	}
+}
+
+func TestSetServo(t *testing.T) {
+	c, vehicle := newLinkedTestClient(t)
+
+	// Out of range: refused before anything is sent
+	if err := c.SetServo(17, 1500); err == nil {
+		t.Error("channel 17 accepted")
+	}
+	if err := c.SetServo(9, 2500); err == nil {
+		t.Error("2500 us accepted")
+	}
+
+	result := make(chan error, 1)
+	go func() { result <- c.SetServo(9, 1900) }()
+	expectCommand(t, c, vehicle, common.MAV_CMD_DO_SET_SERVO, 9, 1900, common.MAV_RESULT_ACCEPTED)
+	if err := <-result; err != nil {
+		t.Fatalf("accepted servo command: %v", err)
+	}
+
+	go func() { result <- c.SetServo(10, 1100) }()
+	expectCommand(t, c, vehicle, common.MAV_CMD_DO_SET_SERVO, 10, 1100, common.MAV_RESULT_DENIED)
+	if err := <-result; err == nil {
+		t.Error("denied servo command reported as success")
+	}
+}
+
+func TestValidateServoRepeat(t *testing.T) {
+	tests := []struct {
+		name      string
+		channel   int
+		pwm       int
+		count     int
+		cycleTime float64
+		ok        bool
+	}{
+		{"bounds low", 1, 1000, 1, 0.1, true},
+		{"bounds high", 16, 2000, 100, 60, true},
+		{"bad channel", 0, 1500, 3, 1, false},
+		{"bad pwm", 9, 900, 3, 1, false},
+		{"no cycles", 9, 1500, 0, 1, false},
+		{"too many cycles", 9, 1500, 101, 1, false},
+		{"cycle too short", 9, 1500, 3, 0.05, false},
+		{"cycle too long", 9, 1500, 3, 61, false},
+		{"cycle NaN", 9, 1500, 3, math.NaN(), false},
+	}
+	for _, tt := range tests {
+		if err := ValidateServoRepeat(tt.channel, tt.pwm, tt.count, tt.cycleTime); (err == nil) != tt.ok {
+			t.Errorf("%s: ValidateServoRepeat = %v, want ok %v", tt.name, err, tt.ok)
+		}
+	}
+}
+
+func TestRepeatServo(t *testing.T) {
+	c, vehicle := newLinkedTestClient(t)
+	if err := c.RepeatServo(9, 1900, 0, 1); err == nil {
+		t.Error("zero cycles accepted")
+	}
+
+	result := make(chan error, 1)
+	go func() { result <- c.RepeatServo(9, 1900, 4, 1.5) }()
+	msg := receive[*common.MessageCommandLong](t, vehicle)
+	if msg.Command != common.MAV_CMD_DO_REPEAT_SERVO ||
+		msg.Param1 != 9 || msg.Param2 != 1900 || msg.Param3 != 4 || msg.Param4 != 1.5 {
+		t.Fatalf("sent %s (%v, %v, %v, %v)", msg.Command, msg.Param1, msg.Param2, msg.Param3, msg.Param4)
+	}
+	ack(c, msg.Command, common.MAV_RESULT_ACCEPTED)
+	if err := <-result; err != nil {
+		t.Errorf("accepted repeat: %v", err)
+	}
+}
@@ -0,0 +1,108 @@
+package mavlink
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/bluenviron/gomavlib/v3/pkg/dialects/common"
+)
+
+const (
+	// statusTextChunkLen is the size of STATUSTEXT's text field
+	statusTextChunkLen = 50
+
+	// maxStatusTextLen fits the 256 chunks ChunkSeq can number
+	maxStatusTextLen = 256 * statusTextChunkLen
+)
+
+// StatusText is a STATUSTEXT message received from the vehicle
+type StatusText struct {
+	Severity common.MAV_SEVERITY
+	Text     string
+	Time     time.Time
+}
+
+// SubscribeStatusText returns a channel receiving every STATUSTEXT from the vehicle
+// Call the returned function to unsubscribe; the channel is closed afterwards.
+func (c *Client) SubscribeStatusText() (<-chan StatusText, func()) {
+	return c.statusTexts.subscribe()
+}
+
+// handleStatusText processes STATUSTEXT messages
+func (c *Client) handleStatusText(msg *common.MessageStatustext) {
+	c.logger.Printf("MAVLink STATUS: [%d] %s", msg.Severity, msg.Text)
+
+	c.statusTexts.publish(StatusText{
+		Severity: msg.Severity,
+		Text:     msg.Text,
+		Time:     time.Now(),
+	})
+
+	c.handleFailsafeText(msg.Text)
+}
+
+// SendStatusText sends a text to the vehicle as STATUSTEXT (e.g. to annotate its flight log)
+// Text longer than one message is split into 50-byte chunks sharing a non-zero Id,
+// numbered from ChunkSeq 0. A text that fills its last chunk exactly is followed
+// by an empty chunk, since receivers only see the end at a null character.
+// Severity is a MAV_SEVERITY (0 emergency - 7 debug).
+func (c *Client) SendStatusText(severity int, text string) error {
+	if severity < int(common.MAV_SEVERITY_EMERGENCY) || severity > int(common.MAV_SEVERITY_DEBUG) {
+		return fmt.Errorf("severity must be 0-7: %d", severity)
+	}
+	if text == "" {
+		return fmt.Errorf("text is required")
+	}
+	if len(text) > maxStatusTextLen {
+		return fmt.Errorf("text must be at most %d bytes: %d", maxStatusTextLen, len(text))
+	}
+	if !c.IsConnected() {
+		return fmt.Errorf("not connected to drone")
+	}
+
+	chunks := statusTextChunks(text)
+
+	var id uint16
+	if len(chunks) > 1 {
+		c.mu.Lock()
+		c.statusTextID++
+		if c.statusTextID == 0 {
+			c.statusTextID = 1 // 0 means single-chunk
+		}
+		id = c.statusTextID
+		c.mu.Unlock()
+	}
+
+	c.logger.Printf("MAVLink: Sending STATUSTEXT: severity=%d, %d chunk(s): %s", severity, len(chunks), text)
+
+	for seq, chunk := range chunks {
+		err := c.writeMessage(&common.MessageStatustext{
+			Severity: common.MAV_SEVERITY(severity),
+			Text:     chunk,
+			Id:       id,
+			ChunkSeq: uint8(seq),
+		})
+		if err != nil {
+			return fmt.Errorf("failed to send STATUSTEXT chunk %d/%d: %w", seq+1, len(chunks), err)
+		}
+	}
+	return nil
+}
+
+// statusTextChunks splits text into STATUSTEXT-sized chunks
+// Splits on bytes, so a multi-byte character may span two chunks; concatenating
+// the chunks restores it.
+func statusTextChunks(text string) []string {
+	var chunks []string
+	for len(text) > statusTextChunkLen {
+		chunks = append(chunks, text[:statusTextChunkLen])
+		text = text[statusTextChunkLen:]
+	}
+	chunks = append(chunks, text)
+
+	// A full last chunk carries no null terminator
+	if len(chunks) > 1 && len(text) == statusTextChunkLen {
+		chunks = append(chunks, "")
+	}
+	return chunks
+}
@@ -0,0 +1,69 @@
+package mavlink
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/bluenviron/gomavlib/v3/pkg/dialects/common"
+)
+
+func TestStatusTextChunks(t *testing.T) {
+	tests := []struct {
+		name string
+		text string
+		lens []int // length of each chunk
+	}{
+		{"short", "Survey started", []int{14}},
+		{"exactly one chunk", strings.Repeat("a", 50), []int{50}},
+		{"two chunks", strings.Repeat("a", 51), []int{50, 1}},
+		{"full last chunk gets an empty one", strings.Repeat("a", 100), []int{50, 50, 0}},
+		{"multi-byte character across chunks", strings.Repeat("a", 49) + "ü", []int{50, 1}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			chunks := statusTextChunks(tt.text)
+			if len(chunks) != len(tt.lens) {
+				t.Fatalf("got %d chunks, want %d", len(chunks), len(tt.lens))
+			}
+			for i, chunk := range chunks {
+				if len(chunk) != tt.lens[i] {
+					t.Errorf("chunk %d: %d bytes, want %d", i, len(chunk), tt.lens[i])
+				}
+			}
+			if got := strings.Join(chunks, ""); got != tt.text {
+				t.Errorf("chunks join to %q", got)
+			}
+		})
+	}
+}
+
+func TestSendStatusTextChunkIDs(t *testing.T) {
+	c, vehicle := newLinkedTestClient(t)
+
+	if err := c.SendStatusText(int(common.MAV_SEVERITY_INFO), "single chunk"); err != nil {
+		t.Fatal(err)
+	}
+	if msg := receive[*common.MessageStatustext](t, vehicle); msg.Id != 0 || msg.ChunkSeq != 0 {
+		t.Errorf("single chunk: id=%d seq=%d, want 0, 0", msg.Id, msg.ChunkSeq)
+	}
+
+	// Chunked texts get the next non-zero ID, skipping 0 when it wraps
+	c.statusTextID = 0xFFFE
+	text := strings.Repeat("x", 120)
+	for _, wantID := range []uint16{0xFFFF, 1} {
+		if err := c.SendStatusText(int(common.MAV_SEVERITY_INFO), text); err != nil {
+			t.Fatal(err)
+		}
+		var received string
+		for seq := 0; seq < 3; seq++ {
+			msg := receive[*common.MessageStatustext](t, vehicle)
+			if msg.Id != wantID || int(msg.ChunkSeq) != seq {
+				t.Errorf("chunk %d: id=%d seq=%d, want id %d", seq, msg.Id, msg.ChunkSeq, wantID)
+			}
+			received += msg.Text
+		}
+		if received != text {
+			t.Errorf("vehicle received %q", received)
+		}
+	}
+}
@@ -0,0 +1,210 @@
+package mavlink
+
+import (
+	"errors"
+	"math"
+	"math/bits"
+	"time"
+
+	"github.com/bluenviron/gomavlib/v3/pkg/dialects/common"
+)
+
+const (
+	// terrainMaskAll covers the 56 blocks of a TERRAIN_REQUEST (8 east by 7 north)
+	terrainMaskAll = 1<<56 - 1
+
+	// terrainBlocksPerRow is how many 4x4 blocks each row of the request
+	// grid has; gridbit / 8 is the block's row (north), gridbit % 8 its column (east)
+	terrainBlocksPerRow = 8
+
+	// terrainBlocksPerRequest caps the TERRAIN_DATA sent per TERRAIN_REQUEST so
+	// a slow link isn't flooded; the vehicle repeats the request for the rest
+	terrainBlocksPerRequest = 14
+
+	// terrainMetersPerUnit is the meters per 1e-7 degree of latitude
+	// ArduPilot positions its terrain grid with, so our points match its own
+	terrainMetersPerUnit = 0.011131884502145034
+)
+
+// TerrainSource provides terrain heights for the vehicle's TERRAIN_REQUESTs
+type TerrainSource interface {
+	// Elevation returns the terrain height at a point in meters AMSL
+	Elevation(lat, lon float64) (float64, error)
+}
+
+// ErrNoTerrainData is what a TerrainSource returns (wrapped or not) for
+// points it has no data for; such blocks are skipped without logging
+var ErrNoTerrainData = errors.New("no terrain data")
+
+// TerrainStatus reports how terrain serving is going and the vehicle's own
+// view of its terrain data
+type TerrainStatus struct {
+	Enabled bool `json:"enabled"` // a terrain source is configured
+
+	Requests      int   `json:"requests"`       // TERRAIN_REQUESTs received
+	BlocksSent    int   `json:"blocks_sent"`    // 4x4 blocks answered with TERRAIN_DATA
+	BlocksMissing int   `json:"blocks_missing"` // requested blocks the source had no data for (repeats counted)
+	Outstanding   int   `json:"outstanding"`    // blocks of the latest request not yet answered
+	LastRequestMs int64 `json:"last_request_ms,omitempty"`
+
+	// Last TERRAIN_REPORT from the vehicle (nil until one arrives)
+	Report *TerrainReport `json:"report,omitempty"`
+}
+
+// TerrainReport is the vehicle's TERRAIN_REPORT
+type TerrainReport struct {
+	Latitude      float64 `json:"latitude"`
+	Longitude     float64 `json:"longitude"`
+	Spacing       int     `json:"spacing"`        // grid spacing in meters; 0: no terrain here
+	TerrainHeight float64 `json:"terrain_height"` // terrain below the vehicle, meters AMSL
+	CurrentHeight float64 `json:"current_height"` // vehicle height above that terrain
+	Pending       int     `json:"pending"`        // blocks the vehicle is still waiting for
+	Loaded        int     `json:"loaded"`         // blocks in the vehicle's memory
+	UpdatedMs     int64   `json:"updated_ms"`
+
+	// Terrain is available at the vehicle's position and nothing is pending
+	Healthy bool `json:"healthy"`
+}
+
+// terrainState tracks terrain serving, guarded by c.mu
+type terrainState struct {
+	requests    int
+	sent        int
+	missing     int
+	outstanding int
+	lastRequest time.Time
+	answering   bool // a request is being answered; others are dropped meanwhile
+	report      *TerrainReport
+}
+
+// TerrainStatus returns terrain serving statistics and the vehicle's last
+// TERRAIN_REPORT
+func (c *Client) TerrainStatus() TerrainStatus {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	status := TerrainStatus{
+		Enabled:       c.terrainSource != nil,
+		Requests:      c.terrain.requests,
+		BlocksSent:    c.terrain.sent,
+		BlocksMissing: c.terrain.missing,
+		Outstanding:   c.terrain.outstanding,
+	}
+	if !c.terrain.lastRequest.IsZero() {
+		status.LastRequestMs = c.terrain.lastRequest.UnixMilli()
+	}
+	if c.terrain.report != nil {
+		report := *c.terrain.report
+		status.Report = &report
+	}
+	return status
+}
+
+// handleTerrainRequest answers a TERRAIN_REQUEST in the background
+// Without a terrain source (or in passive mode) requests are only counted.
+func (c *Client) handleTerrainRequest(msg *common.MessageTerrainRequest) {
+	c.mu.Lock()
+	c.terrain.requests++
+	c.terrain.lastRequest = time.Now()
+	if c.terrainSource == nil || c.passive || c.terrain.answering {
+		// The vehicle repeats the request until every block arrives
+		c.mu.Unlock()
+		return
+	}
+	c.terrain.answering = true
+	c.mu.Unlock()
+
+	go c.answerTerrainRequest(*msg)
+}
+
+// answerTerrainRequest sends TERRAIN_DATA for up to terrainBlocksPerRequest
+// requested blocks, skipping those the source has no data for
+func (c *Client) answerTerrainRequest(req common.MessageTerrainRequest) {
+	mask := req.Mask & terrainMaskAll
+	sent, missing := 0, 0
+
+	for mask != 0 && sent+missing < terrainBlocksPerRequest {
+		bit := uint8(bits.TrailingZeros64(mask))
+		mask &^= 1 << bit
+
+		data, err := terrainBlock(c.terrainSource, req, bit)
+		if err != nil {
+			if !errors.Is(err, ErrNoTerrainData) {
+				c.logger.Printf("MAVLink: Terrain block %d unavailable: %v", bit, err)
+			}
+			missing++
+			continue
+		}
+
+		err = c.writeMessage(&common.MessageTerrainData{
+			Lat:         req.Lat,
+			Lon:         req.Lon,
+			GridSpacing: req.GridSpacing,
+			Gridbit:     bit,
+			Data:        data,
+		})
+		if err != nil {
+			c.logger.Printf("MAVLink: Error sending terrain data: %v", err)
+			mask |= 1 << bit
+			break
+		}
+		sent++
+	}
+
+	c.mu.Lock()
+	c.terrain.sent += sent
+	c.terrain.missing += missing
+	c.terrain.outstanding = bits.OnesCount64(mask)
+	c.terrain.answering = false
+	c.mu.Unlock()
+}
+
+// terrainBlock looks up the 16 heights of one 4x4 block of a request
+// Points are indexed north row first: Data[north*4+east].
+func terrainBlock(source TerrainSource, req common.MessageTerrainRequest, bit uint8) ([16]int16, error) {
+	var data [16]int16
+	spacing := float64(req.GridSpacing)
+	blockNorth := int(bit/terrainBlocksPerRow) * 4
+	blockEast := int(bit%terrainBlocksPerRow) * 4
+
+	for north := range 4 {
+		for east := range 4 {
+			lat, lon := terrainGridPoint(req.Lat, req.Lon,
+				spacing*float64(blockNorth+north), spacing*float64(blockEast+east))
+			height, err := source.Elevation(lat, lon)
+			if err != nil {
+				return data, err
+			}
+			data[north*4+east] = int16(max(math.MinInt16, min(math.MaxInt16, math.Round(height))))
+		}
+	}
+	return data, nil
+}
+
+// terrainGridPoint offsets a request corner (degrees * 1e7) by meters north
+// and east the way ArduPilot does, returning degrees
+func terrainGridPoint(lat, lon int32, north, east float64) (float64, float64) {
+	dLat := north / terrainMetersPerUnit
+	midLat := (float64(lat) + dLat/2) * 1e-7
+	lonScale := max(0.01, math.Cos(midLat*math.Pi/180))
+	dLon := east / terrainMetersPerUnit / lonScale
+	return (float64(lat) + dLat) * 1e-7, (float64(lon) + dLon) * 1e-7
+}
+
+// handleTerrainReport records the vehicle's TERRAIN_REPORT
+func (c *Client) handleTerrainReport(msg *common.MessageTerrainReport) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.terrain.report = &TerrainReport{
+		Latitude:      float64(msg.Lat) / 1e7,
+		Longitude:     float64(msg.Lon) / 1e7,
+		Spacing:       int(msg.Spacing),
+		TerrainHeight: float64(msg.TerrainHeight),
+		CurrentHeight: float64(msg.CurrentHeight),
+		Pending:       int(msg.Pending),
+		Loaded:        int(msg.Loaded),
+		UpdatedMs:     time.Now().UnixMilli(),
+		Healthy:       msg.Spacing != 0 && msg.Pending == 0,
+	}
+}
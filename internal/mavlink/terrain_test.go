@@ -0,0 +1,136 @@
+package mavlink
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	"github.com/bluenviron/gomavlib/v3/pkg/dialects/common"
+)
+
+// gridTerrain is a synthetic terrain around a request corner: each grid
+// point's height encodes its place in the request grid, 1000 m plus its
+// distance north plus its column east, and there is no data beyond noDataNorth
+type gridTerrain struct {
+	lat, lon    int32
+	spacing     float64
+	noDataNorth float64
+}
+
+func (g gridTerrain) Elevation(lat, lon float64) (float64, error) {
+	north := (lat*1e7 - float64(g.lat)) * terrainMetersPerUnit
+	if north >= g.noDataNorth {
+		return 0, ErrNoTerrainData
+	}
+	midLat := (lat + float64(g.lat)*1e-7) / 2
+	east := (lon*1e7 - float64(g.lon)) * terrainMetersPerUnit * math.Cos(midLat*math.Pi/180)
+	return 1000 + north + east/g.spacing, nil
+}
+
+// terrainRequest is a 30 m grid request at the gridTerrain corner for blocks in mask
+func terrainRequest(mask uint64) *common.MessageTerrainRequest {
+	return &common.MessageTerrainRequest{Lat: 473977419, Lon: 85455938, GridSpacing: 30, Mask: mask}
+}
+
+// waitTerrainAnswered waits until sent blocks have been answered
+func waitTerrainAnswered(t *testing.T, c *Client, sent int) TerrainStatus {
+	t.Helper()
+	deadline := time.Now().Add(2 * time.Second)
+	for {
+		status := c.TerrainStatus()
+		if status.BlocksSent+status.BlocksMissing >= sent {
+			return status
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("terrain request not answered: %+v", status)
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
+
+func TestTerrainRequest(t *testing.T) {
+	c, vehicle := newLinkedTestClient(t)
+	// Rows 6 and up (720 m north and beyond) are off the dataset
+	c.terrainSource = gridTerrain{lat: 473977419, lon: 85455938, spacing: 30, noDataNorth: 700}
+
+	// The first block, one in the second row and column, and the last
+	c.handleMessage(terrainRequest(1<<0|1<<9|1<<55), 1, 1)
+	for _, bit := range []uint8{0, 9} {
+		msg := receive[*common.MessageTerrainData](t, vehicle)
+		if msg.Gridbit != bit || msg.GridSpacing != 30 || msg.Lat != 473977419 || msg.Lon != 85455938 {
+			t.Fatalf("TERRAIN_DATA block %d spacing %d at %d, %d; want block %d", msg.Gridbit, msg.GridSpacing, msg.Lat, msg.Lon, bit)
+		}
+		blockNorth, blockEast := int(bit/8)*4, int(bit%8)*4
+		for north := range 4 {
+			for east := range 4 {
+				want := int16(1000 + 30*(blockNorth+north) + blockEast + east)
+				if got := msg.Data[north*4+east]; got != want {
+					t.Errorf("block %d point %d north, %d east = %d m, want %d m", bit, north, east, got, want)
+				}
+			}
+		}
+	}
+
+	status := waitTerrainAnswered(t, c, 3)
+	if !status.Enabled || status.Requests != 1 || status.BlocksSent != 2 || status.BlocksMissing != 1 || status.Outstanding != 0 {
+		t.Errorf("status = %+v, want 2 blocks sent and 1 missing", status)
+	}
+	if status.LastRequestMs == 0 {
+		t.Error("last request time not recorded")
+	}
+}
+
+func TestTerrainRequestBounded(t *testing.T) {
+	c, vehicle := newLinkedTestClient(t)
+	c.terrainSource = gridTerrain{lat: 473977419, lon: 85455938, spacing: 30, noDataNorth: math.Inf(1)}
+
+	// A request for the whole grid is answered a batch at a time
+	c.handleMessage(terrainRequest(terrainMaskAll), 1, 1)
+	for bit := range uint8(terrainBlocksPerRequest) {
+		if msg := receive[*common.MessageTerrainData](t, vehicle); msg.Gridbit != bit {
+			t.Fatalf("sent block %d, want %d", msg.Gridbit, bit)
+		}
+	}
+	status := waitTerrainAnswered(t, c, terrainBlocksPerRequest)
+	if status.BlocksSent != terrainBlocksPerRequest || status.Outstanding != 56-terrainBlocksPerRequest {
+		t.Errorf("status = %+v, want %d sent and %d outstanding", status, terrainBlocksPerRequest, 56-terrainBlocksPerRequest)
+	}
+
+	// The vehicle repeats the request for the rest
+	c.handleMessage(terrainRequest(terrainMaskAll&^(1<<terrainBlocksPerRequest-1)), 1, 1)
+	if msg := receive[*common.MessageTerrainData](t, vehicle); msg.Gridbit != terrainBlocksPerRequest {
+		t.Errorf("repeated request started at block %d, want %d", msg.Gridbit, terrainBlocksPerRequest)
+	}
+}
+
+func TestTerrainRequestWithoutSource(t *testing.T) {
+	c := newConnectedTestClient()
+	c.handleMessage(terrainRequest(terrainMaskAll), 1, 1)
+	if status := c.TerrainStatus(); status.Enabled || status.Requests != 1 || status.BlocksSent != 0 {
+		t.Errorf("status = %+v, want the request only counted", status)
+	}
+}
+
+func TestTerrainReport(t *testing.T) {
+	c := newConnectedTestClient()
+	if c.TerrainStatus().Report != nil {
+		t.Fatal("report before TERRAIN_REPORT")
+	}
+
+	c.handleMessage(&common.MessageTerrainReport{
+		Lat: 473977419, Lon: 85455938, Spacing: 30, TerrainHeight: 488.5, CurrentHeight: 40, Pending: 3, Loaded: 20,
+	}, 1, 1)
+	report := c.TerrainStatus().Report
+	if report == nil || report.Healthy || report.Pending != 3 || report.TerrainHeight != 488.5 || !near(report.Latitude, 47.3977419) {
+		t.Fatalf("report = %+v, want unhealthy with 3 blocks pending", report)
+	}
+
+	c.handleMessage(&common.MessageTerrainReport{Spacing: 30, Loaded: 23}, 1, 1)
+	if report := c.TerrainStatus().Report; !report.Healthy {
+		t.Errorf("report = %+v, want healthy with nothing pending", report)
+	}
+	c.handleMessage(&common.MessageTerrainReport{}, 1, 1)
+	if report := c.TerrainStatus().Report; report.Healthy {
+		t.Errorf("report = %+v, want unhealthy without terrain at the vehicle", report)
+	}
+}
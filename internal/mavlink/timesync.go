@@ -0,0 +1,112 @@
+package mavlink
+
+import (
+	"time"
+
+	"github.com/bluenviron/gomavlib/v3/pkg/dialects/common"
+)
+
+const (
+	// timesyncMaxRTT discards exchanges too slow to say anything about the offset
+	timesyncMaxRTT = time.Second
+
+	// timesyncResetJump is an offset change treated as a vehicle reboot rather than drift
+	timesyncResetJump = time.Second
+
+	// timesyncSmoothing weights each new offset sample (exponential moving average)
+	timesyncSmoothing = 0.2
+
+	// timesyncMaxCorrection bounds how far back a corrected telemetry timestamp may go
+	timesyncMaxCorrection = 5 * time.Second
+)
+
+// timesyncState holds the TIMESYNC estimate, guarded by c.mu
+type timesyncState struct {
+	pendingTs1 int64 // ts1 of the request awaiting a reply (0 = none)
+
+	rtt     time.Duration // last round-trip time
+	offset  time.Duration // vehicle clock minus ours, smoothed
+	updated time.Time     // zero until the first exchange completes
+}
+
+// sendTimesync starts a TIMESYNC exchange; the reply is matched in handleTimesync
+func (c *Client) sendTimesync(now time.Time) error {
+	ts1 := now.UnixNano()
+
+	c.mu.Lock()
+	c.timesync.pendingTs1 = ts1
+	systemID := c.systemID
+	c.mu.Unlock()
+
+	return c.writeMessage(&common.MessageTimesync{
+		Tc1:          0,
+		Ts1:          ts1,
+		TargetSystem: systemID,
+	})
+}
+
+// handleTimesync answers the vehicle's TIMESYNC requests and completes our own
+func (c *Client) handleTimesync(msg *common.MessageTimesync, sysID, compID uint8) {
+	now := time.Now()
+
+	if msg.Tc1 == 0 {
+		// Request: reply with our clock, echoing theirs
+		if c.passive {
+			return
+		}
+		err := c.writeMessage(&common.MessageTimesync{
+			Tc1:             now.UnixNano(),
+			Ts1:             msg.Ts1,
+			TargetSystem:    sysID,
+			TargetComponent: compID,
+		})
+		if err != nil {
+			c.logger.Printf("MAVLink: Error answering TIMESYNC: %v", err)
+		}
+		return
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	// Replies to other ground stations on the link carry their ts1, not ours
+	if c.timesync.pendingTs1 == 0 || msg.Ts1 != c.timesync.pendingTs1 {
+		return
+	}
+	c.timesync.pendingTs1 = 0
+
+	rtt := time.Duration(now.UnixNano() - msg.Ts1)
+	if rtt < 0 || rtt > timesyncMaxRTT {
+		return
+	}
+
+	// The vehicle stamped tc1 halfway through the round trip
+	sample := time.Duration(msg.Tc1 - (msg.Ts1+now.UnixNano())/2)
+
+	ts := &c.timesync
+	jump := sample - ts.offset
+	if ts.updated.IsZero() || jump > timesyncResetJump || jump < -timesyncResetJump {
+		ts.offset = sample
+	} else {
+		ts.offset += time.Duration(float64(jump) * timesyncSmoothing)
+	}
+	ts.rtt = rtt
+	ts.updated = now
+}
+
+// vehicleTime converts a vehicle time_boot_ms to our clock using the TIMESYNC offset
+// Falls back to now when timestamp correction is off, no exchange has completed,
+// or the result is implausible (in the future or older than timesyncMaxCorrection).
+// Caller must hold c.mu.
+func (c *Client) vehicleTime(timeBootMs uint32, now time.Time) time.Time {
+	if !c.correctTimestamps || c.timesync.updated.IsZero() {
+		return now
+	}
+
+	vehicleNs := int64(timeBootMs) * int64(time.Millisecond)
+	t := time.Unix(0, vehicleNs-int64(c.timesync.offset))
+	if t.After(now) || now.Sub(t) > timesyncMaxCorrection {
+		return now
+	}
+	return t
+}
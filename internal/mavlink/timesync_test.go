@@ -0,0 +1,101 @@
+package mavlink
+
+import (
+	"testing"
+	"time"
+
+	"github.com/bluenviron/gomavlib/v3"
+	"github.com/bluenviron/gomavlib/v3/pkg/dialects/common"
+)
+
+// timesyncExchange runs one TIMESYNC exchange with a vehicle whose clock
+// reads nanoseconds since boot
+func timesyncExchange(t *testing.T, c *Client, vehicle *gomavlib.Node, boot time.Time) {
+	t.Helper()
+	if err := c.sendTimesync(time.Now()); err != nil {
+		t.Fatal(err)
+	}
+	req := receive[*common.MessageTimesync](t, vehicle)
+	if req.Tc1 != 0 || req.Ts1 == 0 {
+		t.Fatalf("request tc1 = %d, ts1 = %d", req.Tc1, req.Ts1)
+	}
+	c.handleMessage(&common.MessageTimesync{Tc1: time.Since(boot).Nanoseconds(), Ts1: req.Ts1}, 1, 1)
+}
+
+func TestTimesyncOffset(t *testing.T) {
+	c, vehicle := newLinkedTestClient(t)
+	boot := time.Now().Add(-10 * time.Minute)
+
+	timesyncExchange(t, c, vehicle, boot)
+	info := c.GetConnectionInfo()
+	// Vehicle clock minus ours: minus the boot time, to within the round trip
+	want := -time.Duration(boot.UnixNano())
+	if diff := info.ClockOffset - want; diff < -info.RoundTripTime || diff > info.RoundTripTime {
+		t.Errorf("offset = %s, want %s (round trip %s)", info.ClockOffset, want, info.RoundTripTime)
+	}
+	if info.RoundTripTime <= 0 || info.RoundTripTime > timesyncMaxRTT {
+		t.Errorf("round trip = %s", info.RoundTripTime)
+	}
+
+	// The vehicle rebooted: the offset jumps instead of drifting there
+	boot = time.Now()
+	timesyncExchange(t, c, vehicle, boot)
+	want = -time.Duration(boot.UnixNano())
+	if diff := c.GetConnectionInfo().ClockOffset - want; diff < -timesyncResetJump/10 || diff > timesyncResetJump/10 {
+		t.Errorf("offset after reboot = %s, want %s", c.GetConnectionInfo().ClockOffset, want)
+	}
+}
+
+func TestTimesyncIgnoresOtherReplies(t *testing.T) {
+	c, vehicle := newLinkedTestClient(t)
+	if err := c.sendTimesync(time.Now()); err != nil {
+		t.Fatal(err)
+	}
+	req := receive[*common.MessageTimesync](t, vehicle)
+
+	// A reply to another ground station's request
+	c.handleMessage(&common.MessageTimesync{Tc1: 42, Ts1: req.Ts1 + 1}, 1, 1)
+	if info := c.GetConnectionInfo(); info.ClockOffset != 0 || info.RoundTripTime != 0 {
+		t.Errorf("offset %s, round trip %s from a reply to someone else", info.ClockOffset, info.RoundTripTime)
+	}
+}
+
+func TestTimesyncAnswersVehicle(t *testing.T) {
+	c, vehicle := newLinkedTestClient(t)
+
+	before := time.Now().UnixNano()
+	c.handleMessage(&common.MessageTimesync{Ts1: 123456789}, 1, 1)
+	reply := receive[*common.MessageTimesync](t, vehicle)
+	if reply.Ts1 != 123456789 || reply.Tc1 < before || reply.Tc1 > time.Now().UnixNano() {
+		t.Errorf("reply tc1 = %d, ts1 = %d", reply.Tc1, reply.Ts1)
+	}
+}
+
+func TestVehicleTime(t *testing.T) {
+	c, vehicle := newLinkedTestClient(t)
+	boot := time.Now().Add(-time.Hour)
+	timesyncExchange(t, c, vehicle, boot)
+
+	now := time.Now()
+	sampled := now.Add(-200 * time.Millisecond)
+	bootMs := uint32(sampled.Sub(boot).Milliseconds())
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if got := c.vehicleTime(bootMs, now); got != now {
+		t.Errorf("corrected while correction is off: %s", got)
+	}
+
+	c.correctTimestamps = true
+	if got := c.vehicleTime(bootMs, now); got.Sub(sampled).Abs() > 20*time.Millisecond {
+		t.Errorf("vehicle time = %s, want about %s", got, sampled)
+	}
+	// Implausible: in the future, or too long ago
+	future := uint32(now.Add(time.Second).Sub(boot).Milliseconds())
+	stale := uint32(now.Add(-timesyncMaxCorrection - time.Second).Sub(boot).Milliseconds())
+	for _, ms := range []uint32{future, stale} {
+		if got := c.vehicleTime(ms, now); got != now {
+			t.Errorf("vehicleTime(%d) = %s, want now", ms, got)
+		}
+	}
+}
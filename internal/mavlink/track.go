@@ -0,0 +1,101 @@
+package mavlink
+
+import (
+	"time"
+)
+
+const (
+	// trackInterval is the minimum time between recorded track points
+	trackInterval = time.Second
+
+	// maxTrackPoints bounds a track (10 hours at trackInterval); the oldest
+	// points are dropped beyond it
+	maxTrackPoints = 36000
+)
+
+// TrackPoint is one recorded position fix
+type TrackPoint struct {
+	Time      time.Time
+	Latitude  float64 // degrees
+	Longitude float64 // degrees
+	Altitude  float64 // meters (MSL)
+
+	Satellites int32
+	HDOP       float64 // 0 when the receiver doesn't report it
+}
+
+// Track is the flight path recorded since the vehicle last armed (or since
+// connecting, before it first arms)
+type Track struct {
+	Started time.Time
+	Points  []TrackPoint
+}
+
+// TrackSink saves the track when the client closes
+type TrackSink interface {
+	SaveTrack(track Track) error
+}
+
+// trackRecorder accumulates position fixes, guarded by c.mu
+type trackRecorder struct {
+	started time.Time
+	points  []TrackPoint
+}
+
+// recordTrackPointLocked adds the current position to the track, at most one
+// point per trackInterval; positions without a valid fix are skipped
+// c.mu must be held.
+func (c *Client) recordTrackPointLocked(now time.Time) {
+	t := &c.telemetry
+	if !t.PositionValid() {
+		return
+	}
+	if n := len(c.track.points); n > 0 && now.Sub(c.track.points[n-1].Time) < trackInterval {
+		return
+	}
+
+	if len(c.track.points) >= maxTrackPoints {
+		c.track.points = append(c.track.points[:0], c.track.points[1:]...)
+	}
+	c.track.points = append(c.track.points, TrackPoint{
+		Time:       now,
+		Latitude:   t.Latitude,
+		Longitude:  t.Longitude,
+		Altitude:   t.Altitude,
+		Satellites: t.SatelliteCount,
+		HDOP:       t.GPSHDOP,
+	})
+}
+
+// startTrackLocked begins a new track, e.g. when the vehicle arms; c.mu must be held
+func (c *Client) startTrackLocked(now time.Time) {
+	c.track = trackRecorder{started: now}
+}
+
+// Track returns a copy of the flight path recorded so far
+func (c *Client) Track() Track {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	return Track{
+		Started: c.track.started,
+		Points:  append([]TrackPoint(nil), c.track.points...),
+	}
+}
+
+// saveTrack hands the recorded track to the track sink, if there is one and
+// the track has points
+func (c *Client) saveTrack() {
+	if c.trackSink == nil {
+		return
+	}
+	track := c.Track()
+	if len(track.Points) == 0 {
+		return
+	}
+	if err := c.trackSink.SaveTrack(track); err != nil {
+		c.logger.Printf("MAVLink: Warning - failed to save track: %v", err)
+		return
+	}
+	c.logger.Printf("MAVLink: Saved track of %d points", len(track.Points))
+}
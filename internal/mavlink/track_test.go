@@ -0,0 +1,43 @@
+package mavlink
+
+import (
+	"testing"
+
+	"github.com/bluenviron/gomavlib/v3/pkg/dialects/common"
+)
+
+func TestTrackRecording(t *testing.T) {
+	c := newConnectedTestClient()
+	position := &common.MessageGlobalPositionInt{Lat: 470000000, Lon: 80000000, Alt: 450000}
+
+	// No GPS fix: not recorded
+	c.handleMessage(&common.MessageGpsRawInt{FixType: common.GPS_FIX_TYPE_NO_FIX}, 1, 1)
+	c.handleMessage(position, 1, 1)
+	if track := c.Track(); len(track.Points) != 0 {
+		t.Fatalf("recorded without a fix: %+v", track.Points)
+	}
+
+	c.handleMessage(&common.MessageGpsRawInt{FixType: common.GPS_FIX_TYPE_3D_FIX, Eph: 90, SatellitesVisible: 14}, 1, 1)
+	c.handleMessage(position, 1, 1)
+	// Within trackInterval of the last point: skipped
+	c.handleMessage(&common.MessageGlobalPositionInt{Lat: 470000100, Lon: 80000000, Alt: 450000}, 1, 1)
+
+	track := c.Track()
+	if len(track.Points) != 1 {
+		t.Fatalf("%d points, want 1", len(track.Points))
+	}
+	p := track.Points[0]
+	if p.Latitude != 47 || p.Longitude != 8 || p.Altitude != 450 || p.Satellites != 14 || !near(p.HDOP, 0.9) {
+		t.Errorf("point = %+v", p)
+	}
+
+	// Arming starts a new track
+	c.handleMessage(&common.MessageHeartbeat{
+		Type:      common.MAV_TYPE_QUADROTOR,
+		Autopilot: common.MAV_AUTOPILOT_PX4,
+		BaseMode:  common.MAV_MODE_FLAG_SAFETY_ARMED,
+	}, 1, 1)
+	if track := c.Track(); len(track.Points) != 0 || track.Started.IsZero() {
+		t.Errorf("track after arming: %+v", track)
+	}
+}
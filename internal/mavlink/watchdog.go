@@ -0,0 +1,73 @@
+package mavlink
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/bluenviron/gomavlib/v3/pkg/dialects/common"
+)
+
+// DefaultTelemetryStaleTimeout is how old position data may get while armed
+// before the watchdog raises an alarm
+const DefaultTelemetryStaleTimeout = 3 * time.Second
+
+// watchdogInterval is how often the watchdog checks telemetry age
+const watchdogInterval = 500 * time.Millisecond
+
+// watchTelemetry alarms while the vehicle is armed and position updates have stopped
+// Heartbeats alone can keep the link looking alive on a degraded connection,
+// so this checks GLOBAL_POSITION_INT freshness rather than IsConnected.
+func (c *Client) watchTelemetry() {
+	defer close(c.watchdogDone)
+
+	ticker := time.NewTicker(watchdogInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-c.stopWatchdog:
+			return
+		case now := <-ticker.C:
+			c.checkTelemetryStale(now)
+		}
+	}
+}
+
+// checkTelemetryStale raises or clears the stale-telemetry alarm
+func (c *Client) checkTelemetryStale(now time.Time) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	// Nothing to compare against until position has been received once
+	positionUpdated := c.telemetry.PositionUpdated
+	stale := c.armed && !positionUpdated.IsZero() &&
+		now.Sub(positionUpdated) > c.telemetryStaleTimeout
+
+	if stale == c.telemetryStale {
+		return
+	}
+	c.telemetryStale = stale
+
+	if stale {
+		message := fmt.Sprintf("Telemetry stale while armed: no position update for %s",
+			now.Sub(positionUpdated).Round(100*time.Millisecond))
+		c.logger.Printf("MAVLink: ERROR - %s", message)
+		c.publishEvent(Event{
+			Kind:     EventTelemetryStale,
+			Severity: common.MAV_SEVERITY_ALERT,
+			Message:  message,
+		})
+		return
+	}
+
+	message := "Telemetry resumed"
+	if !c.armed {
+		message = "Telemetry alarm cleared: vehicle disarmed"
+	}
+	c.logger.Printf("MAVLink: %s", message)
+	c.publishEvent(Event{
+		Kind:     EventTelemetryResumed,
+		Severity: common.MAV_SEVERITY_INFO,
+		Message:  message,
+	})
+}
@@ -0,0 +1,76 @@
+package mavlink
+
+import (
+	"testing"
+	"time"
+
+	"github.com/bluenviron/gomavlib/v3/pkg/dialects/common"
+)
+
+// nextEvent returns the next event published, failing unless it is of the given kind
+func nextEvent(t *testing.T, events <-chan Event, kind EventKind) Event {
+	t.Helper()
+	select {
+	case evt := <-events:
+		if evt.Kind != kind {
+			t.Fatalf("event = %s (%s), want %s", evt.Kind, evt.Message, kind)
+		}
+		return evt
+	default:
+		t.Fatalf("no %s event published", kind)
+		return Event{}
+	}
+}
+
+func TestCheckTelemetryStale(t *testing.T) {
+	c := newConnectedTestClient()
+	c.telemetryStaleTimeout = DefaultTelemetryStaleTimeout
+	events, unsubscribe := c.SubscribeEvents()
+	defer unsubscribe()
+
+	heartbeat := func(mode common.MAV_MODE_FLAG) *common.MessageHeartbeat {
+		return &common.MessageHeartbeat{
+			Type:      common.MAV_TYPE_QUADROTOR,
+			Autopilot: common.MAV_AUTOPILOT_PX4,
+			BaseMode:  mode,
+		}
+	}
+	position := &common.MessageGlobalPositionInt{Lat: 473977420, Lon: 85455940}
+
+	// Disarmed: old position data isn't an alarm
+	c.handleMessage(position, 1, 1)
+	c.checkTelemetryStale(time.Now().Add(time.Minute))
+	noEvent(t, events)
+
+	c.handleMessage(heartbeat(common.MAV_MODE_FLAG_SAFETY_ARMED), 1, 1)
+	c.handleMessage(position, 1, 1)
+	c.checkTelemetryStale(time.Now().Add(time.Second))
+	noEvent(t, events)
+
+	c.checkTelemetryStale(time.Now().Add(DefaultTelemetryStaleTimeout + time.Second))
+	if evt := nextEvent(t, events, EventTelemetryStale); evt.Severity != common.MAV_SEVERITY_ALERT {
+		t.Errorf("stale severity = %v, want alert", evt.Severity)
+	}
+	if !c.GetConnectionInfo().TelemetryStale {
+		t.Error("connection info doesn't report stale telemetry")
+	}
+	// Still stale: no repeat
+	c.checkTelemetryStale(time.Now().Add(2 * DefaultTelemetryStaleTimeout))
+	noEvent(t, events)
+
+	c.handleMessage(position, 1, 1)
+	c.checkTelemetryStale(time.Now())
+	nextEvent(t, events, EventTelemetryResumed)
+	if c.GetConnectionInfo().TelemetryStale {
+		t.Error("connection info still reports stale telemetry")
+	}
+
+	// Disarming clears a raised alarm
+	c.checkTelemetryStale(time.Now().Add(DefaultTelemetryStaleTimeout + time.Second))
+	nextEvent(t, events, EventTelemetryStale)
+	c.handleMessage(heartbeat(0), 1, 1)
+	c.checkTelemetryStale(time.Now().Add(time.Minute))
+	if evt := nextEvent(t, events, EventTelemetryResumed); evt.Message != "Telemetry alarm cleared: vehicle disarmed" {
+		t.Errorf("cleared by disarming: %q", evt.Message)
+	}
+}
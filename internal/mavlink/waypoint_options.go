@@ -0,0 +1,60 @@
+package mavlink
+
+import (
+	"fmt"
+
+	drone "github.com/flightpath-dev/flightpath-proto/gen/go/drone/v1"
+)
+
+// WaypointOptions are per-waypoint mission settings the proto Waypoint has no fields for
+// The zero value uploads a plain waypoint relative to home.
+type WaypointOptions struct {
+	AltitudeFrame AltitudeFrame `json:"altitude_frame"`
+
+	// Loiter circle radius in meters for LOITER and HOLD waypoints (negative
+	// circles counter-clockwise); 0 leaves it to the vehicle
+	LoiterRadius float64 `json:"loiter_radius,omitempty"`
+
+	// Circle this many times before continuing (MAV_CMD_NAV_LOITER_TURNS)
+	// instead of loitering indefinitely (LOITER) or for the hold time (HOLD)
+	LoiterTurns float64 `json:"loiter_turns,omitempty"`
+
+	// Continue to the next item on arrival (true) or wait for the operator
+	// (false); nil uses the client's default (see Config.WaitAtWaypoints)
+	Autocontinue *bool `json:"autocontinue,omitempty"`
+
+	// Camera commands run once the waypoint is reached, in order
+	Camera []CameraAction `json:"camera,omitempty"`
+}
+
+// DefaultWaypointOptions returns options for n waypoints relative to home
+func DefaultWaypointOptions(n int) []WaypointOptions {
+	options := make([]WaypointOptions, n)
+	for i := range options {
+		options[i].AltitudeFrame = AltitudeRelative
+	}
+	return options
+}
+
+// Validate checks the options make sense for a waypoint action
+func (o WaypointOptions) Validate(action drone.Waypoint_Action) error {
+	if _, err := ParseAltitudeFrame(string(o.AltitudeFrame)); err != nil {
+		return err
+	}
+	if o.LoiterTurns < 0 {
+		return fmt.Errorf("loiter_turns must not be negative")
+	}
+	if (o.LoiterRadius != 0 || o.LoiterTurns != 0) && !isLoiterAction(action) {
+		return fmt.Errorf("loiter_radius and loiter_turns only apply to LOITER and HOLD waypoints")
+	}
+	for i, action := range o.Camera {
+		if err := action.Validate(); err != nil {
+			return fmt.Errorf("camera action %d: %w", i, err)
+		}
+	}
+	return nil
+}
+
+func isLoiterAction(action drone.Waypoint_Action) bool {
+	return action == drone.Waypoint_ACTION_LOITER || action == drone.Waypoint_ACTION_HOLD
+}
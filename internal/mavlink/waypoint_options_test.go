@@ -0,0 +1,111 @@
+package mavlink
+
+import (
+	"testing"
+
+	"github.com/bluenviron/gomavlib/v3/pkg/dialects/common"
+
+	drone "github.com/flightpath-dev/flightpath-proto/gen/go/drone/v1"
+)
+
+func TestLoiterWaypointItems(t *testing.T) {
+	c := newTestClient()
+	tests := []struct {
+		name    string
+		action  drone.Waypoint_Action
+		opts    WaypointOptions
+		command common.MAV_CMD
+		param1  float32 // hold time or turns
+		param3  float32 // loiter radius
+	}{
+		{"loiter", drone.Waypoint_ACTION_LOITER, WaypointOptions{}, common.MAV_CMD_NAV_LOITER_UNLIM, 0, 0},
+		{"loiter with radius", drone.Waypoint_ACTION_LOITER, WaypointOptions{LoiterRadius: 40},
+			common.MAV_CMD_NAV_LOITER_UNLIM, 0, 40},
+		{"counter-clockwise loiter", drone.Waypoint_ACTION_LOITER, WaypointOptions{LoiterRadius: -40},
+			common.MAV_CMD_NAV_LOITER_UNLIM, 0, -40},
+		{"hold", drone.Waypoint_ACTION_HOLD, WaypointOptions{LoiterRadius: 25},
+			common.MAV_CMD_NAV_LOITER_TIME, 30, 25},
+		{"loiter turns", drone.Waypoint_ACTION_LOITER, WaypointOptions{LoiterRadius: 25, LoiterTurns: 3},
+			common.MAV_CMD_NAV_LOITER_TURNS, 3, 25},
+		{"hold turns", drone.Waypoint_ACTION_HOLD, WaypointOptions{LoiterTurns: 2},
+			common.MAV_CMD_NAV_LOITER_TURNS, 2, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tt.opts.Validate(tt.action); err != nil {
+				t.Fatal(err)
+			}
+			wp := &drone.Waypoint{
+				Position:    &drone.Position{Latitude: 47, Longitude: 8, Altitude: 30},
+				Action:      tt.action,
+				HoldTimeSec: 30,
+			}
+			if tt.action == drone.Waypoint_ACTION_LOITER {
+				wp.HoldTimeSec = 0
+			}
+			item := c.waypointToMissionItem(wp, tt.opts)
+			if item.Command != tt.command || item.Param1 != tt.param1 || item.Param3 != tt.param3 {
+				t.Errorf("item = %s, param1 %v, param3 %v, want %s, %v, %v",
+					item.Command, item.Param1, item.Param3, tt.command, tt.param1, tt.param3)
+			}
+		})
+	}
+
+	// A radius or turns on anything but a loiter is refused, not dropped
+	for _, opts := range []WaypointOptions{{LoiterRadius: 25}, {LoiterTurns: 2}} {
+		if err := opts.Validate(drone.Waypoint_ACTION_WAYPOINT); err == nil {
+			t.Errorf("%+v accepted on a plain waypoint", opts)
+		}
+	}
+	if err := (WaypointOptions{LoiterTurns: -1}).Validate(drone.Waypoint_ACTION_LOITER); err == nil {
+		t.Error("negative turns accepted")
+	}
+}
+
+func TestWaypointDefaults(t *testing.T) {
+	c, vehicle := newLinkedTestClient(t)
+	v := serveMissions(t, c, vehicle)
+	c.waypointAcceptanceRadius = 3
+	c.waitAtWaypoints = true
+
+	at := func(lat float64) *drone.Position { return &drone.Position{Latitude: lat, Longitude: 8, Altitude: 30} }
+	waypoints := []*drone.Waypoint{
+		{Position: at(47), Action: drone.Waypoint_ACTION_TAKEOFF},
+		{Position: at(47.001), Action: drone.Waypoint_ACTION_WAYPOINT},
+		{Position: at(47.002), Action: drone.Waypoint_ACTION_WAYPOINT, AcceptanceRadius: 5},
+		{Position: at(47.003), Action: drone.Waypoint_ACTION_HOLD, HoldTimeSec: 10},
+	}
+	options := DefaultWaypointOptions(len(waypoints))
+	proceed := true
+	options[2].Autocontinue = &proceed
+
+	if err := c.UploadMissionOptions(waypoints, options); err != nil {
+		t.Fatal(err)
+	}
+	v.mu.Lock()
+	items := v.items[common.MAV_MISSION_TYPE_MISSION]
+	v.mu.Unlock()
+
+	// Only WAYPOINT items take the default radius (param 2 means something
+	// else elsewhere); only the override continues on its own
+	want := []struct {
+		param2       float32
+		autocontinue uint8
+	}{{0, 0}, {3, 0}, {5, 1}, {0, 0}}
+	if len(items) != len(want) {
+		t.Fatalf("%d items uploaded", len(items))
+	}
+	for i, item := range items {
+		if item.Param2 != want[i].param2 || item.Autocontinue != want[i].autocontinue {
+			t.Errorf("item %d: param2 %v, autocontinue %d, want %v and %d",
+				i, item.Param2, item.Autocontinue, want[i].param2, want[i].autocontinue)
+		}
+	}
+
+	// Without the server defaults, a zero radius is left to the vehicle
+	c.waypointAcceptanceRadius = 0
+	c.waitAtWaypoints = false
+	if item := c.waypointToMissionItem(waypoints[1], WaypointOptions{}); item.Param2 != 0 || !item.Autocontinue {
+		t.Errorf("item = param2 %v, autocontinue %v", item.Param2, item.Autocontinue)
+	}
+}
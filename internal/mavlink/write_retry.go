@@ -0,0 +1,43 @@
+package mavlink
+
+import (
+	"errors"
+	"net"
+	"os"
+	"syscall"
+	"time"
+)
+
+// writeRetryDelay is the wait before the first retry of a failed write,
+// doubling for each further one
+const writeRetryDelay = 10 * time.Millisecond
+
+// writeWithRetry calls write, retrying transient failures up to
+// c.writeRetries times
+// Fatal errors (encoding, a closed node) are returned at once. The caller
+// counts only the final outcome toward link-down detection, so a glitch that
+// clears on retry doesn't count as a failed write.
+func (c *Client) writeWithRetry(write func() error) error {
+	delay := writeRetryDelay
+	for attempt := 0; ; attempt++ {
+		err := write()
+		if err == nil || attempt >= c.writeRetries || !isTransientWriteError(err) {
+			return err
+		}
+
+		c.logger.Printf("MAVLink: Warning - write failed (%v), retrying in %s", err, delay)
+		time.Sleep(delay)
+		delay *= 2
+	}
+}
+
+// isTransientWriteError reports whether a write may succeed if tried again:
+// a full buffer, an interrupted call or a write timeout
+func isTransientWriteError(err error) bool {
+	if errors.Is(err, syscall.EAGAIN) || errors.Is(err, syscall.EINTR) ||
+		errors.Is(err, syscall.ENOBUFS) || errors.Is(err, os.ErrDeadlineExceeded) {
+		return true
+	}
+	var netErr net.Error
+	return errors.As(err, &netErr) && netErr.Timeout()
+}
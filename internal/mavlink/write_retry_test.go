@@ -0,0 +1,99 @@
+package mavlink
+
+import (
+	"errors"
+	"fmt"
+	"syscall"
+	"testing"
+)
+
+// flakyWriter fails its first failures calls with err, then succeeds
+type flakyWriter struct {
+	failures int
+	err      error
+	calls    int
+}
+
+func (w *flakyWriter) write() error {
+	w.calls++
+	if w.calls <= w.failures {
+		return w.err
+	}
+	return nil
+}
+
+func TestWriteWithRetry(t *testing.T) {
+	bufferFull := fmt.Errorf("write /dev/ttyUSB0: %w", syscall.EAGAIN)
+	tests := []struct {
+		name      string
+		retries   int
+		writer    flakyWriter
+		wantErr   bool
+		wantCalls int
+	}{
+		{"succeeds on second attempt", 2, flakyWriter{failures: 1, err: bufferFull}, false, 2},
+		{"retries exhausted", 2, flakyWriter{failures: 5, err: bufferFull}, true, 3},
+		{"retries disabled", 0, flakyWriter{failures: 1, err: bufferFull}, true, 1},
+		{"fatal error not retried", 2, flakyWriter{failures: 1, err: errors.New("write: broken pipe")}, true, 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := newTestClient()
+			c.writeRetries = tt.retries
+
+			err := c.writeWithRetry(tt.writer.write)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("err = %v, want error %v", err, tt.wantErr)
+			}
+			if tt.writer.calls != tt.wantCalls {
+				t.Errorf("write called %d times, want %d", tt.writer.calls, tt.wantCalls)
+			}
+		})
+	}
+}
+
+func TestWriteRetryFailuresMarkLinkDown(t *testing.T) {
+	c := newTestClient()
+	c.writeRetries = 1
+	writer := flakyWriter{failures: 1, err: syscall.ENOBUFS}
+
+	// A glitch that clears on retry isn't a failed write
+	if err := c.writeWithRetry(writer.write); err != nil {
+		t.Fatalf("write failed after retry: %v", err)
+	}
+
+	// Each write that fails all its attempts counts once
+	writer = flakyWriter{failures: 1000, err: syscall.ENOBUFS}
+	for i := 0; i < writeFailureThreshold; i++ {
+		if err := c.writeWithRetry(writer.write); err == nil {
+			t.Fatal("persistently failing write succeeded")
+		} else {
+			c.recordWriteFailure(err)
+		}
+	}
+	if writer.calls != 2*writeFailureThreshold {
+		t.Errorf("write called %d times, want %d", writer.calls, 2*writeFailureThreshold)
+	}
+	if !c.IsWriteDown() {
+		t.Error("link not down after persistent write failures")
+	}
+}
+
+func TestIsTransientWriteError(t *testing.T) {
+	tests := []struct {
+		err  error
+		want bool
+	}{
+		{syscall.EAGAIN, true},
+		{fmt.Errorf("write: %w", syscall.EINTR), true},
+		{syscall.ENOBUFS, true},
+		{syscall.EPIPE, false},
+		{errors.New("closed"), false},
+	}
+	for _, tt := range tests {
+		if got := isTransientWriteError(tt.err); got != tt.want {
+			t.Errorf("isTransientWriteError(%v) = %v, want %v", tt.err, got, tt.want)
+		}
+	}
+}
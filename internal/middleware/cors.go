@@ -2,28 +2,78 @@ package middleware
 
 import (
 	"net/http"
+	"strings"
+
+	"github.com/flightpath-dev/flightpath-server/internal/config"
+)
+
+// Methods and headers allowed for origins whose policy doesn't list its own
+const (
+	defaultCORSMethods = "GET, POST, PUT, DELETE, OPTIONS"
+	defaultCORSHeaders = "Content-Type, Connect-Protocol-Version, Connect-Timeout-Ms, Authorization, Flightpath-Units, Flightpath-Velocity-Frame, Flightpath-Verify-Upload, Flightpath-Force-Disconnect, Flightpath-Force-Takeoff, Flightpath-Override-Mission, Flightpath-Operator, X-Request-Id"
 )
 
-// CORS creates a CORS middleware with the given allowed origins
-func CORS(allowedOrigins []string) func(http.Handler) http.Handler {
+// CORS creates a CORS middleware
+// An origin with an entry in policies gets that policy; other allowed origins
+// get the global policy (default methods and headers, with credentials). A "*"
+// policy applies to any origin not matched otherwise and, without
+// credentials, is answered with a literal "*".
+func CORS(allowedOrigins []string, policies map[string]config.CORSPolicy) func(http.Handler) http.Handler {
 	// Convert slice to map for faster lookup
 	originsMap := make(map[string]bool)
 	for _, origin := range allowedOrigins {
 		originsMap[origin] = true
 	}
 
+	global := config.CORSPolicy{Credentials: true}
+
+	// selectPolicy returns the origin's policy and the Allow-Origin value ("" if not allowed)
+	selectPolicy := func(origin string) (config.CORSPolicy, string) {
+		if origin == "" {
+			return global, ""
+		}
+		if policy, ok := policies[origin]; ok {
+			return policy, origin
+		}
+		if originsMap[origin] {
+			return global, origin
+		}
+		if policy, ok := policies["*"]; ok {
+			if policy.Credentials {
+				// Browsers reject "*" on credentialed requests
+				return policy, origin
+			}
+			return policy, "*"
+		}
+		if originsMap["*"] {
+			return global, origin
+		}
+		return global, ""
+	}
+
 	return func(next http.Handler) http.Handler {
 		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-			origin := r.Header.Get("Origin")
+			policy, allowOrigin := selectPolicy(r.Header.Get("Origin"))
 
 			// Check if origin is allowed
-			if origin != "" && (originsMap["*"] || originsMap[origin]) {
-				w.Header().Set("Access-Control-Allow-Origin", origin)
+			if allowOrigin != "" {
+				w.Header().Set("Access-Control-Allow-Origin", allowOrigin)
 			}
+			w.Header().Add("Vary", "Origin")
 
-			w.Header().Set("Access-Control-Allow-Methods", "GET, POST, PUT, DELETE, OPTIONS")
-			w.Header().Set("Access-Control-Allow-Headers", "Content-Type, Connect-Protocol-Version, Connect-Timeout-Ms, Authorization")
-			w.Header().Set("Access-Control-Allow-Credentials", "true")
+			methods, headers := defaultCORSMethods, defaultCORSHeaders
+			if len(policy.Methods) > 0 {
+				methods = strings.Join(policy.Methods, ", ")
+			}
+			if len(policy.Headers) > 0 {
+				headers = strings.Join(policy.Headers, ", ")
+			}
+
+			w.Header().Set("Access-Control-Allow-Methods", methods)
+			w.Header().Set("Access-Control-Allow-Headers", headers)
+			if policy.Credentials {
+				w.Header().Set("Access-Control-Allow-Credentials", "true")
+			}
 			w.Header().Set("Access-Control-Max-Age", "3600")
 
 			// Handle preflight requests
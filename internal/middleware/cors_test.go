@@ -0,0 +1,93 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/flightpath-dev/flightpath-server/internal/config"
+)
+
+func TestCORSPerOriginPolicies(t *testing.T) {
+	var served int
+	handler := CORS([]string{"https://app.example.com"}, map[string]config.CORSPolicy{
+		// The operator console sends credentials on a restricted set of requests
+		"https://ops.example.com": {
+			Credentials: true,
+			Methods:     []string{"GET", "POST"},
+			Headers:     []string{"Content-Type", "Authorization"},
+		},
+		// Public dashboards read telemetry without credentials
+		"*": {Methods: []string{"GET"}},
+	})(http.HandlerFunc(func(http.ResponseWriter, *http.Request) { served++ }))
+
+	tests := []struct {
+		name        string
+		origin      string
+		allowOrigin string
+		credentials string
+		methods     string
+		headers     string
+	}{
+		{"own policy", "https://ops.example.com", "https://ops.example.com", "true", "GET, POST", "Content-Type, Authorization"},
+		{"global policy", "https://app.example.com", "https://app.example.com", "true", defaultCORSMethods, defaultCORSHeaders},
+		{"wildcard policy", "https://public.example.org", "*", "", "GET", defaultCORSHeaders},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/api/v1/drones", nil)
+			req.Header.Set("Origin", tt.origin)
+			rec := httptest.NewRecorder()
+			handler.ServeHTTP(rec, req)
+
+			h := rec.Header()
+			if got := h.Get("Access-Control-Allow-Origin"); got != tt.allowOrigin {
+				t.Errorf("Allow-Origin = %q, want %q", got, tt.allowOrigin)
+			}
+			if got := h.Get("Access-Control-Allow-Credentials"); got != tt.credentials {
+				t.Errorf("Allow-Credentials = %q, want %q", got, tt.credentials)
+			}
+			if got := h.Get("Access-Control-Allow-Methods"); got != tt.methods {
+				t.Errorf("Allow-Methods = %q, want %q", got, tt.methods)
+			}
+			if got := h.Get("Access-Control-Allow-Headers"); got != tt.headers {
+				t.Errorf("Allow-Headers = %q, want %q", got, tt.headers)
+			}
+			if got := h.Get("Vary"); got != "Origin" {
+				t.Errorf("Vary = %q", got)
+			}
+		})
+	}
+	if served != len(tests) {
+		t.Errorf("%d requests served, want %d", served, len(tests))
+	}
+
+	// Preflights are answered here
+	req := httptest.NewRequest(http.MethodOptions, "/api/v1/drones/alpha/arm", nil)
+	req.Header.Set("Origin", "https://ops.example.com")
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+	if rec.Code != http.StatusOK || served != len(tests) {
+		t.Errorf("preflight: %d, passed on: %v", rec.Code, served != len(tests))
+	}
+}
+
+func TestCORSUnlistedOrigin(t *testing.T) {
+	handler := CORS([]string{"https://app.example.com"}, nil)(http.HandlerFunc(func(http.ResponseWriter, *http.Request) {}))
+
+	req := httptest.NewRequest(http.MethodGet, "/api/v1/drones", nil)
+	req.Header.Set("Origin", "https://evil.example.net")
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+	if got := rec.Header().Get("Access-Control-Allow-Origin"); got != "" {
+		t.Errorf("unlisted origin allowed: %q", got)
+	}
+
+	// A credentialed wildcard policy echoes the origin, as browsers reject "*" with credentials
+	handler = CORS(nil, map[string]config.CORSPolicy{"*": {Credentials: true}})(http.HandlerFunc(func(http.ResponseWriter, *http.Request) {}))
+	rec = httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+	if got := rec.Header().Get("Access-Control-Allow-Origin"); got != "https://evil.example.net" {
+		t.Errorf("credentialed wildcard: Allow-Origin = %q", got)
+	}
+}
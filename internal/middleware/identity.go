@@ -0,0 +1,19 @@
+package middleware
+
+import (
+	"net/http"
+
+	"github.com/flightpath-dev/flightpath-server/internal/audit"
+)
+
+// Identity stores the operator and request ID headers in the request context,
+// where the services' audit records pick them up (Connect and REST alike)
+func Identity(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		id := audit.Identity{
+			Operator:  r.Header.Get(audit.OperatorHeader),
+			RequestID: r.Header.Get(audit.RequestIDHeader),
+		}
+		next.ServeHTTP(w, r.WithContext(audit.WithIdentity(r.Context(), id)))
+	})
+}
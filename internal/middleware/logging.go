@@ -1,9 +1,18 @@
 package middleware
 
 import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
 	"log"
 	"net/http"
+	"strings"
 	"time"
+
+	"connectrpc.com/connect"
+
+	"github.com/flightpath-dev/flightpath-server/internal/audit"
 )
 
 // responseWriter wraps http.ResponseWriter to capture status code
@@ -31,8 +40,108 @@ func (rw *responseWriter) Write(b []byte) (int, error) {
 	return n, err
 }
 
+// Flush keeps streaming responses working through the wrapper; Connect
+// streams need an http.Flusher, not just http.ResponseController
+func (rw *responseWriter) Flush() {
+	if f, ok := rw.ResponseWriter.(http.Flusher); ok {
+		f.Flush()
+	}
+}
+
+// Unwrap exposes the underlying writer to http.ResponseController (needed to
+// flush streaming responses)
+func (rw *responseWriter) Unwrap() http.ResponseWriter {
+	return rw.ResponseWriter
+}
+
+// countingBody counts the request body bytes the handler reads
+type countingBody struct {
+	io.ReadCloser
+	read int64
+}
+
+func (b *countingBody) Read(p []byte) (int, error) {
+	n, err := b.ReadCloser.Read(p)
+	b.read += int64(n)
+	return n, err
+}
+
+// rpcOutcome is where ConnectAccessLog leaves an RPC's result for the access
+// log; Connect errors often ride on HTTP 200 (streams, gRPC), so the status
+// alone doesn't tell. Handlers run on the request's goroutine, so it needs no
+// lock.
+type rpcOutcome struct {
+	rpc  bool
+	code connect.Code // 0 (not an error) when the RPC succeeded
+}
+
+type rpcOutcomeKey struct{}
+
+func (o *rpcOutcome) record(err error) {
+	if o == nil {
+		return
+	}
+	o.rpc = true
+	if err != nil {
+		o.code = connect.CodeOf(err)
+	}
+}
+
+// codeName is the Connect code of the RPC ("ok" when it succeeded); "" for
+// plain HTTP requests
+func (o *rpcOutcome) codeName() string {
+	switch {
+	case !o.rpc:
+		return ""
+	case o.code == 0:
+		return "ok"
+	default:
+		return o.code.String()
+	}
+}
+
+// accessEntry is one access log line
+type accessEntry struct {
+	Method        string  `json:"method"`
+	Path          string  `json:"path"`
+	Status        int     `json:"status"`
+	Code          string  `json:"code,omitempty"` // Connect code, RPCs only
+	DurationMs    float64 `json:"duration_ms"`
+	Remote        string  `json:"remote"`
+	RequestID     string  `json:"request_id,omitempty"`
+	RequestBytes  int64   `json:"request_bytes"`
+	ResponseBytes int64   `json:"response_bytes"`
+}
+
+// format renders the entry as JSON or, for any other format, as
+// space-separated key=value pairs
+func (e accessEntry) format(format string) string {
+	if format == "json" {
+		b, err := json.Marshal(e)
+		if err == nil {
+			return "access " + string(b)
+		}
+	}
+
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "access method=%s path=%q status=%d", e.Method, e.Path, e.Status)
+	if e.Code != "" {
+		fmt.Fprintf(&sb, " code=%s", e.Code)
+	}
+	fmt.Fprintf(&sb, " duration_ms=%.3f remote=%s", e.DurationMs, e.Remote)
+	if e.RequestID != "" {
+		fmt.Fprintf(&sb, " request_id=%q", e.RequestID)
+	}
+	fmt.Fprintf(&sb, " request_bytes=%d response_bytes=%d", e.RequestBytes, e.ResponseBytes)
+	return sb.String()
+}
+
 // Logging creates a logging middleware
-func Logging(logger *log.Logger) func(http.Handler) http.Handler {
+// Each request gets one access line once it completes (streams when they
+// end): method, path, HTTP status, Connect code (with ConnectAccessLog on the
+// handler), duration, remote address, request ID and body sizes. format is
+// "json" for a JSON object after the log prefix, anything else for key=value.
+func Logging(logger *log.Logger, format string) func(http.Handler) http.Handler {
 	return func(next http.Handler) http.Handler {
 		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 			start := time.Now()
@@ -40,19 +149,69 @@ func Logging(logger *log.Logger) func(http.Handler) http.Handler {
 			// Wrap response writer to capture status
 			wrapped := newResponseWriter(w)
 
+			var body *countingBody
+			if r.Body != nil && r.Body != http.NoBody {
+				body = &countingBody{ReadCloser: r.Body}
+				r.Body = body
+			}
+
+			outcome := &rpcOutcome{}
+			ctx := context.WithValue(r.Context(), rpcOutcomeKey{}, outcome)
+
 			// Process request
-			next.ServeHTTP(wrapped, r)
+			next.ServeHTTP(wrapped, r.WithContext(ctx))
 
 			// Log request
-			duration := time.Since(start)
-			logger.Printf(
-				"%s %s %d %s %d bytes",
-				r.Method,
-				r.URL.Path,
-				wrapped.statusCode,
-				duration,
-				wrapped.written,
-			)
+			entry := accessEntry{
+				Method:        r.Method,
+				Path:          r.URL.Path,
+				Status:        wrapped.statusCode,
+				Code:          outcome.codeName(),
+				DurationMs:    float64(time.Since(start).Microseconds()) / 1000.0,
+				Remote:        r.RemoteAddr,
+				RequestID:     r.Header.Get(audit.RequestIDHeader),
+				ResponseBytes: wrapped.written,
+			}
+			if body != nil {
+				// A body the handler left unread still counts
+				entry.RequestBytes = max(body.read, r.ContentLength)
+			}
+			logger.Print(entry.format(format))
 		})
 	}
 }
+
+// ConnectAccessLog returns a handler option that hands each RPC's Connect code
+// to the Logging middleware
+// Add it before ConnectRecovery so it also sees the error a panic becomes.
+func ConnectAccessLog() connect.HandlerOption {
+	return connect.WithInterceptors(accessLogInterceptor{})
+}
+
+type accessLogInterceptor struct{}
+
+func (accessLogInterceptor) WrapUnary(next connect.UnaryFunc) connect.UnaryFunc {
+	return func(ctx context.Context, req connect.AnyRequest) (connect.AnyResponse, error) {
+		resp, err := next(ctx, req)
+		outcomeFrom(ctx).record(err)
+		return resp, err
+	}
+}
+
+func (accessLogInterceptor) WrapStreamingClient(next connect.StreamingClientFunc) connect.StreamingClientFunc {
+	return next
+}
+
+func (accessLogInterceptor) WrapStreamingHandler(next connect.StreamingHandlerFunc) connect.StreamingHandlerFunc {
+	return func(ctx context.Context, conn connect.StreamingHandlerConn) error {
+		err := next(ctx, conn)
+		outcomeFrom(ctx).record(err)
+		return err
+	}
+}
+
+// outcomeFrom returns the request's rpcOutcome; nil outside Logging
+func outcomeFrom(ctx context.Context) *rpcOutcome {
+	outcome, _ := ctx.Value(rpcOutcomeKey{}).(*rpcOutcome)
+	return outcome
+}
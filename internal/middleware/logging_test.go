@@ -0,0 +1,166 @@
+package middleware
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"connectrpc.com/connect"
+
+	drone "github.com/flightpath-dev/flightpath-proto/gen/go/drone/v1"
+	"github.com/flightpath-dev/flightpath-server/internal/audit"
+)
+
+// lineWriter passes on each log line, so a test can wait for the access line
+// written after the response has gone out
+type lineWriter chan string
+
+func (w lineWriter) Write(p []byte) (int, error) {
+	w <- string(p)
+	return len(p), nil
+}
+
+func nextEntry(t *testing.T, lines lineWriter) accessEntry {
+	t.Helper()
+	select {
+	case line := <-lines:
+		var entry accessEntry
+		if err := json.Unmarshal([]byte(strings.TrimPrefix(strings.TrimSpace(line), "access ")), &entry); err != nil {
+			t.Fatalf("access line %q: %v", line, err)
+		}
+		return entry
+	case <-time.After(5 * time.Second):
+		t.Fatal("no access line logged")
+		return accessEntry{}
+	}
+}
+
+func TestLoggingConnectCode(t *testing.T) {
+	const (
+		unary  = "/drone.v1.ConnectionService/Connect"
+		stream = "/drone.v1.TelemetryService/StreamTelemetry"
+	)
+	options := []connect.HandlerOption{
+		ConnectAccessLog(),
+		ConnectRecovery(discard),
+		connect.WithCodec(jsonCodec{}),
+	}
+
+	mux := http.NewServeMux()
+	mux.Handle(unary, connect.NewUnaryHandler(unary,
+		func(_ context.Context, req *connect.Request[drone.ConnectRequest]) (*connect.Response[drone.ConnectResponse], error) {
+			switch req.Msg.DroneId {
+			case "ghost":
+				return nil, connect.NewError(connect.CodeNotFound, errors.New("no such drone"))
+			case "boom":
+				panic("boom")
+			}
+			return connect.NewResponse(&drone.ConnectResponse{Success: true}), nil
+		},
+		options...,
+	))
+	mux.Handle(stream, connect.NewServerStreamHandler(stream,
+		func(_ context.Context, _ *connect.Request[drone.ConnectRequest], s *connect.ServerStream[drone.ConnectResponse]) error {
+			if err := s.Send(&drone.ConnectResponse{Success: true}); err != nil {
+				return err
+			}
+			return connect.NewError(connect.CodeUnavailable, errors.New("link lost"))
+		},
+		options...,
+	))
+	mux.HandleFunc("/health", func(w http.ResponseWriter, _ *http.Request) {
+		w.Write([]byte("OK")) //nolint:errcheck
+	})
+
+	lines := make(lineWriter, 10)
+	srv := httptest.NewServer(Logging(log.New(lines, "", 0), "json")(mux))
+	defer srv.Close()
+
+	call := func(droneID string) {
+		t.Helper()
+		client := connect.NewClient[drone.ConnectRequest, drone.ConnectResponse](srv.Client(), srv.URL+unary,
+			connect.WithCodec(jsonCodec{}))
+		req := connect.NewRequest(&drone.ConnectRequest{DroneId: droneID})
+		req.Header().Set(audit.RequestIDHeader, "req-"+droneID)
+		client.CallUnary(context.Background(), req) //nolint:errcheck
+	}
+
+	tests := []struct {
+		droneID string
+		status  int
+		code    string
+	}{
+		{"alpha", http.StatusOK, "ok"},
+		{"ghost", http.StatusNotFound, "not_found"},
+		{"boom", http.StatusInternalServerError, "internal"},
+	}
+	for _, tt := range tests {
+		call(tt.droneID)
+		entry := nextEntry(t, lines)
+		if entry.Status != tt.status || entry.Code != tt.code {
+			t.Errorf("%s: status %d, code %q; want %d, %q", tt.droneID, entry.Status, entry.Code, tt.status, tt.code)
+		}
+		if entry.Method != http.MethodPost || entry.Path != unary || entry.RequestID != "req-"+tt.droneID ||
+			entry.RequestBytes == 0 || entry.Remote == "" {
+			t.Errorf("%s: entry = %+v", tt.droneID, entry)
+		}
+	}
+
+	// A failed stream still answers HTTP 200; the code tells
+	client := connect.NewClient[drone.ConnectRequest, drone.ConnectResponse](srv.Client(), srv.URL+stream,
+		connect.WithCodec(jsonCodec{}))
+	s, err := client.CallServerStream(context.Background(), connect.NewRequest(&drone.ConnectRequest{}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for s.Receive() {
+	}
+	if connect.CodeOf(s.Err()) != connect.CodeUnavailable {
+		t.Errorf("stream through the middleware: %v, want unavailable", s.Err())
+	}
+	s.Close()
+	if entry := nextEntry(t, lines); entry.Status != http.StatusOK || entry.Code != "unavailable" || entry.ResponseBytes == 0 {
+		t.Errorf("stream: %+v, want status 200, code unavailable", entry)
+	}
+
+	// Plain HTTP routes have no code
+	resp, err := srv.Client().Get(srv.URL + "/health")
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if entry := nextEntry(t, lines); entry.Status != http.StatusOK || entry.Code != "" || entry.ResponseBytes != 2 {
+		t.Errorf("plain route: %+v", entry)
+	}
+}
+
+func TestAccessEntryFormat(t *testing.T) {
+	entry := accessEntry{
+		Method:        http.MethodPost,
+		Path:          "/drone.v1.ControlService/Arm",
+		Status:        http.StatusOK,
+		Code:          "failed_precondition",
+		DurationMs:    1.5,
+		Remote:        "10.0.0.2:51234",
+		RequestID:     "req-7",
+		RequestBytes:  2,
+		ResponseBytes: 120,
+	}
+	want := `access method=POST path="/drone.v1.ControlService/Arm" status=200 code=failed_precondition duration_ms=1.500 remote=10.0.0.2:51234 request_id="req-7" request_bytes=2 response_bytes=120`
+	if got := entry.format("text"); got != want {
+		t.Errorf("key=value:\n got %s\nwant %s", got, want)
+	}
+
+	// Plain HTTP requests leave code and request ID out
+	entry.Code, entry.RequestID = "", ""
+	if got := entry.format("json"); strings.Contains(got, `"code"`) || strings.Contains(got, `"request_id"`) ||
+		!strings.HasPrefix(got, `access {"method":"POST"`) {
+		t.Errorf("json: %s", got)
+	}
+}
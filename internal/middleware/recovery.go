@@ -1,28 +1,84 @@
 package middleware
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"log"
 	"net/http"
 	"runtime/debug"
+
+	"connectrpc.com/connect"
 )
 
-// Recovery creates a panic recovery middleware
+// recoveryWriter records whether the handler has started the response
+type recoveryWriter struct {
+	http.ResponseWriter
+	wroteHeader bool
+}
+
+func (rw *recoveryWriter) WriteHeader(code int) {
+	rw.wroteHeader = true
+	rw.ResponseWriter.WriteHeader(code)
+}
+
+func (rw *recoveryWriter) Write(b []byte) (int, error) {
+	rw.wroteHeader = true
+	return rw.ResponseWriter.Write(b)
+}
+
+// Flush keeps streaming responses working through the wrapper
+func (rw *recoveryWriter) Flush() {
+	rw.wroteHeader = true
+	if f, ok := rw.ResponseWriter.(http.Flusher); ok {
+		f.Flush()
+	}
+}
+
+// Unwrap exposes the underlying writer to http.ResponseController
+func (rw *recoveryWriter) Unwrap() http.ResponseWriter {
+	return rw.ResponseWriter
+}
+
+// Recovery creates a panic recovery middleware for plain HTTP routes
+// Connect RPCs recover inside the handler (see ConnectRecovery) so clients get
+// a proper Connect error; this is the fallback for everything else.
 func Recovery(logger *log.Logger) func(http.Handler) http.Handler {
 	return func(next http.Handler) http.Handler {
 		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			wrapped := &recoveryWriter{ResponseWriter: w}
+
 			defer func() {
 				if err := recover(); err != nil {
+					if err == http.ErrAbortHandler {
+						panic(err)
+					}
+
 					// Log the panic and stack trace
 					logger.Printf("PANIC: %v\n%s", err, debug.Stack())
 
+					// A partly written response can't be turned into an error;
+					// abort it so the client sees a broken response, not a truncated one
+					if wrapped.wroteHeader {
+						panic(http.ErrAbortHandler)
+					}
+
 					// Return 500 error
-					w.WriteHeader(http.StatusInternalServerError)
-					fmt.Fprintf(w, "Internal server error")
+					wrapped.WriteHeader(http.StatusInternalServerError)
+					fmt.Fprintf(wrapped, "Internal server error")
 				}
 			}()
 
-			next.ServeHTTP(w, r)
+			next.ServeHTTP(wrapped, r)
 		})
 	}
 }
+
+// ConnectRecovery returns a handler option that turns panics in RPC handlers
+// into CodeInternal errors, so Connect and gRPC clients get a parseable response
+func ConnectRecovery(logger *log.Logger) connect.HandlerOption {
+	return connect.WithRecover(func(ctx context.Context, spec connect.Spec, _ http.Header, p any) error {
+		logger.Printf("PANIC in %s: %v\n%s", spec.Procedure, p, debug.Stack())
+		return connect.NewError(connect.CodeInternal, errors.New("internal server error"))
+	})
+}
@@ -0,0 +1,76 @@
+package middleware
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"connectrpc.com/connect"
+
+	drone "github.com/flightpath-dev/flightpath-proto/gen/go/drone/v1"
+)
+
+var discard = log.New(io.Discard, "", 0)
+
+// jsonCodec encodes messages with encoding/json, so the test doesn't depend
+// on the generated protobuf code
+type jsonCodec struct{}
+
+func (jsonCodec) Name() string                      { return "json" }
+func (jsonCodec) Marshal(msg any) ([]byte, error)   { return json.Marshal(msg) }
+func (jsonCodec) Unmarshal(b []byte, msg any) error { return json.Unmarshal(b, msg) }
+
+func TestRecoveryPlainRoute(t *testing.T) {
+	handler := Recovery(discard)(http.HandlerFunc(func(http.ResponseWriter, *http.Request) {
+		panic("boom")
+	}))
+
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/health", nil))
+	if rec.Code != http.StatusInternalServerError || rec.Body.String() != "Internal server error" {
+		t.Errorf("response = %d %q", rec.Code, rec.Body.String())
+	}
+}
+
+func TestRecoveryAfterResponseStarted(t *testing.T) {
+	handler := Recovery(discard)(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusOK)
+		w.Write([]byte("partial")) //nolint:errcheck
+		panic("boom")
+	}))
+
+	// The response is aborted rather than a second status written
+	defer func() {
+		if p := recover(); p != http.ErrAbortHandler {
+			t.Errorf("panic = %v, want http.ErrAbortHandler", p)
+		}
+	}()
+	handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/health", nil))
+	t.Error("panic swallowed after the response started")
+}
+
+func TestConnectRecovery(t *testing.T) {
+	const procedure = "/drone.v1.ConnectionService/Connect"
+	mux := http.NewServeMux()
+	mux.Handle(procedure, connect.NewUnaryHandler(procedure,
+		func(context.Context, *connect.Request[drone.ConnectRequest]) (*connect.Response[drone.ConnectResponse], error) {
+			panic("boom")
+		},
+		ConnectRecovery(discard),
+		connect.WithCodec(jsonCodec{}),
+	))
+	srv := httptest.NewServer(Recovery(discard)(mux))
+	defer srv.Close()
+
+	client := connect.NewClient[drone.ConnectRequest, drone.ConnectResponse](srv.Client(), srv.URL+procedure,
+		connect.WithCodec(jsonCodec{}))
+	_, err := client.CallUnary(context.Background(), connect.NewRequest(&drone.ConnectRequest{DroneId: "alpha"}))
+	// The plain-text 500 fallback would come back as unknown
+	if connect.CodeOf(err) != connect.CodeInternal {
+		t.Errorf("panicking RPC: %v, want internal", err)
+	}
+}
@@ -0,0 +1,287 @@
+// Package missionfile converts missions to and from mission planner files:
+// QGroundControl .plan (JSON) and the QGC WPL 110 .waypoints text format.
+package missionfile
+
+import (
+	"bytes"
+	"fmt"
+	"math"
+
+	"github.com/bluenviron/gomavlib/v3/pkg/dialects/common"
+
+	drone "github.com/flightpath-dev/flightpath-proto/gen/go/drone/v1"
+	"github.com/flightpath-dev/flightpath-server/internal/mavlink"
+)
+
+// Format is a mission file format
+type Format string
+
+const (
+	FormatPlan      Format = "plan"      // QGroundControl .plan
+	FormatWaypoints Format = "waypoints" // QGC WPL 110 .waypoints
+)
+
+// ParseFormat parses a format name; "" means detect it from the content
+func ParseFormat(name string) (Format, error) {
+	switch format := Format(name); format {
+	case "", FormatPlan, FormatWaypoints:
+		return format, nil
+	default:
+		return "", fmt.Errorf("unknown mission file format: %q (must be plan or waypoints)", name)
+	}
+}
+
+// Detect guesses the format of file content
+func Detect(content []byte) Format {
+	if bytes.HasPrefix(bytes.TrimSpace(content), []byte(wplHeader)) {
+		return FormatWaypoints
+	}
+	return FormatPlan
+}
+
+// Mission is the content of a mission file
+type Mission struct {
+	Waypoints []*drone.Waypoint
+	Options   []mavlink.WaypointOptions // one per waypoint
+
+	// Planned home (altitude MSL); nil if the file has none
+	Home *drone.Position
+}
+
+// Parse reads a mission file; an empty format is detected from the content
+func Parse(format Format, content []byte) (*Mission, error) {
+	if format == "" {
+		format = Detect(content)
+	}
+	switch format {
+	case FormatPlan:
+		return parsePlan(content)
+	case FormatWaypoints:
+		return parseWaypoints(content)
+	default:
+		return nil, fmt.Errorf("unknown mission file format: %q", format)
+	}
+}
+
+// Encode writes a mission file
+// Without a Home, the first waypoint's position at altitude 0 stands in for it.
+func Encode(format Format, m *Mission) ([]byte, error) {
+	if len(m.Waypoints) != len(m.Options) {
+		return nil, fmt.Errorf("%d waypoints but %d waypoint options", len(m.Waypoints), len(m.Options))
+	}
+	switch format {
+	case FormatPlan:
+		return encodePlan(m)
+	case FormatWaypoints:
+		return encodeWaypoints(m)
+	default:
+		return nil, fmt.Errorf("unknown mission file format: %q", format)
+	}
+}
+
+// Extension returns the usual file extension for the format
+func (f Format) Extension() string {
+	return "." + string(f)
+}
+
+// commandActions maps the navigation commands a waypoint can express
+// LOITER_TURNS is a LOITER waypoint with loiter_turns set.
+var commandActions = map[common.MAV_CMD]drone.Waypoint_Action{
+	common.MAV_CMD_NAV_WAYPOINT:     drone.Waypoint_ACTION_WAYPOINT,
+	common.MAV_CMD_NAV_TAKEOFF:      drone.Waypoint_ACTION_TAKEOFF,
+	common.MAV_CMD_NAV_LAND:         drone.Waypoint_ACTION_LAND,
+	common.MAV_CMD_NAV_LOITER_UNLIM: drone.Waypoint_ACTION_LOITER,
+	common.MAV_CMD_NAV_LOITER_TIME:  drone.Waypoint_ACTION_HOLD,
+	common.MAV_CMD_NAV_LOITER_TURNS: drone.Waypoint_ACTION_LOITER,
+}
+
+// actionCommand returns the MAV_CMD a waypoint is uploaded as, matching the client
+func actionCommand(action drone.Waypoint_Action, opts mavlink.WaypointOptions) common.MAV_CMD {
+	switch action {
+	case drone.Waypoint_ACTION_TAKEOFF:
+		return common.MAV_CMD_NAV_TAKEOFF
+	case drone.Waypoint_ACTION_LAND:
+		return common.MAV_CMD_NAV_LAND
+	case drone.Waypoint_ACTION_LOITER, drone.Waypoint_ACTION_HOLD:
+		if opts.LoiterTurns > 0 {
+			return common.MAV_CMD_NAV_LOITER_TURNS
+		}
+		if action == drone.Waypoint_ACTION_HOLD {
+			return common.MAV_CMD_NAV_LOITER_TIME
+		}
+		return common.MAV_CMD_NAV_LOITER_UNLIM
+	default:
+		return common.MAV_CMD_NAV_WAYPOINT
+	}
+}
+
+// isLoiter reports whether a waypoint action uses the loiter radius and turns
+func isLoiter(action drone.Waypoint_Action) bool {
+	return action == drone.Waypoint_ACTION_LOITER || action == drone.Waypoint_ACTION_HOLD
+}
+
+// item is one mission item in file terms (MAV_CMD params, degrees)
+type item struct {
+	command  common.MAV_CMD
+	frame    common.MAV_FRAME
+	params   [4]float64
+	lat, lon float64
+	alt      float64
+
+	autocontinue bool
+}
+
+// toWaypoint converts a file item, rejecting what a waypoint can't express
+// Params map as the client uploads them: 1 hold time (turns for LOITER_TURNS),
+// 2 acceptance radius, 3 loiter radius, 4 heading. Waypoints have no "unset"
+// value, so NaN params (null in .plan) become 0.
+func (it item) toWaypoint(seq int) (*drone.Waypoint, mavlink.WaypointOptions, error) {
+	var opts mavlink.WaypointOptions
+
+	action, ok := commandActions[it.command]
+	if !ok {
+		return nil, opts, fmt.Errorf("item %d: command %d (%s) has no waypoint equivalent",
+			seq, it.command, it.command)
+	}
+	frame, ok := mavlink.AltitudeFrameFromMAV(it.frame)
+	if !ok {
+		return nil, opts, fmt.Errorf("item %d: frame %d is not a global position frame", seq, it.frame)
+	}
+
+	opts.AltitudeFrame = frame
+	opts.Autocontinue = &it.autocontinue
+	holdTime := zeroNaN(it.params[0])
+	if isLoiter(action) {
+		opts.LoiterRadius = zeroNaN(it.params[2])
+	}
+	if it.command == common.MAV_CMD_NAV_LOITER_TURNS {
+		opts.LoiterTurns = holdTime
+		holdTime = 0
+	}
+
+	return &drone.Waypoint{
+		Sequence: int32(seq),
+		Position: &drone.Position{
+			Latitude:  it.lat,
+			Longitude: it.lon,
+			Altitude:  it.alt,
+		},
+		Action:           action,
+		HoldTimeSec:      holdTime,
+		AcceptanceRadius: zeroNaN(it.params[1]),
+		Heading:          zeroNaN(it.params[3]),
+	}, opts, nil
+}
+
+func zeroNaN(v float64) float64 {
+	if math.IsNaN(v) {
+		return 0
+	}
+	return v
+}
+
+// fromWaypoint converts a waypoint to a file item
+// Unset autocontinue is written as set; Mission.Options should carry the defaults.
+func fromWaypoint(wp *drone.Waypoint, opts mavlink.WaypointOptions) item {
+	command := actionCommand(wp.Action, opts)
+	params := [4]float64{wp.HoldTimeSec, wp.AcceptanceRadius, 0, wp.Heading}
+	if isLoiter(wp.Action) {
+		params[2] = opts.LoiterRadius
+	}
+	if command == common.MAV_CMD_NAV_LOITER_TURNS {
+		params[0] = opts.LoiterTurns
+	}
+
+	return item{
+		command: command,
+		frame:   opts.AltitudeFrame.MAVFrame(),
+		params:  params,
+		lat:     wp.Position.Latitude,
+		lon:     wp.Position.Longitude,
+		alt:     wp.Position.Altitude,
+
+		autocontinue: opts.Autocontinue == nil || *opts.Autocontinue,
+	}
+}
+
+// cameraItem converts a waypoint's camera action to a file item
+func cameraItem(action mavlink.CameraAction) item {
+	mi := action.MissionItem()
+	return item{
+		command: mi.Command,
+		frame:   mi.Frame,
+		params:  [4]float64{float64(mi.Param1), float64(mi.Param2), float64(mi.Param3), float64(mi.Param4)},
+		lat:     float64(mi.X),
+		lon:     float64(mi.Y),
+		alt:     float64(mi.Z),
+
+		autocontinue: mi.Autocontinue,
+	}
+}
+
+// cameraAction recognizes a file item as a camera action
+// Lat/lon/alt are params 5-7, which command items don't use as a position.
+func (it item) cameraAction() (mavlink.CameraAction, bool) {
+	return mavlink.CameraActionFromItem(mavlink.MissionItem{
+		Command: it.command,
+		Param1:  float32(zeroNaN(it.params[0])),
+		Param2:  float32(zeroNaN(it.params[1])),
+		Param3:  float32(zeroNaN(it.params[2])),
+		Param4:  float32(zeroNaN(it.params[3])),
+		X:       int32(zeroNaN(it.lat)),
+		Y:       int32(zeroNaN(it.lon)),
+		Z:       float32(zeroNaN(it.alt)),
+	})
+}
+
+// add appends a file item (index is its position in the file, for errors)
+// Camera commands become camera actions of the waypoint before them.
+func (m *Mission) add(it item, index int) error {
+	if action, ok := it.cameraAction(); ok {
+		if len(m.Waypoints) == 0 {
+			return fmt.Errorf("item %d: camera command %s before the first waypoint", index, it.command)
+		}
+		last := &m.Options[len(m.Options)-1]
+		last.Camera = append(last.Camera, action)
+		return nil
+	}
+
+	wp, opts, err := it.toWaypoint(index)
+	if err != nil {
+		return err
+	}
+	wp.Sequence = int32(len(m.Waypoints))
+	m.Waypoints = append(m.Waypoints, wp)
+	m.Options = append(m.Options, opts)
+	return nil
+}
+
+// items returns the file items of the mission: each waypoint followed by its
+// camera actions
+func (m *Mission) items() ([]item, error) {
+	items := make([]item, 0, mavlink.MissionItemCount(len(m.Waypoints), m.Options))
+	for i, wp := range m.Waypoints {
+		if wp.Position == nil {
+			return nil, fmt.Errorf("waypoint %d: missing position", i)
+		}
+		items = append(items, fromWaypoint(wp, m.Options[i]))
+		for _, action := range m.Options[i].Camera {
+			items = append(items, cameraItem(action))
+		}
+	}
+	return items, nil
+}
+
+// home returns the mission's home, or the first waypoint's position at altitude 0
+func (m *Mission) home() *drone.Position {
+	if m.Home != nil {
+		return m.Home
+	}
+	if len(m.Waypoints) > 0 && m.Waypoints[0].Position != nil {
+		return &drone.Position{
+			Latitude:  m.Waypoints[0].Position.Latitude,
+			Longitude: m.Waypoints[0].Position.Longitude,
+		}
+	}
+	return &drone.Position{}
+}
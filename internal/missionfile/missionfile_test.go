@@ -0,0 +1,249 @@
+package missionfile
+
+import (
+	"os"
+	"reflect"
+	"strings"
+	"testing"
+
+	drone "github.com/flightpath-dev/flightpath-proto/gen/go/drone/v1"
+	"github.com/flightpath-dev/flightpath-server/internal/mavlink"
+)
+
+// readPlan parses testdata/survey.plan, a QGroundControl export with a
+// takeoff, trigger-distance survey legs, a timed loiter and a landing
+func readPlan(t *testing.T) *Mission {
+	t.Helper()
+	content, err := os.ReadFile("testdata/survey.plan")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if format := Detect(content); format != FormatPlan {
+		t.Fatalf("detected %s", format)
+	}
+	m, err := Parse("", content)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return m
+}
+
+// sameMission fails unless got has want's waypoints, options and home
+func sameMission(t *testing.T, got, want *Mission) {
+	t.Helper()
+	if len(got.Waypoints) != len(want.Waypoints) {
+		t.Fatalf("%d waypoints, want %d", len(got.Waypoints), len(want.Waypoints))
+	}
+	for i, w := range want.Waypoints {
+		g := got.Waypoints[i]
+		if g.Sequence != w.Sequence || g.Action != w.Action || g.HoldTimeSec != w.HoldTimeSec ||
+			g.AcceptanceRadius != w.AcceptanceRadius || g.Heading != w.Heading ||
+			*g.Position != *w.Position {
+			t.Errorf("waypoint %d = %+v at %+v, want %+v at %+v", i, g, g.Position, w, w.Position)
+		}
+	}
+	if !reflect.DeepEqual(got.Options, want.Options) {
+		t.Errorf("options = %+v, want %+v", got.Options, want.Options)
+	}
+	if (got.Home == nil) != (want.Home == nil) || got.Home != nil && *got.Home != *want.Home {
+		t.Errorf("home = %+v, want %+v", got.Home, want.Home)
+	}
+}
+
+func TestParsePlan(t *testing.T) {
+	m := readPlan(t)
+
+	wantActions := []drone.Waypoint_Action{
+		drone.Waypoint_ACTION_TAKEOFF,
+		drone.Waypoint_ACTION_WAYPOINT,
+		drone.Waypoint_ACTION_WAYPOINT,
+		drone.Waypoint_ACTION_HOLD,
+		drone.Waypoint_ACTION_LAND,
+	}
+	if len(m.Waypoints) != len(wantActions) {
+		t.Fatalf("%d waypoints, want %d", len(m.Waypoints), len(wantActions))
+	}
+	for i, wp := range m.Waypoints {
+		if wp.Sequence != int32(i) || wp.Action != wantActions[i] {
+			t.Errorf("waypoint %d: sequence %d, action %s, want %s", i, wp.Sequence, wp.Action, wantActions[i])
+		}
+	}
+
+	// Lat/lon are degrees already; the null takeoff yaw is 0
+	if p := m.Waypoints[1].Position; p.Latitude != 47.3985123 || p.Longitude != 8.5461024 || p.Altitude != 30 {
+		t.Errorf("waypoint 1 at %+v", p)
+	}
+	if m.Waypoints[1].AcceptanceRadius != 2 || m.Waypoints[0].Heading != 0 || m.Waypoints[2].Heading != 90 {
+		t.Errorf("params: acceptance %v, headings %v and %v",
+			m.Waypoints[1].AcceptanceRadius, m.Waypoints[0].Heading, m.Waypoints[2].Heading)
+	}
+	if hold := m.Waypoints[3]; hold.HoldTimeSec != 10 || m.Options[3].LoiterRadius != 15 {
+		t.Errorf("loiter: %v s, radius %v", hold.HoldTimeSec, m.Options[3].LoiterRadius)
+	}
+
+	// Frame 0 is MSL, 3 relative to home
+	for i, want := range []mavlink.AltitudeFrame{
+		mavlink.AltitudeRelative, mavlink.AltitudeRelative, mavlink.AltitudeMSL, mavlink.AltitudeRelative, mavlink.AltitudeRelative,
+	} {
+		if m.Options[i].AltitudeFrame != want {
+			t.Errorf("waypoint %d frame = %s, want %s", i, m.Options[i].AltitudeFrame, want)
+		}
+	}
+
+	// Camera commands attach to the waypoint before them
+	wantCamera := [][]mavlink.CameraAction{
+		nil,
+		{{Action: mavlink.CameraTriggerDistance, Distance: 12.5}},
+		{{Action: mavlink.CameraTriggerDistance}},
+		nil,
+		nil,
+	}
+	for i, want := range wantCamera {
+		if !reflect.DeepEqual(m.Options[i].Camera, want) {
+			t.Errorf("waypoint %d camera = %+v, want %+v", i, m.Options[i].Camera, want)
+		}
+	}
+
+	if m.Home == nil || *m.Home != (drone.Position{Latitude: 47.3977419, Longitude: 8.5455938, Altitude: 488.1}) {
+		t.Errorf("home = %+v", m.Home)
+	}
+}
+
+func TestPlanRoundTrip(t *testing.T) {
+	m := readPlan(t)
+
+	for _, format := range []Format{FormatPlan, FormatWaypoints} {
+		t.Run(string(format), func(t *testing.T) {
+			content, err := Encode(format, m)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if detected := Detect(content); detected != format {
+				t.Errorf("encoded file detected as %s", detected)
+			}
+			back, err := Parse(format, content)
+			if err != nil {
+				t.Fatalf("%v\n%s", err, content)
+			}
+			sameMission(t, back, m)
+		})
+	}
+}
+
+func TestParseWaypoints(t *testing.T) {
+	content := "QGC WPL 110\n" +
+		"0\t1\t0\t16\t0\t0\t0\t0\t47.3977419\t8.5455938\t488.1\t1\n" +
+		"1\t0\t3\t22\t0\t0\t0\t0\t47.3977419\t8.5455938\t20\t1\n" +
+		"2\t0\t10\t18\t3\t0\t25\t0\t47.3985123\t8.5461024\t40\t0\n" +
+		"3\t0\t2\t2000\t0\t5\t0\t0\t0\t0\t0\t1\n"
+
+	m, err := Parse("", []byte(content))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(m.Waypoints) != 2 || m.Home == nil || m.Home.Altitude != 488.1 {
+		t.Fatalf("mission = %+v, home %+v", m.Waypoints, m.Home)
+	}
+
+	// LOITER_TURNS in the terrain frame, not continuing, then an interval capture
+	loiter, opts := m.Waypoints[1], m.Options[1]
+	if loiter.Action != drone.Waypoint_ACTION_LOITER || opts.LoiterTurns != 3 || opts.LoiterRadius != 25 ||
+		opts.AltitudeFrame != mavlink.AltitudeTerrain || *opts.Autocontinue {
+		t.Errorf("loiter = %+v, options %+v", loiter, opts)
+	}
+	if want := []mavlink.CameraAction{{Action: mavlink.CameraStartInterval, Interval: 5}}; !reflect.DeepEqual(opts.Camera, want) {
+		t.Errorf("camera = %+v", opts.Camera)
+	}
+
+	encoded, err := Encode(FormatWaypoints, m)
+	if err != nil {
+		t.Fatal(err)
+	}
+	back, err := Parse(FormatWaypoints, encoded)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sameMission(t, back, m)
+}
+
+func TestParseRejects(t *testing.T) {
+	tests := []struct {
+		name    string
+		format  Format
+		content string
+		want    string
+	}{
+		{"return to launch", FormatWaypoints,
+			"QGC WPL 110\n1\t0\t2\t20\t0\t0\t0\t0\t0\t0\t0\t1\n", "no waypoint equivalent"},
+		{"mission frame", FormatWaypoints,
+			"QGC WPL 110\n1\t0\t2\t16\t0\t0\t0\t0\t47\t8\t30\t1\n", "not a global position frame"},
+		{"camera first", FormatWaypoints,
+			"QGC WPL 110\n1\t0\t2\t2001\t0\t0\t0\t0\t0\t0\t0\t1\n", "before the first waypoint"},
+		{"short line", FormatWaypoints, "QGC WPL 110\n1\t0\t3\t16\n", "expected 12 fields"},
+		{"no header", FormatWaypoints, "1\t0\t3\t16\t0\t0\t0\t0\t47\t8\t30\t1\n", "header"},
+		{"survey", FormatPlan,
+			`{"fileType":"Plan","mission":{"version":2,"items":[{"type":"ComplexItem","complexItemType":"survey"}]}}`,
+			"only simple items"},
+		{"no position", FormatPlan,
+			`{"fileType":"Plan","mission":{"version":2,"items":[{"type":"SimpleItem","command":16,"frame":3,
+			"params":[0,0,0,0,null,null,30]}]}}`, "missing position"},
+		{"old mission version", FormatPlan, `{"fileType":"Plan","mission":{"version":1}}`, "mission version"},
+		{"not a plan", FormatPlan, `{"fileType":"GeoFence"}`, "not a QGroundControl plan"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := Parse(tt.format, []byte(tt.content))
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("Parse: %v, want %q", err, tt.want)
+			}
+		})
+	}
+
+	if _, err := ParseFormat("kml"); err == nil {
+		t.Error("unknown format accepted")
+	}
+}
+
+func TestLoiterRoundTrip(t *testing.T) {
+	tests := []struct {
+		name   string
+		action drone.Waypoint_Action
+		hold   float64
+		opts   mavlink.WaypointOptions
+		want   drone.Waypoint_Action // turns-based loiters read back as LOITER
+	}{
+		{"loiter", drone.Waypoint_ACTION_LOITER, 0, mavlink.WaypointOptions{LoiterRadius: -40}, drone.Waypoint_ACTION_LOITER},
+		{"hold", drone.Waypoint_ACTION_HOLD, 30, mavlink.WaypointOptions{LoiterRadius: 25}, drone.Waypoint_ACTION_HOLD},
+		{"turns", drone.Waypoint_ACTION_LOITER, 0, mavlink.WaypointOptions{LoiterRadius: 25, LoiterTurns: 3}, drone.Waypoint_ACTION_LOITER},
+		{"hold turns", drone.Waypoint_ACTION_HOLD, 0, mavlink.WaypointOptions{LoiterTurns: 2}, drone.Waypoint_ACTION_LOITER},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.opts.AltitudeFrame = mavlink.AltitudeRelative
+			autocontinue := true
+			tt.opts.Autocontinue = &autocontinue
+			m := &Mission{
+				Waypoints: []*drone.Waypoint{{
+					Position:    &drone.Position{Latitude: 47, Longitude: 8, Altitude: 30},
+					Action:      tt.action,
+					HoldTimeSec: tt.hold,
+				}},
+				Options: []mavlink.WaypointOptions{tt.opts},
+			}
+			content, err := Encode(FormatWaypoints, m)
+			if err != nil {
+				t.Fatal(err)
+			}
+			back, err := Parse(FormatWaypoints, content)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if wp := back.Waypoints[0]; wp.Action != tt.want || wp.HoldTimeSec != tt.hold {
+				t.Errorf("waypoint = %s holding %v s, want %s holding %v s", wp.Action, wp.HoldTimeSec, tt.want, tt.hold)
+			}
+			if !reflect.DeepEqual(back.Options[0], tt.opts) {
+				t.Errorf("options = %+v, want %+v", back.Options[0], tt.opts)
+			}
+		})
+	}
+}
@@ -0,0 +1,153 @@
+package missionfile
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+
+	"github.com/bluenviron/gomavlib/v3/pkg/dialects/common"
+
+	drone "github.com/flightpath-dev/flightpath-proto/gen/go/drone/v1"
+)
+
+// QGroundControl .plan layout (file version 1, mission version 2)
+// Only SimpleItems are read; complex items (surveys, corridor scans) are
+// generated by QGC and have no item list to convert.
+type planFile struct {
+	FileType      string          `json:"fileType"`
+	Version       int             `json:"version"`
+	GroundStation string          `json:"groundStation"`
+	Mission       planMission     `json:"mission"`
+	GeoFence      json.RawMessage `json:"geoFence"`
+	RallyPoints   json.RawMessage `json:"rallyPoints"`
+}
+
+type planMission struct {
+	Version             int        `json:"version"`
+	FirmwareType        int        `json:"firmwareType"`
+	VehicleType         int        `json:"vehicleType"`
+	CruiseSpeed         float64    `json:"cruiseSpeed"`
+	HoverSpeed          float64    `json:"hoverSpeed"`
+	PlannedHomePosition []float64  `json:"plannedHomePosition"` // lat, lon, alt (MSL)
+	Items               []planItem `json:"items"`
+}
+
+type planItem struct {
+	Type            string `json:"type"`
+	ComplexItemType string `json:"complexItemType,omitempty"`
+	AutoContinue    bool   `json:"autoContinue"`
+	Command         int    `json:"command"`
+	DoJumpID        int    `json:"doJumpId"`
+	Frame           int    `json:"frame"`
+
+	// Seven MAV_CMD params; null is NaN (e.g. "keep the current heading")
+	Params []*float64 `json:"params"`
+}
+
+// Empty geofence and rally point sections, as QGC writes them
+var (
+	planEmptyGeoFence    = json.RawMessage(`{"circles":[],"polygons":[],"version":2}`)
+	planEmptyRallyPoints = json.RawMessage(`{"points":[],"version":2}`)
+)
+
+func parsePlan(content []byte) (*Mission, error) {
+	var plan planFile
+	if err := json.Unmarshal(content, &plan); err != nil {
+		return nil, fmt.Errorf("invalid .plan JSON: %w", err)
+	}
+	if plan.FileType != "Plan" {
+		return nil, fmt.Errorf("not a QGroundControl plan: fileType %q", plan.FileType)
+	}
+	if plan.Mission.Version != 2 {
+		return nil, fmt.Errorf("unsupported .plan mission version %d (need 2)", plan.Mission.Version)
+	}
+
+	m := &Mission{}
+	if home := plan.Mission.PlannedHomePosition; len(home) == 3 {
+		m.Home = &drone.Position{Latitude: home[0], Longitude: home[1], Altitude: home[2]}
+	}
+
+	for i, pi := range plan.Mission.Items {
+		if pi.Type != "SimpleItem" {
+			return nil, fmt.Errorf("item %d: %s %q isn't supported; only simple items can be imported",
+				i, pi.Type, pi.ComplexItemType)
+		}
+		if len(pi.Params) != 7 {
+			return nil, fmt.Errorf("item %d: expected 7 params, got %d", i, len(pi.Params))
+		}
+		for p := 4; p < 7; p++ {
+			if pi.Params[p] == nil {
+				return nil, fmt.Errorf("item %d: missing position (param %d)", i, p+1)
+			}
+		}
+
+		it := item{
+			command: common.MAV_CMD(pi.Command),
+			frame:   common.MAV_FRAME(pi.Frame),
+			lat:     *pi.Params[4],
+			lon:     *pi.Params[5],
+			alt:     *pi.Params[6],
+
+			autocontinue: pi.AutoContinue,
+		}
+		for p := range it.params {
+			it.params[p] = math.NaN()
+			if pi.Params[p] != nil {
+				it.params[p] = *pi.Params[p]
+			}
+		}
+
+		if err := m.add(it, i); err != nil {
+			return nil, err
+		}
+	}
+	return m, nil
+}
+
+func encodePlan(m *Mission) ([]byte, error) {
+	items, err := m.items()
+	if err != nil {
+		return nil, err
+	}
+
+	home := m.home()
+	plan := planFile{
+		FileType:      "Plan",
+		Version:       1,
+		GroundStation: "Flightpath",
+		Mission: planMission{
+			Version:             2,
+			FirmwareType:        int(common.MAV_AUTOPILOT_PX4),
+			VehicleType:         int(common.MAV_TYPE_QUADROTOR),
+			PlannedHomePosition: []float64{home.Latitude, home.Longitude, home.Altitude},
+			Items:               make([]planItem, len(items)),
+		},
+		GeoFence:    planEmptyGeoFence,
+		RallyPoints: planEmptyRallyPoints,
+	}
+
+	for i, it := range items {
+		plan.Mission.Items[i] = planItem{
+			Type:         "SimpleItem",
+			AutoContinue: it.autocontinue,
+			Command:      int(it.command),
+			DoJumpID:     i + 1,
+			Frame:        int(it.frame),
+			Params: []*float64{
+				planParam(it.params[0]), planParam(it.params[1]),
+				planParam(it.params[2]), planParam(it.params[3]),
+				planParam(it.lat), planParam(it.lon), planParam(it.alt),
+			},
+		}
+	}
+
+	return json.MarshalIndent(plan, "", "    ")
+}
+
+// planParam writes NaN as null, which is how .plan files carry it
+func planParam(v float64) *float64 {
+	if math.IsNaN(v) {
+		return nil
+	}
+	return &v
+}
@@ -0,0 +1,127 @@
+package missionfile
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/bluenviron/gomavlib/v3/pkg/dialects/common"
+
+	drone "github.com/flightpath-dev/flightpath-proto/gen/go/drone/v1"
+)
+
+// wplHeader starts a QGC WPL 110 file
+// Each following line is one item, tab-separated:
+// seq, current, frame, command, param1-4, latitude, longitude, altitude, autocontinue.
+// Item 0 is the home position, not part of the mission.
+const wplHeader = "QGC WPL 110"
+
+func parseWaypoints(content []byte) (*Mission, error) {
+	scanner := bufio.NewScanner(bytes.NewReader(content))
+
+	if !scanner.Scan() || strings.TrimSpace(scanner.Text()) != wplHeader {
+		return nil, fmt.Errorf("not a .waypoints file: missing %q header", wplHeader)
+	}
+
+	m := &Mission{}
+	line := 1
+	for scanner.Scan() {
+		line++
+		fields := strings.Fields(scanner.Text())
+		if len(fields) == 0 {
+			continue
+		}
+		if len(fields) != 12 {
+			return nil, fmt.Errorf("line %d: expected 12 fields, got %d", line, len(fields))
+		}
+
+		values := make([]float64, len(fields))
+		for i, field := range fields {
+			v, err := strconv.ParseFloat(field, 64)
+			if err != nil {
+				return nil, fmt.Errorf("line %d: field %d: %v", line, i+1, err)
+			}
+			values[i] = v
+		}
+
+		if values[0] == 0 {
+			m.Home = &drone.Position{Latitude: values[8], Longitude: values[9], Altitude: values[10]}
+			continue
+		}
+
+		it := item{
+			command: common.MAV_CMD(values[3]),
+			frame:   common.MAV_FRAME(values[2]),
+			params:  [4]float64{values[4], values[5], values[6], values[7]},
+			lat:     values[8],
+			lon:     values[9],
+			alt:     values[10],
+
+			autocontinue: values[11] != 0,
+		}
+		if err := m.add(it, int(values[0])); err != nil {
+			return nil, fmt.Errorf("line %d: %w", line, err)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+func encodeWaypoints(m *Mission) ([]byte, error) {
+	items, err := m.items()
+	if err != nil {
+		return nil, err
+	}
+
+	var b bytes.Buffer
+	b.WriteString(wplHeader + "\n")
+
+	home := m.home()
+	writeWPLLine(&b, 0, true, item{
+		command: common.MAV_CMD_NAV_WAYPOINT,
+		frame:   common.MAV_FRAME_GLOBAL,
+		lat:     home.Latitude,
+		lon:     home.Longitude,
+		alt:     home.Altitude,
+
+		autocontinue: true,
+	})
+
+	for i, it := range items {
+		writeWPLLine(&b, i+1, false, it)
+	}
+	return b.Bytes(), nil
+}
+
+func writeWPLLine(b *bytes.Buffer, seq int, current bool, it item) {
+	fields := []string{
+		strconv.Itoa(seq),
+		wplFlag(current),
+		strconv.Itoa(int(it.frame)),
+		strconv.Itoa(int(it.command)),
+		formatWPL(it.params[0]),
+		formatWPL(it.params[1]),
+		formatWPL(it.params[2]),
+		formatWPL(it.params[3]),
+		formatWPL(it.lat),
+		formatWPL(it.lon),
+		formatWPL(it.alt),
+		wplFlag(it.autocontinue),
+	}
+	b.WriteString(strings.Join(fields, "\t") + "\n")
+}
+
+func formatWPL(v float64) string {
+	return strconv.FormatFloat(v, 'f', -1, 64)
+}
+
+func wplFlag(set bool) string {
+	if set {
+		return "1"
+	}
+	return "0"
+}
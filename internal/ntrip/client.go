@@ -0,0 +1,265 @@
+package ntrip
+
+import (
+	"bufio"
+	"context"
+	"encoding/base64"
+	"fmt"
+	"math"
+	"net"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/flightpath-dev/flightpath-server/internal/mavlink"
+)
+
+const (
+	// DefaultPort is the registered NTRIP caster port
+	DefaultPort = 2101
+
+	// DefaultGGAInterval is how often the vehicle position is reported to the caster
+	DefaultGGAInterval = 10 * time.Second
+
+	// dialTimeout bounds connecting to the caster and reading its response
+	dialTimeout = 10 * time.Second
+
+	// readTimeout ends a session whose caster has stopped sending corrections
+	readTimeout = 30 * time.Second
+
+	// scanBufferLen holds the largest RTCM3 frame (6 + 1023 bytes) with room to resync
+	scanBufferLen = 4096
+
+	userAgent = "NTRIP flightpath-server"
+)
+
+// Config is an NTRIP caster mountpoint
+type Config struct {
+	Host       string
+	Port       int // 0 uses DefaultPort
+	Mountpoint string
+	Username   string
+	Password   string
+
+	// 0 uses DefaultGGAInterval; negative sends no GGA
+	GGAInterval time.Duration
+}
+
+// Position is the vehicle position reported to the caster in GGA sentences
+type Position struct {
+	Latitude   float64 // degrees
+	Longitude  float64 // degrees
+	Altitude   float64 // meters (MSL)
+	Satellites int
+}
+
+// Vehicle is where a session gets its position from and sends corrections to
+type Vehicle interface {
+	// Position returns the current position, or false without a fix
+	Position() (Position, bool)
+
+	// InjectRTCM forwards one RTCM3 frame; an error ends the session
+	InjectRTCM(frame []byte) error
+}
+
+// Stats counts what a session forwarded
+type Stats struct {
+	Frames  int
+	Bytes   int
+	Skipped int // frames over mavlink.MaxRTCMMessageLen
+}
+
+// Stream connects to the caster and forwards corrections to the vehicle until
+// ctx is cancelled, the caster stops sending, or the vehicle rejects a frame
+// It always returns a non-nil error describing why the session ended.
+func Stream(ctx context.Context, cfg Config, vehicle Vehicle) (Stats, error) {
+	var stats Stats
+
+	port := cfg.Port
+	if port == 0 {
+		port = DefaultPort
+	}
+	address := net.JoinHostPort(cfg.Host, strconv.Itoa(port))
+
+	dialer := net.Dialer{Timeout: dialTimeout}
+	conn, err := dialer.DialContext(ctx, "tcp", address)
+	if err != nil {
+		return stats, fmt.Errorf("failed to connect to caster %s: %w", address, err)
+	}
+	defer conn.Close()
+
+	// Closing the connection unblocks the reader when ctx ends
+	sessionCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	go func() {
+		<-sessionCtx.Done()
+		conn.Close()
+	}()
+
+	conn.SetDeadline(time.Now().Add(dialTimeout))
+	if _, err := conn.Write([]byte(request(cfg))); err != nil {
+		return stats, fmt.Errorf("failed to send request: %w", err)
+	}
+
+	reader := bufio.NewReader(conn)
+	if err := readResponse(reader); err != nil {
+		return stats, err
+	}
+	conn.SetDeadline(time.Time{})
+
+	ggaInterval := cfg.GGAInterval
+	if ggaInterval == 0 {
+		ggaInterval = DefaultGGAInterval
+	}
+	if ggaInterval > 0 {
+		go sendGGA(sessionCtx, conn, vehicle, ggaInterval)
+	}
+
+	scanner := bufio.NewScanner(&deadlineReader{conn: conn, r: reader})
+	scanner.Buffer(make([]byte, scanBufferLen), scanBufferLen)
+	scanner.Split(mavlink.ScanRTCM3Frames)
+
+	for scanner.Scan() {
+		frame := scanner.Bytes()
+		if len(frame) > mavlink.MaxRTCMMessageLen {
+			stats.Skipped++
+			continue
+		}
+		if err := vehicle.InjectRTCM(frame); err != nil {
+			return stats, fmt.Errorf("failed to forward corrections: %w", err)
+		}
+		stats.Frames++
+		stats.Bytes += len(frame)
+	}
+
+	if ctx.Err() != nil {
+		return stats, ctx.Err()
+	}
+	if err := scanner.Err(); err != nil {
+		return stats, fmt.Errorf("correction stream failed: %w", err)
+	}
+	return stats, fmt.Errorf("caster closed the connection")
+}
+
+// request is an NTRIP 1.0 GET for the mountpoint
+func request(cfg Config) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "GET /%s HTTP/1.0\r\n", strings.TrimPrefix(cfg.Mountpoint, "/"))
+	fmt.Fprintf(&b, "User-Agent: %s\r\n", userAgent)
+	b.WriteString("Accept: */*\r\n")
+	if cfg.Username != "" || cfg.Password != "" {
+		credentials := base64.StdEncoding.EncodeToString([]byte(cfg.Username + ":" + cfg.Password))
+		fmt.Fprintf(&b, "Authorization: Basic %s\r\n", credentials)
+	}
+	b.WriteString("Connection: close\r\n\r\n")
+	return b.String()
+}
+
+// readResponse consumes the caster's response header
+// NTRIP 1.0 casters answer "ICY 200 OK"; some answer in HTTP. An unknown
+// mountpoint returns the source table instead.
+func readResponse(r *bufio.Reader) error {
+	status, err := r.ReadString('\n')
+	if err != nil {
+		return fmt.Errorf("failed to read caster response: %w", err)
+	}
+	status = strings.TrimSpace(status)
+
+	switch {
+	case status == "ICY 200 OK":
+		return nil
+	case strings.HasPrefix(status, "SOURCETABLE"):
+		return fmt.Errorf("caster has no such mountpoint (returned its source table)")
+	case strings.HasPrefix(status, "HTTP/"):
+		if fields := strings.Fields(status); len(fields) < 2 || fields[1] != "200" {
+			return fmt.Errorf("caster refused the request: %s", status)
+		}
+		// Skip the headers
+		for {
+			line, err := r.ReadString('\n')
+			if err != nil {
+				return fmt.Errorf("failed to read caster response: %w", err)
+			}
+			if strings.TrimSpace(line) == "" {
+				return nil
+			}
+		}
+	default:
+		return fmt.Errorf("unexpected caster response: %q", status)
+	}
+}
+
+// sendGGA reports the vehicle position every interval, starting immediately
+// A position is only sent once the vehicle has a fix.
+func sendGGA(ctx context.Context, conn net.Conn, vehicle Vehicle, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		if pos, ok := vehicle.Position(); ok {
+			conn.SetWriteDeadline(time.Now().Add(dialTimeout))
+			if _, err := conn.Write([]byte(FormatGGA(pos, time.Now()))); err != nil {
+				return
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// FormatGGA formats a position as an NMEA GGA sentence, including the trailing CRLF
+func FormatGGA(pos Position, t time.Time) string {
+	t = t.UTC()
+	latDeg, latHemi := nmeaAngle(pos.Latitude, "N", "S")
+	lonDeg, lonHemi := nmeaAngle(pos.Longitude, "E", "W")
+
+	body := fmt.Sprintf("GPGGA,%02d%02d%02d.%02d,%02d%08.5f,%s,%03d%08.5f,%s,1,%02d,1.0,%.1f,M,0.0,M,,",
+		t.Hour(), t.Minute(), t.Second(), t.Nanosecond()/1e7,
+		latDeg.degrees, latDeg.minutes, latHemi,
+		lonDeg.degrees, lonDeg.minutes, lonHemi,
+		min(pos.Satellites, 99), pos.Altitude)
+
+	var checksum byte
+	for i := 0; i < len(body); i++ {
+		checksum ^= body[i]
+	}
+	return fmt.Sprintf("$%s*%02X\r\n", body, checksum)
+}
+
+type degreesMinutes struct {
+	degrees int
+	minutes float64
+}
+
+// nmeaAngle splits an angle into whole degrees and minutes plus its hemisphere
+func nmeaAngle(angle float64, positive, negative string) (degreesMinutes, string) {
+	hemisphere := positive
+	if angle < 0 {
+		hemisphere = negative
+		angle = -angle
+	}
+	degrees := math.Floor(angle)
+	minutes := (angle - degrees) * 60
+	// Rounding to 5 decimals must not print 60 minutes
+	if math.Round(minutes*1e5) >= 60*1e5 {
+		degrees++
+		minutes = 0
+	}
+	return degreesMinutes{degrees: int(degrees), minutes: minutes}, hemisphere
+}
+
+// deadlineReader extends the read deadline before every read, so a caster
+// that goes silent ends the session
+type deadlineReader struct {
+	conn net.Conn
+	r    *bufio.Reader
+}
+
+func (d *deadlineReader) Read(p []byte) (int, error) {
+	d.conn.SetReadDeadline(time.Now().Add(readTimeout))
+	return d.r.Read(p)
+}
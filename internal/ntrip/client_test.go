@@ -0,0 +1,220 @@
+package ntrip
+
+import (
+	"bufio"
+	"context"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"net"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// testVehicle records the corrections it is sent
+type testVehicle struct {
+	mu     sync.Mutex
+	pos    Position
+	fix    bool
+	frames [][]byte
+}
+
+func (v *testVehicle) Position() (Position, bool) {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	return v.pos, v.fix
+}
+
+func (v *testVehicle) InjectRTCM(frame []byte) error {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	v.frames = append(v.frames, append([]byte(nil), frame...))
+	return nil
+}
+
+// rtcm3Frame wraps payload in an RTCM3 frame with its CRC-24Q
+func rtcm3Frame(payload ...byte) []byte {
+	frame := append([]byte{0xD3, byte(len(payload) >> 8), byte(len(payload))}, payload...)
+	var crc uint32
+	for _, b := range frame {
+		crc ^= uint32(b) << 16
+		for i := 0; i < 8; i++ {
+			crc <<= 1
+			if crc&0x1000000 != 0 {
+				crc ^= 0x1864CFB
+			}
+		}
+	}
+	return append(frame, byte(crc>>16), byte(crc>>8), byte(crc))
+}
+
+// caster is a mock NTRIP caster accepting one client
+// serve gets the request line, the request headers and the connection.
+func caster(t *testing.T, serve func(request string, headers map[string]string, conn net.Conn)) Config {
+	t.Helper()
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { listener.Close() })
+
+	go func() {
+		conn, err := listener.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+
+		r := bufio.NewReader(conn)
+		request, _ := r.ReadString('\n')
+		headers := make(map[string]string)
+		for {
+			line, err := r.ReadString('\n')
+			if err != nil || strings.TrimSpace(line) == "" {
+				break
+			}
+			name, value, _ := strings.Cut(strings.TrimSpace(line), ": ")
+			headers[name] = value
+		}
+		serve(strings.TrimSpace(request), headers, conn)
+	}()
+
+	host, port, _ := net.SplitHostPort(listener.Addr().String())
+	p, _ := strconv.Atoi(port)
+	return Config{Host: host, Port: p, Mountpoint: "RTCM3_ZURICH"}
+}
+
+func TestStreamForwardsCorrections(t *testing.T) {
+	frames := [][]byte{rtcm3Frame(0x43, 0x50, 0x01), rtcm3Frame(0x3E, 0xD0, 0x02, 0x03)}
+	requests := make(chan string, 1)
+	authorization := make(chan string, 1)
+	gga := make(chan string, 1)
+
+	cfg := caster(t, func(request string, headers map[string]string, conn net.Conn) {
+		requests <- request
+		authorization <- headers["Authorization"]
+
+		fmt.Fprint(conn, "ICY 200 OK\r\n")
+		// Noise before the first preamble is skipped
+		conn.Write([]byte{0x00, 0x17})
+		for _, frame := range frames {
+			conn.Write(frame)
+		}
+
+		// Close once the vehicle position has been reported
+		line, _ := bufio.NewReader(conn).ReadString('\n')
+		gga <- line
+	})
+	cfg.Username = "pilot"
+	cfg.Password = "s3cret"
+	cfg.GGAInterval = time.Hour // just the one sent on connecting
+
+	vehicle := &testVehicle{pos: Position{Latitude: 47.3977, Longitude: 8.5456, Altitude: 488, Satellites: 14}, fix: true}
+	stats, err := Stream(context.Background(), cfg, vehicle)
+	if err == nil || !strings.Contains(err.Error(), "caster closed the connection") {
+		t.Errorf("Stream: %v", err)
+	}
+
+	if request := <-requests; request != "GET /RTCM3_ZURICH HTTP/1.0" {
+		t.Errorf("request = %q", request)
+	}
+	if auth, want := <-authorization, "Basic "+base64.StdEncoding.EncodeToString([]byte("pilot:s3cret")); auth != want {
+		t.Errorf("Authorization = %q, want %q", auth, want)
+	}
+	if line := <-gga; !strings.HasPrefix(line, "$GPGGA,") || !strings.Contains(line, ",4723.86200,N,00832.73600,E,1,14,") {
+		t.Errorf("GGA = %q", line)
+	}
+
+	if stats.Frames != 2 || stats.Bytes != len(frames[0])+len(frames[1]) {
+		t.Errorf("stats = %+v", stats)
+	}
+	vehicle.mu.Lock()
+	defer vehicle.mu.Unlock()
+	for i, frame := range frames {
+		if i >= len(vehicle.frames) || string(vehicle.frames[i]) != string(frame) {
+			t.Errorf("frame %d not forwarded intact", i)
+		}
+	}
+}
+
+func TestStreamCasterResponses(t *testing.T) {
+	tests := []struct {
+		name     string
+		response string
+		want     string // "" when the session starts
+	}{
+		{"NTRIP 1.0", "ICY 200 OK\r\n", ""},
+		{"HTTP", "HTTP/1.1 200 OK\r\nContent-Type: gnss/data\r\n\r\n", ""},
+		{"unknown mountpoint", "SOURCETABLE 200 OK\r\n", "no such mountpoint"},
+		{"bad credentials", "HTTP/1.1 401 Unauthorized\r\n\r\n", "refused the request"},
+		{"not a caster", "SSH-2.0-OpenSSH_9.6\r\n", "unexpected caster response"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := caster(t, func(_ string, _ map[string]string, conn net.Conn) {
+				fmt.Fprint(conn, tt.response)
+			})
+			cfg.GGAInterval = -1
+
+			_, err := Stream(context.Background(), cfg, &testVehicle{})
+			if tt.want == "" {
+				if err == nil || !strings.Contains(err.Error(), "caster closed the connection") {
+					t.Errorf("Stream: %v, want a session ended by the caster", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("Stream: %v, want %q", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestStreamCancel(t *testing.T) {
+	cfg := caster(t, func(_ string, _ map[string]string, conn net.Conn) {
+		fmt.Fprint(conn, "ICY 200 OK\r\n")
+		// Quiet until the client hangs up
+		conn.Read(make([]byte, 1))
+	})
+	cfg.GGAInterval = -1
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() {
+		_, err := Stream(ctx, cfg, &testVehicle{})
+		done <- err
+	}()
+
+	time.Sleep(100 * time.Millisecond)
+	cancel()
+	select {
+	case err := <-done:
+		if !errors.Is(err, context.Canceled) {
+			t.Errorf("Stream: %v, want context.Canceled", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("Stream kept running after cancel")
+	}
+}
+
+func TestFormatGGA(t *testing.T) {
+	at := time.Date(2026, 10, 15, 9, 5, 7, 250e6, time.UTC)
+
+	got := FormatGGA(Position{Latitude: -33.5, Longitude: -70.75, Altitude: 520.46, Satellites: 9}, at)
+	body := "GPGGA,090507.25,3330.00000,S,07045.00000,W,1,09,1.0,520.5,M,0.0,M,,"
+	var checksum byte
+	for i := 0; i < len(body); i++ {
+		checksum ^= body[i]
+	}
+	if want := fmt.Sprintf("$%s*%02X\r\n", body, checksum); got != want {
+		t.Errorf("FormatGGA = %q, want %q", got, want)
+	}
+
+	// Minutes that round to 60 carry into the degrees
+	if got := FormatGGA(Position{Latitude: 46.9999999999, Longitude: 8}, at); !strings.Contains(got, ",4700.00000,N,") {
+		t.Errorf("FormatGGA = %q", got)
+	}
+}
@@ -0,0 +1,158 @@
+package ntrip
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/flightpath-dev/flightpath-server/internal/config"
+	"github.com/flightpath-dev/flightpath-server/internal/server"
+)
+
+// Retry delays for caster sessions that fail (doubling up to the maximum)
+const (
+	retryInitialDelay = 2 * time.Second
+	retryMaxDelay     = time.Minute
+)
+
+// waitPollInterval is how often a forwarder checks whether its drone connected
+const waitPollInterval = 2 * time.Second
+
+// StartForwarders starts a forwarder for every registry drone with an ntrip section
+// Each runs until ctx is cancelled.
+func StartForwarders(ctx context.Context, deps *server.Dependencies) {
+	logger := deps.GetLogger()
+
+	for _, droneConfig := range deps.GetDroneRegistry().Drones {
+		if droneConfig.NTRIP == nil {
+			continue
+		}
+		if droneConfig.GetConnectionBool("passive") {
+			logger.Printf("NTRIP: Warning - skipping %s: passive drones can't receive corrections", droneConfig.ID)
+			continue
+		}
+		cfg, err := configFor(droneConfig.NTRIP)
+		if err != nil {
+			logger.Printf("NTRIP: Warning - skipping %s: %v", droneConfig.ID, err)
+			continue
+		}
+
+		logger.Printf("NTRIP: Forwarding %s:%d/%s to %s", cfg.Host, cfg.Port, cfg.Mountpoint, droneConfig.ID)
+		f := &forwarder{deps: deps, droneID: droneConfig.ID, cfg: cfg}
+		go f.run(ctx)
+	}
+}
+
+// configFor validates a registry ntrip section and applies its defaults
+func configFor(c *config.NTRIPConfig) (Config, error) {
+	if c.Host == "" {
+		return Config{}, fmt.Errorf("ntrip host is required")
+	}
+	if c.Mountpoint == "" {
+		return Config{}, fmt.Errorf("ntrip mountpoint is required")
+	}
+	if c.Port < 0 || c.Port > 65535 {
+		return Config{}, fmt.Errorf("invalid ntrip port: %d", c.Port)
+	}
+
+	cfg := Config{
+		Host:        c.Host,
+		Port:        c.Port,
+		Mountpoint:  c.Mountpoint,
+		Username:    c.Username,
+		Password:    c.Password,
+		GGAInterval: time.Duration(c.GGAIntervalS) * time.Second,
+	}
+	if cfg.Port == 0 {
+		cfg.Port = DefaultPort
+	}
+	return cfg, nil
+}
+
+// forwarder keeps a caster session open while its drone is connected
+type forwarder struct {
+	deps    *server.Dependencies
+	droneID string
+	cfg     Config
+}
+
+func (f *forwarder) run(ctx context.Context) {
+	logger := f.deps.GetLogger()
+	delay := retryInitialDelay
+
+	for {
+		if !f.waitForDrone(ctx) {
+			return
+		}
+
+		start := time.Now()
+		stats, err := Stream(ctx, f.cfg, &droneVehicle{deps: f.deps, droneID: f.droneID})
+		if errors.Is(err, context.Canceled) {
+			return
+		}
+
+		// A session that ran a while was healthy; start backing off afresh
+		if stats.Frames > 0 && time.Since(start) > retryMaxDelay {
+			delay = retryInitialDelay
+		}
+		logger.Printf("NTRIP: Warning - %s session ended after %d frames (%d bytes, %d skipped): %v (retrying in %s)",
+			f.droneID, stats.Frames, stats.Bytes, stats.Skipped, err, delay)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(delay):
+		}
+		delay = min(delay*2, retryMaxDelay)
+	}
+}
+
+// waitForDrone waits until the drone is connected, returning false if ctx ends first
+func (f *forwarder) waitForDrone(ctx context.Context) bool {
+	for {
+		if client, ok := f.deps.GetMAVLinkClientFor(f.droneID); ok && client.IsConnected() {
+			return true
+		}
+
+		select {
+		case <-ctx.Done():
+			return false
+		case <-time.After(waitPollInterval):
+		}
+	}
+}
+
+// droneVehicle adapts whichever MAVLink client is connected to the drone
+// The client is looked up on every call, so corrections follow a client
+// replaced by Connect and stop once the drone is disconnected.
+type droneVehicle struct {
+	deps    *server.Dependencies
+	droneID string
+}
+
+func (v *droneVehicle) Position() (Position, bool) {
+	client, ok := v.deps.GetMAVLinkClientFor(v.droneID)
+	if !ok {
+		return Position{}, false
+	}
+
+	telemetry := client.GetTelemetry()
+	if !telemetry.PositionValid() {
+		return Position{}, false
+	}
+	return Position{
+		Latitude:   telemetry.Latitude,
+		Longitude:  telemetry.Longitude,
+		Altitude:   telemetry.Altitude,
+		Satellites: int(telemetry.SatelliteCount),
+	}, true
+}
+
+func (v *droneVehicle) InjectRTCM(frame []byte) error {
+	client, ok := v.deps.GetMAVLinkClientFor(v.droneID)
+	if !ok {
+		return fmt.Errorf("drone %s disconnected", v.droneID)
+	}
+	return client.InjectRTCM(frame)
+}
@@ -0,0 +1,28 @@
+package ntrip
+
+import (
+	"testing"
+	"time"
+
+	"github.com/flightpath-dev/flightpath-server/internal/config"
+)
+
+func TestConfigFor(t *testing.T) {
+	cfg, err := configFor(&config.NTRIPConfig{Host: "caster.example", Mountpoint: "RTCM3", GGAIntervalS: 5})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cfg.Port != DefaultPort || cfg.GGAInterval != 5*time.Second {
+		t.Errorf("config = %+v", cfg)
+	}
+
+	for _, invalid := range []config.NTRIPConfig{
+		{Mountpoint: "RTCM3"},
+		{Host: "caster.example"},
+		{Host: "caster.example", Mountpoint: "RTCM3", Port: 70000},
+	} {
+		if _, err := configFor(&invalid); err == nil {
+			t.Errorf("%+v accepted", invalid)
+		}
+	}
+}
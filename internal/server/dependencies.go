@@ -2,8 +2,12 @@ package server
 
 import (
 	"log"
+	"sort"
 	"sync"
+	"sync/atomic"
+	"time"
 
+	"github.com/flightpath-dev/flightpath-server/internal/audit"
 	"github.com/flightpath-dev/flightpath-server/internal/config"
 	"github.com/flightpath-dev/flightpath-server/internal/mavlink"
 )
@@ -13,15 +17,49 @@ type Dependencies struct {
 	Config        *config.Config
 	DroneRegistry *config.DroneRegistry
 	Logger        *log.Logger
-	MAVLinkClient *mavlink.Client
 
-	// Mutex for thread-safe operations
+	// Filters Logger output by the runtime log level
+	logLevel *levelWriter
+
+	// How the registry loaded (missing/invalid files fall back to an empty registry)
+	RegistryStatus config.RegistryStatus
+
+	// Whether state-changing requests are accepted (false: safe boot)
+	commandsEnabled atomic.Bool
+
+	// Audit log of state-changing requests (nil without FLIGHTPATH_AUDIT_LOG);
+	// set before the server starts
+	Audit *audit.Log
+
+	// Terrain heights for TERRAIN_REQUESTs (nil without FLIGHTPATH_TERRAIN_DIR);
+	// set before the server starts
+	Terrain mavlink.TerrainSource
+
+	// MAVLink clients keyed by drone ID
+	mavlinkClients map[string]*mavlink.Client
+
+	// When RPCs last used each client and how many streams hold it, for the
+	// idle timeout
+	clientUsage map[*mavlink.Client]*clientUsage
+
+	// Drone addressed by RPCs that don't carry a drone ID
+	// (the most recently connected or selected drone)
+	activeDroneID string
+
+	// Per-drone locks for slow operations (connect, mission edits), so one
+	// drone's operation doesn't hold up another's; created on first use
+	droneLocks map[string]*sync.Mutex
+
+	// Guards the maps and activeDroneID only; never held across drone I/O
 	mu sync.RWMutex
 }
 
 // NewDependencies creates a new Dependencies instance
 func NewDependencies(cfg *config.Config) *Dependencies {
-	logger := log.New(log.Writer(), "[flightpath] ", log.LstdFlags|log.Lshortfile)
+	// Validate has already rejected unknown level names
+	level, _ := parseLogLevel(cfg.Logging.Level)
+	logLevel := newLevelWriter(log.Writer(), level)
+	logger := log.New(logLevel, "[flightpath] ", log.LstdFlags|log.Lshortfile)
 
 	// Try to load drone registry
 	registryPath := cfg.Server.DroneRegistryPath
@@ -30,19 +68,40 @@ func NewDependencies(cfg *config.Config) *Dependencies {
 	}
 
 	registry, err := config.LoadDroneRegistry(registryPath)
+	registryStatus := config.RegistryStatusFor(registryPath, registry, err)
+
+	switch registryStatus.State {
+	case config.RegistryLoaded:
+		logger.Printf("Loaded drone registry with %d drones", len(registry.Drones))
+	case config.RegistryMissing:
+		logger.Printf("Warning: Drone registry not found at %s; no drones can connect", registryPath)
+	default:
+		logger.Printf("ERROR: Drone registry at %s is invalid; no drones can connect: %v", registryPath, err)
+	}
+
 	if err != nil {
-		logger.Printf("Warning: Could not load drone registry: %v", err)
-		// Create empty registry if file doesn't exist
+		if cfg.Server.RequireRegistry {
+			logger.Fatalf("Drone registry is required but %s: %v", registryStatus.State, err)
+		}
+		// Continue with an empty registry
 		registry = &config.DroneRegistry{Drones: []config.DroneConfig{}}
-	} else {
-		logger.Printf("Loaded drone registry with %d drones", len(registry.Drones))
 	}
 
-	return &Dependencies{
-		Config:        cfg,
-		DroneRegistry: registry,
-		Logger:        logger,
+	deps := &Dependencies{
+		Config:         cfg,
+		DroneRegistry:  registry,
+		RegistryStatus: registryStatus,
+		Logger:         logger,
+		logLevel:       logLevel,
+		mavlinkClients: make(map[string]*mavlink.Client),
+		clientUsage:    make(map[*mavlink.Client]*clientUsage),
+		droneLocks:     make(map[string]*sync.Mutex),
 	}
+	deps.commandsEnabled.Store(cfg.Server.CommandsEnabled)
+	if !cfg.Server.CommandsEnabled {
+		logger.Println("Safe boot: commands are disabled until enabled with PUT /api/v1/commands")
+	}
+	return deps
 }
 
 // SetLogger allows updating the logger (useful for testing)
@@ -52,6 +111,32 @@ func (d *Dependencies) SetLogger(logger *log.Logger) {
 	d.Logger = logger
 }
 
+// SetLogLevel changes the level below which Logger output is dropped
+// Takes effect for the next line logged; already-created loggers share the filter.
+func (d *Dependencies) SetLogLevel(name string) error {
+	level, err := parseLogLevel(name)
+	if err != nil {
+		return err
+	}
+	d.logLevel.level.Store(level)
+	return nil
+}
+
+// GetLogLevel returns the current log level name
+func (d *Dependencies) GetLogLevel() string {
+	return logLevelNames[d.logLevel.level.Load()]
+}
+
+// CommandsEnabled reports whether state-changing requests are accepted
+func (d *Dependencies) CommandsEnabled() bool {
+	return d.commandsEnabled.Load()
+}
+
+// SetCommandsEnabled enables or disables state-changing requests until restart
+func (d *Dependencies) SetCommandsEnabled(enabled bool) {
+	d.commandsEnabled.Store(enabled)
+}
+
 // GetLogger returns the logger (thread-safe)
 func (d *Dependencies) GetLogger() *log.Logger {
 	d.mu.RLock()
@@ -59,32 +144,185 @@ func (d *Dependencies) GetLogger() *log.Logger {
 	return d.Logger
 }
 
-// SetMAVLinkClient sets the MAVLink client
-func (d *Dependencies) SetMAVLinkClient(client *mavlink.Client) {
+// SetMAVLinkClient stores the MAVLink client for a drone and makes it the active drone
+func (d *Dependencies) SetMAVLinkClient(droneID string, client *mavlink.Client) {
 	d.mu.Lock()
 	defer d.mu.Unlock()
-	d.MAVLinkClient = client
+	d.storeMAVLinkClientLocked(droneID, client)
+	d.activeDroneID = droneID
+}
+
+// AddMAVLinkClient stores the MAVLink client for a drone without taking over
+// the active drone: it only becomes active while no drone is
+func (d *Dependencies) AddMAVLinkClient(droneID string, client *mavlink.Client) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.storeMAVLinkClientLocked(droneID, client)
+	if d.activeDroneID == "" {
+		d.activeDroneID = droneID
+	}
+}
+
+// storeMAVLinkClientLocked replaces a drone's client; d.mu must be held
+func (d *Dependencies) storeMAVLinkClientLocked(droneID string, client *mavlink.Client) {
+	if old := d.mavlinkClients[droneID]; old != nil {
+		delete(d.clientUsage, old)
+	}
+	d.mavlinkClients[droneID] = client
+	d.clientUsage[client] = &clientUsage{lastUsed: time.Now()}
 }
 
-// GetMAVLinkClient returns the MAVLink client (thread-safe)
+// GetMAVLinkClient returns the active drone's MAVLink client (thread-safe)
 func (d *Dependencies) GetMAVLinkClient() *mavlink.Client {
 	d.mu.RLock()
 	defer d.mu.RUnlock()
-	return d.MAVLinkClient
+	return d.mavlinkClients[d.activeDroneID]
 }
 
-// HasMAVLinkClient returns true if MAVLink client is set
+// HasMAVLinkClient returns true if the active drone has a MAVLink client
 func (d *Dependencies) HasMAVLinkClient() bool {
 	d.mu.RLock()
 	defer d.mu.RUnlock()
-	return d.MAVLinkClient != nil
+	return d.mavlinkClients[d.activeDroneID] != nil
 }
 
-// ClearMAVLinkClient removes the MAVLink client from dependencies
+// ClearMAVLinkClient removes the active drone's MAVLink client from dependencies
 func (d *Dependencies) ClearMAVLinkClient() {
 	d.mu.Lock()
 	defer d.mu.Unlock()
-	d.MAVLinkClient = nil
+	delete(d.clientUsage, d.mavlinkClients[d.activeDroneID])
+	delete(d.mavlinkClients, d.activeDroneID)
+	d.activeDroneID = ""
+}
+
+// RemoveMAVLinkClient removes a specific drone's MAVLink client from dependencies
+func (d *Dependencies) RemoveMAVLinkClient(droneID string) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	delete(d.clientUsage, d.mavlinkClients[droneID])
+	delete(d.mavlinkClients, droneID)
+	if d.activeDroneID == droneID {
+		d.activeDroneID = ""
+	}
+}
+
+// GetMAVLinkClientFor returns the MAVLink client for a specific drone
+func (d *Dependencies) GetMAVLinkClientFor(droneID string) (*mavlink.Client, bool) {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+	client, ok := d.mavlinkClients[droneID]
+	return client, ok
+}
+
+// SetActiveDrone selects which drone un-scoped RPCs address
+// Returns false if the drone has no MAVLink client
+func (d *Dependencies) SetActiveDrone(droneID string) bool {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if _, ok := d.mavlinkClients[droneID]; !ok {
+		return false
+	}
+	d.activeDroneID = droneID
+	return true
+}
+
+// GetActiveDroneID returns the ID of the active drone ("" if none)
+func (d *Dependencies) GetActiveDroneID() string {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+	return d.activeDroneID
+}
+
+// LockDrone serializes slow operations on one drone and returns the unlock function
+// Operations on different drones proceed concurrently.
+func (d *Dependencies) LockDrone(droneID string) (unlock func()) {
+	d.mu.Lock()
+	lock, ok := d.droneLocks[droneID]
+	if !ok {
+		lock = &sync.Mutex{}
+		d.droneLocks[droneID] = lock
+	}
+	d.mu.Unlock()
+
+	lock.Lock()
+	return lock.Unlock
+}
+
+// ResetDroneLock replaces a drone's lock so new operations don't queue behind
+// one that is wedged; whoever holds the old lock keeps it
+func (d *Dependencies) ResetDroneLock(droneID string) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	delete(d.droneLocks, droneID)
+}
+
+// GetMAVLinkDroneIDs returns the IDs of all drones with a MAVLink client, sorted
+func (d *Dependencies) GetMAVLinkDroneIDs() []string {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+
+	ids := make([]string, 0, len(d.mavlinkClients))
+	for id := range d.mavlinkClients {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	return ids
+}
+
+// clientUsage is how a MAVLink client is being used by RPCs
+type clientUsage struct {
+	lastUsed time.Time
+	streams  int
+}
+
+// MarkMAVLinkClientUsed records that an RPC used a client, resetting its idle time
+// Background work (exporter, NTRIP) doesn't mark clients, so only requests keep
+// a drone from being reclaimed as idle.
+func (d *Dependencies) MarkMAVLinkClientUsed(client *mavlink.Client) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if usage, ok := d.clientUsage[client]; ok {
+		usage.lastUsed = time.Now()
+	}
+}
+
+// HoldMAVLinkClient marks a client in use for the length of a stream and
+// returns the release function; a held client is never idle
+func (d *Dependencies) HoldMAVLinkClient(client *mavlink.Client) (release func()) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	usage, ok := d.clientUsage[client]
+	if !ok {
+		return func() {}
+	}
+	usage.streams++
+
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			d.mu.Lock()
+			defer d.mu.Unlock()
+			usage.streams--
+			usage.lastUsed = time.Now()
+		})
+	}
+}
+
+// GetIdleMAVLinkDroneIDs returns the drones whose client no RPC has used for
+// at least timeout and no stream holds, sorted
+func (d *Dependencies) GetIdleMAVLinkDroneIDs(timeout time.Duration) []string {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+
+	var ids []string
+	for id, client := range d.mavlinkClients {
+		usage, ok := d.clientUsage[client]
+		if ok && usage.streams == 0 && time.Since(usage.lastUsed) >= timeout {
+			ids = append(ids, id)
+		}
+	}
+	sort.Strings(ids)
+	return ids
 }
 
 // GetDroneRegistry returns the drone registry (thread-safe)
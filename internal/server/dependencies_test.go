@@ -0,0 +1,160 @@
+package server
+
+import (
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/flightpath-dev/flightpath-server/internal/config"
+	"github.com/flightpath-dev/flightpath-server/internal/mavlink"
+)
+
+// newTestDependencies returns dependencies with an empty registry that log
+// nowhere
+func newTestDependencies(tb testing.TB) *Dependencies {
+	tb.Helper()
+	out := log.Writer()
+	log.SetOutput(io.Discard)
+	tb.Cleanup(func() { log.SetOutput(out) })
+
+	cfg := config.Default()
+	cfg.Server.DroneRegistryPath = filepath.Join(tb.TempDir(), "drones.yaml")
+	return NewDependencies(cfg)
+}
+
+func TestAddMAVLinkClientKeepsActiveDrone(t *testing.T) {
+	deps := newTestDependencies(t)
+
+	deps.AddMAVLinkClient("alpha", &mavlink.Client{})
+	if got := deps.GetActiveDroneID(); got != "alpha" {
+		t.Fatalf("first added drone: active = %q, want alpha", got)
+	}
+
+	deps.AddMAVLinkClient("bravo", &mavlink.Client{})
+	if got := deps.GetActiveDroneID(); got != "alpha" {
+		t.Errorf("after AddMAVLinkClient(bravo): active = %q, want alpha", got)
+	}
+	if _, ok := deps.GetMAVLinkClientFor("bravo"); !ok {
+		t.Error("bravo's client was not stored")
+	}
+
+	deps.SetMAVLinkClient("charlie", &mavlink.Client{})
+	if got := deps.GetActiveDroneID(); got != "charlie" {
+		t.Errorf("after SetMAVLinkClient(charlie): active = %q, want charlie", got)
+	}
+}
+
+// Run with -race: operations on different drones hold their own locks and
+// run concurrently while clients come and go
+func TestLockDroneConcurrent(t *testing.T) {
+	deps := newTestDependencies(t)
+	drones := []string{"alpha", "bravo"}
+	for _, id := range drones {
+		deps.SetMAVLinkClient(id, &mavlink.Client{})
+	}
+
+	// A slow operation on alpha doesn't hold up bravo
+	unlockAlpha := deps.LockDrone("alpha")
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		deps.LockDrone("bravo")()
+	}()
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("LockDrone(bravo) blocked behind alpha's lock")
+	}
+	unlockAlpha()
+
+	var wg sync.WaitGroup
+	for i := range 8 {
+		id := drones[i%len(drones)]
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for range 100 {
+				unlock := deps.LockDrone(id)
+				if client, ok := deps.GetMAVLinkClientFor(id); ok {
+					deps.MarkMAVLinkClientUsed(client)
+				}
+				deps.SetMAVLinkClient(id, &mavlink.Client{})
+				unlock()
+				deps.GetMAVLinkDroneIDs()
+				deps.GetActiveDroneID()
+			}
+		}()
+	}
+	wg.Wait()
+}
+
+// BenchmarkLockDroneTwoDrones holds each drone's lock for a fixed time; with
+// per-drone locks two drones take about half as long as one
+func BenchmarkLockDroneTwoDrones(b *testing.B) {
+	const hold = 100 * time.Microsecond
+
+	for _, n := range []int{1, 2} {
+		b.Run(fmt.Sprintf("drones=%d", n), func(b *testing.B) {
+			deps := newTestDependencies(b)
+
+			var next atomic.Int64
+			b.SetParallelism(2)
+			b.RunParallel(func(pb *testing.PB) {
+				id := fmt.Sprintf("drone-%d", next.Add(1)%int64(n))
+				for pb.Next() {
+					unlock := deps.LockDrone(id)
+					time.Sleep(hold)
+					unlock()
+				}
+			})
+		})
+	}
+}
+
+func TestRegistryStatus(t *testing.T) {
+	dir := t.TempDir()
+	valid := filepath.Join(dir, "valid.yaml")
+	invalid := filepath.Join(dir, "invalid.yaml")
+	registry := "drones:\n  - id: alpha\n    protocol: mavlink\n  - id: bravo\n    protocol: mavlink\n"
+	if err := os.WriteFile(valid, []byte(registry), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(invalid, []byte("drones: [alpha\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		path   string
+		state  string
+		drones int
+	}{
+		{valid, config.RegistryLoaded, 2},
+		{filepath.Join(dir, "missing.yaml"), config.RegistryMissing, 0},
+		{invalid, config.RegistryInvalid, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.state, func(t *testing.T) {
+			cfg := newTestDependencies(t).Config
+			cfg.Server.DroneRegistryPath = tt.path
+			deps := NewDependencies(cfg)
+
+			status := deps.RegistryStatus
+			if status.State != tt.state || status.Path != tt.path || status.Drones != tt.drones {
+				t.Errorf("status = %+v, want %s with %d drones", status, tt.state, tt.drones)
+			}
+			if (status.Error == "") != (tt.state == config.RegistryLoaded) {
+				t.Errorf("error = %q", status.Error)
+			}
+			// Without require_registry the server starts with an empty registry
+			if got := len(deps.GetDroneRegistry().Drones); got != tt.drones {
+				t.Errorf("registry has %d drones, want %d", got, tt.drones)
+			}
+		})
+	}
+}
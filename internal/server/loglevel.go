@@ -0,0 +1,64 @@
+package server
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"sync/atomic"
+)
+
+// Log levels, least to most severe
+const (
+	levelDebug int32 = iota
+	levelInfo
+	levelWarn
+	levelError
+)
+
+var logLevelNames = []string{"debug", "info", "warn", "error"}
+
+// parseLogLevel maps a config level name to its severity
+func parseLogLevel(name string) (int32, error) {
+	for i, n := range logLevelNames {
+		if n == name {
+			return int32(i), nil
+		}
+	}
+	return 0, fmt.Errorf("invalid log level %q (must be debug, info, warn or error)", name)
+}
+
+// levelWriter drops log lines below a level that can change at runtime
+// The logger is a plain log.Logger, so a line's level comes from its text:
+// "ERROR"/"Error"/"PANIC" is error, "Warning"/"WARN" is warn, anything else info.
+type levelWriter struct {
+	out   io.Writer
+	level atomic.Int32
+}
+
+func newLevelWriter(out io.Writer, level int32) *levelWriter {
+	w := &levelWriter{out: out}
+	w.level.Store(level)
+	return w
+}
+
+// Write is called once per log line by log.Logger
+func (w *levelWriter) Write(p []byte) (int, error) {
+	if lineLevel(p) < w.level.Load() {
+		return len(p), nil
+	}
+	return w.out.Write(p)
+}
+
+func lineLevel(line []byte) int32 {
+	switch {
+	case bytes.Contains(line, []byte("ERROR")),
+		bytes.Contains(line, []byte("Error")),
+		bytes.Contains(line, []byte("PANIC")):
+		return levelError
+	case bytes.Contains(line, []byte("Warning")),
+		bytes.Contains(line, []byte("WARN")):
+		return levelWarn
+	default:
+		return levelInfo
+	}
+}
@@ -0,0 +1,81 @@
+package server
+
+import (
+	"bytes"
+	"log"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/flightpath-dev/flightpath-server/internal/config"
+)
+
+func TestSetLogLevelFiltersLaterLines(t *testing.T) {
+	var buf bytes.Buffer
+	out := log.Writer()
+	log.SetOutput(&buf)
+	defer log.SetOutput(out)
+
+	cfg := config.Default()
+	cfg.Server.DroneRegistryPath = filepath.Join(t.TempDir(), "drones.yaml")
+	cfg.Logging.Level = "info"
+	deps := NewDependencies(cfg)
+	logger := deps.GetLogger()
+
+	logged := func(line string) bool {
+		t.Helper()
+		buf.Reset()
+		logger.Println(line)
+		return strings.Contains(buf.String(), line)
+	}
+
+	if !logged("Connect request: drone_id=alpha") {
+		t.Error("info line dropped at info level")
+	}
+
+	if err := deps.SetLogLevel("error"); err != nil {
+		t.Fatal(err)
+	}
+	if deps.GetLogLevel() != "error" {
+		t.Errorf("level = %s, want error", deps.GetLogLevel())
+	}
+	if logged("Connect request: drone_id=bravo") || logged("Warning: registry reloaded") {
+		t.Error("info or warning line logged at error level")
+	}
+	if !logged("ERROR: MAVLink link lost") {
+		t.Error("error line dropped at error level")
+	}
+
+	// Back to info for the rest of the incident
+	if err := deps.SetLogLevel("info"); err != nil {
+		t.Fatal(err)
+	}
+	if !logged("Connect request: drone_id=charlie") {
+		t.Error("info line dropped after returning to info")
+	}
+
+	if err := deps.SetLogLevel("verbose"); err == nil {
+		t.Error("unknown level accepted")
+	}
+	if deps.GetLogLevel() != "info" {
+		t.Errorf("unknown level changed the level to %s", deps.GetLogLevel())
+	}
+}
+
+func TestLineLevel(t *testing.T) {
+	tests := []struct {
+		line string
+		want int32
+	}{
+		{"Connected to drone alpha", levelInfo},
+		{"MAVLink: Warning - failed to request HOME_POSITION", levelWarn},
+		{"WARN slow client", levelWarn},
+		{"MAVLink: Error sending TIMESYNC", levelError},
+		{"PANIC: runtime error", levelError},
+	}
+	for _, tt := range tests {
+		if got := lineLevel([]byte(tt.line)); got != tt.want {
+			t.Errorf("lineLevel(%q) = %s, want %s", tt.line, logLevelNames[got], logLevelNames[tt.want])
+		}
+	}
+}
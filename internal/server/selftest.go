@@ -0,0 +1,92 @@
+package server
+
+import (
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/http"
+
+	"github.com/flightpath-dev/flightpath-server/internal/config"
+	"github.com/flightpath-dev/flightpath-server/internal/mavlink"
+)
+
+// CheckResult is the outcome of one self-test check
+type CheckResult struct {
+	Name   string `json:"name"`
+	OK     bool   `json:"ok"`
+	Detail string `json:"detail,omitempty"`
+}
+
+// SelfTestResult is the consolidated readiness result
+type SelfTestResult struct {
+	Ready  bool          `json:"ready"`
+	Checks []CheckResult `json:"checks"`
+}
+
+// SelfTest checks the drone registry, the default serial device and the listen address
+// Checks that would conflict with the running server pass once it is serving:
+// the address is already bound, and a connected drone may hold the serial device.
+func (s *Server) SelfTest() SelfTestResult {
+	checks := []CheckResult{
+		s.checkRegistry(),
+		s.checkSerialDevice(),
+		s.checkListenAddr(),
+	}
+
+	ready := true
+	for _, check := range checks {
+		ready = ready && check.OK
+	}
+	return SelfTestResult{Ready: ready, Checks: checks}
+}
+
+func (s *Server) checkRegistry() CheckResult {
+	status := s.dependencies.RegistryStatus
+	if status.State != config.RegistryLoaded {
+		return CheckResult{Name: "registry", Detail: fmt.Sprintf("%s is %s: %s", status.Path, status.State, status.Error)}
+	}
+	return CheckResult{Name: "registry", OK: true, Detail: fmt.Sprintf("%d drones", status.Drones)}
+}
+
+func (s *Server) checkSerialDevice() CheckResult {
+	port := s.config.MAVLink.DefaultPort
+
+	for _, droneID := range s.dependencies.GetMAVLinkDroneIDs() {
+		if client, ok := s.dependencies.GetMAVLinkClientFor(droneID); ok && client.GetConnectionInfo().Port == port {
+			return CheckResult{Name: "serial", OK: true, Detail: fmt.Sprintf("%s in use by drone %s", port, droneID)}
+		}
+	}
+
+	if err := mavlink.CheckSerialDevice(port, s.config.MAVLink.DefaultBaudRate); err != nil {
+		return CheckResult{Name: "serial", Detail: err.Error()}
+	}
+	return CheckResult{Name: "serial", OK: true, Detail: port}
+}
+
+func (s *Server) checkListenAddr() CheckResult {
+	addr := s.config.ServerAddr()
+	if s.listening.Load() {
+		return CheckResult{Name: "listen", OK: true, Detail: addr + " (serving)"}
+	}
+
+	ln, err := net.Listen("tcp", addr)
+	if err != nil {
+		return CheckResult{Name: "listen", Detail: err.Error()}
+	}
+	ln.Close()
+	return CheckResult{Name: "listen", OK: true, Detail: addr}
+}
+
+// handleReadyz serves the self-test result: 200 when ready, 503 otherwise
+func (s *Server) handleReadyz(w http.ResponseWriter, r *http.Request) {
+	result := s.SelfTest()
+
+	status := http.StatusOK
+	if !result.Ready {
+		status = http.StatusServiceUnavailable
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(result)
+}
@@ -0,0 +1,141 @@
+package server
+
+import (
+	"encoding/json"
+	"io"
+	"log"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/flightpath-dev/flightpath-server/internal/config"
+	"github.com/flightpath-dev/flightpath-server/internal/mavlink"
+)
+
+// newSelfTestServer returns a server whose checks all pass: a valid registry,
+// a serial device held by a connected drone and a free local port
+func newSelfTestServer(t *testing.T, change func(*config.Config)) *Server {
+	t.Helper()
+	cfg := newTestDependencies(t).Config
+	cfg.Server.DroneRegistryPath = filepath.Join(t.TempDir(), "drones.yaml")
+	if err := os.WriteFile(cfg.Server.DroneRegistryPath, []byte("drones:\n  - id: alpha\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	cfg.Server.Host = "127.0.0.1"
+	cfg.Server.Port = 0
+	cfg.MAVLink.DefaultPort = filepath.Join(t.TempDir(), "ttyUSB0")
+	if change != nil {
+		change(cfg)
+	}
+
+	s := New(cfg)
+	// Non-default serial settings open the device lazily, so it needn't exist
+	client, err := mavlink.NewClient(mavlink.Config{
+		Port:        cfg.MAVLink.DefaultPort,
+		BaudRate:    57600,
+		Serial:      mavlink.SerialConfig{StopBits: 2},
+		PassiveMode: true,
+		Logger:      log.New(io.Discard, "", 0),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { client.Close() })
+	s.dependencies.AddMAVLinkClient("alpha", client)
+	return s
+}
+
+// check returns the named check from a self-test result
+func check(t *testing.T, result SelfTestResult, name string) CheckResult {
+	t.Helper()
+	for _, c := range result.Checks {
+		if c.Name == name {
+			return c
+		}
+	}
+	t.Fatalf("no %s check in %+v", name, result)
+	return CheckResult{}
+}
+
+func TestSelfTestPasses(t *testing.T) {
+	result := newSelfTestServer(t, nil).SelfTest()
+	if !result.Ready || len(result.Checks) != 3 {
+		t.Fatalf("result = %+v", result)
+	}
+	if c := check(t, result, "registry"); c.Detail != "1 drones" {
+		t.Errorf("registry check = %+v", c)
+	}
+	if c := check(t, result, "serial"); !strings.Contains(c.Detail, "in use by drone alpha") {
+		t.Errorf("serial check = %+v", c)
+	}
+}
+
+func TestSelfTestRegistryFails(t *testing.T) {
+	result := newSelfTestServer(t, func(cfg *config.Config) {
+		cfg.Server.DroneRegistryPath = filepath.Join(t.TempDir(), "missing.yaml")
+	}).SelfTest()
+	if c := check(t, result, "registry"); result.Ready || c.OK || !strings.Contains(c.Detail, "is missing") {
+		t.Errorf("registry check = %+v, ready %v", c, result.Ready)
+	}
+}
+
+func TestSelfTestSerialDeviceFails(t *testing.T) {
+	s := newSelfTestServer(t, nil)
+	// No drone holds it: the device itself must open
+	s.dependencies.RemoveMAVLinkClient("alpha")
+
+	result := s.SelfTest()
+	if c := check(t, result, "serial"); result.Ready || c.OK || !strings.Contains(c.Detail, mavlink.ErrDeviceNotFound.Error()) {
+		t.Errorf("serial check = %+v, ready %v", c, result.Ready)
+	}
+}
+
+func TestSelfTestListenAddrFails(t *testing.T) {
+	taken, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer taken.Close()
+
+	result := newSelfTestServer(t, func(cfg *config.Config) {
+		cfg.Server.Port = taken.Addr().(*net.TCPAddr).Port
+	}).SelfTest()
+	if c := check(t, result, "listen"); result.Ready || c.OK {
+		t.Errorf("listen check = %+v, ready %v", c, result.Ready)
+	}
+
+	// Once serving, the bound address is the server's own
+	s := newSelfTestServer(t, func(cfg *config.Config) {
+		cfg.Server.Port = taken.Addr().(*net.TCPAddr).Port
+	})
+	s.listening.Store(true)
+	if c := check(t, s.SelfTest(), "listen"); !c.OK || !strings.HasSuffix(c.Detail, strconv.Itoa(s.config.Server.Port)+" (serving)") {
+		t.Errorf("listen check while serving = %+v", c)
+	}
+}
+
+func TestReadyz(t *testing.T) {
+	for _, ready := range []bool{true, false} {
+		s := newSelfTestServer(t, func(cfg *config.Config) {
+			if !ready {
+				cfg.Server.DroneRegistryPath = filepath.Join(t.TempDir(), "missing.yaml")
+			}
+		})
+		rec := httptest.NewRecorder()
+		s.mux.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/readyz", nil))
+
+		want := http.StatusOK
+		if !ready {
+			want = http.StatusServiceUnavailable
+		}
+		var result SelfTestResult
+		if err := json.Unmarshal(rec.Body.Bytes(), &result); err != nil || rec.Code != want || result.Ready != ready {
+			t.Errorf("ready %v: /readyz = %d %s", ready, rec.Code, rec.Body.String())
+		}
+	}
+}
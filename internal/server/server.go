@@ -1,8 +1,11 @@
 package server
 
 import (
+	"fmt"
 	"log"
+	"net"
 	"net/http"
+	"sync/atomic"
 
 	"golang.org/x/net/http2"
 	"golang.org/x/net/http2/h2c"
@@ -17,18 +20,26 @@ type Server struct {
 	dependencies *Dependencies
 	mux          *http.ServeMux
 	logger       *log.Logger
+
+	// Set once the listener is bound (see SelfTest)
+	listening atomic.Bool
 }
 
 // New creates a new Server instance
 func New(cfg *config.Config) *Server {
 	deps := NewDependencies(cfg)
 
-	return &Server{
+	s := &Server{
 		config:       cfg,
 		dependencies: deps,
 		mux:          http.NewServeMux(),
 		logger:       deps.GetLogger(),
 	}
+
+	// Readiness probe for deployments
+	s.mux.HandleFunc("GET /readyz", s.handleReadyz)
+
+	return s
 }
 
 // RegisterService registers a Connect service handler
@@ -37,14 +48,15 @@ func (s *Server) RegisterService(path string, handler http.Handler) {
 	s.mux.Handle(path, handler)
 }
 
-// buildHandler builds the final HTTP handler with all middleware
-func (s *Server) buildHandler() http.Handler {
+// Handler builds the final HTTP handler with all middleware
+func (s *Server) Handler() http.Handler {
 	// Start with the mux
 	handler := http.Handler(s.mux)
 
 	// Add middleware in reverse order (last applied first)
-	handler = middleware.CORS(s.config.Server.CORSOrigins)(handler)
-	handler = middleware.Logging(s.logger)(handler)
+	handler = middleware.Identity(handler)
+	handler = middleware.CORS(s.config.Server.CORSOrigins, s.config.Server.CORSPolicies)(handler)
+	handler = middleware.Logging(s.logger, s.config.Logging.Format)(handler)
 	handler = middleware.Recovery(s.logger)(handler)
 
 	// Wrap with h2c (HTTP/2 Cleartext) for Connect protocol
@@ -54,12 +66,32 @@ func (s *Server) buildHandler() http.Handler {
 // Start starts the HTTP server
 func (s *Server) Start() error {
 	addr := s.config.ServerAddr()
-	handler := s.buildHandler()
+	handler := s.Handler()
+
+	if s.config.Server.SelfTest {
+		result := s.SelfTest()
+		for _, check := range result.Checks {
+			mark := "✅"
+			if !check.OK {
+				mark = "❌"
+			}
+			s.logger.Printf("Self-test %s %s: %s", mark, check.Name, check.Detail)
+		}
+		if !result.Ready {
+			return fmt.Errorf("self-test failed")
+		}
+	}
+
+	ln, err := net.Listen("tcp", addr)
+	if err != nil {
+		return err
+	}
+	s.listening.Store(true)
 
 	s.logger.Printf("🚀 Flightpath server starting on %s", addr)
 	s.logger.Printf("📡 Ready to accept Connect protocol requests")
 
-	return http.ListenAndServe(addr, handler)
+	return http.Serve(ln, handler)
 }
 
 // GetDependencies returns the shared dependencies
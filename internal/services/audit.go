@@ -0,0 +1,68 @@
+package services
+
+import (
+	"context"
+
+	"connectrpc.com/connect"
+
+	"github.com/flightpath-dev/flightpath-server/internal/audit"
+	"github.com/flightpath-dev/flightpath-server/internal/server"
+)
+
+// auditable is a result carrying its own outcome (proto responses, CommandResponse)
+type auditable interface {
+	GetSuccess() bool
+	GetMessage() string
+}
+
+func (r *CommandResponse) GetSuccess() bool {
+	return r != nil && r.Success
+}
+
+func (r *CommandResponse) GetMessage() string {
+	if r == nil {
+		return ""
+	}
+	return r.Message
+}
+
+// recordAudit appends a state-changing request and its outcome to the audit log
+// State-changing methods defer it with their named results. The outcome is the
+// error if there is one, else the result's own Success and Message (results
+// without them succeeded). An empty droneID means the request's target drone.
+// Does nothing without an audit log.
+func recordAudit(ctx context.Context, deps *server.Dependencies, action, droneID string, params, result any, err error) {
+	if deps.Audit == nil {
+		return
+	}
+	droneID = targetDrone(ctx, deps, droneID)
+
+	id := audit.IdentityFrom(ctx)
+	entry := audit.Entry{
+		Operator:  id.Operator,
+		RequestID: id.RequestID,
+		DroneID:   droneID,
+		Action:    action,
+		Params:    params,
+		Success:   err == nil,
+	}
+	if err != nil {
+		entry.Message = err.Error()
+	} else if r, ok := result.(auditable); ok {
+		entry.Success = r.GetSuccess()
+		entry.Message = r.GetMessage()
+	}
+
+	if err := deps.Audit.Record(entry); err != nil {
+		deps.GetLogger().Printf("ERROR - %v (action=%s, drone_id=%s)", err, action, droneID)
+	}
+}
+
+// auditResponse is recordAudit for Connect responses
+func auditResponse[T any](ctx context.Context, deps *server.Dependencies, action, droneID string, params any, resp *connect.Response[T], err error) {
+	var result any
+	if resp != nil {
+		result = resp.Msg
+	}
+	recordAudit(ctx, deps, action, droneID, params, result, err)
+}
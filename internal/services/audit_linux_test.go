@@ -0,0 +1,82 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"connectrpc.com/connect"
+	"github.com/bluenviron/gomavlib/v3"
+	"github.com/bluenviron/gomavlib/v3/pkg/dialects/common"
+
+	drone "github.com/flightpath-dev/flightpath-proto/gen/go/drone/v1"
+	"github.com/flightpath-dev/flightpath-server/internal/audit"
+	"github.com/flightpath-dev/flightpath-server/internal/mavlink"
+)
+
+func TestArmAuditRecord(t *testing.T) {
+	deps, _, vehicle := activeDrone(t, &common.MessageHeartbeat{
+		Type:      common.MAV_TYPE_QUADROTOR,
+		Autopilot: common.MAV_AUTOPILOT_PX4,
+	}, mavlink.Config{})
+	path := filepath.Join(t.TempDir(), "audit.jsonl")
+	auditLog, err := audit.Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { auditLog.Close() })
+	deps.Audit = auditLog
+
+	// The vehicle accepts the arm command
+	go func() {
+		for evt := range vehicle.Events() {
+			frame, ok := evt.(*gomavlib.EventFrame)
+			if !ok {
+				continue
+			}
+			if msg, ok := frame.Message().(*common.MessageCommandLong); ok {
+				vehicle.WriteMessageAll(&common.MessageCommandAck{ //nolint:errcheck
+					Command: msg.Command, Result: common.MAV_RESULT_ACCEPTED,
+				})
+			}
+		}
+	}()
+
+	ctx := audit.WithIdentity(context.Background(), audit.Identity{Operator: "alice", RequestID: "req-42"})
+	before := time.Now()
+	resp, err := NewControlServer(deps).Arm(ctx, connect.NewRequest(&drone.ArmRequest{}))
+	if err != nil || !resp.Msg.Success {
+		t.Fatalf("Arm: %+v, %v", resp, err)
+	}
+
+	// Arm returns only after its record is on disk
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	lines := bytes.Split(bytes.TrimSpace(data), []byte("\n"))
+	if len(lines) != 1 {
+		t.Fatalf("audit log has %d records, want 1:\n%s", len(lines), data)
+	}
+	var entry audit.Entry
+	if err := json.Unmarshal(lines[0], &entry); err != nil {
+		t.Fatal(err)
+	}
+
+	if entry.Time.Before(before.Add(-time.Second)) || entry.Time.After(time.Now()) {
+		t.Errorf("time = %v, want around %v", entry.Time, before)
+	}
+	if entry.Operator != "alice" || entry.RequestID != "req-42" {
+		t.Errorf("operator, request ID = %q, %q, want alice, req-42", entry.Operator, entry.RequestID)
+	}
+	if entry.DroneID != "alpha" || entry.Action != "arm" {
+		t.Errorf("drone, action = %q, %q, want alpha, arm", entry.DroneID, entry.Action)
+	}
+	if !entry.Success || entry.Message != "Arm command accepted" {
+		t.Errorf("outcome = %v %q, want success", entry.Success, entry.Message)
+	}
+}
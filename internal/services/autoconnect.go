@@ -0,0 +1,120 @@
+package services
+
+import (
+	"context"
+	"time"
+
+	"connectrpc.com/connect"
+
+	drone "github.com/flightpath-dev/flightpath-proto/gen/go/drone/v1"
+)
+
+// Retry delays for auto-connect attempts that fail (doubling up to the maximum)
+const (
+	autoConnectInitialDelay = 2 * time.Second
+	autoConnectMaxDelay     = time.Minute
+)
+
+// linkCheckInterval is how often ReconnectDownLinks looks for links that
+// stopped accepting writes
+const linkCheckInterval = time.Second
+
+// AutoConnect connects every registry drone marked auto_connect in the background
+// Each drone is retried with backoff until it connects or ctx is cancelled.
+// Attempts go through Connect, so they coexist with manual Connect calls: a drone
+// connected manually in the meantime counts as connected. An auto-connected
+// drone only becomes the active drone while none is. Once connected,
+// gomavlib's reconnect loop keeps the link up; a later Disconnect is not undone.
+func (s *ConnectionServer) AutoConnect(ctx context.Context) {
+	logger := s.deps.GetLogger()
+
+	for _, droneConfig := range s.deps.GetDroneRegistry().Drones {
+		if !droneConfig.GetConnectionBool("auto_connect") {
+			continue
+		}
+		if droneConfig.Protocol != "mavlink" {
+			logger.Printf("Auto-connect: Warning - skipping %s: protocol %s not supported",
+				droneConfig.ID, droneConfig.Protocol)
+			continue
+		}
+
+		logger.Printf("Auto-connect: Connecting to %s", droneConfig.ID)
+		go s.autoConnect(ctx, droneConfig.ID)
+	}
+}
+
+// autoConnect retries Connect for one drone until it succeeds or ctx is cancelled
+func (s *ConnectionServer) autoConnect(ctx context.Context, droneID string) {
+	logger := s.deps.GetLogger()
+	delay := autoConnectInitialDelay
+
+	for attempt := 1; ; attempt++ {
+		// Without activating, so it doesn't take over the drone an operator selected
+		resp, err := s.connect(ctx, connect.NewRequest(&drone.ConnectRequest{DroneId: droneID}), false)
+		if err == nil && resp.Msg.Success {
+			logger.Printf("Auto-connect: %s connected (attempt %d)", droneID, attempt)
+			return
+		}
+
+		var message string
+		if err != nil {
+			message = err.Error()
+		} else {
+			message = resp.Msg.Message
+		}
+		logger.Printf("Auto-connect: Warning - %s attempt %d failed: %s (retrying in %s)",
+			droneID, attempt, message, delay)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(delay):
+		}
+
+		delay = min(delay*2, autoConnectMaxDelay)
+	}
+}
+
+// ReconnectDownLinks reconnects drones whose link stopped accepting writes in
+// the background, until ctx is cancelled
+// Heartbeats can keep arriving over such a link, so gomavlib's own reconnect
+// loop may never kick in. The client is closed and the drone reconnected with
+// auto-connect's retry and backoff; it doesn't take over the active drone.
+func (s *ConnectionServer) ReconnectDownLinks(ctx context.Context) {
+	go func() {
+		ticker := time.NewTicker(linkCheckInterval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				for _, droneID := range s.deps.GetMAVLinkDroneIDs() {
+					if client, ok := s.deps.GetMAVLinkClientFor(droneID); ok && client.IsWriteDown() {
+						s.reconnectDownLink(ctx, droneID)
+					}
+				}
+			}
+		}
+	}()
+}
+
+// reconnectDownLink replaces one drone's write-down client and starts
+// reconnecting it
+func (s *ConnectionServer) reconnectDownLink(ctx context.Context, droneID string) {
+	// Connect and Disconnect hold the lock; recheck once it's ours
+	unlock := s.deps.LockDrone(droneID)
+	client, ok := s.deps.GetMAVLinkClientFor(droneID)
+	if !ok || !client.IsWriteDown() {
+		unlock()
+		return
+	}
+
+	s.deps.GetLogger().Printf("Link down: Warning - writes to %s keep failing, reconnecting (armed=%v)",
+		droneID, client.IsArmed())
+	s.replaceStaleClient(droneID, client)
+	unlock()
+
+	go s.autoConnect(ctx, droneID)
+}
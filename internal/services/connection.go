@@ -3,6 +3,7 @@ package services
 import (
 	"context"
 	"fmt"
+	"strconv"
 	"time"
 
 	"connectrpc.com/connect"
@@ -11,6 +12,7 @@ import (
 	"github.com/flightpath-dev/flightpath-server/internal/config"
 	"github.com/flightpath-dev/flightpath-server/internal/mavlink"
 	"github.com/flightpath-dev/flightpath-server/internal/server"
+	"github.com/flightpath-dev/flightpath-server/internal/track"
 )
 
 // ConnectionServer implements the ConnectionService
@@ -29,6 +31,18 @@ func (s *ConnectionServer) Connect(
 	ctx context.Context,
 	req *connect.Request[drone.ConnectRequest],
 ) (*connect.Response[drone.ConnectResponse], error) {
+	return s.connect(ctx, req, true)
+}
+
+// connect connects a registry drone; activate makes it the active drone,
+// otherwise it only becomes active while no drone is (auto-connect)
+func (s *ConnectionServer) connect(
+	ctx context.Context,
+	req *connect.Request[drone.ConnectRequest],
+	activate bool,
+) (resp *connect.Response[drone.ConnectResponse], err error) {
+	defer func() { auditResponse(ctx, s.deps, "connect", req.Msg.DroneId, req.Msg, resp, err) }()
+
 	logger := s.deps.GetLogger()
 	logger.Printf("Connect request: drone_id=%s", req.Msg.DroneId)
 
@@ -40,39 +54,79 @@ func (s *ConnectionServer) Connect(
 		}), nil
 	}
 
-	// Check if already connected
-	if s.deps.HasMAVLinkClient() {
-		client := s.deps.GetMAVLinkClient()
-		if client.IsConnected() {
-			return connect.NewResponse(&drone.ConnectResponse{
-				Success: false,
-				Message: "Already connected to a drone. Disconnect first.",
-			}), nil
-		}
-
-		// Clean up old disconnected client
-		client.Close()
+	if req.Msg.TimeoutMs < 0 {
+		return nil, connect.NewError(connect.CodeInvalidArgument,
+			fmt.Errorf("timeout_ms must not be negative: %d", req.Msg.TimeoutMs))
 	}
 
+	// Serialize Connect per drone so concurrent requests can't race to replace
+	// its client; other drones connect in parallel
+	defer s.deps.LockDrone(req.Msg.DroneId)()
+
 	// Look up drone in registry
 	registry := s.deps.GetDroneRegistry()
 	droneConfig, err := registry.FindDrone(req.Msg.DroneId)
 	if err != nil {
 		// Drone not found in registry
+		message := fmt.Sprintf("Drone not found in registry: %s. Available drones: %v",
+			req.Msg.DroneId, s.getAvailableDroneIDs())
+		if status := s.deps.RegistryStatus; status.State != config.RegistryLoaded {
+			message = fmt.Sprintf("Drone not found: the drone registry at %s is %s (%s)",
+				status.Path, status.State, status.Error)
+		}
 		return connect.NewResponse(&drone.ConnectResponse{
 			Success: false,
-			Message: fmt.Sprintf("Drone not found in registry: %s. Available drones: %v",
-				req.Msg.DroneId, s.getAvailableDroneIDs()),
+			Message: message,
 		}), nil
 	}
 
+	// Check if this drone is already connected
+	if client, ok := s.deps.GetMAVLinkClientFor(droneConfig.ID); ok {
+		if client.IsConnected() {
+			// Select it as the active drone (auto-connect leaves that alone) instead of
+			// opening a second link
+			message := fmt.Sprintf("Already connected to %s", droneConfig.Name)
+			if activate {
+				s.deps.SetActiveDrone(droneConfig.ID)
+				message += " (now the active drone)"
+			}
+			return connect.NewResponse(&drone.ConnectResponse{
+				Success:   true,
+				Message:   message,
+				DroneId:   droneConfig.ID,
+				DroneName: droneConfig.Name,
+				Model:     droneConfig.Description,
+			}), nil
+		}
+
+		// gomavlib keeps retrying the link, so the old client may come back on its own
+		if s.deps.Config.MAVLink.StaleClientPolicy == config.StaleClientReuse {
+			logger.Printf("Waiting for existing client of %s to reconnect", droneConfig.ID)
+			if err := client.WaitForConnection(s.connectTimeout(req)); err == nil {
+				if activate {
+					s.deps.SetActiveDrone(droneConfig.ID)
+				}
+				return connect.NewResponse(&drone.ConnectResponse{
+					Success:   true,
+					Message:   fmt.Sprintf("Reconnected to %s (System ID: %d)", droneConfig.Name, client.GetSystemID()),
+					DroneId:   droneConfig.ID,
+					DroneName: droneConfig.Name,
+					Model:     droneConfig.Description,
+				}), nil
+			}
+			logger.Printf("Existing client for %s did not reconnect", droneConfig.ID)
+		}
+
+		s.replaceStaleClient(droneConfig.ID, client)
+	}
+
 	logger.Printf("Found drone in registry: %s (%s) using protocol: %s",
 		droneConfig.ID, droneConfig.Name, droneConfig.Protocol)
 
 	// Route to appropriate protocol handler
 	switch droneConfig.Protocol {
 	case "mavlink":
-		return s.connectMAVLink(ctx, req, droneConfig)
+		return s.connectMAVLink(ctx, req, droneConfig, activate)
 	case "dji":
 		// TODO: Implement DJI protocol
 		return connect.NewResponse(&drone.ConnectResponse{
@@ -92,6 +146,7 @@ func (s *ConnectionServer) connectMAVLink(
 	ctx context.Context,
 	req *connect.Request[drone.ConnectRequest],
 	droneConfig *config.DroneConfig,
+	activate bool,
 ) (*connect.Response[drone.ConnectResponse], error) {
 	logger := s.deps.GetLogger()
 
@@ -108,19 +163,155 @@ func (s *ConnectionServer) connectMAVLink(
 		logger.Printf("No baud rate specified in config, using default: %d", baudRate)
 	}
 
-	logger.Printf("Connecting to MAVLink drone on %s at %d baud", port, baudRate)
+	systemID := droneConfig.GetConnectionInt("system_id")
+	if systemID < 0 || systemID > 255 {
+		return connect.NewResponse(&drone.ConnectResponse{
+			Success: false,
+			Message: fmt.Sprintf("Invalid system_id in drone config: %d (must be 1-255)", systemID),
+		}), nil
+	}
+
+	maxMissionItems := s.deps.Config.MAVLink.MaxMissionItems
+	if n := droneConfig.GetConnectionInt("max_mission_items"); n > 0 {
+		maxMissionItems = n
+	}
+
+	staleTimeout := s.deps.Config.MAVLink.TelemetryStaleTimeout
+	if staleMs := droneConfig.GetConnectionInt("telemetry_stale_ms"); staleMs > 0 {
+		staleTimeout = time.Duration(staleMs) * time.Millisecond
+	}
+
+	profileName := droneConfig.GetConnectionString("telemetry_profile")
+	if t := droneConfig.Telemetry; t != nil && t.Profile != "" {
+		profileName = t.Profile
+	}
+	if profileName == "" {
+		profileName = s.deps.Config.MAVLink.TelemetryProfile
+	}
+	var messageRates map[string]float64
+	if profileName != "" {
+		profile, ok := s.deps.Config.MAVLink.TelemetryProfiles[profileName]
+		if !ok {
+			return connect.NewResponse(&drone.ConnectResponse{
+				Success: false,
+				Message: fmt.Sprintf("Unknown telemetry_profile in drone config: %s", profileName),
+			}), nil
+		}
+		messageRates = profile
+	}
+	if t := droneConfig.Telemetry; t != nil && len(t.Rates) > 0 {
+		if err := mavlink.ValidateMessageRates(t.Rates); err != nil {
+			return connect.NewResponse(&drone.ConnectResponse{
+				Success: false,
+				Message: fmt.Sprintf("Invalid telemetry rates in drone config: %v", err),
+			}), nil
+		}
+		// Copy so the shared profile isn't modified
+		merged := make(map[string]float64, len(messageRates)+len(t.Rates))
+		for name, hz := range messageRates {
+			merged[name] = hz
+		}
+		for name, hz := range t.Rates {
+			merged[name] = hz
+		}
+		messageRates = merged
+	}
 
-	// Get timeout (use from request or default to 5 seconds)
-	timeout := 5 * time.Second
-	if req.Msg.TimeoutMs > 0 {
-		timeout = time.Duration(req.Msg.TimeoutMs) * time.Millisecond
+	sendSystemTime := s.deps.Config.MAVLink.SendSystemTime
+	if send, ok := droneConfig.LookupConnectionBool("send_system_time"); ok {
+		sendSystemTime = send
+	}
+	rerequestStreams := s.deps.Config.MAVLink.RerequestStreams
+	if rerequest, ok := droneConfig.LookupConnectionBool("rerequest_streams"); ok {
+		rerequestStreams = rerequest
 	}
 
+	var allowedArea *mavlink.AllowedArea
+	if area := droneConfig.AllowedArea; area != nil {
+		allowedArea = &mavlink.AllowedArea{
+			MinLatitude:  area.MinLatitude,
+			MaxLatitude:  area.MaxLatitude,
+			MinLongitude: area.MinLongitude,
+			MaxLongitude: area.MaxLongitude,
+		}
+	}
+
+	var maxAltitude *mavlink.AltitudeLimit
+	if limit := droneConfig.MaxAltitude; limit != nil {
+		if limit.Action != "" && limit.Action != config.MaxAltitudeReject && limit.Action != config.MaxAltitudeClamp {
+			return connect.NewResponse(&drone.ConnectResponse{
+				Success: false,
+				Message: fmt.Sprintf("Invalid max_altitude action in drone config: %q (must be %s or %s)",
+					limit.Action, config.MaxAltitudeReject, config.MaxAltitudeClamp),
+			}), nil
+		}
+		maxAltitude = &mavlink.AltitudeLimit{
+			Max:   limit.Altitude,
+			Clamp: limit.Action == config.MaxAltitudeClamp,
+		}
+	}
+
+	if _, err := allowedFlightModes(droneConfig); err != nil {
+		return connect.NewResponse(&drone.ConnectResponse{
+			Success: false,
+			Message: fmt.Sprintf("Invalid drone config: %v", err),
+		}), nil
+	}
+
+	var trackSink mavlink.TrackSink
+	if dir := s.deps.Config.Export.TrackDir; dir != "" {
+		trackSink = track.NewDir(dir, droneConfig.ID)
+	}
+
+	logger.Printf("Connecting to MAVLink drone on %s at %d baud", port, baudRate)
+
+	timeout := s.connectTimeout(req)
+
 	// Create MAVLink client
 	client, err := mavlink.NewClient(mavlink.Config{
-		Port:     port,
-		BaudRate: baudRate,
-		Logger:   logger,
+		Port:           port,
+		BaudRate:       baudRate,
+		Logger:         logger,
+		PassiveMode:    droneConfig.GetConnectionBool("passive"),
+		TargetSystemID: uint8(systemID),
+		Serial: mavlink.SerialConfig{
+			DataBits:    droneConfig.GetConnectionInt("data_bits"),
+			Parity:      droneConfig.GetConnectionString("parity"),
+			StopBits:    droneConfig.GetConnectionInt("stop_bits"),
+			FlowControl: droneConfig.GetConnectionString("flow_control"),
+		},
+
+		MessageRates:          messageRates,
+		InboundMessages:       droneConfig.GetConnectionStringList("inbound_messages"),
+		TelemetryStaleTimeout: staleTimeout,
+		MaxMissionItems:       maxMissionItems,
+		CommandRetries:        s.deps.Config.MAVLink.CommandRetries,
+		WriteRetries:          s.deps.Config.MAVLink.WriteRetries,
+		CommandAckTimeout:     s.deps.Config.MAVLink.CommandAckTimeout,
+		CommandAckTimeouts:    s.deps.Config.MAVLink.CommandAckTimeouts,
+		ParamCacheMaxAge:      s.deps.Config.MAVLink.ParamCacheMaxAge,
+		GoToSetpointRate:      s.deps.Config.MAVLink.GoToSetpointRate,
+		GoToAcceptanceRadius:  s.deps.Config.MAVLink.GoToAcceptanceRadius,
+		GoToHoldOnStop:        s.deps.Config.MAVLink.GoToHoldOnStop,
+		CorrectTimestamps: s.deps.Config.MAVLink.CorrectTimestamps ||
+			droneConfig.GetConnectionBool("correct_timestamps"),
+
+		WaypointAcceptanceRadius: s.deps.Config.MAVLink.MissionAcceptanceRadius,
+		WaitAtWaypoints:          !s.deps.Config.MAVLink.MissionAutocontinue,
+
+		AllowedArea:     allowedArea,
+		PushAllowedArea: allowedArea != nil && droneConfig.AllowedArea.Push,
+
+		MaxAltitude: maxAltitude,
+
+		DisableSystemTime: !sendSystemTime,
+		RerequestStreams:  rerequestStreams,
+		Terrain:           s.deps.Terrain,
+		TrackSink:         trackSink,
+
+		GCSSystemStatus:     s.deps.Config.MAVLink.GCSSystemStatus,
+		GCSIdentity:         s.deps.Config.MAVLink.GCSIdentity,
+		GCSIdentityInterval: s.deps.Config.MAVLink.GCSIdentityInterval,
 	})
 	if err != nil {
 		return connect.NewResponse(&drone.ConnectResponse{
@@ -134,12 +325,16 @@ func (s *ConnectionServer) connectMAVLink(
 		client.Close()
 		return connect.NewResponse(&drone.ConnectResponse{
 			Success: false,
-			Message: fmt.Sprintf("Connection timeout: %v", err),
+			Message: fmt.Sprintf("Connection failed: %v", client.DiagnoseConnectFailure()),
 		}), nil
 	}
 
-	// Store client in dependencies
-	s.deps.SetMAVLinkClient(client)
+	// Store client in dependencies (becomes the active drone)
+	if activate {
+		s.deps.SetMAVLinkClient(droneConfig.ID, client)
+	} else {
+		s.deps.AddMAVLinkClient(droneConfig.ID, client)
+	}
 
 	logger.Printf("Successfully connected to drone %s (MAVLink System ID: %d)",
 		droneConfig.ID, client.GetSystemID())
@@ -151,10 +346,35 @@ func (s *ConnectionServer) connectMAVLink(
 		DroneName:    droneConfig.Name,
 		Manufacturer: "PX4", // TODO: Get from AUTOPILOT_VERSION message
 		Model:        droneConfig.Description,
-		// TODO: Get capabilities from drone
+		// ConnectResponse has no capabilities field; see GetCapabilities
 	}), nil
 }
 
+// connectTimeout returns the heartbeat timeout for a connect request
+// Uses the timeout from the request or defaults to 5 seconds
+func (s *ConnectionServer) connectTimeout(req *connect.Request[drone.ConnectRequest]) time.Duration {
+	if req.Msg.TimeoutMs <= 0 {
+		return 5 * time.Second
+	}
+
+	// Connect holds the drone's lock and the serial port while waiting
+	timeout := time.Duration(req.Msg.TimeoutMs) * time.Millisecond
+	if maxTimeout := s.deps.Config.MAVLink.MaxConnectTimeout; timeout > maxTimeout {
+		s.deps.GetLogger().Printf("Connect timeout %s exceeds maximum, using %s", timeout, maxTimeout)
+		return maxTimeout
+	}
+	return timeout
+}
+
+// replaceStaleClient removes a drone's disconnected client and shuts it down
+// The client is removed first so no RPC picks it up while closing; Close cancels
+// gomavlib's reconnect loop and waits for the client's goroutines to stop.
+func (s *ConnectionServer) replaceStaleClient(droneID string, client *mavlink.Client) {
+	s.deps.GetLogger().Printf("Replacing stale MAVLink client for %s", droneID)
+	s.deps.RemoveMAVLinkClient(droneID)
+	client.Close()
+}
+
 // getAvailableDroneIDs returns list of configured drone IDs
 func (s *ConnectionServer) getAvailableDroneIDs() []string {
 	registry := s.deps.GetDroneRegistry()
@@ -165,44 +385,55 @@ func (s *ConnectionServer) getAvailableDroneIDs() []string {
 	return ids
 }
 
+// CommandsEnabledHeader on GetStatus responses is "false" while state-changing
+// requests are refused (safe boot); GetStatusResponse has no field for it yet
+const CommandsEnabledHeader = "Flightpath-Commands-Enabled"
+
 func (s *ConnectionServer) GetStatus(
 	ctx context.Context,
 	req *connect.Request[drone.GetStatusRequest],
 ) (*connect.Response[drone.GetStatusResponse], error) {
 	s.deps.GetLogger().Println("GetStatus request")
 
+	status := &drone.GetStatusResponse{}
+
 	// Check if MAVLink client exists
-	if !s.deps.HasMAVLinkClient() {
-		return connect.NewResponse(&drone.GetStatusResponse{
-			Connected: false,
-			Armed:     false,
-		}), nil
-	}
+	if client, ok := s.deps.GetMAVLinkClientFor(targetDrone(ctx, s.deps, "")); ok {
+		s.deps.MarkMAVLinkClientUsed(client)
 
-	client := s.deps.GetMAVLinkClient()
+		status.Connected = client.IsConnected()
+		status.Armed = client.IsArmed()
+	}
 
-	return connect.NewResponse(&drone.GetStatusResponse{
-		Connected: client.IsConnected(),
-		Armed:     client.IsArmed(),
-	}), nil
+	resp := connect.NewResponse(status)
+	resp.Header().Set(CommandsEnabledHeader, strconv.FormatBool(s.deps.CommandsEnabled()))
+	return resp, nil
 }
 
 func (s *ConnectionServer) Disconnect(
 	ctx context.Context,
 	req *connect.Request[drone.DisconnectRequest],
-) (*connect.Response[drone.DisconnectResponse], error) {
+) (resp *connect.Response[drone.DisconnectResponse], err error) {
+	// Resolved now: disconnecting removes the client
+	droneID := targetDrone(ctx, s.deps, "")
+	defer func() { auditResponse(ctx, s.deps, "disconnect", droneID, req.Msg, resp, err) }()
+
 	logger := s.deps.GetLogger()
 	logger.Println("Disconnect request")
 
-	// Check if MAVLink client exists
-	if !s.deps.HasMAVLinkClient() {
+	client, err := requireReadyClient(ctx, s.deps, droneID, policyClient)
+	if err != nil {
 		return connect.NewResponse(&drone.DisconnectResponse{
 			Success: false,
-			Message: "Not connected to any drone",
+			Message: errorMessage(err),
 		}), nil
 	}
 
-	client := s.deps.GetMAVLinkClient()
+	if force, _ := strconv.ParseBool(req.Header().Get(ForceDisconnectHeader)); force {
+		logger.Printf("Disconnect: forced (armed=%v)", client.IsArmed())
+	} else if err := s.prepareDisconnect(client); err != nil {
+		return nil, err
+	}
 
 	// Close the connection
 	if err := client.Close(); err != nil {
@@ -213,7 +444,7 @@ func (s *ConnectionServer) Disconnect(
 	}
 
 	// Remove client from dependencies after closing
-	s.deps.ClearMAVLinkClient()
+	s.deps.RemoveMAVLinkClient(droneID)
 
 	logger.Println("Successfully disconnected from drone")
 
@@ -223,6 +454,148 @@ func (s *ConnectionServer) Disconnect(
 	}), nil
 }
 
+// forceResetCloseTimeout is how long ForceReset waits for the old client to close
+const forceResetCloseTimeout = 3 * time.Second
+
+// ForceReset abandons a drone's client so a fresh Connect can replace it
+// A recovery tool for a wedged client (e.g. a stuck MAVLink node): it doesn't
+// wait for the drone's lock, applies no disconnect-while-armed policy, and if
+// the client won't close within forceResetCloseTimeout its goroutines are left
+// to finish on their own. An empty droneID means the active drone.
+func (s *ConnectionServer) ForceReset(ctx context.Context, droneID string) (resp *CommandResponse, err error) {
+	defer func() { recordAudit(ctx, s.deps, "force_reset", droneID, nil, resp, err) }()
+
+	logger := s.deps.GetLogger()
+
+	droneID = targetDrone(ctx, s.deps, droneID)
+	client, ok := s.deps.GetMAVLinkClientFor(droneID)
+	if !ok {
+		return nil, connect.NewError(connect.CodeNotFound,
+			fmt.Errorf("drone %q has no client to reset", droneID))
+	}
+
+	logger.Printf("Warning: ForceReset of drone %s: abandoning its MAVLink client (armed=%v)",
+		droneID, client.IsArmed())
+
+	// Detach first so nothing new reaches the old client while it closes
+	s.deps.RemoveMAVLinkClient(droneID)
+	s.deps.ResetDroneLock(droneID)
+
+	message := "Client closed and removed; Connect to start a new one"
+	if !client.CloseTimeout(forceResetCloseTimeout) {
+		logger.Printf("ERROR: ForceReset of drone %s: client did not close within %s; abandoned it and its goroutines",
+			droneID, forceResetCloseTimeout)
+		message = fmt.Sprintf("Client did not close within %s and was abandoned; "+
+			"its serial port is released once it unblocks", forceResetCloseTimeout)
+	} else {
+		logger.Printf("ForceReset of drone %s: client closed", droneID)
+	}
+
+	return &CommandResponse{
+		Success: true,
+		Message: message,
+	}, nil
+}
+
+// ForceDisconnectHeader set to "true" closes the link even while the drone is armed
+const ForceDisconnectHeader = "Flightpath-Force-Disconnect"
+
+// prepareDisconnect applies the disconnect-while-armed policy to a flying drone
+// Returns a FailedPrecondition error describing the risk when the policy refuses.
+func (s *ConnectionServer) prepareDisconnect(client *mavlink.Client) error {
+	if !client.IsConnected() || !client.IsArmed() {
+		return nil
+	}
+
+	risk := "the drone is armed"
+	if mavlink.IsMissionMode(client.GetTelemetry().CustomMode) {
+		risk = "the drone is flying a mission"
+	}
+
+	logger := s.deps.GetLogger()
+
+	switch s.deps.Config.MAVLink.DisconnectWhileArmed {
+	case config.DisconnectRTL:
+		logger.Printf("Disconnect: %s, commanding RTL first", risk)
+		if err := client.ReturnToLaunch(); err != nil {
+			return connect.NewError(connect.CodeUnavailable,
+				fmt.Errorf("not disconnecting: %s and RTL could not be sent: %w", risk, err))
+		}
+	case config.DisconnectHold:
+		logger.Printf("Disconnect: %s, commanding hold first", risk)
+		if err := client.SetMode(uint32(mavlink.PX4_MAIN_MODE_AUTO | (mavlink.PX4_AUTO_MODE_LOITER << 16))); err != nil {
+			return connect.NewError(connect.CodeUnavailable,
+				fmt.Errorf("not disconnecting: %s and hold could not be sent: %w", risk, err))
+		}
+	default:
+		logger.Printf("Disconnect: Warning - refused, %s", risk)
+		return connect.NewError(connect.CodeFailedPrecondition,
+			fmt.Errorf("refusing to disconnect: %s and would continue without a ground station, "+
+				"leaving it to the vehicle's GCS-loss failsafe; land it first or set %s: true",
+				risk, ForceDisconnectHeader))
+	}
+	return nil
+}
+
+// GetConnectionInfo returns link statistics and vehicle identity for a drone
+// An empty droneID means the active drone.
+func (s *ConnectionServer) GetConnectionInfo(ctx context.Context, droneID string) (*mavlink.ConnectionInfo, error) {
+	s.deps.GetLogger().Printf("GetConnectionInfo request: drone_id=%s", droneID)
+
+	droneID = targetDrone(ctx, s.deps, droneID)
+
+	client, ok := s.deps.GetMAVLinkClientFor(droneID)
+	if !ok {
+		return nil, connect.NewError(connect.CodeNotFound,
+			fmt.Errorf("drone %q is not connected", droneID))
+	}
+	s.deps.MarkMAVLinkClientUsed(client)
+
+	info := client.GetConnectionInfo()
+	return &info, nil
+}
+
+// GetCapabilities returns what a connected drone reports it supports (missions,
+// fences, rally points, position targets, VTOL, gimbal, camera, parameters)
+// and the flight modes its allowed_flight_modes permit
+// An empty droneID means the active drone. The protocol flags stay false, with
+// Reported false, until the vehicle answers the AUTOPILOT_VERSION request sent
+// after connecting.
+func (s *ConnectionServer) GetCapabilities(ctx context.Context, droneID string) (*mavlink.Capabilities, error) {
+	s.deps.GetLogger().Printf("GetCapabilities request: drone_id=%s", droneID)
+
+	client, err := requireReadyClient(ctx, s.deps, droneID, policyClient)
+	if err != nil {
+		return nil, err
+	}
+
+	caps := client.GetCapabilities()
+	modes, err := allowedFlightModesFor(ctx, s.deps, droneID)
+	if err != nil {
+		return nil, connect.NewError(connect.CodeFailedPrecondition, err)
+	}
+	caps.FlightModes = flightModeNames(modes)
+	return &caps, nil
+}
+
+// Diagnostics reports server-side state that explains failing requests
+type Diagnostics struct {
+	Registry config.RegistryStatus `json:"registry"`
+
+	// False while state-changing requests are refused (safe boot)
+	CommandsEnabled bool `json:"commands_enabled"`
+}
+
+// GetDiagnostics returns the registry load state and error
+func (s *ConnectionServer) GetDiagnostics(ctx context.Context) *Diagnostics {
+	s.deps.GetLogger().Println("GetDiagnostics request")
+
+	return &Diagnostics{
+		Registry:        s.deps.RegistryStatus,
+		CommandsEnabled: s.deps.CommandsEnabled(),
+	}
+}
+
 func (s *ConnectionServer) ListDrones(
 	ctx context.Context,
 	req *connect.Request[drone.ListDronesRequest],
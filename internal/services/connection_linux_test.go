@@ -0,0 +1,474 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"connectrpc.com/connect"
+	"github.com/bluenviron/gomavlib/v3"
+	"github.com/bluenviron/gomavlib/v3/pkg/dialects/common"
+
+	drone "github.com/flightpath-dev/flightpath-proto/gen/go/drone/v1"
+	"github.com/flightpath-dev/flightpath-server/internal/config"
+	"github.com/flightpath-dev/flightpath-server/internal/mavlink"
+	"github.com/flightpath-dev/flightpath-server/internal/server"
+)
+
+// silentDrone is a simulated vehicle on a pty that stays quiet until
+// heartbeats is called, if ever, then sends one every 50 ms until the test ends
+func silentDrone(t *testing.T) (vehicle *gomavlib.Node, device string, heartbeats func()) {
+	t.Helper()
+	return simulatedDrone(t, &common.MessageHeartbeat{
+		Type:      common.MAV_TYPE_QUADROTOR,
+		Autopilot: common.MAV_AUTOPILOT_PX4,
+	})
+}
+
+// simulatedDrone is silentDrone sending heartbeat, e.g. to report it armed
+func simulatedDrone(t *testing.T, heartbeat *common.MessageHeartbeat) (vehicle *gomavlib.Node, device string, heartbeats func()) {
+	t.Helper()
+	var err error
+	master, device := openPTY(t)
+	vehicle, err = gomavlib.NewNode(gomavlib.NodeConf{
+		Endpoints:        []gomavlib.EndpointConf{gomavlib.EndpointCustom{ReadWriteCloser: master}},
+		Dialect:          common.Dialect,
+		OutVersion:       gomavlib.V2,
+		OutSystemID:      1,
+		OutComponentID:   1,
+		HeartbeatDisable: true,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	done := make(chan struct{})
+	var sending sync.WaitGroup
+	t.Cleanup(func() {
+		close(done)
+		sending.Wait()
+		// The node's reader only returns once the pty is gone
+		master.Close()
+		vehicle.Close()
+	})
+	return vehicle, device, func() {
+		sending.Add(1)
+		go func() {
+			defer sending.Done()
+			for {
+				vehicle.WriteMessageAll(heartbeat) //nolint:errcheck
+				select {
+				case <-done:
+					return
+				case <-time.After(50 * time.Millisecond):
+				}
+			}
+		}()
+	}
+}
+
+// registerDrone adds a passive MAVLink drone on device to the registry
+func registerDrone(deps *server.Dependencies, droneID, device string) {
+	registry := deps.GetDroneRegistry()
+	registry.Drones = append(registry.Drones, config.DroneConfig{
+		ID:       droneID,
+		Name:     strings.ToUpper(droneID),
+		Protocol: "mavlink",
+		Connection: map[string]interface{}{
+			"port":      device,
+			"baud_rate": 57600,
+			"passive":   true,
+		},
+	})
+}
+
+// staleClient returns a passive client for device that has heard no heartbeat
+// With non-default serial settings the device is opened lazily, so a missing
+// one leaves the client retrying in the background, as after a lost link.
+func staleClient(t *testing.T, device string) *mavlink.Client {
+	t.Helper()
+	client, err := mavlink.NewClient(mavlink.Config{
+		Port:        device,
+		BaudRate:    57600,
+		Serial:      mavlink.SerialConfig{StopBits: 2},
+		PassiveMode: true,
+		Logger:      log.New(io.Discard, "", 0),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { client.Close() })
+	return client
+}
+
+func connectDrone(t *testing.T, deps *server.Dependencies, droneID string, timeout time.Duration) *drone.ConnectResponse {
+	t.Helper()
+	resp, err := NewConnectionServer(deps).Connect(context.Background(), connect.NewRequest(&drone.ConnectRequest{
+		DroneId:   droneID,
+		TimeoutMs: int32(timeout / time.Millisecond),
+	}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		if client, ok := deps.GetMAVLinkClientFor(droneID); ok {
+			client.Close()
+		}
+	})
+	return resp.Msg
+}
+
+func TestConnectReplacesReconnectingClient(t *testing.T) {
+	deps := newTestDependencies(t)
+	_, device, heartbeats := silentDrone(t)
+	registerDrone(deps, "alpha", device)
+	heartbeats()
+
+	old := staleClient(t, filepath.Join(t.TempDir(), "ttyGone"))
+	deps.AddMAVLinkClient("alpha", old)
+
+	resp := connectDrone(t, deps, "alpha", 2*time.Second)
+	if !resp.Success || !strings.HasPrefix(resp.Message, "Connected to") {
+		t.Fatalf("Connect = %v: %s", resp.Success, resp.Message)
+	}
+	client, ok := deps.GetMAVLinkClientFor("alpha")
+	if !ok || client == old {
+		t.Fatal("stale client not replaced")
+	}
+	if !client.IsConnected() {
+		t.Error("replacement client not connected")
+	}
+	// Replacing closed the old client: its reconnect loop and goroutines are gone
+	if !old.CloseTimeout(100 * time.Millisecond) {
+		t.Error("old client still shutting down")
+	}
+}
+
+func TestConnectReusesReconnectingClient(t *testing.T) {
+	deps := newTestDependencies(t)
+	deps.Config.MAVLink.StaleClientPolicy = config.StaleClientReuse
+	_, device, heartbeats := silentDrone(t)
+	registerDrone(deps, "alpha", device)
+
+	old := staleClient(t, device)
+	deps.AddMAVLinkClient("alpha", old)
+
+	// The vehicle comes back while Connect waits on the old client
+	time.AfterFunc(200*time.Millisecond, heartbeats)
+	resp := connectDrone(t, deps, "alpha", 3*time.Second)
+	if !resp.Success || !strings.HasPrefix(resp.Message, "Reconnected to") {
+		t.Fatalf("Connect = %v: %s", resp.Success, resp.Message)
+	}
+	if client, _ := deps.GetMAVLinkClientFor("alpha"); client != old {
+		t.Error("reconnected client replaced")
+	}
+}
+
+func TestConnectReplacesClientThatDoesNotReconnect(t *testing.T) {
+	deps := newTestDependencies(t)
+	deps.Config.MAVLink.StaleClientPolicy = config.StaleClientReuse
+	_, device, heartbeats := silentDrone(t)
+	registerDrone(deps, "alpha", device)
+	heartbeats()
+
+	// Retrying a device that's gone: the wait for it times out
+	old := staleClient(t, filepath.Join(t.TempDir(), "ttyGone"))
+	deps.AddMAVLinkClient("alpha", old)
+
+	resp := connectDrone(t, deps, "alpha", 500*time.Millisecond)
+	if !resp.Success || !strings.HasPrefix(resp.Message, "Connected to") {
+		t.Fatalf("Connect = %v: %s", resp.Success, resp.Message)
+	}
+	if client, _ := deps.GetMAVLinkClientFor("alpha"); client == old {
+		t.Error("client that never reconnected kept")
+	}
+}
+
+func TestPassiveDroneSendsNothing(t *testing.T) {
+	deps := newTestDependencies(t)
+	vehicle, device, heartbeats := silentDrone(t)
+	registerDrone(deps, "alpha", device)
+	heartbeats()
+
+	if resp := connectDrone(t, deps, "alpha", 2*time.Second); !resp.Success {
+		t.Fatalf("Connect: %s", resp.Message)
+	}
+	client, _ := deps.GetMAVLinkClientFor("alpha")
+	if !client.GetConnectionInfo().Passive {
+		t.Fatal("registry passive setting not applied")
+	}
+	if err := client.Arm(); !errors.Is(err, mavlink.ErrPassiveMode) {
+		t.Errorf("Arm() = %v, want ErrPassiveMode", err)
+	}
+
+	// Longer than the 1 Hz GCS heartbeat and SYSTEM_TIME, and the stream
+	// requests and version handshake sent after connecting
+	quiet := time.After(1500 * time.Millisecond)
+	for {
+		select {
+		case evt := <-vehicle.Events():
+			if frame, ok := evt.(*gomavlib.EventFrame); ok {
+				t.Fatalf("passive client sent %T", frame.Message())
+			}
+		case <-quiet:
+			return
+		}
+	}
+}
+
+func TestConnectDiagnosesMissingDevice(t *testing.T) {
+	deps := newTestDependencies(t)
+	registerDrone(deps, "alpha", filepath.Join(t.TempDir(), "ttyGone"))
+
+	resp := connectDrone(t, deps, "alpha", 300*time.Millisecond)
+	if resp.Success || !strings.Contains(resp.Message, mavlink.ErrDeviceNotFound.Error()) {
+		t.Errorf("Connect = %v: %s", resp.Success, resp.Message)
+	}
+}
+
+func TestConnectDiagnosesMissingHeartbeat(t *testing.T) {
+	deps := newTestDependencies(t)
+	_, device, _ := silentDrone(t)
+	registerDrone(deps, "alpha", device)
+
+	resp := connectDrone(t, deps, "alpha", 300*time.Millisecond)
+	if resp.Success || !strings.Contains(resp.Message, mavlink.ErrNoHeartbeat.Error()) {
+		t.Errorf("Connect = %v: %s", resp.Success, resp.Message)
+	}
+}
+
+func TestConnectAppliesDroneMissionLimit(t *testing.T) {
+	deps := newTestDependencies(t)
+	deps.Config.MAVLink.MaxMissionItems = 500
+	_, device, heartbeats := silentDrone(t)
+	registerDrone(deps, "alpha", device)
+	deps.GetDroneRegistry().Drones[0].Connection["max_mission_items"] = 50
+	heartbeats()
+
+	if resp := connectDrone(t, deps, "alpha", 2*time.Second); !resp.Success {
+		t.Fatalf("Connect: %s", resp.Message)
+	}
+	client, _ := deps.GetMAVLinkClientFor("alpha")
+	if got := client.MaxMissionItems(); got != 50 {
+		t.Errorf("mission limit = %d, want the registry's 50", got)
+	}
+}
+
+// connectArmedDrone connects drone alpha, reporting itself armed in customMode
+func connectArmedDrone(t *testing.T, deps *server.Dependencies, customMode uint32) *mavlink.Client {
+	t.Helper()
+	_, device, heartbeats := simulatedDrone(t, &common.MessageHeartbeat{
+		Type:       common.MAV_TYPE_QUADROTOR,
+		Autopilot:  common.MAV_AUTOPILOT_PX4,
+		BaseMode:   common.MAV_MODE_FLAG_SAFETY_ARMED | common.MAV_MODE_FLAG_CUSTOM_MODE_ENABLED,
+		CustomMode: customMode,
+	})
+	registerDrone(deps, "alpha", device)
+	heartbeats()
+
+	if resp := connectDrone(t, deps, "alpha", 2*time.Second); !resp.Success {
+		t.Fatalf("Connect: %s", resp.Message)
+	}
+	client, _ := deps.GetMAVLinkClientFor("alpha")
+	if !client.IsArmed() {
+		t.Fatal("drone not reported armed")
+	}
+	return client
+}
+
+func disconnect(deps *server.Dependencies, force bool) (*drone.DisconnectResponse, error) {
+	req := connect.NewRequest(&drone.DisconnectRequest{})
+	if force {
+		req.Header().Set(ForceDisconnectHeader, "true")
+	}
+	resp, err := NewConnectionServer(deps).Disconnect(context.Background(), req)
+	if err != nil {
+		return nil, err
+	}
+	return resp.Msg, nil
+}
+
+func TestDisconnectRefusedWhileArmed(t *testing.T) {
+	deps := newTestDependencies(t)
+	client := connectArmedDrone(t, deps, mavlink.PX4_MAIN_MODE_POSCTL)
+
+	_, err := disconnect(deps, false)
+	if connect.CodeOf(err) != connect.CodeFailedPrecondition || !strings.Contains(err.Error(), "the drone is armed") {
+		t.Fatalf("Disconnect: %v, want failed_precondition", err)
+	}
+	if got, ok := deps.GetMAVLinkClientFor("alpha"); !ok || got != client || !client.IsConnected() {
+		t.Error("refused disconnect dropped the client")
+	}
+}
+
+func TestDisconnectRefusedDuringMission(t *testing.T) {
+	deps := newTestDependencies(t)
+	connectArmedDrone(t, deps, mavlink.PX4_MAIN_MODE_AUTO|mavlink.PX4_AUTO_MODE_MISSION<<16)
+
+	if _, err := disconnect(deps, false); !strings.Contains(err.Error(), "flying a mission") {
+		t.Errorf("Disconnect: %v", err)
+	}
+}
+
+func TestDisconnectForcedWhileArmed(t *testing.T) {
+	deps := newTestDependencies(t)
+	connectArmedDrone(t, deps, mavlink.PX4_MAIN_MODE_POSCTL)
+
+	resp, err := disconnect(deps, true)
+	if err != nil || !resp.Success {
+		t.Fatalf("forced Disconnect = %+v, %v", resp, err)
+	}
+	if _, ok := deps.GetMAVLinkClientFor("alpha"); ok {
+		t.Error("client kept after a forced disconnect")
+	}
+}
+
+func TestDisconnectRTLPolicy(t *testing.T) {
+	deps := newTestDependencies(t)
+	deps.Config.MAVLink.DisconnectWhileArmed = config.DisconnectRTL
+	connectArmedDrone(t, deps, mavlink.PX4_MAIN_MODE_POSCTL)
+
+	// The passive client can't send RTL, so it stays connected
+	_, err := disconnect(deps, false)
+	if connect.CodeOf(err) != connect.CodeUnavailable || !strings.Contains(err.Error(), "RTL could not be sent") {
+		t.Errorf("Disconnect: %v, want unavailable", err)
+	}
+	if _, ok := deps.GetMAVLinkClientFor("alpha"); !ok {
+		t.Error("client dropped without RTL")
+	}
+}
+
+// openFDs counts the process's open file descriptors
+func openFDs(t *testing.T) int {
+	t.Helper()
+	entries, err := os.ReadDir("/proc/self/fd")
+	if err != nil {
+		t.Fatal(err)
+	}
+	return len(entries)
+}
+
+func TestForceResetStuckClient(t *testing.T) {
+	deps := newTestDependencies(t)
+	_, device, heartbeats := silentDrone(t)
+	registerDrone(deps, "alpha", device)
+	heartbeats()
+	s := NewConnectionServer(deps)
+
+	if resp := connectDrone(t, deps, "alpha", 2*time.Second); !resp.Success {
+		t.Fatalf("Connect: %s", resp.Message)
+	}
+	stuck, _ := deps.GetMAVLinkClientFor("alpha")
+
+	// An operation wedged while holding the drone's lock
+	deps.LockDrone("alpha")
+
+	resp, err := s.ForceReset(context.Background(), "alpha")
+	if err != nil || !resp.Success {
+		t.Fatalf("ForceReset = %+v, %v", resp, err)
+	}
+	if _, ok := deps.GetMAVLinkClientFor("alpha"); ok {
+		t.Fatal("client still registered after a reset")
+	}
+	if stuck.IsConnected() {
+		t.Error("old client still connected")
+	}
+
+	// New operations don't queue behind the wedged one
+	locked := make(chan func(), 1)
+	go func() { locked <- deps.LockDrone("alpha") }()
+	select {
+	case unlock := <-locked:
+		unlock()
+	case <-time.After(2 * time.Second):
+		t.Fatal("drone lock still held by the wedged operation")
+	}
+
+	// Reconnecting and resetting again releases the port each time
+	before := openFDs(t)
+	for i := 0; i < 5; i++ {
+		if resp := connectDrone(t, deps, "alpha", 2*time.Second); !resp.Success {
+			t.Fatalf("Connect after reset %d: %s", i, resp.Message)
+		}
+		if client, _ := deps.GetMAVLinkClientFor("alpha"); client == stuck {
+			t.Fatal("Connect reused the reset client")
+		}
+		if _, err := s.ForceReset(context.Background(), "alpha"); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if after := openFDs(t); after > before {
+		t.Errorf("%d file descriptors open after 5 resets, %d before", after, before)
+	}
+
+	if _, err := s.ForceReset(context.Background(), "alpha"); connect.CodeOf(err) != connect.CodeNotFound {
+		t.Errorf("reset without a client: %v, want not_found", err)
+	}
+}
+
+func TestConnectAppliesDroneTelemetryRates(t *testing.T) {
+	deps := newTestDependencies(t)
+	vehicle, device, heartbeats := silentDrone(t)
+	registerDrone(deps, "alpha", device)
+	drones := deps.GetDroneRegistry().Drones
+	drones[0].Connection["passive"] = false
+	drones[0].Telemetry = &config.TelemetryConfig{Rates: config.TelemetryProfile{
+		"ATTITUDE":            2,
+		"GLOBAL_POSITION_INT": 20,
+		"RADIO_STATUS":        0,
+	}}
+	heartbeats()
+
+	if resp := connectDrone(t, deps, "alpha", 2*time.Second); !resp.Success {
+		t.Fatalf("Connect: %s", resp.Message)
+	}
+
+	// Intervals in microseconds by message ID; -1 disables
+	want := map[float32]float32{
+		float32((&common.MessageAttitude{}).GetID()):          500000,
+		float32((&common.MessageGlobalPositionInt{}).GetID()): 50000,
+		float32((&common.MessageRadioStatus{}).GetID()):       -1,
+	}
+	timeout := time.After(2 * time.Second)
+	for len(want) > 0 {
+		select {
+		case evt := <-vehicle.Events():
+			frame, ok := evt.(*gomavlib.EventFrame)
+			if !ok {
+				continue
+			}
+			cmd, ok := frame.Message().(*common.MessageCommandLong)
+			if !ok || cmd.Command != common.MAV_CMD_SET_MESSAGE_INTERVAL {
+				continue
+			}
+			if interval, ok := want[cmd.Param1]; ok {
+				if cmd.Param2 != interval {
+					t.Errorf("message %v interval = %v us, want %v", cmd.Param1, cmd.Param2, interval)
+				}
+				delete(want, cmd.Param1)
+			}
+		case <-timeout:
+			t.Fatalf("no SET_MESSAGE_INTERVAL for messages %v", want)
+		}
+	}
+}
+
+func TestConnectRejectsInvalidTelemetryRates(t *testing.T) {
+	deps := newTestDependencies(t)
+	_, device, heartbeats := silentDrone(t)
+	registerDrone(deps, "alpha", device)
+	deps.GetDroneRegistry().Drones[0].Telemetry = &config.TelemetryConfig{Rates: config.TelemetryProfile{"ATTITUDE": -2}}
+	heartbeats()
+
+	resp := connectDrone(t, deps, "alpha", 2*time.Second)
+	if resp.Success || !strings.Contains(resp.Message, "Invalid telemetry rates") {
+		t.Errorf("Connect: %+v", resp)
+	}
+}
@@ -0,0 +1,62 @@
+package services
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"connectrpc.com/connect"
+
+	drone "github.com/flightpath-dev/flightpath-proto/gen/go/drone/v1"
+	"github.com/flightpath-dev/flightpath-server/internal/config"
+)
+
+func TestConnectTimeout(t *testing.T) {
+	deps := newTestDependencies(t)
+	deps.Config.MAVLink.MaxConnectTimeout = 30 * time.Second
+	s := NewConnectionServer(deps)
+
+	tests := []struct {
+		timeoutMs int32
+		want      time.Duration
+	}{
+		{0, 5 * time.Second},
+		{2000, 2 * time.Second},
+		{30000, 30 * time.Second},
+		{600000, 30 * time.Second}, // clamped to the maximum
+	}
+	for _, tt := range tests {
+		req := connect.NewRequest(&drone.ConnectRequest{DroneId: "alpha", TimeoutMs: tt.timeoutMs})
+		if got := s.connectTimeout(req); got != tt.want {
+			t.Errorf("connectTimeout(%d ms) = %s, want %s", tt.timeoutMs, got, tt.want)
+		}
+	}
+}
+
+func TestConnectRejectsNegativeTimeout(t *testing.T) {
+	_, err := NewConnectionServer(newTestDependencies(t)).Connect(context.Background(),
+		connect.NewRequest(&drone.ConnectRequest{DroneId: "alpha", TimeoutMs: -1}))
+	if connect.CodeOf(err) != connect.CodeInvalidArgument {
+		t.Errorf("negative timeout: %v, want invalid_argument", err)
+	}
+}
+
+func TestConnectReportsMissingRegistry(t *testing.T) {
+	// newTestDependencies points at a registry file that doesn't exist
+	deps := newTestDependencies(t)
+	s := NewConnectionServer(deps)
+
+	resp, err := s.Connect(context.Background(), connect.NewRequest(&drone.ConnectRequest{DroneId: "alpha"}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.Msg.Success || !strings.Contains(resp.Msg.Message, "drone registry at "+deps.RegistryStatus.Path+" is missing") {
+		t.Errorf("Connect = %v: %s", resp.Msg.Success, resp.Msg.Message)
+	}
+
+	diag := s.GetDiagnostics(context.Background())
+	if diag.Registry.State != config.RegistryMissing || diag.Registry.Error == "" {
+		t.Errorf("diagnostics registry = %+v", diag.Registry)
+	}
+}
@@ -1,8 +1,15 @@
 package services
 
 import (
+	"bufio"
 	"context"
+	"errors"
 	"fmt"
+	"io"
+	"math"
+	"slices"
+	"strconv"
+	"time"
 
 	"connectrpc.com/connect"
 
@@ -13,38 +20,43 @@ import (
 
 // ControlServer implements the ControlService
 type ControlServer struct {
-	deps *server.Dependencies
+	deps      *server.Dependencies
+	debouncer *commandDebouncer
 }
 
 // NewControlServer creates a new ControlServer
 func NewControlServer(deps *server.Dependencies) *ControlServer {
 	return &ControlServer{
-		deps: deps,
+		deps:      deps,
+		debouncer: newCommandDebouncer(deps),
 	}
 }
 
 func (s *ControlServer) Arm(
 	ctx context.Context,
 	req *connect.Request[drone.ArmRequest],
-) (*connect.Response[drone.ArmResponse], error) {
+) (resp *connect.Response[drone.ArmResponse], err error) {
+	defer func() { auditResponse(ctx, s.deps, "arm", "", req.Msg, resp, err) }()
+
 	logger := s.deps.GetLogger()
 	logger.Println("Arm request")
 
-	// Check if MAVLink client exists
-	if !s.deps.HasMAVLinkClient() {
-		return connect.NewResponse(&drone.ArmResponse{
-			Success: false,
-			Message: "Not connected to drone. Call Connect first.",
-		}), nil
+	if err := requireCommandsEnabled(s.deps); err != nil {
+		return nil, err
 	}
 
-	client := s.deps.GetMAVLinkClient()
+	prior, finish := s.debouncer.start(ctx, "arm", "")
+	if prior != nil {
+		logger.Println("Arm: duplicate within the debounce window, answered with the previous result")
+		return debouncedResponse[drone.ArmResponse](ctx, prior)
+	}
+	defer func() { finish(resp, err) }()
 
-	// Check if connected
-	if !client.IsConnected() {
+	client, err := requireReadyClient(ctx, s.deps, "", commandPolicy(s.deps.Config, "arm"))
+	if err != nil {
 		return connect.NewResponse(&drone.ArmResponse{
 			Success: false,
-			Message: "Drone is not connected",
+			Message: errorMessage(err),
 		}), nil
 	}
 
@@ -58,32 +70,35 @@ func (s *ControlServer) Arm(
 
 	return connect.NewResponse(&drone.ArmResponse{
 		Success: true,
-		Message: "Arm command sent successfully",
+		Message: "Arm command accepted",
 	}), nil
 }
 
 func (s *ControlServer) Disarm(
 	ctx context.Context,
 	req *connect.Request[drone.DisarmRequest],
-) (*connect.Response[drone.DisarmResponse], error) {
+) (resp *connect.Response[drone.DisarmResponse], err error) {
+	defer func() { auditResponse(ctx, s.deps, "disarm", "", req.Msg, resp, err) }()
+
 	logger := s.deps.GetLogger()
 	logger.Println("Disarm request")
 
-	// Check if MAVLink client exists
-	if !s.deps.HasMAVLinkClient() {
-		return connect.NewResponse(&drone.DisarmResponse{
-			Success: false,
-			Message: "Not connected to drone. Call Connect first.",
-		}), nil
+	if err := requireCommandsEnabled(s.deps); err != nil {
+		return nil, err
 	}
 
-	client := s.deps.GetMAVLinkClient()
+	prior, finish := s.debouncer.start(ctx, "disarm", "")
+	if prior != nil {
+		logger.Println("Disarm: duplicate within the debounce window, answered with the previous result")
+		return debouncedResponse[drone.DisarmResponse](ctx, prior)
+	}
+	defer func() { finish(resp, err) }()
 
-	// Check if connected
-	if !client.IsConnected() {
+	client, err := requireReadyClient(ctx, s.deps, "", policyConnected)
+	if err != nil {
 		return connect.NewResponse(&drone.DisarmResponse{
 			Success: false,
-			Message: "Drone is not connected",
+			Message: errorMessage(err),
 		}), nil
 	}
 
@@ -97,32 +112,46 @@ func (s *ControlServer) Disarm(
 
 	return connect.NewResponse(&drone.DisarmResponse{
 		Success: true,
-		Message: "Disarm command sent successfully",
+		Message: "Disarm command accepted",
 	}), nil
 }
 
 func (s *ControlServer) SetFlightMode(
 	ctx context.Context,
 	req *connect.Request[drone.SetFlightModeRequest],
-) (*connect.Response[drone.SetFlightModeResponse], error) {
+) (resp *connect.Response[drone.SetFlightModeResponse], err error) {
+	defer func() { auditResponse(ctx, s.deps, "set_flight_mode", "", req.Msg, resp, err) }()
+
 	logger := s.deps.GetLogger()
 	logger.Printf("SetFlightMode request: mode=%s", req.Msg.Mode)
 
-	// Check if MAVLink client exists
-	if !s.deps.HasMAVLinkClient() {
-		return connect.NewResponse(&drone.SetFlightModeResponse{
-			Success: false,
-			Message: "Not connected to drone",
-		}), nil
+	if err := requireCommandsEnabled(s.deps); err != nil {
+		return nil, err
 	}
 
-	client := s.deps.GetMAVLinkClient()
+	// Unsupported modes fall through to the mapping error below
+	droneID := targetDrone(ctx, s.deps, "")
+	allowed, err := allowedFlightModesFor(ctx, s.deps, droneID)
+	if err != nil {
+		return nil, connect.NewError(connect.CodeFailedPrecondition, err)
+	}
+	if slices.Contains(settableFlightModes, req.Msg.Mode) && !slices.Contains(allowed, req.Msg.Mode) {
+		return nil, connect.NewError(connect.CodePermissionDenied,
+			fmt.Errorf("flight mode %s is not allowed for drone %s", req.Msg.Mode, droneID))
+	}
+
+	prior, finish := s.debouncer.start(ctx, "set_mode", req.Msg.Mode.String())
+	if prior != nil {
+		logger.Println("SetFlightMode: duplicate within the debounce window, answered with the previous result")
+		return debouncedResponse[drone.SetFlightModeResponse](ctx, prior)
+	}
+	defer func() { finish(resp, err) }()
 
-	// Check if connected
-	if !client.IsConnected() {
+	client, err := requireReadyClient(ctx, s.deps, "", commandPolicy(s.deps.Config, "set_mode"))
+	if err != nil {
 		return connect.NewResponse(&drone.SetFlightModeResponse{
 			Success: false,
-			Message: "Drone is not connected",
+			Message: errorMessage(err),
 		}), nil
 	}
 
@@ -213,31 +242,55 @@ func (s *ControlServer) encodePX4AutoMode(subMode uint32) uint32 {
 	return mavlink.PX4_MAIN_MODE_AUTO | (subMode << 16)
 }
 
+// ForceTakeoffHeader set to "true" skips the takeoff GPS check (TakeoffGPSGate)
+const ForceTakeoffHeader = "Flightpath-Force-Takeoff"
+
 func (s *ControlServer) Takeoff(
 	ctx context.Context,
 	req *connect.Request[drone.TakeoffRequest],
-) (*connect.Response[drone.TakeoffResponse], error) {
+) (resp *connect.Response[drone.TakeoffResponse], err error) {
+	defer func() { auditResponse(ctx, s.deps, "takeoff", "", req.Msg, resp, err) }()
+
 	logger := s.deps.GetLogger()
 	logger.Printf("Takeoff request: altitude=%.2fm", req.Msg.Altitude)
 
-	// Check if MAVLink client exists
-	if !s.deps.HasMAVLinkClient() {
-		return connect.NewResponse(&drone.TakeoffResponse{
-			Success: false,
-			Message: "Not connected to drone",
-		}), nil
+	if err := requireCommandsEnabled(s.deps); err != nil {
+		return nil, err
 	}
 
-	client := s.deps.GetMAVLinkClient()
+	// A forced or overriding retry is not a duplicate of the refused attempt
+	params := fmt.Sprint(req.Msg.Altitude, req.Header().Get(ForceTakeoffHeader), req.Header().Get(OverrideMissionHeader))
+	prior, finish := s.debouncer.start(ctx, "takeoff", params)
+	if prior != nil {
+		logger.Println("Takeoff: duplicate within the debounce window, answered with the previous result")
+		return debouncedResponse[drone.TakeoffResponse](ctx, prior)
+	}
+	defer func() { finish(resp, err) }()
 
-	// Check if connected
-	if !client.IsConnected() {
+	client, err := requireReadyClient(ctx, s.deps, "", commandPolicy(s.deps.Config, "takeoff"))
+	if err != nil {
 		return connect.NewResponse(&drone.TakeoffResponse{
 			Success: false,
-			Message: "Drone is not connected",
+			Message: errorMessage(err),
 		}), nil
 	}
 
+	override, _ := strconv.ParseBool(req.Header().Get(OverrideMissionHeader))
+	if err := checkMissionConflict(s.deps, client, "takeoff", override); err != nil {
+		return nil, err
+	}
+
+	if s.deps.Config.MAVLink.TakeoffGPSGate {
+		if force, _ := strconv.ParseBool(req.Header().Get(ForceTakeoffHeader)); force {
+			logger.Printf("Takeoff: Warning - GPS check overridden (%s)", ForceTakeoffHeader)
+		} else if err := checkTakeoffGPS(&s.deps.Config.MAVLink, client.GetTelemetry()); err != nil {
+			return connect.NewResponse(&drone.TakeoffResponse{
+				Success: false,
+				Message: fmt.Sprintf("Refusing takeoff: %v; wait for a better fix or set %s: true", err, ForceTakeoffHeader),
+			}), nil
+		}
+	}
+
 	// Send takeoff command
 	if err := client.Takeoff(float32(req.Msg.Altitude)); err != nil {
 		return connect.NewResponse(&drone.TakeoffResponse{
@@ -255,25 +308,28 @@ func (s *ControlServer) Takeoff(
 func (s *ControlServer) Land(
 	ctx context.Context,
 	req *connect.Request[drone.LandRequest],
-) (*connect.Response[drone.LandResponse], error) {
+) (resp *connect.Response[drone.LandResponse], err error) {
+	defer func() { auditResponse(ctx, s.deps, "land", "", req.Msg, resp, err) }()
+
 	logger := s.deps.GetLogger()
 	logger.Println("Land request")
 
-	// Check if MAVLink client exists
-	if !s.deps.HasMAVLinkClient() {
-		return connect.NewResponse(&drone.LandResponse{
-			Success: false,
-			Message: "Not connected to drone",
-		}), nil
+	if err := requireCommandsEnabled(s.deps); err != nil {
+		return nil, err
 	}
 
-	client := s.deps.GetMAVLinkClient()
+	prior, finish := s.debouncer.start(ctx, "land", "")
+	if prior != nil {
+		logger.Println("Land: duplicate within the debounce window, answered with the previous result")
+		return debouncedResponse[drone.LandResponse](ctx, prior)
+	}
+	defer func() { finish(resp, err) }()
 
-	// Check if connected
-	if !client.IsConnected() {
+	client, err := requireReadyClient(ctx, s.deps, "", policyConnected)
+	if err != nil {
 		return connect.NewResponse(&drone.LandResponse{
 			Success: false,
-			Message: "Drone is not connected",
+			Message: errorMessage(err),
 		}), nil
 	}
 
@@ -294,25 +350,28 @@ func (s *ControlServer) Land(
 func (s *ControlServer) ReturnHome(
 	ctx context.Context,
 	req *connect.Request[drone.ReturnHomeRequest],
-) (*connect.Response[drone.ReturnHomeResponse], error) {
+) (resp *connect.Response[drone.ReturnHomeResponse], err error) {
+	defer func() { auditResponse(ctx, s.deps, "return_home", "", req.Msg, resp, err) }()
+
 	logger := s.deps.GetLogger()
 	logger.Println("ReturnHome request")
 
-	// Check if MAVLink client exists
-	if !s.deps.HasMAVLinkClient() {
-		return connect.NewResponse(&drone.ReturnHomeResponse{
-			Success: false,
-			Message: "Not connected to drone",
-		}), nil
+	if err := requireCommandsEnabled(s.deps); err != nil {
+		return nil, err
 	}
 
-	client := s.deps.GetMAVLinkClient()
+	prior, finish := s.debouncer.start(ctx, "return_home", "")
+	if prior != nil {
+		logger.Println("ReturnHome: duplicate within the debounce window, answered with the previous result")
+		return debouncedResponse[drone.ReturnHomeResponse](ctx, prior)
+	}
+	defer func() { finish(resp, err) }()
 
-	// Check if connected
-	if !client.IsConnected() {
+	client, err := requireReadyClient(ctx, s.deps, "", policyConnected)
+	if err != nil {
 		return connect.NewResponse(&drone.ReturnHomeResponse{
 			Success: false,
-			Message: "Drone is not connected",
+			Message: errorMessage(err),
 		}), nil
 	}
 
@@ -333,29 +392,30 @@ func (s *ControlServer) ReturnHome(
 func (s *ControlServer) GoToPosition(
 	ctx context.Context,
 	req *connect.Request[drone.GoToPositionRequest],
-) (*connect.Response[drone.GoToPositionResponse], error) {
+) (resp *connect.Response[drone.GoToPositionResponse], err error) {
+	defer func() { auditResponse(ctx, s.deps, "go_to_position", "", req.Msg, resp, err) }()
+
 	logger := s.deps.GetLogger()
 	logger.Printf("GoToPosition request: lat=%.6f, lon=%.6f, alt=%.2f",
 		req.Msg.Target.Latitude, req.Msg.Target.Longitude, req.Msg.Target.Altitude)
 
-	// Check if MAVLink client exists
-	if !s.deps.HasMAVLinkClient() {
-		return connect.NewResponse(&drone.GoToPositionResponse{
-			Success: false,
-			Message: "Not connected to drone",
-		}), nil
+	if err := requireCommandsEnabled(s.deps); err != nil {
+		return nil, err
 	}
 
-	client := s.deps.GetMAVLinkClient()
-
-	// Check if connected
-	if !client.IsConnected() {
+	client, err := requireReadyClient(ctx, s.deps, "", commandPolicy(s.deps.Config, "goto"))
+	if err != nil {
 		return connect.NewResponse(&drone.GoToPositionResponse{
 			Success: false,
-			Message: "Drone is not connected",
+			Message: errorMessage(err),
 		}), nil
 	}
 
+	override, _ := strconv.ParseBool(req.Header().Get(OverrideMissionHeader))
+	if err := checkMissionConflict(s.deps, client, "go-to", override); err != nil {
+		return nil, err
+	}
+
 	// Check if drone is in GUIDED mode
 	telemetry := client.GetTelemetry()
 	if telemetry.CustomMode != mavlink.PX4_MAIN_MODE_OFFBOARD {
@@ -366,7 +426,7 @@ func (s *ControlServer) GoToPosition(
 	}
 
 	// Send position setpoint
-	err := client.GoToPosition(
+	err = client.GoToPosition(
 		req.Msg.Target.Latitude,
 		req.Msg.Target.Longitude,
 		req.Msg.Target.Altitude,
@@ -386,3 +446,509 @@ func (s *ControlServer) GoToPosition(
 		Message: "Position command sent successfully",
 	}), nil
 }
+
+// CancelGoTo stops re-sending the active drone's go-to target
+// Only relevant with FLIGHTPATH_MAVLINK_GOTO_SETPOINT_RATE set; the vehicle
+// holds its last setpoint until PX4's offboard-loss failsafe reacts, unless
+// hold (or FLIGHTPATH_MAVLINK_GOTO_HOLD_ON_STOP) switches it to AUTO.LOITER.
+// Nothing to cancel is not an error.
+func (s *ControlServer) CancelGoTo(ctx context.Context, hold bool) (resp *CommandResponse, err error) {
+	defer func() { recordAudit(ctx, s.deps, "cancel_go_to", "", map[string]any{"hold": hold}, resp, err) }()
+
+	logger := s.deps.GetLogger()
+	logger.Printf("CancelGoTo request: hold=%v", hold)
+
+	if err := requireCommandsEnabled(s.deps); err != nil {
+		return nil, err
+	}
+
+	client, err := requireReadyClient(ctx, s.deps, "", policyClient)
+	if err != nil {
+		return &CommandResponse{
+			Success: false,
+			Message: errorMessage(err),
+		}, nil
+	}
+
+	message := "Go-to target cancelled"
+	if !client.CancelGoTo() {
+		message = "No go-to target was being sent"
+	}
+
+	if hold {
+		if err := client.SetMode(uint32(mavlink.PX4_MAIN_MODE_AUTO | (mavlink.PX4_AUTO_MODE_LOITER << 16))); err != nil {
+			return &CommandResponse{
+				Success: false,
+				Message: fmt.Sprintf("%s, but hold failed: %v", message, err),
+			}, nil
+		}
+		message += "; holding position"
+	}
+
+	return &CommandResponse{
+		Success: true,
+		Message: message,
+	}, nil
+}
+
+// RepositionRequest asks the vehicle to fly to a position with MAV_CMD_DO_REPOSITION
+type RepositionRequest struct {
+	Latitude  float64 `json:"latitude"`
+	Longitude float64 `json:"longitude"`
+	Altitude  float64 `json:"altitude"` // meters relative to home
+
+	// Optional: ground speed in m/s (0 = vehicle default) and yaw in degrees
+	// (nil = keep the vehicle's heading behavior)
+	GroundSpeed float64  `json:"ground_speed,omitempty"`
+	Yaw         *float64 `json:"yaw,omitempty"`
+
+	// Reposition even while a mission is flying (see RejectDuringMission)
+	OverrideMission bool `json:"override_mission,omitempty"`
+}
+
+// CommandResponse reports the outcome of an acknowledged command
+type CommandResponse struct {
+	Success bool   `json:"success"`
+	Message string `json:"message"`
+	// COMMAND_ACK result (e.g. "ACCEPTED", "DENIED"); empty if no ACK arrived
+	Result string `json:"result,omitempty"`
+}
+
+// Reposition sends the active drone to a position without requiring GUIDED mode
+// A mode-tolerant alternative to GoToPosition: the vehicle switches into its
+// reposition mode itself and acknowledges the command.
+func (s *ControlServer) Reposition(ctx context.Context, req *RepositionRequest) (resp *CommandResponse, err error) {
+	defer func() { recordAudit(ctx, s.deps, "reposition", "", req, resp, err) }()
+
+	logger := s.deps.GetLogger()
+	logger.Printf("Reposition request: lat=%.6f, lon=%.6f, alt=%.2f",
+		req.Latitude, req.Longitude, req.Altitude)
+
+	if err := requireCommandsEnabled(s.deps); err != nil {
+		return nil, err
+	}
+
+	client, err := requireReadyClient(ctx, s.deps, "", commandPolicy(s.deps.Config, "reposition"))
+	if err != nil {
+		return &CommandResponse{
+			Success: false,
+			Message: errorMessage(err),
+		}, nil
+	}
+
+	if err := checkMissionConflict(s.deps, client, "reposition", req.OverrideMission); err != nil {
+		return nil, err
+	}
+
+	yaw := math.NaN()
+	if req.Yaw != nil {
+		yaw = *req.Yaw
+	}
+
+	err = client.Reposition(req.Latitude, req.Longitude, req.Altitude, req.GroundSpeed, yaw)
+	if err != nil {
+		resp := &CommandResponse{
+			Success: false,
+			Message: fmt.Sprintf("Reposition failed: %v", err),
+		}
+		var rejected *mavlink.CommandRejectedError
+		if errors.As(err, &rejected) {
+			resp.Result = rejected.ResultName()
+		}
+		return resp, nil
+	}
+
+	logger.Printf("Reposition accepted")
+
+	return &CommandResponse{
+		Success: true,
+		Message: "Reposition accepted",
+		Result:  "ACCEPTED",
+	}, nil
+}
+
+// SetYawRequest turns the vehicle to a heading with MAV_CMD_CONDITION_YAW
+type SetYawRequest struct {
+	Heading   float64 `json:"heading"`             // degrees 0-360
+	Relative  bool    `json:"relative,omitempty"`  // heading is an offset from the current one
+	Rate      float64 `json:"rate,omitempty"`      // deg/s (0 = vehicle default)
+	Clockwise bool    `json:"clockwise,omitempty"` // turn direction (default counter-clockwise)
+}
+
+// SetYaw points the active drone at a heading while it holds position
+// The vehicle only honors CONDITION_YAW in GUIDED or AUTO, so other modes are refused.
+func (s *ControlServer) SetYaw(ctx context.Context, req *SetYawRequest) (resp *CommandResponse, err error) {
+	defer func() { recordAudit(ctx, s.deps, "set_yaw", "", req, resp, err) }()
+
+	logger := s.deps.GetLogger()
+	logger.Printf("SetYaw request: heading=%.1f, relative=%v, rate=%.1f, clockwise=%v",
+		req.Heading, req.Relative, req.Rate, req.Clockwise)
+
+	if err := requireCommandsEnabled(s.deps); err != nil {
+		return nil, err
+	}
+
+	client, err := requireReadyClient(ctx, s.deps, "", policyConnected)
+	if err != nil {
+		return &CommandResponse{
+			Success: false,
+			Message: errorMessage(err),
+		}, nil
+	}
+
+	mainMode := client.GetTelemetry().CustomMode & 0xFF
+	if mainMode != mavlink.PX4_MAIN_MODE_OFFBOARD && mainMode != mavlink.PX4_MAIN_MODE_AUTO {
+		return &CommandResponse{
+			Success: false,
+			Message: "Drone must be in GUIDED or AUTO mode to accept yaw commands",
+		}, nil
+	}
+
+	if err := client.SetYaw(req.Heading, req.Relative, req.Rate, req.Clockwise); err != nil {
+		resp := &CommandResponse{
+			Success: false,
+			Message: fmt.Sprintf("Set yaw failed: %v", err),
+		}
+		var rejected *mavlink.CommandRejectedError
+		if errors.As(err, &rejected) {
+			resp.Result = rejected.ResultName()
+		}
+		return resp, nil
+	}
+
+	logger.Printf("Set yaw accepted")
+
+	return &CommandResponse{
+		Success: true,
+		Message: "Set yaw accepted",
+		Result:  "ACCEPTED",
+	}, nil
+}
+
+// FlightTerminateRequest asks for flight termination of one drone
+// Confirm must be "TERMINATE <drone_id>" so a termination can't be sent to the
+// wrong drone or by a client that meant something else.
+type FlightTerminateRequest struct {
+	DroneID string `json:"drone_id"`
+	Confirm string `json:"confirm"`
+	Reason  string `json:"reason,omitempty"`
+
+	// Audit fields filled in by the transport, not the caller
+	RequestID string `json:"-"`
+	Operator  string `json:"-"`
+}
+
+// flightTerminateConfirmation is the Confirm value FlightTerminate expects for a drone
+func flightTerminateConfirmation(droneID string) string {
+	return "TERMINATE " + droneID
+}
+
+// FlightTerminate cuts a drone's motors in flight with MAV_CMD_DO_FLIGHTTERMINATION
+// This is not Disarm: the vehicle falls. The drone is named explicitly rather than
+// taken from the active drone, and every request is logged at error level with
+// the request ID and operator, whether or not it goes through.
+func (s *ControlServer) FlightTerminate(ctx context.Context, req *FlightTerminateRequest) (resp *CommandResponse, err error) {
+	defer func() { recordAudit(ctx, s.deps, "flight_terminate", req.DroneID, req, resp, err) }()
+
+	logger := s.deps.GetLogger()
+
+	operator := req.Operator
+	if operator == "" {
+		operator = "unauthenticated"
+	}
+	logger.Printf("ERROR - FlightTerminate request: drone_id=%s, request_id=%s, operator=%s, reason=%q",
+		req.DroneID, req.RequestID, operator, req.Reason)
+
+	if err := requireCommandsEnabled(s.deps); err != nil {
+		return nil, err
+	}
+
+	if req.DroneID == "" {
+		return &CommandResponse{
+			Success: false,
+			Message: "drone_id is required",
+		}, nil
+	}
+	if want := flightTerminateConfirmation(req.DroneID); req.Confirm != want {
+		logger.Printf("FlightTerminate refused: confirmation missing or wrong (request_id=%s)", req.RequestID)
+		return &CommandResponse{
+			Success: false,
+			Message: fmt.Sprintf("Flight termination not sent: confirm must be %q", want),
+		}, nil
+	}
+
+	client, err := requireReadyClient(ctx, s.deps, req.DroneID, policyConnected)
+	if err != nil {
+		return &CommandResponse{
+			Success: false,
+			Message: errorMessage(err),
+		}, nil
+	}
+
+	if err := client.FlightTerminate(); err != nil {
+		logger.Printf("ERROR - FlightTerminate failed: drone_id=%s, request_id=%s: %v", req.DroneID, req.RequestID, err)
+		resp := &CommandResponse{
+			Success: false,
+			Message: fmt.Sprintf("Flight termination failed: %v", err),
+		}
+		var rejected *mavlink.CommandRejectedError
+		if errors.As(err, &rejected) {
+			resp.Result = rejected.ResultName()
+		}
+		return resp, nil
+	}
+
+	logger.Printf("ERROR - FlightTerminate accepted: drone_id=%s, request_id=%s, operator=%s",
+		req.DroneID, req.RequestID, operator)
+
+	return &CommandResponse{
+		Success: true,
+		Message: "Flight termination accepted",
+		Result:  "ACCEPTED",
+	}, nil
+}
+
+// SendStatusTextRequest sends a text message to the vehicle
+type SendStatusTextRequest struct {
+	Severity int    `json:"severity"` // MAV_SEVERITY: 0 emergency - 7 debug
+	Text     string `json:"text"`
+}
+
+// SendStatusText sends a STATUSTEXT to the active drone
+// Some autopilots (e.g. ArduPilot) record GCS STATUSTEXT in the onboard log.
+func (s *ControlServer) SendStatusText(ctx context.Context, req *SendStatusTextRequest) (resp *CommandResponse, err error) {
+	defer func() { recordAudit(ctx, s.deps, "send_status_text", "", req, resp, err) }()
+
+	logger := s.deps.GetLogger()
+	logger.Printf("SendStatusText request: severity=%d, length=%d", req.Severity, len(req.Text))
+
+	if err := requireCommandsEnabled(s.deps); err != nil {
+		return nil, err
+	}
+
+	if req.Severity < 0 || req.Severity > 7 {
+		return nil, connect.NewError(connect.CodeInvalidArgument,
+			fmt.Errorf("severity must be 0-7: %d", req.Severity))
+	}
+
+	client, err := requireReadyClient(ctx, s.deps, "", policyConnected)
+	if err != nil {
+		return &CommandResponse{
+			Success: false,
+			Message: errorMessage(err),
+		}, nil
+	}
+
+	if err := client.SendStatusText(req.Severity, req.Text); err != nil {
+		return &CommandResponse{
+			Success: false,
+			Message: fmt.Sprintf("Send status text failed: %v", err),
+		}, nil
+	}
+
+	return &CommandResponse{
+		Success: true,
+		Message: "Status text sent",
+	}, nil
+}
+
+// RTCMInjectResult summarizes an RTCM correction stream
+type RTCMInjectResult struct {
+	Frames  int `json:"frames"`  // RTCM3 frames sent to the vehicle
+	Bytes   int `json:"bytes"`   // bytes in those frames
+	Skipped int `json:"skipped"` // frames too large for GPS_RTCM_DATA (over 720 bytes)
+}
+
+// InjectRTCM forwards an RTCM3 correction stream to a drone's GPS until the
+// stream ends or ctx is cancelled
+// The stream is split into RTCM3 frames (corrupt bytes are skipped) and each is
+// sent as GPS_RTCM_DATA. An empty droneID means the active drone.
+func (s *ControlServer) InjectRTCM(ctx context.Context, droneID string, stream io.Reader) (resp *RTCMInjectResult, err error) {
+	defer func() { recordAudit(ctx, s.deps, "inject_rtcm", droneID, nil, resp, err) }()
+
+	logger := s.deps.GetLogger()
+	logger.Printf("InjectRTCM request: drone_id=%s", droneID)
+
+	if err := requireCommandsEnabled(s.deps); err != nil {
+		return nil, err
+	}
+
+	client, err := requireReadyClient(ctx, s.deps, droneID, policyConnected)
+	if err != nil {
+		return nil, err
+	}
+
+	scanner := bufio.NewScanner(stream)
+	scanner.Buffer(make([]byte, 0, 4096), 4096)
+	scanner.Split(mavlink.ScanRTCM3Frames)
+
+	result := &RTCMInjectResult{}
+	for scanner.Scan() {
+		if ctx.Err() != nil {
+			break
+		}
+
+		frame := scanner.Bytes()
+		if len(frame) > mavlink.MaxRTCMMessageLen {
+			result.Skipped++
+			continue
+		}
+		if err := client.InjectRTCM(frame); err != nil {
+			logger.Printf("InjectRTCM stopped after %d frames: %v", result.Frames, err)
+			return result, connect.NewError(connect.CodeUnavailable, err)
+		}
+		result.Frames++
+		result.Bytes += len(frame)
+	}
+
+	logger.Printf("InjectRTCM finished: %d frames, %d bytes, %d skipped",
+		result.Frames, result.Bytes, result.Skipped)
+	if err := scanner.Err(); err != nil && ctx.Err() == nil {
+		return result, connect.NewError(connect.CodeInvalidArgument, fmt.Errorf("reading RTCM stream: %w", err))
+	}
+	return result, nil
+}
+
+// SetHomeRequest sets the home position to a location or the current position
+type SetHomeRequest struct {
+	Latitude  float64 `json:"latitude"`
+	Longitude float64 `json:"longitude"`
+	Altitude  float64 `json:"altitude"` // meters MSL
+
+	// Use the vehicle's current position; the coordinates are ignored
+	UseCurrent bool `json:"use_current"`
+}
+
+// SetHomeResponse reports the home position the vehicle confirmed
+type SetHomeResponse struct {
+	CommandResponse
+	Home *mavlink.HomePosition `json:"home,omitempty"`
+}
+
+// SetHome sets the active drone's home position with MAV_CMD_DO_SET_HOME
+// Succeeds once the vehicle acknowledges the command and reports the new home.
+func (s *ControlServer) SetHome(ctx context.Context, req *SetHomeRequest) (resp *SetHomeResponse, err error) {
+	defer func() { recordAudit(ctx, s.deps, "set_home", "", req, resp, err) }()
+
+	logger := s.deps.GetLogger()
+	logger.Printf("SetHome request: lat=%.6f, lon=%.6f, alt=%.2f, use_current=%v",
+		req.Latitude, req.Longitude, req.Altitude, req.UseCurrent)
+
+	if err := requireCommandsEnabled(s.deps); err != nil {
+		return nil, err
+	}
+
+	client, err := requireReadyClient(ctx, s.deps, "", policyConnected)
+	if err != nil {
+		return &SetHomeResponse{CommandResponse: CommandResponse{
+			Success: false,
+			Message: errorMessage(err),
+		}}, nil
+	}
+
+	var home mavlink.HomePosition
+	if req.UseCurrent {
+		home, err = client.SetHomeToCurrent()
+	} else {
+		home, err = client.SetHome(req.Latitude, req.Longitude, req.Altitude)
+	}
+	if err != nil {
+		resp := &SetHomeResponse{CommandResponse: CommandResponse{
+			Success: false,
+			Message: fmt.Sprintf("Set home failed: %v", err),
+		}}
+		var rejected *mavlink.CommandRejectedError
+		if errors.As(err, &rejected) {
+			resp.Result = rejected.ResultName()
+		}
+		return resp, nil
+	}
+
+	return &SetHomeResponse{
+		CommandResponse: CommandResponse{
+			Success: true,
+			Message: "Home position set",
+			Result:  "ACCEPTED",
+		},
+		Home: &home,
+	}, nil
+}
+
+// calibrationIdleTimeout fails a calibration when the vehicle stops reporting progress
+const calibrationIdleTimeout = 60 * time.Second
+
+// StreamCalibration starts a sensor calibration and streams its progress
+// Every calibration STATUSTEXT is forwarded verbatim so operators see the
+// orientation prompts of multi-step mag/accel calibration. The stream ends when
+// the calibration completes or fails; if the caller goes away first, the
+// calibration is cancelled on the vehicle. An empty droneID means the active drone.
+func (s *ControlServer) StreamCalibration(
+	ctx context.Context,
+	droneID string,
+	calType mavlink.CalibrationType,
+	stream streamSender[mavlink.CalibrationStatus],
+) error {
+	logger := s.deps.GetLogger()
+	logger.Printf("StreamCalibration request: drone_id=%s, type=%s", droneID, calType)
+
+	if err := requireCommandsEnabled(s.deps); err != nil {
+		return err
+	}
+
+	client, err := requireReadyClient(ctx, s.deps, droneID, policyConnected)
+	if err != nil {
+		return err
+	}
+	defer s.deps.HoldMAVLinkClient(client)()
+
+	// Subscribe before starting so no early progress message is missed
+	statusTexts, unsubscribe := client.SubscribeStatusText()
+	defer unsubscribe()
+
+	err = client.StartCalibration(calType)
+	recordAudit(ctx, s.deps, "start_calibration", droneID, map[string]any{"type": calType.String()}, nil, err)
+	if err != nil {
+		return connect.NewError(connect.CodeFailedPrecondition, err)
+	}
+
+	idle := time.NewTimer(calibrationIdleTimeout)
+	defer idle.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			logger.Println("StreamCalibration: Client disconnected, cancelling calibration")
+			if err := client.CancelCalibration(); err != nil {
+				logger.Printf("StreamCalibration: Error cancelling: %v", err)
+			}
+			return nil
+
+		case <-idle.C:
+			return connect.NewError(connect.CodeDeadlineExceeded,
+				fmt.Errorf("no calibration progress for %s", calibrationIdleTimeout))
+
+		case text, ok := <-statusTexts:
+			if !ok {
+				return connect.NewError(connect.CodeUnavailable,
+					fmt.Errorf("connection closed during calibration"))
+			}
+
+			status, isCalibration := mavlink.ParseCalibrationStatus(text.Text)
+			if !isCalibration {
+				continue
+			}
+
+			idle.Reset(calibrationIdleTimeout)
+
+			if err := stream.Send(&status); err != nil {
+				logger.Printf("StreamCalibration: Error sending: %v", err)
+				return err
+			}
+
+			if status.Done || status.Failed {
+				logger.Printf("StreamCalibration: %s calibration finished (failed=%v)", calType, status.Failed)
+				return nil
+			}
+		}
+	}
+}
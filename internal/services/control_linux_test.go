@@ -0,0 +1,351 @@
+package services
+
+import (
+	"context"
+	"io"
+	"log"
+	"slices"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"connectrpc.com/connect"
+	"github.com/bluenviron/gomavlib/v3"
+	"github.com/bluenviron/gomavlib/v3/pkg/dialects/common"
+
+	drone "github.com/flightpath-dev/flightpath-proto/gen/go/drone/v1"
+	"github.com/flightpath-dev/flightpath-server/internal/config"
+	"github.com/flightpath-dev/flightpath-server/internal/mavlink"
+	"github.com/flightpath-dev/flightpath-server/internal/server"
+)
+
+// activeDrone connects an active client configured as cfg to a simulated
+// vehicle sending heartbeat, and makes it the active drone of new dependencies
+// with commands enabled
+func activeDrone(t *testing.T, heartbeat *common.MessageHeartbeat, cfg mavlink.Config) (*server.Dependencies, *mavlink.Client, *gomavlib.Node) {
+	t.Helper()
+	vehicle, device, heartbeats := simulatedDrone(t, heartbeat)
+	heartbeats()
+
+	cfg.Port = device
+	cfg.BaudRate = 57600
+	cfg.Logger = log.New(io.Discard, "", 0)
+	client, err := mavlink.NewClient(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { client.Close() })
+	if err := client.WaitForConnection(2 * time.Second); err != nil {
+		t.Fatal(err)
+	}
+
+	deps := newTestDependencies(t)
+	deps.SetCommandsEnabled(true)
+	deps.AddMAVLinkClient("alpha", client)
+	return deps, client, vehicle
+}
+
+func TestTakeoffGPSGate(t *testing.T) {
+	deps, client, vehicle := activeDrone(t, &common.MessageHeartbeat{
+		Type:      common.MAV_TYPE_QUADROTOR,
+		Autopilot: common.MAV_AUTOPILOT_PX4,
+	}, mavlink.Config{})
+	s := NewControlServer(deps)
+
+	// A 2D fix with plenty of satellites
+	vehicle.WriteMessageAll(&common.MessageGpsRawInt{ //nolint:errcheck
+		FixType: common.GPS_FIX_TYPE_2D_FIX, SatellitesVisible: 12, Eph: 90,
+	})
+	deadline := time.Now().Add(2 * time.Second)
+	for client.GetTelemetry().GPSFixType != common.GPS_FIX_TYPE_2D_FIX {
+		if time.Now().After(deadline) {
+			t.Fatal("GPS_RAW_INT not processed")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	takeoff := func(force bool) *drone.TakeoffResponse {
+		t.Helper()
+		req := connect.NewRequest(&drone.TakeoffRequest{Altitude: 10})
+		if force {
+			req.Header().Set(ForceTakeoffHeader, "true")
+		}
+		resp, err := s.Takeoff(context.Background(), req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return resp.Msg
+	}
+
+	if resp := takeoff(false); resp.Success ||
+		!strings.Contains(resp.Message, "GPS has no 3D fix (2D_FIX)") || !strings.Contains(resp.Message, ForceTakeoffHeader) {
+		t.Fatalf("gated takeoff: %+v", resp)
+	}
+
+	if resp := takeoff(true); !resp.Success {
+		t.Fatalf("forced takeoff: %s", resp.Message)
+	}
+	timeout := time.After(2 * time.Second)
+	for {
+		select {
+		case evt := <-vehicle.Events():
+			if frame, ok := evt.(*gomavlib.EventFrame); ok {
+				if cmd, ok := frame.Message().(*common.MessageCommandLong); ok && cmd.Command == common.MAV_CMD_NAV_TAKEOFF {
+					if cmd.Param7 != 10 {
+						t.Errorf("takeoff to %v m", cmd.Param7)
+					}
+					return
+				}
+			}
+		case <-timeout:
+			t.Fatal("forced takeoff not sent")
+		}
+	}
+}
+
+func TestCancelGoTo(t *testing.T) {
+	deps, _, vehicle := activeDrone(t, &common.MessageHeartbeat{
+		Type:       common.MAV_TYPE_QUADROTOR,
+		Autopilot:  common.MAV_AUTOPILOT_PX4,
+		BaseMode:   common.MAV_MODE_FLAG_SAFETY_ARMED | common.MAV_MODE_FLAG_CUSTOM_MODE_ENABLED,
+		CustomMode: mavlink.PX4_MAIN_MODE_OFFBOARD,
+	}, mavlink.Config{GoToSetpointRate: 20})
+	s := NewControlServer(deps)
+
+	// The vehicle counts setpoints and accepts commands, passing on mode changes
+	var setpoints atomic.Int32
+	modes := make(chan float32, 10)
+	done := make(chan struct{})
+	stopped := make(chan struct{})
+	go func() {
+		defer close(stopped)
+		for {
+			select {
+			case evt := <-vehicle.Events():
+				frame, ok := evt.(*gomavlib.EventFrame)
+				if !ok {
+					continue
+				}
+				switch msg := frame.Message().(type) {
+				case *common.MessageSetPositionTargetGlobalInt:
+					setpoints.Add(1)
+				case *common.MessageCommandLong:
+					if msg.Command == common.MAV_CMD_DO_SET_MODE {
+						modes <- msg.Param2
+					}
+					vehicle.WriteMessageAll(&common.MessageCommandAck{ //nolint:errcheck
+						Command: msg.Command, Result: common.MAV_RESULT_ACCEPTED,
+					})
+				}
+			case <-done:
+				return
+			}
+		}
+	}()
+	t.Cleanup(func() {
+		close(done)
+		<-stopped
+	})
+
+	cancelGoTo := func(hold bool) *CommandResponse {
+		t.Helper()
+		resp, err := s.CancelGoTo(context.Background(), hold)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return resp
+	}
+	goTo := func() {
+		t.Helper()
+		resp, err := s.GoToPosition(context.Background(), connect.NewRequest(&drone.GoToPositionRequest{
+			Target: &drone.Position{Latitude: 47.001, Longitude: 8, Altitude: 30},
+		}))
+		if err != nil || !resp.Msg.Success {
+			t.Fatalf("GoToPosition: %+v, %v", resp, err)
+		}
+		deadline := time.Now().Add(2 * time.Second)
+		for start := setpoints.Load(); setpoints.Load() < start+3; {
+			if time.Now().After(deadline) {
+				t.Fatal("setpoints not streamed")
+			}
+			time.Sleep(10 * time.Millisecond)
+		}
+	}
+	// quiet fails if setpoints keep arriving once those on the link are in
+	quiet := func() {
+		t.Helper()
+		time.Sleep(100 * time.Millisecond)
+		sent := setpoints.Load()
+		time.Sleep(250 * time.Millisecond)
+		if more := setpoints.Load() - sent; more != 0 {
+			t.Errorf("%d setpoints sent after cancelling", more)
+		}
+	}
+
+	// Nothing in flight: harmless
+	if resp := cancelGoTo(false); !resp.Success || resp.Message != "No go-to target was being sent" {
+		t.Errorf("cancel with nothing in flight: %+v", resp)
+	}
+
+	goTo()
+	if resp := cancelGoTo(false); !resp.Success || resp.Message != "Go-to target cancelled" {
+		t.Errorf("cancel: %+v", resp)
+	}
+	quiet()
+	select {
+	case mode := <-modes:
+		t.Errorf("mode changed to %v without hold", mode)
+	default:
+	}
+
+	goTo()
+	if resp := cancelGoTo(true); !resp.Success || !strings.Contains(resp.Message, "holding position") {
+		t.Errorf("cancel with hold: %+v", resp)
+	}
+	quiet()
+	select {
+	case mode := <-modes:
+		if mode != float32(mavlink.PX4_MAIN_MODE_AUTO|mavlink.PX4_AUTO_MODE_LOITER<<16) {
+			t.Errorf("hold switched to mode %v", mode)
+		}
+	default:
+		t.Error("hold didn't change mode")
+	}
+}
+
+func TestRejectDuringMission(t *testing.T) {
+	deps, _, vehicle := activeDrone(t, &common.MessageHeartbeat{
+		Type:       common.MAV_TYPE_QUADROTOR,
+		Autopilot:  common.MAV_AUTOPILOT_PX4,
+		BaseMode:   common.MAV_MODE_FLAG_SAFETY_ARMED | common.MAV_MODE_FLAG_CUSTOM_MODE_ENABLED,
+		CustomMode: mavlink.PX4_MAIN_MODE_AUTO | mavlink.PX4_AUTO_MODE_MISSION<<16,
+	}, mavlink.Config{})
+	deps.Config.MAVLink.TakeoffGPSGate = false
+	s := NewControlServer(deps)
+
+	takeoff := func(override bool) (*drone.TakeoffResponse, error) {
+		req := connect.NewRequest(&drone.TakeoffRequest{Altitude: 10})
+		if override {
+			req.Header().Set(OverrideMissionHeader, "true")
+		}
+		resp, err := s.Takeoff(context.Background(), req)
+		if err != nil {
+			return nil, err
+		}
+		return resp.Msg, nil
+	}
+
+	// Off by default
+	if resp, err := takeoff(false); err != nil || !resp.Success {
+		t.Fatalf("takeoff without the policy: %+v, %v", resp, err)
+	}
+
+	deps.Config.MAVLink.RejectDuringMission = true
+	_, err := takeoff(false)
+	if connect.CodeOf(err) != connect.CodeFailedPrecondition || !strings.Contains(err.Error(), "a mission is active") {
+		t.Errorf("takeoff during a mission: %v, want failed_precondition", err)
+	}
+	goTo, err := s.GoToPosition(context.Background(), connect.NewRequest(&drone.GoToPositionRequest{
+		Target: &drone.Position{Latitude: 47.001, Longitude: 8, Altitude: 30},
+	}))
+	if connect.CodeOf(err) != connect.CodeFailedPrecondition {
+		t.Errorf("go-to during a mission: %+v, %v, want failed_precondition", goTo, err)
+	}
+
+	if resp, err := takeoff(true); err != nil || !resp.Success {
+		t.Fatalf("overriding takeoff: %+v, %v", resp, err)
+	}
+
+	// Both accepted takeoffs reached the vehicle
+	timeout := time.After(2 * time.Second)
+	for sent := 0; sent < 2; {
+		select {
+		case evt := <-vehicle.Events():
+			if frame, ok := evt.(*gomavlib.EventFrame); ok {
+				if cmd, ok := frame.Message().(*common.MessageCommandLong); ok && cmd.Command == common.MAV_CMD_NAV_TAKEOFF {
+					sent++
+				}
+			}
+		case <-timeout:
+			t.Fatalf("%d takeoffs sent, want 2", sent)
+		}
+	}
+}
+
+func TestSetFlightModeAllowlist(t *testing.T) {
+	deps, _, vehicle := activeDrone(t, &common.MessageHeartbeat{
+		Type:      common.MAV_TYPE_QUADROTOR,
+		Autopilot: common.MAV_AUTOPILOT_PX4,
+	}, mavlink.Config{})
+	registry := deps.GetDroneRegistry()
+	registry.Drones = append(registry.Drones, config.DroneConfig{
+		ID:                 "alpha",
+		Protocol:           "mavlink",
+		AllowedFlightModes: []string{"FLIGHT_MODE_AUTO", "FLIGHT_MODE_RETURN_HOME", "FLIGHT_MODE_LAND"},
+	})
+	s := NewControlServer(deps)
+
+	// The vehicle accepts commands, passing on mode changes
+	modes := make(chan float32, 10)
+	done := make(chan struct{})
+	stopped := make(chan struct{})
+	go func() {
+		defer close(stopped)
+		for {
+			select {
+			case evt := <-vehicle.Events():
+				frame, ok := evt.(*gomavlib.EventFrame)
+				if !ok {
+					continue
+				}
+				if msg, ok := frame.Message().(*common.MessageCommandLong); ok {
+					if msg.Command == common.MAV_CMD_DO_SET_MODE {
+						modes <- msg.Param2
+					}
+					vehicle.WriteMessageAll(&common.MessageCommandAck{ //nolint:errcheck
+						Command: msg.Command, Result: common.MAV_RESULT_ACCEPTED,
+					})
+				}
+			case <-done:
+				return
+			}
+		}
+	}()
+	t.Cleanup(func() {
+		close(done)
+		<-stopped
+	})
+
+	setMode := func(mode drone.FlightMode) (*drone.SetFlightModeResponse, error) {
+		resp, err := s.SetFlightMode(context.Background(), connect.NewRequest(&drone.SetFlightModeRequest{Mode: mode}))
+		if err != nil {
+			return nil, err
+		}
+		return resp.Msg, nil
+	}
+
+	// Refused before anything is sent
+	if _, err := setMode(drone.FlightMode_FLIGHT_MODE_MANUAL); connect.CodeOf(err) != connect.CodePermissionDenied {
+		t.Errorf("MANUAL: %v, want permission_denied", err)
+	}
+	if resp, err := setMode(drone.FlightMode_FLIGHT_MODE_LAND); err != nil || !resp.Success {
+		t.Fatalf("LAND: %+v, %v", resp, err)
+	}
+
+	// Only the land mode reached the vehicle
+	if len(modes) != 1 {
+		t.Errorf("%d mode changes sent, want 1", len(modes))
+	} else if mode := <-modes; mode != float32(mavlink.PX4_MAIN_MODE_AUTO|mavlink.PX4_AUTO_MODE_LAND<<16) {
+		t.Errorf("mode %v sent, want land", mode)
+	}
+
+	// The UI is told which modes to offer
+	caps, err := NewConnectionServer(deps).GetCapabilities(context.Background(), "alpha")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"FLIGHT_MODE_AUTO", "FLIGHT_MODE_RETURN_HOME", "FLIGHT_MODE_LAND"}; !slices.Equal(caps.FlightModes, want) {
+		t.Errorf("capabilities flight modes = %v, want %v", caps.FlightModes, want)
+	}
+}
@@ -0,0 +1,40 @@
+package services
+
+import (
+	"context"
+	"net/http"
+
+	"connectrpc.com/connect"
+
+	"github.com/flightpath-dev/flightpath-server/internal/mavlink"
+)
+
+// ControlStreamCalibrationProcedure is the calibration stream, which the
+// generated ControlService doesn't have yet; like the telemetry RPCs in
+// telemetry_rpc.go its messages are JSON
+const ControlStreamCalibrationProcedure = "/drone.v1.ControlService/StreamCalibration"
+
+// StreamCalibrationRequest selects the drone (empty means the active drone)
+// and the sensor to calibrate: gyro, mag, accel, level, baro or radio
+type StreamCalibrationRequest struct {
+	DroneID string `json:"drone_id,omitempty"`
+	Type    string `json:"type"`
+}
+
+// NewControlStreamCalibrationHandler returns the StreamCalibration RPC and the
+// path to register it on
+// Closing the stream before the calibration ends cancels it on the vehicle.
+func NewControlStreamCalibrationHandler(s *ControlServer, opts ...connect.HandlerOption) (string, http.Handler) {
+	opts = append(opts, connect.WithCodec(jsonCodec{}))
+	return ControlStreamCalibrationProcedure, connect.NewServerStreamHandler(
+		ControlStreamCalibrationProcedure,
+		func(ctx context.Context, req *connect.Request[StreamCalibrationRequest], stream *connect.ServerStream[mavlink.CalibrationStatus]) error {
+			calType, err := mavlink.ParseCalibrationType(req.Msg.Type)
+			if err != nil {
+				return connect.NewError(connect.CodeInvalidArgument, err)
+			}
+			return s.StreamCalibration(ctx, req.Msg.DroneID, calType, stream)
+		},
+		opts...,
+	)
+}
@@ -0,0 +1,95 @@
+package services
+
+import (
+	"context"
+	"slices"
+	"sync"
+	"time"
+
+	"connectrpc.com/connect"
+
+	"github.com/flightpath-dev/flightpath-server/internal/server"
+)
+
+// commandDebouncer answers an identical command to the same drone, repeated
+// within the debounce window, with the result of the first
+// A duplicate arriving while the first is still running waits for it.
+type commandDebouncer struct {
+	deps *server.Dependencies
+
+	mu     sync.Mutex
+	recent map[string]*debouncedCommand // by drone, command and params
+}
+
+// debouncedCommand is a command that ran (or is running) recently
+type debouncedCommand struct {
+	done     chan struct{} // closed once resp and err are set
+	finished time.Time
+	resp     any
+	err      error
+}
+
+func newCommandDebouncer(deps *server.Dependencies) *commandDebouncer {
+	return &commandDebouncer{
+		deps:   deps,
+		recent: make(map[string]*debouncedCommand),
+	}
+}
+
+// start registers a command to the request's target drone (params tell
+// identical commands apart)
+// When an identical one ran within the window, or is running, prior is it and
+// the command must not be sent. Otherwise finish must be called with the
+// command's result. Commands not in DebouncedCommands, or with debouncing
+// off, always run.
+func (d *commandDebouncer) start(ctx context.Context, command, params string) (prior *debouncedCommand, finish func(resp any, err error)) {
+	cfg := &d.deps.Config.MAVLink
+	window := cfg.CommandDebounce
+	if window <= 0 || !slices.Contains(cfg.DebouncedCommands, command) {
+		return nil, func(any, error) {}
+	}
+
+	key := targetDrone(ctx, d.deps, "") + "\x00" + command + "\x00" + params
+	now := time.Now()
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	for k, c := range d.recent {
+		if !c.finished.IsZero() && now.Sub(c.finished) > window {
+			delete(d.recent, k)
+		}
+	}
+	if c, ok := d.recent[key]; ok {
+		return c, nil
+	}
+
+	c := &debouncedCommand{done: make(chan struct{})}
+	d.recent[key] = c
+	return nil, func(resp any, err error) {
+		d.mu.Lock()
+		c.resp, c.err = resp, err
+		c.finished = time.Now()
+		d.mu.Unlock()
+		close(c.done)
+	}
+}
+
+// debouncedResponse waits for a prior identical command and returns its result
+// as a fresh response with the same message
+func debouncedResponse[T any](ctx context.Context, prior *debouncedCommand) (*connect.Response[T], error) {
+	select {
+	case <-prior.done:
+	case <-ctx.Done():
+		return nil, connect.NewError(connect.CodeCanceled, ctx.Err())
+	}
+
+	if prior.err != nil {
+		return nil, prior.err
+	}
+	resp, _ := prior.resp.(*connect.Response[T])
+	if resp == nil {
+		return nil, nil
+	}
+	return connect.NewResponse(resp.Msg), nil
+}
@@ -0,0 +1,64 @@
+package services
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestCommandDebouncerKey(t *testing.T) {
+	alpha := WithDrone(context.Background(), "alpha")
+	bravo := WithDrone(context.Background(), "bravo")
+
+	tests := []struct {
+		name    string
+		ctx     context.Context
+		command string
+		params  string
+		deduped bool
+	}{
+		{"same drone, command and params", alpha, "takeoff", "alt=10", true},
+		{"other drone", bravo, "takeoff", "alt=10", false},
+		{"other command", alpha, "land", "alt=10", false},
+		{"other params", alpha, "takeoff", "alt=20", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			deps := newTestDependencies(t)
+			deps.Config.MAVLink.CommandDebounce = time.Minute
+			deps.Config.MAVLink.DebouncedCommands = []string{"takeoff", "land"}
+			d := newCommandDebouncer(deps)
+
+			// Every case follows the same first command
+			prior, finish := d.start(alpha, "takeoff", "alt=10")
+			if prior != nil {
+				t.Fatal("first command was debounced")
+			}
+			finish("first", nil)
+
+			prior, _ = d.start(tt.ctx, tt.command, tt.params)
+			if got := prior != nil; got != tt.deduped {
+				t.Fatalf("deduped = %v, want %v", got, tt.deduped)
+			}
+			if tt.deduped && prior.resp != "first" {
+				t.Errorf("prior result = %v, want the first command's", prior.resp)
+			}
+		})
+	}
+}
+
+func TestCommandDebouncerSkipsOtherCommands(t *testing.T) {
+	deps := newTestDependencies(t)
+	deps.Config.MAVLink.CommandDebounce = time.Minute
+	deps.Config.MAVLink.DebouncedCommands = []string{"takeoff"}
+	d := newCommandDebouncer(deps)
+	ctx := WithDrone(context.Background(), "alpha")
+
+	for i := 0; i < 2; i++ {
+		prior, finish := d.start(ctx, "arm", "")
+		if prior != nil {
+			t.Fatalf("arm %d was debounced", i)
+		}
+		finish(nil, nil)
+	}
+}
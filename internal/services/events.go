@@ -0,0 +1,148 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"connectrpc.com/connect"
+
+	"github.com/flightpath-dev/flightpath-server/internal/mavlink"
+	"github.com/flightpath-dev/flightpath-server/internal/server"
+)
+
+// EventServer streams link-level feeds and vehicle events that have no proto
+// definition yet
+type EventServer struct {
+	deps *server.Dependencies
+}
+
+// NewEventServer creates a new EventServer
+func NewEventServer(deps *server.Dependencies) *EventServer {
+	return &EventServer{
+		deps: deps,
+	}
+}
+
+// StreamRawMessages streams every incoming MAVLink frame with its decoded fields
+// types optionally limits the stream to the named messages (e.g. "ATTITUDE").
+// Frames are dropped, not queued, when the caller falls behind; the count is in
+// the drone's connection info. An empty droneID means the active drone.
+func (s *EventServer) StreamRawMessages(
+	ctx context.Context,
+	droneID string,
+	types []string,
+	stream streamSender[mavlink.RawMessage],
+) error {
+	logger := s.deps.GetLogger()
+	logger.Printf("StreamRawMessages request: drone_id=%s, types=%v", droneID, types)
+
+	if err := mavlink.ValidateMessageNames(types); err != nil {
+		return connect.NewError(connect.CodeInvalidArgument, err)
+	}
+
+	var filter map[string]bool
+	if len(types) > 0 {
+		filter = make(map[string]bool, len(types))
+		for _, t := range types {
+			filter[strings.ToUpper(t)] = true
+		}
+	}
+
+	client, err := requireReadyClient(ctx, s.deps, droneID, policyClient)
+	if err != nil {
+		return err
+	}
+	defer s.deps.HoldMAVLinkClient(client)()
+
+	messages, unsubscribe := client.SubscribeRawMessages()
+	defer unsubscribe()
+
+	for {
+		select {
+		case <-ctx.Done():
+			logger.Println("StreamRawMessages: Client disconnected")
+			return nil
+
+		case msg, ok := <-messages:
+			if !ok {
+				return connect.NewError(connect.CodeUnavailable,
+					fmt.Errorf("connection to drone closed"))
+			}
+
+			// Undecoded messages have no name and only pass an empty filter
+			if filter != nil && !filter[msg.Name] {
+				continue
+			}
+
+			if err := stream.Send(&msg); err != nil {
+				logger.Printf("StreamRawMessages: Error sending: %v", err)
+				return err
+			}
+		}
+	}
+}
+
+// StreamEvents streams the drone's vehicle and link events: failsafes, the
+// stale-telemetry alarm, link down/restored and go-to arrivals
+// Events are dropped, not queued, when the caller falls behind. An empty
+// droneID means the active drone.
+func (s *EventServer) StreamEvents(
+	ctx context.Context,
+	droneID string,
+	stream streamSender[mavlink.Event],
+) error {
+	logger := s.deps.GetLogger()
+	logger.Printf("StreamEvents request: drone_id=%s", droneID)
+
+	client, err := requireReadyClient(ctx, s.deps, droneID, policyClient)
+	if err != nil {
+		return err
+	}
+	defer s.deps.HoldMAVLinkClient(client)()
+
+	events, unsubscribe := client.SubscribeEvents()
+	defer unsubscribe()
+
+	for {
+		select {
+		case <-ctx.Done():
+			logger.Println("StreamEvents: Client disconnected")
+			return nil
+
+		case evt, ok := <-events:
+			if !ok {
+				return connect.NewError(connect.CodeUnavailable,
+					fmt.Errorf("connection to drone closed"))
+			}
+			if err := stream.Send(&evt); err != nil {
+				logger.Printf("StreamEvents: Error sending: %v", err)
+				return err
+			}
+		}
+	}
+}
+
+// EventStreamEventsProcedure serves StreamEvents over Connect; there is no
+// proto EventService yet, so its messages are JSON like the telemetry RPCs in
+// telemetry_rpc.go
+const EventStreamEventsProcedure = "/drone.v1.EventService/StreamEvents"
+
+// StreamEventsRequest selects the drone; empty means the active drone
+type StreamEventsRequest struct {
+	DroneID string `json:"drone_id,omitempty"`
+}
+
+// NewEventStreamEventsHandler returns the StreamEvents RPC and the path to
+// register it on
+func NewEventStreamEventsHandler(s *EventServer, opts ...connect.HandlerOption) (string, http.Handler) {
+	opts = append(opts, connect.WithCodec(jsonCodec{}))
+	return EventStreamEventsProcedure, connect.NewServerStreamHandler(
+		EventStreamEventsProcedure,
+		func(ctx context.Context, req *connect.Request[StreamEventsRequest], stream *connect.ServerStream[mavlink.Event]) error {
+			return s.StreamEvents(ctx, req.Msg.DroneID, stream)
+		},
+		opts...,
+	)
+}
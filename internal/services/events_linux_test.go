@@ -0,0 +1,62 @@
+package services
+
+import (
+	"context"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"connectrpc.com/connect"
+	"github.com/bluenviron/gomavlib/v3/pkg/dialects/common"
+
+	"github.com/flightpath-dev/flightpath-server/internal/mavlink"
+)
+
+func TestStreamEventsRPC(t *testing.T) {
+	deps := newTestDependencies(t)
+	client, vehicle := startMockDrone(t, 1, 473977420, 85455940)
+	deps.AddMAVLinkClient("alpha", client)
+
+	path, handler := NewEventStreamEventsHandler(NewEventServer(deps))
+	ts := httptest.NewServer(handler)
+	defer ts.Close()
+	rpc := connect.NewClient[StreamEventsRequest, mavlink.Event](
+		ts.Client(), ts.URL+path, connect.WithCodec(jsonCodec{}),
+	)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	// The vehicle keeps losing and regaining RC until the stream reports it:
+	// the call only returns once the server sends its first event
+	go func() {
+		texts := []string{"RC signal lost - return to launch", "RC regained"}
+		for i := 0; ctx.Err() == nil; i++ {
+			vehicle.WriteMessageAll(&common.MessageStatustext{ //nolint:errcheck
+				Severity: common.MAV_SEVERITY_CRITICAL,
+				Text:     texts[i%2],
+			})
+			time.Sleep(50 * time.Millisecond)
+		}
+	}()
+
+	stream, err := rpc.CallServerStream(ctx, connect.NewRequest(&StreamEventsRequest{DroneID: "alpha"}))
+	if err != nil {
+		t.Fatalf("StreamEvents: %v", err)
+	}
+	defer stream.Close()
+
+	for stream.Receive() {
+		evt := stream.Msg()
+		if evt.Kind != mavlink.EventFailsafe || evt.Failsafe == nil || !evt.Failsafe.Active {
+			continue
+		}
+		if evt.Failsafe.Type != mavlink.FailsafeRCLoss || evt.Failsafe.Action != mavlink.FailsafeActionRTL {
+			t.Errorf("failsafe = %+v, want rc_loss/rtl", evt.Failsafe)
+		}
+		if evt.Severity != common.MAV_SEVERITY_CRITICAL {
+			t.Errorf("severity = %v, want critical", evt.Severity)
+		}
+		return
+	}
+	t.Fatalf("stream ended without a failsafe event: %v", stream.Err())
+}
@@ -0,0 +1,177 @@
+package services
+
+import (
+	"context"
+	"net/http"
+	"slices"
+	"time"
+
+	"connectrpc.com/connect"
+
+	drone "github.com/flightpath-dev/flightpath-proto/gen/go/drone/v1"
+	"github.com/flightpath-dev/flightpath-server/internal/mavlink"
+)
+
+// Fleet membership changes announced in StreamFleetTelemetry
+const (
+	FleetEventJoined = "joined" // The drone connected; its telemetry follows
+	FleetEventLeft   = "left"   // The drone disconnected; no more frames for it
+)
+
+// FleetTelemetryFrame is one item of StreamFleetTelemetry
+// Membership frames carry Event and no telemetry. Telemetry is left out while
+// the drone's link is down (Connected false).
+type FleetTelemetryFrame struct {
+	DroneID     string `json:"drone_id"`
+	TimestampMs int64  `json:"timestamp_ms"`
+	Event       string `json:"event,omitempty"`
+
+	Connected     bool                           `json:"connected"`
+	PositionValid bool                           `json:"position_valid"`
+	Telemetry     *drone.StreamTelemetryResponse `json:"telemetry,omitempty"`
+}
+
+// StreamFleetTelemetry multiplexes the telemetry of every drone with a MAVLink
+// client into one stream
+// rateHz is the aggregate rate: each tick sends the next drone in turn, so
+// every drone gets rateHz divided by the fleet size. 0 means the configured
+// FleetTelemetryRate. Drones connecting or disconnecting mid-stream are added
+// or dropped with a joined/left frame; the stream itself keeps going, also
+// with no drones at all. header carries the StreamTelemetry output options.
+func (s *TelemetryServer) StreamFleetTelemetry(
+	ctx context.Context,
+	rateHz int32,
+	header http.Header,
+	stream streamSender[FleetTelemetryFrame],
+) error {
+	logger := s.deps.GetLogger()
+	logger.Printf("StreamFleetTelemetry request: rate_hz=%d", rateHz)
+
+	if rateHz == 0 {
+		rateHz = s.deps.Config.Server.FleetTelemetryRate
+	}
+	interval, err := streamIntervalFromRate(rateHz)
+	if err != nil {
+		return err
+	}
+
+	output, err := telemetryOutputFromHeader(header)
+	if err != nil {
+		return connect.NewError(connect.CodeInvalidArgument, err)
+	}
+
+	// Streamed drones and the release of their idle-timeout hold
+	members := make(map[string]*fleetMember)
+	defer func() {
+		for _, member := range members {
+			member.release()
+		}
+	}()
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	next := 0
+	for {
+		select {
+		case <-ctx.Done():
+			logger.Println("StreamFleetTelemetry: Client disconnected")
+			return nil
+
+		case now := <-ticker.C:
+			droneIDs, err := s.syncFleetMembers(members, now, stream)
+			if err != nil {
+				logger.Printf("StreamFleetTelemetry: Error sending: %v", err)
+				return err
+			}
+			if len(droneIDs) == 0 {
+				continue
+			}
+
+			next %= len(droneIDs)
+			droneID := droneIDs[next]
+			next++
+
+			frame := FleetTelemetryFrame{
+				DroneID:     droneID,
+				TimestampMs: now.UnixMilli(),
+			}
+			client := members[droneID].client
+			if client.IsConnected() {
+				telemetry := client.TelemetrySnapshot()
+				frame.Connected = true
+				frame.PositionValid = telemetry.PositionValid()
+				frame.Telemetry = s.buildStreamResponse(telemetry)
+				output.apply(frame.Telemetry)
+			}
+
+			if err := stream.Send(&frame); err != nil {
+				logger.Printf("StreamFleetTelemetry: Error sending: %v", err)
+				return err
+			}
+		}
+	}
+}
+
+// fleetMember is a drone in a StreamFleetTelemetry multiplex
+type fleetMember struct {
+	client  *mavlink.Client
+	release func()
+}
+
+// syncFleetMembers adds drones that connected and drops those that
+// disconnected (or reconnected with a new client), sending a frame for each
+// change
+// Returns the sorted IDs of the drones to stream.
+func (s *TelemetryServer) syncFleetMembers(
+	members map[string]*fleetMember,
+	now time.Time,
+	stream streamSender[FleetTelemetryFrame],
+) ([]string, error) {
+	current := make(map[string]*mavlink.Client)
+	for _, droneID := range s.deps.GetMAVLinkDroneIDs() {
+		if client, ok := s.deps.GetMAVLinkClientFor(droneID); ok {
+			current[droneID] = client
+		}
+	}
+
+	for droneID, member := range members {
+		if current[droneID] == member.client {
+			continue
+		}
+		member.release()
+		delete(members, droneID)
+		s.deps.GetLogger().Printf("StreamFleetTelemetry: Drone %s left", droneID)
+		if err := stream.Send(&FleetTelemetryFrame{
+			DroneID:     droneID,
+			TimestampMs: now.UnixMilli(),
+			Event:       FleetEventLeft,
+		}); err != nil {
+			return nil, err
+		}
+	}
+
+	droneIDs := make([]string, 0, len(current))
+	for droneID, client := range current {
+		droneIDs = append(droneIDs, droneID)
+		if _, ok := members[droneID]; ok {
+			continue
+		}
+		members[droneID] = &fleetMember{
+			client:  client,
+			release: s.deps.HoldMAVLinkClient(client),
+		}
+		s.deps.GetLogger().Printf("StreamFleetTelemetry: Drone %s joined", droneID)
+		if err := stream.Send(&FleetTelemetryFrame{
+			DroneID:     droneID,
+			TimestampMs: now.UnixMilli(),
+			Event:       FleetEventJoined,
+			Connected:   client.IsConnected(),
+		}); err != nil {
+			return nil, err
+		}
+	}
+
+	slices.Sort(droneIDs)
+	return droneIDs, nil
+}
@@ -0,0 +1,118 @@
+package services
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/bluenviron/gomavlib/v3/pkg/dialects/common"
+
+	"github.com/flightpath-dev/flightpath-server/internal/mavlink"
+)
+
+// fleetRecorder is a fleet telemetry stream that hands frames to the test
+type fleetRecorder chan FleetTelemetryFrame
+
+func (r fleetRecorder) Send(frame *FleetTelemetryFrame) error {
+	r <- *frame
+	return nil
+}
+
+// positionedDrone returns a connected client for a mock drone at latitude lat (1E7 degrees)
+func positionedDrone(t *testing.T, lat int32) *mavlink.Client {
+	t.Helper()
+	vehicle, device, heartbeats := silentDrone(t)
+	heartbeats()
+	client := staleClient(t, device)
+	if err := client.WaitForConnection(2 * time.Second); err != nil {
+		t.Fatal(err)
+	}
+
+	vehicle.WriteMessageAll(&common.MessageGlobalPositionInt{Lat: lat, Lon: 85455940}) //nolint:errcheck
+	deadline := time.Now().Add(2 * time.Second)
+	for !client.GetTelemetry().PositionValid() {
+		if time.Now().After(deadline) {
+			t.Fatal("position not received")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	return client
+}
+
+func TestStreamFleetTelemetry(t *testing.T) {
+	deps := newTestDependencies(t)
+	deps.AddMAVLinkClient("alpha", positionedDrone(t, 471000000))
+	bravo := positionedDrone(t, 472000000)
+	deps.AddMAVLinkClient("bravo", bravo)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	frames := make(fleetRecorder, 100)
+	done := make(chan error, 1)
+	go func() {
+		done <- NewTelemetryServer(deps).StreamFleetTelemetry(ctx, 40, http.Header{}, frames)
+	}()
+	t.Cleanup(func() {
+		cancel()
+		<-done
+	})
+
+	next := func() FleetTelemetryFrame {
+		t.Helper()
+		select {
+		case frame := <-frames:
+			return frame
+		case err := <-done:
+			t.Fatalf("stream ended: %v", err)
+		case <-time.After(2 * time.Second):
+			t.Fatal("no frame sent")
+		}
+		return FleetTelemetryFrame{}
+	}
+
+	// Both join, in no particular order
+	joined := make(map[string]bool)
+	for range 2 {
+		frame := next()
+		if frame.Event != FleetEventJoined || !frame.Connected {
+			t.Fatalf("frame = %+v, want a drone joining", frame)
+		}
+		joined[frame.DroneID] = true
+	}
+	if !joined["alpha"] || !joined["bravo"] {
+		t.Fatalf("joined: %v", joined)
+	}
+	// Telemetry alternates between the drones, each tagged with its own
+	wantLatitude := map[string]float64{"alpha": 47.1, "bravo": 47.2}
+	for _, want := range []string{"alpha", "bravo", "alpha", "bravo"} {
+		frame := next()
+		if frame.DroneID != want || frame.Event != "" || frame.Telemetry == nil {
+			t.Fatalf("frame = %+v, want %s telemetry", frame, want)
+		}
+		if lat := frame.Telemetry.Position.Latitude; !frame.PositionValid || lat != wantLatitude[want] {
+			t.Errorf("%s latitude = %v (valid %v), want %v", want, lat, frame.PositionValid, wantLatitude[want])
+		}
+	}
+	// Streamed drones are never idle
+	if idle := deps.GetIdleMAVLinkDroneIDs(0); len(idle) != 0 {
+		t.Errorf("idle while streamed: %v", idle)
+	}
+
+	// bravo disconnects mid-stream: it leaves and alpha keeps streaming
+	deps.RemoveMAVLinkClient("bravo")
+	bravo.Close()
+	for {
+		frame := next()
+		if frame.Event == FleetEventLeft {
+			if frame.DroneID != "bravo" {
+				t.Fatalf("%s left, want bravo", frame.DroneID)
+			}
+			break
+		}
+	}
+	for range 5 {
+		if frame := next(); frame.DroneID != "alpha" || frame.Telemetry == nil {
+			t.Fatalf("frame = %+v after bravo left, want alpha telemetry", frame)
+		}
+	}
+}
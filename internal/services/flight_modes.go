@@ -0,0 +1,65 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"slices"
+
+	drone "github.com/flightpath-dev/flightpath-proto/gen/go/drone/v1"
+	"github.com/flightpath-dev/flightpath-server/internal/config"
+	"github.com/flightpath-dev/flightpath-server/internal/server"
+)
+
+// settableFlightModes are the modes SetFlightMode can switch to
+var settableFlightModes = []drone.FlightMode{
+	drone.FlightMode_FLIGHT_MODE_MANUAL,
+	drone.FlightMode_FLIGHT_MODE_STABILIZED,
+	drone.FlightMode_FLIGHT_MODE_ALTITUDE_HOLD,
+	drone.FlightMode_FLIGHT_MODE_POSITION_HOLD,
+	drone.FlightMode_FLIGHT_MODE_GUIDED,
+	drone.FlightMode_FLIGHT_MODE_AUTO,
+	drone.FlightMode_FLIGHT_MODE_RETURN_HOME,
+	drone.FlightMode_FLIGHT_MODE_LAND,
+	drone.FlightMode_FLIGHT_MODE_TAKEOFF,
+	drone.FlightMode_FLIGHT_MODE_LOITER,
+}
+
+// allowedFlightModes returns the modes a drone's allowed_flight_modes permits,
+// all settable modes when it has none
+// Names are FlightMode values (e.g. FLIGHT_MODE_AUTO); unknown ones are an error.
+func allowedFlightModes(droneConfig *config.DroneConfig) ([]drone.FlightMode, error) {
+	if droneConfig == nil || len(droneConfig.AllowedFlightModes) == 0 {
+		return settableFlightModes, nil
+	}
+
+	modes := make([]drone.FlightMode, 0, len(droneConfig.AllowedFlightModes))
+	for _, name := range droneConfig.AllowedFlightModes {
+		value, ok := drone.FlightMode_value[name]
+		if !ok || !slices.Contains(settableFlightModes, drone.FlightMode(value)) {
+			return nil, fmt.Errorf("unknown flight mode in allowed_flight_modes: %q", name)
+		}
+		modes = append(modes, drone.FlightMode(value))
+	}
+	return modes, nil
+}
+
+// allowedFlightModesFor returns the allowed modes of a registry drone (the
+// request's target drone for an empty droneID); drones not in the registry
+// allow all
+func allowedFlightModesFor(ctx context.Context, deps *server.Dependencies, droneID string) ([]drone.FlightMode, error) {
+	droneID = targetDrone(ctx, deps, droneID)
+	droneConfig, err := deps.GetDroneRegistry().FindDrone(droneID)
+	if err != nil {
+		return settableFlightModes, nil
+	}
+	return allowedFlightModes(droneConfig)
+}
+
+// flightModeNames returns the FlightMode names of modes
+func flightModeNames(modes []drone.FlightMode) []string {
+	names := make([]string, len(modes))
+	for i, mode := range modes {
+		names[i] = mode.String()
+	}
+	return names
+}
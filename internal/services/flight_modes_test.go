@@ -0,0 +1,40 @@
+package services
+
+import (
+	"reflect"
+	"testing"
+
+	drone "github.com/flightpath-dev/flightpath-proto/gen/go/drone/v1"
+	"github.com/flightpath-dev/flightpath-server/internal/config"
+)
+
+func TestAllowedFlightModes(t *testing.T) {
+	// Unspecified: every settable mode
+	for _, droneConfig := range []*config.DroneConfig{nil, {ID: "alpha"}} {
+		if modes, err := allowedFlightModes(droneConfig); err != nil || !reflect.DeepEqual(modes, settableFlightModes) {
+			t.Errorf("no allowlist: %v, %v", modes, err)
+		}
+	}
+
+	modes, err := allowedFlightModes(&config.DroneConfig{
+		AllowedFlightModes: []string{"FLIGHT_MODE_AUTO", "FLIGHT_MODE_RETURN_HOME", "FLIGHT_MODE_LAND"},
+	})
+	want := []drone.FlightMode{
+		drone.FlightMode_FLIGHT_MODE_AUTO,
+		drone.FlightMode_FLIGHT_MODE_RETURN_HOME,
+		drone.FlightMode_FLIGHT_MODE_LAND,
+	}
+	if err != nil || !reflect.DeepEqual(modes, want) {
+		t.Errorf("allowlist: %v, %v; want %v", modes, err, want)
+	}
+	if names := flightModeNames(modes); !reflect.DeepEqual(names, []string{"FLIGHT_MODE_AUTO", "FLIGHT_MODE_RETURN_HOME", "FLIGHT_MODE_LAND"}) {
+		t.Errorf("names = %v", names)
+	}
+
+	// Unknown names, and modes SetFlightMode can't switch to, are refused
+	for _, name := range []string{"AUTO", "FLIGHT_MODE_UNSPECIFIED"} {
+		if _, err := allowedFlightModes(&config.DroneConfig{AllowedFlightModes: []string{name}}); err == nil {
+			t.Errorf("%q accepted", name)
+		}
+	}
+}
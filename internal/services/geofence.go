@@ -0,0 +1,115 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/flightpath-dev/flightpath-server/internal/mavlink"
+	"github.com/flightpath-dev/flightpath-server/internal/server"
+)
+
+// GeofenceServer configures the vehicle's geofence (no proto definition yet)
+type GeofenceServer struct {
+	deps *server.Dependencies
+}
+
+// NewGeofenceServer creates a new GeofenceServer
+func NewGeofenceServer(deps *server.Dependencies) *GeofenceServer {
+	return &GeofenceServer{
+		deps: deps,
+	}
+}
+
+// SetGeofenceRequest changes the geofence state and/or breach action
+// Omitted fields are left unchanged.
+type SetGeofenceRequest struct {
+	Enabled *bool  `json:"enabled,omitempty"`
+	Action  string `json:"action,omitempty"` // none, warn, rtl, land
+}
+
+// SetGeofence enables/disables the active drone's geofence and sets its breach action
+// The action is applied first, so enabling on PX4 uses the action from the same request.
+func (s *GeofenceServer) SetGeofence(ctx context.Context, req *SetGeofenceRequest) (resp *CommandResponse, err error) {
+	defer func() { recordAudit(ctx, s.deps, "set_geofence", "", req, resp, err) }()
+
+	logger := s.deps.GetLogger()
+	logger.Printf("SetGeofence request: enabled=%v, action=%s", formatOptionalBool(req.Enabled), req.Action)
+
+	if err := requireCommandsEnabled(s.deps); err != nil {
+		return nil, err
+	}
+
+	if req.Enabled == nil && req.Action == "" {
+		return &CommandResponse{
+			Success: false,
+			Message: "Nothing to set: specify enabled and/or action",
+		}, nil
+	}
+
+	var action mavlink.GeofenceAction
+	if req.Action != "" {
+		var err error
+		if action, err = mavlink.ParseGeofenceAction(req.Action); err != nil {
+			return &CommandResponse{
+				Success: false,
+				Message: err.Error(),
+			}, nil
+		}
+	}
+
+	client, err := requireReadyClient(ctx, s.deps, "", policyConnected)
+	if err != nil {
+		return &CommandResponse{
+			Success: false,
+			Message: errorMessage(err),
+		}, nil
+	}
+
+	var changes []string
+	if action != "" {
+		if err := client.SetGeofenceAction(action); err != nil {
+			return geofenceFailure("Set geofence action", err), nil
+		}
+		changes = append(changes, fmt.Sprintf("breach action %s", action))
+	}
+
+	if req.Enabled != nil {
+		if err := client.SetGeofenceEnabled(*req.Enabled); err != nil {
+			return geofenceFailure("Set geofence enabled", err), nil
+		}
+		if *req.Enabled {
+			changes = append(changes, "enabled")
+		} else {
+			changes = append(changes, "disabled")
+		}
+	}
+
+	return &CommandResponse{
+		Success: true,
+		Message: "Geofence " + strings.Join(changes, ", "),
+		Result:  "ACCEPTED",
+	}, nil
+}
+
+// geofenceFailure reports a failed geofence change with the COMMAND_ACK result if any
+func geofenceFailure(what string, err error) *CommandResponse {
+	resp := &CommandResponse{
+		Success: false,
+		Message: fmt.Sprintf("%s failed: %v", what, err),
+	}
+	var rejected *mavlink.CommandRejectedError
+	if errors.As(err, &rejected) {
+		resp.Result = rejected.ResultName()
+	}
+	return resp
+}
+
+// formatOptionalBool formats an optional flag for logging
+func formatOptionalBool(b *bool) string {
+	if b == nil {
+		return "unchanged"
+	}
+	return fmt.Sprint(*b)
+}
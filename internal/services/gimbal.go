@@ -0,0 +1,115 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/flightpath-dev/flightpath-server/internal/mavlink"
+)
+
+// GetGimbal returns a drone's gimbal: protocol, capabilities, supported modes
+// and current mode
+// An empty droneID means the active drone. Protocol stays empty until a gimbal
+// manager answers discovery or a gimbal component's heartbeat is seen.
+func (s *ControlServer) GetGimbal(ctx context.Context, droneID string) (*mavlink.GimbalInfo, error) {
+	s.deps.GetLogger().Printf("GetGimbal request: drone_id=%s", droneID)
+
+	client, err := requireReadyClient(ctx, s.deps, droneID, policyClient)
+	if err != nil {
+		return nil, err
+	}
+
+	gimbal := client.GetGimbal()
+	return &gimbal, nil
+}
+
+// SetGimbalModeRequest switches the gimbal mode
+type SetGimbalModeRequest struct {
+	Mode string `json:"mode"` // retract, neutral, follow or lock
+}
+
+// SetGimbalMode switches the active drone's gimbal mode
+// Uses the gimbal manager protocol when discovered, else DO_MOUNT_CONTROL.
+func (s *ControlServer) SetGimbalMode(ctx context.Context, req *SetGimbalModeRequest) (resp *CommandResponse, err error) {
+	defer func() { recordAudit(ctx, s.deps, "set_gimbal_mode", "", req, resp, err) }()
+
+	logger := s.deps.GetLogger()
+	logger.Printf("SetGimbalMode request: mode=%s", req.Mode)
+
+	if err := requireCommandsEnabled(s.deps); err != nil {
+		return nil, err
+	}
+
+	client, err := requireReadyClient(ctx, s.deps, "", policyConnected)
+	if err != nil {
+		return &CommandResponse{
+			Success: false,
+			Message: errorMessage(err),
+		}, nil
+	}
+
+	if err := client.SetGimbalMode(req.Mode); err != nil {
+		return gimbalCommandFailed("Set gimbal mode", err), nil
+	}
+
+	logger.Printf("Gimbal mode %s accepted", req.Mode)
+
+	return &CommandResponse{
+		Success: true,
+		Message: fmt.Sprintf("Gimbal mode %s accepted", req.Mode),
+		Result:  "ACCEPTED",
+	}, nil
+}
+
+// SetGimbalFlagsRequest sets the gimbal manager flags
+type SetGimbalFlagsRequest struct {
+	// e.g. pitch_lock, roll_lock, yaw_lock, yaw_in_earth_frame; replaces the current flags
+	Flags []string `json:"flags"`
+}
+
+// SetGimbalFlags applies gimbal manager control flags on the active drone
+// Needs a discovered gimbal manager.
+func (s *ControlServer) SetGimbalFlags(ctx context.Context, req *SetGimbalFlagsRequest) (resp *CommandResponse, err error) {
+	defer func() { recordAudit(ctx, s.deps, "set_gimbal_flags", "", req, resp, err) }()
+
+	logger := s.deps.GetLogger()
+	logger.Printf("SetGimbalFlags request: flags=%v", req.Flags)
+
+	if err := requireCommandsEnabled(s.deps); err != nil {
+		return nil, err
+	}
+
+	client, err := requireReadyClient(ctx, s.deps, "", policyConnected)
+	if err != nil {
+		return &CommandResponse{
+			Success: false,
+			Message: errorMessage(err),
+		}, nil
+	}
+
+	if err := client.SetGimbalFlags(req.Flags); err != nil {
+		return gimbalCommandFailed("Set gimbal flags", err), nil
+	}
+
+	logger.Printf("Gimbal flags accepted")
+
+	return &CommandResponse{
+		Success: true,
+		Message: "Gimbal flags accepted",
+		Result:  "ACCEPTED",
+	}, nil
+}
+
+// gimbalCommandFailed builds the response for a failed gimbal command
+func gimbalCommandFailed(action string, err error) *CommandResponse {
+	resp := &CommandResponse{
+		Success: false,
+		Message: fmt.Sprintf("%s failed: %v", action, err),
+	}
+	var rejected *mavlink.CommandRejectedError
+	if errors.As(err, &rejected) {
+		resp.Result = rejected.ResultName()
+	}
+	return resp
+}
@@ -0,0 +1,66 @@
+package services
+
+import (
+	"context"
+	"slices"
+	"time"
+)
+
+// maxIdleCheckInterval caps how often ReclaimIdleClients looks for idle drones
+const maxIdleCheckInterval = 30 * time.Second
+
+// ReclaimIdleClients disconnects drones no RPC or stream has used for timeout
+// in the background, until ctx is cancelled
+// A client left behind by a frontend that crashed without disconnecting would
+// otherwise hold its serial port and goroutines until restart. Armed drones
+// (e.g. flying a mission) are kept, and so are auto_connect drones, which
+// nothing would reconnect.
+func (s *ConnectionServer) ReclaimIdleClients(ctx context.Context, timeout time.Duration) {
+	go func() {
+		ticker := time.NewTicker(min(timeout/2, maxIdleCheckInterval))
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				for _, droneID := range s.deps.GetIdleMAVLinkDroneIDs(timeout) {
+					s.reclaimIdleClient(droneID, timeout)
+				}
+			}
+		}
+	}()
+}
+
+// reclaimIdleClient disconnects one idle drone unless it is flying or auto-connected
+func (s *ConnectionServer) reclaimIdleClient(droneID string, timeout time.Duration) {
+	logger := s.deps.GetLogger()
+
+	if droneConfig, err := s.deps.GetDroneRegistry().FindDrone(droneID); err == nil &&
+		droneConfig.GetConnectionBool("auto_connect") {
+		return
+	}
+
+	// Connect and Disconnect hold the lock; recheck once it's ours
+	unlock := s.deps.LockDrone(droneID)
+	defer unlock()
+
+	client, ok := s.deps.GetMAVLinkClientFor(droneID)
+	if !ok || !slices.Contains(s.deps.GetIdleMAVLinkDroneIDs(timeout), droneID) {
+		return
+	}
+
+	if client.IsConnected() && client.IsArmed() {
+		// Checked again after another timeout
+		logger.Printf("Idle timeout: Warning - %s unused for %s but armed, keeping it connected", droneID, timeout)
+		s.deps.MarkMAVLinkClientUsed(client)
+		return
+	}
+
+	logger.Printf("Idle timeout: disconnecting %s (no requests or streams for %s)", droneID, timeout)
+	s.deps.RemoveMAVLinkClient(droneID)
+	if err := client.Close(); err != nil {
+		logger.Printf("Idle timeout: ERROR - closing %s: %v", droneID, err)
+	}
+}
@@ -0,0 +1,62 @@
+package services
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/flightpath-dev/flightpath-server/internal/mavlink"
+)
+
+func TestReclaimIdleClients(t *testing.T) {
+	deps := newTestDependencies(t)
+
+	// alpha is armed, bravo disarmed, charlie held open by a stream
+	alpha := connectArmedDrone(t, deps, mavlink.PX4_MAIN_MODE_AUTO|mavlink.PX4_AUTO_MODE_MISSION<<16)
+	_, device, heartbeats := silentDrone(t)
+	registerDrone(deps, "bravo", device)
+	heartbeats()
+	if resp := connectDrone(t, deps, "bravo", 2*time.Second); !resp.Success {
+		t.Fatalf("Connect bravo: %s", resp.Message)
+	}
+	charlie := staleClient(t, filepath.Join(t.TempDir(), "ttyCharlie"))
+	deps.AddMAVLinkClient("charlie", charlie)
+	release := deps.HoldMAVLinkClient(charlie)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	const timeout = 200 * time.Millisecond
+	NewConnectionServer(deps).ReclaimIdleClients(ctx, timeout)
+
+	waitGone := func(droneID string) {
+		t.Helper()
+		deadline := time.Now().Add(3 * time.Second)
+		for {
+			if _, ok := deps.GetMAVLinkClientFor(droneID); !ok {
+				return
+			}
+			if time.Now().After(deadline) {
+				t.Fatalf("idle %s not disconnected", droneID)
+			}
+			time.Sleep(20 * time.Millisecond)
+		}
+	}
+	waitGone("bravo")
+
+	// Several timeouts on, the armed and streaming drones are still connected
+	time.Sleep(3 * timeout)
+	if client, ok := deps.GetMAVLinkClientFor("alpha"); !ok || client != alpha {
+		t.Error("armed drone disconnected as idle")
+	}
+	if _, ok := deps.GetMAVLinkClientFor("charlie"); !ok {
+		t.Error("drone disconnected while a stream held it")
+	}
+
+	// Once the stream ends, charlie goes idle too
+	release()
+	waitGone("charlie")
+	if _, ok := deps.GetMAVLinkClientFor("alpha"); !ok {
+		t.Error("armed drone disconnected as idle")
+	}
+}
@@ -0,0 +1,54 @@
+package services
+
+import (
+	"context"
+
+	"github.com/bluenviron/gomavlib/v3/pkg/dialects/common"
+
+	"github.com/flightpath-dev/flightpath-server/internal/mavlink"
+)
+
+// InventoryEntry is one registry drone in the fleet inventory
+// Drones without a MAVLink client have only the registry fields; the vehicle
+// fields stay zero until AUTOPILOT_VERSION arrives (Version.Reported).
+type InventoryEntry struct {
+	DroneID     string `json:"drone_id"`
+	Name        string `json:"name"`
+	Description string `json:"description,omitempty"`
+	Connected   bool   `json:"connected"`
+
+	SystemID    uint8                     `json:"system_id,omitempty"`
+	Autopilot   common.MAV_AUTOPILOT      `json:"autopilot,omitempty"`
+	VehicleType common.MAV_TYPE           `json:"vehicle_type,omitempty"`
+	Version     *mavlink.AutopilotVersion `json:"autopilot_version,omitempty"`
+}
+
+// GetFleetInventory lists every registry drone with the firmware and board
+// its vehicle reports, for auditing what each vehicle runs
+// Only drones with a MAVLink client (connected now, or with a link that
+// dropped) carry vehicle details.
+func (s *ConnectionServer) GetFleetInventory(ctx context.Context) []InventoryEntry {
+	s.deps.GetLogger().Println("GetFleetInventory request")
+
+	registry := s.deps.GetDroneRegistry()
+	inventory := make([]InventoryEntry, 0, len(registry.Drones))
+
+	for _, droneConfig := range registry.Drones {
+		entry := InventoryEntry{
+			DroneID:     droneConfig.ID,
+			Name:        droneConfig.Name,
+			Description: droneConfig.Description,
+		}
+
+		if client, ok := s.deps.GetMAVLinkClientFor(droneConfig.ID); ok {
+			info := client.GetConnectionInfo()
+			entry.Connected = info.Connected
+			entry.SystemID = info.SystemID
+			entry.Autopilot = info.Autopilot
+			entry.VehicleType = info.VehicleType
+			entry.Version = &info.Version
+		}
+		inventory = append(inventory, entry)
+	}
+	return inventory
+}
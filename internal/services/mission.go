@@ -2,7 +2,10 @@ package services
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"slices"
+	"strings"
 	"time"
 
 	"connectrpc.com/connect"
@@ -24,30 +27,52 @@ func NewMissionServer(deps *server.Dependencies) *MissionServer {
 	}
 }
 
+// VerifyUploadHeader opts into reading back the mission count after an upload
+// ("count"); UploadMissionRequest has no field for it yet
+const VerifyUploadHeader = "Flightpath-Verify-Upload"
+
 // UploadMission uploads a mission to the drone
+// Altitudes are relative to home; see UploadMissionOptions for other frames.
 func (s *MissionServer) UploadMission(
 	ctx context.Context,
 	req *connect.Request[drone.UploadMissionRequest],
 ) (*connect.Response[drone.UploadMissionResponse], error) {
+	return s.UploadMissionOptions(ctx, req, nil, nil)
+}
+
+// UploadMissionOptions uploads a mission with per-waypoint options (altitude
+// frame, loiter radius and turns)
+// options is nil (defaults, relative to home) or has one entry per waypoint. With the
+// Flightpath-Verify-Upload: count header, an accepted upload is only reported
+// as successful if the vehicle then reports the same item count.
+//
+// plannedHome (altitude MSL, as in a QGroundControl .plan) is optional. It is
+// set with DO_SET_HOME before the upload rather than sent as item 0: PX4 would
+// fly an item 0 as a waypoint, and ArduPilot replaces it with its own home.
+func (s *MissionServer) UploadMissionOptions(
+	ctx context.Context,
+	req *connect.Request[drone.UploadMissionRequest],
+	options []mavlink.WaypointOptions,
+	plannedHome *drone.Position,
+) (resp *connect.Response[drone.UploadMissionResponse], err error) {
+	defer func() {
+		params := map[string]any{"mission": req.Msg.Mission, "options": options, "planned_home": plannedHome}
+		auditResponse(ctx, s.deps, "upload_mission", "", params, resp, err)
+	}()
+
 	logger := s.deps.GetLogger()
 	logger.Printf("UploadMission request: mission_id=%s, waypoints=%d",
 		req.Msg.Mission.Id, len(req.Msg.Mission.Waypoints))
 
-	// Check if MAVLink client exists
-	if !s.deps.HasMAVLinkClient() {
-		return connect.NewResponse(&drone.UploadMissionResponse{
-			Success: false,
-			Message: "Not connected to drone",
-		}), nil
+	if err := requireCommandsEnabled(s.deps); err != nil {
+		return nil, err
 	}
 
-	client := s.deps.GetMAVLinkClient()
-
-	// Check if connected
-	if !client.IsConnected() {
+	client, err := requireReadyClient(ctx, s.deps, "", commandPolicy(s.deps.Config, "upload_mission"))
+	if err != nil {
 		return connect.NewResponse(&drone.UploadMissionResponse{
 			Success: false,
-			Message: "Drone is not connected",
+			Message: errorMessage(err),
 		}), nil
 	}
 
@@ -59,8 +84,29 @@ func (s *MissionServer) UploadMission(
 		}), nil
 	}
 
+	if err := validateWaypointOptions(req.Msg.Mission.Waypoints, options); err != nil {
+		return nil, connect.NewError(connect.CodeInvalidArgument, err)
+	}
+
+	// Camera actions upload as items of their own
+	itemCount := mavlink.MissionItemCount(len(req.Msg.Mission.Waypoints), options)
+	if err := checkMissionSize(client, itemCount); err != nil {
+		return nil, err
+	}
+
+	if plannedHome != nil {
+		home, err := client.SetHome(plannedHome.Latitude, plannedHome.Longitude, plannedHome.Altitude)
+		if err != nil {
+			return connect.NewResponse(&drone.UploadMissionResponse{
+				Success: false,
+				Message: fmt.Sprintf("Setting the planned home failed, mission not uploaded: %v", err),
+			}), nil
+		}
+		logger.Printf("Planned home set: lat=%.6f, lon=%.6f, alt=%.2f", home.Latitude, home.Longitude, home.Altitude)
+	}
+
 	// Upload mission via MAVLink
-	err := client.UploadMission(req.Msg.Mission.Waypoints)
+	err = client.UploadMissionOptions(req.Msg.Mission.Waypoints, options)
 	if err != nil {
 		return connect.NewResponse(&drone.UploadMissionResponse{
 			Success: false,
@@ -70,6 +116,18 @@ func (s *MissionServer) UploadMission(
 
 	logger.Printf("Mission uploaded successfully: %d waypoints", len(req.Msg.Mission.Waypoints))
 
+	if strings.EqualFold(req.Header().Get(VerifyUploadHeader), "count") {
+		if err := verifyMissionCount(client, itemCount); err != nil {
+			logger.Printf("UploadMission: Warning - %v", err)
+			return connect.NewResponse(&drone.UploadMissionResponse{
+				Success:           false,
+				Message:           fmt.Sprintf("Mission upload accepted but not verified: %v", err),
+				WaypointsUploaded: int32(len(req.Msg.Mission.Waypoints)),
+			}), nil
+		}
+		logger.Printf("Mission count verified: %d items", itemCount)
+	}
+
 	return connect.NewResponse(&drone.UploadMissionResponse{
 		Success:           true,
 		Message:           "Mission uploaded successfully",
@@ -77,6 +135,27 @@ func (s *MissionServer) UploadMission(
 	}), nil
 }
 
+// checkMissionSize rejects missions over the drone's item limit before any transfer
+func checkMissionSize(client *mavlink.Client, items int) error {
+	if limit := client.MaxMissionItems(); limit > 0 && items > limit {
+		return connect.NewError(connect.CodeInvalidArgument,
+			fmt.Errorf("mission has %d items, this drone accepts at most %d", items, limit))
+	}
+	return nil
+}
+
+// verifyMissionCount reads the mission item count back from the vehicle
+func verifyMissionCount(client *mavlink.Client, want int) error {
+	got, err := client.ReadMissionCount()
+	if err != nil {
+		return fmt.Errorf("could not read back mission count: %w", err)
+	}
+	if got != want {
+		return fmt.Errorf("vehicle reports %d mission items, uploaded %d", got, want)
+	}
+	return nil
+}
+
 // DownloadMission downloads current mission from drone
 func (s *MissionServer) DownloadMission(
 	ctx context.Context,
@@ -85,20 +164,25 @@ func (s *MissionServer) DownloadMission(
 	logger := s.deps.GetLogger()
 	logger.Println("DownloadMission request")
 
-	// Check if MAVLink client exists
-	if !s.deps.HasMAVLinkClient() {
+	client, err := requireReadyClient(ctx, s.deps, "", policyConnected)
+	if err != nil {
 		return connect.NewResponse(&drone.DownloadMissionResponse{
 			Success: false,
-			Message: "Not connected to drone",
+			Message: errorMessage(err),
 		}), nil
 	}
 
-	// TODO: Implement mission download via MAVLink
-	// This requires MISSION_REQUEST_LIST and handling MISSION_COUNT/MISSION_ITEM responses
+	items, err := client.DownloadMission()
+	if err != nil {
+		return connect.NewResponse(&drone.DownloadMissionResponse{
+			Success: false,
+			Message: fmt.Sprintf("Failed to download mission: %v", err),
+		}), nil
+	}
 
 	return connect.NewResponse(&drone.DownloadMissionResponse{
-		Success: false,
-		Message: "Mission download not yet implemented",
+		Success: true,
+		Message: fmt.Sprintf("Downloaded %d mission items", len(items)),
 	}), nil
 }
 
@@ -106,25 +190,21 @@ func (s *MissionServer) DownloadMission(
 func (s *MissionServer) StartMission(
 	ctx context.Context,
 	req *connect.Request[drone.StartMissionRequest],
-) (*connect.Response[drone.StartMissionResponse], error) {
+) (resp *connect.Response[drone.StartMissionResponse], err error) {
+	defer func() { auditResponse(ctx, s.deps, "start_mission", "", req.Msg, resp, err) }()
+
 	logger := s.deps.GetLogger()
 	logger.Println("StartMission request")
 
-	// Check if MAVLink client exists
-	if !s.deps.HasMAVLinkClient() {
-		return connect.NewResponse(&drone.StartMissionResponse{
-			Success: false,
-			Message: "Not connected to drone",
-		}), nil
+	if err := requireCommandsEnabled(s.deps); err != nil {
+		return nil, err
 	}
 
-	client := s.deps.GetMAVLinkClient()
-
-	// Check if connected
-	if !client.IsConnected() {
+	client, err := requireReadyClient(ctx, s.deps, "", commandPolicy(s.deps.Config, "start_mission"))
+	if err != nil {
 		return connect.NewResponse(&drone.StartMissionResponse{
 			Success: false,
-			Message: "Drone is not connected",
+			Message: errorMessage(err),
 		}), nil
 	}
 
@@ -157,42 +237,38 @@ func (s *MissionServer) StartMission(
 func (s *MissionServer) PauseMission(
 	ctx context.Context,
 	req *connect.Request[drone.PauseMissionRequest],
-) (*connect.Response[drone.PauseMissionResponse], error) {
+) (resp *connect.Response[drone.PauseMissionResponse], err error) {
+	defer func() { auditResponse(ctx, s.deps, "pause_mission", "", req.Msg, resp, err) }()
+
 	logger := s.deps.GetLogger()
 	logger.Println("PauseMission request")
 
-	// Check if MAVLink client exists
-	if !s.deps.HasMAVLinkClient() {
-		return connect.NewResponse(&drone.PauseMissionResponse{
-			Success: false,
-			Message: "Not connected to drone",
-		}), nil
+	if err := requireCommandsEnabled(s.deps); err != nil {
+		return nil, err
 	}
 
-	client := s.deps.GetMAVLinkClient()
-
-	// Check if connected
-	if !client.IsConnected() {
+	client, err := requireReadyClient(ctx, s.deps, "", policyConnected)
+	if err != nil {
 		return connect.NewResponse(&drone.PauseMissionResponse{
 			Success: false,
-			Message: "Drone is not connected",
+			Message: errorMessage(err),
 		}), nil
 	}
 
-	// Switch to LOITER mode to pause (holds current position)
-	autoLoiterMode := uint32(mavlink.PX4_MAIN_MODE_AUTO | (mavlink.PX4_AUTO_MODE_LOITER << 16))
-	if err := client.SetMode(autoLoiterMode); err != nil {
+	// DO_PAUSE_CONTINUE, or AUTO.LOITER if the vehicle doesn't support it
+	method, err := client.PauseMission()
+	if err != nil {
 		return connect.NewResponse(&drone.PauseMissionResponse{
 			Success: false,
 			Message: fmt.Sprintf("Failed to pause mission: %v", err),
 		}), nil
 	}
 
-	logger.Println("Mission paused successfully")
+	logger.Printf("Mission paused successfully (%s)", method)
 
 	return connect.NewResponse(&drone.PauseMissionResponse{
 		Success: true,
-		Message: "Mission paused successfully",
+		Message: fmt.Sprintf("Mission paused successfully (%s)", method),
 	}), nil
 }
 
@@ -200,38 +276,34 @@ func (s *MissionServer) PauseMission(
 func (s *MissionServer) ResumeMission(
 	ctx context.Context,
 	req *connect.Request[drone.ResumeMissionRequest],
-) (*connect.Response[drone.ResumeMissionResponse], error) {
+) (resp *connect.Response[drone.ResumeMissionResponse], err error) {
+	defer func() { auditResponse(ctx, s.deps, "resume_mission", "", req.Msg, resp, err) }()
+
 	logger := s.deps.GetLogger()
 	logger.Println("ResumeMission request")
 
-	// Check if MAVLink client exists
-	if !s.deps.HasMAVLinkClient() {
-		return connect.NewResponse(&drone.ResumeMissionResponse{
-			Success: false,
-			Message: "Not connected to drone",
-		}), nil
+	if err := requireCommandsEnabled(s.deps); err != nil {
+		return nil, err
 	}
 
-	client := s.deps.GetMAVLinkClient()
-
-	// Check if connected
-	if !client.IsConnected() {
+	client, err := requireReadyClient(ctx, s.deps, "", commandPolicy(s.deps.Config, "resume_mission"))
+	if err != nil {
 		return connect.NewResponse(&drone.ResumeMissionResponse{
 			Success: false,
-			Message: "Drone is not connected",
+			Message: errorMessage(err),
 		}), nil
 	}
 
-	// Switch back to AUTO MISSION mode
-	autoMissionMode := uint32(mavlink.PX4_MAIN_MODE_AUTO | (mavlink.PX4_AUTO_MODE_MISSION << 16))
-	if err := client.SetMode(autoMissionMode); err != nil {
+	// Continue the way the mission was paused
+	method, err := client.ResumeMission()
+	if err != nil {
 		return connect.NewResponse(&drone.ResumeMissionResponse{
 			Success: false,
 			Message: fmt.Sprintf("Failed to resume mission: %v", err),
 		}), nil
 	}
 
-	logger.Println("Mission resumed successfully")
+	logger.Printf("Mission resumed successfully (%s)", method)
 
 	return connect.NewResponse(&drone.ResumeMissionResponse{
 		Success: true,
@@ -239,29 +311,104 @@ func (s *MissionServer) ResumeMission(
 	}), nil
 }
 
+// AbortMission stops the active drone's mission and holds position
+// The vehicle switches to AUTO.LOITER; unlike ClearMission the mission stays
+// loaded, so ResumeMission or StartMission can fly it again.
+func (s *MissionServer) AbortMission(ctx context.Context) (resp *CommandResponse, err error) {
+	defer func() { recordAudit(ctx, s.deps, "abort_mission", "", nil, resp, err) }()
+
+	logger := s.deps.GetLogger()
+	logger.Println("AbortMission request")
+
+	if err := requireCommandsEnabled(s.deps); err != nil {
+		return nil, err
+	}
+
+	client, err := requireReadyClient(ctx, s.deps, "", policyConnected)
+	if err != nil {
+		return &CommandResponse{
+			Success: false,
+			Message: errorMessage(err),
+		}, nil
+	}
+
+	if err := client.AbortMission(); err != nil {
+		return &CommandResponse{
+			Success: false,
+			Message: fmt.Sprintf("Failed to abort mission: %v", err),
+		}, nil
+	}
+
+	logger.Println("Mission aborted, holding position")
+
+	return &CommandResponse{
+		Success: true,
+		Message: "Mission aborted, holding position; the mission is still loaded",
+	}, nil
+}
+
+// CancelUpload aborts the active drone's mission transfer in progress (of any
+// mission type, e.g. a geofence upload); the waiting upload call fails with
+// "upload cancelled"
+// Doesn't take the drone's lock, which an upload may be holding. Fails with
+// FailedPrecondition when nothing is being uploaded.
+func (s *MissionServer) CancelUpload(ctx context.Context) (resp *CommandResponse, err error) {
+	defer func() { recordAudit(ctx, s.deps, "cancel_upload", "", nil, resp, err) }()
+
+	logger := s.deps.GetLogger()
+	logger.Println("CancelUpload request")
+
+	if err := requireCommandsEnabled(s.deps); err != nil {
+		return nil, err
+	}
+
+	client, err := requireReadyClient(ctx, s.deps, "", policyClient)
+	if err != nil {
+		return &CommandResponse{
+			Success: false,
+			Message: errorMessage(err),
+		}, nil
+	}
+
+	cancelled, err := client.CancelMissionUpload()
+	if !cancelled {
+		return nil, connect.NewError(connect.CodeFailedPrecondition, errors.New("no upload in progress"))
+	}
+	if err != nil {
+		logger.Printf("CancelUpload: Warning - %v", err)
+		return &CommandResponse{
+			Success: true,
+			Message: fmt.Sprintf("Upload cancelled; %v", err),
+		}, nil
+	}
+
+	logger.Println("Upload cancelled")
+
+	return &CommandResponse{
+		Success: true,
+		Message: "Upload cancelled",
+	}, nil
+}
+
 // ClearMission clears mission from drone
 func (s *MissionServer) ClearMission(
 	ctx context.Context,
 	req *connect.Request[drone.ClearMissionRequest],
-) (*connect.Response[drone.ClearMissionResponse], error) {
+) (resp *connect.Response[drone.ClearMissionResponse], err error) {
+	defer func() { auditResponse(ctx, s.deps, "clear_mission", "", req.Msg, resp, err) }()
+
 	logger := s.deps.GetLogger()
 	logger.Println("ClearMission request")
 
-	// Check if MAVLink client exists
-	if !s.deps.HasMAVLinkClient() {
-		return connect.NewResponse(&drone.ClearMissionResponse{
-			Success: false,
-			Message: "Not connected to drone",
-		}), nil
+	if err := requireCommandsEnabled(s.deps); err != nil {
+		return nil, err
 	}
 
-	client := s.deps.GetMAVLinkClient()
-
-	// Check if connected
-	if !client.IsConnected() {
+	client, err := requireReadyClient(ctx, s.deps, "", policyConnected)
+	if err != nil {
 		return connect.NewResponse(&drone.ClearMissionResponse{
 			Success: false,
-			Message: "Drone is not connected",
+			Message: errorMessage(err),
 		}), nil
 	}
 
@@ -281,6 +428,228 @@ func (s *MissionServer) ClearMission(
 	}), nil
 }
 
+// UploadedMission is the mission the server last uploaded to a drone
+type UploadedMission struct {
+	DroneID   string            `json:"drone_id"`
+	Waypoints []*drone.Waypoint `json:"waypoints"`
+	// Altitude frame and loiter settings of each waypoint
+	Options []mavlink.WaypointOptions `json:"options"`
+	// Confirmed is true once a mission download matched the waypoints
+	Confirmed bool `json:"confirmed"`
+}
+
+// GetUploadedMission returns the waypoints last uploaded to a drone from server memory
+// No MAVLink traffic is generated. An empty droneID means the active drone.
+func (s *MissionServer) GetUploadedMission(ctx context.Context, droneID string) (*UploadedMission, error) {
+	s.deps.GetLogger().Printf("GetUploadedMission request: drone_id=%s", droneID)
+
+	droneID = targetDrone(ctx, s.deps, droneID)
+
+	client, ok := s.deps.GetMAVLinkClientFor(droneID)
+	if !ok {
+		return nil, connect.NewError(connect.CodeNotFound,
+			fmt.Errorf("drone %q is not connected", droneID))
+	}
+	s.deps.MarkMAVLinkClientUsed(client)
+
+	waypoints, options, confirmed := client.GetUploadedWaypoints()
+
+	return &UploadedMission{
+		DroneID:   droneID,
+		Waypoints: waypoints,
+		Options:   options,
+		Confirmed: confirmed,
+	}, nil
+}
+
+// AppendWaypoint adds a waypoint to the end of the uploaded mission and re-uploads it
+// MAVLink has no reliable partial edit, so the full re-sequenced mission is sent.
+// An empty droneID means the active drone.
+func (s *MissionServer) AppendWaypoint(
+	ctx context.Context,
+	droneID string,
+	wp *drone.Waypoint,
+	opts mavlink.WaypointOptions,
+) (resp *UploadedMission, err error) {
+	defer func() {
+		params := map[string]any{"waypoint": wp, "options": opts}
+		recordAudit(ctx, s.deps, "append_waypoint", droneID, params, resp, err)
+	}()
+
+	s.deps.GetLogger().Printf("AppendWaypoint request: drone_id=%s", droneID)
+
+	if err := requireCommandsEnabled(s.deps); err != nil {
+		return nil, err
+	}
+
+	return s.insertIntoMission(ctx, droneID, -1, wp, opts)
+}
+
+// InsertWaypoint inserts a waypoint before index in the uploaded mission and re-uploads it
+// index may equal the mission length to append. An empty droneID means the active drone.
+func (s *MissionServer) InsertWaypoint(
+	ctx context.Context,
+	droneID string,
+	index int,
+	wp *drone.Waypoint,
+	opts mavlink.WaypointOptions,
+) (resp *UploadedMission, err error) {
+	defer func() {
+		params := map[string]any{"index": index, "waypoint": wp, "options": opts}
+		recordAudit(ctx, s.deps, "insert_waypoint", droneID, params, resp, err)
+	}()
+
+	s.deps.GetLogger().Printf("InsertWaypoint request: drone_id=%s, index=%d", droneID, index)
+
+	if err := requireCommandsEnabled(s.deps); err != nil {
+		return nil, err
+	}
+
+	if index < 0 {
+		return nil, connect.NewError(connect.CodeInvalidArgument,
+			fmt.Errorf("index must not be negative: %d", index))
+	}
+	return s.insertIntoMission(ctx, droneID, index, wp, opts)
+}
+
+// insertIntoMission inserts a waypoint into the server's copy of the uploaded
+// mission (index -1 appends), re-sequences and validates the result, then
+// uploads it in full
+func (s *MissionServer) insertIntoMission(
+	ctx context.Context,
+	droneID string,
+	index int,
+	wp *drone.Waypoint,
+	opts mavlink.WaypointOptions,
+) (*UploadedMission, error) {
+	logger := s.deps.GetLogger()
+
+	droneID = targetDrone(ctx, s.deps, droneID)
+
+	client, err := requireReadyClient(ctx, s.deps, droneID, commandPolicy(s.deps.Config, "upload_mission"))
+	if err != nil {
+		return nil, err
+	}
+
+	// Serialize edits per drone so they don't overwrite each other
+	defer s.deps.LockDrone(droneID)()
+
+	waypoints, options, _ := client.GetUploadedWaypoints()
+	if _, total, _ := client.GetMissionProgress(); len(waypoints) == 0 && total > 0 {
+		// Uploaded as raw mission items; editing would silently drop them
+		return nil, connect.NewError(connect.CodeFailedPrecondition,
+			fmt.Errorf("the vehicle's mission was not uploaded as waypoints and can't be edited"))
+	}
+
+	edited, editedOptions, err := insertWaypoint(waypoints, options, index, wp, opts)
+	if err != nil {
+		return nil, err
+	}
+	if err := validateWaypoints(edited); err != nil {
+		return nil, connect.NewError(connect.CodeInvalidArgument, err)
+	}
+	if err := validateWaypointOptions(edited, editedOptions); err != nil {
+		return nil, connect.NewError(connect.CodeInvalidArgument, err)
+	}
+	if err := checkMissionSize(client, mavlink.MissionItemCount(len(edited), editedOptions)); err != nil {
+		return nil, err
+	}
+
+	if err := client.UploadMissionOptions(edited, editedOptions); err != nil {
+		return nil, connect.NewError(connect.CodeUnavailable,
+			fmt.Errorf("mission upload failed: %w", err))
+	}
+
+	logger.Printf("Mission edited and re-uploaded: %d waypoints", len(edited))
+
+	return &UploadedMission{
+		DroneID:   droneID,
+		Waypoints: edited,
+		Options:   editedOptions,
+	}, nil
+}
+
+// insertWaypoint returns the mission with wp inserted before index (-1 appends)
+// options has one entry per waypoint; neither input slice is modified.
+func insertWaypoint(
+	waypoints []*drone.Waypoint,
+	options []mavlink.WaypointOptions,
+	index int,
+	wp *drone.Waypoint,
+	opts mavlink.WaypointOptions,
+) ([]*drone.Waypoint, []mavlink.WaypointOptions, error) {
+	if index == -1 {
+		index = len(waypoints)
+	}
+	if index < 0 || index > len(waypoints) {
+		return nil, nil, connect.NewError(connect.CodeInvalidArgument,
+			fmt.Errorf("index must be between 0 and %d: %d", len(waypoints), index))
+	}
+
+	edited := resequenceWaypoints(slices.Insert(slices.Clip(waypoints), index, wp))
+	editedOptions := slices.Insert(slices.Clip(options), index, opts)
+	return edited, editedOptions, nil
+}
+
+// resequenceWaypoints returns copies of the waypoints numbered 0..n-1
+// Copies keep the previously uploaded mission untouched if the upload fails.
+func resequenceWaypoints(waypoints []*drone.Waypoint) []*drone.Waypoint {
+	result := make([]*drone.Waypoint, len(waypoints))
+	for i, wp := range waypoints {
+		result[i] = &drone.Waypoint{
+			Sequence:         int32(i),
+			Position:         wp.Position,
+			Action:           wp.Action,
+			HoldTimeSec:      wp.HoldTimeSec,
+			AcceptanceRadius: wp.AcceptanceRadius,
+			Heading:          wp.Heading,
+		}
+	}
+	return result
+}
+
+// validateWaypoints checks waypoints are complete and within range
+func validateWaypoints(waypoints []*drone.Waypoint) error {
+	if len(waypoints) == 0 {
+		return fmt.Errorf("mission must have at least one waypoint")
+	}
+
+	for i, wp := range waypoints {
+		switch {
+		case wp.Position == nil:
+			return fmt.Errorf("waypoint %d: missing position", i)
+		case wp.Position.Latitude < -90 || wp.Position.Latitude > 90:
+			return fmt.Errorf("waypoint %d: latitude out of range: %f", i, wp.Position.Latitude)
+		case wp.Position.Longitude < -180 || wp.Position.Longitude > 180:
+			return fmt.Errorf("waypoint %d: longitude out of range: %f", i, wp.Position.Longitude)
+		case wp.Action == drone.Waypoint_ACTION_UNSPECIFIED:
+			return fmt.Errorf("waypoint %d: action is required", i)
+		case wp.HoldTimeSec < 0:
+			return fmt.Errorf("waypoint %d: hold_time_sec must not be negative", i)
+		case wp.AcceptanceRadius < 0:
+			return fmt.Errorf("waypoint %d: acceptance_radius must not be negative", i)
+		}
+	}
+	return nil
+}
+
+// validateWaypointOptions checks per-waypoint options against their waypoints
+// nil options (the defaults) are always valid.
+func validateWaypointOptions(waypoints []*drone.Waypoint, options []mavlink.WaypointOptions) error {
+	if options == nil {
+		return nil
+	}
+	if len(options) != len(waypoints) {
+		return fmt.Errorf("got %d waypoint options for %d waypoints", len(options), len(waypoints))
+	}
+	for i, opts := range options {
+		if err := opts.Validate(waypoints[i].Action); err != nil {
+			return fmt.Errorf("waypoint %d: %w", i, err)
+		}
+	}
+	return nil
+}
+
 // GetProgress gets current mission progress
 func (s *MissionServer) GetProgress(
 	ctx context.Context,
@@ -290,19 +659,23 @@ func (s *MissionServer) GetProgress(
 	logger.Println("GetProgress request")
 
 	// Check if MAVLink client exists
-	if !s.deps.HasMAVLinkClient() {
+	client, ok := s.deps.GetMAVLinkClientFor(targetDrone(ctx, s.deps, ""))
+	if !ok {
 		return connect.NewResponse(&drone.GetProgressResponse{
 			Status: drone.GetProgressResponse_STATUS_IDLE,
 		}), nil
 	}
-
-	client := s.deps.GetMAVLinkClient()
+	s.deps.MarkMAVLinkClientUsed(client)
 
 	// Get mission progress from MAVLink client
 	currentWaypoint, totalWaypoints, active := client.GetMissionProgress()
 
+	// MISSION_CURRENT stays on the last item, so completion comes from MISSION_ITEM_REACHED.
+	// It is latched until the next upload, start or clear, so it outlasts landing.
 	var status drone.GetProgressResponse_Status
-	if !active {
+	if client.GetMissionTimeline().Complete {
+		status = drone.GetProgressResponse_STATUS_COMPLETED
+	} else if !active {
 		status = drone.GetProgressResponse_STATUS_IDLE
 	} else if currentWaypoint >= 0 && currentWaypoint < totalWaypoints {
 		status = drone.GetProgressResponse_STATUS_IN_PROGRESS
@@ -319,6 +692,41 @@ func (s *MissionServer) GetProgress(
 	}), nil
 }
 
+// GetTimeline returns when each waypoint of a drone's mission was reached,
+// the average leg duration and an ETA for the rest
+// An empty droneID means the active drone.
+func (s *MissionServer) GetTimeline(ctx context.Context, droneID string) (*mavlink.MissionProgress, error) {
+	s.deps.GetLogger().Printf("GetTimeline request: drone_id=%s", droneID)
+
+	client, err := requireReadyClient(ctx, s.deps, droneID, policyClient)
+	if err != nil {
+		return nil, err
+	}
+
+	timeline := client.GetMissionTimeline()
+	return &timeline, nil
+}
+
+// GetMissionStats counts the items of a drone's uploaded mission by kind and
+// estimates its distance, duration and altitude span
+// Computed from the mission in server memory (downloading isn't implemented);
+// FailedPrecondition when none was uploaded. An empty droneID means the active drone.
+func (s *MissionServer) GetMissionStats(ctx context.Context, droneID string) (*mavlink.MissionStats, error) {
+	s.deps.GetLogger().Printf("GetMissionStats request: drone_id=%s", droneID)
+
+	client, err := requireReadyClient(ctx, s.deps, droneID, policyClient)
+	if err != nil {
+		return nil, err
+	}
+
+	stats, ok := client.GetMissionStats(s.deps.Config.MAVLink.MissionCruiseSpeed)
+	if !ok {
+		return nil, connect.NewError(connect.CodeFailedPrecondition,
+			fmt.Errorf("no mission uploaded"))
+	}
+	return &stats, nil
+}
+
 // StreamProgress streams mission progress updates
 func (s *MissionServer) StreamProgress(
 	ctx context.Context,
@@ -328,18 +736,16 @@ func (s *MissionServer) StreamProgress(
 	logger := s.deps.GetLogger()
 	logger.Printf("StreamProgress request: interval_ms=%d", req.Msg.IntervalMs)
 
-	// Check if MAVLink client exists
-	if !s.deps.HasMAVLinkClient() {
-		return connect.NewError(connect.CodeFailedPrecondition,
-			fmt.Errorf("not connected to drone"))
+	client, err := requireReadyClient(ctx, s.deps, "", policyClient)
+	if err != nil {
+		return err
 	}
-
-	client := s.deps.GetMAVLinkClient()
+	defer s.deps.HoldMAVLinkClient(client)()
 
 	// Calculate interval
-	interval := time.Second
-	if req.Msg.IntervalMs > 0 {
-		interval = time.Duration(req.Msg.IntervalMs) * time.Millisecond
+	interval, err := streamIntervalFromMs(req.Msg.IntervalMs, time.Second)
+	if err != nil {
+		return err
 	}
 
 	ticker := time.NewTicker(interval)
@@ -356,7 +762,9 @@ func (s *MissionServer) StreamProgress(
 			currentWaypoint, totalWaypoints, active := client.GetMissionProgress()
 
 			var status drone.StreamProgressResponse_Status
-			if !active {
+			if client.GetMissionTimeline().Complete {
+				status = drone.StreamProgressResponse_STATUS_COMPLETED
+			} else if !active {
 				status = drone.StreamProgressResponse_STATUS_IDLE
 			} else if currentWaypoint >= 0 && currentWaypoint < totalWaypoints {
 				status = drone.StreamProgressResponse_STATUS_IN_PROGRESS
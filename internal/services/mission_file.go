@@ -0,0 +1,121 @@
+package services
+
+import (
+	"context"
+	"fmt"
+
+	"connectrpc.com/connect"
+
+	drone "github.com/flightpath-dev/flightpath-proto/gen/go/drone/v1"
+	"github.com/flightpath-dev/flightpath-server/internal/missionfile"
+)
+
+// ImportMissionRequest uploads a mission from a planner file
+type ImportMissionRequest struct {
+	ID      string `json:"id,omitempty"`
+	Format  string `json:"format,omitempty"` // plan, waypoints ("" = detect)
+	Content string `json:"content"`
+
+	// Read the item count back from the vehicle after the upload
+	VerifyCount bool `json:"verify_count,omitempty"`
+
+	// Set the file's planned home with DO_SET_HOME before uploading (see
+	// UploadMissionOptions); ignored when the file has none
+	SetHome bool `json:"set_home,omitempty"`
+}
+
+// ImportMission converts a QGroundControl .plan or .waypoints file and uploads
+// it to the active drone like UploadMission
+// Files with items a waypoint can't express (surveys, DO_ commands, RTL) are
+// rejected rather than uploaded without them.
+func (s *MissionServer) ImportMission(
+	ctx context.Context,
+	req *ImportMissionRequest,
+) (*connect.Response[drone.UploadMissionResponse], error) {
+	s.deps.GetLogger().Printf("ImportMission request: format=%q, %d bytes", req.Format, len(req.Content))
+
+	format, err := missionfile.ParseFormat(req.Format)
+	if err != nil {
+		return nil, connect.NewError(connect.CodeInvalidArgument, err)
+	}
+	mission, err := missionfile.Parse(format, []byte(req.Content))
+	if err != nil {
+		return nil, connect.NewError(connect.CodeInvalidArgument, err)
+	}
+	if err := validateWaypoints(mission.Waypoints); err != nil {
+		return nil, connect.NewError(connect.CodeInvalidArgument, err)
+	}
+
+	upload := connect.NewRequest(&drone.UploadMissionRequest{
+		Mission: &drone.Mission{
+			Id:        req.ID,
+			Waypoints: mission.Waypoints,
+		},
+	})
+	if req.VerifyCount {
+		upload.Header().Set(VerifyUploadHeader, "count")
+	}
+	var plannedHome *drone.Position
+	if req.SetHome {
+		plannedHome = mission.Home
+	}
+	return s.UploadMissionOptions(ctx, upload, mission.Options, plannedHome)
+}
+
+// MissionFile is a mission written in a planner file format
+type MissionFile struct {
+	Format  missionfile.Format `json:"format"`
+	Content string             `json:"content"`
+}
+
+// ExportMission writes the mission last uploaded to a drone as a planner file
+// Like GetUploadedMission it uses server memory, not the vehicle. The vehicle's
+// home, when known, becomes the planned home. An empty droneID means the active drone.
+func (s *MissionServer) ExportMission(ctx context.Context, droneID, format string) (*MissionFile, error) {
+	s.deps.GetLogger().Printf("ExportMission request: drone_id=%s, format=%s", droneID, format)
+
+	fileFormat, err := missionfile.ParseFormat(format)
+	if err != nil {
+		return nil, connect.NewError(connect.CodeInvalidArgument, err)
+	}
+	if fileFormat == "" {
+		fileFormat = missionfile.FormatPlan
+	}
+
+	uploaded, err := s.GetUploadedMission(ctx, droneID)
+	if err != nil {
+		return nil, err
+	}
+	if len(uploaded.Waypoints) == 0 {
+		return nil, connect.NewError(connect.CodeFailedPrecondition,
+			fmt.Errorf("no waypoint mission was uploaded to %s through this server", uploaded.DroneID))
+	}
+
+	// Write the autocontinue the vehicle got for waypoints that left it unset
+	autocontinue := s.deps.Config.MAVLink.MissionAutocontinue
+	for i := range uploaded.Options {
+		if uploaded.Options[i].Autocontinue == nil {
+			uploaded.Options[i].Autocontinue = &autocontinue
+		}
+	}
+
+	mission := &missionfile.Mission{
+		Waypoints: uploaded.Waypoints,
+		Options:   uploaded.Options,
+	}
+	if client, ok := s.deps.GetMAVLinkClientFor(uploaded.DroneID); ok {
+		if home, ok := client.GetHomePosition(); ok {
+			mission.Home = &drone.Position{
+				Latitude:  home.Latitude,
+				Longitude: home.Longitude,
+				Altitude:  home.Altitude,
+			}
+		}
+	}
+
+	content, err := missionfile.Encode(fileFormat, mission)
+	if err != nil {
+		return nil, connect.NewError(connect.CodeInternal, err)
+	}
+	return &MissionFile{Format: fileFormat, Content: string(content)}, nil
+}
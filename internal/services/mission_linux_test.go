@@ -0,0 +1,322 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log"
+	"slices"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"connectrpc.com/connect"
+	"github.com/bluenviron/gomavlib/v3"
+	"github.com/bluenviron/gomavlib/v3/pkg/dialects/common"
+	"github.com/bluenviron/gomavlib/v3/pkg/message"
+
+	drone "github.com/flightpath-dev/flightpath-proto/gen/go/drone/v1"
+	"github.com/flightpath-dev/flightpath-server/internal/mavlink"
+)
+
+// missionCountDrone connects an active client to a simulated vehicle that
+// reports holding count mission items
+func missionCountDrone(t *testing.T, count uint16) (*mavlink.Client, *gomavlib.Node) {
+	t.Helper()
+	vehicle, device, heartbeats := silentDrone(t)
+	heartbeats()
+
+	client, err := mavlink.NewClient(mavlink.Config{
+		Port:     device,
+		BaudRate: 57600,
+		Logger:   log.New(io.Discard, "", 0),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { client.Close() })
+	if err := client.WaitForConnection(2 * time.Second); err != nil {
+		t.Fatal(err)
+	}
+
+	done := make(chan struct{})
+	stopped := make(chan struct{})
+	go func() {
+		defer close(stopped)
+		for {
+			select {
+			case evt := <-vehicle.Events():
+				frame, ok := evt.(*gomavlib.EventFrame)
+				if !ok {
+					continue
+				}
+				if req, ok := frame.Message().(*common.MessageMissionRequestList); ok {
+					vehicle.WriteMessageAll(&common.MessageMissionCount{ //nolint:errcheck
+						TargetSystem: frame.SystemID(),
+						Count:        count,
+						MissionType:  req.MissionType,
+					})
+				}
+			case <-done:
+				return
+			}
+		}
+	}()
+	t.Cleanup(func() {
+		close(done)
+		<-stopped
+	})
+	return client, vehicle
+}
+
+func TestVerifyMissionCount(t *testing.T) {
+	client, _ := missionCountDrone(t, 4)
+
+	if err := verifyMissionCount(client, 4); err != nil {
+		t.Errorf("matching count: %v", err)
+	}
+	// The vehicle kept fewer items than were uploaded
+	err := verifyMissionCount(client, 5)
+	if err == nil || !strings.Contains(err.Error(), "vehicle reports 4 mission items, uploaded 5") {
+		t.Errorf("count mismatch: %v", err)
+	}
+}
+
+func TestProgressFromLoadedMission(t *testing.T) {
+	// Connecting to a vehicle already flying item 1 of a 4-item mission
+	client, vehicle := missionCountDrone(t, 4)
+	deps := newTestDependencies(t)
+	deps.AddMAVLinkClient("alpha", client)
+	vehicle.WriteMessageAll(&common.MessageMissionCurrent{Seq: 1}) //nolint:errcheck
+
+	s := NewMissionServer(deps)
+	deadline := time.Now().Add(2 * time.Second)
+	for {
+		resp, err := s.GetProgress(context.Background(), connect.NewRequest(&drone.GetProgressRequest{}))
+		if err != nil {
+			t.Fatal(err)
+		}
+		p := resp.Msg
+		if p.Status == drone.GetProgressResponse_STATUS_IN_PROGRESS && p.TotalWaypoints == 4 && p.CurrentWaypoint == 1 {
+			return
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("progress = %+v without an upload, want item 1 of 4 in progress", p)
+		}
+		time.Sleep(20 * time.Millisecond)
+	}
+}
+
+func TestProgressCompletionLatched(t *testing.T) {
+	client, vehicle := missionCountDrone(t, 3)
+	deps := newTestDependencies(t)
+	deps.AddMAVLinkClient("alpha", client)
+	s := NewMissionServer(deps)
+
+	status := func() drone.GetProgressResponse_Status {
+		t.Helper()
+		resp, err := s.GetProgress(context.Background(), connect.NewRequest(&drone.GetProgressRequest{}))
+		if err != nil {
+			t.Fatal(err)
+		}
+		return resp.Msg.Status
+	}
+	// waitFor polls until the client has processed what the vehicle sent
+	waitFor := func(done func() bool) {
+		t.Helper()
+		deadline := time.Now().Add(2 * time.Second)
+		for !done() {
+			if time.Now().After(deadline) {
+				t.Fatal("vehicle messages not processed")
+			}
+			time.Sleep(10 * time.Millisecond)
+		}
+	}
+
+	// The mission already on the vehicle is read on connecting
+	waitFor(func() bool { _, total, _ := client.GetMissionProgress(); return total == 3 })
+
+	vehicle.WriteMessageAll(&common.MessageMissionCurrent{Seq: 1}) //nolint:errcheck
+	waitFor(func() bool { _, _, active := client.GetMissionProgress(); return active })
+	if got := status(); got != drone.GetProgressResponse_STATUS_IN_PROGRESS {
+		t.Fatalf("status = %v, want in progress", got)
+	}
+
+	for seq := uint16(0); seq < 3; seq++ {
+		vehicle.WriteMessageAll(&common.MessageMissionItemReached{Seq: seq}) //nolint:errcheck
+	}
+	waitFor(func() bool { return client.GetMissionTimeline().Complete })
+	completed := client.GetMissionTimeline().Completed
+
+	// Still completed on later polls, with the vehicle back on the last item and
+	// resending it as reached, as autopilots do after landing
+	for i := 0; i < 3; i++ {
+		vehicle.WriteMessageAll(&common.MessageMissionCurrent{Seq: 2})     //nolint:errcheck
+		vehicle.WriteMessageAll(&common.MessageMissionItemReached{Seq: 2}) //nolint:errcheck
+		time.Sleep(50 * time.Millisecond)
+		if got := status(); got != drone.GetProgressResponse_STATUS_COMPLETED {
+			t.Fatalf("poll %d: status = %v, want completed", i, got)
+		}
+	}
+	if p := client.GetMissionTimeline(); !p.Completed.Equal(completed) {
+		t.Errorf("completion time moved from %s to %s", completed, p.Completed)
+	}
+
+	// Starting again clears it
+	if err := client.StartMission(0); err != nil {
+		t.Fatal(err)
+	}
+	if got := status(); got == drone.GetProgressResponse_STATUS_COMPLETED {
+		t.Error("still completed after starting the mission again")
+	}
+}
+
+// uploadVehicle is the vehicle side of DO_SET_HOME and mission uploads,
+// logging what it was sent
+type uploadVehicle struct {
+	mu       sync.Mutex
+	log      []string
+	denyHome bool
+}
+
+func (v *uploadVehicle) record(format string, args ...any) {
+	v.mu.Lock()
+	v.log = append(v.log, fmt.Sprintf(format, args...))
+	v.mu.Unlock()
+}
+
+func (v *uploadVehicle) sent() []string {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	return slices.Clone(v.log)
+}
+
+// serveUploads answers home and mission upload requests on vehicle until the
+// test ends; it holds no mission of its own
+func serveUploads(t *testing.T, vehicle *gomavlib.Node) *uploadVehicle {
+	t.Helper()
+	v := &uploadVehicle{}
+	var home common.MessageHomePosition
+	var count uint16
+	reply := func(msg message.Message) { vehicle.WriteMessageAll(msg) } //nolint:errcheck
+
+	done := make(chan struct{})
+	stopped := make(chan struct{})
+	go func() {
+		defer close(stopped)
+		for {
+			var evt gomavlib.Event
+			select {
+			case evt = <-vehicle.Events():
+			case <-done:
+				return
+			}
+			frame, ok := evt.(*gomavlib.EventFrame)
+			if !ok {
+				continue
+			}
+			gcs := frame.SystemID()
+
+			switch msg := frame.Message().(type) {
+			case *common.MessageCommandInt:
+				result := common.MAV_RESULT_ACCEPTED
+				if msg.Command == common.MAV_CMD_DO_SET_HOME {
+					v.record("DO_SET_HOME %d, %d, %v", msg.X, msg.Y, msg.Z)
+					v.mu.Lock()
+					if v.denyHome {
+						result = common.MAV_RESULT_DENIED
+					}
+					v.mu.Unlock()
+					home = common.MessageHomePosition{Latitude: msg.X, Longitude: msg.Y, Altitude: int32(msg.Z * 1000)}
+				}
+				reply(&common.MessageCommandAck{Command: msg.Command, Result: result})
+			case *common.MessageCommandLong:
+				reply(&common.MessageCommandAck{Command: msg.Command, Result: common.MAV_RESULT_ACCEPTED})
+				if msg.Command == common.MAV_CMD_REQUEST_MESSAGE && msg.Param1 == float32(home.GetID()) {
+					h := home
+					reply(&h)
+				}
+			case *common.MessageMissionRequestList:
+				reply(&common.MessageMissionCount{TargetSystem: gcs, MissionType: msg.MissionType})
+			case *common.MessageMissionCount:
+				v.record("MISSION_COUNT %d", msg.Count)
+				count = msg.Count
+				reply(&common.MessageMissionRequestInt{TargetSystem: gcs, MissionType: msg.MissionType})
+			case *common.MessageMissionItemInt:
+				if msg.Seq == 0 {
+					v.record("item 0 at %d", msg.X)
+				}
+				if msg.Seq+1 < count {
+					reply(&common.MessageMissionRequestInt{TargetSystem: gcs, Seq: msg.Seq + 1, MissionType: msg.MissionType})
+				} else {
+					reply(&common.MessageMissionAck{TargetSystem: gcs, Type: common.MAV_MISSION_ACCEPTED, MissionType: msg.MissionType})
+				}
+			}
+		}
+	}()
+	t.Cleanup(func() {
+		close(done)
+		<-stopped
+	})
+	return v
+}
+
+func TestUploadMissionPlannedHome(t *testing.T) {
+	deps, client, vehicle := activeDrone(t, &common.MessageHeartbeat{
+		Type:      common.MAV_TYPE_QUADROTOR,
+		Autopilot: common.MAV_AUTOPILOT_PX4,
+	}, mavlink.Config{})
+	v := serveUploads(t, vehicle)
+	s := NewMissionServer(deps)
+
+	upload := func(plannedHome *drone.Position) *drone.UploadMissionResponse {
+		t.Helper()
+		waypoints, options := testMission(2)
+		waypoints[0].Position.Latitude = 47.3985
+		resp, err := s.UploadMissionOptions(context.Background(), connect.NewRequest(&drone.UploadMissionRequest{
+			Mission: &drone.Mission{Id: "survey", Waypoints: waypoints},
+		}), options, plannedHome)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return resp.Msg
+	}
+	home := &drone.Position{Latitude: 47.3977419, Longitude: 8.5455938, Altitude: 488.5}
+
+	// Home is set first and isn't sent as item 0
+	if resp := upload(home); !resp.Success {
+		t.Fatalf("upload: %s", resp.Message)
+	}
+	want := []string{"DO_SET_HOME 473977419, 85455938, 488.5", "MISSION_COUNT 2", "item 0 at 473985000"}
+	if got := v.sent(); !slices.Equal(got, want) {
+		t.Errorf("vehicle was sent %q, want %q", got, want)
+	}
+	if h, ok := client.GetHomePosition(); !ok || h.Altitude != 488.5 {
+		t.Errorf("home = %+v, %v", h, ok)
+	}
+
+	// Optional
+	v.mu.Lock()
+	v.log = nil
+	v.mu.Unlock()
+	if resp := upload(nil); !resp.Success {
+		t.Fatalf("upload without a planned home: %s", resp.Message)
+	}
+	if got := v.sent(); len(got) == 0 || got[0] != "MISSION_COUNT 2" {
+		t.Errorf("vehicle was sent %q", got)
+	}
+
+	// A refused home stops the upload
+	v.mu.Lock()
+	v.log = nil
+	v.denyHome = true
+	v.mu.Unlock()
+	resp := upload(home)
+	if resp.Success || !strings.Contains(resp.Message, "planned home failed, mission not uploaded") {
+		t.Errorf("upload with a refused home: %+v", resp)
+	}
+	if got := v.sent(); len(got) != 1 {
+		t.Errorf("vehicle was sent %q, want only DO_SET_HOME", got)
+	}
+}
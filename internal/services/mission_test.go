@@ -0,0 +1,130 @@
+package services
+
+import (
+	"io"
+	"log"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"connectrpc.com/connect"
+
+	drone "github.com/flightpath-dev/flightpath-proto/gen/go/drone/v1"
+	"github.com/flightpath-dev/flightpath-server/internal/mavlink"
+)
+
+// testMission returns n waypoints, sequenced, whose latitudes are their
+// original positions
+func testMission(n int) ([]*drone.Waypoint, []mavlink.WaypointOptions) {
+	waypoints := make([]*drone.Waypoint, n)
+	for i := range waypoints {
+		waypoints[i] = &drone.Waypoint{
+			Sequence: int32(i),
+			Position: &drone.Position{Latitude: float64(i), Longitude: 8, Altitude: 30},
+		}
+	}
+	return waypoints, mavlink.DefaultWaypointOptions(n)
+}
+
+// checkSequence fails unless waypoints are numbered 0..n-1 and have the
+// latitudes want
+func checkSequence(t *testing.T, waypoints []*drone.Waypoint, want ...float64) {
+	t.Helper()
+	if len(waypoints) != len(want) {
+		t.Fatalf("%d waypoints, want %d", len(waypoints), len(want))
+	}
+	for i, wp := range waypoints {
+		if wp.Sequence != int32(i) || wp.Position.Latitude != want[i] {
+			t.Errorf("waypoint %d: sequence %d, latitude %v, want latitude %v",
+				i, wp.Sequence, wp.Position.Latitude, want[i])
+		}
+	}
+}
+
+func TestInsertWaypointAppends(t *testing.T) {
+	waypoints, options := testMission(2)
+	added := &drone.Waypoint{Sequence: 7, Position: &drone.Position{Latitude: 9}}
+	loiter := mavlink.WaypointOptions{LoiterRadius: 25}
+
+	edited, editedOptions, err := insertWaypoint(waypoints, options, -1, added, loiter)
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkSequence(t, edited, 0, 1, 9)
+	if len(editedOptions) != 3 || editedOptions[2].LoiterRadius != 25 {
+		t.Errorf("options = %+v", editedOptions)
+	}
+	// The same as inserting at the end
+	if edited, _, _ := insertWaypoint(waypoints, options, 2, added, loiter); edited[2].Position.Latitude != 9 {
+		t.Error("insert at the mission length didn't append")
+	}
+}
+
+func TestInsertWaypointInMiddle(t *testing.T) {
+	waypoints, options := testMission(3)
+	added := &drone.Waypoint{Position: &drone.Position{Latitude: 9}}
+
+	edited, editedOptions, err := insertWaypoint(waypoints, options, 1, added, mavlink.WaypointOptions{LoiterTurns: 2})
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Later waypoints move up one and are renumbered
+	checkSequence(t, edited, 0, 9, 1, 2)
+	if len(editedOptions) != 4 || editedOptions[1].LoiterTurns != 2 || editedOptions[2].LoiterTurns != 0 {
+		t.Errorf("options = %+v", editedOptions)
+	}
+
+	// The retained mission is untouched, in case the upload fails
+	checkSequence(t, waypoints, 0, 1, 2)
+	if added.Sequence != 0 || edited[1] == added {
+		t.Error("inserted waypoint modified in place")
+	}
+}
+
+func TestInsertWaypointOutOfRange(t *testing.T) {
+	waypoints, options := testMission(2)
+	for _, index := range []int{3, -2} {
+		_, _, err := insertWaypoint(waypoints, options, index, &drone.Waypoint{}, mavlink.WaypointOptions{})
+		if connect.CodeOf(err) != connect.CodeInvalidArgument {
+			t.Errorf("index %d: %v, want invalid_argument", index, err)
+		}
+	}
+}
+
+func TestResequenceWaypoints(t *testing.T) {
+	waypoints := []*drone.Waypoint{
+		{Sequence: 4, Position: &drone.Position{Latitude: 1}, HoldTimeSec: 5},
+		{Sequence: 4, Position: &drone.Position{Latitude: 2}, Heading: 90},
+	}
+	got := resequenceWaypoints(waypoints)
+	checkSequence(t, got, 1, 2)
+	if got[0].HoldTimeSec != 5 || got[1].Heading != 90 {
+		t.Errorf("waypoint fields not copied: %+v", got)
+	}
+	if waypoints[0].Sequence != 4 {
+		t.Error("input waypoints renumbered")
+	}
+}
+
+func TestCheckMissionSize(t *testing.T) {
+	client, err := mavlink.NewClient(mavlink.Config{
+		Port:            filepath.Join(t.TempDir(), "ttyGone"),
+		BaudRate:        57600,
+		Serial:          mavlink.SerialConfig{StopBits: 2},
+		PassiveMode:     true,
+		MaxMissionItems: 10,
+		Logger:          log.New(io.Discard, "", 0),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer client.Close()
+
+	if err := checkMissionSize(client, 10); err != nil {
+		t.Errorf("mission at the limit: %v", err)
+	}
+	err = checkMissionSize(client, 11)
+	if connect.CodeOf(err) != connect.CodeInvalidArgument || !strings.Contains(err.Error(), "at most 10") {
+		t.Errorf("oversized mission: %v, want invalid_argument", err)
+	}
+}
@@ -0,0 +1,97 @@
+package services
+
+import (
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/bluenviron/gomavlib/v3"
+	"github.com/bluenviron/gomavlib/v3/pkg/dialects/common"
+	"golang.org/x/sys/unix"
+
+	"github.com/flightpath-dev/flightpath-server/internal/mavlink"
+)
+
+// openPTY opens a pseudo-terminal and returns its master side and the path of
+// its serial device
+func openPTY(t *testing.T) (*os.File, string) {
+	t.Helper()
+	master, err := os.OpenFile("/dev/ptmx", os.O_RDWR|syscall.O_NOCTTY, 0)
+	if err != nil {
+		t.Skipf("no pseudo-terminals: %v", err)
+	}
+	t.Cleanup(func() { master.Close() })
+
+	fd := int(master.Fd())
+	if err := unix.IoctlSetPointerInt(fd, unix.TIOCSPTLCK, 0); err != nil {
+		t.Fatalf("unlocking pty: %v", err)
+	}
+	n, err := unix.IoctlGetInt(fd, unix.TIOCGPTN)
+	if err != nil {
+		t.Fatalf("pty number: %v", err)
+	}
+	return master, fmt.Sprintf("/dev/pts/%d", n)
+}
+
+// startMockDrone connects a client to a simulated PX4 vehicle that sends
+// HEARTBEAT and GLOBAL_POSITION_INT at lat, lon until the test ends
+// The returned node is the vehicle, for sending it further messages.
+func startMockDrone(t *testing.T, systemID uint8, lat, lon int32) (*mavlink.Client, *gomavlib.Node) {
+	t.Helper()
+	master, device := openPTY(t)
+
+	vehicle, err := gomavlib.NewNode(gomavlib.NodeConf{
+		Endpoints:        []gomavlib.EndpointConf{gomavlib.EndpointCustom{ReadWriteCloser: master}},
+		Dialect:          common.Dialect,
+		OutVersion:       gomavlib.V2,
+		OutSystemID:      systemID,
+		OutComponentID:   1,
+		HeartbeatDisable: true,
+	})
+	if err != nil {
+		t.Fatalf("mock drone %d: %v", systemID, err)
+	}
+
+	client, err := mavlink.NewClient(mavlink.Config{
+		Port:        device,
+		BaudRate:    57600,
+		PassiveMode: true,
+		Logger:      log.New(io.Discard, "", 0),
+	})
+	if err != nil {
+		vehicle.Close()
+		t.Fatalf("client for mock drone %d: %v", systemID, err)
+	}
+
+	done := make(chan struct{})
+	stopped := make(chan struct{})
+	go func() {
+		defer close(stopped)
+		ticker := time.NewTicker(50 * time.Millisecond)
+		defer ticker.Stop()
+		for {
+			vehicle.WriteMessageAll(&common.MessageHeartbeat{ //nolint:errcheck
+				Type:      common.MAV_TYPE_QUADROTOR,
+				Autopilot: common.MAV_AUTOPILOT_PX4,
+			})
+			vehicle.WriteMessageAll(&common.MessageGlobalPositionInt{Lat: lat, Lon: lon}) //nolint:errcheck
+			select {
+			case <-done:
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+	t.Cleanup(func() {
+		close(done)
+		<-stopped
+		client.Close()
+		vehicle.Close()
+	})
+
+	return client, vehicle
+}
@@ -0,0 +1,94 @@
+package services
+
+import (
+	"context"
+	"errors"
+
+	"connectrpc.com/connect"
+
+	"github.com/flightpath-dev/flightpath-server/internal/mavlink"
+	"github.com/flightpath-dev/flightpath-server/internal/server"
+)
+
+// maxParameterBatch caps the names in one GetParameters call
+const maxParameterBatch = 500
+
+// ParameterServer reads vehicle parameters (no proto definition yet)
+// Reads are served from the client's parameter cache; only missing or stale
+// parameters cost MAVLink traffic.
+type ParameterServer struct {
+	deps *server.Dependencies
+}
+
+// NewParameterServer creates a new ParameterServer
+func NewParameterServer(deps *server.Dependencies) *ParameterServer {
+	return &ParameterServer{
+		deps: deps,
+	}
+}
+
+// GetParametersRequest reads a batch of parameters by name
+type GetParametersRequest struct {
+	Names []string `json:"names"`
+}
+
+// GetParametersResponse holds the parameters read and the names the vehicle
+// didn't answer for (unknown names, or lost on the link)
+type GetParametersResponse struct {
+	Parameters []mavlink.Parameter `json:"parameters"`
+	Missing    []string            `json:"missing,omitempty"`
+}
+
+// GetParameters returns the named parameters of a drone
+// An empty droneID means the active drone.
+func (s *ParameterServer) GetParameters(
+	ctx context.Context,
+	droneID string,
+	req *GetParametersRequest,
+) (*GetParametersResponse, error) {
+	s.deps.GetLogger().Printf("GetParameters request: drone_id=%s, %d names", droneID, len(req.Names))
+
+	if len(req.Names) == 0 {
+		return nil, connect.NewError(connect.CodeInvalidArgument, errors.New("names is required"))
+	}
+	if len(req.Names) > maxParameterBatch {
+		return nil, connect.NewError(connect.CodeInvalidArgument,
+			errors.New("too many names; use the prefix listing for large reads"))
+	}
+
+	client, err := requireReadyClient(ctx, s.deps, droneID, policyConnected)
+	if err != nil {
+		return nil, err
+	}
+
+	params, missing, err := client.GetParameters(req.Names)
+	if err != nil {
+		return nil, connect.NewError(connect.CodeUnavailable, err)
+	}
+	if params == nil {
+		params = []mavlink.Parameter{}
+	}
+	return &GetParametersResponse{Parameters: params, Missing: missing}, nil
+}
+
+// GetParametersByPrefix returns every parameter of a drone starting with prefix
+// ("" returns all). The first call downloads the full list, which takes a few
+// seconds on slow links; later calls are served from cache until it is stale.
+func (s *ParameterServer) GetParametersByPrefix(
+	ctx context.Context,
+	droneID string,
+	prefix string,
+) ([]mavlink.Parameter, error) {
+	s.deps.GetLogger().Printf("GetParametersByPrefix request: drone_id=%s, prefix=%q", droneID, prefix)
+
+	client, err := requireReadyClient(ctx, s.deps, droneID, policyConnected)
+	if err != nil {
+		return nil, err
+	}
+
+	params, err := client.GetParametersByPrefix(prefix)
+	if err != nil {
+		return nil, connect.NewError(connect.CodeUnavailable, err)
+	}
+	return params, nil
+}
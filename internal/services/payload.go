@@ -0,0 +1,121 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/flightpath-dev/flightpath-server/internal/mavlink"
+)
+
+// SetServoRequest sets an aux servo output for payload actuation
+type SetServoRequest struct {
+	Channel int `json:"channel"` // servo output, 1-16
+	PWM     int `json:"pwm"`     // pulse width, 1000-2000 us
+}
+
+// SetServo sets a servo output on the active drone with MAV_CMD_DO_SET_SERVO
+// Out-of-range channels and pulse widths are refused before anything is sent.
+func (s *ControlServer) SetServo(ctx context.Context, req *SetServoRequest) (resp *CommandResponse, err error) {
+	defer func() { recordAudit(ctx, s.deps, "set_servo", "", req, resp, err) }()
+
+	logger := s.deps.GetLogger()
+	logger.Printf("SetServo request: channel=%d, pwm=%d", req.Channel, req.PWM)
+
+	if err := mavlink.ValidateServo(req.Channel, req.PWM); err != nil {
+		return &CommandResponse{
+			Success: false,
+			Message: fmt.Sprintf("Invalid servo command: %v", err),
+		}, nil
+	}
+
+	if err := requireCommandsEnabled(s.deps); err != nil {
+		return nil, err
+	}
+
+	client, err := requireReadyClient(ctx, s.deps, "", policyConnected)
+	if err != nil {
+		return &CommandResponse{
+			Success: false,
+			Message: errorMessage(err),
+		}, nil
+	}
+
+	if err := client.SetServo(req.Channel, req.PWM); err != nil {
+		resp := &CommandResponse{
+			Success: false,
+			Message: fmt.Sprintf("Set servo failed: %v", err),
+		}
+		var rejected *mavlink.CommandRejectedError
+		if errors.As(err, &rejected) {
+			resp.Result = rejected.ResultName()
+		}
+		return resp, nil
+	}
+
+	logger.Printf("Servo %d set to %d us", req.Channel, req.PWM)
+
+	return &CommandResponse{
+		Success: true,
+		Message: fmt.Sprintf("Servo %d set to %d us", req.Channel, req.PWM),
+		Result:  "ACCEPTED",
+	}, nil
+}
+
+// RepeatServoRequest cycles an aux servo output, for repeated payload actuation
+type RepeatServoRequest struct {
+	Channel   int     `json:"channel"`    // servo output, 1-16
+	PWM       int     `json:"pwm"`        // pulse width, 1000-2000 us
+	Count     int     `json:"count"`      // cycles, 1-100
+	CycleTime float64 `json:"cycle_time"` // seconds per cycle, 0.1-60
+}
+
+// RepeatServo cycles a servo output on the active drone with
+// MAV_CMD_DO_REPEAT_SERVO
+// Invalid settings are refused before anything is sent.
+func (s *ControlServer) RepeatServo(ctx context.Context, req *RepeatServoRequest) (resp *CommandResponse, err error) {
+	defer func() { recordAudit(ctx, s.deps, "repeat_servo", "", req, resp, err) }()
+
+	logger := s.deps.GetLogger()
+	logger.Printf("RepeatServo request: channel=%d, pwm=%d, count=%d, cycle_time=%.2f",
+		req.Channel, req.PWM, req.Count, req.CycleTime)
+
+	if err := mavlink.ValidateServoRepeat(req.Channel, req.PWM, req.Count, req.CycleTime); err != nil {
+		return &CommandResponse{
+			Success: false,
+			Message: fmt.Sprintf("Invalid servo repeat command: %v", err),
+		}, nil
+	}
+
+	if err := requireCommandsEnabled(s.deps); err != nil {
+		return nil, err
+	}
+
+	client, err := requireReadyClient(ctx, s.deps, "", policyConnected)
+	if err != nil {
+		return &CommandResponse{
+			Success: false,
+			Message: errorMessage(err),
+		}, nil
+	}
+
+	if err := client.RepeatServo(req.Channel, req.PWM, req.Count, req.CycleTime); err != nil {
+		resp := &CommandResponse{
+			Success: false,
+			Message: fmt.Sprintf("Repeat servo failed: %v", err),
+		}
+		var rejected *mavlink.CommandRejectedError
+		if errors.As(err, &rejected) {
+			resp.Result = rejected.ResultName()
+		}
+		return resp, nil
+	}
+
+	logger.Printf("Servo %d repeat accepted", req.Channel)
+
+	return &CommandResponse{
+		Success: true,
+		Message: fmt.Sprintf("Servo %d cycling to %d us %d times", req.Channel, req.PWM, req.Count),
+		Result:  "ACCEPTED",
+	}, nil
+}
@@ -0,0 +1,126 @@
+package services
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/flightpath-dev/flightpath-server/internal/config"
+	"github.com/flightpath-dev/flightpath-server/internal/mavlink"
+)
+
+// ReadinessLevel is an overall green/yellow/red flight readiness indicator
+type ReadinessLevel string
+
+const (
+	ReadinessReady    ReadinessLevel = "READY"
+	ReadinessCaution  ReadinessLevel = "CAUTION"
+	ReadinessNotReady ReadinessLevel = "NOT_READY"
+)
+
+// severity orders levels so the worst reason decides the overall level
+func (l ReadinessLevel) severity() int {
+	switch l {
+	case ReadinessNotReady:
+		return 2
+	case ReadinessCaution:
+		return 1
+	default:
+		return 0
+	}
+}
+
+// ReadinessReason is one check that lowered the readiness level
+type ReadinessReason struct {
+	Check   string         `json:"check"` // gps, battery, sensors, estimator, link, telemetry, failsafe
+	Level   ReadinessLevel `json:"level"`
+	Message string         `json:"message"`
+}
+
+// Readiness combines GPS, battery, sensor, estimator, link and failsafe state
+// Level is READY with no reasons, otherwise the worst level among Reasons.
+type Readiness struct {
+	Level   ReadinessLevel    `json:"level"`
+	Reasons []ReadinessReason `json:"reasons,omitempty"`
+}
+
+func (r *Readiness) add(check string, level ReadinessLevel, format string, args ...any) {
+	r.Reasons = append(r.Reasons, ReadinessReason{
+		Check:   check,
+		Level:   level,
+		Message: fmt.Sprintf(format, args...),
+	})
+	if level.severity() > r.Level.severity() {
+		r.Level = level
+	}
+}
+
+// computeReadiness scores a drone's state against the configured thresholds
+// NOT_READY uses the same GPS and battery minimums as health-gated commands.
+func computeReadiness(
+	cfg *config.MAVLinkConfig,
+	t mavlink.TelemetryData,
+	link mavlink.ConnectionInfo,
+	failsafes []mavlink.FailsafeEvent,
+) Readiness {
+	r := Readiness{Level: ReadinessReady}
+
+	if !link.Connected {
+		r.add("link", ReadinessNotReady, "link is down")
+	} else if link.PacketLossPercent > cfg.MaxPacketLossPercent {
+		r.add("link", ReadinessNotReady, "packet loss %.1f%% (max %.1f%%)", link.PacketLossPercent, cfg.MaxPacketLossPercent)
+	} else if link.PacketLossPercent > cfg.CautionPacketLossPercent {
+		r.add("link", ReadinessCaution, "packet loss %.1f%% (caution above %.1f%%)", link.PacketLossPercent, cfg.CautionPacketLossPercent)
+	}
+	if link.TelemetryStale {
+		r.add("telemetry", ReadinessNotReady, "no position update while armed")
+	}
+
+	switch sats := int(t.SatelliteCount); {
+	case sats < cfg.MinSatellites:
+		r.add("gps", ReadinessNotReady, "%d satellites (need %d)", sats, cfg.MinSatellites)
+	case sats < cfg.CautionSatellites:
+		r.add("gps", ReadinessCaution, "%d satellites (caution below %d)", sats, cfg.CautionSatellites)
+	}
+
+	// -1 means the autopilot doesn't estimate remaining capacity
+	if battery := int(t.BatteryRemaining); battery >= 0 {
+		switch {
+		case battery < cfg.MinBatteryPercent:
+			r.add("battery", ReadinessNotReady, "battery at %d%% (need %d%%)", battery, cfg.MinBatteryPercent)
+		case battery < cfg.CautionBatteryPercent:
+			r.add("battery", ReadinessCaution, "battery at %d%% (caution below %d%%)", battery, cfg.CautionBatteryPercent)
+		}
+	}
+
+	if !t.SensorsHealthy {
+		r.add("sensors", ReadinessNotReady, "sensors report unhealthy")
+	}
+	if !t.EstimatorHealthy {
+		r.add("estimator", ReadinessNotReady, "attitude/position estimator (EKF) reports unhealthy")
+	}
+
+	for _, fs := range failsafes {
+		r.add("failsafe", ReadinessNotReady, "%s failsafe active (action: %s)", fs.Type, fs.Action)
+	}
+
+	return r
+}
+
+// GetReadiness returns a drone's overall readiness and the reasons behind it
+// An empty droneID means the active drone.
+func (s *TelemetryServer) GetReadiness(ctx context.Context, droneID string) (*Readiness, error) {
+	s.deps.GetLogger().Printf("GetReadiness request: drone_id=%s", droneID)
+
+	client, err := requireReadyClient(ctx, s.deps, droneID, policyClient)
+	if err != nil {
+		return nil, err
+	}
+
+	readiness := readinessOf(&s.deps.Config.MAVLink, client)
+	return &readiness, nil
+}
+
+// readinessOf computes a client's readiness from its current state
+func readinessOf(cfg *config.MAVLinkConfig, client *mavlink.Client) Readiness {
+	return computeReadiness(cfg, client.GetTelemetry(), client.GetConnectionInfo(), client.GetActiveFailsafes())
+}
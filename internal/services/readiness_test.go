@@ -0,0 +1,90 @@
+package services
+
+import (
+	"slices"
+	"testing"
+
+	"github.com/flightpath-dev/flightpath-server/internal/config"
+	"github.com/flightpath-dev/flightpath-server/internal/mavlink"
+)
+
+func TestComputeReadiness(t *testing.T) {
+	// Defaults: NOT_READY below 6 satellites / 20% battery / above 20% loss,
+	// CAUTION below 10 satellites / 40% battery / above 5% loss
+	defaults := config.Default().MAVLink
+	strict := defaults
+	strict.CautionSatellites = 14
+	strict.CautionBatteryPercent = 80
+
+	healthy := mavlink.TelemetryData{
+		SatelliteCount:   12,
+		BatteryRemaining: 90,
+		SensorsHealthy:   true,
+		EstimatorHealthy: true,
+	}
+	with := func(change func(*mavlink.TelemetryData)) mavlink.TelemetryData {
+		t := healthy
+		change(&t)
+		return t
+	}
+	up := mavlink.ConnectionInfo{Connected: true}
+
+	tests := []struct {
+		name      string
+		cfg       config.MAVLinkConfig
+		telemetry mavlink.TelemetryData
+		link      mavlink.ConnectionInfo
+		failsafes []mavlink.FailsafeEvent
+		want      ReadinessLevel
+		checks    []string
+	}{
+		{"healthy", defaults, healthy, up, nil, ReadinessReady, nil},
+		{"few satellites", defaults, with(func(t *mavlink.TelemetryData) { t.SatelliteCount = 8 }), up, nil,
+			ReadinessCaution, []string{"gps"}},
+		{"no GPS", defaults, with(func(t *mavlink.TelemetryData) { t.SatelliteCount = 3 }), up, nil,
+			ReadinessNotReady, []string{"gps"}},
+		{"battery getting low", defaults, with(func(t *mavlink.TelemetryData) { t.BatteryRemaining = 30 }), up, nil,
+			ReadinessCaution, []string{"battery"}},
+		{"battery too low", defaults, with(func(t *mavlink.TelemetryData) { t.BatteryRemaining = 10 }), up, nil,
+			ReadinessNotReady, []string{"battery"}},
+		{"battery level unknown", defaults, with(func(t *mavlink.TelemetryData) { t.BatteryRemaining = -1 }), up, nil,
+			ReadinessReady, nil},
+		{"unhealthy sensors", defaults, with(func(t *mavlink.TelemetryData) { t.SensorsHealthy = false }), up, nil,
+			ReadinessNotReady, []string{"sensors"}},
+		{"unhealthy estimator", defaults, with(func(t *mavlink.TelemetryData) { t.EstimatorHealthy = false }), up, nil,
+			ReadinessNotReady, []string{"estimator"}},
+		{"link down", defaults, healthy, mavlink.ConnectionInfo{}, nil,
+			ReadinessNotReady, []string{"link"}},
+		{"some packet loss", defaults, healthy, mavlink.ConnectionInfo{Connected: true, PacketLossPercent: 10}, nil,
+			ReadinessCaution, []string{"link"}},
+		{"heavy packet loss", defaults, healthy, mavlink.ConnectionInfo{Connected: true, PacketLossPercent: 30}, nil,
+			ReadinessNotReady, []string{"link"}},
+		{"stale telemetry", defaults, healthy, mavlink.ConnectionInfo{Connected: true, TelemetryStale: true}, nil,
+			ReadinessNotReady, []string{"telemetry"}},
+		{"active failsafe", defaults, healthy, up,
+			[]mavlink.FailsafeEvent{{Type: mavlink.FailsafeRCLoss, Action: mavlink.FailsafeActionRTL, Active: true}},
+			ReadinessNotReady, []string{"failsafe"}},
+		{"worst reason wins", defaults, with(func(t *mavlink.TelemetryData) {
+			t.SatelliteCount = 8
+			t.BatteryRemaining = 10
+		}), up, nil, ReadinessNotReady, []string{"gps", "battery"}},
+		{"configured caution thresholds", strict, healthy, up, nil,
+			ReadinessCaution, []string{"gps"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := computeReadiness(&tt.cfg, tt.telemetry, tt.link, tt.failsafes)
+			if got.Level != tt.want {
+				t.Errorf("level = %s, want %s (reasons %+v)", got.Level, tt.want, got.Reasons)
+			}
+
+			var checks []string
+			for _, reason := range got.Reasons {
+				checks = append(checks, reason.Check)
+			}
+			if !slices.Equal(checks, tt.checks) {
+				t.Errorf("checks = %v, want %v", checks, tt.checks)
+			}
+		})
+	}
+}
@@ -0,0 +1,174 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"connectrpc.com/connect"
+	"github.com/bluenviron/gomavlib/v3/pkg/dialects/common"
+
+	"github.com/flightpath-dev/flightpath-server/internal/config"
+	"github.com/flightpath-dev/flightpath-server/internal/mavlink"
+	"github.com/flightpath-dev/flightpath-server/internal/server"
+)
+
+// readyPolicy is how ready a drone must be before a handler may use its client
+type readyPolicy int
+
+const (
+	// policyClient only needs a client; the link may be down (streams, disconnect)
+	policyClient readyPolicy = iota
+	// policyConnected needs a live link (heartbeats arriving, writes succeeding)
+	policyConnected
+	// policyHealthy also needs GPS, battery and sensors to pass the health checks
+	policyHealthy
+)
+
+// requireCommandsEnabled refuses a state-changing request while commands are
+// disabled (safe boot) with CodeFailedPrecondition
+// Connecting, disconnecting and telemetry profiles stay allowed: monitoring
+// needs them.
+func requireCommandsEnabled(deps *server.Dependencies) error {
+	if deps.CommandsEnabled() {
+		return nil
+	}
+	return connect.NewError(connect.CodeFailedPrecondition,
+		errors.New("Commands are disabled (safe boot). Enable them with PUT /api/v1/commands"))
+}
+
+// commandPolicy returns the policy for a flight command
+// Commands listed in MAVLinkConfig.HealthGatedCommands need a healthy vehicle;
+// the rest only a live link.
+func commandPolicy(cfg *config.Config, command string) readyPolicy {
+	for _, gated := range cfg.MAVLink.HealthGatedCommands {
+		if gated == command {
+			return policyHealthy
+		}
+	}
+	return policyConnected
+}
+
+type droneScopeKey struct{}
+
+// WithDrone scopes a request's context to a drone: service methods then address
+// it instead of the active drone
+// The REST gateway scopes its /drones/{id} routes this way, so concurrent
+// requests for different drones never go through the process-wide active drone.
+func WithDrone(ctx context.Context, droneID string) context.Context {
+	return context.WithValue(ctx, droneScopeKey{}, droneID)
+}
+
+// targetDrone returns the drone a request addresses: droneID if given, else the
+// drone the context is scoped to (WithDrone), else the active drone
+func targetDrone(ctx context.Context, deps *server.Dependencies, droneID string) string {
+	if droneID != "" {
+		return droneID
+	}
+	if scoped, _ := ctx.Value(droneScopeKey{}).(string); scoped != "" {
+		return scoped
+	}
+	return deps.GetActiveDroneID()
+}
+
+// requireReadyClient returns the drone's client if it satisfies policy
+// An empty droneID means the request's target drone (see targetDrone).
+// Failures are Connect errors: CodeFailedPrecondition without a client or when
+// unhealthy, CodeUnavailable when the link is down.
+func requireReadyClient(ctx context.Context, deps *server.Dependencies, droneID string, policy readyPolicy) (*mavlink.Client, error) {
+	droneID = targetDrone(ctx, deps, droneID)
+
+	client, ok := deps.GetMAVLinkClientFor(droneID)
+	if !ok {
+		return nil, connect.NewError(connect.CodeFailedPrecondition,
+			errors.New("Not connected to drone. Call Connect first."))
+	}
+	deps.MarkMAVLinkClientUsed(client)
+
+	if policy == policyClient {
+		return client, nil
+	}
+
+	if !client.IsConnected() {
+		return nil, connect.NewError(connect.CodeUnavailable,
+			errors.New("Drone is not connected"))
+	}
+	if policy == policyConnected {
+		return client, nil
+	}
+
+	if err := checkHealth(&deps.Config.MAVLink, client.GetTelemetry()); err != nil {
+		return nil, connect.NewError(connect.CodeFailedPrecondition,
+			fmt.Errorf("Drone is not ready: %w", err))
+	}
+	return client, nil
+}
+
+// checkHealth applies the configured GPS, battery and sensor checks
+func checkHealth(cfg *config.MAVLinkConfig, t mavlink.TelemetryData) error {
+	if int(t.SatelliteCount) < cfg.MinSatellites {
+		return fmt.Errorf("GPS has %d satellites (need %d)", t.SatelliteCount, cfg.MinSatellites)
+	}
+	// -1 means the autopilot doesn't estimate remaining capacity
+	if t.BatteryRemaining >= 0 && int(t.BatteryRemaining) < cfg.MinBatteryPercent {
+		return fmt.Errorf("battery at %d%% (need %d%%)", t.BatteryRemaining, cfg.MinBatteryPercent)
+	}
+	if !t.SensorsHealthy {
+		return errors.New("sensors report unhealthy")
+	}
+	return nil
+}
+
+// checkTakeoffGPS requires a 3D fix, enough satellites and a low enough HDOP
+// for an automatic takeoff, which needs GPS to hold position
+func checkTakeoffGPS(cfg *config.MAVLinkConfig, t mavlink.TelemetryData) error {
+	if t.GPSFixType < common.GPS_FIX_TYPE_3D_FIX {
+		return fmt.Errorf("GPS has no 3D fix (%s)", strings.TrimPrefix(t.GPSFixType.String(), "GPS_FIX_TYPE_"))
+	}
+	if int(t.SatelliteCount) < cfg.MinSatellites {
+		return fmt.Errorf("GPS has %d satellites (need %d)", t.SatelliteCount, cfg.MinSatellites)
+	}
+	if t.GPSHDOP == 0 {
+		return errors.New("GPS doesn't report HDOP")
+	}
+	if t.GPSHDOP > cfg.TakeoffMaxHDOP {
+		return fmt.Errorf("GPS HDOP is %.1f (need at most %.1f)", t.GPSHDOP, cfg.TakeoffMaxHDOP)
+	}
+	return nil
+}
+
+// OverrideMissionHeader set to "true" lets a manual flight command through
+// while a mission is flying (see MAVLinkConfig.RejectDuringMission)
+const OverrideMissionHeader = "Flightpath-Override-Mission"
+
+// checkMissionConflict refuses a manual flight command while the vehicle flies
+// a mission: armed in AUTO.MISSION with the mission not yet complete
+// Only with RejectDuringMission; override lets the command through. The error
+// is CodeFailedPrecondition.
+func checkMissionConflict(deps *server.Dependencies, client *mavlink.Client, command string, override bool) error {
+	if !deps.Config.MAVLink.RejectDuringMission {
+		return nil
+	}
+
+	telemetry := client.TelemetrySnapshot()
+	if !telemetry.Armed() || !mavlink.IsMissionMode(telemetry.CustomMode) || client.GetMissionTimeline().Complete {
+		return nil
+	}
+
+	if override {
+		deps.GetLogger().Printf("Warning - %s sent during a mission (%s)", command, OverrideMissionHeader)
+		return nil
+	}
+	return connect.NewError(connect.CodeFailedPrecondition,
+		fmt.Errorf("Refusing %s: a mission is active; pause it first or set %s: true", command, OverrideMissionHeader))
+}
+
+// errorMessage returns the human-readable part of an error for Success:false responses
+func errorMessage(err error) string {
+	var connectErr *connect.Error
+	if errors.As(err, &connectErr) {
+		return connectErr.Message()
+	}
+	return err.Error()
+}
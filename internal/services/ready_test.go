@@ -0,0 +1,133 @@
+package services
+
+import (
+	"context"
+	"io"
+	"log"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/bluenviron/gomavlib/v3/pkg/dialects/common"
+
+	"github.com/flightpath-dev/flightpath-server/internal/config"
+	"github.com/flightpath-dev/flightpath-server/internal/mavlink"
+	"github.com/flightpath-dev/flightpath-server/internal/server"
+)
+
+// newTestDependencies returns dependencies with an empty registry that log
+// nowhere
+func newTestDependencies(tb testing.TB) *server.Dependencies {
+	tb.Helper()
+	out := log.Writer()
+	log.SetOutput(io.Discard)
+	tb.Cleanup(func() { log.SetOutput(out) })
+
+	cfg := config.Default()
+	cfg.Server.DroneRegistryPath = filepath.Join(tb.TempDir(), "drones.yaml")
+	return server.NewDependencies(cfg)
+}
+
+func TestTargetDrone(t *testing.T) {
+	deps := &server.Dependencies{}
+	scoped := WithDrone(context.Background(), "bravo")
+
+	tests := []struct {
+		name    string
+		ctx     context.Context
+		droneID string
+		want    string
+	}{
+		{"explicit ID wins over scope", scoped, "charlie", "charlie"},
+		{"scoped context", scoped, "", "bravo"},
+		{"unscoped falls back to the active drone", context.Background(), "", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := targetDrone(tt.ctx, deps, tt.droneID); got != tt.want {
+				t.Errorf("targetDrone() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCheckHealth(t *testing.T) {
+	// Defaults: at least 6 satellites and 20% battery
+	cfg := config.Default().MAVLink
+	healthy := mavlink.TelemetryData{SatelliteCount: 6, BatteryRemaining: 20, SensorsHealthy: true}
+
+	tests := []struct {
+		name   string
+		change func(*mavlink.TelemetryData)
+		reason string // in the error; empty for healthy
+	}{
+		{"at the thresholds", func(*mavlink.TelemetryData) {}, ""},
+		{"too few satellites", func(t *mavlink.TelemetryData) { t.SatelliteCount = 5 }, "5 satellites (need 6)"},
+		{"battery too low", func(t *mavlink.TelemetryData) { t.BatteryRemaining = 19 }, "battery at 19% (need 20%)"},
+		{"battery level unknown", func(t *mavlink.TelemetryData) { t.BatteryRemaining = -1 }, ""},
+		{"unhealthy sensors", func(t *mavlink.TelemetryData) { t.SensorsHealthy = false }, "sensors report unhealthy"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			telemetry := healthy
+			tt.change(&telemetry)
+			err := checkHealth(&cfg, telemetry)
+			switch {
+			case tt.reason == "" && err != nil:
+				t.Errorf("unexpected error: %v", err)
+			case tt.reason != "" && (err == nil || !strings.Contains(err.Error(), tt.reason)):
+				t.Errorf("error = %v, want %q", err, tt.reason)
+			}
+		})
+	}
+}
+
+func TestCommandPolicyHealthGating(t *testing.T) {
+	cfg := config.Default()
+	for _, command := range config.HealthGatableCommands {
+		if commandPolicy(cfg, command) != policyConnected {
+			t.Errorf("%s health gated by default", command)
+		}
+	}
+
+	cfg.MAVLink.HealthGatedCommands = []string{"takeoff"}
+	if commandPolicy(cfg, "takeoff") != policyHealthy {
+		t.Error("configured takeoff not health gated")
+	}
+	if commandPolicy(cfg, "arm") != policyConnected {
+		t.Error("arm health gated without being configured")
+	}
+}
+
+func TestCheckTakeoffGPS(t *testing.T) {
+	// Defaults: a 3D fix, at least 6 satellites and HDOP up to 2.5
+	cfg := config.Default().MAVLink
+	good := mavlink.TelemetryData{GPSFixType: common.GPS_FIX_TYPE_3D_FIX, SatelliteCount: 6, GPSHDOP: 2.5}
+
+	tests := []struct {
+		name   string
+		change func(*mavlink.TelemetryData)
+		reason string // in the error; empty when takeoff may go ahead
+	}{
+		{"at the thresholds", func(*mavlink.TelemetryData) {}, ""},
+		{"RTK fix", func(t *mavlink.TelemetryData) { t.GPSFixType = common.GPS_FIX_TYPE_RTK_FIXED }, ""},
+		{"2D fix", func(t *mavlink.TelemetryData) { t.GPSFixType = common.GPS_FIX_TYPE_2D_FIX }, "no 3D fix (2D_FIX)"},
+		{"no GPS", func(t *mavlink.TelemetryData) { t.GPSFixType = common.GPS_FIX_TYPE_NO_GPS }, "no 3D fix (NO_GPS)"},
+		{"too few satellites", func(t *mavlink.TelemetryData) { t.SatelliteCount = 5 }, "5 satellites (need 6)"},
+		{"HDOP too high", func(t *mavlink.TelemetryData) { t.GPSHDOP = 3.1 }, "HDOP is 3.1 (need at most 2.5)"},
+		{"HDOP unknown", func(t *mavlink.TelemetryData) { t.GPSHDOP = 0 }, "doesn't report HDOP"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			telemetry := good
+			tt.change(&telemetry)
+			err := checkTakeoffGPS(&cfg, telemetry)
+			switch {
+			case tt.reason == "" && err != nil:
+				t.Errorf("unexpected error: %v", err)
+			case tt.reason != "" && (err == nil || !strings.Contains(err.Error(), tt.reason)):
+				t.Errorf("error = %v, want %q", err, tt.reason)
+			}
+		})
+	}
+}
@@ -0,0 +1,51 @@
+package services
+
+import (
+	"fmt"
+	"time"
+
+	"connectrpc.com/connect"
+)
+
+// Bounds for client-requested stream rates
+const (
+	maxStreamRateHz   = 50
+	minStreamInterval = 20 * time.Millisecond
+	maxStreamInterval = time.Minute
+)
+
+// streamIntervalFromRate converts a requested rate to a tick interval
+// 0 means the default of 1 Hz; out-of-range rates are rejected.
+func streamIntervalFromRate(rateHz int32) (time.Duration, error) {
+	if rateHz < 0 || rateHz > maxStreamRateHz {
+		return 0, connect.NewError(connect.CodeInvalidArgument,
+			fmt.Errorf("rate_hz must be between 0 and %d: %d", maxStreamRateHz, rateHz))
+	}
+	if rateHz == 0 {
+		return time.Second, nil
+	}
+	return time.Second / time.Duration(rateHz), nil
+}
+
+// streamIntervalFromMs validates a requested tick interval in milliseconds
+// 0 means defaultInterval; out-of-range intervals are rejected.
+func streamIntervalFromMs(intervalMs int32, defaultInterval time.Duration) (time.Duration, error) {
+	if intervalMs == 0 {
+		return defaultInterval, nil
+	}
+
+	interval := time.Duration(intervalMs) * time.Millisecond
+	if interval < minStreamInterval || interval > maxStreamInterval {
+		return 0, connect.NewError(connect.CodeInvalidArgument,
+			fmt.Errorf("interval_ms must be 0 or between %d and %d: %d",
+				minStreamInterval.Milliseconds(), maxStreamInterval.Milliseconds(), intervalMs))
+	}
+	return interval, nil
+}
+
+// streamSender is the send side of a server stream
+// *connect.ServerStream satisfies it, which lets streaming methods without a
+// proto definition yet be served by other transports.
+type streamSender[T any] interface {
+	Send(*T) error
+}
@@ -0,0 +1,38 @@
+package services
+
+import (
+	"testing"
+	"time"
+
+	"connectrpc.com/connect"
+)
+
+func TestStreamIntervalFromRate(t *testing.T) {
+	for _, tt := range []struct {
+		rateHz int32
+		want   time.Duration
+	}{{0, time.Second}, {1, time.Second}, {10, 100 * time.Millisecond}, {50, 20 * time.Millisecond}} {
+		if got, err := streamIntervalFromRate(tt.rateHz); err != nil || got != tt.want {
+			t.Errorf("streamIntervalFromRate(%d) = %s, %v, want %s", tt.rateHz, got, err, tt.want)
+		}
+	}
+	for _, rateHz := range []int32{-1, 51, 1000} {
+		if _, err := streamIntervalFromRate(rateHz); connect.CodeOf(err) != connect.CodeInvalidArgument {
+			t.Errorf("streamIntervalFromRate(%d): %v, want invalid_argument", rateHz, err)
+		}
+	}
+}
+
+func TestStreamIntervalFromMs(t *testing.T) {
+	if got, err := streamIntervalFromMs(0, 3*time.Second); err != nil || got != 3*time.Second {
+		t.Errorf("default interval = %s, %v", got, err)
+	}
+	if got, err := streamIntervalFromMs(250, time.Second); err != nil || got != 250*time.Millisecond {
+		t.Errorf("250 ms interval = %s, %v", got, err)
+	}
+	for _, ms := range []int32{-100, 5, 60001} {
+		if _, err := streamIntervalFromMs(ms, time.Second); connect.CodeOf(err) != connect.CodeInvalidArgument {
+			t.Errorf("streamIntervalFromMs(%d): %v, want invalid_argument", ms, err)
+		}
+	}
+}
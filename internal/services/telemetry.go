@@ -1,15 +1,19 @@
 package services
 
 import (
+	"bytes"
 	"context"
 	"fmt"
+	"net/http"
 	"time"
 
 	"connectrpc.com/connect"
 
 	drone "github.com/flightpath-dev/flightpath-proto/gen/go/drone/v1"
+	"github.com/flightpath-dev/flightpath-server/internal/config"
 	"github.com/flightpath-dev/flightpath-server/internal/mavlink"
 	"github.com/flightpath-dev/flightpath-server/internal/server"
+	"github.com/flightpath-dev/flightpath-server/internal/track"
 )
 
 // TelemetryServer implements the TelemetryService
@@ -25,6 +29,10 @@ func NewTelemetryServer(deps *server.Dependencies) *TelemetryServer {
 }
 
 // StreamTelemetry streams real-time telemetry data
+// Units and velocity frame come from the Flightpath-Units and
+// Flightpath-Velocity-Frame headers (default metric, NED). Every tick repeats
+// the latest values, also during a telemetry gap; StreamTelemetryFrames marks
+// those as stale instead.
 func (s *TelemetryServer) StreamTelemetry(
 	ctx context.Context,
 	req *connect.Request[drone.StreamTelemetryRequest],
@@ -33,18 +41,21 @@ func (s *TelemetryServer) StreamTelemetry(
 	logger := s.deps.GetLogger()
 	logger.Printf("StreamTelemetry request: rate_hz=%d", req.Msg.RateHz)
 
-	// Check if MAVLink client exists
-	if !s.deps.HasMAVLinkClient() {
-		return connect.NewError(connect.CodeFailedPrecondition,
-			fmt.Errorf("not connected to drone"))
+	client, err := requireReadyClient(ctx, s.deps, "", policyClient)
+	if err != nil {
+		return err
 	}
-
-	client := s.deps.GetMAVLinkClient()
+	defer s.deps.HoldMAVLinkClient(client)()
 
 	// Calculate interval from rate
-	interval := time.Second
-	if req.Msg.RateHz > 0 {
-		interval = time.Second / time.Duration(req.Msg.RateHz)
+	interval, err := streamIntervalFromRate(req.Msg.RateHz)
+	if err != nil {
+		return err
+	}
+
+	output, err := telemetryOutputFromHeader(req.Header())
+	if err != nil {
+		return connect.NewError(connect.CodeInvalidArgument, err)
 	}
 
 	ticker := time.NewTicker(interval)
@@ -57,57 +68,8 @@ func (s *TelemetryServer) StreamTelemetry(
 			return nil
 
 		case <-ticker.C:
-			// Get telemetry from MAVLink client
-			telemetry := client.GetTelemetry()
-
-			response := &drone.StreamTelemetryResponse{
-				TimestampMs: time.Now().UnixMilli(),
-
-				// Position
-				Position: &drone.Position{
-					Latitude:  telemetry.Latitude,
-					Longitude: telemetry.Longitude,
-					Altitude:  telemetry.Altitude,
-				},
-
-				// Velocity
-				Velocity: &drone.Velocity{
-					X: telemetry.VelocityX,
-					Y: telemetry.VelocityY,
-					Z: telemetry.VelocityZ,
-				},
-
-				// Attitude
-				Attitude: &drone.Attitude{
-					Roll:  telemetry.Roll,
-					Pitch: telemetry.Pitch,
-					Yaw:   telemetry.Yaw,
-				},
-
-				// Battery
-				Battery: &drone.BatteryStatus{
-					Voltage:   telemetry.BatteryVoltage,
-					Current:   telemetry.BatteryCurrent,
-					Remaining: telemetry.BatteryRemaining,
-				},
-
-				// Health
-				Health: &drone.SystemHealth{
-					SensorsOk: telemetry.SensorsHealthy,
-					GpsOk:     telemetry.SatelliteCount >= 6,
-				},
-
-				// Status
-				Armed:         client.IsArmed(),
-				Mode:          s.mapPX4ModeToFlightMode(telemetry.CustomMode),
-				Heading:       telemetry.Heading,
-				GroundSpeed:   telemetry.GroundSpeed,
-				VerticalSpeed: telemetry.VerticalSpeed,
-
-				// GPS
-				GpsAccuracy:    telemetry.GPSAccuracy,
-				SatelliteCount: telemetry.SatelliteCount,
-			}
+			response := s.buildStreamResponse(client.TelemetrySnapshot())
+			output.apply(response)
 
 			if err := stream.Send(response); err != nil {
 				logger.Printf("StreamTelemetry: Error sending: %v", err)
@@ -117,6 +79,140 @@ func (s *TelemetryServer) StreamTelemetry(
 	}
 }
 
+// TelemetryFrame is one item of StreamTelemetryFrames
+// Stale frames are keepalives sent while no new telemetry has arrived; they
+// carry no telemetry.
+type TelemetryFrame struct {
+	TimestampMs int64                          `json:"timestamp_ms"`
+	Stale       bool                           `json:"stale"`
+	Telemetry   *drone.StreamTelemetryResponse `json:"telemetry,omitempty"`
+
+	// Telemetry has a position (see mavlink.TelemetryData.PositionValid)
+	PositionValid bool `json:"position_valid"`
+
+	// Per-battery detail from BATTERY_STATUS, when the vehicle sends it
+	Batteries []mavlink.BatteryInfo `json:"batteries,omitempty"`
+}
+
+// StreamTelemetryFrames streams telemetry like StreamTelemetry, but only when
+// new telemetry arrived since the last frame
+// During a gap a stale keepalive frame goes out every stream keepalive interval,
+// so proxies don't close the idle stream and clients can tell stale data from
+// a lost connection. header carries the StreamTelemetry output options. An empty
+// droneID means the active drone.
+func (s *TelemetryServer) StreamTelemetryFrames(
+	ctx context.Context,
+	droneID string,
+	rateHz int32,
+	header http.Header,
+	stream streamSender[TelemetryFrame],
+) error {
+	logger := s.deps.GetLogger()
+	logger.Printf("StreamTelemetryFrames request: drone_id=%s, rate_hz=%d", droneID, rateHz)
+
+	client, err := requireReadyClient(ctx, s.deps, droneID, policyClient)
+	if err != nil {
+		return err
+	}
+	defer s.deps.HoldMAVLinkClient(client)()
+
+	interval, err := streamIntervalFromRate(rateHz)
+	if err != nil {
+		return err
+	}
+
+	output, err := telemetryOutputFromHeader(header)
+	if err != nil {
+		return connect.NewError(connect.CodeInvalidArgument, err)
+	}
+
+	keepalive := s.deps.Config.Server.StreamKeepalive
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	var lastUpdate, lastSent time.Time
+	for {
+		select {
+		case <-ctx.Done():
+			logger.Println("StreamTelemetryFrames: Client disconnected")
+			return nil
+
+		case now := <-ticker.C:
+			telemetry := client.TelemetrySnapshot()
+
+			frame := TelemetryFrame{TimestampMs: now.UnixMilli()}
+			switch {
+			case telemetry.LastUpdate.After(lastUpdate):
+				lastUpdate = telemetry.LastUpdate
+				frame.Telemetry = s.buildStreamResponse(telemetry)
+				frame.PositionValid = telemetry.PositionValid()
+				frame.Batteries = telemetry.Batteries
+				output.apply(frame.Telemetry)
+			case now.Sub(lastSent) >= keepalive:
+				frame.Stale = true
+			default:
+				continue
+			}
+
+			if err := stream.Send(&frame); err != nil {
+				logger.Printf("StreamTelemetryFrames: Error sending: %v", err)
+				return err
+			}
+			lastSent = now
+		}
+	}
+}
+
+// buildStreamResponse converts telemetry to a StreamTelemetry response (SI/NED)
+// It reads only the snapshot, so stream ticks take no client lock.
+func (s *TelemetryServer) buildStreamResponse(telemetry *mavlink.TelemetryData) *drone.StreamTelemetryResponse {
+	return &drone.StreamTelemetryResponse{
+		TimestampMs: time.Now().UnixMilli(),
+
+		// Position (nil until valid, so it is never plotted at 0, 0)
+		Position: positionOf(telemetry),
+
+		// Velocity
+		Velocity: &drone.Velocity{
+			X: telemetry.VelocityX,
+			Y: telemetry.VelocityY,
+			Z: telemetry.VelocityZ,
+		},
+
+		// Attitude
+		Attitude: &drone.Attitude{
+			Roll:  telemetry.Roll,
+			Pitch: telemetry.Pitch,
+			Yaw:   telemetry.Yaw,
+		},
+
+		// Battery
+		Battery: &drone.BatteryStatus{
+			Voltage:   telemetry.BatteryVoltage,
+			Current:   telemetry.BatteryCurrent,
+			Remaining: telemetry.BatteryRemaining,
+		},
+
+		// Health
+		Health: &drone.SystemHealth{
+			SensorsOk: telemetry.SensorsHealthy,
+			GpsOk:     telemetry.SatelliteCount >= 6,
+		},
+
+		// Status
+		Armed:         telemetry.Armed(),
+		Mode:          s.mapPX4ModeToFlightMode(telemetry.CustomMode),
+		Heading:       telemetry.Heading,
+		GroundSpeed:   telemetry.GroundSpeed,
+		VerticalSpeed: telemetry.VerticalSpeed,
+
+		// GPS
+		GpsAccuracy:    telemetry.GPSAccuracy,
+		SatelliteCount: telemetry.SatelliteCount,
+	}
+}
+
 // GetSnapshot returns current telemetry snapshot
 func (s *TelemetryServer) GetSnapshot(
 	ctx context.Context,
@@ -125,24 +221,323 @@ func (s *TelemetryServer) GetSnapshot(
 	logger := s.deps.GetLogger()
 	logger.Println("GetSnapshot request")
 
-	// Check if MAVLink client exists
-	if !s.deps.HasMAVLinkClient() {
-		return nil, connect.NewError(connect.CodeFailedPrecondition,
-			fmt.Errorf("not connected to drone"))
+	client, err := requireReadyClient(ctx, s.deps, "", policyClient)
+	if err != nil {
+		return nil, err
+	}
+
+	return connect.NewResponse(s.buildSnapshot(client)), nil
+}
+
+// DroneSnapshot is a telemetry snapshot tagged with the drone it belongs to
+type DroneSnapshot struct {
+	DroneID   string                     `json:"drone_id"`
+	Connected bool                       `json:"connected"`
+	Snapshot  *drone.GetSnapshotResponse `json:"snapshot,omitempty"`
+
+	// The snapshot has a position; without one (no fix yet) it is left out
+	PositionValid bool `json:"position_valid"`
+
+	// Custom metrics from NAMED_VALUE_FLOAT/INT, keyed by name
+	NamedValues map[string]mavlink.NamedValue `json:"named_values,omitempty"`
+
+	// Overall readiness (also for drones whose link is down)
+	Readiness *Readiness `json:"readiness,omitempty"`
+
+	// Whether the battery lasts for a return to launch, with the margin
+	RTL *mavlink.RTLEstimate `json:"rtl,omitempty"`
+
+	// Per-battery detail from BATTERY_STATUS, when the vehicle sends it
+	Batteries []mavlink.BatteryInfo `json:"batteries,omitempty"`
+}
+
+// GetSnapshotAll returns telemetry snapshots for every drone with a MAVLink client
+// Drones whose link is down are included with Connected=false and no snapshot.
+// Each client is read under its own lock; no shared lock is held across the loop.
+func (s *TelemetryServer) GetSnapshotAll(ctx context.Context) []*DroneSnapshot {
+	logger := s.deps.GetLogger()
+	logger.Println("GetSnapshotAll request")
+
+	droneIDs := s.deps.GetMAVLinkDroneIDs()
+	snapshots := make([]*DroneSnapshot, 0, len(droneIDs))
+
+	for _, droneID := range droneIDs {
+		client, ok := s.deps.GetMAVLinkClientFor(droneID)
+		if !ok {
+			// Disconnected since the ID list was taken
+			continue
+		}
+		s.deps.MarkMAVLinkClientUsed(client)
+
+		readiness := readinessOf(&s.deps.Config.MAVLink, client)
+
+		if !client.IsConnected() {
+			snapshots = append(snapshots, &DroneSnapshot{
+				DroneID:   droneID,
+				Connected: false,
+				Readiness: &readiness,
+			})
+			continue
+		}
+
+		rtl := client.EstimateRTL(rtlAssumptions(&s.deps.Config.MAVLink))
+		telemetry := client.TelemetrySnapshot()
+		snapshots = append(snapshots, &DroneSnapshot{
+			DroneID:     droneID,
+			Connected:   true,
+			Snapshot:    s.buildSnapshot(client),
+			NamedValues: client.GetNamedValues(),
+			Readiness:   &readiness,
+
+			PositionValid: telemetry.PositionValid(),
+			RTL:           &rtl,
+			Batteries:     telemetry.Batteries,
+		})
+	}
+
+	return snapshots
+}
+
+// GetNamedValues returns a drone's latest NAMED_VALUE_FLOAT/INT values keyed by name
+// An empty droneID means the active drone.
+func (s *TelemetryServer) GetNamedValues(ctx context.Context, droneID string) (map[string]mavlink.NamedValue, error) {
+	s.deps.GetLogger().Printf("GetNamedValues request: drone_id=%s", droneID)
+
+	client, err := requireReadyClient(ctx, s.deps, droneID, policyClient)
+	if err != nil {
+		return nil, err
+	}
+	return client.GetNamedValues(), nil
+}
+
+// GetRTLEstimate returns whether a drone's battery lasts for a return to launch
+// An empty droneID means the active drone.
+func (s *TelemetryServer) GetRTLEstimate(ctx context.Context, droneID string) (*mavlink.RTLEstimate, error) {
+	s.deps.GetLogger().Printf("GetRTLEstimate request: drone_id=%s", droneID)
+
+	client, err := requireReadyClient(ctx, s.deps, droneID, policyClient)
+	if err != nil {
+		return nil, err
+	}
+	rtl := client.EstimateRTL(rtlAssumptions(&s.deps.Config.MAVLink))
+	return &rtl, nil
+}
+
+// GetBatteries returns a drone's batteries as reported by BATTERY_STATUS, by
+// ID; empty when the vehicle only sends SYS_STATUS
+// An empty droneID means the active drone.
+func (s *TelemetryServer) GetBatteries(ctx context.Context, droneID string) ([]mavlink.BatteryInfo, error) {
+	s.deps.GetLogger().Printf("GetBatteries request: drone_id=%s", droneID)
+
+	client, err := requireReadyClient(ctx, s.deps, droneID, policyClient)
+	if err != nil {
+		return nil, err
+	}
+	batteries := client.TelemetrySnapshot().Batteries
+	if batteries == nil {
+		batteries = []mavlink.BatteryInfo{}
+	}
+	return batteries, nil
+}
+
+// GetTrackGPX returns a drone's flight path since it last armed (or since
+// connecting, before it first arms) as a GPX file
+// Only fixes with a valid position are recorded, at most one per second. An
+// empty droneID means the active drone.
+func (s *TelemetryServer) GetTrackGPX(ctx context.Context, droneID string) ([]byte, error) {
+	s.deps.GetLogger().Printf("GetTrackGPX request: drone_id=%s", droneID)
+
+	droneID = targetDrone(ctx, s.deps, droneID)
+	client, err := requireReadyClient(ctx, s.deps, droneID, policyClient)
+	if err != nil {
+		return nil, err
+	}
+
+	var buf bytes.Buffer
+	if err := track.WriteGPX(&buf, droneID, client.Track()); err != nil {
+		return nil, connect.NewError(connect.CodeInternal, err)
+	}
+	return buf.Bytes(), nil
+}
+
+// GetTerrainStatus reports terrain serving for a drone: TERRAIN_REQUESTs
+// received, blocks sent or missing from the terrain data, blocks of the latest
+// request still outstanding and the vehicle's last TERRAIN_REPORT
+// An empty droneID means the active drone.
+func (s *TelemetryServer) GetTerrainStatus(ctx context.Context, droneID string) (*mavlink.TerrainStatus, error) {
+	s.deps.GetLogger().Printf("GetTerrainStatus request: drone_id=%s", droneID)
+
+	client, err := requireReadyClient(ctx, s.deps, droneID, policyClient)
+	if err != nil {
+		return nil, err
+	}
+	status := client.TerrainStatus()
+	return &status, nil
+}
+
+// rtlAssumptions returns the configured figures behind RTL estimates
+func rtlAssumptions(cfg *config.MAVLinkConfig) mavlink.RTLAssumptions {
+	return mavlink.RTLAssumptions{
+		CruiseSpeed:    cfg.RTLCruiseSpeed,
+		DescentSpeed:   cfg.RTLDescentSpeed,
+		ReservePercent: float64(cfg.RTLReservePercent),
 	}
+}
+
+// StreamNamedValues streams named value updates as they arrive
+// names optionally limits the stream to specific names (case-sensitive, as sent).
+// An empty droneID means the active drone.
+func (s *TelemetryServer) StreamNamedValues(
+	ctx context.Context,
+	droneID string,
+	names []string,
+	stream streamSender[mavlink.NamedValue],
+) error {
+	logger := s.deps.GetLogger()
+	logger.Printf("StreamNamedValues request: drone_id=%s, names=%v", droneID, names)
+
+	client, err := requireReadyClient(ctx, s.deps, droneID, policyClient)
+	if err != nil {
+		return err
+	}
+	defer s.deps.HoldMAVLinkClient(client)()
+
+	var filter map[string]bool
+	if len(names) > 0 {
+		filter = make(map[string]bool, len(names))
+		for _, name := range names {
+			filter[name] = true
+		}
+	}
+
+	updates, unsubscribe := client.SubscribeNamedValues()
+	defer unsubscribe()
 
-	client := s.deps.GetMAVLinkClient()
+	for {
+		select {
+		case <-ctx.Done():
+			logger.Println("StreamNamedValues: Client disconnected")
+			return nil
+
+		case v, ok := <-updates:
+			if !ok {
+				return connect.NewError(connect.CodeUnavailable,
+					fmt.Errorf("connection to drone closed"))
+			}
+			if filter != nil && !filter[v.Name] {
+				continue
+			}
+
+			if err := stream.Send(&v); err != nil {
+				logger.Printf("StreamNamedValues: Error sending: %v", err)
+				return err
+			}
+		}
+	}
+}
+
+// StreamIMU streams raw IMU samples for sensor diagnostics
+// HIGHRES_IMU and SCALED_IMU are requested at rateHz (0 uses
+// MAVLinkConfig.IMURate) while the stream runs. An empty droneID means the
+// active drone.
+func (s *TelemetryServer) StreamIMU(
+	ctx context.Context,
+	droneID string,
+	rateHz float64,
+	stream streamSender[mavlink.IMUSample],
+) error {
+	logger := s.deps.GetLogger()
+	logger.Printf("StreamIMU request: drone_id=%s, rate=%gHz", droneID, rateHz)
+
+	if rateHz == 0 {
+		rateHz = s.deps.Config.MAVLink.IMURate
+	}
+	if rateHz < 0 || rateHz > config.MaxIMURate {
+		return connect.NewError(connect.CodeInvalidArgument,
+			fmt.Errorf("invalid IMU rate: %g Hz (must be above 0, at most %d)", rateHz, config.MaxIMURate))
+	}
+
+	client, err := requireReadyClient(ctx, s.deps, droneID, policyConnected)
+	if err != nil {
+		return err
+	}
+	defer s.deps.HoldMAVLinkClient(client)()
+
+	samples, unsubscribe, err := client.SubscribeIMU(rateHz)
+	if err != nil {
+		return connect.NewError(connect.CodeUnavailable, err)
+	}
+	defer unsubscribe()
+
+	for {
+		select {
+		case <-ctx.Done():
+			logger.Println("StreamIMU: Client disconnected")
+			return nil
+
+		case sample, ok := <-samples:
+			if !ok {
+				return connect.NewError(connect.CodeUnavailable,
+					fmt.Errorf("connection to drone closed"))
+			}
+
+			if err := stream.Send(&sample); err != nil {
+				logger.Printf("StreamIMU: Error sending: %v", err)
+				return err
+			}
+		}
+	}
+}
+
+// SetTelemetryProfile switches a drone to a named telemetry profile at runtime
+// An empty droneID means the active drone.
+func (s *TelemetryServer) SetTelemetryProfile(ctx context.Context, droneID, profileName string) (err error) {
+	defer func() {
+		params := map[string]any{"profile": profileName}
+		recordAudit(ctx, s.deps, "set_telemetry_profile", droneID, params, nil, err)
+	}()
+
+	logger := s.deps.GetLogger()
+	logger.Printf("SetTelemetryProfile request: drone_id=%s, profile=%s", droneID, profileName)
+
+	profile, ok := s.deps.Config.MAVLink.TelemetryProfiles[profileName]
+	if !ok {
+		return connect.NewError(connect.CodeInvalidArgument,
+			fmt.Errorf("unknown telemetry profile: %s", profileName))
+	}
+
+	client, err := requireReadyClient(ctx, s.deps, droneID, policyConnected)
+	if err != nil {
+		return err
+	}
+
+	if err := client.SetMessageRates(profile); err != nil {
+		return connect.NewError(connect.CodeInternal, err)
+	}
+
+	logger.Printf("Telemetry profile %s applied to %s", profileName, droneID)
+	return nil
+}
+
+// buildSnapshot builds a telemetry snapshot from a MAVLink client's current state
+func (s *TelemetryServer) buildSnapshot(client *mavlink.Client) *drone.GetSnapshotResponse {
 	telemetry := client.GetTelemetry()
 
-	snapshot := &drone.GetSnapshotResponse{
+	// Home position (nil until a usable HOME_POSITION is received)
+	var homePosition *drone.Position
+	if home, ok := client.GetHomePosition(); ok {
+		homePosition = &drone.Position{
+			Latitude:  home.Latitude,
+			Longitude: home.Longitude,
+			Altitude:  home.Altitude,
+		}
+	}
+
+	return &drone.GetSnapshotResponse{
 		TimestampMs: time.Now().UnixMilli(),
 
-		// Position
-		Position: &drone.Position{
-			Latitude:  telemetry.Latitude,
-			Longitude: telemetry.Longitude,
-			Altitude:  telemetry.Altitude,
-		},
+		// Position (nil until valid, so it is never plotted at 0, 0)
+		Position: positionOf(&telemetry),
 
 		// Velocity
 		Velocity: &drone.Velocity{
@@ -175,12 +570,7 @@ func (s *TelemetryServer) GetSnapshot(
 		Armed: client.IsArmed(),
 		Mode:  s.mapPX4ModeToFlightMode(telemetry.CustomMode),
 
-		// Home position (will be zero until mission planning tracks it)
-		HomePosition: &drone.Position{
-			Latitude:  0,
-			Longitude: 0,
-			Altitude:  0,
-		},
+		HomePosition: homePosition,
 
 		// Capabilities
 		Capabilities: &drone.Capabilities{
@@ -191,8 +581,18 @@ func (s *TelemetryServer) GetSnapshot(
 			CanReturnHome: true,
 		},
 	}
+}
 
-	return connect.NewResponse(snapshot), nil
+// positionOf returns the telemetry position, or nil while it isn't valid
+func positionOf(telemetry *mavlink.TelemetryData) *drone.Position {
+	if !telemetry.PositionValid() {
+		return nil
+	}
+	return &drone.Position{
+		Latitude:  telemetry.Latitude,
+		Longitude: telemetry.Longitude,
+		Altitude:  telemetry.Altitude,
+	}
 }
 
 // mapPX4ModeToFlightMode maps PX4 custom mode back to generic FlightMode
@@ -0,0 +1,75 @@
+package services
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+
+	drone "github.com/flightpath-dev/flightpath-proto/gen/go/drone/v1"
+)
+
+// StreamTelemetryRequest has no fields for output options yet, so they are
+// read from request headers
+const (
+	UnitsHeader         = "Flightpath-Units"          // metric (default), imperial
+	VelocityFrameHeader = "Flightpath-Velocity-Frame" // ned (default), enu
+)
+
+// metersToFeet converts lengths and speeds (m, m/s) to imperial (ft, ft/s)
+const metersToFeet = 3.28084
+
+// telemetryOutput is how a telemetry stream presents values
+// Stored telemetry stays SI/NED; conversion happens per response.
+type telemetryOutput struct {
+	imperial bool
+	enu      bool
+}
+
+// telemetryOutputFromHeader parses the output option headers (empty = metric, NED)
+func telemetryOutputFromHeader(header http.Header) (telemetryOutput, error) {
+	var out telemetryOutput
+
+	switch units := strings.ToLower(header.Get(UnitsHeader)); units {
+	case "", "metric":
+	case "imperial":
+		out.imperial = true
+	default:
+		return out, fmt.Errorf("invalid %s: %q (want metric or imperial)", UnitsHeader, units)
+	}
+
+	switch frame := strings.ToLower(header.Get(VelocityFrameHeader)); frame {
+	case "", "ned":
+	case "enu":
+		out.enu = true
+	default:
+		return out, fmt.Errorf("invalid %s: %q (want ned or enu)", VelocityFrameHeader, frame)
+	}
+
+	return out, nil
+}
+
+// apply converts a response built from SI/NED telemetry in place
+// Imperial converts altitude, velocity, ground/vertical speed and GPS accuracy
+// from meters to feet. ENU reorders velocity to east, north, up. Angles,
+// coordinates and battery values are unchanged.
+func (o telemetryOutput) apply(resp *drone.StreamTelemetryResponse) {
+	if o.enu && resp.Velocity != nil {
+		v := resp.Velocity
+		v.X, v.Y, v.Z = v.Y, v.X, -v.Z
+	}
+
+	if !o.imperial {
+		return
+	}
+	if resp.Position != nil {
+		resp.Position.Altitude *= metersToFeet
+	}
+	if resp.Velocity != nil {
+		resp.Velocity.X *= metersToFeet
+		resp.Velocity.Y *= metersToFeet
+		resp.Velocity.Z *= metersToFeet
+	}
+	resp.GroundSpeed *= metersToFeet
+	resp.VerticalSpeed *= metersToFeet
+	resp.GpsAccuracy *= metersToFeet
+}
@@ -0,0 +1,95 @@
+package services
+
+import (
+	"math"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/flightpath-dev/flightpath-server/internal/mavlink"
+)
+
+func TestTelemetryOutputFromHeader(t *testing.T) {
+	tests := []struct {
+		units, frame string
+		want         telemetryOutput
+	}{
+		{"", "", telemetryOutput{}},
+		{"metric", "ned", telemetryOutput{}},
+		{"Imperial", "ENU", telemetryOutput{imperial: true, enu: true}},
+	}
+	for _, tt := range tests {
+		header := http.Header{}
+		header.Set(UnitsHeader, tt.units)
+		header.Set(VelocityFrameHeader, tt.frame)
+		if got, err := telemetryOutputFromHeader(header); err != nil || got != tt.want {
+			t.Errorf("units %q, frame %q: %+v, %v", tt.units, tt.frame, got, err)
+		}
+	}
+
+	for _, header := range []http.Header{{UnitsHeader: {"furlongs"}}, {VelocityFrameHeader: {"body"}}} {
+		if _, err := telemetryOutputFromHeader(header); err == nil {
+			t.Errorf("%v accepted", header)
+		}
+	}
+}
+
+func TestTelemetryOutputImperial(t *testing.T) {
+	s := NewTelemetryServer(newTestDependencies(t))
+	telemetry := mavlink.TelemetryData{
+		Latitude: 47.3977, Longitude: 8.5456, Altitude: 100, PositionUpdated: time.Now(),
+		VelocityX: 2, VelocityY: -1, VelocityZ: 0.5,
+		Roll: 0.1, Heading: 90,
+		GroundSpeed: 10, VerticalSpeed: -0.5, GPSAccuracy: 1.5,
+		BatteryVoltage: 16.2, BatteryRemaining: 80,
+	}
+
+	metric := s.buildStreamResponse(&telemetry)
+	telemetryOutput{}.apply(metric)
+	imperial := s.buildStreamResponse(&telemetry)
+	telemetryOutput{imperial: true}.apply(imperial)
+
+	feet := func(name string, m, ft float64) {
+		t.Helper()
+		if math.Abs(ft-m*metersToFeet) > 1e-9 {
+			t.Errorf("%s: %v ft for %v m", name, ft, m)
+		}
+	}
+	feet("altitude", metric.Position.Altitude, imperial.Position.Altitude)
+	feet("velocity x", metric.Velocity.X, imperial.Velocity.X)
+	feet("velocity y", metric.Velocity.Y, imperial.Velocity.Y)
+	feet("velocity z", metric.Velocity.Z, imperial.Velocity.Z)
+	feet("ground speed", metric.GroundSpeed, imperial.GroundSpeed)
+	feet("vertical speed", metric.VerticalSpeed, imperial.VerticalSpeed)
+	feet("GPS accuracy", metric.GpsAccuracy, imperial.GpsAccuracy)
+	if imperial.Position.Altitude != 328.084 {
+		t.Errorf("100 m = %v ft", imperial.Position.Altitude)
+	}
+
+	// Metric output is the stored SI value; angles, coordinates and battery never convert
+	if metric.Position.Altitude != 100 || metric.GroundSpeed != 10 {
+		t.Errorf("metric output converted: %+v", metric)
+	}
+	if imperial.Position.Latitude != 47.3977 || imperial.Heading != 90 || imperial.Attitude.Roll != 0.1 ||
+		imperial.Battery.Voltage != 16.2 || imperial.Battery.Remaining != 80 {
+		t.Errorf("imperial output converted non-length fields: %+v", imperial)
+	}
+}
+
+func TestTelemetryOutputENU(t *testing.T) {
+	s := NewTelemetryServer(newTestDependencies(t))
+	// 2 m/s north, 1 m/s west, descending at 0.5 m/s
+	telemetry := mavlink.TelemetryData{VelocityX: 2, VelocityY: -1, VelocityZ: 0.5}
+
+	resp := s.buildStreamResponse(&telemetry)
+	telemetryOutput{enu: true}.apply(resp)
+	if v := resp.Velocity; v.X != -1 || v.Y != 2 || v.Z != -0.5 {
+		t.Errorf("ENU velocity = %+v, want -1, 2, -0.5", v)
+	}
+	// No position yet: converting must not touch it
+	resp = s.buildStreamResponse(&telemetry)
+	telemetryOutput{imperial: true, enu: true}.apply(resp)
+	if resp.Position != nil {
+		t.Errorf("position = %+v before a fix", resp.Position)
+	}
+}
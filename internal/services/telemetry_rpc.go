@@ -0,0 +1,79 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+
+	"connectrpc.com/connect"
+)
+
+// Telemetry RPCs the generated TelemetryService doesn't have yet
+// They are served under the service's own path, so Connect clients call them
+// like the generated ones, but their messages are Go types encoded as JSON:
+// call them with the Connect protocol and Content-Type application/json.
+const (
+	TelemetryGetSnapshotAllProcedure = "/drone.v1.TelemetryService/GetSnapshotAll"
+	TelemetryGetReadinessProcedure   = "/drone.v1.TelemetryService/GetReadiness"
+)
+
+// jsonCodec encodes plain Go types with encoding/json, in place of Connect's
+// protojson codec (which only takes proto messages)
+type jsonCodec struct{}
+
+func (jsonCodec) Name() string { return "json" }
+
+func (jsonCodec) Marshal(message any) ([]byte, error) {
+	return json.Marshal(message)
+}
+
+func (jsonCodec) Unmarshal(data []byte, message any) error {
+	// Connect clients may send an empty body for an empty request
+	if len(data) == 0 {
+		return nil
+	}
+	return json.Unmarshal(data, message)
+}
+
+// GetSnapshotAllRequest is the (empty) GetSnapshotAll request
+type GetSnapshotAllRequest struct{}
+
+// GetSnapshotAllResponse holds one snapshot per drone with a MAVLink client
+type GetSnapshotAllResponse struct {
+	Snapshots []*DroneSnapshot `json:"snapshots"`
+}
+
+// GetReadinessRequest selects the drone; empty means the active drone
+type GetReadinessRequest struct {
+	DroneID string `json:"drone_id,omitempty"`
+}
+
+// NewTelemetryGetSnapshotAllHandler returns the GetSnapshotAll RPC and the
+// path to register it on
+func NewTelemetryGetSnapshotAllHandler(s *TelemetryServer, opts ...connect.HandlerOption) (string, http.Handler) {
+	opts = append(opts, connect.WithCodec(jsonCodec{}))
+	return TelemetryGetSnapshotAllProcedure, connect.NewUnaryHandler(
+		TelemetryGetSnapshotAllProcedure,
+		func(ctx context.Context, _ *connect.Request[GetSnapshotAllRequest]) (*connect.Response[GetSnapshotAllResponse], error) {
+			return connect.NewResponse(&GetSnapshotAllResponse{Snapshots: s.GetSnapshotAll(ctx)}), nil
+		},
+		opts...,
+	)
+}
+
+// NewTelemetryGetReadinessHandler returns the GetReadiness RPC and the path to
+// register it on
+func NewTelemetryGetReadinessHandler(s *TelemetryServer, opts ...connect.HandlerOption) (string, http.Handler) {
+	opts = append(opts, connect.WithCodec(jsonCodec{}))
+	return TelemetryGetReadinessProcedure, connect.NewUnaryHandler(
+		TelemetryGetReadinessProcedure,
+		func(ctx context.Context, req *connect.Request[GetReadinessRequest]) (*connect.Response[Readiness], error) {
+			readiness, err := s.GetReadiness(ctx, req.Msg.DroneID)
+			if err != nil {
+				return nil, err
+			}
+			return connect.NewResponse(readiness), nil
+		},
+		opts...,
+	)
+}
@@ -0,0 +1,221 @@
+package services
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"connectrpc.com/connect"
+	"github.com/bluenviron/gomavlib/v3/pkg/dialects/common"
+)
+
+func TestGetSnapshotAllRPC(t *testing.T) {
+	deps := newTestDependencies(t)
+	alpha, _ := startMockDrone(t, 1, 473977420, 85455940)
+	bravo, _ := startMockDrone(t, 2, 473980000, 85460000)
+	deps.AddMAVLinkClient("alpha", alpha)
+	deps.AddMAVLinkClient("bravo", bravo)
+
+	path, handler := NewTelemetryGetSnapshotAllHandler(NewTelemetryServer(deps))
+	ts := httptest.NewServer(handler)
+	defer ts.Close()
+	rpc := connect.NewClient[GetSnapshotAllRequest, GetSnapshotAllResponse](
+		ts.Client(), ts.URL+path, connect.WithCodec(jsonCodec{}),
+	)
+
+	// Wait for both drones' heartbeats and positions
+	want := map[string]float64{"alpha": 47.3977420, "bravo": 47.3980000}
+	var snapshots []*DroneSnapshot
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		resp, err := rpc.CallUnary(context.Background(), connect.NewRequest(&GetSnapshotAllRequest{}))
+		if err != nil {
+			t.Fatalf("GetSnapshotAll: %v", err)
+		}
+		snapshots = resp.Msg.Snapshots
+
+		ready := len(snapshots) == len(want)
+		for _, snap := range snapshots {
+			ready = ready && snap.Connected && snap.PositionValid
+		}
+		if ready {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("drones never reported a position: %d snapshots", len(snapshots))
+		}
+		time.Sleep(20 * time.Millisecond)
+	}
+
+	for _, snap := range snapshots {
+		lat, ok := want[snap.DroneID]
+		if !ok {
+			t.Errorf("unexpected drone %q", snap.DroneID)
+			continue
+		}
+		delete(want, snap.DroneID)
+
+		if snap.Snapshot == nil || snap.Snapshot.Position == nil {
+			t.Errorf("%s: no position in snapshot", snap.DroneID)
+			continue
+		}
+		if got := snap.Snapshot.Position.Latitude; got < lat-1e-6 || got > lat+1e-6 {
+			t.Errorf("%s: latitude = %v, want %v", snap.DroneID, got, lat)
+		}
+		if snap.Readiness == nil {
+			t.Errorf("%s: no readiness", snap.DroneID)
+		}
+	}
+}
+
+func TestGetReadinessRPC(t *testing.T) {
+	deps := newTestDependencies(t)
+	alpha, _ := startMockDrone(t, 1, 473977420, 85455940)
+	deps.AddMAVLinkClient("alpha", alpha)
+
+	path, handler := NewTelemetryGetReadinessHandler(NewTelemetryServer(deps))
+	ts := httptest.NewServer(handler)
+	defer ts.Close()
+	rpc := connect.NewClient[GetReadinessRequest, Readiness](
+		ts.Client(), ts.URL+path, connect.WithCodec(jsonCodec{}),
+	)
+
+	_, err := rpc.CallUnary(context.Background(), connect.NewRequest(&GetReadinessRequest{DroneID: "bravo"}))
+	if connect.CodeOf(err) != connect.CodeFailedPrecondition {
+		t.Errorf("unknown drone: err = %v, want FailedPrecondition", err)
+	}
+
+	// The mock drone reports no GPS, battery or sensor health
+	resp, err := rpc.CallUnary(context.Background(), connect.NewRequest(&GetReadinessRequest{DroneID: "alpha"}))
+	if err != nil {
+		t.Fatalf("GetReadiness: %v", err)
+	}
+	if resp.Msg.Level != ReadinessNotReady || len(resp.Msg.Reasons) == 0 {
+		t.Errorf("readiness = %+v, want NOT_READY with reasons", resp.Msg)
+	}
+}
+
+// frameRecorder is a telemetry frame stream that hands frames to the test
+type frameRecorder chan TelemetryFrame
+
+func (r frameRecorder) Send(frame *TelemetryFrame) error {
+	r <- *frame
+	return nil
+}
+
+func TestStreamTelemetryFramesKeepalive(t *testing.T) {
+	vehicle, device, heartbeats := silentDrone(t)
+	heartbeats()
+	client := staleClient(t, device)
+	if err := client.WaitForConnection(2 * time.Second); err != nil {
+		t.Fatal(err)
+	}
+
+	deps := newTestDependencies(t)
+	deps.Config.Server.StreamKeepalive = 100 * time.Millisecond
+	deps.AddMAVLinkClient("alpha", client)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	frames := make(frameRecorder, 100)
+	done := make(chan error, 1)
+	go func() {
+		done <- NewTelemetryServer(deps).StreamTelemetryFrames(ctx, "alpha", 50, http.Header{}, frames)
+	}()
+	t.Cleanup(func() {
+		cancel()
+		<-done
+	})
+
+	next := func() TelemetryFrame {
+		t.Helper()
+		select {
+		case frame := <-frames:
+			return frame
+		case <-time.After(2 * time.Second):
+			t.Fatal("no frame sent")
+			return TelemetryFrame{}
+		}
+	}
+	position := func(lat int32) *TelemetryFrame {
+		t.Helper()
+		vehicle.WriteMessageAll(&common.MessageGlobalPositionInt{Lat: lat, Lon: 85455940}) //nolint:errcheck
+		for {
+			if frame := next(); !frame.Stale {
+				return &frame
+			}
+		}
+	}
+
+	if frame := position(473977420); frame.Telemetry == nil || frame.Telemetry.Position.Latitude < 47.39774 {
+		t.Fatalf("fresh frame = %+v", frame)
+	}
+
+	// Heartbeats only: keepalives, one per interval rather than per tick
+	last := time.Now().UnixMilli()
+	for i := 0; i < 3; i++ {
+		frame := next()
+		if !frame.Stale || frame.Telemetry != nil {
+			t.Fatalf("frame %d during the gap = %+v", i, frame)
+		}
+		if gap := frame.TimestampMs - last; i > 0 && gap < 90 {
+			t.Errorf("keepalive %d after %d ms", i, gap)
+		}
+		last = frame.TimestampMs
+	}
+
+	// Telemetry is back
+	if frame := position(473980000); frame.Telemetry == nil || frame.Telemetry.Position.Latitude < 47.39799 {
+		t.Fatalf("frame after the gap = %+v", frame)
+	}
+}
+
+func TestSnapshotPositionBeforeFix(t *testing.T) {
+	vehicle, device, heartbeats := silentDrone(t)
+	heartbeats()
+	client := staleClient(t, device)
+	if err := client.WaitForConnection(2 * time.Second); err != nil {
+		t.Fatal(err)
+	}
+	deps := newTestDependencies(t)
+	deps.AddMAVLinkClient("alpha", client)
+	s := NewTelemetryServer(deps)
+
+	// sendFix sends what the vehicle reports with fix, waiting until it is handled
+	sendFix := func(fix common.GPS_FIX_TYPE, lat, lon int32) *DroneSnapshot {
+		t.Helper()
+		vehicle.WriteMessageAll(&common.MessageGpsRawInt{FixType: fix, SatellitesVisible: 10}) //nolint:errcheck
+		vehicle.WriteMessageAll(&common.MessageHomePosition{Latitude: lat, Longitude: lon})    //nolint:errcheck
+		vehicle.WriteMessageAll(&common.MessageGlobalPositionInt{Lat: lat, Lon: lon})          //nolint:errcheck
+		deadline := time.Now().Add(2 * time.Second)
+		for {
+			telemetry := client.GetTelemetry()
+			home, _ := client.GetHomePosition()
+			if telemetry.GPSFixType == fix && !telemetry.PositionUpdated.IsZero() && telemetry.Latitude == float64(lat)/1e7 &&
+				!home.Updated.IsZero() && home.Latitude == float64(lat)/1e7 {
+				return s.GetSnapshotAll(context.Background())[0]
+			}
+			if time.Now().After(deadline) {
+				t.Fatal("vehicle messages not processed")
+			}
+			time.Sleep(10 * time.Millisecond)
+		}
+	}
+
+	// No fix yet: the autopilot reports 0, 0
+	snap := sendFix(common.GPS_FIX_TYPE_NO_FIX, 0, 0)
+	if snap.PositionValid || snap.Snapshot.Position != nil || snap.Snapshot.HomePosition != nil {
+		t.Errorf("before a fix: valid %v, position %+v, home %+v",
+			snap.PositionValid, snap.Snapshot.Position, snap.Snapshot.HomePosition)
+	}
+
+	snap = sendFix(common.GPS_FIX_TYPE_3D_FIX, 473977420, 85455940)
+	if !snap.PositionValid || snap.Snapshot.Position == nil || snap.Snapshot.HomePosition == nil {
+		t.Fatalf("with a fix: valid %v, position %+v, home %+v",
+			snap.PositionValid, snap.Snapshot.Position, snap.Snapshot.HomePosition)
+	}
+	if lat := snap.Snapshot.Position.Latitude; lat < 47.39774 || lat > 47.39775 {
+		t.Errorf("latitude = %v", lat)
+	}
+}
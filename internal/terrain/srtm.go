@@ -0,0 +1,162 @@
+package terrain
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"math"
+	"os"
+	"path/filepath"
+	"sync"
+
+	"github.com/flightpath-dev/flightpath-server/internal/mavlink"
+)
+
+// ErrNoData is returned for points no tile in the directory covers, or that
+// fall on a void in the data
+var ErrNoData = mavlink.ErrNoTerrainData
+
+const (
+	// maxCachedTiles bounds the tiles kept in memory (an SRTM1 tile is ~25 MB)
+	maxCachedTiles = 8
+
+	// voidValue marks a sample SRTM has no elevation for
+	voidValue = -32768
+)
+
+// Source reads elevations from a directory of SRTM .hgt tiles
+// Tiles are named for their south-west corner (e.g. N47E008.hgt) and hold
+// 1201x1201 (3 arc-second) or 3601x3601 (1 arc-second) big-endian int16
+// samples in meters AMSL, north row first. Tiles are loaded on first use.
+type Source struct {
+	dir string
+
+	mu    sync.Mutex
+	tiles map[string]*tile // nil for tiles not in the directory
+}
+
+// tile is one loaded .hgt file
+type tile struct {
+	size    int // samples per row and column
+	samples []int16
+}
+
+// Open returns a Source for the SRTM tiles in dir
+func Open(dir string) (*Source, error) {
+	info, err := os.Stat(dir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open terrain directory: %w", err)
+	}
+	if !info.IsDir() {
+		return nil, fmt.Errorf("terrain path is not a directory: %s", dir)
+	}
+	return &Source{dir: dir, tiles: make(map[string]*tile)}, nil
+}
+
+// Dir returns the directory tiles are read from
+func (s *Source) Dir() string {
+	return s.dir
+}
+
+// Elevation returns the terrain height in meters AMSL at a point, bilinearly
+// interpolated between the surrounding samples
+func (s *Source) Elevation(lat, lon float64) (float64, error) {
+	if lat < -90 || lat >= 90 || lon < -180 || lon >= 180 {
+		return 0, fmt.Errorf("invalid position: %v, %v", lat, lon)
+	}
+
+	south, west := math.Floor(lat), math.Floor(lon)
+	t, err := s.tile(int(south), int(west))
+	if err != nil {
+		return 0, err
+	}
+	if t == nil {
+		return 0, ErrNoData
+	}
+
+	// Fractional sample position, row 0 at the north edge
+	cells := float64(t.size - 1)
+	row := (south + 1 - lat) * cells
+	col := (lon - west) * cells
+	r0, c0 := int(math.Floor(row)), int(math.Floor(col))
+	r1, c1 := min(r0+1, t.size-1), min(c0+1, t.size-1)
+	fr, fc := row-float64(r0), col-float64(c0)
+
+	nw, ne := t.samples[r0*t.size+c0], t.samples[r0*t.size+c1]
+	sw, se := t.samples[r1*t.size+c0], t.samples[r1*t.size+c1]
+	if nw == voidValue || ne == voidValue || sw == voidValue || se == voidValue {
+		return 0, ErrNoData
+	}
+
+	north := float64(nw)*(1-fc) + float64(ne)*fc
+	southRow := float64(sw)*(1-fc) + float64(se)*fc
+	return north*(1-fr) + southRow*fr, nil
+}
+
+// tile returns the tile with the given south-west corner, loading it if
+// needed; nil when the directory has none
+func (s *Source) tile(south, west int) (*tile, error) {
+	name := TileName(south, west)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if t, ok := s.tiles[name]; ok {
+		return t, nil
+	}
+
+	t, err := loadTile(filepath.Join(s.dir, name))
+	if err != nil {
+		return nil, err
+	}
+
+	if len(s.tiles) >= maxCachedTiles {
+		for cached := range s.tiles {
+			delete(s.tiles, cached)
+			break
+		}
+	}
+	s.tiles[name] = t
+	return t, nil
+}
+
+// TileName returns the .hgt file name for the tile with the given south-west
+// corner, e.g. N47E008.hgt or S34W071.hgt
+func TileName(south, west int) string {
+	ns, ew := 'N', 'E'
+	if south < 0 {
+		ns, south = 'S', -south
+	}
+	if west < 0 {
+		ew, west = 'W', -west
+	}
+	return fmt.Sprintf("%c%02d%c%03d.hgt", ns, south, ew, west)
+}
+
+// loadTile reads an .hgt file; nil without error when it doesn't exist
+func loadTile(path string) (*tile, error) {
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read terrain tile: %w", err)
+	}
+
+	var size int
+	switch len(data) {
+	case 1201 * 1201 * 2:
+		size = 1201
+	case 3601 * 3601 * 2:
+		size = 3601
+	default:
+		return nil, fmt.Errorf("terrain tile %s has unexpected size %d bytes (want SRTM1 or SRTM3)",
+			filepath.Base(path), len(data))
+	}
+
+	samples := make([]int16, size*size)
+	for i := range samples {
+		samples[i] = int16(binary.BigEndian.Uint16(data[i*2:]))
+	}
+	return &tile{size: size, samples: samples}, nil
+}
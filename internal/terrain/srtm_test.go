@@ -0,0 +1,119 @@
+package terrain
+
+import (
+	"encoding/binary"
+	"errors"
+	"math"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// writeTile writes an SRTM3 tile whose sample at row r (from the north edge)
+// and column c is height(r, c)
+func writeTile(t *testing.T, dir, name string, height func(r, c int) int16) {
+	t.Helper()
+	data := make([]byte, 1201*1201*2)
+	for r := range 1201 {
+		for c := range 1201 {
+			binary.BigEndian.PutUint16(data[(r*1201+c)*2:], uint16(height(r, c)))
+		}
+	}
+	if err := os.WriteFile(filepath.Join(dir, name), data, 0o644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// slopeSource is a directory with N47E008 rising 1 m per sample east and
+// falling 1 m per sample north from 1000 m at its north-west corner, with one
+// void sample
+func slopeSource(t *testing.T) *Source {
+	t.Helper()
+	dir := t.TempDir()
+	writeTile(t, dir, "N47E008.hgt", func(r, c int) int16 {
+		if r == 600 && c == 600 {
+			return voidValue
+		}
+		return int16(1000 + r + c)
+	})
+	source, err := Open(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return source
+}
+
+func TestElevation(t *testing.T) {
+	source := slopeSource(t)
+	const sample = 1.0 / 1200 // degrees between SRTM3 samples
+
+	tests := []struct {
+		name     string
+		lat, lon float64
+		want     float64
+	}{
+		{"north-west corner", 48 - 1e-9, 8, 1000},
+		{"on a sample", 48 - 100*sample, 8 + 50*sample, 1150},
+		// Halfway between samples in both directions
+		{"interpolated", 48 - 100.5*sample, 8 + 50.5*sample, 1151},
+		{"south-east corner", 47, 9 - 1e-9, 3400},
+	}
+	for _, tt := range tests {
+		got, err := source.Elevation(tt.lat, tt.lon)
+		if err != nil {
+			t.Errorf("%s: %v", tt.name, err)
+			continue
+		}
+		if math.Abs(got-tt.want) > 0.01 {
+			t.Errorf("%s: Elevation(%v, %v) = %v, want %v", tt.name, tt.lat, tt.lon, got, tt.want)
+		}
+	}
+
+	// Next to the void, no interpolation is possible
+	if _, err := source.Elevation(48-600.5*sample, 8+600.5*sample); !errors.Is(err, ErrNoData) {
+		t.Errorf("beside a void: %v, want ErrNoData", err)
+	}
+	// No tile for the neighbouring degree
+	if _, err := source.Elevation(46.5, 8.5); !errors.Is(err, ErrNoData) {
+		t.Errorf("outside the dataset: %v, want ErrNoData", err)
+	}
+	if _, err := source.Elevation(91, 8); err == nil {
+		t.Error("invalid latitude accepted")
+	}
+}
+
+func TestLoadTileRejectsBadSize(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "N47E008.hgt"), make([]byte, 1000), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	source, err := Open(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := source.Elevation(47.5, 8.5); err == nil || errors.Is(err, ErrNoData) {
+		t.Errorf("truncated tile: %v, want a size error", err)
+	}
+}
+
+func TestTileName(t *testing.T) {
+	tests := []struct {
+		south, west int
+		want        string
+	}{
+		{47, 8, "N47E008.hgt"},
+		{-34, -71, "S34W071.hgt"},
+		{0, -1, "N00W001.hgt"},
+	}
+	for _, tt := range tests {
+		if got := TileName(tt.south, tt.west); got != tt.want {
+			t.Errorf("TileName(%d, %d) = %s, want %s", tt.south, tt.west, got, tt.want)
+		}
+	}
+}
+
+func TestOpenRequiresDirectory(t *testing.T) {
+	if _, err := Open(filepath.Join(t.TempDir(), "missing")); err == nil {
+		t.Error("missing directory accepted")
+	}
+}
@@ -0,0 +1,127 @@
+package track
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/flightpath-dev/flightpath-server/internal/mavlink"
+)
+
+// gpxCreator identifies the server in the files it writes
+const gpxCreator = "flightpath-server"
+
+// GPX 1.1 document, with just the elements a recorded track uses
+type gpxFile struct {
+	XMLName  xml.Name    `xml:"http://www.topografix.com/GPX/1/1 gpx"`
+	Version  string      `xml:"version,attr"`
+	Creator  string      `xml:"creator,attr"`
+	Metadata gpxMetadata `xml:"metadata"`
+	Track    gpxTrack    `xml:"trk"`
+}
+
+type gpxMetadata struct {
+	Name string `xml:"name"`
+	Time string `xml:"time,omitempty"`
+}
+
+type gpxTrack struct {
+	Name    string     `xml:"name"`
+	Segment gpxSegment `xml:"trkseg"`
+}
+
+type gpxSegment struct {
+	Points []gpxPoint `xml:"trkpt"`
+}
+
+// gpxPoint children must stay in the schema's order (ele, time, sat, hdop)
+type gpxPoint struct {
+	Lat  float64  `xml:"lat,attr"`
+	Lon  float64  `xml:"lon,attr"`
+	Ele  float64  `xml:"ele"`
+	Time string   `xml:"time"`
+	Sat  int32    `xml:"sat,omitempty"`
+	HDOP *float64 `xml:"hdop,omitempty"`
+}
+
+// WriteGPX writes a track as a GPX 1.1 file with one track segment
+// name labels the file and track (e.g. the drone ID); times are UTC.
+func WriteGPX(w io.Writer, name string, track mavlink.Track) error {
+	doc := gpxFile{
+		Version:  "1.1",
+		Creator:  gpxCreator,
+		Metadata: gpxMetadata{Name: name},
+		Track:    gpxTrack{Name: name},
+	}
+	if !track.Started.IsZero() {
+		doc.Metadata.Time = formatTime(track.Started)
+	}
+
+	doc.Track.Segment.Points = make([]gpxPoint, len(track.Points))
+	for i, p := range track.Points {
+		point := gpxPoint{
+			Lat:  p.Latitude,
+			Lon:  p.Longitude,
+			Ele:  p.Altitude,
+			Time: formatTime(p.Time),
+			Sat:  p.Satellites,
+		}
+		if p.HDOP > 0 {
+			hdop := p.HDOP
+			point.HDOP = &hdop
+		}
+		doc.Track.Segment.Points[i] = point
+	}
+
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return err
+	}
+	enc := xml.NewEncoder(w)
+	enc.Indent("", "  ")
+	if err := enc.Encode(doc); err != nil {
+		return fmt.Errorf("failed to encode GPX: %w", err)
+	}
+	_, err := io.WriteString(w, "\n")
+	return err
+}
+
+func formatTime(t time.Time) string {
+	return t.UTC().Format(time.RFC3339Nano)
+}
+
+// Dir saves each drone's track as a GPX file in a directory when its client
+// closes, named <drone ID>-<track start, UTC>.gpx
+type Dir struct {
+	dir     string
+	droneID string
+}
+
+// NewDir returns a track sink writing droneID's tracks to dir, which is
+// created when the first track is saved
+func NewDir(dir, droneID string) *Dir {
+	return &Dir{dir: dir, droneID: droneID}
+}
+
+// SaveTrack writes the track to a new file
+func (d *Dir) SaveTrack(track mavlink.Track) error {
+	if err := os.MkdirAll(d.dir, 0o755); err != nil {
+		return fmt.Errorf("failed to create track directory: %w", err)
+	}
+
+	// Drone IDs are free-form; keep the file in the directory
+	droneID := strings.NewReplacer("/", "_", `\`, "_").Replace(d.droneID)
+	name := fmt.Sprintf("%s-%s.gpx", droneID, track.Started.UTC().Format("20060102T150405Z"))
+	f, err := os.Create(filepath.Join(d.dir, name))
+	if err != nil {
+		return fmt.Errorf("failed to create track file: %w", err)
+	}
+	if err := WriteGPX(f, d.droneID, track); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
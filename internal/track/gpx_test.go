@@ -0,0 +1,99 @@
+package track
+
+import (
+	"bytes"
+	"encoding/xml"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/flightpath-dev/flightpath-server/internal/mavlink"
+)
+
+// sampleTrack is a three-point track, the last point without HDOP
+func sampleTrack() mavlink.Track {
+	start := time.Date(2026, 5, 4, 10, 30, 0, 0, time.FixedZone("CEST", 2*60*60))
+	return mavlink.Track{
+		Started: start,
+		Points: []mavlink.TrackPoint{
+			{Time: start, Latitude: 47.397742, Longitude: 8.545594, Altitude: 488.2, Satellites: 14, HDOP: 0.8},
+			{Time: start.Add(time.Second), Latitude: 47.397800, Longitude: 8.545600, Altitude: 492.5, Satellites: 14, HDOP: 0.8},
+			{Time: start.Add(2500 * time.Millisecond), Latitude: 47.397850, Longitude: 8.545650, Altitude: 497},
+		},
+	}
+}
+
+// wellFormed fails unless data is well-formed XML
+func wellFormed(t *testing.T, data []byte) {
+	t.Helper()
+	dec := xml.NewDecoder(bytes.NewReader(data))
+	for {
+		if _, err := dec.Token(); err == io.EOF {
+			return
+		} else if err != nil {
+			t.Fatalf("malformed GPX: %v\n%s", err, data)
+		}
+	}
+}
+
+func TestWriteGPX(t *testing.T) {
+	var buf bytes.Buffer
+	if err := WriteGPX(&buf, "alpha", sampleTrack()); err != nil {
+		t.Fatal(err)
+	}
+	wellFormed(t, buf.Bytes())
+	if !bytes.HasPrefix(buf.Bytes(), []byte(xml.Header)) {
+		t.Errorf("no XML declaration: %.60s", buf.String())
+	}
+
+	var doc gpxFile
+	if err := xml.Unmarshal(buf.Bytes(), &doc); err != nil {
+		t.Fatal(err)
+	}
+	if doc.XMLName.Space != "http://www.topografix.com/GPX/1/1" || doc.Version != "1.1" || doc.Creator != gpxCreator {
+		t.Errorf("root: %v version %q creator %q", doc.XMLName, doc.Version, doc.Creator)
+	}
+	if doc.Metadata.Name != "alpha" || doc.Track.Name != "alpha" || doc.Metadata.Time != "2026-05-04T08:30:00Z" {
+		t.Errorf("metadata %+v, track name %q", doc.Metadata, doc.Track.Name)
+	}
+
+	points := doc.Track.Segment.Points
+	if len(points) != 3 {
+		t.Fatalf("%d track points, want 3", len(points))
+	}
+	first := points[0]
+	if first.Lat != 47.397742 || first.Lon != 8.545594 || first.Ele != 488.2 || first.Sat != 14 ||
+		first.HDOP == nil || *first.HDOP != 0.8 || first.Time != "2026-05-04T08:30:00Z" {
+		t.Errorf("first point = %+v", first)
+	}
+	if last := points[2]; last.HDOP != nil || last.Sat != 0 || last.Time != "2026-05-04T08:30:02.5Z" {
+		t.Errorf("last point = %+v, want no hdop or sat", last)
+	}
+}
+
+func TestWriteGPXEmptyTrack(t *testing.T) {
+	var buf bytes.Buffer
+	if err := WriteGPX(&buf, "alpha", mavlink.Track{}); err != nil {
+		t.Fatal(err)
+	}
+	wellFormed(t, buf.Bytes())
+}
+
+func TestDirSaveTrack(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "tracks")
+	if err := NewDir(dir, "fleet/alpha").SaveTrack(sampleTrack()); err != nil {
+		t.Fatal(err)
+	}
+
+	data, err := os.ReadFile(filepath.Join(dir, "fleet_alpha-20260504T083000Z.gpx"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	wellFormed(t, data)
+	var doc gpxFile
+	if err := xml.Unmarshal(data, &doc); err != nil || doc.Track.Name != "fleet/alpha" || len(doc.Track.Segment.Points) != 3 {
+		t.Errorf("saved track: %+v, %v", doc.Track, err)
+	}
+}